@@ -20,11 +20,15 @@ import (
 	"context"
 	"sort"
 	"strings"
+	"time"
 
 	volumesnapshot "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
 )
 
 // BackupPhase is the phase of the backup
@@ -48,6 +52,107 @@ const (
 
 	// BackupPhaseWalArchivingFailing means wal archiving isn't properly working
 	BackupPhaseWalArchivingFailing = "walArchivingFailing"
+
+	// BackupPhaseCoalesced means that the backup has not been executed, being
+	// a duplicate of a backup requested shortly before
+	BackupPhaseCoalesced = "coalesced"
+
+	// BackupPhaseValidated means that the backup has been validated by a
+	// dry-run, without being executed
+	BackupPhaseValidated = "validated"
+)
+
+// DefaultBackupTimeout is the default in seconds for the maximum time a
+// volume snapshot backup can take to complete
+const DefaultBackupTimeout = 43200
+
+// BackupSnapshotNoWALArchive is the value stored as the last archived LSN
+// of a volume snapshot backup taken on a cluster without WAL archiving
+const BackupSnapshotNoWALArchive = "no WAL archive"
+
+// BackupConditionType defines types of backup conditions
+type BackupConditionType string
+
+const (
+	// ConditionBackupRestorable represents whether a volume snapshot backup
+	// can still be used to recover a cluster, rolling forward through the
+	// WAL archive
+	ConditionBackupRestorable BackupConditionType = "Restorable"
+
+	// ConditionBackupNotified represents whether the outcome of the backup
+	// has been delivered to the configured notification webhook
+	ConditionBackupNotified BackupConditionType = "Notified"
+
+	// ConditionBackupCompletedEventRecorded represents whether the
+	// `BackupCompleted` event of the backup has been recorded
+	ConditionBackupCompletedEventRecorded BackupConditionType = "CompletedEventRecorded"
+
+	// ConditionBackupFencing represents whether the target instance of a
+	// volume snapshot backup is being fenced
+	ConditionBackupFencing BackupConditionType = "Fencing"
+
+	// ConditionBackupSnapshotCreating represents whether the VolumeSnapshots
+	// of a volume snapshot backup are being created
+	ConditionBackupSnapshotCreating BackupConditionType = "SnapshotCreating"
+
+	// ConditionBackupSnapshotWaiting represents whether a volume snapshot
+	// backup is waiting for its VolumeSnapshots to be ready to use
+	ConditionBackupSnapshotWaiting BackupConditionType = "SnapshotWaiting"
+
+	// ConditionBackupFinalizing represents whether a volume snapshot backup
+	// is unfencing its target instance and recording its results
+	ConditionBackupFinalizing BackupConditionType = "Finalizing"
+)
+
+// backupProgressConditions are the conditions reporting the phases a volume
+// snapshot backup goes through, in the order they happen
+var backupProgressConditions = []BackupConditionType{
+	ConditionBackupFencing,
+	ConditionBackupSnapshotCreating,
+	ConditionBackupSnapshotWaiting,
+	ConditionBackupFinalizing,
+}
+
+const (
+	// ConditionReasonSnapshotRestorable means that the volume snapshot backup
+	// is recent enough and the WAL archive extends back to its start LSN
+	ConditionReasonSnapshotRestorable ConditionReason = "SnapshotRestorable"
+
+	// ConditionReasonSnapshotTooOld means that the volume snapshot backup
+	// is older than the configured maximum restorable age
+	ConditionReasonSnapshotTooOld ConditionReason = "SnapshotTooOld"
+
+	// ConditionReasonSnapshotWALsUnavailable means that the WAL files needed to
+	// roll forward from the volume snapshot backup are not in the WAL archive anymore
+	ConditionReasonSnapshotWALsUnavailable ConditionReason = "SnapshotWALsUnavailable"
+
+	// ConditionReasonNotificationSent means that the backup notification
+	// webhook acknowledged the payload
+	ConditionReasonNotificationSent ConditionReason = "NotificationSent"
+
+	// ConditionReasonNotificationPending means that the backup notification
+	// couldn't be delivered yet, and will be retried
+	ConditionReasonNotificationPending ConditionReason = "NotificationPending"
+
+	// ConditionReasonNotificationFailed means that the backup notification
+	// couldn't be delivered after retrying
+	ConditionReasonNotificationFailed ConditionReason = "NotificationFailed"
+
+	// ConditionReasonCompletedEventRecorded means that the `BackupCompleted`
+	// event has been recorded
+	ConditionReasonCompletedEventRecorded ConditionReason = "CompletedEventRecorded"
+
+	// ConditionReasonPhaseInProgress means that the backup is going
+	// through the phase
+	ConditionReasonPhaseInProgress ConditionReason = "PhaseInProgress"
+
+	// ConditionReasonPhaseCompleted means that the backup went through
+	// the phase and moved on
+	ConditionReasonPhaseCompleted ConditionReason = "PhaseCompleted"
+
+	// ConditionReasonPhaseFailed means that the backup failed while
+	// going through the phase
+	ConditionReasonPhaseFailed ConditionReason = "PhaseFailed"
 )
 
 // BackupMethod defines the way of executing the physical base backups of
@@ -85,6 +190,29 @@ type BackupSpec struct {
 	// +kubebuilder:validation:Enum=barmanObjectStore;volumeSnapshot
 	// +kubebuilder:default:=barmanObjectStore
 	Method BackupMethod `json:"method,omitempty"`
+
+	// The maximum time in seconds, since the backup started, for a volume
+	// snapshot backup to complete, including fencing the instance, taking
+	// the snapshots and waiting for them to be ready. When exceeded, the
+	// backup fails, the instance is unfenced and the snapshots taken so
+	// far are deleted. Defaults to 43200 seconds (12 hours)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Timeout int32 `json:"timeout,omitempty"`
+
+	// When enabled, a volume snapshot backup is only validated: the PVCs
+	// of the target instance must exist, their snapshot classes must resolve
+	// and the instance must be fenceable, but no snapshot is taken and the
+	// instance is not fenced. The snapshots that would be taken are reported
+	// in the status
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// The type of owner reference the volume snapshots taken by the backup
+	// should have, overriding the one of the cluster
+	// +optional
+	// +kubebuilder:validation:Enum=none;cluster;backup
+	SnapshotOwnerReference SnapshotOwnerReference `json:"snapshotOwnerReference,omitempty"`
 }
 
 // BackupSnapshotStatus the fields exclusive to the volumeSnapshot method backup
@@ -92,6 +220,131 @@ type BackupSnapshotStatus struct {
 	// The snapshot lists, populated if it is a snapshot type backup
 	// +optional
 	Snapshots []string `json:"snapshots,omitempty"`
+
+	// The snapshots taken by previous backups and reused by this one,
+	// as their PVCs didn't change since then. They are included in
+	// the snapshot list too
+	// +optional
+	ReusedSnapshots []string `json:"reusedSnapshots,omitempty"`
+
+	// The LSN up to which the WAL archive was known to extend when the
+	// snapshots were completed, or `no WAL archive` if WAL archiving was
+	// not enabled on the cluster
+	// +optional
+	LastArchivedLSN string `json:"lastArchivedLSN,omitempty"`
+
+	// The PostgreSQL extensions that were installed in the cluster
+	// when the backup was started
+	// +optional
+	Extensions []InstalledExtension `json:"extensions,omitempty"`
+
+	// True when the snapshots have been taken without fencing the target
+	// standby, as it was quiescent when the backup was started
+	// +optional
+	FencingSkipped bool `json:"fencingSkipped,omitempty"`
+
+	// When the fencing of the target instance was requested
+	// +optional
+	FencedAt *metav1.Time `json:"fencedAt,omitempty"`
+
+	// How long the target instance stayed fenced
+	// +optional
+	FenceDuration string `json:"fenceDuration,omitempty"`
+
+	// True when the target instance stayed fenced longer than the
+	// maximum fence duration, and the backup was failed to restore
+	// its availability
+	// +optional
+	FenceDurationExceeded bool `json:"fenceDurationExceeded,omitempty"`
+
+	// The position of the designated primary of the replica cluster as
+	// seen by the source when the backup was started
+	// +optional
+	SourceReplication *SourceReplicationStatus `json:"sourceReplication,omitempty"`
+
+	// The snapshots that would be taken by the backup, populated
+	// when the backup is a dry-run
+	// +optional
+	DryRunSnapshots []DryRunSnapshot `json:"dryRunSnapshots,omitempty"`
+
+	// True when the snapshots have been taken online, with the target
+	// instance in backup mode instead of being fenced
+	// +optional
+	Online bool `json:"online,omitempty"`
+
+	// The content of the backup label returned by `pg_backup_stop`, to be
+	// written in the data directory when restoring an online backup
+	// +optional
+	BackupLabelFile []byte `json:"backupLabelFile,omitempty"`
+
+	// The content of the tablespace map returned by `pg_backup_stop`, to be
+	// written in the data directory when restoring an online backup
+	// +optional
+	TablespaceMapFile []byte `json:"tablespaceMapFile,omitempty"`
+
+	// The name of the instance whose volumes have been snapshotted
+	// +optional
+	TargetPod string `json:"targetPod,omitempty"`
+
+	// The role of the target instance when the snapshots were taken,
+	// either `primary` or `standby`
+	// +optional
+	TargetRole string `json:"targetRole,omitempty"`
+
+	// The WAL file containing the checkpoint the snapshots are consistent
+	// at, which the backup waits to be archived before being completed
+	// +optional
+	RequiredWAL string `json:"requiredWAL,omitempty"`
+
+	// When the backup started waiting for the required WAL file
+	// to be archived
+	// +optional
+	ArchiveWaitStartedAt *metav1.Time `json:"archiveWaitStartedAt,omitempty"`
+
+	// True when the pre-snapshot commands have been executed in the
+	// target instance
+	// +optional
+	PreSnapshotCommandsExecuted bool `json:"preSnapshotCommandsExecuted,omitempty"`
+}
+
+// DryRunSnapshot is a snapshot that would be taken by a backup,
+// as validated by a dry-run
+type DryRunSnapshot struct {
+	// The name of the PVC to be snapshotted
+	PVCName string `json:"pvcName"`
+
+	// The name of the VolumeSnapshot that would be created
+	SnapshotName string `json:"snapshotName"`
+
+	// The volume snapshot class resolved for the PVC
+	// +optional
+	ClassName string `json:"className,omitempty"`
+}
+
+// SourceReplicationStatus is the position of the designated primary of a
+// replica cluster, as reported by the `pg_stat_replication` view of the source
+type SourceReplicationStatus struct {
+	// The last WAL location sent by the source
+	// +optional
+	SentLSN string `json:"sentLSN,omitempty"`
+
+	// The last WAL location flushed to disk by the designated primary
+	// +optional
+	FlushLSN string `json:"flushLSN,omitempty"`
+
+	// The last WAL location replayed by the designated primary
+	// +optional
+	ReplayLSN string `json:"replayLSN,omitempty"`
+}
+
+// InstalledExtension is a PostgreSQL extension installed in at least one
+// database of the backed up cluster
+type InstalledExtension struct {
+	// The name of the extension
+	Name string `json:"name"`
+
+	// The version of the extension
+	Version string `json:"version"`
 }
 
 // BackupStatus defines the observed state of Backup
@@ -184,6 +437,40 @@ type BackupStatus struct {
 	// The backup method being used
 	// +optional
 	Method BackupMethod `json:"method,omitempty"`
+
+	// The name of the external cluster the backed up cluster was
+	// replicating from. It is set only when the backup has been taken
+	// from a replica cluster
+	// +optional
+	ReplicaSourceCluster string `json:"replicaSourceCluster,omitempty"`
+
+	// Conditions for the backup object
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// The name of the backup this one has been coalesced into, being a
+	// duplicate of it
+	// +optional
+	CoalescedInto string `json:"coalescedInto,omitempty"`
+
+	// The total size of the data captured by the volume snapshots of the
+	// backup, as reported by their restore size
+	// +optional
+	TotalSize *BackupTotalSize `json:"totalSize,omitempty"`
+}
+
+// BackupTotalSize is the total size of the data captured by a backup
+type BackupTotalSize struct {
+	// Bytes is the total size, in bytes
+	Bytes int64 `json:"bytes"`
+
+	// Size is the total size in a human-readable format, e.g. `1.5 GiB`
+	Size string `json:"size"`
+
+	// SnapshotsWithoutSize are the names of the volume snapshots not
+	// reporting their restore size, which are counted as zero
+	// +optional
+	SnapshotsWithoutSize []string `json:"snapshotsWithoutSize,omitempty"`
 }
 
 // InstanceID contains the information to identify an instance
@@ -237,6 +524,7 @@ func (backupStatus *BackupStatus) SetAsFailed(
 	err error,
 ) {
 	backupStatus.Phase = BackupPhaseFailed
+	backupStatus.endProgressConditions(ConditionReasonPhaseFailed, "")
 
 	if err != nil {
 		backupStatus.Error = err.Error()
@@ -249,6 +537,69 @@ func (backupStatus *BackupStatus) SetAsFailed(
 func (backupStatus *BackupStatus) SetAsCompleted() {
 	backupStatus.Phase = BackupPhaseCompleted
 	backupStatus.Error = ""
+	backupStatus.endProgressConditions(ConditionReasonPhaseCompleted, "")
+}
+
+// SetProgressCondition marks the passed phase of a volume snapshot backup
+// as in progress, and the phase previously in progress as completed. The
+// time of the transition is kept when the phase was already in progress.
+// Returns true when the conditions have been changed
+func (backupStatus *BackupStatus) SetProgressCondition(phase BackupConditionType) bool {
+	changed := backupStatus.endProgressConditions(ConditionReasonPhaseCompleted, phase)
+	if meta.IsStatusConditionTrue(backupStatus.Conditions, string(phase)) {
+		return changed
+	}
+
+	meta.SetStatusCondition(&backupStatus.Conditions, metav1.Condition{
+		Type:    string(phase),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(ConditionReasonPhaseInProgress),
+		Message: "The backup is in this phase",
+	})
+	return true
+}
+
+// endProgressConditions marks the phases in progress, except the passed
+// one, as over for the passed reason. Returns true when the conditions
+// have been changed
+func (backupStatus *BackupStatus) endProgressConditions(
+	reason ConditionReason,
+	except BackupConditionType,
+) bool {
+	message := "The backup went through this phase"
+	if reason == ConditionReasonPhaseFailed {
+		message = "The backup failed in this phase"
+	}
+
+	changed := false
+	for _, phase := range backupProgressConditions {
+		if phase == except || !meta.IsStatusConditionTrue(backupStatus.Conditions, string(phase)) {
+			continue
+		}
+
+		meta.SetStatusCondition(&backupStatus.Conditions, metav1.Condition{
+			Type:    string(phase),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(reason),
+			Message: message,
+		})
+		changed = true
+	}
+
+	return changed
+}
+
+// SetAsValidated marks a certain backup as validated by a dry-run
+func (backupStatus *BackupStatus) SetAsValidated() {
+	backupStatus.Phase = BackupPhaseValidated
+	backupStatus.Error = ""
+}
+
+// SetAsCoalesced marks a certain backup as a duplicate of the passed one
+func (backupStatus *BackupStatus) SetAsCoalesced(backupName string) {
+	backupStatus.Phase = BackupPhaseCoalesced
+	backupStatus.CoalescedInto = backupName
+	backupStatus.Error = ""
 }
 
 // SetAsStarted marks a certain backup as started
@@ -261,18 +612,40 @@ func (backupStatus *BackupStatus) SetAsStarted(targetPod *corev1.Pod, method Bac
 	backupStatus.Method = method
 }
 
-// SetSnapshotList sets the Snapshots field from a list of VolumeSnapshot
+// SetReplicaSourceCluster records the source cluster followed by the
+// backed up cluster, when it is a replica cluster
+func (backupStatus *BackupStatus) SetReplicaSourceCluster(cluster *Cluster) {
+	backupStatus.ReplicaSourceCluster = ""
+	if cluster.IsReplica() {
+		backupStatus.ReplicaSourceCluster = cluster.Spec.ReplicaCluster.Source
+	}
+}
+
+// SetSnapshotList sets the Snapshots field from a list of VolumeSnapshot,
+// followed by the reused snapshots
 func (snapshotStatus *BackupSnapshotStatus) SetSnapshotList(snapshots []volumesnapshot.VolumeSnapshot) {
-	snapshotNames := make([]string, len(snapshots))
-	for idx, volumeSnapshot := range snapshots {
-		snapshotNames[idx] = volumeSnapshot.Name
+	snapshotNames := make([]string, 0, len(snapshots)+len(snapshotStatus.ReusedSnapshots))
+	for _, volumeSnapshot := range snapshots {
+		snapshotNames = append(snapshotNames, volumeSnapshot.Name)
+	}
+	snapshotStatus.Snapshots = append(snapshotNames, snapshotStatus.ReusedSnapshots...)
+
+	// the target is the same for all the snapshots taken by the backup
+	for _, volumeSnapshot := range snapshots {
+		if podName := volumeSnapshot.Labels[utils.SnapshotSourcePodLabelName]; podName != "" {
+			snapshotStatus.TargetPod = podName
+			snapshotStatus.TargetRole = volumeSnapshot.Labels[utils.SnapshotSourceRoleLabelName]
+			break
+		}
 	}
-	snapshotStatus.Snapshots = snapshotNames
 }
 
 // IsDone check if a backup is completed or still in progress
 func (backupStatus *BackupStatus) IsDone() bool {
-	return backupStatus.Phase == BackupPhaseCompleted || backupStatus.Phase == BackupPhaseFailed
+	return backupStatus.Phase == BackupPhaseCompleted ||
+		backupStatus.Phase == BackupPhaseFailed ||
+		backupStatus.Phase == BackupPhaseCoalesced ||
+		backupStatus.Phase == BackupPhaseValidated
 }
 
 // IsInProgress check if a certain backup is in progress or not
@@ -331,6 +704,78 @@ func (list *BackupList) CanExecuteBackup(backupName string) bool {
 	return true
 }
 
+// CanExecuteParallelBackup tells whether a backup can start, given the
+// maximum number of backups of the cluster which can run at the same time,
+// zero meaning no limit. The backups are started in the order they have been
+// created, and volume snapshot backups, which may fence the target instance,
+// never run together with any other backup.
+//
+// A backup which is already in progress can always continue. Otherwise it can
+// start if:
+// - no other backup is running when it is a volume snapshot backup, and no
+// volume snapshot backup is running otherwise
+// - no backup is waiting to be started before it when it is a volume snapshot
+// backup, and no volume snapshot backup is waiting to be started before it
+// otherwise
+// - the number of running backups, together with the backups waiting to be
+// started before it, is lower than the limit
+//
+// As a side effect, this function will sort the backup list
+func (list *BackupList) CanExecuteParallelBackup(backupName string, maxParallelBackups int) bool {
+	list.SortByCreationTimestamp()
+
+	var target *Backup
+	for i := range list.Items {
+		if list.Items[i].Name == backupName {
+			target = &list.Items[i]
+			break
+		}
+	}
+	if target != nil && target.Status.IsInProgress() {
+		return true
+	}
+	isSnapshot := target != nil && target.Spec.Method == BackupMethodVolumeSnapshot
+
+	runningBackups := 0
+	for _, concurrentBackup := range list.Items {
+		if concurrentBackup.Name == backupName || !concurrentBackup.Status.IsInProgress() {
+			continue
+		}
+		if isSnapshot || concurrentBackup.Spec.Method == BackupMethodVolumeSnapshot {
+			return false
+		}
+		runningBackups++
+	}
+
+	waitingBackups := 0
+	for _, concurrentBackup := range list.Items {
+		if concurrentBackup.Status.IsDone() || concurrentBackup.Status.IsInProgress() {
+			continue
+		}
+		if concurrentBackup.Name == backupName {
+			break
+		}
+		if isSnapshot || concurrentBackup.Spec.Method == BackupMethodVolumeSnapshot {
+			return false
+		}
+		waitingBackups++
+	}
+
+	return maxParallelBackups <= 0 || runningBackups+waitingBackups < maxParallelBackups
+}
+
+// SortByCreationTimestamp sorts the backup items from the oldest to the
+// newest, using the name to order the backups created at the same time
+func (list *BackupList) SortByCreationTimestamp() {
+	sort.Slice(list.Items, func(i, j int) bool {
+		left, right := list.Items[i].CreationTimestamp, list.Items[j].CreationTimestamp
+		if !left.Equal(&right) {
+			return left.Before(&right)
+		}
+		return list.Items[i].Name < list.Items[j].Name
+	})
+}
+
 // SortByName sorts the backup items in alphabetical order
 func (list *BackupList) SortByName() {
 	// Sort the list of backups in alphabetical order
@@ -359,6 +804,45 @@ func (backup *Backup) GetNamespace() string {
 	return backup.Namespace
 }
 
+// GetTimeout returns the maximum time the backup can take to complete,
+// defaulting to DefaultBackupTimeout if empty
+func (backup *Backup) GetTimeout() time.Duration {
+	if backup.Spec.Timeout <= 0 {
+		return DefaultBackupTimeout * time.Second
+	}
+	return time.Duration(backup.Spec.Timeout) * time.Second
+}
+
+// IsTimeoutExceeded checks whether the backup started more than its
+// timeout before the passed time
+func (backup *Backup) IsTimeoutExceeded(now time.Time) bool {
+	if backup.Status.StartedAt == nil {
+		return false
+	}
+	return now.Sub(backup.Status.StartedAt.Time) > backup.GetTimeout()
+}
+
+// GetOrigin tells whether the backup was created by a ScheduledBackup or
+// was requested manually
+func (backup *Backup) GetOrigin() utils.BackupOrigin {
+	if backup.Labels[utils.ParentScheduledBackupLabelName] != "" {
+		return utils.BackupOriginScheduled
+	}
+	return utils.BackupOriginManual
+}
+
+// GetSnapshotOwnerReference gets the owner reference of the volume snapshots
+// taken by the backup of the passed cluster. The one of the backup takes
+// precedence over the one of the cluster, which takes precedence over the
+// default of the operator
+func (backup *Backup) GetSnapshotOwnerReference(cluster *Cluster) SnapshotOwnerReference {
+	if backup.Spec.SnapshotOwnerReference != "" {
+		return backup.Spec.SnapshotOwnerReference
+	}
+
+	return cluster.GetSnapshotOwnerReference()
+}
+
 // GetAssignedInstance fetches the instance that was assigned to the backup execution
 func (backup *Backup) GetAssignedInstance(ctx context.Context, cli client.Client) (*corev1.Pod, error) {
 	if backup.Status.InstanceID == nil || len(backup.Status.InstanceID.PodName) == 0 {
@@ -17,10 +17,16 @@ limitations under the License.
 package v1
 
 import (
+	"time"
+
 	volumesnapshot "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/cloudnative-pg/cloudnative-pg/internal/configuration"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -49,6 +55,47 @@ var _ = Describe("BackupStatus structure", func() {
 		Expect(status.IsDone()).To(BeFalse())
 	})
 
+	It("records the source cluster when backing up a replica cluster", func() {
+		status := BackupStatus{}
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				ReplicaCluster: &ReplicaClusterConfiguration{
+					Enabled: true,
+					Source:  "cluster-origin",
+				},
+			},
+		}
+
+		status.SetReplicaSourceCluster(cluster)
+		Expect(status.ReplicaSourceCluster).To(Equal("cluster-origin"))
+	})
+
+	It("doesn't record any source cluster when backing up a primary cluster", func() {
+		status := BackupStatus{}
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				ReplicaCluster: &ReplicaClusterConfiguration{
+					Enabled: false,
+					Source:  "cluster-origin",
+				},
+			},
+		}
+
+		status.SetReplicaSourceCluster(cluster)
+		Expect(status.ReplicaSourceCluster).To(BeEmpty())
+
+		status.SetReplicaSourceCluster(&Cluster{})
+		Expect(status.ReplicaSourceCluster).To(BeEmpty())
+	})
+
+	It("tells the origin of the backup", func() {
+		backup := &Backup{}
+		Expect(backup.GetOrigin()).To(Equal(utils.BackupOriginManual))
+
+		backup.Labels = map[string]string{utils.ParentScheduledBackupLabelName: "daily"}
+		Expect(backup.GetOrigin()).To(Equal(utils.BackupOriginScheduled))
+	})
+
 	It("can be set to contain a snapshot list", func() {
 		status := BackupStatus{}
 		status.BackupSnapshotStatus.SetSnapshotList([]volumesnapshot.VolumeSnapshot{
@@ -70,6 +117,62 @@ var _ = Describe("BackupStatus structure", func() {
 			"cluster-example-snapshot-2"))
 	})
 
+	It("records the target of the snapshots in the status", func() {
+		status := BackupStatus{}
+		status.BackupSnapshotStatus.SetSnapshotList([]volumesnapshot.VolumeSnapshot{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cluster-example-snapshot-1",
+					Labels: map[string]string{
+						utils.SnapshotSourcePodLabelName:  "cluster-example-2",
+						utils.SnapshotSourceRoleLabelName: string(utils.SnapshotSourceRoleStandby),
+					},
+				},
+			},
+		})
+
+		Expect(status.BackupSnapshotStatus.TargetPod).To(Equal("cluster-example-2"))
+		Expect(status.BackupSnapshotStatus.TargetRole).To(Equal("standby"))
+	})
+
+	Context("progress conditions", func() {
+		It("moves the condition in progress from one phase to the next one", func() {
+			status := BackupStatus{}
+			Expect(status.SetProgressCondition(ConditionBackupFencing)).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(status.Conditions, string(ConditionBackupFencing))).To(BeTrue())
+
+			Expect(status.SetProgressCondition(ConditionBackupSnapshotCreating)).To(BeTrue())
+			fencing := meta.FindStatusCondition(status.Conditions, string(ConditionBackupFencing))
+			Expect(fencing.Status).To(Equal(metav1.ConditionFalse))
+			Expect(fencing.Reason).To(Equal(string(ConditionReasonPhaseCompleted)))
+			Expect(meta.IsStatusConditionTrue(status.Conditions, string(ConditionBackupSnapshotCreating))).To(BeTrue())
+		})
+
+		It("is idempotent", func() {
+			status := BackupStatus{}
+			Expect(status.SetProgressCondition(ConditionBackupSnapshotWaiting)).To(BeTrue())
+			transitionTime := status.Conditions[0].LastTransitionTime
+
+			Expect(status.SetProgressCondition(ConditionBackupSnapshotWaiting)).To(BeFalse())
+			Expect(status.Conditions).To(HaveLen(1))
+			Expect(status.Conditions[0].LastTransitionTime).To(Equal(transitionTime))
+		})
+
+		It("ends the phase in progress when the backup is over", func() {
+			completed := BackupStatus{}
+			completed.SetProgressCondition(ConditionBackupFinalizing)
+			completed.SetAsCompleted()
+			Expect(completed.Conditions[0].Status).To(Equal(metav1.ConditionFalse))
+			Expect(completed.Conditions[0].Reason).To(Equal(string(ConditionReasonPhaseCompleted)))
+
+			failed := BackupStatus{}
+			failed.SetProgressCondition(ConditionBackupSnapshotWaiting)
+			failed.SetAsFailed(nil)
+			Expect(failed.Conditions[0].Status).To(Equal(metav1.ConditionFalse))
+			Expect(failed.Conditions[0].Reason).To(Equal(string(ConditionReasonPhaseFailed)))
+		})
+	})
+
 	Context("backup phases", func() {
 		When("the backup phase is `running`", func() {
 			It("can tell if a backup is in progress or done", func() {
@@ -319,3 +422,146 @@ var _ = Describe("backup_controller volumeSnapshot unit tests", func() {
 		})
 	})
 })
+
+var _ = Describe("parallel backups", func() {
+	now := time.Now()
+	newBackup := func(name string, age time.Duration, method BackupMethod, phase BackupPhase) Backup {
+		return Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			Spec:   BackupSpec{Method: method},
+			Status: BackupStatus{Phase: phase},
+		}
+	}
+
+	It("respects the maximum number of parallel backups", func() {
+		backupList := BackupList{Items: []Backup{
+			newBackup("backup-1", 4*time.Minute, BackupMethodBarmanObjectStore, BackupPhaseRunning),
+			newBackup("backup-2", 3*time.Minute, BackupMethodBarmanObjectStore, ""),
+			newBackup("backup-3", 2*time.Minute, BackupMethodBarmanObjectStore, ""),
+			newBackup("backup-4", time.Minute, BackupMethodBarmanObjectStore, BackupPhaseCompleted),
+		}}
+
+		Expect(backupList.CanExecuteParallelBackup("backup-2", 2)).To(BeTrue())
+		Expect(backupList.CanExecuteParallelBackup("backup-3", 2)).To(BeFalse())
+		Expect(backupList.CanExecuteParallelBackup("backup-3", 3)).To(BeTrue())
+		Expect(backupList.CanExecuteParallelBackup("backup-2", 1)).To(BeFalse())
+	})
+
+	It("starts the backups in the order they have been created", func() {
+		backupList := BackupList{Items: []Backup{
+			newBackup("backup-a", time.Minute, BackupMethodBarmanObjectStore, ""),
+			newBackup("backup-b", 2*time.Minute, BackupMethodBarmanObjectStore, ""),
+		}}
+
+		Expect(backupList.CanExecuteParallelBackup("backup-a", 1)).To(BeFalse())
+		Expect(backupList.CanExecuteParallelBackup("backup-b", 1)).To(BeTrue())
+	})
+
+	It("lets the backups in progress continue", func() {
+		backupList := BackupList{Items: []Backup{
+			newBackup("backup-1", 2*time.Minute, BackupMethodBarmanObjectStore, BackupPhaseRunning),
+			newBackup("backup-2", time.Minute, BackupMethodBarmanObjectStore, BackupPhaseRunning),
+		}}
+
+		Expect(backupList.CanExecuteParallelBackup("backup-2", 1)).To(BeTrue())
+	})
+
+	It("never runs a backup together with a volume snapshot backup", func() {
+		backupList := BackupList{Items: []Backup{
+			newBackup("backup-1", 2*time.Minute, BackupMethodVolumeSnapshot, BackupPhaseRunning),
+			newBackup("backup-2", time.Minute, BackupMethodBarmanObjectStore, ""),
+		}}
+
+		Expect(backupList.CanExecuteParallelBackup("backup-2", 5)).To(BeFalse())
+	})
+
+	It("never runs a volume snapshot backup together with another backup", func() {
+		backupList := BackupList{Items: []Backup{
+			newBackup("backup-1", 2*time.Minute, BackupMethodBarmanObjectStore, BackupPhaseRunning),
+			newBackup("backup-2", time.Minute, BackupMethodVolumeSnapshot, ""),
+		}}
+
+		Expect(backupList.CanExecuteParallelBackup("backup-2", 5)).To(BeFalse())
+
+		backupList.Items[0].Status.Phase = BackupPhaseCompleted
+		Expect(backupList.CanExecuteParallelBackup("backup-2", 5)).To(BeTrue())
+	})
+
+	It("lets a volume snapshot backup waiting before go first", func() {
+		backupList := BackupList{Items: []Backup{
+			newBackup("backup-2", 2*time.Minute, BackupMethodVolumeSnapshot, ""),
+			newBackup("backup-1", time.Minute, BackupMethodBarmanObjectStore, ""),
+		}}
+
+		Expect(backupList.CanExecuteParallelBackup("backup-1", 5)).To(BeFalse())
+		Expect(backupList.CanExecuteParallelBackup("backup-2", 5)).To(BeTrue())
+	})
+
+	It("lets the backups waiting before a volume snapshot backup go first", func() {
+		backupList := BackupList{Items: []Backup{
+			newBackup("backup-2", 2*time.Minute, BackupMethodBarmanObjectStore, ""),
+			newBackup("backup-1", time.Minute, BackupMethodVolumeSnapshot, ""),
+		}}
+
+		Expect(backupList.CanExecuteParallelBackup("backup-1", 5)).To(BeFalse())
+		Expect(backupList.CanExecuteParallelBackup("backup-2", 5)).To(BeTrue())
+	})
+})
+
+var _ = Describe("snapshot owner reference resolution", func() {
+	var (
+		backup  *Backup
+		cluster *Cluster
+	)
+
+	BeforeEach(func() {
+		backup = &Backup{}
+		cluster = &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					VolumeSnapshot: &VolumeSnapshotConfiguration{},
+				},
+			},
+		}
+
+		originalDefault := configuration.Current.SnapshotOwnerReference
+		DeferCleanup(func() {
+			configuration.Current.SnapshotOwnerReference = originalDefault
+		})
+		configuration.Current.SnapshotOwnerReference = ""
+	})
+
+	It("defaults to no owner reference", func() {
+		Expect(backup.GetSnapshotOwnerReference(cluster)).To(Equal(ShapshotOwnerReferenceNone))
+
+		cluster.Spec.Backup = nil
+		Expect(backup.GetSnapshotOwnerReference(cluster)).To(Equal(ShapshotOwnerReferenceNone))
+	})
+
+	It("uses the default of the operator", func() {
+		configuration.Current.SnapshotOwnerReference = string(SnapshotOwnerReferenceCluster)
+		Expect(backup.GetSnapshotOwnerReference(cluster)).To(Equal(SnapshotOwnerReferenceCluster))
+	})
+
+	It("lets the cluster override the default of the operator", func() {
+		configuration.Current.SnapshotOwnerReference = string(SnapshotOwnerReferenceCluster)
+		cluster.Spec.Backup.VolumeSnapshot.SnapshotOwnerReference = ShapshotOwnerReferenceNone
+		Expect(backup.GetSnapshotOwnerReference(cluster)).To(Equal(ShapshotOwnerReferenceNone))
+	})
+
+	It("lets the backup override the cluster", func() {
+		configuration.Current.SnapshotOwnerReference = string(SnapshotOwnerReferenceCluster)
+		cluster.Spec.Backup.VolumeSnapshot.SnapshotOwnerReference = ShapshotOwnerReferenceNone
+		backup.Spec.SnapshotOwnerReference = SnapshotOwnerReferenceBackup
+		Expect(backup.GetSnapshotOwnerReference(cluster)).To(Equal(SnapshotOwnerReferenceBackup))
+	})
+
+	It("lets the backup override the default of the operator", func() {
+		configuration.Current.SnapshotOwnerReference = string(SnapshotOwnerReferenceCluster)
+		backup.Spec.SnapshotOwnerReference = ShapshotOwnerReferenceNone
+		Expect(backup.GetSnapshotOwnerReference(cluster)).To(Equal(ShapshotOwnerReferenceNone))
+	})
+})
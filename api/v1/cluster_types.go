@@ -20,10 +20,13 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -129,12 +132,51 @@ const (
 	SnapshotOwnerReferenceCluster SnapshotOwnerReference = "cluster"
 )
 
+// The placeholders which can be used, in the `${name}` form, in the values of
+// the labels and of the annotations of the volume snapshots
+const (
+	// VolumeSnapshotPlaceholderClusterName is replaced with the name of the cluster
+	VolumeSnapshotPlaceholderClusterName = "cluster.name"
+	// VolumeSnapshotPlaceholderClusterNamespace is replaced with the namespace of the cluster
+	VolumeSnapshotPlaceholderClusterNamespace = "cluster.namespace"
+	// VolumeSnapshotPlaceholderBackupName is replaced with the name of the backup
+	VolumeSnapshotPlaceholderBackupName = "backup.name"
+	// VolumeSnapshotPlaceholderBackupTimestamp is replaced with the time the
+	// backup started at, in UTC and in the `20060102T150405Z` format
+	VolumeSnapshotPlaceholderBackupTimestamp = "backup.timestamp"
+	// VolumeSnapshotPlaceholderInstanceName is replaced with the name of the
+	// instance the snapshot is taken from
+	VolumeSnapshotPlaceholderInstanceName = "instance.name"
+	// VolumeSnapshotPlaceholderInstanceRole is replaced with the role of the
+	// instance the snapshot is taken from, `primary` or `standby`
+	VolumeSnapshotPlaceholderInstanceRole = "instance.role"
+	// VolumeSnapshotPlaceholderPVCRole is replaced with the role of the
+	// snapshotted PersistentVolumeClaim, such as `PG_DATA`
+	VolumeSnapshotPlaceholderPVCRole = "pvc.role"
+)
+
+// VolumeSnapshotPlaceholders are the placeholders which can be used in the
+// values of the labels and of the annotations of the volume snapshots
+var VolumeSnapshotPlaceholders = []string{
+	VolumeSnapshotPlaceholderClusterName,
+	VolumeSnapshotPlaceholderClusterNamespace,
+	VolumeSnapshotPlaceholderBackupName,
+	VolumeSnapshotPlaceholderBackupTimestamp,
+	VolumeSnapshotPlaceholderInstanceName,
+	VolumeSnapshotPlaceholderInstanceRole,
+	VolumeSnapshotPlaceholderPVCRole,
+}
+
 // VolumeSnapshotConfiguration represents the configuration for the execution of snapshot backups.
 type VolumeSnapshotConfiguration struct {
 	// Labels are key-value pairs that will be added to .metadata.labels snapshot resources.
+	// The values can contain placeholders, such as `${cluster.name}`, replaced
+	// with the metadata of the cluster, of the backup and of the snapshot
 	// +optional
 	Labels map[string]string `json:"labels,omitempty"`
 	// Annotations key-value pairs that will be added to .metadata.annotations snapshot resources.
+	// The values can contain placeholders, such as `${cluster.name}`, replaced
+	// with the metadata of the cluster, of the backup and of the snapshot
 	// +optional
 	Annotations map[string]string `json:"annotations,omitempty"`
 	// ClassName specifies the Snapshot Class to be used for PG_DATA PersistentVolumeClaim.
@@ -144,11 +186,511 @@ type VolumeSnapshotConfiguration struct {
 	// WalClassName specifies the Snapshot Class to be used for the PG_WAL PersistentVolumeClaim.
 	// +optional
 	WalClassName string `json:"walClassName,omitempty"`
-	// SnapshotOwnerReference indicates the type of owner reference the snapshot should have. .
+	// StandbyClassName specifies the Snapshot Class to be used in place of
+	// ClassName when the backup is taken from a standby instance, i.e. to use
+	// a cheaper storage tier for the backups not taken from the primary
+	// +optional
+	StandbyClassName string `json:"standbyClassName,omitempty"`
+	// SnapshotOwnerReference indicates the type of owner reference the snapshot should have.
+	// It can be overridden by the backup, and defaults to the one configured in the
+	// operator, or to `none`
 	// +optional
 	// +kubebuilder:validation:Enum=none;cluster;backup
-	// +kubebuilder:default:=none
 	SnapshotOwnerReference SnapshotOwnerReference `json:"snapshotOwnerReference,omitempty"`
+	// InheritedLabelPrefixes is the list of prefixes of the keys of the Cluster
+	// labels that will be added to .metadata.labels snapshot resources.
+	// Labels managed by Kubernetes or by the operator are never inherited.
+	// +optional
+	InheritedLabelPrefixes []string `json:"inheritedLabelPrefixes,omitempty"`
+	// InheritedAnnotationPrefixes is the list of prefixes of the keys of the Cluster
+	// annotations that will be added to .metadata.annotations snapshot resources.
+	// Annotations managed by Kubernetes or by the operator are never inherited.
+	// +optional
+	InheritedAnnotationPrefixes []string `json:"inheritedAnnotationPrefixes,omitempty"`
+	// RequiredLabels are key-value pairs that must be present in the
+	// .metadata.labels of every snapshot resource taken by backups, i.e. to
+	// have them included in the reports of external backup scanners.
+	// They are added to the new snapshots and restored on the existing
+	// ones when missing or changed. Labels managed by Kubernetes or by
+	// the operator cannot be required.
+	// +optional
+	RequiredLabels map[string]string `json:"requiredLabels,omitempty"`
+	// Retention is the retention policy of the snapshots of the PG_DATA
+	// PersistentVolumeClaims taken by completed backups. A backup retained
+	// by none of the policies is deleted together with all its snapshots.
+	// +optional
+	Retention *VolumeSnapshotRetention `json:"retention,omitempty"`
+	// ManualRetention is the retention policy of the snapshots of the PG_DATA
+	// PersistentVolumeClaims taken by manual backups, i.e. the ones not
+	// created by a ScheduledBackup. When specified, the `retention` policy
+	// only applies to the snapshots taken by scheduled backups.
+	// +optional
+	ManualRetention *VolumeSnapshotRetention `json:"manualRetention,omitempty"`
+	// WalRetention is the retention policy of the snapshots of the PG_WAL
+	// PersistentVolumeClaims taken by backups. A backup whose PG_DATA
+	// snapshot is not retained anymore is kept as long as its PG_WAL
+	// snapshot is retained by this policy. The PG_WAL snapshots not retained
+	// are deleted on their own when the WAL archive contains the WAL files
+	// needed to restore the PG_DATA snapshots taken by the same backups.
+	// When not specified, only the PG_DATA snapshots are considered.
+	// +optional
+	WalRetention *VolumeSnapshotRetention `json:"walRetention,omitempty"`
+	// FencingRequirements declares, for each role of the PersistentVolumeClaims,
+	// whether the instance needs to be fenced while they are snapshotted.
+	// Every PersistentVolumeClaim of the instance must have the same
+	// requirement, and the instance is not fenced at all when fencing is
+	// not required. Fencing is required for the roles not listed here.
+	// +optional
+	FencingRequirements []VolumeSnapshotFencingRequirement `json:"fencingRequirements,omitempty"`
+	// QuietPeriod configures the backups to wait for a period of low write
+	// activity on the primary instance before fencing the target instance
+	// and taking the snapshots
+	// +optional
+	QuietPeriod *VolumeSnapshotQuietPeriod `json:"quietPeriod,omitempty"`
+	// MinHealthyStandbys configures the backups taken from a standby to
+	// require a minimum number of other healthy standbys to remain available
+	// while the target instance is fenced
+	// +optional
+	MinHealthyStandbys *VolumeSnapshotMinHealthyStandbys `json:"minHealthyStandbys,omitempty"`
+	// MaxStandbyLag configures the backups taken from a standby to check
+	// that the target is not lagging too much behind the primary, as the
+	// backup would be stale
+	// +optional
+	MaxStandbyLag *VolumeSnapshotMaxStandbyLag `json:"maxStandbyLag,omitempty"`
+	// PreflightCheck configures the backups to check that the
+	// external-snapshotter is responsive before fencing the target
+	// instance, failing the backup otherwise. The check is skipped
+	// when not specified
+	// +optional
+	PreflightCheck *VolumeSnapshotPreflightCheck `json:"preflightCheck,omitempty"`
+	// CheckSnapshotQuota configures the backups to check, before fencing
+	// the target instance, that the snapshots fit within the quota of the
+	// namespace and of the CSI drivers exposing it, failing the backup
+	// otherwise. The quotas which are not available are not checked
+	// +optional
+	CheckSnapshotQuota bool `json:"checkSnapshotQuota,omitempty"`
+	// Incremental configures the backups to chain each new snapshot to the
+	// snapshot of the same PersistentVolumeClaim taken by the most recent
+	// completed backup, through the `cnpg.io/parentSnapshot` annotation, so
+	// that the CSI drivers supporting it can take space-efficient incremental
+	// snapshots. Only the snapshot classes annotated with
+	// `cnpg.io/incrementalSnapshots: "true"` are chained, and a full snapshot
+	// is taken otherwise
+	// +optional
+	Incremental bool `json:"incremental,omitempty"`
+	// SkipDriverHealthCheck disables the check that the CSI drivers of the
+	// snapshot classes are registered on the node of the target instance
+	// before starting a backup. The check is best-effort, and is enabled
+	// by default
+	// +optional
+	SkipDriverHealthCheck bool `json:"skipDriverHealthCheck,omitempty"`
+	// SkipFencingOnQuiescentStandby configures the backups taken from a
+	// standby to skip fencing when the target is already quiescent, i.e.
+	// its WAL replay is paused and its WAL receiver is not streaming. The
+	// snapshot is then crash-consistent. The target is fenced as usual
+	// when it is not quiescent, or when it has more than one volume
+	// +optional
+	SkipFencingOnQuiescentStandby bool `json:"skipFencingOnQuiescentStandby,omitempty"`
+
+	// MaxFenceDuration is the maximum time in seconds the target instance
+	// can stay fenced while its volumes are snapshotted. When exceeded, the
+	// instance is unfenced to restore its availability and the backup fails.
+	// Zero, the default, means no limit
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxFenceDuration int32 `json:"maxFenceDuration,omitempty"`
+
+	// MaxRestorableAge is the age beyond which a volume snapshot backup is
+	// considered too old to roll forward, and flagged as not restorable
+	// through the `Restorable` condition of the Backup. When set, backups
+	// whose start LSN precedes the WALs available in the WAL archive are
+	// flagged too. The age is expressed in the form of `XXu` where `XX` is
+	// a positive integer and `u` is in `[hdw]` - hours, days, weeks.
+	// +kubebuilder:validation:Pattern=^[1-9][0-9]*[hdw]$
+	// +optional
+	MaxRestorableAge string `json:"maxRestorableAge,omitempty"`
+
+	// PgControldataContainer is the name of the container of the instance
+	// Pod where `pg_controldata` is executed to annotate the volume snapshots.
+	// The command runs with the user of the container security context.
+	// When empty, the output of `pg_controldata` is requested to the
+	// instance manager
+	// +optional
+	PgControldataContainer string `json:"pgControldataContainer,omitempty"`
+
+	// When enabled, the backup fails if the output of `pg_controldata`
+	// cannot be captured before taking a snapshot, instead of taking the
+	// snapshot without it. The output is needed to validate the consistency
+	// of the snapshot when restoring it
+	// +optional
+	RequireControlData bool `json:"requireControlData,omitempty"`
+
+	// When enabled, the backups of a replica cluster record the position
+	// of its designated primary as seen by the source, i.e. the sent, flushed
+	// and replayed LSNs reported in `pg_stat_replication`, to assess the
+	// freshness of the backup relative to the source
+	// +optional
+	CaptureSourceReplication bool `json:"captureSourceReplication,omitempty"`
+
+	// When enabled, the temporary files and the temporary statistics of
+	// PostgreSQL are removed from the fenced instance before snapshotting
+	// its volumes, to reduce the size of the snapshots. The cleanup is skipped
+	// when the instance is not fenced, as the files may still be in use
+	// +optional
+	CleanTemporaryFiles bool `json:"cleanTemporaryFiles,omitempty"`
+
+	// DeletionPolicy is the deletion policy expected on the
+	// VolumeSnapshotContents bound to the volume snapshots taken by the
+	// backups. When set, the operator detects the contents whose deletion
+	// policy has been changed, and handles them according to
+	// `deletionPolicyDriftAction`
+	// +kubebuilder:validation:Enum=Delete;Retain
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+
+	// DeletionPolicyDriftAction is the action taken when the deletion policy
+	// of a VolumeSnapshotContent differs from `deletionPolicy`: `correct`
+	// restores the expected deletion policy, `report` only raises an event.
+	// Defaults to `correct`
+	// +kubebuilder:validation:Enum=correct;report
+	// +optional
+	DeletionPolicyDriftAction DeletionPolicyDriftAction `json:"deletionPolicyDriftAction,omitempty"`
+
+	// ReadyTimeout is the maximum time in seconds a volume snapshot can
+	// take, since its creation, to be ready to use. When exceeded, the
+	// backup fails and the target instance is unfenced. Zero, the default,
+	// means no limit
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ReadyTimeout int32 `json:"readyTimeout,omitempty"`
+
+	// RemoteTarget configures the backups to take the snapshots of the
+	// designated primary of a replica cluster running in a different
+	// Kubernetes cluster, instead of an instance of this cluster
+	// +optional
+	RemoteTarget *VolumeSnapshotRemoteTarget `json:"remoteTarget,omitempty"`
+
+	// ReuseWindow is the maximum age in seconds of a snapshot taken by a
+	// previous backup which can be reused by a new one, instead of taking
+	// a new snapshot, when the fenced PVC didn't change since then.
+	// Zero, the default, means the snapshots are never reused
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ReuseWindow int32 `json:"reuseWindow,omitempty"`
+
+	// Online enables the online (hot) snapshot backups: instead of fencing
+	// the target instance, the operator puts it in backup mode through the
+	// PostgreSQL low level API for base backups while the snapshots are
+	// taken. Defaults to `false`, taking offline (cold) backups
+	// +optional
+	Online bool `json:"online,omitempty"`
+
+	// OnlineConfiguration configures the online snapshot backups
+	// +optional
+	OnlineConfiguration *OnlineConfiguration `json:"onlineConfiguration,omitempty"`
+
+	// CreationRetry configures the backups to retry, with an exponential
+	// backoff, the creation of the volume snapshots failing with a
+	// transient error, such as the rate limiting of the storage backend,
+	// before failing the backup. The creation is not retried when not
+	// specified
+	// +optional
+	CreationRetry *VolumeSnapshotCreationRetry `json:"creationRetry,omitempty"`
+
+	// ArchiveVerification makes the offline backups wait, once the target
+	// instance has been unfenced, for the WAL file containing the checkpoint
+	// the snapshots are consistent at to be archived, before being
+	// completed. Only applies when WAL archiving is configured
+	// +optional
+	ArchiveVerification *VolumeSnapshotArchiveVerification `json:"archiveVerification,omitempty"`
+
+	// PreSnapshotCommands are the SQL commands executed, in order, in the
+	// target instance just before its volumes are snapshotted, while
+	// PostgreSQL is still running, that is before fencing the instance.
+	// A failing command fails the backup, unless it's marked as best-effort
+	// +optional
+	PreSnapshotCommands []VolumeSnapshotPreSnapshotCommand `json:"preSnapshotCommands,omitempty"`
+
+	// TolerateFencedInstances allows the backups to fence the target
+	// instance while other instances of the cluster are already fenced,
+	// i.e. a standby fenced for maintenance. By default, the backup fails
+	// when any instance other than the target is fenced. Fencing the whole
+	// cluster is never tolerated
+	// +optional
+	TolerateFencedInstances bool `json:"tolerateFencedInstances,omitempty"`
+
+	// RequeueBackoff configures how often the backups check the fencing
+	// of the target instance and the readiness of the snapshots, i.e. to
+	// reduce the API traffic while the snapshots of large volumes are taken
+	// +optional
+	RequeueBackoff *VolumeSnapshotRequeueBackoff `json:"requeueBackoff,omitempty"`
+}
+
+// VolumeSnapshotRequeueBackoff configures the interval between the checks
+// of the operations a volume snapshot backup is waiting for. The interval
+// starts from the initial one, and grows with the waiting time up to the
+// maximum one
+type VolumeSnapshotRequeueBackoff struct {
+	// Interval is the initial time in seconds between the checks.
+	// Defaults to 10 seconds
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default:=10
+	// +optional
+	Interval int32 `json:"interval,omitempty"`
+	// MaxInterval is the longest time in seconds between the checks.
+	// Setting it to the initial interval makes the interval fixed.
+	// Defaults to 60 seconds
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default:=60
+	// +optional
+	MaxInterval int32 `json:"maxInterval,omitempty"`
+}
+
+// VolumeSnapshotPreSnapshotCommand is a SQL command executed in the target
+// instance of a volume snapshot backup before taking the snapshots
+type VolumeSnapshotPreSnapshotCommand struct {
+	// SQL is the command to be executed, as the `postgres` user in the
+	// `postgres` database, such as `CHECKPOINT`
+	// +kubebuilder:validation:MinLength=1
+	SQL string `json:"sql"`
+
+	// BestEffort makes a failure of the command be reported through a
+	// warning event, instead of failing the backup
+	// +optional
+	BestEffort bool `json:"bestEffort,omitempty"`
+}
+
+// VolumeSnapshotArchiveVerification configures the verification that the
+// WAL file needed by the snapshots of a backup has been archived
+type VolumeSnapshotArchiveVerification struct {
+	// Timeout is the maximum time in seconds the backup waits for the WAL
+	// file to be archived, failing when exceeded. Zero, the default, means
+	// no limit
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Timeout int32 `json:"timeout,omitempty"`
+}
+
+// VolumeSnapshotCreationRetry configures the retry of the creation of the
+// volume snapshots failing with a transient error
+type VolumeSnapshotCreationRetry struct {
+	// ErrorPatterns are the regular expressions, matched case-insensitively
+	// against the creation errors, recognizing the transient errors to be
+	// retried. Defaults to the errors reporting rate limiting and throttling
+	// +optional
+	ErrorPatterns []string `json:"errorPatterns,omitempty"`
+	// MaxAttempts is the maximum number of attempts to create a volume
+	// snapshot, including the first one. Defaults to 5
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default:=5
+	// +optional
+	MaxAttempts int32 `json:"maxAttempts,omitempty"`
+	// Backoff is the time in seconds to wait before the first retry, which
+	// is doubled at every following one. Defaults to 1 second
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default:=1
+	// +optional
+	Backoff int32 `json:"backoff,omitempty"`
+}
+
+// DefaultSnapshotCreationRetryErrorPatterns are the patterns recognizing the
+// transient errors in the creation of a volume snapshot, used when none is
+// specified
+var DefaultSnapshotCreationRetryErrorPatterns = []string{
+	"rate limit",
+	"throttl",
+	"too many requests",
+}
+
+// OnlineConfiguration configures the online snapshot backups
+type OnlineConfiguration struct {
+	// Whether `pg_backup_stop` waits for the WAL files required by the
+	// backup to be archived, which is needed to restore it. Defaults to `true`
+	// +optional
+	WaitForArchive *bool `json:"waitForArchive,omitempty"`
+
+	// Whether `pg_backup_start` requests an immediate checkpoint, instead of
+	// spreading its I/O over time, to start the backup as soon as possible.
+	// Defaults to `false`
+	// +optional
+	ImmediateCheckpoint bool `json:"immediateCheckpoint,omitempty"`
+}
+
+// DeletionPolicyDriftAction is the action taken when the deletion policy
+// of a VolumeSnapshotContent differs from the expected one
+type DeletionPolicyDriftAction string
+
+const (
+	// DeletionPolicyDriftActionCorrect restores the expected deletion policy
+	DeletionPolicyDriftActionCorrect DeletionPolicyDriftAction = "correct"
+
+	// DeletionPolicyDriftActionReport only reports the drift through an event
+	DeletionPolicyDriftActionReport DeletionPolicyDriftAction = "report"
+)
+
+// DefaultQuietPeriodDeadline is the default in seconds for the maximum time
+// a backup waits for a quiet period
+const DefaultQuietPeriodDeadline = 3600
+
+// VolumeSnapshotQuietPeriod configures the backups to wait, up to a deadline,
+// for the write activity of the primary instance to drop below a threshold
+type VolumeSnapshotQuietPeriod struct {
+	// MaxTransactionsPerSecond is the number of transactions per second
+	// executed by the primary instance below which the backup is started
+	// +kubebuilder:validation:Minimum=0
+	MaxTransactionsPerSecond int32 `json:"maxTransactionsPerSecond"`
+	// Deadline is the maximum time in seconds, since the creation of the
+	// Backup, to wait for a quiet period. When the deadline passes the backup
+	// is started anyway. Defaults to 3600 seconds
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default:=3600
+	// +optional
+	Deadline int32 `json:"deadline,omitempty"`
+}
+
+// MinHealthyStandbysPolicy is the action taken when fencing the target
+// standby of a backup would leave too few healthy standbys
+type MinHealthyStandbysPolicy string
+
+const (
+	// MinHealthyStandbysPolicyWait keeps the backup pending until enough
+	// standbys are healthy
+	MinHealthyStandbysPolicyWait MinHealthyStandbysPolicy = "wait"
+
+	// MinHealthyStandbysPolicyFail marks the backup as failed
+	MinHealthyStandbysPolicyFail MinHealthyStandbysPolicy = "fail"
+)
+
+// VolumeSnapshotMinHealthyStandbys declares how many healthy standbys,
+// besides the target one, must remain available while a standby is fenced
+// for a backup
+type VolumeSnapshotMinHealthyStandbys struct {
+	// Count is the minimum number of healthy standbys, not including the
+	// target of the backup, that must be available to fence the target
+	// +kubebuilder:validation:Minimum=1
+	Count int32 `json:"count"`
+	// Policy is the action taken when there are not enough healthy
+	// standbys: `wait` keeps the backup pending until enough standbys are
+	// healthy, `fail` marks the backup as failed. Defaults to `wait`
+	// +kubebuilder:validation:Enum=wait;fail
+	// +kubebuilder:default:=wait
+	// +optional
+	Policy MinHealthyStandbysPolicy `json:"policy,omitempty"`
+}
+
+// StandbyLagPolicy is the action taken when the target standby of a
+// backup is lagging too much behind the primary
+type StandbyLagPolicy string
+
+const (
+	// StandbyLagPolicyFail marks the backup as failed
+	StandbyLagPolicyFail StandbyLagPolicy = "fail"
+
+	// StandbyLagPolicyPickFresher elects as target the least lagging
+	// standby within the threshold, failing the backup if there's none
+	StandbyLagPolicyPickFresher StandbyLagPolicy = "pickFresher"
+)
+
+// VolumeSnapshotMaxStandbyLag declares how much the target standby of a
+// backup can lag behind the primary
+type VolumeSnapshotMaxStandbyLag struct {
+	// MaxLagBytes is the maximum amount of WAL, in bytes, the target standby
+	// can still have to replay compared to the current LSN of the primary
+	// +kubebuilder:validation:Minimum=0
+	MaxLagBytes int64 `json:"maxLagBytes"`
+	// Policy is the action taken when the target standby is lagging too
+	// much: `fail` marks the backup as failed, `pickFresher` elects as
+	// target the least lagging standby within the threshold, failing the
+	// backup if there's none. Defaults to `fail`
+	// +kubebuilder:validation:Enum=fail;pickFresher
+	// +kubebuilder:default:=fail
+	// +optional
+	Policy StandbyLagPolicy `json:"policy,omitempty"`
+}
+
+// VolumeSnapshotPreflightCheck configures the check that the
+// external-snapshotter is responsive, run before fencing the target
+// instance of a backup
+type VolumeSnapshotPreflightCheck struct {
+	// Timeout is the maximum time in seconds the API server can take to
+	// list the volume snapshot classes before the check fails.
+	// Defaults to 10 seconds
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default:=10
+	// +optional
+	Timeout int32 `json:"timeout,omitempty"`
+	// StalledSnapshotThreshold is the time in seconds after which a
+	// VolumeSnapshot of the cluster whose status has not been set yet
+	// means that the snapshot controller is not running.
+	// Defaults to 60 seconds
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default:=60
+	// +optional
+	StalledSnapshotThreshold int32 `json:"stalledSnapshotThreshold,omitempty"`
+}
+
+// VolumeSnapshotRemoteTarget declares the replica cluster, running in a
+// different Kubernetes cluster, whose designated primary is the target
+// of the backups
+type VolumeSnapshotRemoteTarget struct {
+	// KubeconfigSecret is the key of a Secret, in the namespace of the
+	// Cluster, containing the kubeconfig used to reach the Kubernetes
+	// cluster where the replica cluster runs
+	KubeconfigSecret SecretKeySelector `json:"kubeconfigSecret"`
+	// ClusterName is the name of the replica cluster
+	// +kubebuilder:validation:MinLength=1
+	ClusterName string `json:"clusterName"`
+	// Namespace is the namespace of the replica cluster. Defaults to the
+	// namespace of the Cluster
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// VolumeSnapshotFencingRequirement declares whether the snapshots of the
+// PersistentVolumeClaims having a certain role need the instance to be fenced
+type VolumeSnapshotFencingRequirement struct {
+	// Role is the role of the PersistentVolumeClaims
+	// +kubebuilder:validation:Enum=PG_DATA;PG_WAL
+	Role string `json:"role"`
+	// FencingRequired tells whether the instance needs to be fenced while the
+	// PersistentVolumeClaims having this role are snapshotted
+	FencingRequired bool `json:"fencingRequired"`
+}
+
+// VolumeSnapshotRetention defines which volume snapshots taken by backups
+// are retained. A snapshot is not retained as soon as it violates one of
+// the specified rules
+type VolumeSnapshotRetention struct {
+	// MaxCount is the number of the most recent snapshots to be retained
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxCount int `json:"maxCount,omitempty"`
+	// MaxAge is the maximum age of the snapshots to be retained, expressed
+	// in the form of `XXu` where `XX` is a positive integer and `u` is in
+	// `[hdw]` - hours, days, weeks.
+	// +kubebuilder:validation:Pattern=^[1-9][0-9]*[hdw]$
+	// +optional
+	MaxAge string `json:"maxAge,omitempty"`
+	// KeepDaily is the number of the most recent days, having at least a
+	// snapshot, for which the last snapshot of the day is retained
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepDaily int `json:"keepDaily,omitempty"`
+	// KeepWeekly is the number of the most recent ISO weeks, having at
+	// least a snapshot, for which the last snapshot of the week is retained
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepWeekly int `json:"keepWeekly,omitempty"`
+	// KeepMonthly is the number of the most recent months, having at least
+	// a snapshot, for which the last snapshot of the month is retained
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepMonthly int `json:"keepMonthly,omitempty"`
+	// KeepYearly is the number of the most recent years, having at least
+	// a snapshot, for which the last snapshot of the year is retained
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepYearly int `json:"keepYearly,omitempty"`
 }
 
 // ClusterSpec defines the desired state of Cluster
@@ -292,6 +834,15 @@ type ClusterSpec struct {
 	// +optional
 	FailoverDelay int32 `json:"failoverDelay,omitempty"`
 
+	// The interval (in seconds) at which the instance manager of each standby
+	// verifies that the `standby.signal` file is still present in PGDATA,
+	// re-creating it if missing, to prevent an accidental promotion on restart.
+	// The check is disabled when set to 0 (default)
+	// +kubebuilder:default:=0
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	StandbySignalCheckInterval int32 `json:"standbySignalCheckInterval,omitempty"`
+
 	// Affinity/Anti-affinity rules for Pods
 	// +optional
 	Affinity AffinityConfiguration `json:"affinity,omitempty"`
@@ -411,6 +962,10 @@ const (
 	// PhaseUnrecoverable for an unrecoverable cluster
 	PhaseUnrecoverable = "Cluster is in an unrecoverable state, needs manual intervention"
 
+	// PhasePostRestoreFailed is set by the restore job when the post-restore
+	// SQL queries could not be executed
+	PhasePostRestoreFailed = "Post-restore SQL failed, needs manual intervention"
+
 	// PhaseWaitingForInstancesToBeActive is a waiting phase that is triggered when an instance pod is not active
 	PhaseWaitingForInstancesToBeActive = "Waiting for the instances to become active"
 
@@ -681,6 +1236,162 @@ type ClusterStatus struct {
 	// AzurePVCUpdateEnabled shows if the PVC online upgrade is enabled for this cluster
 	// +optional
 	AzurePVCUpdateEnabled bool `json:"azurePVCUpdateEnabled,omitempty"`
+
+	// ReplicaStreamingPaused shows if the designated primary of this replica
+	// cluster has paused streaming from an unreachable source
+	// +optional
+	ReplicaStreamingPaused bool `json:"replicaStreamingPaused,omitempty"`
+
+	// The last LSN replayed by the designated primary while streaming from
+	// the source is paused
+	// +optional
+	ReplicaStreamingPausedLSN string `json:"replicaStreamingPausedLSN,omitempty"`
+
+	// The name of the external cluster the designated primary of this
+	// replica cluster streams from. It is only reported when fallback
+	// sources are defined in the replica cluster configuration
+	// +optional
+	ReplicaActiveSource string `json:"replicaActiveSource,omitempty"`
+
+	// The status of the source of this replica cluster, as probed by the
+	// designated primary. It is only reported when enabled through the
+	// `reportSourceStatus` option of the replica cluster configuration
+	// +optional
+	SourceStatus *ReplicaSourceStatus `json:"sourceStatus,omitempty"`
+
+	// The timeline of the source followed by this replica cluster, as last
+	// probed by the designated primary. It is kept while the source is not
+	// reachable, and it is only reported when enabled through the
+	// `reportSourceStatus` option of the replica cluster configuration
+	// +optional
+	SourceTimelineID int `json:"sourceTimelineID,omitempty"`
+
+	// Whether the source of this replica cluster has a successful backup
+	// more recent than the maximum age. It is only reported when enabled
+	// through the `sourceBackupCheck` option of the replica cluster
+	// configuration, and it is false when the source Cluster is not found
+	// +optional
+	SourceBackupFresh *bool `json:"sourceBackupFresh,omitempty"`
+
+	// The time of the last successful backup of the source of this replica
+	// cluster, as reported by the source Cluster
+	// +optional
+	SourceLastSuccessfulBackup string `json:"sourceLastSuccessfulBackup,omitempty"`
+
+	// The status of the automatic re-seed of this replica cluster. It is
+	// only reported when enabled through the `automaticReseed` option of
+	// the replica cluster configuration
+	// +optional
+	ReplicaReseed *ReplicaReseedStatus `json:"replicaReseed,omitempty"`
+
+	// The status of the WAL archiving of the designated primary of this
+	// replica cluster. It is only reported when enabled through the
+	// `localArchive` option of the replica cluster configuration
+	// +optional
+	LocalArchiveStatus *ReplicaLocalArchiveStatus `json:"localArchiveStatus,omitempty"`
+
+	// The prefixes used over time for the names of the HA replication slots,
+	// the last one being the current prefix. The slots named after the
+	// previous prefixes are stale, and are removed by the primary instance
+	// +optional
+	HASlotPrefixes []string `json:"haSlotPrefixes,omitempty"`
+
+	// The most recent backup attempts of the cluster, from the oldest to the
+	// newest. It is only reported when enabled through the `historyLimit`
+	// option of the backup configuration
+	// +optional
+	BackupHistory []BackupAttempt `json:"backupHistory,omitempty"`
+}
+
+// RecordBackupAttempt records the passed terminated backup in the backup
+// history of the cluster, which is kept sorted by termination time and bounded
+// by the history limit of the backup configuration. Recording a backup twice
+// has no effect, and so does recording a backup older than the whole history
+// when it is full. The history is cleared when disabled. The returned flag
+// tells whether the history has been changed
+func (cluster *Cluster) RecordBackupAttempt(backup *Backup) bool {
+	var limit int
+	if cluster.Spec.Backup != nil {
+		limit = int(cluster.Spec.Backup.HistoryLimit)
+	}
+
+	if limit <= 0 {
+		changed := len(cluster.Status.BackupHistory) > 0
+		cluster.Status.BackupHistory = nil
+		return changed
+	}
+
+	// the limit may have been lowered
+	history := cluster.Status.BackupHistory
+	changed := false
+	if len(history) > limit {
+		history = history[len(history)-limit:]
+		cluster.Status.BackupHistory = history
+		changed = true
+	}
+
+	for _, attempt := range history {
+		if attempt.Name == backup.Name {
+			return changed
+		}
+	}
+
+	attempt := BackupAttempt{
+		Name:   backup.Name,
+		Method: backup.Spec.Method,
+		Phase:  backup.Status.Phase,
+		Error:  backup.Status.Error,
+	}
+	if backup.Status.StartedAt != nil {
+		attempt.StartedAt = backup.Status.StartedAt.UTC().Format(time.RFC3339)
+	}
+	if backup.Status.StoppedAt != nil {
+		attempt.StoppedAt = backup.Status.StoppedAt.UTC().Format(time.RFC3339)
+		if backup.Status.StartedAt != nil {
+			attempt.Duration = backup.Status.StoppedAt.Sub(backup.Status.StartedAt.Time).String()
+		}
+	}
+
+	// RFC3339 timestamps in UTC sort as strings, and the attempts
+	// without a termination time are considered the oldest ones
+	position := sort.Search(len(history), func(i int) bool {
+		return history[i].StoppedAt > attempt.StoppedAt
+	})
+	if len(history) >= limit && position == 0 {
+		return changed
+	}
+
+	history = append(history, BackupAttempt{})
+	copy(history[position+1:], history[position:])
+	history[position] = attempt
+	if len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+
+	cluster.Status.BackupHistory = history
+	return true
+}
+
+// MaxHASlotPrefixes is the maximum number of prefixes of the HA replication
+// slots kept in the cluster status
+const MaxHASlotPrefixes = 5
+
+// GetStaleHASlotPrefixes returns the prefixes previously used for the HA
+// replication slots, excluding the current one
+func (cluster *Cluster) GetStaleHASlotPrefixes() []string {
+	var haConfiguration *ReplicationSlotsHAConfiguration
+	if cluster.Spec.ReplicationSlots != nil {
+		haConfiguration = cluster.Spec.ReplicationSlots.HighAvailability
+	}
+	currentPrefix := haConfiguration.GetSlotPrefix()
+
+	var result []string
+	for _, prefix := range cluster.Status.HASlotPrefixes {
+		if prefix != currentPrefix {
+			result = append(result, prefix)
+		}
+	}
+	return result
 }
 
 // InstanceReportedState describes the last reported state of an instance during a reconciliation loop
@@ -704,6 +1415,18 @@ const (
 	ConditionBackup ClusterConditionType = "LastBackupSucceeded"
 	// ConditionClusterReady represents whether a cluster is Ready
 	ConditionClusterReady ClusterConditionType = "Ready"
+	// ConditionBackupProtected represents whether the cluster has a recent
+	// successful backup
+	ConditionBackupProtected ClusterConditionType = "BackupProtected"
+	// ConditionReplicationSlotsValid represents whether the replication slots
+	// of the primary instance are still usable, or some of them have been invalidated
+	ConditionReplicationSlotsValid ClusterConditionType = "ReplicationSlotsValid"
+	// ConditionSourceWalLevelValid represents whether the source of a replica
+	// cluster requiring logical decoding runs with `wal_level` set to `logical`
+	ConditionSourceWalLevelValid ClusterConditionType = "SourceWalLevelValid"
+	// ConditionReplicaRecoveryTargetReached represents whether the designated
+	// primary of a replica cluster reached the recovery target it was pinned to
+	ConditionReplicaRecoveryTargetReached ClusterConditionType = "ReplicaRecoveryTargetReached"
 )
 
 // A Condition that can be used to communicate the Backup progress
@@ -782,45 +1505,524 @@ const (
 	// ClusterIsNotReady means that the condition changed because the cluster is not ready
 	ClusterIsNotReady ConditionReason = "ClusterIsNotReady"
 
+	// ConditionReasonRecentBackupAvailable means that the condition changed because the
+	// last successful backup is recent enough
+	ConditionReasonRecentBackupAvailable ConditionReason = "RecentBackupAvailable"
+
+	// ConditionReasonNoRecentBackup means that the condition changed because there is no
+	// successful backup which is recent enough
+	ConditionReasonNoRecentBackup ConditionReason = "NoRecentBackup"
+
+	// ConditionReasonReplicationSlotsValid means that the condition changed because no
+	// replication slot of the primary instance is invalidated
+	ConditionReasonReplicationSlotsValid ConditionReason = "ReplicationSlotsValid"
+
+	// ConditionReasonReplicationSlotInvalidated means that the condition changed because
+	// some replication slots of the primary instance have been invalidated
+	ConditionReasonReplicationSlotInvalidated ConditionReason = "ReplicationSlotInvalidated"
+
+	// ConditionReasonSourceWalLevelLogical means that the condition changed because
+	// the source of the replica cluster runs with `wal_level` set to `logical`
+	ConditionReasonSourceWalLevelLogical ConditionReason = "SourceWalLevelLogical"
+
+	// ConditionReasonSourceWalLevelNotLogical means that the condition changed because
+	// the source of the replica cluster runs with a `wal_level` lower than `logical`
+	ConditionReasonSourceWalLevelNotLogical ConditionReason = "SourceWalLevelNotLogical"
+
+	// ConditionReasonReplicaRecoveryTargetReached means that the condition changed because
+	// the designated primary of the replica cluster paused the replay at its recovery target
+	ConditionReasonReplicaRecoveryTargetReached ConditionReason = "ReplicaRecoveryTargetReached"
+
 	// DetachedVolume is the reason that is set when we do a rolling upgrade to add a PVC volume to a cluster
 	DetachedVolume ConditionReason = "DetachedVolume"
 )
 
-// EmbeddedObjectMetadata contains metadata to be inherited by all resources related to a Cluster
-type EmbeddedObjectMetadata struct {
+// EmbeddedObjectMetadata contains metadata to be inherited by all resources related to a Cluster
+type EmbeddedObjectMetadata struct {
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// PoolerIntegrations encapsulates the needed integration for the poolers referencing the cluster
+type PoolerIntegrations struct {
+	// +optional
+	PgBouncerIntegration PgBouncerIntegrationStatus `json:"pgBouncerIntegration,omitempty"`
+}
+
+// PgBouncerIntegrationStatus encapsulates the needed integration for the pgbouncer poolers referencing the cluster
+type PgBouncerIntegrationStatus struct {
+	// +optional
+	Secrets []string `json:"secrets,omitempty"`
+}
+
+// ReplicaClusterConfiguration encapsulates the configuration of a replica
+// cluster
+type ReplicaClusterConfiguration struct {
+	// The name of the external cluster which is the replication origin
+	// +kubebuilder:validation:MinLength=1
+	Source string `json:"source"`
+
+	// The names of the external clusters the designated primary falls back
+	// to, in order, when the source is not reachable. The designated primary
+	// probes the sources in the background, and streams from the first
+	// reachable one among `source` and the fallback sources, switching back
+	// as soon as a preferred source is reachable again. Only the streaming
+	// connection falls back, and the fallback sources need their connection
+	// parameters
+	// +optional
+	FallbackSources []string `json:"fallbackSources,omitempty"`
+
+	// If replica mode is enabled, this cluster will be a replica of an
+	// existing cluster. Replica cluster can be created from a recovery
+	// object store or via streaming through pg_basebackup.
+	// Refer to the Replica clusters page of the documentation for more information.
+	Enabled bool `json:"enabled"`
+
+	// When enabled, the designated primary stops streaming from the source
+	// while the source is not reachable, for example during a planned
+	// maintenance, and keeps serving read-only queries at the last replayed LSN.
+	// Streaming resumes automatically as soon as the source is reachable again.
+	// +optional
+	PauseStreamingOnSourceMaintenance bool `json:"pauseStreamingOnSourceMaintenance,omitempty"`
+
+	// DesignatedPrimaryFailover defines what happens when the designated
+	// primary fails. With `automatic` (the default) the most advanced healthy
+	// replica is promoted to designated primary, while with `manual` the
+	// operator waits for a replica to be promoted by the user.
+	// +kubebuilder:validation:Enum=automatic;manual
+	// +kubebuilder:default:=automatic
+	// +optional
+	DesignatedPrimaryFailover DesignatedPrimaryFailoverPolicy `json:"designatedPrimaryFailover,omitempty"`
+
+	// DetachedSlots defines what happens, when the replica cluster is
+	// detached from the source and its designated primary is promoted, to
+	// the physical replication slots inherited from the source, that is the
+	// ones not managed by the replica cluster for high availability.
+	// With `keep` (the default) they are kept as user-managed slots, while
+	// with `drop` the inactive ones are dropped, so that they don't retain
+	// WAL files forever
+	// +kubebuilder:validation:Enum=keep;drop
+	// +kubebuilder:default:=keep
+	// +optional
+	DetachedSlots DetachedSlotsPolicy `json:"detachedSlots,omitempty"`
+
+	// When enabled, and the `host` connection parameter of the source lists
+	// more than one host, the designated primary probes each of them and
+	// moves the reachable ones at the beginning of the list, so that
+	// PostgreSQL tries the healthiest host first when connecting to the source
+	// +optional
+	OrderSourceHostsByHealth bool `json:"orderSourceHostsByHealth,omitempty"`
+
+	// The timeline of the source followed by the designated primary, used as
+	// `recovery_target_timeline`. It can be `latest` (the default), to follow
+	// the timeline switches happening in the source, or the ID of a timeline
+	// to pin the designated primary to it
+	// +kubebuilder:validation:Pattern=`^(latest|[1-9][0-9]*)$`
+	// +optional
+	TargetTimeline string `json:"targetTimeline,omitempty"`
+
+	// ChannelBinding configures the SCRAM channel binding of the connection
+	// of the designated primary to the source, as the `channel_binding`
+	// connection parameter. With `require` the connection fails unless the
+	// source authenticates it through SCRAM over SSL, protecting the
+	// streaming from man-in-the-middle attacks, while with `prefer` channel
+	// binding is used only when the source supports it.
+	// Requires PostgreSQL 13 or above
+	// +kubebuilder:validation:Enum=prefer;require
+	// +optional
+	ChannelBinding ChannelBindingMode `json:"channelBinding,omitempty"`
+
+	// When enabled, the designated primary periodically probes the source
+	// through the external cluster connection, and the operator reports
+	// whether it is reachable, whether it is a primary, and its current LSN
+	// in the `sourceStatus` field of the cluster status
+	// +optional
+	ReportSourceStatus bool `json:"reportSourceStatus,omitempty"`
+
+	// When enabled, the replica cluster is expected to support logical
+	// decoding, which requires the source to run with `wal_level` set to
+	// `logical`. The designated primary, which always runs with `logical`,
+	// periodically checks the `wal_level` of the source, and the operator
+	// reports the outcome through the `SourceWalLevelValid` condition.
+	// The source needs to be reachable through its connection parameters
+	// +optional
+	LogicalDecoding bool `json:"logicalDecoding,omitempty"`
+
+	// AutomaticReseed enables the operator to re-seed the replica cluster
+	// from scratch, using its bootstrap method, when the designated primary
+	// is irrecoverably unable to follow the source, for example because of
+	// a lost replication slot, a diverged timeline or a gap in the WAL.
+	// Requires `reportSourceStatus` to be enabled
+	// +optional
+	AutomaticReseed *ReplicaReseedConfiguration `json:"automaticReseed,omitempty"`
+
+	// When enabled, the designated primary prefers to stream from a standby
+	// of the source, cascading across clusters, to offload the source primary.
+	// The `host` connection parameter of the source should list its read-only
+	// endpoint, followed by its read-write one: the designated primary
+	// connects to the first standby available, moving to another standby or
+	// to the primary when it fails, as the `target_session_attrs` connection
+	// parameter is set to `prefer-standby`. Requires PostgreSQL 14 or above
+	// +optional
+	PreferSourceStandby bool `json:"preferSourceStandby,omitempty"`
+
+	// When enabled, the bootstrap of the replica cluster checks that its
+	// data checksums setting, as reported by `pg_controldata`, matches the
+	// `data_checksums` setting of the source, and fails otherwise. The
+	// source needs to be reachable through its connection parameters
+	// +optional
+	ValidateDataChecksums bool `json:"validateDataChecksums,omitempty"`
+
+	// When enabled, the designated primary is reported as ready only while
+	// its WAL receiver is streaming from the source, so that the services
+	// and the load balancers stop routing traffic to it while the replication
+	// is broken or paused. By default, the designated primary is ready
+	// regardless of the streaming
+	// +optional
+	ReadinessRequiresStreaming bool `json:"readinessRequiresStreaming,omitempty"`
+
+	// When set, the designated primary is reported as ready only while the
+	// amount of WAL, in bytes, it still needs to replay to reach the current
+	// LSN of the source is not greater than this value, so that the services
+	// don't route stale reads to it, for example right after the bootstrap.
+	// The lag is measured by the periodic probes of the source, and the
+	// designated primary is not ready until it is measured for the first
+	// time. By default, the readiness doesn't depend on the lag
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ReadinessMaxLagBytes int64 `json:"readinessMaxLagBytes,omitempty"`
+
+	// Prewarm loads the configured tables and indexes in the shared buffers
+	// through the `pg_prewarm` extension, once the designated primary is up,
+	// so that the first queries don't hit cold caches. The extension needs
+	// to be installed in the source database
+	// +optional
+	Prewarm *PrewarmConfiguration `json:"prewarm,omitempty"`
+
+	// RecoveryTarget pins the designated primary to a point of the history
+	// of the source when the replica cluster is bootstrapped: the WAL is
+	// replayed up to the target, then the designated primary resumes
+	// following the source. The target is applied only once
+	// +optional
+	RecoveryTarget *ReplicaRecoveryTarget `json:"recoveryTarget,omitempty"`
+
+	// SourceBackupCheck configures the operator to check whether the source,
+	// when it is a CloudNativePG cluster managed by the same operator, has a
+	// recent backup which could be used to re-seed the replica cluster. The
+	// result is reported in the `sourceBackupFresh` field of the status
+	// +optional
+	SourceBackupCheck *ReplicaSourceBackupCheck `json:"sourceBackupCheck,omitempty"`
+
+	// When enabled, the designated primary archives the WAL files received
+	// from the source in the object store of `.spec.backup.barmanObjectStore`
+	// while following the source, so that the replica cluster has its own
+	// backup chain and is restorable independently of the source. The object
+	// store must be configured, and must not be the WAL archive of the source
+	// +optional
+	LocalArchive bool `json:"localArchive,omitempty"`
+
+	// SourceSlotName is the name of the physical replication slot, on the
+	// source, used by the designated primary to stream from it. When not
+	// set, the name is derived from the name of the designated primary
+	// as for the HA replication slots, which can collide between replica
+	// clusters of the same source. The slot is not created by the operator.
+	// It may only contain lower case letters, numbers, and the underscore
+	// character
+	// +kubebuilder:validation:Pattern=^[0-9a-z_]*$
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	SourceSlotName string `json:"sourceSlotName,omitempty"`
+}
+
+// DefaultReplicaReseedStalledTimeout is the default in seconds for the time
+// the designated primary needs to be stalled before re-seeding the replica cluster
+const DefaultReplicaReseedStalledTimeout = 1800
+
+// DefaultReplicaReseedMinInterval is the default in seconds for the minimum
+// time between two automatic re-seeds of a replica cluster
+const DefaultReplicaReseedMinInterval = 86400
+
+// MinReplicaReseedMinInterval is the lowest accepted value in seconds for
+// the minimum time between two automatic re-seeds of a replica cluster
+const MinReplicaReseedMinInterval = 3600
+
+// ReplicaReseedConfiguration configures the automatic re-seed of a replica
+// cluster whose designated primary stopped following the source
+type ReplicaReseedConfiguration struct {
+	// StalledTimeout is the time in seconds the designated primary needs
+	// to be stalled, i.e. not streaming from a reachable source which is
+	// ahead of it, without replaying any WAL, before the replica cluster is
+	// re-seeded. Defaults to 1800 seconds
+	// +kubebuilder:validation:Minimum=60
+	// +optional
+	StalledTimeout int32 `json:"stalledTimeout,omitempty"`
+
+	// MinInterval is the minimum time in seconds between two automatic
+	// re-seeds of the replica cluster, protecting it from re-seed loops.
+	// Defaults to 86400 seconds, and cannot be less than 3600 seconds
+	// +kubebuilder:validation:Minimum=3600
+	// +optional
+	MinInterval int32 `json:"minInterval,omitempty"`
+}
+
+// PrewarmConfiguration tells which relations the designated primary of a
+// replica cluster loads in the shared buffers once it is up
+type PrewarmConfiguration struct {
+	// The database containing the relations
+	// +kubebuilder:validation:MinLength=1
+	Database string `json:"database"`
+
+	// The names of the tables and indexes to be loaded, optionally
+	// schema-qualified, in the order they are loaded. The relations
+	// which don't exist are skipped
+	// +kubebuilder:validation:MinItems=1
+	Relations []string `json:"relations"`
+}
+
+// ReplicaRecoveryTarget is the point the designated primary of a replica
+// cluster replays the WAL up to, before following the source. Exactly one
+// of the targets needs to be set
+type ReplicaRecoveryTarget struct {
+	// The time stamp up to which the WAL is replayed, in RFC3339 format,
+	// or as a PostgreSQL timestamp
+	// +optional
+	TargetTime string `json:"targetTime,omitempty"`
+
+	// The LSN up to which the WAL is replayed
+	// +optional
+	TargetLSN string `json:"targetLSN,omitempty"`
+}
+
+// BuildPostgresOptions builds the PostgreSQL settings pausing the replay
+// at the recovery target
+func (target *ReplicaRecoveryTarget) BuildPostgresOptions() map[string]string {
+	options := map[string]string{
+		"recovery_target_action": "pause",
+	}
+	if target.TargetTime != "" {
+		options["recovery_target_time"] = utils.ConvertToPostgresFormat(target.TargetTime)
+	}
+	if target.TargetLSN != "" {
+		options["recovery_target_lsn"] = target.TargetLSN
+	}
+
+	return options
+}
+
+// ReplicaSourceBackupCheck declares how the freshness of the backups of the
+// source of a replica cluster is checked
+type ReplicaSourceBackupCheck struct {
+	// The name of the source Cluster. Defaults to the name of the external
+	// cluster used as `source`
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// The namespace of the source Cluster. Defaults to the namespace of the
+	// replica cluster
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// The maximum age of the last successful backup of the source for it
+	// to be considered fresh. The age is expressed in the form of `XXu`
+	// where `XX` is a positive integer and `u` is in `[hdw]` - hours, days,
+	// weeks.
+	// +kubebuilder:validation:Pattern=^[1-9][0-9]*[hdw]$
+	MaxAge string `json:"maxAge"`
+}
+
+// GetClusterName gets the name of the source Cluster, given the name
+// of the external cluster used as source
+func (check *ReplicaSourceBackupCheck) GetClusterName(source string) string {
+	if check.ClusterName == "" {
+		return source
+	}
+	return check.ClusterName
+}
+
+// GetNamespace gets the namespace of the source Cluster, given the
+// namespace of the replica cluster
+func (check *ReplicaSourceBackupCheck) GetNamespace(localNamespace string) string {
+	if check.Namespace == "" {
+		return localNamespace
+	}
+	return check.Namespace
+}
+
+// GetMaxAge parses the maximum age of the last successful backup of the
+// source for it to be considered fresh
+func (check *ReplicaSourceBackupCheck) GetMaxAge() (time.Duration, error) {
+	maxAge, err := parseAge(check.MaxAge)
+	if err != nil {
+		return 0, fmt.Errorf("not a valid source backup max age: %s", check.MaxAge)
+	}
+	return maxAge, nil
+}
+
+// ReplicaLocalArchiveStatus is the status of the WAL archiving of the
+// designated primary of a replica cluster, as reported by `pg_stat_archiver`
+type ReplicaLocalArchiveStatus struct {
+	// Archiving tells whether the last WAL file was archived successfully,
+	// i.e. the last archived WAL file is more recent than the last failed one
+	Archiving bool `json:"archiving"`
+
+	// LastArchivedWAL is the name of the last WAL file archived
+	// +optional
+	LastArchivedWAL string `json:"lastArchivedWAL,omitempty"`
+
+	// LastArchivedWALTime is the time the last WAL file was archived
+	// +optional
+	LastArchivedWALTime string `json:"lastArchivedWALTime,omitempty"`
+
+	// LastFailedWAL is the name of the last WAL file whose archiving failed
+	// +optional
+	LastFailedWAL string `json:"lastFailedWAL,omitempty"`
+
+	// LastFailedWALTime is the time the archiving of the last failed WAL
+	// file failed
+	// +optional
+	LastFailedWALTime string `json:"lastFailedWALTime,omitempty"`
+}
+
+// ReplicaReseedStatus is the status of the automatic re-seed of a replica
+// cluster
+type ReplicaReseedStatus struct {
+	// StalledSince is the time since when the designated primary has been
+	// stalled at StalledLSN
+	// +optional
+	StalledSince string `json:"stalledSince,omitempty"`
+
+	// StalledLSN is the last LSN replayed by the stalled designated primary
+	// +optional
+	StalledLSN string `json:"stalledLSN,omitempty"`
+
+	// LastReseedTime is the time the last automatic re-seed was started
+	// +optional
+	LastReseedTime string `json:"lastReseedTime,omitempty"`
+
+	// InProgress tells whether the instances have been deleted, and the
+	// designated primary is being bootstrapped again. It is cleared once
+	// the new designated primary is ready
+	// +optional
+	InProgress bool `json:"inProgress,omitempty"`
+}
+
+// ReplicaSourceStatus is the status of the source of a replica cluster,
+// as probed by its designated primary
+type ReplicaSourceStatus struct {
+	// Reachable tells whether the designated primary could connect to the
+	// source and query its status
+	Reachable bool `json:"reachable"`
+
+	// IsPrimary tells whether the source is a primary, i.e. it is not in
+	// recovery
+	// +optional
+	IsPrimary bool `json:"isPrimary,omitempty"`
+
+	// CurrentLSN is the current WAL write location of the source when it is
+	// a primary, or its last replayed LSN otherwise
+	// +optional
+	CurrentLSN string `json:"currentLSN,omitempty"`
+
+	// WalLevel is the value of the `wal_level` parameter of the source
 	// +optional
-	Labels map[string]string `json:"labels,omitempty"`
+	WalLevel string `json:"walLevel,omitempty"`
 
+	// CaughtUp tells whether the designated primary has replayed the WAL
+	// up to the current LSN of the source
 	// +optional
-	Annotations map[string]string `json:"annotations,omitempty"`
-}
+	CaughtUp bool `json:"caughtUp"`
 
-// PoolerIntegrations encapsulates the needed integration for the poolers referencing the cluster
-type PoolerIntegrations struct {
+	// LagBytes is the amount of WAL, in bytes, between the current LSN of
+	// the source and the last LSN replayed by the designated primary
 	// +optional
-	PgBouncerIntegration PgBouncerIntegrationStatus `json:"pgBouncerIntegration,omitempty"`
-}
+	LagBytes int64 `json:"lagBytes,omitempty"`
 
-// PgBouncerIntegrationStatus encapsulates the needed integration for the pgbouncer poolers referencing the cluster
-type PgBouncerIntegrationStatus struct {
+	// Error is the error encountered while probing the source, if any
 	// +optional
-	Secrets []string `json:"secrets,omitempty"`
+	Error string `json:"error,omitempty"`
 }
 
-// ReplicaClusterConfiguration encapsulates the configuration of a replica
-// cluster
-type ReplicaClusterConfiguration struct {
-	// The name of the external cluster which is the replication origin
-	// +kubebuilder:validation:MinLength=1
-	Source string `json:"source"`
+// ReplicaTargetTimelineLatest means that the designated primary follows
+// the latest timeline of the source
+const ReplicaTargetTimelineLatest = "latest"
 
-	// If replica mode is enabled, this cluster will be a replica of an
-	// existing cluster. Replica cluster can be created from a recovery
-	// object store or via streaming through pg_basebackup.
-	// Refer to the Replica clusters page of the documentation for more information.
-	Enabled bool `json:"enabled"`
+// GetTargetTimeline gets the timeline of the source followed by the
+// designated primary, defaulting to ReplicaTargetTimelineLatest if empty
+func (replicaCluster *ReplicaClusterConfiguration) GetTargetTimeline() string {
+	if replicaCluster == nil || replicaCluster.TargetTimeline == "" {
+		return ReplicaTargetTimelineLatest
+	}
+	return replicaCluster.TargetTimeline
+}
+
+// GetSources gets the names of the external clusters the designated primary
+// can stream from, in order of preference: the source, followed by the
+// fallback sources
+func (replicaCluster *ReplicaClusterConfiguration) GetSources() []string {
+	if replicaCluster == nil {
+		return nil
+	}
+
+	sources := make([]string, 0, len(replicaCluster.FallbackSources)+1)
+	sources = append(sources, replicaCluster.Source)
+	return append(sources, replicaCluster.FallbackSources...)
+}
+
+// GetDetachedSlots gets what happens to the replication slots inherited
+// from the source when the replica cluster is detached from it, defaulting
+// to DetachedSlotsKeep if empty
+func (replicaCluster *ReplicaClusterConfiguration) GetDetachedSlots() DetachedSlotsPolicy {
+	if replicaCluster == nil || replicaCluster.DetachedSlots == "" {
+		return DetachedSlotsKeep
+	}
+	return replicaCluster.DetachedSlots
 }
 
+// DesignatedPrimaryFailoverPolicy defines how the operator reacts to the
+// failure of the designated primary of a replica cluster
+type DesignatedPrimaryFailoverPolicy string
+
+const (
+	// DesignatedPrimaryFailoverAutomatic means that the most advanced healthy
+	// replica is automatically promoted to designated primary
+	DesignatedPrimaryFailoverAutomatic DesignatedPrimaryFailoverPolicy = "automatic"
+
+	// DesignatedPrimaryFailoverManual means that the operator waits for
+	// the user to promote a replica to designated primary
+	DesignatedPrimaryFailoverManual DesignatedPrimaryFailoverPolicy = "manual"
+)
+
+// DetachedSlotsPolicy defines what happens to the replication slots
+// inherited from the source when a replica cluster is detached from it
+type DetachedSlotsPolicy string
+
+const (
+	// DetachedSlotsKeep means that the replication slots inherited from
+	// the source are kept
+	DetachedSlotsKeep DetachedSlotsPolicy = "keep"
+
+	// DetachedSlotsDrop means that the inactive replication slots inherited
+	// from the source are dropped
+	DetachedSlotsDrop DetachedSlotsPolicy = "drop"
+)
+
+// ChannelBindingMode is the SCRAM channel binding mode of the connection
+// of the designated primary to the source of a replica cluster
+type ChannelBindingMode string
+
+const (
+	// ChannelBindingPrefer means that channel binding is used when the
+	// source supports it
+	ChannelBindingPrefer ChannelBindingMode = "prefer"
+
+	// ChannelBindingRequire means that the connection to the source fails
+	// unless channel binding is used
+	ChannelBindingRequire ChannelBindingMode = "require"
+)
+
 // DefaultReplicationSlotsUpdateInterval is the default in seconds for the replication slots update interval
 const DefaultReplicationSlotsUpdateInterval = 30
 
@@ -878,6 +2080,47 @@ type ReplicationSlotsHAConfiguration struct {
 	// +kubebuilder:validation:Pattern=^[0-9a-z_]*$
 	// +optional
 	SlotPrefix string `json:"slotPrefix,omitempty"`
+
+	// The type of replication slots used by the standby instances:
+	// `permanent` (default) slots are managed by the operator on the primary
+	// and retain the WAL files needed by a disconnected standby, while
+	// `temporary` slots are created by each standby and dropped as soon as
+	// it disconnects, avoiding WAL retention at the risk of having to
+	// re-clone a standby which falls too much behind. Temporary slots
+	// require PostgreSQL 13 or above.
+	// +kubebuilder:validation:Enum=permanent;temporary
+	// +kubebuilder:default:=permanent
+	// +optional
+	SlotType ReplicationSlotType `json:"slotType,omitempty"`
+}
+
+// ReplicationSlotType is the type of the replication slots used by the standbys
+type ReplicationSlotType string
+
+const (
+	// ReplicationSlotTypePermanent means that the operator manages a permanent
+	// replication slot on the primary for every standby
+	ReplicationSlotTypePermanent = ReplicationSlotType("permanent")
+
+	// ReplicationSlotTypeTemporary means that every standby creates a temporary
+	// replication slot which is dropped when the streaming connection ends
+	ReplicationSlotTypeTemporary = ReplicationSlotType("temporary")
+)
+
+// GetSlotType returns the type of the HA replication slots, defaulting to permanent
+func (r *ReplicationSlotsHAConfiguration) GetSlotType() ReplicationSlotType {
+	if r == nil || r.SlotType == "" {
+		return ReplicationSlotTypePermanent
+	}
+	return r.SlotType
+}
+
+// UsesTemporarySlots returns true if the standbys are streaming
+// through temporary replication slots
+func (r *ReplicationSlotsConfiguration) UsesTemporarySlots() bool {
+	return r != nil &&
+		r.HighAvailability.GetEnabled() &&
+		r.HighAvailability.GetSlotType() == ReplicationSlotTypeTemporary
 }
 
 // GetSlotPrefix returns the HA slot prefix, defaulting to DefaultReplicationSlotsHASlotPrefix if empty
@@ -890,8 +2133,9 @@ func (r *ReplicationSlotsHAConfiguration) GetSlotPrefix() string {
 
 // GetSlotNameFromInstanceName returns the slot name, given the instance name.
 // It returns an empty string if High Availability Replication Slots are disabled
+// or temporary, as the name of a temporary slot is chosen by PostgreSQL
 func (r *ReplicationSlotsHAConfiguration) GetSlotNameFromInstanceName(instanceName string) string {
-	if r == nil || !r.GetEnabled() {
+	if r == nil || !r.GetEnabled() || r.GetSlotType() == ReplicationSlotTypeTemporary {
 		return ""
 	}
 
@@ -1363,6 +2607,15 @@ type BootstrapRecovery struct {
 	// created from scratch
 	// +optional
 	Secret *LocalObjectReference `json:"secret,omitempty"`
+
+	// List of SQL queries to be executed as a superuser in the application
+	// database once the restore from volume snapshots is completed, before
+	// the cluster is exposed (e.g. to scrub production data in a clone).
+	// The queries are executed in a single transaction: if any of them fails,
+	// the cluster is kept in the "post-restore failed" phase - to be used
+	// with extreme care (by default empty)
+	// +optional
+	PostRestoreApplicationSQL []string `json:"postRestoreApplicationSQL,omitempty"`
 }
 
 // DataSource contains the configuration required to bootstrap a
@@ -1721,6 +2974,124 @@ type BackupConfiguration struct {
 	// +kubebuilder:default:=prefer-standby
 	// +optional
 	Target BackupTarget `json:"target,omitempty"`
+
+	// BackupProtectionMaxAge enables the `BackupProtected` condition of the
+	// cluster, which is true only when the last successful backup is more
+	// recent than the given age (i.e. '24h'). The age is expressed in the form
+	// of `XXu` where `XX` is a positive integer and `u` is in `[hdw]` -
+	// hours, days, weeks.
+	// +kubebuilder:validation:Pattern=^[1-9][0-9]*[hdw]$
+	// +optional
+	BackupProtectionMaxAge string `json:"backupProtectionMaxAge,omitempty"`
+
+	// CoalesceWindow is the time in seconds within which a backup requested
+	// with the same method as a previous backup of the cluster is considered
+	// a duplicate of it, and is not executed. Disabled when zero
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	CoalesceWindow int32 `json:"coalesceWindow,omitempty"`
+
+	// MaxParallelBackups is the maximum number of backups of the cluster
+	// which can run at the same time. When set, the backups are started in
+	// the order they have been created, and volume snapshot backups, which
+	// may fence the target instance, never run together with any other
+	// backup. No limit is applied when zero
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxParallelBackups int32 `json:"maxParallelBackups,omitempty"`
+
+	// Notification is the configuration of the webhook notified when
+	// a backup of the cluster is completed or failed
+	// +optional
+	Notification *BackupNotificationConfiguration `json:"notification,omitempty"`
+
+	// HistoryLimit is the number of the most recent backup attempts of the
+	// cluster, either completed or failed, recorded in the `backupHistory`
+	// field of the cluster status. Disabled when zero
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	HistoryLimit int32 `json:"historyLimit,omitempty"`
+
+	// CompletedEvent enables the `BackupCompleted` event, recorded on each
+	// completed backup of the cluster with a JSON summary of the backup as
+	// its message, for the automation reacting to the Kubernetes events
+	// +optional
+	CompletedEvent bool `json:"completedEvent,omitempty"`
+}
+
+// BackupAttempt is a terminated backup attempt, recorded in the backup
+// history of the cluster
+type BackupAttempt struct {
+	// The name of the backup
+	Name string `json:"name"`
+
+	// The method of the backup
+	// +optional
+	Method BackupMethod `json:"method,omitempty"`
+
+	// The phase the backup terminated with, either `completed` or `failed`
+	Phase BackupPhase `json:"phase"`
+
+	// The time the backup started, in RFC3339 format
+	// +optional
+	StartedAt string `json:"startedAt,omitempty"`
+
+	// The time the backup terminated, in RFC3339 format
+	// +optional
+	StoppedAt string `json:"stoppedAt,omitempty"`
+
+	// The duration of the backup
+	// +optional
+	Duration string `json:"duration,omitempty"`
+
+	// The error which made the backup fail
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// DefaultBackupNotificationTimeout is the default in seconds for the
+// timeout of a single backup notification request
+const DefaultBackupNotificationTimeout = 10
+
+// MaxBackupNotificationTimeout is the maximum in seconds for the timeout
+// of a single backup notification request, which is made while reconciling
+// the backup
+const MaxBackupNotificationTimeout = 30
+
+// BackupNotificationConfiguration is the configuration of the webhook
+// receiving the outcome of the backups of a cluster
+type BackupNotificationConfiguration struct {
+	// The URL where a JSON payload describing the backup is POSTed when
+	// the backup is completed or failed
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+
+	// The secret containing the key used to sign the payload. When set,
+	// the HMAC-SHA256 of the payload is sent in the
+	// `X-CNPG-Signature` header, in the form `sha256=<hex digest>`
+	// +optional
+	SigningSecret *SecretKeySelector `json:"signingSecret,omitempty"`
+
+	// The timeout in seconds of each notification attempt, defaults to 10
+	// +kubebuilder:default:=10
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=30
+	// +optional
+	Timeout int32 `json:"timeout,omitempty"`
+}
+
+// GetTimeout returns the timeout of a single notification attempt,
+// defaulting to DefaultBackupNotificationTimeout if empty and bounded
+// by MaxBackupNotificationTimeout
+func (configuration *BackupNotificationConfiguration) GetTimeout() time.Duration {
+	switch {
+	case configuration.Timeout <= 0:
+		return DefaultBackupNotificationTimeout * time.Second
+	case configuration.Timeout > MaxBackupNotificationTimeout:
+		return MaxBackupNotificationTimeout * time.Second
+	}
+	return time.Duration(configuration.Timeout) * time.Second
 }
 
 // WalBackupConfiguration is the configuration of the backup of the
@@ -1916,11 +3287,28 @@ type ExternalCluster struct {
 	// +optional
 	Password *corev1.SecretKeySelector `json:"password,omitempty"`
 
+	// The namespace of the secrets referenced by `sslCert`, `sslKey`,
+	// `sslRootCert` and `password`, defaulting to the namespace of the
+	// cluster. The service account of the cluster must be granted the
+	// permission to read them through a Role and a RoleBinding in that
+	// namespace, which are not created by the operator
+	// +optional
+	SecretsNamespace string `json:"secretsNamespace,omitempty"`
+
 	// The configuration for the barman-cloud tool suite
 	// +optional
 	BarmanObjectStore *BarmanObjectStoreConfiguration `json:"barmanObjectStore,omitempty"`
 }
 
+// GetSecretsNamespace returns the namespace of the secrets needed to
+// connect to the external cluster, given the namespace of the cluster
+func (in ExternalCluster) GetSecretsNamespace(clusterNamespace string) string {
+	if in.SecretsNamespace != "" {
+		return in.SecretsNamespace
+	}
+	return clusterNamespace
+}
+
 // GetServerName returns the server name, defaulting to the name of the external cluster or using the one specified
 // in the BarmanObjectStore
 func (in ExternalCluster) GetServerName() string {
@@ -2391,6 +3779,15 @@ func (cluster *Cluster) GetMaxStartDelay() int32 {
 	return DefaultStartupDelay
 }
 
+// GetStandbySignalCheckInterval gets the interval at which the presence of
+// the standby.signal file is verified, zero if the check is disabled
+func (cluster *Cluster) GetStandbySignalCheckInterval() time.Duration {
+	if cluster.Spec.StandbySignalCheckInterval > 0 {
+		return time.Duration(cluster.Spec.StandbySignalCheckInterval) * time.Second
+	}
+	return 0
+}
+
 // GetMaxStopDelay get the amount of time PostgreSQL has to stop
 func (cluster *Cluster) GetMaxStopDelay() int32 {
 	if cluster.Spec.MaxStopDelay > 0 {
@@ -2630,11 +4027,68 @@ func (cluster Cluster) ExternalCluster(name string) (ExternalCluster, bool) {
 	return ExternalCluster{}, false
 }
 
+// IsLocalArchiveSharedWithSource checks whether the WAL archive of this
+// replica cluster is the one of its source, i.e. they use the same
+// destination path and server name in the object store
+func (cluster Cluster) IsLocalArchiveSharedWithSource() bool {
+	if cluster.Spec.ReplicaCluster == nil || cluster.Spec.Backup == nil ||
+		cluster.Spec.Backup.BarmanObjectStore == nil {
+		return false
+	}
+
+	source, found := cluster.ExternalCluster(cluster.Spec.ReplicaCluster.Source)
+	if !found || source.BarmanObjectStore == nil {
+		return false
+	}
+
+	localStore := cluster.Spec.Backup.BarmanObjectStore
+	localServerName := localStore.ServerName
+	if localServerName == "" {
+		localServerName = cluster.Name
+	}
+	sourceServerName := source.BarmanObjectStore.ServerName
+	if sourceServerName == "" {
+		sourceServerName = source.Name
+	}
+
+	return strings.TrimSuffix(localStore.DestinationPath, "/") ==
+		strings.TrimSuffix(source.BarmanObjectStore.DestinationPath, "/") &&
+		localServerName == sourceServerName
+}
+
 // IsReplica checks if this is a replica cluster or not
 func (cluster Cluster) IsReplica() bool {
 	return cluster.Spec.ReplicaCluster != nil && cluster.Spec.ReplicaCluster.Enabled
 }
 
+// IsDesignatedPrimaryFailoverManual checks if the designated primary of this
+// replica cluster needs to be replaced by the user when it fails
+func (cluster Cluster) IsDesignatedPrimaryFailoverManual() bool {
+	return cluster.IsReplica() &&
+		cluster.Spec.ReplicaCluster.DesignatedPrimaryFailover == DesignatedPrimaryFailoverManual
+}
+
+// GetPendingReplicaRecoveryTarget gets the recovery target the designated
+// primary of this replica cluster is pinned to, or nil if there is none
+// or it has already been reached
+func (cluster Cluster) GetPendingReplicaRecoveryTarget() *ReplicaRecoveryTarget {
+	if !cluster.IsReplica() || cluster.Spec.ReplicaCluster.RecoveryTarget == nil {
+		return nil
+	}
+
+	if meta.IsStatusConditionTrue(cluster.Status.Conditions, string(ConditionReplicaRecoveryTargetReached)) {
+		return nil
+	}
+
+	return cluster.Spec.ReplicaCluster.RecoveryTarget
+}
+
+// IsReplicaStreamingPausable checks if the designated primary of this replica
+// cluster is allowed to pause streaming while the source is not reachable
+func (cluster Cluster) IsReplicaStreamingPausable() bool {
+	return cluster.IsReplica() && cluster.Spec.ReplicaCluster.PauseStreamingOnSourceMaintenance
+}
+
 var slotNameNegativeRegex = regexp.MustCompile("[^a-z0-9_]+")
 
 // GetSlotNameFromInstanceName returns the slot name, given the instance name.
@@ -2649,6 +4103,17 @@ func (cluster Cluster) GetSlotNameFromInstanceName(instanceName string) string {
 	return cluster.Spec.ReplicationSlots.HighAvailability.GetSlotNameFromInstanceName(instanceName)
 }
 
+// GetReplicaSourceSlotName returns the name of the replication slot used by
+// the designated primary of a replica cluster to stream from the source:
+// the configured one, or the one derived from the name of the instance
+func (cluster Cluster) GetReplicaSourceSlotName(instanceName string) string {
+	if cluster.Spec.ReplicaCluster != nil && cluster.Spec.ReplicaCluster.SourceSlotName != "" {
+		return cluster.Spec.ReplicaCluster.SourceSlotName
+	}
+
+	return cluster.GetSlotNameFromInstanceName(instanceName)
+}
+
 // GetBarmanEndpointCAForReplicaCluster checks if this is a replica cluster which needs barman endpoint CA
 func (cluster Cluster) GetBarmanEndpointCAForReplicaCluster() *SecretKeySelector {
 	if !cluster.IsReplica() {
@@ -2871,6 +4336,344 @@ func (backupConfiguration *BackupConfiguration) IsBarmanBackupConfigured() bool
 		backupConfiguration.BarmanObjectStore.BarmanCredentials.ArePopulated()
 }
 
+// GetBackupProtectionMaxAge parses the maximum age of the last successful
+// backup for the cluster to be considered protected by backups. It returns
+// zero if the backup protection check is not enabled
+func (backupConfiguration *BackupConfiguration) GetBackupProtectionMaxAge() (time.Duration, error) {
+	if backupConfiguration == nil || backupConfiguration.BackupProtectionMaxAge == "" {
+		return 0, nil
+	}
+
+	maxAge, err := parseAge(backupConfiguration.BackupProtectionMaxAge)
+	if err != nil {
+		return 0, fmt.Errorf("not a valid backup protection max age: %s",
+			backupConfiguration.BackupProtectionMaxAge)
+	}
+
+	return maxAge, nil
+}
+
+// GetCoalesceWindow returns the time within which a backup is
+// considered a duplicate of a previous one. It returns zero if
+// duplicate backups are not coalesced
+func (backupConfiguration *BackupConfiguration) GetCoalesceWindow() time.Duration {
+	if backupConfiguration == nil {
+		return 0
+	}
+
+	return time.Duration(backupConfiguration.CoalesceWindow) * time.Second
+}
+
+// GetMaxAge parses the maximum age of the volume snapshots to be retained.
+// It returns zero if the snapshots are not retained by age
+func (retention *VolumeSnapshotRetention) GetMaxAge() (time.Duration, error) {
+	if retention == nil || retention.MaxAge == "" {
+		return 0, nil
+	}
+
+	maxAge, err := parseAge(retention.MaxAge)
+	if err != nil {
+		return 0, fmt.Errorf("not a valid volume snapshot retention max age: %s", retention.MaxAge)
+	}
+
+	return maxAge, nil
+}
+
+// HasTimeBuckets tells whether the retention keeps the last snapshot of
+// some time buckets, i.e. of the days or of the weeks
+func (retention *VolumeSnapshotRetention) HasTimeBuckets() bool {
+	return retention != nil &&
+		(retention.KeepDaily > 0 || retention.KeepWeekly > 0 ||
+			retention.KeepMonthly > 0 || retention.KeepYearly > 0)
+}
+
+// IsFencingRequired tells whether the instance needs to be fenced while
+// snapshotting the PersistentVolumeClaims having the passed role.
+// Fencing is required unless explicitly declared otherwise
+func (configuration *VolumeSnapshotConfiguration) IsFencingRequired(role string) bool {
+	if configuration == nil {
+		return true
+	}
+
+	for _, requirement := range configuration.FencingRequirements {
+		if requirement.Role == role {
+			return requirement.FencingRequired
+		}
+	}
+
+	return true
+}
+
+// GetDeletionPolicyDriftAction returns the action taken when the deletion
+// policy of a VolumeSnapshotContent drifts, defaulting to
+// DeletionPolicyDriftActionCorrect if empty
+func (configuration *VolumeSnapshotConfiguration) GetDeletionPolicyDriftAction() DeletionPolicyDriftAction {
+	if configuration == nil || configuration.DeletionPolicyDriftAction == "" {
+		return DeletionPolicyDriftActionCorrect
+	}
+	return configuration.DeletionPolicyDriftAction
+}
+
+// IsDriverHealthCheckEnabled returns true if the health of the CSI drivers
+// should be checked before starting a volume snapshot backup
+func (configuration *VolumeSnapshotConfiguration) IsDriverHealthCheckEnabled() bool {
+	return configuration == nil || !configuration.SkipDriverHealthCheck
+}
+
+// AreFencedInstancesTolerated returns true if the target instance of a
+// volume snapshot backup can be fenced while other instances are fenced
+func (configuration *VolumeSnapshotConfiguration) AreFencedInstancesTolerated() bool {
+	return configuration != nil && configuration.TolerateFencedInstances
+}
+
+// GetInterval returns the initial interval between the checks of the
+// operations a backup is waiting for, defaulting to 10 seconds
+func (backoff *VolumeSnapshotRequeueBackoff) GetInterval() time.Duration {
+	if backoff == nil || backoff.Interval <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(backoff.Interval) * time.Second
+}
+
+// GetMaxInterval returns the longest interval between the checks of the
+// operations a backup is waiting for, defaulting to one minute
+func (backoff *VolumeSnapshotRequeueBackoff) GetMaxInterval() time.Duration {
+	if backoff == nil || backoff.MaxInterval <= 0 {
+		return time.Minute
+	}
+	return time.Duration(backoff.MaxInterval) * time.Second
+}
+
+// GetTimeout returns the timeout of the pre-flight check, defaulting
+// to 10 seconds
+func (check *VolumeSnapshotPreflightCheck) GetTimeout() time.Duration {
+	if check == nil || check.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(check.Timeout) * time.Second
+}
+
+// GetStalledSnapshotThreshold returns the time after which a
+// VolumeSnapshot without a status is considered stalled, defaulting
+// to 60 seconds
+func (check *VolumeSnapshotPreflightCheck) GetStalledSnapshotThreshold() time.Duration {
+	if check == nil || check.StalledSnapshotThreshold <= 0 {
+		return 60 * time.Second
+	}
+	return time.Duration(check.StalledSnapshotThreshold) * time.Second
+}
+
+// GetErrorPatterns returns the patterns recognizing the transient errors
+// in the creation of a volume snapshot, defaulting to the ones reporting
+// rate limiting and throttling
+func (retry *VolumeSnapshotCreationRetry) GetErrorPatterns() []string {
+	if retry == nil || len(retry.ErrorPatterns) == 0 {
+		return DefaultSnapshotCreationRetryErrorPatterns
+	}
+	return retry.ErrorPatterns
+}
+
+// CompileErrorPatterns compiles the patterns recognizing the transient
+// errors in the creation of a volume snapshot, to be matched
+// case-insensitively
+func (retry *VolumeSnapshotCreationRetry) CompileErrorPatterns() ([]*regexp.Regexp, error) {
+	patterns := retry.GetErrorPatterns()
+	result := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		compiled, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid error pattern %q: %w", pattern, err)
+		}
+		result = append(result, compiled)
+	}
+	return result, nil
+}
+
+// GetMaxAttempts returns the maximum number of attempts to create a volume
+// snapshot, defaulting to 5
+func (retry *VolumeSnapshotCreationRetry) GetMaxAttempts() int {
+	if retry == nil || retry.MaxAttempts <= 0 {
+		return 5
+	}
+	return int(retry.MaxAttempts)
+}
+
+// GetBackoff returns the time to wait before the first retry of the
+// creation of a volume snapshot, defaulting to 1 second
+func (retry *VolumeSnapshotCreationRetry) GetBackoff() time.Duration {
+	if retry == nil || retry.Backoff <= 0 {
+		return time.Second
+	}
+	return time.Duration(retry.Backoff) * time.Second
+}
+
+// GetTimeout returns the maximum time a backup waits for the WAL file
+// needed by its snapshots to be archived, zero if not limited
+func (verification *VolumeSnapshotArchiveVerification) GetTimeout() time.Duration {
+	if verification == nil || verification.Timeout <= 0 {
+		return 0
+	}
+	return time.Duration(verification.Timeout) * time.Second
+}
+
+// GetNamespace gets the namespace of the remote replica cluster, given
+// the namespace of the local one
+func (target *VolumeSnapshotRemoteTarget) GetNamespace(localNamespace string) string {
+	if target.Namespace == "" {
+		return localNamespace
+	}
+	return target.Namespace
+}
+
+// GetReadyTimeout returns the maximum time a volume snapshot can take to
+// be ready to use, zero if not configured
+func (configuration *VolumeSnapshotConfiguration) GetReadyTimeout() time.Duration {
+	if configuration == nil || configuration.ReadyTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(configuration.ReadyTimeout) * time.Second
+}
+
+// GetReuseWindow returns the maximum age of a snapshot which can be reused
+// by a new backup, zero if the snapshots are never reused
+func (configuration *VolumeSnapshotConfiguration) GetReuseWindow() time.Duration {
+	if configuration == nil || configuration.ReuseWindow <= 0 {
+		return 0
+	}
+	return time.Duration(configuration.ReuseWindow) * time.Second
+}
+
+// GetMaxFenceDuration returns the maximum time the target instance of a
+// backup can stay fenced, zero if not configured
+func (configuration *VolumeSnapshotConfiguration) GetMaxFenceDuration() time.Duration {
+	if configuration == nil || configuration.MaxFenceDuration <= 0 {
+		return 0
+	}
+	return time.Duration(configuration.MaxFenceDuration) * time.Second
+}
+
+// GetMaxRestorableAge returns the age beyond which a volume snapshot backup
+// is not considered restorable, zero if not configured
+func (configuration *VolumeSnapshotConfiguration) GetMaxRestorableAge() (time.Duration, error) {
+	if configuration == nil || configuration.MaxRestorableAge == "" {
+		return 0, nil
+	}
+
+	maxAge, err := parseAge(configuration.MaxRestorableAge)
+	if err != nil {
+		return 0, fmt.Errorf("not a valid volume snapshot max restorable age: %s",
+			configuration.MaxRestorableAge)
+	}
+
+	return maxAge, nil
+}
+
+// GetWaitForArchive tells whether the end of an online backup waits for the
+// required WAL files to be archived
+func (configuration *OnlineConfiguration) GetWaitForArchive() bool {
+	if configuration == nil || configuration.WaitForArchive == nil {
+		return true
+	}
+
+	return *configuration.WaitForArchive
+}
+
+// GetImmediateCheckpoint tells whether the start of an online backup
+// requests an immediate checkpoint
+func (configuration *OnlineConfiguration) GetImmediateCheckpoint() bool {
+	return configuration != nil && configuration.ImmediateCheckpoint
+}
+
+// GetSnapshotOwnerReference gets the owner reference of the volume snapshots
+// of the cluster, falling back to the default of the operator, and to
+// `none` when the operator doesn't set one
+func (cluster *Cluster) GetSnapshotOwnerReference() SnapshotOwnerReference {
+	if cluster.Spec.Backup != nil && cluster.Spec.Backup.VolumeSnapshot != nil &&
+		cluster.Spec.Backup.VolumeSnapshot.SnapshotOwnerReference != "" {
+		return cluster.Spec.Backup.VolumeSnapshot.SnapshotOwnerReference
+	}
+
+	if ownerReference := SnapshotOwnerReference(configuration.Current.SnapshotOwnerReference); ownerReference != "" {
+		return ownerReference
+	}
+
+	return ShapshotOwnerReferenceNone
+}
+
+// GetDeadline returns the maximum time to wait for a quiet period,
+// defaulting to DefaultQuietPeriodDeadline if empty
+func (quietPeriod *VolumeSnapshotQuietPeriod) GetDeadline() time.Duration {
+	if quietPeriod.Deadline <= 0 {
+		return DefaultQuietPeriodDeadline * time.Second
+	}
+	return time.Duration(quietPeriod.Deadline) * time.Second
+}
+
+// GetStalledTimeout returns the time the designated primary needs to be
+// stalled before re-seeding the replica cluster, defaulting to
+// DefaultReplicaReseedStalledTimeout if empty
+func (reseed *ReplicaReseedConfiguration) GetStalledTimeout() time.Duration {
+	if reseed.StalledTimeout <= 0 {
+		return DefaultReplicaReseedStalledTimeout * time.Second
+	}
+	return time.Duration(reseed.StalledTimeout) * time.Second
+}
+
+// GetMinInterval returns the minimum time between two automatic re-seeds,
+// defaulting to DefaultReplicaReseedMinInterval if empty and never lower
+// than MinReplicaReseedMinInterval
+func (reseed *ReplicaReseedConfiguration) GetMinInterval() time.Duration {
+	switch {
+	case reseed.MinInterval <= 0:
+		return DefaultReplicaReseedMinInterval * time.Second
+	case reseed.MinInterval < MinReplicaReseedMinInterval:
+		return MinReplicaReseedMinInterval * time.Second
+	}
+	return time.Duration(reseed.MinInterval) * time.Second
+}
+
+// GetPolicy returns the action taken when there are not enough healthy
+// standbys, defaulting to MinHealthyStandbysPolicyWait if empty
+func (minHealthyStandbys *VolumeSnapshotMinHealthyStandbys) GetPolicy() MinHealthyStandbysPolicy {
+	if minHealthyStandbys.Policy == "" {
+		return MinHealthyStandbysPolicyWait
+	}
+	return minHealthyStandbys.Policy
+}
+
+// GetPolicy returns the action taken when the target standby is lagging
+// too much, defaulting to StandbyLagPolicyFail if empty
+func (maxStandbyLag *VolumeSnapshotMaxStandbyLag) GetPolicy() StandbyLagPolicy {
+	if maxStandbyLag.Policy == "" {
+		return StandbyLagPolicyFail
+	}
+	return maxStandbyLag.Policy
+}
+
+// parseAge parses an age expressed in the form of `XXu` where `XX` is a
+// positive integer and `u` is in `[hdw]` - hours, days, weeks
+func parseAge(age string) (time.Duration, error) {
+	if len(age) < 2 {
+		return 0, fmt.Errorf("not a valid age: %s", age)
+	}
+
+	value, err := strconv.Atoi(age[:len(age)-1])
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("not a valid age: %s", age)
+	}
+
+	switch age[len(age)-1] {
+	case 'h':
+		return time.Duration(value) * time.Hour, nil
+	case 'd':
+		return time.Duration(value) * 24 * time.Hour, nil
+	case 'w':
+		return time.Duration(value) * 7 * 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("not a valid age: %s", age)
+	}
+}
+
 // IsBarmanEndpointCASet returns true if we have a CA bundle for the endpoint
 // false otherwise
 func (backupConfiguration *BackupConfiguration) IsBarmanEndpointCASet() bool {
@@ -17,6 +17,8 @@ limitations under the License.
 package v1
 
 import (
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
@@ -374,6 +376,12 @@ var _ = Describe("external cluster list", func() {
 		Expect(ok2).To(BeTrue())
 		Expect(server2.GetServerName()).To(BeEquivalentTo("testServer2"), "default server name")
 	})
+	It("reads the secrets from the namespace of the cluster by default", func() {
+		server := ExternalCluster{Name: "testServer"}
+		Expect(server.GetSecretsNamespace("default")).To(Equal("default"))
+		server.SecretsNamespace = "source"
+		Expect(server.GetSecretsNamespace("default")).To(Equal("source"))
+	})
 })
 
 var _ = Describe("look up for secrets", func() {
@@ -844,6 +852,33 @@ var _ = Describe("Replication slots names for instances", func() {
 		Expect(cluster.GetSlotNameFromInstanceName("cluster-example-1")).To(Equal(
 			"_232_test_cluster_example_1"))
 	})
+
+	It("returns an empty name when the standbys use temporary slots", func() {
+		cluster := Cluster{
+			Spec: ClusterSpec{
+				ReplicationSlots: &ReplicationSlotsConfiguration{
+					HighAvailability: &ReplicationSlotsHAConfiguration{
+						Enabled:  ptr.To(true),
+						SlotType: ReplicationSlotTypeTemporary,
+					},
+				},
+			},
+		}
+		Expect(cluster.Spec.ReplicationSlots.UsesTemporarySlots()).To(BeTrue())
+		Expect(cluster.GetSlotNameFromInstanceName("cluster-example-1")).To(BeEmpty())
+	})
+
+	It("defaults to permanent slots", func() {
+		var haConfig *ReplicationSlotsHAConfiguration
+		Expect(haConfig.GetSlotType()).To(Equal(ReplicationSlotTypePermanent))
+
+		config := &ReplicationSlotsConfiguration{
+			HighAvailability: &ReplicationSlotsHAConfiguration{
+				Enabled: ptr.To(true),
+			},
+		}
+		Expect(config.UsesTemporarySlots()).To(BeFalse())
+	})
 })
 
 var _ = Describe("Managed Roles", func() {
@@ -911,3 +946,305 @@ var _ = Describe("SeccompProfile usages", func() {
 		Expect(returnedProfile.LocalhostProfile).To(BeEquivalentTo(&profilePath))
 	})
 })
+
+var _ = Describe("Backup protection maximum age", func() {
+	It("is zero when the backup protection is not enabled", func() {
+		var backupConfiguration *BackupConfiguration
+		Expect(backupConfiguration.GetBackupProtectionMaxAge()).To(BeZero())
+		Expect((&BackupConfiguration{}).GetBackupProtectionMaxAge()).To(BeZero())
+	})
+
+	It("parses hours, days and weeks", func() {
+		Expect((&BackupConfiguration{BackupProtectionMaxAge: "12h"}).GetBackupProtectionMaxAge()).
+			To(Equal(12 * time.Hour))
+		Expect((&BackupConfiguration{BackupProtectionMaxAge: "2d"}).GetBackupProtectionMaxAge()).
+			To(Equal(48 * time.Hour))
+		Expect((&BackupConfiguration{BackupProtectionMaxAge: "1w"}).GetBackupProtectionMaxAge()).
+			To(Equal(7 * 24 * time.Hour))
+	})
+
+	It("refuses invalid values", func() {
+		for _, value := range []string{"h", "0d", "12", "3m", "-1w"} {
+			_, err := (&BackupConfiguration{BackupProtectionMaxAge: value}).GetBackupProtectionMaxAge()
+			Expect(err).To(HaveOccurred(), value)
+		}
+	})
+})
+
+var _ = Describe("Volume snapshot retention maximum age", func() {
+	It("is zero when the snapshots are not retained by age", func() {
+		var retention *VolumeSnapshotRetention
+		Expect(retention.GetMaxAge()).To(BeZero())
+		Expect((&VolumeSnapshotRetention{MaxCount: 3}).GetMaxAge()).To(BeZero())
+	})
+
+	It("parses hours, days and weeks", func() {
+		Expect((&VolumeSnapshotRetention{MaxAge: "6h"}).GetMaxAge()).To(Equal(6 * time.Hour))
+		Expect((&VolumeSnapshotRetention{MaxAge: "7d"}).GetMaxAge()).To(Equal(7 * 24 * time.Hour))
+		Expect((&VolumeSnapshotRetention{MaxAge: "2w"}).GetMaxAge()).To(Equal(14 * 24 * time.Hour))
+	})
+
+	It("refuses invalid values", func() {
+		for _, value := range []string{"d", "0h", "7", "1m"} {
+			_, err := (&VolumeSnapshotRetention{MaxAge: value}).GetMaxAge()
+			Expect(err).To(HaveOccurred(), value)
+		}
+	})
+})
+
+var _ = Describe("Volume snapshot fencing requirements", func() {
+	It("requires fencing by default", func() {
+		var configuration *VolumeSnapshotConfiguration
+		Expect(configuration.IsFencingRequired("PG_DATA")).To(BeTrue())
+		Expect((&VolumeSnapshotConfiguration{}).IsFencingRequired("PG_WAL")).To(BeTrue())
+	})
+
+	It("honors the declared requirements", func() {
+		configuration := &VolumeSnapshotConfiguration{
+			FencingRequirements: []VolumeSnapshotFencingRequirement{
+				{Role: "PG_WAL", FencingRequired: false},
+			},
+		}
+		Expect(configuration.IsFencingRequired("PG_DATA")).To(BeTrue())
+		Expect(configuration.IsFencingRequired("PG_WAL")).To(BeFalse())
+	})
+})
+
+var _ = Describe("Volume snapshot driver health check", func() {
+	It("is enabled by default", func() {
+		var configuration *VolumeSnapshotConfiguration
+		Expect(configuration.IsDriverHealthCheckEnabled()).To(BeTrue())
+		Expect((&VolumeSnapshotConfiguration{}).IsDriverHealthCheckEnabled()).To(BeTrue())
+	})
+
+	It("can be skipped", func() {
+		configuration := &VolumeSnapshotConfiguration{SkipDriverHealthCheck: true}
+		Expect(configuration.IsDriverHealthCheckEnabled()).To(BeFalse())
+	})
+})
+
+var _ = Describe("Online volume snapshot configuration", func() {
+	It("waits for the WAL archive by default", func() {
+		var configuration *OnlineConfiguration
+		Expect(configuration.GetWaitForArchive()).To(BeTrue())
+		Expect(configuration.GetImmediateCheckpoint()).To(BeFalse())
+		Expect((&OnlineConfiguration{}).GetWaitForArchive()).To(BeTrue())
+	})
+
+	It("honors the configured values", func() {
+		configuration := &OnlineConfiguration{
+			WaitForArchive:      ptr.To(false),
+			ImmediateCheckpoint: true,
+		}
+		Expect(configuration.GetWaitForArchive()).To(BeFalse())
+		Expect(configuration.GetImmediateCheckpoint()).To(BeTrue())
+	})
+})
+
+var _ = Describe("Replica cluster recovery target", func() {
+	It("is pending until the designated primary reaches it", func() {
+		target := &ReplicaRecoveryTarget{TargetLSN: "0/6000028"}
+		cluster := Cluster{
+			Spec: ClusterSpec{
+				ReplicaCluster: &ReplicaClusterConfiguration{
+					Enabled:        true,
+					Source:         "source",
+					RecoveryTarget: target,
+				},
+			},
+		}
+		Expect(cluster.GetPendingReplicaRecoveryTarget()).To(Equal(target))
+
+		cluster.Status.Conditions = []v1.Condition{{
+			Type:   string(ConditionReplicaRecoveryTargetReached),
+			Status: v1.ConditionTrue,
+		}}
+		Expect(cluster.GetPendingReplicaRecoveryTarget()).To(BeNil())
+	})
+
+	It("doesn't apply outside of the replica clusters", func() {
+		cluster := Cluster{
+			Spec: ClusterSpec{
+				ReplicaCluster: &ReplicaClusterConfiguration{
+					Source:         "source",
+					RecoveryTarget: &ReplicaRecoveryTarget{TargetLSN: "0/6000028"},
+				},
+			},
+		}
+		Expect(cluster.GetPendingReplicaRecoveryTarget()).To(BeNil())
+	})
+
+	It("pauses the replay at the target", func() {
+		target := &ReplicaRecoveryTarget{TargetTime: "2023-10-01T12:00:00Z"}
+		Expect(target.BuildPostgresOptions()).To(Equal(map[string]string{
+			"recovery_target_action": "pause",
+			"recovery_target_time":   "2023-10-01 12:00:00.000000Z",
+		}))
+	})
+})
+
+var _ = Describe("Replica cluster local archive", func() {
+	newCluster := func(localStore, sourceStore *BarmanObjectStoreConfiguration) Cluster {
+		return Cluster{
+			ObjectMeta: v1.ObjectMeta{Name: "replica"},
+			Spec: ClusterSpec{
+				ReplicaCluster: &ReplicaClusterConfiguration{
+					Enabled:      true,
+					Source:       "source",
+					LocalArchive: true,
+				},
+				Backup: &BackupConfiguration{BarmanObjectStore: localStore},
+				ExternalClusters: []ExternalCluster{
+					{Name: "source", BarmanObjectStore: sourceStore},
+				},
+			},
+		}
+	}
+
+	It("detects an object store shared with the source", func() {
+		cluster := newCluster(
+			&BarmanObjectStoreConfiguration{DestinationPath: "s3://bucket/", ServerName: "source"},
+			&BarmanObjectStoreConfiguration{DestinationPath: "s3://bucket"},
+		)
+		Expect(cluster.IsLocalArchiveSharedWithSource()).To(BeTrue())
+	})
+
+	It("distinguishes the server names in the same bucket", func() {
+		cluster := newCluster(
+			&BarmanObjectStoreConfiguration{DestinationPath: "s3://bucket"},
+			&BarmanObjectStoreConfiguration{DestinationPath: "s3://bucket"},
+		)
+		Expect(cluster.IsLocalArchiveSharedWithSource()).To(BeFalse())
+	})
+
+	It("distinguishes the destination paths", func() {
+		cluster := newCluster(
+			&BarmanObjectStoreConfiguration{DestinationPath: "s3://replica", ServerName: "source"},
+			&BarmanObjectStoreConfiguration{DestinationPath: "s3://bucket"},
+		)
+		Expect(cluster.IsLocalArchiveSharedWithSource()).To(BeFalse())
+	})
+
+	It("is not shared when the source has no object store", func() {
+		cluster := newCluster(&BarmanObjectStoreConfiguration{DestinationPath: "s3://bucket"}, nil)
+		Expect(cluster.IsLocalArchiveSharedWithSource()).To(BeFalse())
+	})
+})
+
+var _ = Describe("Replica cluster source slot name", func() {
+	newCluster := func(sourceSlotName string) Cluster {
+		return Cluster{
+			Spec: ClusterSpec{
+				ReplicaCluster: &ReplicaClusterConfiguration{
+					Enabled:        true,
+					Source:         "source",
+					SourceSlotName: sourceSlotName,
+				},
+				ReplicationSlots: &ReplicationSlotsConfiguration{
+					HighAvailability: &ReplicationSlotsHAConfiguration{
+						Enabled:    ptr.To(true),
+						SlotPrefix: "_cnpg_",
+					},
+				},
+			},
+		}
+	}
+
+	It("uses the configured slot name", func() {
+		cluster := newCluster("replica_east")
+		Expect(cluster.GetReplicaSourceSlotName("cluster-example-1")).To(Equal("replica_east"))
+	})
+
+	It("derives the slot name from the instance name by default", func() {
+		cluster := newCluster("")
+		Expect(cluster.GetReplicaSourceSlotName("cluster-example-1")).To(Equal("_cnpg_cluster_example_1"))
+	})
+
+	It("uses the configured slot name even without HA replication slots", func() {
+		cluster := newCluster("replica_east")
+		cluster.Spec.ReplicationSlots = nil
+		Expect(cluster.GetReplicaSourceSlotName("cluster-example-1")).To(Equal("replica_east"))
+	})
+})
+
+var _ = Describe("backup history", func() {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	newBackup := func(name string, stoppedAgo time.Duration, phase BackupPhase) *Backup {
+		startedAt := v1.NewTime(now.Add(-stoppedAgo - time.Minute))
+		stoppedAt := v1.NewTime(now.Add(-stoppedAgo))
+		return &Backup{
+			ObjectMeta: v1.ObjectMeta{Name: name},
+			Spec:       BackupSpec{Method: BackupMethodVolumeSnapshot},
+			Status: BackupStatus{
+				Phase:     phase,
+				StartedAt: &startedAt,
+				StoppedAt: &stoppedAt,
+			},
+		}
+	}
+	newCluster := func(limit int32) *Cluster {
+		return &Cluster{Spec: ClusterSpec{Backup: &BackupConfiguration{HistoryLimit: limit}}}
+	}
+	getNames := func(cluster *Cluster) []string {
+		names := make([]string, len(cluster.Status.BackupHistory))
+		for i, attempt := range cluster.Status.BackupHistory {
+			names[i] = attempt.Name
+		}
+		return names
+	}
+
+	It("records the terminated backups", func() {
+		cluster := newCluster(5)
+		failed := newBackup("backup-1", time.Hour, BackupPhaseFailed)
+		failed.Status.Error = "cannot fence the instance"
+		Expect(cluster.RecordBackupAttempt(failed)).To(BeTrue())
+
+		Expect(cluster.Status.BackupHistory).To(Equal([]BackupAttempt{{
+			Name:      "backup-1",
+			Method:    BackupMethodVolumeSnapshot,
+			Phase:     BackupPhaseFailed,
+			StartedAt: "2024-01-01T10:59:00Z",
+			StoppedAt: "2024-01-01T11:00:00Z",
+			Duration:  "1m0s",
+			Error:     "cannot fence the instance",
+		}}))
+	})
+
+	It("records each backup only once", func() {
+		cluster := newCluster(5)
+		Expect(cluster.RecordBackupAttempt(newBackup("backup-1", time.Hour, BackupPhaseCompleted))).To(BeTrue())
+		Expect(cluster.RecordBackupAttempt(newBackup("backup-1", time.Hour, BackupPhaseCompleted))).To(BeFalse())
+		Expect(cluster.Status.BackupHistory).To(HaveLen(1))
+	})
+
+	It("keeps the history sorted and capped", func() {
+		cluster := newCluster(2)
+		Expect(cluster.RecordBackupAttempt(newBackup("backup-2", 2*time.Hour, BackupPhaseCompleted))).To(BeTrue())
+		Expect(cluster.RecordBackupAttempt(newBackup("backup-3", time.Hour, BackupPhaseFailed))).To(BeTrue())
+		Expect(cluster.RecordBackupAttempt(newBackup("backup-1", 3*time.Hour, BackupPhaseCompleted))).To(BeFalse())
+		Expect(getNames(cluster)).To(Equal([]string{"backup-2", "backup-3"}))
+
+		Expect(cluster.RecordBackupAttempt(newBackup("backup-4", 0, BackupPhaseCompleted))).To(BeTrue())
+		Expect(getNames(cluster)).To(Equal([]string{"backup-3", "backup-4"}))
+	})
+
+	It("applies a lowered limit", func() {
+		cluster := newCluster(3)
+		Expect(cluster.RecordBackupAttempt(newBackup("backup-1", 2*time.Hour, BackupPhaseCompleted))).To(BeTrue())
+		Expect(cluster.RecordBackupAttempt(newBackup("backup-2", time.Hour, BackupPhaseCompleted))).To(BeTrue())
+
+		cluster.Spec.Backup.HistoryLimit = 1
+		Expect(cluster.RecordBackupAttempt(newBackup("backup-2", time.Hour, BackupPhaseCompleted))).To(BeTrue())
+		Expect(getNames(cluster)).To(Equal([]string{"backup-2"}))
+	})
+
+	It("clears the history when disabled", func() {
+		cluster := newCluster(3)
+		Expect(cluster.RecordBackupAttempt(newBackup("backup-1", time.Hour, BackupPhaseCompleted))).To(BeTrue())
+
+		cluster.Spec.Backup.HistoryLimit = 0
+		Expect(cluster.RecordBackupAttempt(newBackup("backup-2", 0, BackupPhaseCompleted))).To(BeTrue())
+		Expect(cluster.Status.BackupHistory).To(BeNil())
+		Expect(cluster.RecordBackupAttempt(newBackup("backup-3", 0, BackupPhaseCompleted))).To(BeFalse())
+	})
+})
@@ -17,10 +17,12 @@ limitations under the License.
 package v1
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
 	v1 "k8s.io/api/core/v1"
@@ -33,6 +35,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/strings/slices"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
@@ -61,8 +64,18 @@ const (
 // clusterLog is for logging in this package.
 var clusterLog = log.WithName("cluster-resource").WithValues("version", "v1")
 
+// clusterWebhookReader is used by the validating webhook to look up the
+// objects referenced by a Cluster in its namespace. The lookups are skipped
+// when it is not set, i.e. when the webhook is not running in the operator
+var clusterWebhookReader client.Reader
+
+// externalSourceLookupTimeout is the time allowed to the validating webhook
+// to look up the secrets of the source of a replica cluster
+const externalSourceLookupTimeout = 5 * time.Second
+
 // SetupWebhookWithManager setup the webhook inside the controller manager
 func (r *Cluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	clusterWebhookReader = mgr.GetAPIReader()
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		Complete()
@@ -259,6 +272,7 @@ var _ webhook.Validator = &Cluster{}
 func (r *Cluster) ValidateCreate() (admission.Warnings, error) {
 	clusterLog.Info("validate create", "name", r.Name, "namespace", r.Namespace)
 	allErrs := r.Validate()
+	allErrs = append(allErrs, r.validateReplicaSourceSecrets(clusterWebhookReader)...)
 	if len(allErrs) == 0 {
 		return nil, nil
 	}
@@ -291,11 +305,21 @@ func (r *Cluster) Validate() (allErrs field.ErrorList) {
 		r.validateBootstrapPgBaseBackupSource,
 		r.validateBootstrapRecoverySource,
 		r.validateBootstrapRecoveryDataSource,
+		r.validatePostRestoreApplicationSQL,
 		r.validateExternalClusters,
 		r.validateTolerations,
 		r.validateAntiAffinity,
 		r.validateReplicaMode,
 		r.validateBackupConfiguration,
+		r.validateBackupProtection,
+		r.validateVolumeSnapshotRetention,
+		r.validateVolumeSnapshotFencingRequirements,
+		r.validateVolumeSnapshotRequiredLabels,
+		r.validateVolumeSnapshotMetadataPlaceholders,
+		r.validateVolumeSnapshotRemoteTarget,
+		r.validateVolumeSnapshotReuseWindow,
+		r.validateVolumeSnapshotOnline,
+		r.validateVolumeSnapshotCreationRetry,
 		r.validateConfiguration,
 		r.validateLDAP,
 		r.validateReplicationSlots,
@@ -859,6 +883,37 @@ func (r *Cluster) validateBootstrapRecoveryDataSource() field.ErrorList {
 	return result
 }
 
+// validatePostRestoreApplicationSQL ensures that the post-restore queries
+// are only used when recovering a primary cluster from volume snapshots
+func (r *Cluster) validatePostRestoreApplicationSQL() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		len(r.Spec.Bootstrap.Recovery.PostRestoreApplicationSQL) == 0 {
+		return nil
+	}
+
+	var result field.ErrorList
+	postRestorePath := field.NewPath("spec", "bootstrap", "recovery", "postRestoreApplicationSQL")
+	if r.Spec.Bootstrap.Recovery.VolumeSnapshots == nil {
+		result = append(
+			result,
+			field.Invalid(
+				postRestorePath,
+				r.Spec.Bootstrap.Recovery.PostRestoreApplicationSQL,
+				"Post-restore queries are only supported when recovering from volume snapshots"))
+	}
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				postRestorePath,
+				r.Spec.Bootstrap.Recovery.PostRestoreApplicationSQL,
+				"Post-restore queries cannot be executed in a replica cluster"))
+	}
+
+	return result
+}
+
 // validateVolumeSnapshotSource validates a source of a recovery snapshot.
 // The supported resources are VolumeSnapshots and PersistentVolumeClaim
 func validateVolumeSnapshotSource(
@@ -1537,6 +1592,56 @@ func (r *Cluster) validateExternalCluster(externalCluster *ExternalCluster, path
 				"one of connectionParameters and barmanObjectStore is required"))
 	}
 
+	result = append(result, validateExternalClusterPort(externalCluster, path)...)
+
+	if externalCluster.SecretsNamespace != "" {
+		for _, msg := range validationutil.IsDNS1123Label(externalCluster.SecretsNamespace) {
+			result = append(result, field.Invalid(
+				path.Child("secretsNamespace"),
+				externalCluster.SecretsNamespace,
+				msg))
+		}
+	}
+
+	return result
+}
+
+// validateExternalClusterPort checks the "port" connection parameter of an
+// external cluster. As in libpq, it may contain a comma-separated list of
+// ports, one for each of the specified hosts, or a single port to be used
+// for every host
+func validateExternalClusterPort(externalCluster *ExternalCluster, path *field.Path) field.ErrorList {
+	var result field.ErrorList
+
+	portParameter, ok := externalCluster.ConnectionParameters["port"]
+	if !ok {
+		return result
+	}
+
+	portPath := path.Child("connectionParameters", "port")
+	ports := strings.Split(portParameter, ",")
+	for _, port := range ports {
+		portNumber, err := strconv.Atoi(strings.TrimSpace(port))
+		if err != nil || portNumber < 1 || portNumber > 65535 {
+			result = append(result, field.Invalid(
+				portPath,
+				portParameter,
+				fmt.Sprintf("invalid port %q, must be a number between 1 and 65535", port)))
+		}
+	}
+
+	hosts := externalCluster.ConnectionParameters["host"]
+	if hosts == "" {
+		hosts = externalCluster.ConnectionParameters["hostaddr"]
+	}
+	if hostCount := len(strings.Split(hosts, ",")); len(ports) > 1 && len(ports) != hostCount {
+		result = append(result, field.Invalid(
+			portPath,
+			portParameter,
+			fmt.Sprintf("the number of ports (%d) must be either one or match the number of hosts (%d)",
+				len(ports), hostCount)))
+	}
+
 	return result
 }
 
@@ -1599,14 +1704,351 @@ func (r *Cluster) validateReplicaMode() field.ErrorList {
 			r.Spec.ReplicaCluster,
 			"replica mode is compatible only with bootstrap using pg_basebackup or recovery"))
 	}
-	_, found := r.ExternalCluster(r.Spec.ReplicaCluster.Source)
+	externalCluster, found := r.ExternalCluster(r.Spec.ReplicaCluster.Source)
 	if !found {
 		result = append(
 			result,
 			field.Invalid(
-				field.NewPath("spec", "replicaCluster", "primaryServerName"),
+				field.NewPath("spec", "replicaCluster", "source"),
 				r.Spec.ReplicaCluster.Source,
 				fmt.Sprintf("External cluster %v not found", r.Spec.ReplicaCluster.Source)))
+	} else if r.Spec.ReplicaCluster.LogicalDecoding && len(externalCluster.ConnectionParameters) == 0 {
+		result = append(
+			result,
+			field.Invalid(
+				field.NewPath("spec", "replicaCluster", "logicalDecoding"),
+				r.Spec.ReplicaCluster.LogicalDecoding,
+				fmt.Sprintf("Logical decoding requires the connection parameters of the external cluster %v, "+
+					"to check its wal_level", r.Spec.ReplicaCluster.Source)))
+	}
+
+	if found {
+		result = append(result, r.validateReplicaChannelBinding(externalCluster)...)
+		result = append(result, r.validateReplicaSourceStandby(externalCluster)...)
+	}
+
+	result = append(result, r.validateReplicaFallbackSources()...)
+
+	if r.Spec.ReplicaCluster.AutomaticReseed != nil && !r.Spec.ReplicaCluster.ReportSourceStatus {
+		result = append(
+			result,
+			field.Invalid(
+				field.NewPath("spec", "replicaCluster", "automaticReseed"),
+				r.Spec.ReplicaCluster.AutomaticReseed,
+				"Automatic re-seed requires reportSourceStatus to be enabled, "+
+					"to detect whether the source is ahead of the designated primary"))
+	}
+
+	result = append(result, r.validateReplicaRecoveryTarget()...)
+	result = append(result, r.validateReplicaLocalArchive()...)
+
+	return result
+}
+
+// validateReplicaSourceSecrets checks that the secrets referenced by the
+// source of the replica cluster exist in the namespace of the cluster, so
+// that a cluster which could never replicate is refused at creation time.
+// The secrets living in other namespaces are not checked, not to disclose
+// their existence to the users who can't read them: they are only read by
+// the instances, through the permissions granted by the users.
+// The errors in looking up the secrets, other than them not being found,
+// don't prevent the creation of the cluster
+func (r *Cluster) validateReplicaSourceSecrets(reader client.Reader) field.ErrorList {
+	if reader == nil || !r.IsReplica() {
+		return nil
+	}
+
+	var sourceIndex int
+	var source *ExternalCluster
+	for idx := range r.Spec.ExternalClusters {
+		if r.Spec.ExternalClusters[idx].Name == r.Spec.ReplicaCluster.Source {
+			sourceIndex, source = idx, &r.Spec.ExternalClusters[idx]
+			break
+		}
+	}
+	if source == nil {
+		// Already reported by validateReplicaMode
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), externalSourceLookupTimeout)
+	defer cancel()
+
+	var result field.ErrorList
+	basePath := field.NewPath("spec", "externalClusters").Index(sourceIndex)
+	for _, ref := range getExternalClusterSecretRefs(*source, r.Namespace, basePath) {
+		if ref.namespace != r.Namespace {
+			continue
+		}
+
+		var secret v1.Secret
+		err := reader.Get(ctx, client.ObjectKey{Namespace: ref.namespace, Name: ref.name}, &secret)
+		switch {
+		case apierrors.IsNotFound(err):
+			result = append(result, field.Invalid(
+				ref.path,
+				ref.name,
+				fmt.Sprintf("The secret %v, needed to connect to the source %v, is not found in namespace %v",
+					ref.name, source.Name, r.Namespace)))
+		case err != nil:
+			clusterLog.Info("Cannot look up the secret of the replica cluster source, skipping the check",
+				"name", r.Name, "namespace", r.Namespace, "secret", ref.name, "error", err.Error())
+		}
+	}
+
+	return result
+}
+
+// externalClusterSecretRef is a secret referenced by an external cluster,
+// together with the path of the field referencing it
+type externalClusterSecretRef struct {
+	path      *field.Path
+	namespace string
+	name      string
+}
+
+// getExternalClusterSecretRefs returns the secrets referenced by an external
+// cluster, both for the connection and for the object store, given the
+// namespace of the cluster
+func getExternalClusterSecretRefs(
+	externalCluster ExternalCluster,
+	namespace string,
+	basePath *field.Path,
+) []externalClusterSecretRef {
+	var result []externalClusterSecretRef
+	addCore := func(fieldName string, selector *v1.SecretKeySelector) {
+		if selector != nil && selector.Name != "" {
+			result = append(result, externalClusterSecretRef{
+				path:      basePath.Child(fieldName, "name"),
+				namespace: externalCluster.GetSecretsNamespace(namespace),
+				name:      selector.Name,
+			})
+		}
+	}
+	addCore("sslCert", externalCluster.SSLCert)
+	addCore("sslKey", externalCluster.SSLKey)
+	addCore("sslRootCert", externalCluster.SSLRootCert)
+	addCore("password", externalCluster.Password)
+
+	store := externalCluster.BarmanObjectStore
+	if store == nil {
+		return result
+	}
+
+	storePath := basePath.Child("barmanObjectStore")
+	add := func(path *field.Path, selector *SecretKeySelector) {
+		if selector != nil && selector.Name != "" {
+			result = append(result, externalClusterSecretRef{
+				path:      path.Child("name"),
+				namespace: namespace,
+				name:      selector.Name,
+			})
+		}
+	}
+	add(storePath.Child("endpointCA"), store.EndpointCA)
+	if aws := store.AWS; aws != nil {
+		awsPath := storePath.Child("s3Credentials")
+		add(awsPath.Child("accessKeyId"), aws.AccessKeyIDReference)
+		add(awsPath.Child("secretAccessKey"), aws.SecretAccessKeyReference)
+		add(awsPath.Child("region"), aws.RegionReference)
+		add(awsPath.Child("sessionToken"), aws.SessionToken)
+	}
+	if azure := store.Azure; azure != nil {
+		azurePath := storePath.Child("azureCredentials")
+		add(azurePath.Child("connectionString"), azure.ConnectionString)
+		add(azurePath.Child("storageAccount"), azure.StorageAccount)
+		add(azurePath.Child("storageKey"), azure.StorageKey)
+		add(azurePath.Child("storageSasToken"), azure.StorageSasToken)
+	}
+	if google := store.Google; google != nil {
+		add(storePath.Child("googleCredentials", "applicationCredentials"), google.ApplicationCredentials)
+	}
+
+	return result
+}
+
+// validateReplicaLocalArchive checks that the designated primary has its own
+// object store to archive the WAL files in, distinct from the one of the source
+func (r *Cluster) validateReplicaLocalArchive() field.ErrorList {
+	if !r.Spec.ReplicaCluster.LocalArchive {
+		return nil
+	}
+
+	path := field.NewPath("spec", "replicaCluster", "localArchive")
+	if r.Spec.Backup == nil || r.Spec.Backup.BarmanObjectStore == nil {
+		return field.ErrorList{field.Invalid(
+			path,
+			r.Spec.ReplicaCluster.LocalArchive,
+			"Local archiving requires the object store to be configured in backup.barmanObjectStore")}
+	}
+
+	if r.IsLocalArchiveSharedWithSource() {
+		return field.ErrorList{field.Invalid(
+			path,
+			r.Spec.ReplicaCluster.LocalArchive,
+			fmt.Sprintf("The object store of the replica cluster is the WAL archive of the source %v, "+
+				"use a different destinationPath or serverName", r.Spec.ReplicaCluster.Source))}
+	}
+
+	return nil
+}
+
+// validateReplicaRecoveryTarget checks that the recovery target of the
+// designated primary has exactly one valid target
+func (r *Cluster) validateReplicaRecoveryTarget() field.ErrorList {
+	target := r.Spec.ReplicaCluster.RecoveryTarget
+	if target == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec", "replicaCluster", "recoveryTarget")
+	if (target.TargetTime == "") == (target.TargetLSN == "") {
+		return field.ErrorList{field.Invalid(
+			path,
+			target,
+			"Exactly one of targetTime and targetLSN needs to be set")}
+	}
+
+	var result field.ErrorList
+	if target.TargetTime != "" {
+		if _, err := utils.ParseTargetTime(nil, target.TargetTime); err != nil {
+			result = append(result, field.Invalid(
+				path.Child("targetTime"),
+				target.TargetTime,
+				"The format of targetTime is invalid"))
+		}
+	}
+	if target.TargetLSN != "" {
+		if _, err := postgres.LSN(target.TargetLSN).Parse(); err != nil {
+			result = append(result, field.Invalid(
+				path.Child("targetLSN"),
+				target.TargetLSN,
+				"Invalid targetLSN"))
+		}
+	}
+
+	return result
+}
+
+// validateReplicaFallbackSources checks that the fallback sources of the
+// replica cluster are distinct external clusters, which can be streamed from
+func (r *Cluster) validateReplicaFallbackSources() field.ErrorList {
+	var result field.ErrorList
+
+	seen := stringset.From([]string{r.Spec.ReplicaCluster.Source})
+	for idx, name := range r.Spec.ReplicaCluster.FallbackSources {
+		fieldPath := field.NewPath("spec", "replicaCluster", "fallbackSources").Index(idx)
+		if seen.Has(name) {
+			result = append(result, field.Duplicate(fieldPath, name))
+			continue
+		}
+		seen.Put(name)
+
+		externalCluster, found := r.ExternalCluster(name)
+		switch {
+		case !found:
+			result = append(result, field.Invalid(fieldPath, name,
+				fmt.Sprintf("External cluster %v not found", name)))
+		case len(externalCluster.ConnectionParameters) == 0:
+			result = append(result, field.Invalid(fieldPath, name,
+				fmt.Sprintf("The external cluster %v needs its connection parameters "+
+					"to be streamed from", name)))
+		}
+	}
+
+	return result
+}
+
+// validateReplicaChannelBinding checks that the channel binding requested for
+// the connection to the source is supported by the PostgreSQL version and by
+// the authentication method of the external cluster
+func (r *Cluster) validateReplicaChannelBinding(source ExternalCluster) field.ErrorList {
+	channelBinding := r.Spec.ReplicaCluster.ChannelBinding
+	if channelBinding == "" {
+		return nil
+	}
+
+	var result field.ErrorList
+	fieldPath := field.NewPath("spec", "replicaCluster", "channelBinding")
+
+	pgVersion, err := r.GetPostgresqlVersion()
+	if err != nil {
+		// The validation error will be already raised by the
+		// validateImageName function
+		return nil
+	}
+
+	if pgVersion < 130000 {
+		result = append(result, field.Invalid(
+			fieldPath,
+			channelBinding,
+			"Channel binding requires PostgreSQL 13 or above"))
+	}
+
+	if _, ok := source.ConnectionParameters["channel_binding"]; ok {
+		result = append(result, field.Invalid(
+			fieldPath,
+			channelBinding,
+			fmt.Sprintf("The connection parameters of the external cluster %v already set channel_binding",
+				source.Name)))
+	}
+
+	if channelBinding != ChannelBindingRequire {
+		return result
+	}
+
+	if source.Password == nil {
+		result = append(result, field.Invalid(
+			fieldPath,
+			channelBinding,
+			fmt.Sprintf("Channel binding requires a SCRAM password authentication, "+
+				"but the external cluster %v has no password", source.Name)))
+	}
+
+	if source.ConnectionParameters["sslmode"] == "disable" {
+		result = append(result, field.Invalid(
+			fieldPath,
+			channelBinding,
+			fmt.Sprintf("Channel binding requires SSL, which is disabled for the external cluster %v",
+				source.Name)))
+	}
+
+	return result
+}
+
+// validateReplicaSourceStandby checks that the designated primary can stream
+// from a standby of the source, which requires the libpq of PostgreSQL 14 to
+// prefer the standby hosts and the external cluster to be reachable through
+// streaming replication
+func (r *Cluster) validateReplicaSourceStandby(source ExternalCluster) field.ErrorList {
+	if !r.Spec.ReplicaCluster.PreferSourceStandby {
+		return nil
+	}
+
+	var result field.ErrorList
+	fieldPath := field.NewPath("spec", "replicaCluster", "preferSourceStandby")
+
+	if pgVersion, err := r.GetPostgresqlVersion(); err == nil && pgVersion < 140000 {
+		result = append(result, field.Invalid(
+			fieldPath,
+			r.Spec.ReplicaCluster.PreferSourceStandby,
+			"Streaming from a standby of the source requires PostgreSQL 14 or above"))
+	}
+
+	if source.ConnectionParameters["host"] == "" {
+		result = append(result, field.Invalid(
+			fieldPath,
+			r.Spec.ReplicaCluster.PreferSourceStandby,
+			fmt.Sprintf("Streaming from a standby of the source requires the host connection parameter "+
+				"of the external cluster %v, listing its read-only endpoint", source.Name)))
+	}
+
+	if _, ok := source.ConnectionParameters["target_session_attrs"]; ok {
+		result = append(result, field.Invalid(
+			fieldPath,
+			r.Spec.ReplicaCluster.PreferSourceStandby,
+			fmt.Sprintf("The connection parameters of the external cluster %v already set target_session_attrs",
+				source.Name)))
 	}
 
 	return result
@@ -1771,6 +2213,303 @@ func (r *Cluster) validateBackupConfiguration() field.ErrorList {
 	return allErrors
 }
 
+// validateBackupProtection validates the maximum age of the last successful
+// backup used for the backup protection check
+func (r *Cluster) validateBackupProtection() field.ErrorList {
+	if _, err := r.Spec.Backup.GetBackupProtectionMaxAge(); err != nil {
+		return field.ErrorList{
+			field.Invalid(
+				field.NewPath("spec", "backup", "backupProtectionMaxAge"),
+				r.Spec.Backup.BackupProtectionMaxAge,
+				err.Error(),
+			),
+		}
+	}
+
+	return nil
+}
+
+// validateVolumeSnapshotRetention validates the retention policies of
+// the volume snapshots taken by backups
+func (r *Cluster) validateVolumeSnapshotRetention() field.ErrorList {
+	if r.Spec.Backup == nil || r.Spec.Backup.VolumeSnapshot == nil {
+		return nil
+	}
+
+	var result field.ErrorList
+	basePath := field.NewPath("spec", "backup", "volumeSnapshot")
+	retentions := []struct {
+		name      string
+		retention *VolumeSnapshotRetention
+	}{
+		{name: "retention", retention: r.Spec.Backup.VolumeSnapshot.Retention},
+		{name: "manualRetention", retention: r.Spec.Backup.VolumeSnapshot.ManualRetention},
+		{name: "walRetention", retention: r.Spec.Backup.VolumeSnapshot.WalRetention},
+	}
+	for _, item := range retentions {
+		if item.retention == nil {
+			continue
+		}
+
+		if item.retention.MaxCount < 0 {
+			result = append(result, field.Invalid(
+				basePath.Child(item.name, "maxCount"),
+				item.retention.MaxCount,
+				"the number of snapshots to be retained must be positive"))
+		}
+
+		buckets := []struct {
+			name  string
+			value int
+		}{
+			{name: "keepDaily", value: item.retention.KeepDaily},
+			{name: "keepWeekly", value: item.retention.KeepWeekly},
+			{name: "keepMonthly", value: item.retention.KeepMonthly},
+			{name: "keepYearly", value: item.retention.KeepYearly},
+		}
+		for _, bucket := range buckets {
+			if bucket.value < 0 {
+				result = append(result, field.Invalid(
+					basePath.Child(item.name, bucket.name),
+					bucket.value,
+					"the number of time buckets to be retained must not be negative"))
+			}
+		}
+
+		if _, err := item.retention.GetMaxAge(); err != nil {
+			result = append(result, field.Invalid(
+				basePath.Child(item.name, "maxAge"),
+				item.retention.MaxAge,
+				err.Error()))
+		}
+	}
+
+	if _, err := r.Spec.Backup.VolumeSnapshot.GetMaxRestorableAge(); err != nil {
+		result = append(result, field.Invalid(
+			basePath.Child("maxRestorableAge"),
+			r.Spec.Backup.VolumeSnapshot.MaxRestorableAge,
+			err.Error()))
+	}
+
+	return result
+}
+
+// validateVolumeSnapshotFencingRequirements validates the fencing
+// requirements of the volume snapshots taken by backups
+func (r *Cluster) validateVolumeSnapshotFencingRequirements() field.ErrorList {
+	if r.Spec.Backup == nil || r.Spec.Backup.VolumeSnapshot == nil {
+		return nil
+	}
+
+	var result field.ErrorList
+	basePath := field.NewPath("spec", "backup", "volumeSnapshot", "fencingRequirements")
+	roles := stringset.New()
+	for idx, requirement := range r.Spec.Backup.VolumeSnapshot.FencingRequirements {
+		if roles.Has(requirement.Role) {
+			result = append(result, field.Duplicate(
+				basePath.Index(idx).Child("role"),
+				requirement.Role))
+		}
+		roles.Put(requirement.Role)
+	}
+
+	// The volumes snapshotted while the instance is running would not be
+	// consistent with the ones snapshotted while it is fenced, so every
+	// volume of the instance must have the same requirement
+	snapshotConfig := r.Spec.Backup.VolumeSnapshot
+	if r.ShouldCreateWalArchiveVolume() &&
+		snapshotConfig.IsFencingRequired(string(utils.PVCRolePgData)) !=
+			snapshotConfig.IsFencingRequired(string(utils.PVCRolePgWal)) {
+		result = append(result, field.Invalid(
+			basePath,
+			snapshotConfig.FencingRequirements,
+			"the PG_DATA and PG_WAL PersistentVolumeClaims must have the same fencing requirement"))
+	}
+
+	return result
+}
+
+// volumeSnapshotSystemLabelPrefixes is the list of prefixes of the labels
+// managed by Kubernetes or by the operator, which can't be required on
+// the volume snapshots
+var volumeSnapshotSystemLabelPrefixes = []string{
+	utils.MetadataNamespace + "/",
+	"kubectl.kubernetes.io/",
+	"kubernetes.io/",
+	"k8s.io/",
+}
+
+// validateVolumeSnapshotRequiredLabels validates the labels required
+// on the volume snapshots taken by backups
+func (r *Cluster) validateVolumeSnapshotRequiredLabels() field.ErrorList {
+	if r.Spec.Backup == nil || r.Spec.Backup.VolumeSnapshot == nil {
+		return nil
+	}
+
+	var result field.ErrorList
+	basePath := field.NewPath("spec", "backup", "volumeSnapshot", "requiredLabels")
+	for key, value := range r.Spec.Backup.VolumeSnapshot.RequiredLabels {
+		keyPath := basePath.Key(key)
+		for _, prefix := range volumeSnapshotSystemLabelPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				result = append(result, field.Invalid(
+					keyPath,
+					key,
+					"Labels managed by Kubernetes or by the operator cannot be required"))
+				break
+			}
+		}
+
+		for _, msg := range validationutil.IsQualifiedName(key) {
+			result = append(result, field.Invalid(keyPath, key, msg))
+		}
+
+		for _, msg := range validationutil.IsValidLabelValue(value) {
+			result = append(result, field.Invalid(keyPath, value, msg))
+		}
+	}
+
+	return result
+}
+
+// validateVolumeSnapshotMetadataPlaceholders validates the placeholders
+// used in the values of the labels and of the annotations of the volume
+// snapshots taken by backups
+func (r *Cluster) validateVolumeSnapshotMetadataPlaceholders() field.ErrorList {
+	if r.Spec.Backup == nil || r.Spec.Backup.VolumeSnapshot == nil {
+		return nil
+	}
+
+	placeholders := make(map[string]string, len(VolumeSnapshotPlaceholders))
+	for _, name := range VolumeSnapshotPlaceholders {
+		placeholders[name] = ""
+	}
+
+	var result field.ErrorList
+	basePath := field.NewPath("spec", "backup", "volumeSnapshot")
+	for key, value := range r.Spec.Backup.VolumeSnapshot.Labels {
+		if _, err := utils.ExpandPlaceholders(value, placeholders); err != nil {
+			result = append(result, field.Invalid(basePath.Child("labels").Key(key), value, err.Error()))
+		}
+	}
+	for key, value := range r.Spec.Backup.VolumeSnapshot.Annotations {
+		if _, err := utils.ExpandPlaceholders(value, placeholders); err != nil {
+			result = append(result, field.Invalid(basePath.Child("annotations").Key(key), value, err.Error()))
+		}
+	}
+
+	return result
+}
+
+// validateVolumeSnapshotRemoteTarget validates the remote target
+// of the volume snapshots taken by backups
+func (r *Cluster) validateVolumeSnapshotRemoteTarget() field.ErrorList {
+	if r.Spec.Backup == nil || r.Spec.Backup.VolumeSnapshot == nil ||
+		r.Spec.Backup.VolumeSnapshot.RemoteTarget == nil {
+		return nil
+	}
+
+	var result field.ErrorList
+	snapshotConfig := r.Spec.Backup.VolumeSnapshot
+	basePath := field.NewPath("spec", "backup", "volumeSnapshot", "remoteTarget")
+	if snapshotConfig.RemoteTarget.KubeconfigSecret.Name == "" ||
+		snapshotConfig.RemoteTarget.KubeconfigSecret.Key == "" {
+		result = append(result, field.Required(
+			basePath.Child("kubeconfigSecret"),
+			"the name and the key of the Secret containing the kubeconfig are required"))
+	}
+
+	// The snapshots are created in the remote Kubernetes cluster, where
+	// the resources of this one can't be their owners
+	if snapshotConfig.SnapshotOwnerReference != "" &&
+		snapshotConfig.SnapshotOwnerReference != ShapshotOwnerReferenceNone {
+		result = append(result, field.Invalid(
+			field.NewPath("spec", "backup", "volumeSnapshot", "snapshotOwnerReference"),
+			snapshotConfig.SnapshotOwnerReference,
+			"the snapshots taken from a remote target can't have an owner reference"))
+	}
+
+	return result
+}
+
+// validateVolumeSnapshotReuseWindow validates that the reused snapshots
+// are not deleted together with the backup that took them
+func (r *Cluster) validateVolumeSnapshotReuseWindow() field.ErrorList {
+	if r.Spec.Backup == nil || r.Spec.Backup.VolumeSnapshot == nil ||
+		r.Spec.Backup.VolumeSnapshot.ReuseWindow <= 0 {
+		return nil
+	}
+
+	snapshotConfig := r.Spec.Backup.VolumeSnapshot
+	if r.GetSnapshotOwnerReference() != SnapshotOwnerReferenceBackup {
+		return nil
+	}
+
+	return field.ErrorList{
+		field.Invalid(
+			field.NewPath("spec", "backup", "volumeSnapshot", "reuseWindow"),
+			snapshotConfig.ReuseWindow,
+			"the snapshots can't be reused when they are owned by the backup that took them"),
+	}
+}
+
+// validateVolumeSnapshotCreationRetry validates the patterns recognizing
+// the transient errors in the creation of the volume snapshots
+func (r *Cluster) validateVolumeSnapshotCreationRetry() field.ErrorList {
+	if r.Spec.Backup == nil || r.Spec.Backup.VolumeSnapshot == nil ||
+		r.Spec.Backup.VolumeSnapshot.CreationRetry == nil {
+		return nil
+	}
+
+	creationRetry := r.Spec.Backup.VolumeSnapshot.CreationRetry
+	if _, err := creationRetry.CompileErrorPatterns(); err != nil {
+		return field.ErrorList{field.Invalid(
+			field.NewPath("spec", "backup", "volumeSnapshot", "creationRetry", "errorPatterns"),
+			creationRetry.ErrorPatterns,
+			err.Error())}
+	}
+
+	return nil
+}
+
+// validateVolumeSnapshotOnline validates the online snapshot backups,
+// whose restore requires the WAL files archived during the backup
+func (r *Cluster) validateVolumeSnapshotOnline() field.ErrorList {
+	if r.Spec.Backup == nil || r.Spec.Backup.VolumeSnapshot == nil ||
+		!r.Spec.Backup.VolumeSnapshot.Online {
+		return nil
+	}
+
+	var result field.ErrorList
+	snapshotConfig := r.Spec.Backup.VolumeSnapshot
+	basePath := field.NewPath("spec", "backup", "volumeSnapshot")
+	if r.Spec.Backup.BarmanObjectStore == nil {
+		result = append(result, field.Required(
+			field.NewPath("spec", "backup", "barmanObjectStore"),
+			"the online snapshot backups require the WAL archive"))
+	}
+
+	// The backup mode is controlled through the instance manager,
+	// which is not reachable in a remote Kubernetes cluster
+	if snapshotConfig.RemoteTarget != nil {
+		result = append(result, field.Invalid(
+			basePath.Child("online"),
+			snapshotConfig.Online,
+			"the snapshots of a remote target can't be taken online"))
+	}
+
+	// The PVCs of an instance which is not fenced change while the
+	// snapshots are taken, and can't match a previous snapshot
+	if snapshotConfig.ReuseWindow > 0 {
+		result = append(result, field.Invalid(
+			basePath.Child("reuseWindow"),
+			snapshotConfig.ReuseWindow,
+			"the snapshots taken online can't be reused"))
+	}
+
+	return result
+}
+
 func (r *Cluster) validateReplicationSlots() field.ErrorList {
 	replicationSlots := r.Spec.ReplicationSlots
 	if replicationSlots == nil ||
@@ -1786,16 +2525,25 @@ func (r *Cluster) validateReplicationSlots() field.ErrorList {
 		return nil
 	}
 
-	if psqlVersion >= 110000 {
-		return nil
+	if psqlVersion < 110000 {
+		return field.ErrorList{
+			field.Invalid(
+				field.NewPath("spec", "replicationSlots", "highAvailability", "enabled"),
+				replicationSlots.HighAvailability.GetEnabled(),
+				"Cannot enable replication slot high availability. It requires PostgreSQL 11 or above"),
+		}
 	}
 
-	return field.ErrorList{
-		field.Invalid(
-			field.NewPath("spec", "replicationSlots", "highAvailability", "enabled"),
-			replicationSlots.HighAvailability.GetEnabled(),
-			"Cannot enable replication slot high availability. It requires PostgreSQL 11 or above"),
+	if replicationSlots.UsesTemporarySlots() && psqlVersion < 130000 {
+		return field.ErrorList{
+			field.Invalid(
+				field.NewPath("spec", "replicationSlots", "highAvailability", "slotType"),
+				replicationSlots.HighAvailability.SlotType,
+				"Temporary replication slots require PostgreSQL 13 or above"),
+		}
 	}
+
+	return nil
 }
 
 func (r *Cluster) validateReplicationSlotsChange(old *Cluster) field.ErrorList {
@@ -2045,5 +2793,14 @@ func (r *Cluster) validatePgFailoverSlots() field.ErrorList {
 		)
 	}
 
+	if r.Spec.ReplicationSlots.UsesTemporarySlots() {
+		result = append(result,
+			field.Invalid(
+				field.NewPath("spec", "replicationSlots", "highAvailability", "slotType"),
+				r.Spec.ReplicationSlots.HighAvailability.SlotType,
+				fmt.Sprintf("Permanent replication slots are required to use %s", pgFailoverSlots.Name)),
+		)
+	}
+
 	return result
 }
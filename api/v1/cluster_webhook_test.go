@@ -17,14 +17,20 @@ limitations under the License.
 package v1
 
 import (
+	"context"
+	"errors"
 	"strings"
 
 	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/cloudnative-pg/cloudnative-pg/internal/configuration"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/versions"
@@ -1664,6 +1670,95 @@ var _ = Describe("validation of an external cluster", func() {
 		cluster.Spec.ExternalClusters[0].BarmanObjectStore = &BarmanObjectStoreConfiguration{}
 		Expect(cluster.validateExternalClusters()).To(BeEmpty())
 	})
+
+	It("validates the namespace of the secrets", func() {
+		cluster := Cluster{
+			Spec: ClusterSpec{
+				ExternalClusters: []ExternalCluster{
+					{
+						Name: "one",
+						ConnectionParameters: map[string]string{
+							"dbname": "postgres",
+						},
+						SecretsNamespace: "source-namespace",
+					},
+				},
+			},
+		}
+		Expect(cluster.validateExternalClusters()).To(BeEmpty())
+
+		cluster.Spec.ExternalClusters[0].SecretsNamespace = "Source_Namespace"
+		result := cluster.validateExternalClusters()
+		Expect(result).ToNot(BeEmpty())
+		Expect(result[0].Field).To(Equal("spec.externalClusters[0].secretsNamespace"))
+	})
+
+	It("accepts a custom port for one or more hosts", func() {
+		cluster := Cluster{
+			Spec: ClusterSpec{
+				ExternalClusters: []ExternalCluster{
+					{
+						Name: "one",
+						ConnectionParameters: map[string]string{
+							"host": "gateway.example.com",
+							"port": "15432",
+						},
+					},
+					{
+						Name: "two",
+						ConnectionParameters: map[string]string{
+							"host": "gateway-a.example.com,gateway-b.example.com",
+							"port": "15432,25432",
+						},
+					},
+					{
+						Name: "three",
+						ConnectionParameters: map[string]string{
+							"host": "gateway-a.example.com,gateway-b.example.com",
+							"port": "15432",
+						},
+					},
+				},
+			},
+		}
+		Expect(cluster.validateExternalClusters()).To(BeEmpty())
+	})
+
+	It("complains if the port is out of range or not a number", func() {
+		for _, port := range []string{"0", "65536", "-1", "postgres", "5432,"} {
+			cluster := Cluster{
+				Spec: ClusterSpec{
+					ExternalClusters: []ExternalCluster{
+						{
+							Name: "one",
+							ConnectionParameters: map[string]string{
+								"host": "gateway.example.com",
+								"port": port,
+							},
+						},
+					},
+				},
+			}
+			Expect(cluster.validateExternalClusters()).ToNot(BeEmpty(), "port: %v", port)
+		}
+	})
+
+	It("complains if the number of ports doesn't match the number of hosts", func() {
+		cluster := Cluster{
+			Spec: ClusterSpec{
+				ExternalClusters: []ExternalCluster{
+					{
+						Name: "one",
+						ConnectionParameters: map[string]string{
+							"host": "gateway-a.example.com,gateway-b.example.com,gateway-c.example.com",
+							"port": "15432,25432",
+						},
+					},
+				},
+			},
+		}
+		Expect(cluster.validateExternalClusters()).ToNot(BeEmpty())
+	})
 })
 
 var _ = Describe("bootstrap base backup validation", func() {
@@ -2159,6 +2254,84 @@ var _ = Describe("replica mode validation", func() {
 		Expect(result).ToNot(BeEmpty())
 	})
 
+	It("complains when logical decoding is required on a source without connection parameters", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				ReplicaCluster: &ReplicaClusterConfiguration{
+					Enabled:         true,
+					Source:          "test",
+					LogicalDecoding: true,
+				},
+				Bootstrap: &BootstrapConfiguration{
+					Recovery: &BootstrapRecovery{},
+				},
+				ExternalClusters: []ExternalCluster{
+					{
+						Name: "test",
+					},
+				},
+			},
+		}
+		Expect(cluster.validateReplicaMode()).ToNot(BeEmpty())
+
+		cluster.Spec.ExternalClusters[0].ConnectionParameters = map[string]string{
+			"host": "cluster-example-rw",
+		}
+		Expect(cluster.validateReplicaMode()).To(BeEmpty())
+	})
+
+	It("complains when the automatic re-seed is enabled without reporting the source status", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				ReplicaCluster: &ReplicaClusterConfiguration{
+					Enabled:         true,
+					Source:          "test",
+					AutomaticReseed: &ReplicaReseedConfiguration{},
+				},
+				Bootstrap: &BootstrapConfiguration{
+					Recovery: &BootstrapRecovery{},
+				},
+				ExternalClusters: []ExternalCluster{
+					{Name: "test"},
+				},
+			},
+		}
+		Expect(cluster.validateReplicaMode()).ToNot(BeEmpty())
+
+		cluster.Spec.ReplicaCluster.ReportSourceStatus = true
+		Expect(cluster.validateReplicaMode()).To(BeEmpty())
+	})
+
+	It("validates the fallback sources", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				ReplicaCluster: &ReplicaClusterConfiguration{
+					Enabled:         true,
+					Source:          "test",
+					FallbackSources: []string{"fallback", "test", "missing"},
+				},
+				Bootstrap: &BootstrapConfiguration{
+					Recovery: &BootstrapRecovery{},
+				},
+				ExternalClusters: []ExternalCluster{
+					{Name: "test"},
+					{Name: "fallback"},
+				},
+			},
+		}
+		result := cluster.validateReplicaMode()
+		Expect(result).To(HaveLen(3))
+		Expect(result[0].Field).To(Equal("spec.replicaCluster.fallbackSources[0]"))
+		Expect(result[1].Type).To(Equal(field.ErrorTypeDuplicate))
+		Expect(result[2].Field).To(Equal("spec.replicaCluster.fallbackSources[2]"))
+
+		cluster.Spec.ReplicaCluster.FallbackSources = []string{"fallback"}
+		cluster.Spec.ExternalClusters[1].ConnectionParameters = map[string]string{
+			"host": "fallback-rw",
+		}
+		Expect(cluster.validateReplicaMode()).To(BeEmpty())
+	})
+
 	It("complains when enabled on an existing cluster with no replica mode configured", func() {
 		oldCluster := &Cluster{
 			Spec: ClusterSpec{},
@@ -2198,77 +2371,544 @@ var _ = Describe("replica mode validation", func() {
 		}
 		cluster := &Cluster{
 			Spec: ClusterSpec{
-				ReplicaCluster: &ReplicaClusterConfiguration{
-					Enabled: true,
-					Source:  "test",
-				},
-				Bootstrap: &BootstrapConfiguration{
-					PgBaseBackup: &BootstrapPgBaseBackup{},
-				},
-				ExternalClusters: []ExternalCluster{
-					{Name: "test"},
+				ReplicaCluster: &ReplicaClusterConfiguration{
+					Enabled: true,
+					Source:  "test",
+				},
+				Bootstrap: &BootstrapConfiguration{
+					PgBaseBackup: &BootstrapPgBaseBackup{},
+				},
+				ExternalClusters: []ExternalCluster{
+					{Name: "test"},
+				},
+			},
+		}
+		Expect(cluster.validateReplicaMode()).To(BeEmpty())
+		Expect(cluster.validateReplicaModeChange(oldCluster)).ToNot(BeEmpty())
+	})
+
+	Context("local archive", func() {
+		newCluster := func(localPath string) *Cluster {
+			cluster := &Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "replica"},
+				Spec: ClusterSpec{
+					ReplicaCluster: &ReplicaClusterConfiguration{
+						Enabled:      true,
+						Source:       "source",
+						LocalArchive: true,
+					},
+					Bootstrap: &BootstrapConfiguration{
+						Recovery: &BootstrapRecovery{},
+					},
+					ExternalClusters: []ExternalCluster{
+						{
+							Name: "source",
+							BarmanObjectStore: &BarmanObjectStoreConfiguration{
+								DestinationPath: "s3://source-bucket/",
+							},
+						},
+					},
+				},
+			}
+			if localPath != "" {
+				cluster.Spec.Backup = &BackupConfiguration{
+					BarmanObjectStore: &BarmanObjectStoreConfiguration{
+						DestinationPath: localPath,
+					},
+				}
+			}
+			return cluster
+		}
+
+		It("is valid with an object store of its own", func() {
+			Expect(newCluster("s3://replica-bucket/").validateReplicaMode()).To(BeEmpty())
+		})
+
+		It("complains if the object store is not configured", func() {
+			result := newCluster("").validateReplicaMode()
+			Expect(result).To(HaveLen(1))
+			Expect(result[0].Field).To(Equal("spec.replicaCluster.localArchive"))
+		})
+
+		It("complains if the object store is the WAL archive of the source", func() {
+			cluster := newCluster("s3://source-bucket")
+			cluster.Spec.Backup.BarmanObjectStore.ServerName = "source"
+			result := cluster.validateReplicaMode()
+			Expect(result).To(HaveLen(1))
+			Expect(result[0].Field).To(Equal("spec.replicaCluster.localArchive"))
+		})
+
+		It("is valid sharing the bucket of the source under another server name", func() {
+			Expect(newCluster("s3://source-bucket/").validateReplicaMode()).To(BeEmpty())
+		})
+	})
+})
+
+// forbiddenGetReader is a client.Reader which is not allowed to get objects
+type forbiddenGetReader struct {
+	client.Reader
+}
+
+func (r forbiddenGetReader) Get(_ context.Context, key client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+	return apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, key.Name, errors.New("forbidden"))
+}
+
+var _ = Describe("replica cluster source secrets validation", func() {
+	newCluster := func() *Cluster {
+		return &Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "replica", Namespace: "default"},
+			Spec: ClusterSpec{
+				ReplicaCluster: &ReplicaClusterConfiguration{
+					Enabled: true,
+					Source:  "source",
+				},
+				ExternalClusters: []ExternalCluster{
+					{Name: "other", Password: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "other-password"},
+					}},
+					{
+						Name: "source",
+						Password: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "source-password"},
+							Key:                  "password",
+						},
+						BarmanObjectStore: &BarmanObjectStoreConfiguration{
+							DestinationPath: "s3://source-bucket/",
+							BarmanCredentials: BarmanCredentials{
+								AWS: &S3Credentials{
+									AccessKeyIDReference: &SecretKeySelector{
+										LocalObjectReference: LocalObjectReference{Name: "source-s3"},
+										Key:                  "ACCESS_KEY_ID",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	secret := func(name string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+	}
+
+	It("is valid when the secrets of the source exist", func() {
+		reader := fake.NewClientBuilder().WithObjects(secret("source-password"), secret("source-s3")).Build()
+		Expect(newCluster().validateReplicaSourceSecrets(reader)).To(BeEmpty())
+	})
+
+	It("complains about the missing secrets of the source", func() {
+		reader := fake.NewClientBuilder().WithObjects(secret("source-password")).Build()
+		result := newCluster().validateReplicaSourceSecrets(reader)
+		Expect(result).To(HaveLen(1))
+		Expect(result[0].Field).To(Equal(
+			"spec.externalClusters[1].barmanObjectStore.s3Credentials.accessKeyId.name"))
+		Expect(result[0].BadValue).To(Equal("source-s3"))
+	})
+
+	It("looks up the secrets in the namespace of the cluster", func() {
+		otherNamespace := secret("source-password")
+		otherNamespace.Namespace = "other"
+		reader := fake.NewClientBuilder().WithObjects(otherNamespace, secret("source-s3")).Build()
+		result := newCluster().validateReplicaSourceSecrets(reader)
+		Expect(result).To(HaveLen(1))
+		Expect(result[0].Field).To(Equal("spec.externalClusters[1].password.name"))
+	})
+
+	It("doesn't disclose whether the secrets exist in another namespace", func() {
+		reader := fake.NewClientBuilder().WithObjects(secret("source-s3")).Build()
+		cluster := newCluster()
+		cluster.Spec.ExternalClusters[1].SecretsNamespace = "other"
+		Expect(cluster.validateReplicaSourceSecrets(reader)).To(BeEmpty())
+
+		otherNamespace := secret("source-password")
+		otherNamespace.Namespace = "other"
+		reader = fake.NewClientBuilder().WithObjects(otherNamespace, secret("source-s3")).Build()
+		Expect(cluster.validateReplicaSourceSecrets(reader)).To(BeEmpty())
+	})
+
+	It("doesn't complain when the secrets cannot be looked up", func() {
+		Expect(newCluster().validateReplicaSourceSecrets(forbiddenGetReader{})).To(BeEmpty())
+		Expect(newCluster().validateReplicaSourceSecrets(nil)).To(BeEmpty())
+	})
+
+	It("ignores the clusters which are not replicas", func() {
+		cluster := newCluster()
+		cluster.Spec.ReplicaCluster.Enabled = false
+		Expect(cluster.validateReplicaSourceSecrets(fake.NewClientBuilder().Build())).To(BeEmpty())
+	})
+
+	It("reports the missing source with the field path of the source", func() {
+		cluster := newCluster()
+		cluster.Spec.ReplicaCluster.Source = "missing"
+		cluster.Spec.Bootstrap = &BootstrapConfiguration{PgBaseBackup: &BootstrapPgBaseBackup{}}
+		result := cluster.validateReplicaMode()
+		Expect(result).To(HaveLen(1))
+		Expect(result[0].Field).To(Equal("spec.replicaCluster.source"))
+		Expect(cluster.validateReplicaSourceSecrets(fake.NewClientBuilder().Build())).To(BeEmpty())
+	})
+})
+
+var _ = Describe("replica cluster channel binding validation", func() {
+	newCluster := func(channelBinding ChannelBindingMode, source ExternalCluster) *Cluster {
+		return &Cluster{
+			Spec: ClusterSpec{
+				ImageName: "postgres:16",
+				ReplicaCluster: &ReplicaClusterConfiguration{
+					Enabled:        true,
+					Source:         "test",
+					ChannelBinding: channelBinding,
+				},
+				Bootstrap: &BootstrapConfiguration{
+					PgBaseBackup: &BootstrapPgBaseBackup{},
+				},
+				ExternalClusters: []ExternalCluster{source},
+			},
+		}
+	}
+	passwordSource := ExternalCluster{
+		Name:                 "test",
+		ConnectionParameters: map[string]string{"host": "source-rw", "sslmode": "verify-full"},
+		Password: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "source-secret"},
+			Key:                  "password",
+		},
+	}
+
+	It("accepts requiring the channel binding with a SCRAM password over SSL", func() {
+		Expect(newCluster(ChannelBindingRequire, passwordSource).validateReplicaMode()).To(BeEmpty())
+	})
+
+	It("complains about PostgreSQL versions not supporting channel binding", func() {
+		cluster := newCluster(ChannelBindingPrefer, passwordSource)
+		cluster.Spec.ImageName = "postgres:12"
+		Expect(cluster.validateReplicaMode()).To(HaveLen(1))
+	})
+
+	It("complains when requiring the channel binding without a password", func() {
+		source := ExternalCluster{
+			Name:                 "test",
+			ConnectionParameters: map[string]string{"host": "source-rw", "sslmode": "disable"},
+		}
+		Expect(newCluster(ChannelBindingRequire, source).validateReplicaMode()).To(HaveLen(2))
+		Expect(newCluster(ChannelBindingPrefer, source).validateReplicaMode()).To(BeEmpty())
+	})
+
+	It("complains when the connection parameters already set the channel binding", func() {
+		source := passwordSource
+		source.ConnectionParameters = map[string]string{"host": "source-rw", "channel_binding": "disable"}
+		Expect(newCluster(ChannelBindingRequire, source).validateReplicaMode()).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("replica cluster streaming from a source standby validation", func() {
+	newCluster := func(source ExternalCluster) *Cluster {
+		return &Cluster{
+			Spec: ClusterSpec{
+				ImageName: "postgres:16",
+				ReplicaCluster: &ReplicaClusterConfiguration{
+					Enabled:             true,
+					Source:              "test",
+					PreferSourceStandby: true,
+				},
+				Bootstrap: &BootstrapConfiguration{
+					PgBaseBackup: &BootstrapPgBaseBackup{},
+				},
+				ExternalClusters: []ExternalCluster{source},
+			},
+		}
+	}
+	cascadingSource := ExternalCluster{
+		Name:                 "test",
+		ConnectionParameters: map[string]string{"host": "source-ro,source-rw"},
+	}
+
+	It("accepts a source listing its read-only endpoint", func() {
+		Expect(newCluster(cascadingSource).validateReplicaMode()).To(BeEmpty())
+	})
+
+	It("complains about PostgreSQL versions not preferring the standby hosts", func() {
+		cluster := newCluster(cascadingSource)
+		cluster.Spec.ImageName = "postgres:13"
+		Expect(cluster.validateReplicaMode()).To(HaveLen(1))
+	})
+
+	It("complains when the source can't be reached through streaming", func() {
+		Expect(newCluster(ExternalCluster{Name: "test"}).validateReplicaMode()).To(HaveLen(1))
+	})
+
+	It("complains when the connection parameters already set the target session attributes", func() {
+		source := cascadingSource
+		source.ConnectionParameters = map[string]string{
+			"host":                 "source-ro,source-rw",
+			"target_session_attrs": "read-write",
+		}
+		Expect(newCluster(source).validateReplicaMode()).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("replica cluster recovery target validation", func() {
+	newCluster := func(target *ReplicaRecoveryTarget) *Cluster {
+		return &Cluster{
+			Spec: ClusterSpec{
+				ReplicaCluster: &ReplicaClusterConfiguration{
+					Enabled:        true,
+					Source:         "test",
+					RecoveryTarget: target,
+				},
+				Bootstrap: &BootstrapConfiguration{
+					Recovery: &BootstrapRecovery{Source: "test"},
+				},
+				ExternalClusters: []ExternalCluster{{Name: "test"}},
+			},
+		}
+	}
+
+	It("accepts a target time or a target LSN", func() {
+		Expect(newCluster(&ReplicaRecoveryTarget{TargetTime: "2023-10-01T12:00:00Z"}).validateReplicaMode()).
+			To(BeEmpty())
+		Expect(newCluster(&ReplicaRecoveryTarget{TargetLSN: "0/6000028"}).validateReplicaMode()).To(BeEmpty())
+	})
+
+	It("complains when no target or more than one target is set", func() {
+		Expect(newCluster(&ReplicaRecoveryTarget{}).validateReplicaMode()).To(HaveLen(1))
+		Expect(newCluster(&ReplicaRecoveryTarget{
+			TargetTime: "2023-10-01T12:00:00Z",
+			TargetLSN:  "0/6000028",
+		}).validateReplicaMode()).To(HaveLen(1))
+	})
+
+	It("complains about invalid targets", func() {
+		Expect(newCluster(&ReplicaRecoveryTarget{TargetTime: "yesterday"}).validateReplicaMode()).To(HaveLen(1))
+		Expect(newCluster(&ReplicaRecoveryTarget{TargetLSN: "not-an-lsn"}).validateReplicaMode()).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("Validation changes", func() {
+	It("doesn't complain if given old cluster is nil", func() {
+		newCluster := &Cluster{}
+		err := newCluster.ValidateChanges(nil)
+		Expect(err).To(BeNil())
+	})
+})
+
+var _ = Describe("Backup validation", func() {
+	It("complain if there's no credentials", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					BarmanObjectStore: &BarmanObjectStoreConfiguration{},
+				},
+			},
+		}
+		err := cluster.validateBackupConfiguration()
+		Expect(err).To(HaveLen(1))
+	})
+
+	It("doesn't complain if given policy is not provided", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{},
+			},
+		}
+		err := cluster.validateBackupConfiguration()
+		Expect(err).To(BeNil())
+	})
+
+	It("doesn't complain if given policy is valid", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					RetentionPolicy: "90d",
+				},
+			},
+		}
+		err := cluster.validateBackupConfiguration()
+		Expect(err).To(BeNil())
+	})
+
+	It("complain if a given policy is not valid", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					BarmanObjectStore: &BarmanObjectStoreConfiguration{},
+					RetentionPolicy:   "09",
+				},
+			},
+		}
+		err := cluster.validateBackupConfiguration()
+		Expect(err).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("Backup protection validation", func() {
+	It("doesn't complain if the backup protection is not enabled", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{},
+			},
+		}
+		Expect(cluster.validateBackupProtection()).To(BeNil())
+	})
+
+	It("doesn't complain if the maximum age is valid", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					BackupProtectionMaxAge: "36h",
+				},
+			},
+		}
+		Expect(cluster.validateBackupProtection()).To(BeNil())
+	})
+
+	It("complains if the maximum age is not valid", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					BackupProtectionMaxAge: "3m",
+				},
+			},
+		}
+		Expect(cluster.validateBackupProtection()).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("validate volume snapshot retention", func() {
+	It("doesn't complain if the retention is not specified", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					VolumeSnapshot: &VolumeSnapshotConfiguration{},
+				},
+			},
+		}
+		Expect(cluster.validateVolumeSnapshotRetention()).To(BeEmpty())
+	})
+
+	It("doesn't complain if the data and WAL retentions are valid", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					VolumeSnapshot: &VolumeSnapshotConfiguration{
+						Retention:    &VolumeSnapshotRetention{MaxCount: 7, MaxAge: "4w"},
+						WalRetention: &VolumeSnapshotRetention{MaxCount: 2},
+					},
+				},
+			},
+		}
+		Expect(cluster.validateVolumeSnapshotRetention()).To(BeEmpty())
+	})
+
+	It("complains about invalid retentions", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					VolumeSnapshot: &VolumeSnapshotConfiguration{
+						Retention:       &VolumeSnapshotRetention{MaxAge: "1m"},
+						ManualRetention: &VolumeSnapshotRetention{MaxAge: "1y"},
+						WalRetention:    &VolumeSnapshotRetention{MaxCount: -1},
+					},
+				},
+			},
+		}
+		Expect(cluster.validateVolumeSnapshotRetention()).To(HaveLen(3))
+	})
+
+	It("complains about negative time buckets", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					VolumeSnapshot: &VolumeSnapshotConfiguration{
+						Retention: &VolumeSnapshotRetention{KeepDaily: 7, KeepWeekly: -1, KeepYearly: -2},
+					},
+				},
+			},
+		}
+		Expect(cluster.validateVolumeSnapshotRetention()).To(HaveLen(2))
+	})
+
+	It("complains about an invalid maximum restorable age", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					VolumeSnapshot: &VolumeSnapshotConfiguration{
+						MaxRestorableAge: "3m",
+					},
 				},
 			},
 		}
-		Expect(cluster.validateReplicaMode()).To(BeEmpty())
-		Expect(cluster.validateReplicaModeChange(oldCluster)).ToNot(BeEmpty())
-	})
-})
+		Expect(cluster.validateVolumeSnapshotRetention()).To(HaveLen(1))
 
-var _ = Describe("Validation changes", func() {
-	It("doesn't complain if given old cluster is nil", func() {
-		newCluster := &Cluster{}
-		err := newCluster.ValidateChanges(nil)
-		Expect(err).To(BeNil())
+		cluster.Spec.Backup.VolumeSnapshot.MaxRestorableAge = "7d"
+		Expect(cluster.validateVolumeSnapshotRetention()).To(BeEmpty())
 	})
 })
 
-var _ = Describe("Backup validation", func() {
-	It("complain if there's no credentials", func() {
+var _ = Describe("validate volume snapshot fencing requirements", func() {
+	It("accepts the same fencing requirement for every volume", func() {
 		cluster := &Cluster{
 			Spec: ClusterSpec{
+				WalStorage: &StorageConfiguration{},
 				Backup: &BackupConfiguration{
-					BarmanObjectStore: &BarmanObjectStoreConfiguration{},
+					VolumeSnapshot: &VolumeSnapshotConfiguration{
+						FencingRequirements: []VolumeSnapshotFencingRequirement{
+							{Role: "PG_DATA", FencingRequired: false},
+							{Role: "PG_WAL", FencingRequired: false},
+						},
+					},
 				},
 			},
 		}
-		err := cluster.validateBackupConfiguration()
-		Expect(err).To(HaveLen(1))
+		Expect(cluster.validateVolumeSnapshotFencingRequirements()).To(BeEmpty())
 	})
 
-	It("doesn't complain if given policy is not provided", func() {
+	It("complains about mixed fencing requirements", func() {
 		cluster := &Cluster{
 			Spec: ClusterSpec{
-				Backup: &BackupConfiguration{},
+				WalStorage: &StorageConfiguration{},
+				Backup: &BackupConfiguration{
+					VolumeSnapshot: &VolumeSnapshotConfiguration{
+						FencingRequirements: []VolumeSnapshotFencingRequirement{
+							{Role: "PG_WAL", FencingRequired: false},
+						},
+					},
+				},
 			},
 		}
-		err := cluster.validateBackupConfiguration()
-		Expect(err).To(BeNil())
+		Expect(cluster.validateVolumeSnapshotFencingRequirements()).To(HaveLen(1))
 	})
 
-	It("doesn't complain if given policy is valid", func() {
+	It("ignores the PG_WAL requirement when there's no WAL volume", func() {
 		cluster := &Cluster{
 			Spec: ClusterSpec{
 				Backup: &BackupConfiguration{
-					RetentionPolicy: "90d",
+					VolumeSnapshot: &VolumeSnapshotConfiguration{
+						FencingRequirements: []VolumeSnapshotFencingRequirement{
+							{Role: "PG_DATA", FencingRequired: false},
+						},
+					},
 				},
 			},
 		}
-		err := cluster.validateBackupConfiguration()
-		Expect(err).To(BeNil())
+		Expect(cluster.validateVolumeSnapshotFencingRequirements()).To(BeEmpty())
 	})
 
-	It("complain if a given policy is not valid", func() {
+	It("complains about roles specified more than once", func() {
 		cluster := &Cluster{
 			Spec: ClusterSpec{
 				Backup: &BackupConfiguration{
-					BarmanObjectStore: &BarmanObjectStoreConfiguration{},
-					RetentionPolicy:   "09",
+					VolumeSnapshot: &VolumeSnapshotConfiguration{
+						FencingRequirements: []VolumeSnapshotFencingRequirement{
+							{Role: "PG_WAL", FencingRequired: true},
+							{Role: "PG_WAL", FencingRequired: false},
+						},
+					},
 				},
 			},
 		}
-		err := cluster.validateBackupConfiguration()
-		Expect(err).To(HaveLen(2))
+		Expect(cluster.validateVolumeSnapshotFencingRequirements()).To(HaveLen(1))
 	})
 })
 
@@ -2609,6 +3249,43 @@ var _ = Describe("validation of replication slots configuration", func() {
 		Expect(result).To(BeEmpty())
 	})
 
+	It("prevents using temporary replication slots on PostgreSQL 12 and older", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				ImageName: "ghcr.io/cloudnative-pg/postgresql:12.16",
+				ReplicationSlots: &ReplicationSlotsConfiguration{
+					HighAvailability: &ReplicationSlotsHAConfiguration{
+						Enabled:  ptr.To(true),
+						SlotType: ReplicationSlotTypeTemporary,
+					},
+				},
+			},
+		}
+		cluster.Default()
+
+		result := cluster.validateReplicationSlots()
+		Expect(result).To(HaveLen(1))
+		Expect(result[0].Field).To(Equal("spec.replicationSlots.highAvailability.slotType"))
+	})
+
+	It("allows temporary replication slots on the default PostgreSQL image", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				ImageName: versions.DefaultImageName,
+				ReplicationSlots: &ReplicationSlotsConfiguration{
+					HighAvailability: &ReplicationSlotsHAConfiguration{
+						Enabled:  ptr.To(true),
+						SlotType: ReplicationSlotTypeTemporary,
+					},
+				},
+			},
+		}
+		cluster.Default()
+
+		result := cluster.validateReplicationSlots()
+		Expect(result).To(BeEmpty())
+	})
+
 	It("allows enabling replication slots on the fly", func() {
 		oldCluster := &Cluster{
 			Spec: ClusterSpec{
@@ -2959,6 +3636,26 @@ var _ = Describe("Managed Extensions validation", func() {
 		}
 		Expect(cluster.validatePgFailoverSlots()).To(HaveLen(1))
 	})
+
+	It("should produce an error if pg_failover_slots is enabled with temporary HA slots", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				ReplicationSlots: &ReplicationSlotsConfiguration{
+					HighAvailability: &ReplicationSlotsHAConfiguration{
+						Enabled:  ptr.To(true),
+						SlotType: ReplicationSlotTypeTemporary,
+					},
+				},
+				PostgresConfiguration: PostgresConfiguration{
+					Parameters: map[string]string{
+						"hot_standby_feedback":                     "on",
+						"pg_failover_slots.synchronize_slot_names": "my_slot",
+					},
+				},
+			},
+		}
+		Expect(cluster.validatePgFailoverSlots()).To(HaveLen(1))
+	})
 })
 
 var _ = Describe("Recovery from volume snapshot validation", func() {
@@ -3059,6 +3756,64 @@ var _ = Describe("Recovery from volume snapshot validation", func() {
 		Expect(cluster.validateBootstrapRecoveryDataSource()).To(BeEmpty())
 	})
 
+	It("should refuse post-restore queries when not recovering from volume snapshots", func() {
+		cluster := Cluster{
+			Spec: ClusterSpec{
+				Bootstrap: &BootstrapConfiguration{
+					Recovery: &BootstrapRecovery{
+						Source:                    "origin",
+						PostRestoreApplicationSQL: []string{"DELETE FROM customers"},
+					},
+				},
+			},
+		}
+		Expect(cluster.validatePostRestoreApplicationSQL()).To(HaveLen(1))
+	})
+
+	It("should refuse post-restore queries in a replica cluster", func() {
+		cluster := Cluster{
+			Spec: ClusterSpec{
+				Bootstrap: &BootstrapConfiguration{
+					Recovery: &BootstrapRecovery{
+						VolumeSnapshots: &DataSource{
+							Storage: corev1.TypedLocalObjectReference{
+								APIGroup: ptr.To(""),
+								Kind:     "PersistentVolumeClaim",
+								Name:     "pgdata",
+							},
+						},
+						PostRestoreApplicationSQL: []string{"DELETE FROM customers"},
+					},
+				},
+				ReplicaCluster: &ReplicaClusterConfiguration{
+					Enabled: true,
+					Source:  "origin",
+				},
+			},
+		}
+		Expect(cluster.validatePostRestoreApplicationSQL()).To(HaveLen(1))
+	})
+
+	It("should accept post-restore queries when recovering from volume snapshots", func() {
+		cluster := Cluster{
+			Spec: ClusterSpec{
+				Bootstrap: &BootstrapConfiguration{
+					Recovery: &BootstrapRecovery{
+						VolumeSnapshots: &DataSource{
+							Storage: corev1.TypedLocalObjectReference{
+								APIGroup: ptr.To(""),
+								Kind:     "PersistentVolumeClaim",
+								Name:     "pgdata",
+							},
+						},
+						PostRestoreApplicationSQL: []string{"DELETE FROM customers"},
+					},
+				},
+			},
+		}
+		Expect(cluster.validatePostRestoreApplicationSQL()).To(BeEmpty())
+	})
+
 	It("accepts recovery from a VolumeSnapshot", func() {
 		cluster := clusterFromRecovery(&BootstrapRecovery{
 			VolumeSnapshots: &DataSource{
@@ -3229,3 +3984,226 @@ var _ = Describe("validateResources", func() {
 		Expect(errors).To(BeEmpty())
 	})
 })
+
+var _ = Describe("validation of the labels required on volume snapshots", func() {
+	newCluster := func(requiredLabels map[string]string) *Cluster {
+		return &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					VolumeSnapshot: &VolumeSnapshotConfiguration{
+						RequiredLabels: requiredLabels,
+					},
+				},
+			},
+		}
+	}
+
+	It("accepts valid labels", func() {
+		cluster := newCluster(map[string]string{
+			"compliance.example.com/scan": "enabled",
+			"team":                        "dba",
+		})
+		Expect(cluster.validateVolumeSnapshotRequiredLabels()).To(BeEmpty())
+	})
+
+	It("refuses the labels managed by the operator or by Kubernetes", func() {
+		cluster := newCluster(map[string]string{
+			"cnpg.io/backupName":          "backup",
+			"kubernetes.io/hostname":      "node",
+			"compliance.example.com/scan": "enabled",
+		})
+		Expect(cluster.validateVolumeSnapshotRequiredLabels()).To(HaveLen(2))
+	})
+
+	It("refuses invalid label keys and values", func() {
+		cluster := newCluster(map[string]string{
+			"not a key": "enabled",
+			"team":      "not a value",
+		})
+		Expect(cluster.validateVolumeSnapshotRequiredLabels()).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("volume snapshot metadata placeholders validation", func() {
+	newCluster := func(labels, annotations map[string]string) *Cluster {
+		return &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					VolumeSnapshot: &VolumeSnapshotConfiguration{
+						Labels:      labels,
+						Annotations: annotations,
+					},
+				},
+			},
+		}
+	}
+
+	It("accepts the known placeholders", func() {
+		cluster := newCluster(
+			map[string]string{"policy": "${cluster.namespace}-${cluster.name}"},
+			map[string]string{"storage.example.com/tag": "${backup.name}/${pvc.role}@${backup.timestamp}"},
+		)
+		Expect(cluster.validateVolumeSnapshotMetadataPlaceholders()).To(BeEmpty())
+	})
+
+	It("refuses the unknown and the unterminated placeholders", func() {
+		cluster := newCluster(
+			map[string]string{"policy": "${cluster.uid}"},
+			map[string]string{"storage.example.com/tag": "${instance.name"},
+		)
+		Expect(cluster.validateVolumeSnapshotMetadataPlaceholders()).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("volume snapshot remote target validation", func() {
+	newCluster := func(ownerReference SnapshotOwnerReference) *Cluster {
+		return &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					VolumeSnapshot: &VolumeSnapshotConfiguration{
+						SnapshotOwnerReference: ownerReference,
+						RemoteTarget: &VolumeSnapshotRemoteTarget{
+							KubeconfigSecret: SecretKeySelector{
+								LocalObjectReference: LocalObjectReference{Name: "remote-kubeconfig"},
+								Key:                  "kubeconfig",
+							},
+							ClusterName: "cluster-replica",
+						},
+					},
+				},
+			},
+		}
+	}
+
+	It("accepts a remote target without owner references", func() {
+		Expect(newCluster(ShapshotOwnerReferenceNone).validateVolumeSnapshotRemoteTarget()).To(BeEmpty())
+		Expect(newCluster("").validateVolumeSnapshotRemoteTarget()).To(BeEmpty())
+	})
+
+	It("complains about the owner references of remote snapshots", func() {
+		Expect(newCluster(SnapshotOwnerReferenceCluster).validateVolumeSnapshotRemoteTarget()).To(HaveLen(1))
+	})
+
+	It("complains about a missing kubeconfig", func() {
+		cluster := newCluster(ShapshotOwnerReferenceNone)
+		cluster.Spec.Backup.VolumeSnapshot.RemoteTarget.KubeconfigSecret.Key = ""
+		Expect(cluster.validateVolumeSnapshotRemoteTarget()).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("volume snapshot reuse window validation", func() {
+	newCluster := func(ownerReference SnapshotOwnerReference) *Cluster {
+		return &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					VolumeSnapshot: &VolumeSnapshotConfiguration{
+						ReuseWindow:            3600,
+						SnapshotOwnerReference: ownerReference,
+					},
+				},
+			},
+		}
+	}
+
+	It("accepts reused snapshots not owned by a backup", func() {
+		Expect(newCluster(ShapshotOwnerReferenceNone).validateVolumeSnapshotReuseWindow()).To(BeEmpty())
+		Expect(newCluster(SnapshotOwnerReferenceCluster).validateVolumeSnapshotReuseWindow()).To(BeEmpty())
+	})
+
+	It("complains about reused snapshots owned by a backup", func() {
+		Expect(newCluster(SnapshotOwnerReferenceBackup).validateVolumeSnapshotReuseWindow()).To(HaveLen(1))
+	})
+
+	It("complains about reused snapshots owned by a backup by default", func() {
+		originalDefault := configuration.Current.SnapshotOwnerReference
+		DeferCleanup(func() {
+			configuration.Current.SnapshotOwnerReference = originalDefault
+		})
+		configuration.Current.SnapshotOwnerReference = string(SnapshotOwnerReferenceBackup)
+
+		Expect(newCluster("").validateVolumeSnapshotReuseWindow()).To(HaveLen(1))
+		Expect(newCluster(ShapshotOwnerReferenceNone).validateVolumeSnapshotReuseWindow()).To(BeEmpty())
+	})
+
+	It("ignores the owner references when the snapshots are never reused", func() {
+		cluster := newCluster(SnapshotOwnerReferenceBackup)
+		cluster.Spec.Backup.VolumeSnapshot.ReuseWindow = 0
+		Expect(cluster.validateVolumeSnapshotReuseWindow()).To(BeEmpty())
+	})
+})
+
+var _ = Describe("volume snapshot creation retry validation", func() {
+	newCluster := func(patterns ...string) *Cluster {
+		return &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					VolumeSnapshot: &VolumeSnapshotConfiguration{
+						ClassName:     "csi-snapclass",
+						CreationRetry: &VolumeSnapshotCreationRetry{ErrorPatterns: patterns},
+					},
+				},
+			},
+		}
+	}
+
+	It("accepts the default patterns", func() {
+		Expect(newCluster().validateVolumeSnapshotCreationRetry()).To(BeEmpty())
+	})
+
+	It("accepts valid regular expressions", func() {
+		Expect(newCluster("rate ?limit", "^503 .*unavailable").validateVolumeSnapshotCreationRetry()).To(BeEmpty())
+	})
+
+	It("complains about invalid regular expressions", func() {
+		result := newCluster("rate limit", "throttl(").validateVolumeSnapshotCreationRetry()
+		Expect(result).To(HaveLen(1))
+		Expect(result[0].Field).To(Equal("spec.backup.volumeSnapshot.creationRetry.errorPatterns"))
+	})
+})
+
+var _ = Describe("online volume snapshot validation", func() {
+	var cluster *Cluster
+
+	BeforeEach(func() {
+		cluster = &Cluster{
+			Spec: ClusterSpec{
+				Backup: &BackupConfiguration{
+					BarmanObjectStore: &BarmanObjectStoreConfiguration{
+						DestinationPath: "s3://bucket/path",
+					},
+					VolumeSnapshot: &VolumeSnapshotConfiguration{
+						ClassName: "csi-snapclass",
+						Online:    true,
+					},
+				},
+			},
+		}
+	})
+
+	It("accepts online backups with a WAL archive", func() {
+		Expect(cluster.validateVolumeSnapshotOnline()).To(BeEmpty())
+	})
+
+	It("ignores offline backups", func() {
+		cluster.Spec.Backup.VolumeSnapshot.Online = false
+		cluster.Spec.Backup.BarmanObjectStore = nil
+		Expect(cluster.validateVolumeSnapshotOnline()).To(BeEmpty())
+	})
+
+	It("complains about a missing WAL archive", func() {
+		cluster.Spec.Backup.BarmanObjectStore = nil
+		Expect(cluster.validateVolumeSnapshotOnline()).To(HaveLen(1))
+	})
+
+	It("complains about online backups of a remote target", func() {
+		cluster.Spec.Backup.VolumeSnapshot.RemoteTarget = &VolumeSnapshotRemoteTarget{
+			ClusterName: "cluster-replica",
+		}
+		Expect(cluster.validateVolumeSnapshotOnline()).To(HaveLen(1))
+	})
+
+	It("complains about reused snapshots", func() {
+		cluster.Spec.Backup.VolumeSnapshot.ReuseWindow = 3600
+		Expect(cluster.validateVolumeSnapshotOnline()).To(HaveLen(1))
+	})
+})
@@ -66,6 +66,25 @@ type ScheduledBackupSpec struct {
 	// +kubebuilder:validation:Enum=barmanObjectStore;volumeSnapshot
 	// +kubebuilder:default:=barmanObjectStore
 	Method BackupMethod `json:"method,omitempty"`
+
+	// The maximum time in seconds, since the backup started, for the volume
+	// snapshot backups to complete. Defaults to 43200 seconds (12 hours)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Timeout int32 `json:"timeout,omitempty"`
+
+	// Whether the backups are skipped while the cluster is being
+	// bootstrapped or restored, as they would back up an incomplete
+	// cluster. The schedule resumes once the cluster is set up.
+	// Defaults to `true`
+	// +optional
+	PauseDuringBootstrap *bool `json:"pauseDuringBootstrap,omitempty"`
+
+	// The type of owner reference the volume snapshots taken by the
+	// backups should have, overriding the one of the cluster
+	// +optional
+	// +kubebuilder:validation:Enum=none;cluster;backup
+	SnapshotOwnerReference SnapshotOwnerReference `json:"snapshotOwnerReference,omitempty"`
 }
 
 // ScheduledBackupStatus defines the observed state of ScheduledBackup
@@ -81,6 +100,14 @@ type ScheduledBackupStatus struct {
 	// Next time we will run a backup
 	// +optional
 	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// The time of the last scheduled backup which has been skipped
+	// +optional
+	LastSkippedTime *metav1.Time `json:"lastSkippedTime,omitempty"`
+
+	// Why the last scheduled backup has been skipped
+	// +optional
+	LastSkipReason string `json:"lastSkipReason,omitempty"`
 }
 
 // +genclient
@@ -136,6 +163,16 @@ func (scheduledBackup ScheduledBackup) IsImmediate() bool {
 	return *scheduledBackup.Spec.Immediate
 }
 
+// IsPausedDuringBootstrap checks whether the backups are skipped while the
+// cluster is being bootstrapped or restored
+func (scheduledBackup ScheduledBackup) IsPausedDuringBootstrap() bool {
+	if scheduledBackup.Spec.PauseDuringBootstrap == nil {
+		return true
+	}
+
+	return *scheduledBackup.Spec.PauseDuringBootstrap
+}
+
 // GetName gets the scheduled backup name
 func (scheduledBackup *ScheduledBackup) GetName() string {
 	return scheduledBackup.Name
@@ -164,9 +201,11 @@ func (scheduledBackup *ScheduledBackup) CreateBackup(name string) *Backup {
 			Namespace: scheduledBackup.Namespace,
 		},
 		Spec: BackupSpec{
-			Cluster: scheduledBackup.Spec.Cluster,
-			Target:  scheduledBackup.Spec.Target,
-			Method:  scheduledBackup.Spec.Method,
+			Cluster:                scheduledBackup.Spec.Cluster,
+			Target:                 scheduledBackup.Spec.Target,
+			Method:                 scheduledBackup.Spec.Method,
+			Timeout:                scheduledBackup.Spec.Timeout,
+			SnapshotOwnerReference: scheduledBackup.Spec.SnapshotOwnerReference,
 		},
 	}
 	utils.InheritAnnotations(&backup.ObjectMeta, scheduledBackup.Annotations, nil, configuration.Current)
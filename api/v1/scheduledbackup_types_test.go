@@ -47,6 +47,12 @@ var _ = Describe("Scheduled backup", func() {
 		Expect(backup.Spec.Target).To(BeEmpty())
 	})
 
+	It("properly creates a backup with the snapshot owner reference", func() {
+		scheduledBackup.Spec.SnapshotOwnerReference = SnapshotOwnerReferenceCluster
+		backup := scheduledBackup.CreateBackup("test")
+		Expect(backup.Spec.SnapshotOwnerReference).To(Equal(SnapshotOwnerReferenceCluster))
+	})
+
 	It("properly creates a backup with standby target", func() {
 		scheduledBackup.Spec.Target = BackupTargetStandby
 		backup := scheduledBackup.CreateBackup("test")
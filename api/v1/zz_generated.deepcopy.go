@@ -139,6 +139,21 @@ func (in *Backup) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupAttempt) DeepCopyInto(out *BackupAttempt) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupAttempt.
+func (in *BackupAttempt) DeepCopy() *BackupAttempt {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupAttempt)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupConfiguration) DeepCopyInto(out *BackupConfiguration) {
 	*out = *in
@@ -152,6 +167,11 @@ func (in *BackupConfiguration) DeepCopyInto(out *BackupConfiguration) {
 		*out = new(BarmanObjectStoreConfiguration)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Notification != nil {
+		in, out := &in.Notification, &out.Notification
+		*out = new(BackupNotificationConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupConfiguration.
@@ -196,6 +216,26 @@ func (in *BackupList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupNotificationConfiguration) DeepCopyInto(out *BackupNotificationConfiguration) {
+	*out = *in
+	if in.SigningSecret != nil {
+		in, out := &in.SigningSecret, &out.SigningSecret
+		*out = new(SecretKeySelector)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupNotificationConfiguration.
+func (in *BackupNotificationConfiguration) DeepCopy() *BackupNotificationConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupNotificationConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupSnapshotStatus) DeepCopyInto(out *BackupSnapshotStatus) {
 	*out = *in
@@ -204,6 +244,44 @@ func (in *BackupSnapshotStatus) DeepCopyInto(out *BackupSnapshotStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ReusedSnapshots != nil {
+		in, out := &in.ReusedSnapshots, &out.ReusedSnapshots
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Extensions != nil {
+		in, out := &in.Extensions, &out.Extensions
+		*out = make([]InstalledExtension, len(*in))
+		copy(*out, *in)
+	}
+	if in.FencedAt != nil {
+		in, out := &in.FencedAt, &out.FencedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.SourceReplication != nil {
+		in, out := &in.SourceReplication, &out.SourceReplication
+		*out = new(SourceReplicationStatus)
+		**out = **in
+	}
+	if in.DryRunSnapshots != nil {
+		in, out := &in.DryRunSnapshots, &out.DryRunSnapshots
+		*out = make([]DryRunSnapshot, len(*in))
+		copy(*out, *in)
+	}
+	if in.BackupLabelFile != nil {
+		in, out := &in.BackupLabelFile, &out.BackupLabelFile
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.TablespaceMapFile != nil {
+		in, out := &in.TablespaceMapFile, &out.TablespaceMapFile
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.ArchiveWaitStartedAt != nil {
+		in, out := &in.ArchiveWaitStartedAt, &out.ArchiveWaitStartedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSnapshotStatus.
@@ -276,6 +354,18 @@ func (in *BackupStatus) DeepCopyInto(out *BackupStatus) {
 		**out = **in
 	}
 	in.BackupSnapshotStatus.DeepCopyInto(&out.BackupSnapshotStatus)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TotalSize != nil {
+		in, out := &in.TotalSize, &out.TotalSize
+		*out = new(BackupTotalSize)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStatus.
@@ -288,6 +378,26 @@ func (in *BackupStatus) DeepCopy() *BackupStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupTotalSize) DeepCopyInto(out *BackupTotalSize) {
+	*out = *in
+	if in.SnapshotsWithoutSize != nil {
+		in, out := &in.SnapshotsWithoutSize, &out.SnapshotsWithoutSize
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupTotalSize.
+func (in *BackupTotalSize) DeepCopy() *BackupTotalSize {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupTotalSize)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BarmanCredentials) DeepCopyInto(out *BarmanCredentials) {
 	*out = *in
@@ -491,6 +601,11 @@ func (in *BootstrapRecovery) DeepCopyInto(out *BootstrapRecovery) {
 		*out = new(LocalObjectReference)
 		**out = **in
 	}
+	if in.PostRestoreApplicationSQL != nil {
+		in, out := &in.PostRestoreApplicationSQL, &out.PostRestoreApplicationSQL
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapRecovery.
@@ -627,7 +742,7 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 	if in.ReplicaCluster != nil {
 		in, out := &in.ReplicaCluster, &out.ReplicaCluster
 		*out = new(ReplicaClusterConfiguration)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.SuperuserSecret != nil {
 		in, out := &in.SuperuserSecret, &out.SuperuserSecret
@@ -805,6 +920,36 @@ func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.SourceStatus != nil {
+		in, out := &in.SourceStatus, &out.SourceStatus
+		*out = new(ReplicaSourceStatus)
+		**out = **in
+	}
+	if in.SourceBackupFresh != nil {
+		in, out := &in.SourceBackupFresh, &out.SourceBackupFresh
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ReplicaReseed != nil {
+		in, out := &in.ReplicaReseed, &out.ReplicaReseed
+		*out = new(ReplicaReseedStatus)
+		**out = **in
+	}
+	if in.LocalArchiveStatus != nil {
+		in, out := &in.LocalArchiveStatus, &out.LocalArchiveStatus
+		*out = new(ReplicaLocalArchiveStatus)
+		**out = **in
+	}
+	if in.HASlotPrefixes != nil {
+		in, out := &in.HASlotPrefixes, &out.HASlotPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BackupHistory != nil {
+		in, out := &in.BackupHistory, &out.BackupHistory
+		*out = make([]BackupAttempt, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStatus.
@@ -896,6 +1041,21 @@ func (in *DataSource) DeepCopy() *DataSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunSnapshot) DeepCopyInto(out *DryRunSnapshot) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunSnapshot.
+func (in *DryRunSnapshot) DeepCopy() *DryRunSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EmbeddedObjectMetadata) DeepCopyInto(out *EmbeddedObjectMetadata) {
 	*out = *in
@@ -1038,6 +1198,21 @@ func (in *ImportSource) DeepCopy() *ImportSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstalledExtension) DeepCopyInto(out *InstalledExtension) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstalledExtension.
+func (in *InstalledExtension) DeepCopy() *InstalledExtension {
+	if in == nil {
+		return nil
+	}
+	out := new(InstalledExtension)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InstanceID) DeepCopyInto(out *InstanceID) {
 	*out = *in
@@ -1298,6 +1473,26 @@ func (in *NodeMaintenanceWindow) DeepCopy() *NodeMaintenanceWindow {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OnlineConfiguration) DeepCopyInto(out *OnlineConfiguration) {
+	*out = *in
+	if in.WaitForArchive != nil {
+		in, out := &in.WaitForArchive, &out.WaitForArchive
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnlineConfiguration.
+func (in *OnlineConfiguration) DeepCopy() *OnlineConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(OnlineConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PasswordState) DeepCopyInto(out *PasswordState) {
 	*out = *in
@@ -1656,6 +1851,26 @@ func (in *PostgresConfiguration) DeepCopy() *PostgresConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrewarmConfiguration) DeepCopyInto(out *PrewarmConfiguration) {
+	*out = *in
+	if in.Relations != nil {
+		in, out := &in.Relations, &out.Relations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrewarmConfiguration.
+func (in *PrewarmConfiguration) DeepCopy() *PrewarmConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(PrewarmConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RecoveryTarget) DeepCopyInto(out *RecoveryTarget) {
 	*out = *in
@@ -1684,6 +1899,31 @@ func (in *RecoveryTarget) DeepCopy() *RecoveryTarget {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicaClusterConfiguration) DeepCopyInto(out *ReplicaClusterConfiguration) {
 	*out = *in
+	if in.FallbackSources != nil {
+		in, out := &in.FallbackSources, &out.FallbackSources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AutomaticReseed != nil {
+		in, out := &in.AutomaticReseed, &out.AutomaticReseed
+		*out = new(ReplicaReseedConfiguration)
+		**out = **in
+	}
+	if in.Prewarm != nil {
+		in, out := &in.Prewarm, &out.Prewarm
+		*out = new(PrewarmConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RecoveryTarget != nil {
+		in, out := &in.RecoveryTarget, &out.RecoveryTarget
+		*out = new(ReplicaRecoveryTarget)
+		**out = **in
+	}
+	if in.SourceBackupCheck != nil {
+		in, out := &in.SourceBackupCheck, &out.SourceBackupCheck
+		*out = new(ReplicaSourceBackupCheck)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaClusterConfiguration.
@@ -1696,6 +1936,96 @@ func (in *ReplicaClusterConfiguration) DeepCopy() *ReplicaClusterConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaLocalArchiveStatus) DeepCopyInto(out *ReplicaLocalArchiveStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaLocalArchiveStatus.
+func (in *ReplicaLocalArchiveStatus) DeepCopy() *ReplicaLocalArchiveStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaLocalArchiveStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaRecoveryTarget) DeepCopyInto(out *ReplicaRecoveryTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaRecoveryTarget.
+func (in *ReplicaRecoveryTarget) DeepCopy() *ReplicaRecoveryTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaRecoveryTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaReseedConfiguration) DeepCopyInto(out *ReplicaReseedConfiguration) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaReseedConfiguration.
+func (in *ReplicaReseedConfiguration) DeepCopy() *ReplicaReseedConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaReseedConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaReseedStatus) DeepCopyInto(out *ReplicaReseedStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaReseedStatus.
+func (in *ReplicaReseedStatus) DeepCopy() *ReplicaReseedStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaReseedStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaSourceBackupCheck) DeepCopyInto(out *ReplicaSourceBackupCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaSourceBackupCheck.
+func (in *ReplicaSourceBackupCheck) DeepCopy() *ReplicaSourceBackupCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaSourceBackupCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaSourceStatus) DeepCopyInto(out *ReplicaSourceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaSourceStatus.
+func (in *ReplicaSourceStatus) DeepCopy() *ReplicaSourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaSourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicationSlotsConfiguration) DeepCopyInto(out *ReplicationSlotsConfiguration) {
 	*out = *in
@@ -1894,6 +2224,11 @@ func (in *ScheduledBackupSpec) DeepCopyInto(out *ScheduledBackupSpec) {
 		**out = **in
 	}
 	out.Cluster = in.Cluster
+	if in.PauseDuringBootstrap != nil {
+		in, out := &in.PauseDuringBootstrap, &out.PauseDuringBootstrap
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledBackupSpec.
@@ -1921,6 +2256,10 @@ func (in *ScheduledBackupStatus) DeepCopyInto(out *ScheduledBackupStatus) {
 		in, out := &in.NextScheduleTime, &out.NextScheduleTime
 		*out = (*in).DeepCopy()
 	}
+	if in.LastSkippedTime != nil {
+		in, out := &in.LastSkippedTime, &out.LastSkippedTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledBackupStatus.
@@ -2009,6 +2348,21 @@ func (in *ServiceAccountTemplate) DeepCopy() *ServiceAccountTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SourceReplicationStatus) DeepCopyInto(out *SourceReplicationStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SourceReplicationStatus.
+func (in *SourceReplicationStatus) DeepCopy() *SourceReplicationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SourceReplicationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageConfiguration) DeepCopyInto(out *StorageConfiguration) {
 	*out = *in
@@ -2092,6 +2446,21 @@ func (in *Topology) DeepCopy() *Topology {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotArchiveVerification) DeepCopyInto(out *VolumeSnapshotArchiveVerification) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotArchiveVerification.
+func (in *VolumeSnapshotArchiveVerification) DeepCopy() *VolumeSnapshotArchiveVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotArchiveVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeSnapshotConfiguration) DeepCopyInto(out *VolumeSnapshotConfiguration) {
 	*out = *in
@@ -2109,6 +2478,93 @@ func (in *VolumeSnapshotConfiguration) DeepCopyInto(out *VolumeSnapshotConfigura
 			(*out)[key] = val
 		}
 	}
+	if in.InheritedLabelPrefixes != nil {
+		in, out := &in.InheritedLabelPrefixes, &out.InheritedLabelPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InheritedAnnotationPrefixes != nil {
+		in, out := &in.InheritedAnnotationPrefixes, &out.InheritedAnnotationPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequiredLabels != nil {
+		in, out := &in.RequiredLabels, &out.RequiredLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(VolumeSnapshotRetention)
+		**out = **in
+	}
+	if in.ManualRetention != nil {
+		in, out := &in.ManualRetention, &out.ManualRetention
+		*out = new(VolumeSnapshotRetention)
+		**out = **in
+	}
+	if in.WalRetention != nil {
+		in, out := &in.WalRetention, &out.WalRetention
+		*out = new(VolumeSnapshotRetention)
+		**out = **in
+	}
+	if in.FencingRequirements != nil {
+		in, out := &in.FencingRequirements, &out.FencingRequirements
+		*out = make([]VolumeSnapshotFencingRequirement, len(*in))
+		copy(*out, *in)
+	}
+	if in.QuietPeriod != nil {
+		in, out := &in.QuietPeriod, &out.QuietPeriod
+		*out = new(VolumeSnapshotQuietPeriod)
+		**out = **in
+	}
+	if in.MinHealthyStandbys != nil {
+		in, out := &in.MinHealthyStandbys, &out.MinHealthyStandbys
+		*out = new(VolumeSnapshotMinHealthyStandbys)
+		**out = **in
+	}
+	if in.MaxStandbyLag != nil {
+		in, out := &in.MaxStandbyLag, &out.MaxStandbyLag
+		*out = new(VolumeSnapshotMaxStandbyLag)
+		**out = **in
+	}
+	if in.PreflightCheck != nil {
+		in, out := &in.PreflightCheck, &out.PreflightCheck
+		*out = new(VolumeSnapshotPreflightCheck)
+		**out = **in
+	}
+	if in.RemoteTarget != nil {
+		in, out := &in.RemoteTarget, &out.RemoteTarget
+		*out = new(VolumeSnapshotRemoteTarget)
+		**out = **in
+	}
+	if in.OnlineConfiguration != nil {
+		in, out := &in.OnlineConfiguration, &out.OnlineConfiguration
+		*out = new(OnlineConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CreationRetry != nil {
+		in, out := &in.CreationRetry, &out.CreationRetry
+		*out = new(VolumeSnapshotCreationRetry)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ArchiveVerification != nil {
+		in, out := &in.ArchiveVerification, &out.ArchiveVerification
+		*out = new(VolumeSnapshotArchiveVerification)
+		**out = **in
+	}
+	if in.PreSnapshotCommands != nil {
+		in, out := &in.PreSnapshotCommands, &out.PreSnapshotCommands
+		*out = make([]VolumeSnapshotPreSnapshotCommand, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequeueBackoff != nil {
+		in, out := &in.RequeueBackoff, &out.RequeueBackoff
+		*out = new(VolumeSnapshotRequeueBackoff)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotConfiguration.
@@ -2121,6 +2577,162 @@ func (in *VolumeSnapshotConfiguration) DeepCopy() *VolumeSnapshotConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotCreationRetry) DeepCopyInto(out *VolumeSnapshotCreationRetry) {
+	*out = *in
+	if in.ErrorPatterns != nil {
+		in, out := &in.ErrorPatterns, &out.ErrorPatterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotCreationRetry.
+func (in *VolumeSnapshotCreationRetry) DeepCopy() *VolumeSnapshotCreationRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotCreationRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotFencingRequirement) DeepCopyInto(out *VolumeSnapshotFencingRequirement) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotFencingRequirement.
+func (in *VolumeSnapshotFencingRequirement) DeepCopy() *VolumeSnapshotFencingRequirement {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotFencingRequirement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotMaxStandbyLag) DeepCopyInto(out *VolumeSnapshotMaxStandbyLag) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotMaxStandbyLag.
+func (in *VolumeSnapshotMaxStandbyLag) DeepCopy() *VolumeSnapshotMaxStandbyLag {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotMaxStandbyLag)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotMinHealthyStandbys) DeepCopyInto(out *VolumeSnapshotMinHealthyStandbys) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotMinHealthyStandbys.
+func (in *VolumeSnapshotMinHealthyStandbys) DeepCopy() *VolumeSnapshotMinHealthyStandbys {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotMinHealthyStandbys)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotPreSnapshotCommand) DeepCopyInto(out *VolumeSnapshotPreSnapshotCommand) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotPreSnapshotCommand.
+func (in *VolumeSnapshotPreSnapshotCommand) DeepCopy() *VolumeSnapshotPreSnapshotCommand {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotPreSnapshotCommand)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotPreflightCheck) DeepCopyInto(out *VolumeSnapshotPreflightCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotPreflightCheck.
+func (in *VolumeSnapshotPreflightCheck) DeepCopy() *VolumeSnapshotPreflightCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotPreflightCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotQuietPeriod) DeepCopyInto(out *VolumeSnapshotQuietPeriod) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotQuietPeriod.
+func (in *VolumeSnapshotQuietPeriod) DeepCopy() *VolumeSnapshotQuietPeriod {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotQuietPeriod)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotRemoteTarget) DeepCopyInto(out *VolumeSnapshotRemoteTarget) {
+	*out = *in
+	out.KubeconfigSecret = in.KubeconfigSecret
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRemoteTarget.
+func (in *VolumeSnapshotRemoteTarget) DeepCopy() *VolumeSnapshotRemoteTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotRemoteTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotRequeueBackoff) DeepCopyInto(out *VolumeSnapshotRequeueBackoff) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRequeueBackoff.
+func (in *VolumeSnapshotRequeueBackoff) DeepCopy() *VolumeSnapshotRequeueBackoff {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotRequeueBackoff)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeSnapshotRetention) DeepCopyInto(out *VolumeSnapshotRetention) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeSnapshotRetention.
+func (in *VolumeSnapshotRetention) DeepCopy() *VolumeSnapshotRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeSnapshotRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WalBackupConfiguration) DeepCopyInto(out *WalBackupConfiguration) {
 	*out = *in
@@ -28,6 +28,7 @@ import (
 	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/plugin"
 	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/plugin/backup"
 	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/plugin/certificate"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/plugin/clone"
 	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/plugin/destroy"
 	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/plugin/fence"
 	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/plugin/fio"
@@ -92,6 +93,7 @@ func main() {
 	rootCmd.AddCommand(psql.NewCmd())
 	rootCmd.AddCommand(snapshot.NewCmd())
 	rootCmd.AddCommand(logs.NewCmd())
+	rootCmd.AddCommand(clone.NewCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -18,13 +18,17 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	"go.opentelemetry.io/otel"
 	corev1 "k8s.io/api/core/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
@@ -40,6 +44,8 @@ import (
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/conditions"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres"
+	postgresSpec "github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/backup/notification"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/backup/volumesnapshot"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/persistentvolumeclaim"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/resources/instance"
@@ -61,7 +67,9 @@ type BackupReconciler struct {
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
 
+	apiReader            client.Reader
 	instanceStatusClient *instance.StatusClient
+	remoteClients        remoteClientFactory
 }
 
 // NewBackupReconciler properly initializes the BackupReconciler
@@ -70,17 +78,24 @@ func NewBackupReconciler(mgr manager.Manager) *BackupReconciler {
 		Client:               mgr.GetClient(),
 		Scheme:               mgr.GetScheme(),
 		Recorder:             mgr.GetEventRecorderFor("cloudnative-pg-backup"),
+		apiReader:            mgr.GetAPIReader(),
 		instanceStatusClient: instance.NewStatusClient(),
+		remoteClients:        newRemoteClient,
 	}
 }
 
 // +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=backups,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=backups/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=clusters,verbs=get
-// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;create;watch;list
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;create;watch;list;patch;delete
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotclasses,verbs=get;watch;list
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotcontents,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=csinodes,verbs=get;watch;list
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;watch;list
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups="",resources=pods/exec,verbs=get;list;delete;patch;create;watch
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get
+// +kubebuilder:rbac:groups="",resources=resourcequotas,verbs=get;list;watch
 
 // Reconcile is the main reconciliation loop
 // nolint: gocognit
@@ -97,8 +112,28 @@ func (r *BackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	}
 
 	switch backup.Status.Phase {
-	case apiv1.BackupPhaseFailed, apiv1.BackupPhaseCompleted:
+	case apiv1.BackupPhaseCoalesced, apiv1.BackupPhaseValidated:
 		return ctrl.Result{}, nil
+	case apiv1.BackupPhaseFailed, apiv1.BackupPhaseCompleted:
+		if err := r.ensureBackupFenceIsRemoved(ctx, &backup); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.recordBackupAttempt(ctx, &backup); err != nil {
+			return ctrl.Result{}, err
+		}
+		notificationResult, err := r.notifyBackupOutcome(ctx, &backup)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.recordBackupCompletedEvent(ctx, &backup); err != nil {
+			return ctrl.Result{}, err
+		}
+		result, err := r.reconcileSnapshotRestorability(ctx, &backup)
+		if notificationResult.RequeueAfter > 0 &&
+			(result.RequeueAfter == 0 || notificationResult.RequeueAfter < result.RequeueAfter) {
+			result.RequeueAfter = notificationResult.RequeueAfter
+		}
+		return result, err
 	}
 
 	clusterName := backup.Spec.Cluster.Name
@@ -131,6 +166,37 @@ func (r *BackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 	contextLogger.Debug("Found cluster for backup", "cluster", clusterName)
 
+	if backup.Status.Phase == "" {
+		coalesced, err := r.coalesceDuplicateBackup(ctx, &cluster, &backup)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if coalesced {
+			return ctrl.Result{}, nil
+		}
+	}
+
+	if reason := getDryRunUnsupportedReason(&cluster, &backup); reason != "" {
+		r.Recorder.Event(&backup, "Warning", "DryRunUnsupported", reason)
+		tryFlagBackupAsFailed(ctx, r.Client, &backup, errors.New(reason))
+		return ctrl.Result{}, nil
+	}
+
+	// The target of the snapshots of a remote replica cluster doesn't
+	// belong to this cluster
+	if backup.Spec.Method == apiv1.BackupMethodVolumeSnapshot &&
+		cluster.Spec.Backup.VolumeSnapshot != nil &&
+		cluster.Spec.Backup.VolumeSnapshot.RemoteTarget != nil {
+		res, err := r.startRemoteSnapshotBackup(ctx, &cluster, &backup)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if res != nil {
+			return *res, nil
+		}
+		return ctrl.Result{}, nil
+	}
+
 	isRunning, err := r.isValidBackupRunning(ctx, &backup, &cluster)
 	if err != nil {
 		contextLogger.Error(err, "while running isValidBackupRunning")
@@ -142,35 +208,63 @@ func (r *BackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			return ctrl.Result{}, nil
 		}
 
+		if cluster.Spec.Backup.MaxParallelBackups > 0 {
+			canExecute, err := r.canExecuteParallelBackup(ctx, &cluster, &backup)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if !canExecute {
+				contextLogger.Info(
+					"Too many backups running or waiting to be started, retrying",
+					"targetBackup", backup.Name,
+					"maxParallelBackups", cluster.Spec.Backup.MaxParallelBackups,
+				)
+				return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+			}
+		}
+
 		r.Recorder.Eventf(&backup, "Normal", "Starting",
 			"Starting backup for cluster %v", cluster.Name)
 	}
 
 	origBackup := backup.DeepCopy()
-	// If no good running backups are found we elect a pod for the backup
-	pod, err := r.getBackupTargetPod(ctx, &cluster, &backup)
+	// A snapshot backup which has already been started keeps the instance it
+	// was assigned to, even if it's not ready anymore because of the fencing.
+	// This is what makes it possible to snapshot the primary instance of a
+	// single-instance cluster, where no other target can be elected
+	pod, err := r.getStartedSnapshotBackupTarget(ctx, &backup)
 	if err != nil {
-		if apierrs.IsNotFound(err) {
-			r.Recorder.Eventf(&backup, "Warning", "FindingPod",
-				"Couldn't find target pod %s, will retry in 30 seconds", cluster.Status.TargetPrimary)
-			contextLogger.Info("Couldn't find target pod, will retry in 30 seconds", "target",
+		return ctrl.Result{}, err
+	}
+	if pod != nil {
+		contextLogger.Info("found a previously elected pod, reusing it",
+			"targetPodName", pod.Name)
+	} else {
+		// If no good running backups are found we elect a pod for the backup
+		pod, err = r.getBackupTargetPod(ctx, &cluster, &backup)
+		if err != nil {
+			if apierrs.IsNotFound(err) {
+				r.Recorder.Eventf(&backup, "Warning", "FindingPod",
+					"Couldn't find target pod %s, will retry in 30 seconds", cluster.Status.TargetPrimary)
+				contextLogger.Info("Couldn't find target pod, will retry in 30 seconds", "target",
+					cluster.Status.TargetPrimary)
+				backup.Status.Phase = apiv1.BackupPhasePending
+				return ctrl.Result{RequeueAfter: 30 * time.Second}, r.Status().Patch(ctx, &backup, client.MergeFrom(origBackup))
+			}
+			tryFlagBackupAsFailed(ctx, r.Client, &backup, fmt.Errorf("while getting pod: %w", err))
+			r.Recorder.Eventf(&backup, "Warning", "FindingPod", "Error getting target pod: %s",
 				cluster.Status.TargetPrimary)
+			return ctrl.Result{}, nil
+		}
+		contextLogger.Debug("Found pod for backup", "pod", pod.Name)
+
+		if !utils.IsPodReady(*pod) {
+			contextLogger.Info("Not ready backup target, will retry in 30 seconds", "target", pod.Name)
 			backup.Status.Phase = apiv1.BackupPhasePending
+			r.Recorder.Eventf(&backup, "Warning", "BackupPending", "Backup target pod not ready: %s",
+				cluster.Status.TargetPrimary)
 			return ctrl.Result{RequeueAfter: 30 * time.Second}, r.Status().Patch(ctx, &backup, client.MergeFrom(origBackup))
 		}
-		tryFlagBackupAsFailed(ctx, r.Client, &backup, fmt.Errorf("while getting pod: %w", err))
-		r.Recorder.Eventf(&backup, "Warning", "FindingPod", "Error getting target pod: %s",
-			cluster.Status.TargetPrimary)
-		return ctrl.Result{}, nil
-	}
-	contextLogger.Debug("Found pod for backup", "pod", pod.Name)
-
-	if !utils.IsPodReady(*pod) {
-		contextLogger.Info("Not ready backup target, will retry in 30 seconds", "target", pod.Name)
-		backup.Status.Phase = apiv1.BackupPhasePending
-		r.Recorder.Eventf(&backup, "Warning", "BackupPending", "Backup target pod not ready: %s",
-			cluster.Status.TargetPrimary)
-		return ctrl.Result{RequeueAfter: 30 * time.Second}, r.Status().Patch(ctx, &backup, client.MergeFrom(origBackup))
 	}
 
 	switch backup.Spec.Method {
@@ -197,12 +291,8 @@ func (r *BackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 			return ctrl.Result{}, nil
 		}
 
-		if previousPod, err := backup.GetAssignedInstance(ctx, r.Client); err != nil {
-			return ctrl.Result{}, err
-		} else if previousPod != nil {
-			contextLogger.Info("found a previously elected pod, reusing it",
-				"targetPodName", pod.Name)
-			pod = previousPod
+		if backup.Spec.DryRun {
+			return r.reconcileSnapshotDryRun(ctx, pod, &cluster, &backup)
 		}
 
 		res, err := r.startSnapshotBackup(ctx, pod, &cluster, &backup)
@@ -220,6 +310,125 @@ func (r *BackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	return ctrl.Result{}, nil
 }
 
+// canExecuteParallelBackup checks whether a backup can start, given the
+// other backups of the cluster and the maximum number of backups which can
+// run at the same time
+func (r *BackupReconciler) canExecuteParallelBackup(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+) (bool, error) {
+	var clusterBackups apiv1.BackupList
+	if err := r.List(
+		ctx,
+		&clusterBackups,
+		client.InNamespace(backup.Namespace),
+		client.MatchingFields{clusterName: cluster.Name},
+	); err != nil {
+		return false, err
+	}
+
+	return clusterBackups.CanExecuteParallelBackup(
+		backup.Name,
+		int(cluster.Spec.Backup.MaxParallelBackups),
+	), nil
+}
+
+// coalesceDuplicateBackup checks whether a new backup is a duplicate of a
+// backup of the same cluster, requested with the same method shortly before,
+// and in this case marks it as coalesced instead of executing it. Returns
+// true when the backup has been coalesced
+func (r *BackupReconciler) coalesceDuplicateBackup(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+) (bool, error) {
+	window := cluster.Spec.Backup.GetCoalesceWindow()
+	if window == 0 {
+		return false, nil
+	}
+
+	var clusterBackups apiv1.BackupList
+	if err := r.List(
+		ctx,
+		&clusterBackups,
+		client.InNamespace(backup.Namespace),
+		client.MatchingFields{clusterName: cluster.Name},
+	); err != nil {
+		return false, err
+	}
+
+	original := findCoalescingBackup(backup, clusterBackups.Items, window)
+	if original == nil {
+		return false, nil
+	}
+
+	log.FromContext(ctx).Info("Coalescing duplicate backup",
+		"coalescedInto", original.Name,
+		"coalesceWindow", window)
+	r.Recorder.Eventf(backup, "Normal", "BackupCoalesced",
+		"Backup not executed, being a duplicate of backup %s requested within %s",
+		original.Name, window)
+
+	origBackup := backup.DeepCopy()
+	backup.Status.SetAsCoalesced(original.Name)
+	return true, r.Status().Patch(ctx, backup, client.MergeFrom(origBackup))
+}
+
+// findCoalescingBackup finds, among the passed ones, the backup the passed
+// backup is a duplicate of, i.e. a backup of the same cluster, using the
+// same method, requested no more than the given window before it. Failed
+// and coalesced backups, as well as dry-runs, are never considered, and nil
+// is returned when there is no such backup
+func findCoalescingBackup(backup *apiv1.Backup, backups []apiv1.Backup, window time.Duration) *apiv1.Backup {
+	var result *apiv1.Backup
+	for i := range backups {
+		candidate := &backups[i]
+		if candidate.Name == backup.Name ||
+			candidate.Namespace != backup.Namespace ||
+			candidate.Spec.Cluster.Name != backup.Spec.Cluster.Name ||
+			candidate.Spec.Method != backup.Spec.Method {
+			continue
+		}
+
+		if candidate.Status.Phase == apiv1.BackupPhaseFailed ||
+			candidate.Status.Phase == apiv1.BackupPhaseCoalesced {
+			continue
+		}
+
+		// a dry-run is never a duplicate of a backup, nor the other way
+		if candidate.Spec.DryRun || backup.Spec.DryRun {
+			continue
+		}
+
+		// only the backups requested before the passed one can be the
+		// original, the name breaking the ties between identical timestamps
+		if !isRequestedBefore(candidate, backup) {
+			continue
+		}
+
+		if backup.CreationTimestamp.Sub(candidate.CreationTimestamp.Time) > window {
+			continue
+		}
+
+		if result == nil || isRequestedBefore(candidate, result) {
+			result = candidate
+		}
+	}
+
+	return result
+}
+
+// isRequestedBefore tells whether the first backup has been requested
+// before the second one
+func isRequestedBefore(first, second *apiv1.Backup) bool {
+	if first.CreationTimestamp.Equal(&second.CreationTimestamp) {
+		return first.Name < second.Name
+	}
+
+	return first.CreationTimestamp.Before(&second.CreationTimestamp)
+}
+
 func (r *BackupReconciler) isValidBackupRunning(
 	ctx context.Context,
 	backup *apiv1.Backup,
@@ -310,7 +519,13 @@ func (r *BackupReconciler) startSnapshotBackup(
 		return nil, err
 	}
 
-	if !clusterBackups.CanExecuteBackup(backup.Name) {
+	canExecute := clusterBackups.CanExecuteBackup(backup.Name)
+	if maxParallelBackups := cluster.Spec.Backup.MaxParallelBackups; maxParallelBackups > 0 {
+		// the backups are limited, so every method must follow the same
+		// order not to overtake each other
+		canExecute = clusterBackups.CanExecuteParallelBackup(backup.Name, int(maxParallelBackups))
+	}
+	if !canExecute {
 		contextLogger.Info(
 			"A backup is already in progress or waiting to be started, retrying",
 			"targetBackup", backup.Name,
@@ -319,7 +534,97 @@ func (r *BackupReconciler) startSnapshotBackup(
 	}
 
 	if len(backup.Status.Phase) == 0 || backup.Status.Phase == apiv1.BackupPhasePending {
+		// A backup taken from a standby lagging too much behind the
+		// primary would be stale
+		freshPod, res, err := r.ensureStandbyIsFresh(ctx, cluster, backup, targetPod)
+		if res != nil || err != nil {
+			return res, err
+		}
+		targetPod = freshPod
+
+		// There's no point in fencing an instance for a backup that can't
+		// complete, so we wait for the CSI driver to recover
+		if cluster.Spec.Backup.VolumeSnapshot.IsDriverHealthCheckEnabled() {
+			if reason := volumesnapshot.CheckDriverHealth(ctx, r.Client, cluster, targetPod); reason != "" {
+				contextLogger.Info("CSI driver degraded, deferring the snapshot backup", "reason", reason)
+				r.Recorder.Eventf(backup, "Warning", "SnapshotDriverDegraded",
+					"Deferring the snapshot backup: %s", reason)
+				origBackup := backup.DeepCopy()
+				backup.Status.Phase = apiv1.BackupPhasePending
+				return &ctrl.Result{RequeueAfter: 30 * time.Second},
+					r.Status().Patch(ctx, backup, client.MergeFrom(origBackup))
+			}
+		}
+
+		// A snapshotter which is not responsive would leave the instance
+		// fenced until the backup times out, so we fail before fencing it
+		if cluster.Spec.Backup.VolumeSnapshot.PreflightCheck != nil {
+			if err := volumesnapshot.CheckSnapshotterResponsiveness(ctx, r.Client, cluster, targetPod); err != nil {
+				contextLogger.Info("Snapshotter pre-flight check failed", "err", err.Error())
+				r.Recorder.Eventf(backup, "Warning", "SnapshotterUnresponsive",
+					"Snapshot backup failed before fencing: %v", err)
+				tryFlagBackupAsFailed(ctx, r.Client, backup, fmt.Errorf("pre-flight check failed: %w", err))
+				return &ctrl.Result{}, nil
+			}
+		}
+
+		// A backup exceeding the snapshot quota would fail after fencing
+		// the instance, so we fail it before
+		if cluster.Spec.Backup.VolumeSnapshot.CheckSnapshotQuota {
+			pvcs, err := r.getSnapshotBackupPVCs(ctx, cluster, targetPod)
+			if err != nil {
+				return nil, err
+			}
+			if err := volumesnapshot.CheckSnapshotQuota(ctx, r.Client, cluster, targetPod, pvcs); err != nil {
+				contextLogger.Info("Snapshot quota exceeded", "err", err.Error())
+				r.Recorder.Eventf(backup, "Warning", "SnapshotQuotaExceeded",
+					"Snapshot backup failed before fencing: %v", err)
+				tryFlagBackupAsFailed(ctx, r.Client, backup, err)
+				return &ctrl.Result{}, nil
+			}
+		}
+
+		// An online backup doesn't fence the instance
+		online := cluster.Spec.Backup.VolumeSnapshot.Online
+
+		// Fencing the instance during a period of high write activity
+		// would have a bigger impact, so we wait for a quiet period
+		if !online && r.isWaitingForQuietPeriod(ctx, cluster, backup) {
+			origBackup := backup.DeepCopy()
+			backup.Status.Phase = apiv1.BackupPhasePending
+			return &ctrl.Result{RequeueAfter: 30 * time.Second},
+				r.Status().Patch(ctx, backup, client.MergeFrom(origBackup))
+		}
+
+		// A quiescent standby doesn't need to be fenced, and the decision
+		// is recorded in the backup status to be kept until its completion
+		skipFencing := !online && r.canSkipTargetFencing(ctx, cluster, backup, targetPod)
+
+		// Fencing a standby reduces the redundancy of the cluster, so we
+		// require enough other standbys to stay healthy meanwhile
+		if !online && !skipFencing {
+			if res, err := r.ensureMinHealthyStandbys(ctx, cluster, backup, targetPod); res != nil || err != nil {
+				return res, err
+			}
+		}
+
 		backup.Status.SetAsStarted(targetPod, apiv1.BackupMethodVolumeSnapshot)
+		backup.Status.SetReplicaSourceCluster(cluster)
+		backup.Status.BackupSnapshotStatus.FencingSkipped = skipFencing
+		backup.Status.BackupSnapshotStatus.Online = online
+		// the extensions are collected before the instance is fenced, as
+		// they can only be queried while PostgreSQL is running
+		extensions, err := r.instanceStatusClient.GetInstalledExtensionsFromInstance(ctx, targetPod)
+		if err != nil {
+			contextLogger.Error(err, "while querying the installed extensions")
+		}
+		backup.Status.BackupSnapshotStatus.Extensions = extensions
+		// the source is queried before the instance is fenced too, as
+		// fencing the designated primary stops the streaming
+		if cluster.IsReplica() && cluster.Spec.Backup.VolumeSnapshot.CaptureSourceReplication {
+			backup.Status.BackupSnapshotStatus.SourceReplication = r.getSourceReplication(
+				ctx, cluster, backup, targetPod)
+		}
 		// given that we use only kubernetes resources we can use the backup name as ID
 		backup.Status.BackupID = backup.Name
 		if err := postgres.PatchBackupStatusAndRetry(ctx, r.Client, backup); err != nil {
@@ -331,14 +636,20 @@ func (r *BackupReconciler) startSnapshotBackup(
 		contextLogger.Error(errCond, "Error while updating backup condition (backup starting)")
 	}
 
-	pvcs, err := persistentvolumeclaim.GetInstancePVCs(ctx, r.Client, targetPod.Name, cluster.Namespace)
+	pvcs, err := r.getSnapshotBackupPVCs(ctx, cluster, targetPod)
 	if err != nil {
-		return nil, fmt.Errorf("cannot get PVCs: %w", err)
+		return nil, err
 	}
 
+	requeueBackoff := cluster.Spec.Backup.VolumeSnapshot.RequeueBackoff
 	executor := volumesnapshot.
 		NewExecutorBuilder(r.Client, r.Recorder).
-		FenceInstance(true).
+		FenceInstance(!backup.Status.BackupSnapshotStatus.FencingSkipped).
+		TolerateFencedInstances(cluster.Spec.Backup.VolumeSnapshot.AreFencedInstancesTolerated()).
+		ReadyTimeout(cluster.Spec.Backup.VolumeSnapshot.GetReadyTimeout()).
+		RequeueBackoff(requeueBackoff.GetInterval(), requeueBackoff.GetMaxInterval()).
+		TracerProvider(otel.GetTracerProvider()).
+		LiveReader(r.apiReader).
 		Build()
 
 	res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
@@ -357,6 +668,9 @@ func (r *BackupReconciler) startSnapshotBackup(
 
 		r.Recorder.Eventf(backup, "Warning", "Error", "snapshot backup failed: %v", err)
 		tryFlagBackupAsFailed(ctx, r.Client, backup, fmt.Errorf("can't execute snapshot backup: %w", err))
+		if backup.Status.BackupSnapshotStatus.Online {
+			executor.AbortOnlineBackup(ctx, backup, targetPod)
+		}
 		return nil, executor.EnsurePodIsUnfenced(ctx, cluster, backup, targetPod)
 	}
 
@@ -379,11 +693,700 @@ func (r *BackupReconciler) startSnapshotBackup(
 	return nil, postgres.PatchBackupStatusAndRetry(ctx, r.Client, backup)
 }
 
+// getSnapshotBackupPVCs gets the PVCs of the target instance to be
+// snapshotted, including the ones backing generic ephemeral volumes
+func (r *BackupReconciler) getSnapshotBackupPVCs(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	targetPod *corev1.Pod,
+) ([]corev1.PersistentVolumeClaim, error) {
+	pvcs, err := persistentvolumeclaim.GetInstancePVCs(ctx, r.Client, targetPod.Name, cluster.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get PVCs: %w", err)
+	}
+
+	ephemeralPVCs, err := persistentvolumeclaim.GetPodEphemeralPVCs(ctx, r.Client, targetPod)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get ephemeral volume PVCs: %w", err)
+	}
+
+	return append(pvcs, ephemeralPVCs...), nil
+}
+
+// getDryRunUnsupportedReason returns why the passed backup can't be
+// executed as a dry-run, or an empty string if it can
+func getDryRunUnsupportedReason(cluster *apiv1.Cluster, backup *apiv1.Backup) string {
+	switch {
+	case !backup.Spec.DryRun:
+		return ""
+	case backup.Spec.Method != apiv1.BackupMethodVolumeSnapshot:
+		return "dry-run is only supported by the volumeSnapshot backup method"
+	case cluster.Spec.Backup.VolumeSnapshot != nil && cluster.Spec.Backup.VolumeSnapshot.RemoteTarget != nil:
+		return "dry-run is not supported for the backups of a remote target"
+	default:
+		return ""
+	}
+}
+
+// reconcileSnapshotDryRun validates a volume snapshot backup without
+// fencing the target instance nor taking any snapshot, and marks the
+// backup as validated, reporting the snapshots that would be taken
+func (r *BackupReconciler) reconcileSnapshotDryRun(
+	ctx context.Context,
+	targetPod *corev1.Pod,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+) (ctrl.Result, error) {
+	contextLogger := log.FromContext(ctx)
+
+	pvcs, err := r.getSnapshotBackupPVCs(ctx, cluster, targetPod)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	executor := volumesnapshot.
+		NewExecutorBuilder(r.Client, r.Recorder).
+		FenceInstance(true).
+		TolerateFencedInstances(cluster.Spec.Backup.VolumeSnapshot.AreFencedInstancesTolerated()).
+		DryRun(true).
+		Build()
+
+	_, err = executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+	if isErrorRetryable(err) {
+		contextLogger.Error(err, "detected retryable error while validating snapshot backup, retrying...")
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+	if err != nil {
+		contextLogger.Info("Snapshot backup dry-run failed", "err", err.Error())
+		r.Recorder.Eventf(backup, "Warning", "DryRunFailed", "snapshot backup dry-run failed: %v", err)
+		tryFlagBackupAsFailed(ctx, r.Client, backup, fmt.Errorf("dry-run of the snapshot backup failed: %w", err))
+		return ctrl.Result{}, nil
+	}
+
+	backup.Status.SetAsValidated()
+	backup.Status.Method = apiv1.BackupMethodVolumeSnapshot
+	return ctrl.Result{}, postgres.PatchBackupStatusAndRetry(ctx, r.Client, backup)
+}
+
+// isWaitingForQuietPeriod checks whether the snapshot backup needs to wait for
+// the write activity of the primary instance to drop below the configured
+// threshold. The backup is never deferred after the deadline has passed, or if
+// the write activity cannot be measured
+func (r *BackupReconciler) isWaitingForQuietPeriod(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+) bool {
+	contextLogger := log.FromContext(ctx)
+
+	quietPeriod := cluster.Spec.Backup.VolumeSnapshot.QuietPeriod
+	if quietPeriod == nil {
+		return false
+	}
+
+	waited := time.Since(backup.CreationTimestamp.Time)
+	if waited >= quietPeriod.GetDeadline() {
+		contextLogger.Info("Quiet period deadline passed, starting the snapshot backup")
+		return false
+	}
+
+	var primaryPod corev1.Pod
+	if err := r.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Status.CurrentPrimary},
+		&primaryPod,
+	); err != nil {
+		contextLogger.Error(err, "while getting the primary pod, not waiting for a quiet period")
+		return false
+	}
+
+	transactionRate, err := r.instanceStatusClient.GetTransactionRateFromInstance(ctx, &primaryPod)
+	if err != nil {
+		contextLogger.Error(err, "while measuring the write activity, not waiting for a quiet period")
+		return false
+	}
+
+	if !shouldWaitForQuietPeriod(quietPeriod, transactionRate, waited) {
+		return false
+	}
+
+	contextLogger.Info("Write activity too high, deferring the snapshot backup",
+		"transactionsPerSecond", transactionRate,
+		"maxTransactionsPerSecond", quietPeriod.MaxTransactionsPerSecond)
+	r.Recorder.Eventf(backup, "Normal", "WaitingForQuietPeriod",
+		"Deferring the snapshot backup: %.1f transactions per second on the primary, waiting for at most %d",
+		transactionRate, quietPeriod.MaxTransactionsPerSecond)
+	return true
+}
+
+// getSourceReplication gets the position of the designated primary of a
+// replica cluster as seen by its source. As this only adds context to the
+// backup, a failure is reported without preventing the backup
+func (r *BackupReconciler) getSourceReplication(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	targetPod *corev1.Pod,
+) *apiv1.SourceReplicationStatus {
+	contextLogger := log.FromContext(ctx)
+
+	designatedPrimary := targetPod
+	if cluster.Status.CurrentPrimary != targetPod.Name {
+		designatedPrimary = &corev1.Pod{}
+		if err := r.Get(
+			ctx,
+			client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.Status.CurrentPrimary},
+			designatedPrimary,
+		); err != nil {
+			contextLogger.Error(err, "while getting the designated primary pod, not capturing the source replication")
+			return nil
+		}
+	}
+
+	status, err := r.instanceStatusClient.GetSourceReplicationFromInstance(ctx, designatedPrimary)
+	if err != nil {
+		contextLogger.Error(err, "while querying the source replication")
+		r.Recorder.Eventf(backup, "Warning", "SourceReplicationUnavailable",
+			"Cannot capture the position of the designated primary as seen by the source %s: %v",
+			cluster.Spec.ReplicaCluster.Source, err)
+		return nil
+	}
+
+	return status
+}
+
+// shouldWaitForQuietPeriod tells whether a backup which already waited for the
+// passed time needs to keep waiting, given the current transaction rate
+func shouldWaitForQuietPeriod(
+	quietPeriod *apiv1.VolumeSnapshotQuietPeriod,
+	transactionRate float64,
+	waited time.Duration,
+) bool {
+	if quietPeriod == nil || waited >= quietPeriod.GetDeadline() {
+		return false
+	}
+
+	return transactionRate > float64(quietPeriod.MaxTransactionsPerSecond)
+}
+
+// ensureStandbyIsFresh checks whether the target standby of the backup is
+// lagging too much behind the primary. When this happens, depending on the
+// configured policy, the least lagging standby within the threshold is
+// returned as the new target, or the backup is flagged as failed. A nil
+// result means the backup can proceed on the returned Pod
+func (r *BackupReconciler) ensureStandbyIsFresh(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	targetPod *corev1.Pod,
+) (*corev1.Pod, *ctrl.Result, error) {
+	contextLogger := log.FromContext(ctx)
+
+	maxStandbyLag := cluster.Spec.Backup.VolumeSnapshot.MaxStandbyLag
+	if maxStandbyLag == nil || cluster.Status.CurrentPrimary == targetPod.Name {
+		return targetPod, nil, nil
+	}
+
+	pods, err := GetManagedInstances(ctx, cluster, r.Client)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	statusList := r.instanceStatusClient.GetStatusFromInstances(ctx, pods)
+	lag, known := getStandbyReplicationLag(statusList, targetPod.Name)
+	if known && lag <= maxStandbyLag.MaxLagBytes {
+		return targetPod, nil, nil
+	}
+
+	if maxStandbyLag.GetPolicy() == apiv1.StandbyLagPolicyPickFresher {
+		if fresherPod := getFreshestStandby(statusList, maxStandbyLag.MaxLagBytes); fresherPod != nil {
+			contextLogger.Info("Backup target lagging behind the primary, electing a fresher standby",
+				"target", targetPod.Name,
+				"newTarget", fresherPod.Name)
+			r.Recorder.Eventf(backup, "Normal", "StandbyLagging",
+				"Standby %s is lagging behind the primary, taking the backup from %s",
+				targetPod.Name, fresherPod.Name)
+			return fresherPod, nil, nil
+		}
+	}
+
+	err = fmt.Errorf("standby %s is lagging %d bytes behind the primary, while at most %d are allowed",
+		targetPod.Name, lag, maxStandbyLag.MaxLagBytes)
+	if !known {
+		err = fmt.Errorf("cannot determine the replication lag of standby %s", targetPod.Name)
+	}
+	contextLogger.Info("Backup target lagging behind the primary", "reason", err.Error())
+	r.Recorder.Eventf(backup, "Warning", "StandbyLagging", "Snapshot backup failed: %v", err)
+	tryFlagBackupAsFailed(ctx, r.Client, backup, err)
+	return nil, &ctrl.Result{}, nil
+}
+
+// getStandbyReplicationLag returns the amount of WAL, in bytes, that the
+// passed standby still has to replay compared to the current LSN of the
+// primary, according to the replication info of the primary. The returned
+// flag is false when the lag cannot be determined, i.e. because the standby
+// is not streaming from the primary
+func getStandbyReplicationLag(statusList postgresSpec.PostgresqlStatusList, podName string) (int64, bool) {
+	for _, item := range statusList.Items {
+		if !item.IsPrimary || item.Error != nil {
+			continue
+		}
+
+		primaryLSN, err := item.CurrentLsn.Parse()
+		if err != nil {
+			return 0, false
+		}
+
+		for _, replication := range item.ReplicationInfo {
+			if replication.ApplicationName != podName {
+				continue
+			}
+
+			replayLSN, err := replication.ReplayLsn.Parse()
+			if err != nil {
+				return 0, false
+			}
+			if replayLSN >= primaryLSN {
+				return 0, true
+			}
+			return primaryLSN - replayLSN, true
+		}
+
+		return 0, false
+	}
+
+	return 0, false
+}
+
+// getFreshestStandby returns the ready standby with the lowest replication
+// lag, provided it doesn't exceed the passed threshold, nil otherwise
+func getFreshestStandby(statusList postgresSpec.PostgresqlStatusList, maxLagBytes int64) *corev1.Pod {
+	var freshestPod *corev1.Pod
+	var freshestLag int64
+	for _, item := range statusList.Items {
+		if item.Pod == nil || item.IsPrimary || item.Error != nil || !item.IsPodReady {
+			continue
+		}
+
+		lag, known := getStandbyReplicationLag(statusList, item.Pod.Name)
+		if !known || lag > maxLagBytes {
+			continue
+		}
+		if freshestPod == nil || lag < freshestLag {
+			freshestPod = item.Pod
+			freshestLag = lag
+		}
+	}
+
+	return freshestPod
+}
+
+// ensureMinHealthyStandbys checks whether fencing the target standby would
+// leave fewer healthy standbys than required by the cluster configuration.
+// When this happens, the backup is kept pending or flagged as failed,
+// depending on the configured policy. A nil result means the backup can start
+func (r *BackupReconciler) ensureMinHealthyStandbys(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	targetPod *corev1.Pod,
+) (*ctrl.Result, error) {
+	contextLogger := log.FromContext(ctx)
+
+	snapshotConfig := cluster.Spec.Backup.VolumeSnapshot
+	minHealthyStandbys := snapshotConfig.MinHealthyStandbys
+	if minHealthyStandbys == nil || cluster.Status.CurrentPrimary == targetPod.Name ||
+		!isTargetFencingRequired(cluster) {
+		return nil, nil
+	}
+
+	pods, err := GetManagedInstances(ctx, cluster, r.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	statusList := r.instanceStatusClient.GetStatusFromInstances(ctx, pods)
+	healthyStandbys := countHealthyStandbys(statusList, targetPod.Name)
+	if healthyStandbys >= int(minHealthyStandbys.Count) {
+		return nil, nil
+	}
+
+	contextLogger.Info("Not enough healthy standbys to fence the backup target",
+		"healthyStandbys", healthyStandbys,
+		"minHealthyStandbys", minHealthyStandbys.Count,
+		"policy", minHealthyStandbys.GetPolicy())
+
+	if minHealthyStandbys.GetPolicy() == apiv1.MinHealthyStandbysPolicyFail {
+		err := fmt.Errorf("fencing %s would leave %d healthy standbys, while at least %d are required",
+			targetPod.Name, healthyStandbys, minHealthyStandbys.Count)
+		r.Recorder.Eventf(backup, "Warning", "NotEnoughHealthyStandbys", "Snapshot backup failed: %v", err)
+		tryFlagBackupAsFailed(ctx, r.Client, backup, err)
+		return &ctrl.Result{}, nil
+	}
+
+	r.Recorder.Eventf(backup, "Normal", "WaitingForHealthyStandbys",
+		"Deferring the snapshot backup: %d healthy standbys besides %s, waiting for at least %d",
+		healthyStandbys, targetPod.Name, minHealthyStandbys.Count)
+	origBackup := backup.DeepCopy()
+	backup.Status.Phase = apiv1.BackupPhasePending
+	return &ctrl.Result{RequeueAfter: 30 * time.Second},
+		r.Status().Patch(ctx, backup, client.MergeFrom(origBackup))
+}
+
+// isTargetFencingRequired tells whether the target instance of a snapshot
+// backup will be fenced, i.e. whether any of its volumes requires fencing
+func isTargetFencingRequired(cluster *apiv1.Cluster) bool {
+	snapshotConfig := cluster.Spec.Backup.VolumeSnapshot
+	if snapshotConfig.IsFencingRequired(string(utils.PVCRolePgData)) {
+		return true
+	}
+
+	return cluster.ShouldCreateWalArchiveVolume() &&
+		snapshotConfig.IsFencingRequired(string(utils.PVCRolePgWal))
+}
+
+// canSkipTargetFencing tells whether the target standby of the backup can be
+// snapshotted without being fenced, as it is quiescent. The instance is fenced
+// as usual when its status cannot be collected, and when it has more than one
+// volume: nothing prevents its WAL replay from being resumed while they are
+// snapshotted, and the snapshots wouldn't be consistent with each other
+func (r *BackupReconciler) canSkipTargetFencing(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	targetPod *corev1.Pod,
+) bool {
+	contextLogger := log.FromContext(ctx)
+
+	if !cluster.Spec.Backup.VolumeSnapshot.SkipFencingOnQuiescentStandby ||
+		cluster.Status.CurrentPrimary == targetPod.Name ||
+		!isTargetFencingRequired(cluster) {
+		return false
+	}
+
+	if cluster.ShouldCreateWalArchiveVolume() {
+		contextLogger.Info("Backup target has more than one volume, fencing it", "target", targetPod.Name)
+		return false
+	}
+
+	statusList := r.instanceStatusClient.GetStatusFromInstances(
+		ctx,
+		corev1.PodList{Items: []corev1.Pod{*targetPod}},
+	)
+	if len(statusList.Items) == 0 || !isStandbyQuiescent(statusList.Items[0]) {
+		contextLogger.Info("Backup target is not a quiescent standby, fencing it", "target", targetPod.Name)
+		return false
+	}
+
+	contextLogger.Info("Backup target is a quiescent standby, skipping fencing", "target", targetPod.Name)
+	r.Recorder.Eventf(backup, "Normal", "FencingSkipped",
+		"Standby %s is quiescent, taking crash-consistent snapshots without fencing it",
+		targetPod.Name)
+	return true
+}
+
+// isStandbyQuiescent tells whether the passed instance is a standby whose
+// data is not changing, i.e. its WAL replay is paused and its WAL receiver
+// is not streaming
+func isStandbyQuiescent(status postgresSpec.PostgresqlStatus) bool {
+	if status.Error != nil || status.IsPrimary || status.MightBeUnavailable {
+		return false
+	}
+
+	return status.ReplayPaused && !status.IsWalReceiverActive
+}
+
+// countHealthyStandbys counts the standbys, other than the passed one,
+// which are ready and whose status could be collected
+func countHealthyStandbys(statusList postgresSpec.PostgresqlStatusList, excludedPodName string) int {
+	healthyStandbys := 0
+	for _, item := range statusList.Items {
+		if item.Pod == nil || item.Pod.Name == excludedPodName {
+			continue
+		}
+		if item.Error != nil || item.IsPrimary || !item.IsPodReady {
+			continue
+		}
+		healthyStandbys++
+	}
+
+	return healthyStandbys
+}
+
+// ensureBackupFenceIsRemoved re-runs the unfence step of a terminated volume
+// snapshot backup whose fencing request is still in place, i.e. because the
+// Pod couldn't be unfenced when the backup failed. This allows recovering a
+// stuck fenced instance without deleting the backup
+func (r *BackupReconciler) ensureBackupFenceIsRemoved(ctx context.Context, backup *apiv1.Backup) error {
+	contextLogger := log.FromContext(ctx)
+
+	if backup.Spec.Method != apiv1.BackupMethodVolumeSnapshot || backup.Status.InstanceID == nil {
+		return nil
+	}
+
+	var cluster apiv1.Cluster
+	if err := r.Get(ctx, client.ObjectKey{
+		Namespace: backup.Namespace,
+		Name:      backup.Spec.Cluster.Name,
+	}, &cluster); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	fenceOrigins, err := utils.GetBackupFenceOrigins(cluster.Annotations)
+	if err != nil {
+		return err
+	}
+
+	podName := backup.Status.InstanceID.PodName
+	if fenceOrigins[podName] != backup.Name {
+		return nil
+	}
+
+	var pod corev1.Pod
+	if err := r.Get(ctx, client.ObjectKey{Namespace: backup.Namespace, Name: podName}, &pod); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	contextLogger.Info("Removing the fencing left behind by a terminated backup", "podName", podName)
+	return volumesnapshot.
+		NewExecutorBuilder(r.Client, r.Recorder).
+		FenceInstance(true).
+		Build().
+		EnsurePodIsUnfenced(ctx, &cluster, backup, &pod)
+}
+
+const (
+	// backupNotificationMaxDelay is how long after its termination a backup
+	// is still notified. This avoids notifying the whole backup history when
+	// the notification is enabled on an existing cluster
+	backupNotificationMaxDelay = 24 * time.Hour
+
+	// backupNotificationRetryInterval is how long to wait before retrying
+	// a failed notification
+	backupNotificationRetryInterval = 30 * time.Second
+
+	// backupNotificationRetryPeriod is how long a failed notification is
+	// retried for
+	backupNotificationRetryPeriod = 15 * time.Minute
+)
+
+// notifyBackupOutcome delivers the outcome of a terminated backup to the
+// notification webhook configured in the cluster, recording the result in
+// the `Notified` condition of the backup. Each reconciliation makes a single
+// attempt, and a failed delivery is retried by requeueing the backup while
+// the condition tells that the notification is pending
+func (r *BackupReconciler) notifyBackupOutcome(ctx context.Context, backup *apiv1.Backup) (ctrl.Result, error) {
+	contextLogger := log.FromContext(ctx)
+
+	previous := meta.FindStatusCondition(backup.Status.Conditions, string(apiv1.ConditionBackupNotified))
+	if previous != nil && previous.Reason != string(apiv1.ConditionReasonNotificationPending) {
+		return ctrl.Result{}, nil
+	}
+
+	if stoppedAt := backup.Status.StoppedAt; previous == nil &&
+		stoppedAt != nil && time.Since(stoppedAt.Time) > backupNotificationMaxDelay {
+		return ctrl.Result{}, nil
+	}
+
+	var cluster apiv1.Cluster
+	if err := r.Get(ctx, client.ObjectKey{
+		Namespace: backup.Namespace,
+		Name:      backup.Spec.Cluster.Name,
+	}, &cluster); err != nil {
+		if apierrs.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if cluster.Spec.Backup == nil || cluster.Spec.Backup.Notification == nil {
+		return ctrl.Result{}, nil
+	}
+
+	var result ctrl.Result
+	condition := metav1.Condition{
+		Type:    string(apiv1.ConditionBackupNotified),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(apiv1.ConditionReasonNotificationSent),
+		Message: "The backup notification has been delivered",
+	}
+	if err := notification.Notify(ctx, r.Client, &cluster, backup); err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Message = err.Error()
+
+		// The retry period starts with the first failed attempt, whose time
+		// is kept in the condition while the notification is pending
+		if notification.IsRetryable(err) &&
+			(previous == nil || time.Since(previous.LastTransitionTime.Time) < backupNotificationRetryPeriod) {
+			contextLogger.Info("Cannot deliver the backup notification, retrying", "error", err.Error())
+			condition.Reason = string(apiv1.ConditionReasonNotificationPending)
+			result.RequeueAfter = backupNotificationRetryInterval
+		} else {
+			contextLogger.Error(err, "while delivering the backup notification")
+			r.Recorder.Eventf(backup, "Warning", "BackupNotificationFailed",
+				"Cannot deliver the backup notification: %v", err)
+			condition.Reason = string(apiv1.ConditionReasonNotificationFailed)
+		}
+	}
+
+	origBackup := backup.DeepCopy()
+	meta.SetStatusCondition(&backup.Status.Conditions, condition)
+	return result, r.Status().Patch(ctx, backup, client.MergeFrom(origBackup))
+}
+
+// recordBackupCompletedEvent records the `BackupCompleted` event of a
+// completed backup, with the JSON summary of the backup as its message, when
+// enabled in the cluster. The `CompletedEventRecorded` condition ensures that
+// the event is recorded once per backup
+func (r *BackupReconciler) recordBackupCompletedEvent(ctx context.Context, backup *apiv1.Backup) error {
+	if backup.Status.Phase != apiv1.BackupPhaseCompleted ||
+		meta.FindStatusCondition(
+			backup.Status.Conditions, string(apiv1.ConditionBackupCompletedEventRecorded)) != nil {
+		return nil
+	}
+
+	if stoppedAt := backup.Status.StoppedAt; stoppedAt != nil &&
+		time.Since(stoppedAt.Time) > backupNotificationMaxDelay {
+		return nil
+	}
+
+	var cluster apiv1.Cluster
+	if err := r.Get(ctx, client.ObjectKey{
+		Namespace: backup.Namespace,
+		Name:      backup.Spec.Cluster.Name,
+	}, &cluster); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	if cluster.Spec.Backup == nil || !cluster.Spec.Backup.CompletedEvent {
+		return nil
+	}
+
+	message, err := json.Marshal(notification.NewPayload(backup))
+	if err != nil {
+		return err
+	}
+	r.Recorder.Event(backup, "Normal", "BackupCompleted", string(message))
+
+	origBackup := backup.DeepCopy()
+	meta.SetStatusCondition(&backup.Status.Conditions, metav1.Condition{
+		Type:    string(apiv1.ConditionBackupCompletedEventRecorded),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(apiv1.ConditionReasonCompletedEventRecorded),
+		Message: "The BackupCompleted event has been recorded",
+	})
+	return r.Status().Patch(ctx, backup, client.MergeFrom(origBackup))
+}
+
+// recordBackupAttempt records a terminated backup in the backup history of
+// its cluster, when enabled
+func (r *BackupReconciler) recordBackupAttempt(ctx context.Context, backup *apiv1.Backup) error {
+	var cluster apiv1.Cluster
+	if err := r.Get(ctx, client.ObjectKey{
+		Namespace: backup.Namespace,
+		Name:      backup.Spec.Cluster.Name,
+	}, &cluster); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	origCluster := cluster.DeepCopy()
+	if !cluster.RecordBackupAttempt(backup) {
+		return nil
+	}
+
+	log.FromContext(ctx).Debug("Recording the backup attempt in the history of the cluster",
+		"phase", backup.Status.Phase)
+	return r.Status().Patch(ctx, &cluster, client.MergeFrom(origCluster))
+}
+
+// snapshotRestorabilityCheckInterval is how often the restorable condition
+// of the completed volume snapshot backups is refreshed
+const snapshotRestorabilityCheckInterval = time.Hour
+
+// reconcileSnapshotRestorability keeps the restorable condition of a completed
+// volume snapshot backup up to date. As the condition depends on the time
+// passing and on the WAL archive retention, it is periodically refreshed
+// while a maximum restorable age is configured
+func (r *BackupReconciler) reconcileSnapshotRestorability(
+	ctx context.Context,
+	backup *apiv1.Backup,
+) (ctrl.Result, error) {
+	if backup.Spec.Method != apiv1.BackupMethodVolumeSnapshot ||
+		backup.Status.Phase != apiv1.BackupPhaseCompleted {
+		return ctrl.Result{}, nil
+	}
+
+	var cluster apiv1.Cluster
+	if err := r.Get(ctx, client.ObjectKey{
+		Namespace: backup.Namespace,
+		Name:      backup.Spec.Cluster.Name,
+	}, &cluster); err != nil {
+		if apierrs.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	var clusterBackups apiv1.BackupList
+	if err := r.List(
+		ctx,
+		&clusterBackups,
+		client.InNamespace(backup.Namespace),
+		client.MatchingFields{clusterName: cluster.Name},
+	); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	origBackup := backup.DeepCopy()
+	changed, err := volumesnapshot.SetRestorableCondition(backup, &cluster, clusterBackups.Items, time.Now())
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if changed {
+		if err := r.Status().Patch(ctx, backup, client.MergeFrom(origBackup)); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if meta.FindStatusCondition(backup.Status.Conditions, string(apiv1.ConditionBackupRestorable)) == nil {
+		return ctrl.Result{}, nil
+	}
+
+	return ctrl.Result{RequeueAfter: snapshotRestorabilityCheckInterval}, nil
+}
+
 // isErrorRetryable detects is an error is retryable or not
 func isErrorRetryable(err error) bool {
 	return apierrs.IsServerTimeout(err) || apierrs.IsConflict(err) || apierrs.IsInternalError(err)
 }
 
+// getStartedSnapshotBackupTarget returns the instance a volume snapshot
+// backup has been assigned to when it was started, or nil if the backup
+// is not a volume snapshot one or has not been started yet
+func (r *BackupReconciler) getStartedSnapshotBackupTarget(
+	ctx context.Context,
+	backup *apiv1.Backup,
+) (*corev1.Pod, error) {
+	if backup.Spec.Method != apiv1.BackupMethodVolumeSnapshot {
+		return nil, nil
+	}
+
+	return backup.GetAssignedInstance(ctx, r.Client)
+}
+
 // getBackupTargetPod returns the correct pod that should run the backup according to the current
 // cluster's target policy
 func (r *BackupReconciler) getBackupTargetPod(ctx context.Context,
@@ -448,6 +1451,7 @@ func startBarmanBackup(
 	// This backup has been started
 	status := backup.GetStatus()
 	status.SetAsStarted(pod, apiv1.BackupMethodBarmanObjectStore)
+	status.SetReplicaSourceCluster(cluster)
 
 	if err := postgres.PatchBackupStatusAndRetry(ctx, client, backup); err != nil {
 		return err
@@ -18,11 +18,24 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
 
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/backup/notification"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -247,3 +260,607 @@ var _ = Describe("backup_controller volumeSnapshot unit tests", func() {
 		})
 	})
 })
+
+var _ = Describe("backup quiet period", func() {
+	quietPeriod := &apiv1.VolumeSnapshotQuietPeriod{
+		MaxTransactionsPerSecond: 100,
+		Deadline:                 600,
+	}
+
+	It("doesn't wait when no quiet period is configured", func() {
+		Expect(shouldWaitForQuietPeriod(nil, 5000, 0)).To(BeFalse())
+	})
+
+	It("waits while the write activity is above the threshold", func() {
+		Expect(shouldWaitForQuietPeriod(quietPeriod, 5000, time.Minute)).To(BeTrue())
+		Expect(shouldWaitForQuietPeriod(quietPeriod, 100.5, 2*time.Minute)).To(BeTrue())
+	})
+
+	It("starts the backup as soon as the write activity drops", func() {
+		rates := []float64{2500, 800, 150, 90}
+		waited := time.Duration(0)
+		var waits int
+		for _, rate := range rates {
+			if !shouldWaitForQuietPeriod(quietPeriod, rate, waited) {
+				break
+			}
+			waits++
+			waited += 30 * time.Second
+		}
+		Expect(waits).To(Equal(3))
+		Expect(shouldWaitForQuietPeriod(quietPeriod, 100, waited)).To(BeFalse())
+	})
+
+	It("starts the backup anyway when the deadline passed", func() {
+		Expect(shouldWaitForQuietPeriod(quietPeriod, 5000, 10*time.Minute)).To(BeFalse())
+		Expect(shouldWaitForQuietPeriod(quietPeriod, 5000, time.Hour)).To(BeFalse())
+	})
+
+	It("defaults the deadline to one hour", func() {
+		quietPeriod := &apiv1.VolumeSnapshotQuietPeriod{MaxTransactionsPerSecond: 100}
+		Expect(quietPeriod.GetDeadline()).To(Equal(time.Hour))
+		Expect(shouldWaitForQuietPeriod(quietPeriod, 5000, 59*time.Minute)).To(BeTrue())
+		Expect(shouldWaitForQuietPeriod(quietPeriod, 5000, time.Hour)).To(BeFalse())
+	})
+})
+
+var _ = Describe("backup minimum healthy standbys", func() {
+	newStatus := func(name string, isPrimary, isReady bool) postgres.PostgresqlStatus {
+		return postgres.PostgresqlStatus{
+			Pod:        &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}},
+			IsPrimary:  isPrimary,
+			IsPodReady: isReady,
+		}
+	}
+	minHealthyStandbys := &apiv1.VolumeSnapshotMinHealthyStandbys{Count: 2}
+
+	It("allows the backup when the cluster is at the threshold", func() {
+		statusList := postgres.PostgresqlStatusList{Items: []postgres.PostgresqlStatus{
+			newStatus("cluster-example-1", true, true),
+			newStatus("cluster-example-2", false, true),
+			newStatus("cluster-example-3", false, true),
+			newStatus("cluster-example-4", false, true),
+		}}
+		healthyStandbys := countHealthyStandbys(statusList, "cluster-example-4")
+		Expect(healthyStandbys).To(Equal(2))
+		Expect(healthyStandbys).To(BeNumerically(">=", minHealthyStandbys.Count))
+	})
+
+	It("blocks the backup when the cluster is below the threshold", func() {
+		statusList := postgres.PostgresqlStatusList{Items: []postgres.PostgresqlStatus{
+			newStatus("cluster-example-1", true, true),
+			newStatus("cluster-example-2", false, true),
+			newStatus("cluster-example-3", false, false),
+			newStatus("cluster-example-4", false, true),
+		}}
+		healthyStandbys := countHealthyStandbys(statusList, "cluster-example-4")
+		Expect(healthyStandbys).To(Equal(1))
+		Expect(healthyStandbys).To(BeNumerically("<", minHealthyStandbys.Count))
+	})
+
+	It("doesn't count the standbys whose status can't be collected", func() {
+		unreachable := newStatus("cluster-example-3", false, true)
+		unreachable.Error = errors.New("connection refused")
+		statusList := postgres.PostgresqlStatusList{Items: []postgres.PostgresqlStatus{
+			newStatus("cluster-example-1", true, true),
+			newStatus("cluster-example-2", false, true),
+			unreachable,
+		}}
+		Expect(countHealthyStandbys(statusList, "cluster-example-2")).To(BeZero())
+	})
+
+	It("defaults the policy to wait", func() {
+		Expect(minHealthyStandbys.GetPolicy()).To(Equal(apiv1.MinHealthyStandbysPolicyWait))
+		failing := &apiv1.VolumeSnapshotMinHealthyStandbys{Count: 1, Policy: apiv1.MinHealthyStandbysPolicyFail}
+		Expect(failing.GetPolicy()).To(Equal(apiv1.MinHealthyStandbysPolicyFail))
+	})
+
+	It("applies only when the target instance is fenced", func() {
+		cluster := &apiv1.Cluster{Spec: apiv1.ClusterSpec{
+			Backup: &apiv1.BackupConfiguration{VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{}},
+		}}
+		Expect(isTargetFencingRequired(cluster)).To(BeTrue())
+
+		cluster.Spec.Backup.VolumeSnapshot.FencingRequirements = []apiv1.VolumeSnapshotFencingRequirement{
+			{Role: string(utils.PVCRolePgData), FencingRequired: false},
+		}
+		Expect(isTargetFencingRequired(cluster)).To(BeFalse())
+
+		cluster.Spec.WalStorage = &apiv1.StorageConfiguration{Size: "1Gi"}
+		Expect(isTargetFencingRequired(cluster)).To(BeTrue())
+	})
+})
+
+var _ = Describe("backup fencing of quiescent standbys", func() {
+	It("recognizes a quiescent standby", func() {
+		status := postgres.PostgresqlStatus{
+			Pod:                 &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-2"}},
+			ReplayPaused:        true,
+			IsWalReceiverActive: false,
+		}
+		Expect(isStandbyQuiescent(status)).To(BeTrue())
+	})
+
+	It("fences a standby which is still replaying or streaming WAL", func() {
+		replaying := postgres.PostgresqlStatus{
+			Pod:                 &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-2"}},
+			IsWalReceiverActive: false,
+		}
+		Expect(isStandbyQuiescent(replaying)).To(BeFalse())
+
+		streaming := replaying
+		streaming.ReplayPaused = true
+		streaming.IsWalReceiverActive = true
+		Expect(isStandbyQuiescent(streaming)).To(BeFalse())
+	})
+
+	It("fences the instance when its status is not reliable", func() {
+		primary := postgres.PostgresqlStatus{IsPrimary: true, ReplayPaused: true}
+		Expect(isStandbyQuiescent(primary)).To(BeFalse())
+
+		unreachable := postgres.PostgresqlStatus{ReplayPaused: true, Error: errors.New("connection refused")}
+		Expect(isStandbyQuiescent(unreachable)).To(BeFalse())
+
+		unavailable := postgres.PostgresqlStatus{ReplayPaused: true, MightBeUnavailable: true}
+		Expect(isStandbyQuiescent(unavailable)).To(BeFalse())
+	})
+
+	It("never skips fencing when not enabled or when targeting the primary", func(ctx context.Context) {
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{}},
+			},
+			Status: apiv1.ClusterStatus{CurrentPrimary: "cluster-example-1"},
+		}
+		reconciler := &BackupReconciler{}
+		backup := &apiv1.Backup{}
+		standby := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-2"}}
+		primary := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-1"}}
+
+		Expect(reconciler.canSkipTargetFencing(ctx, cluster, backup, standby)).To(BeFalse())
+
+		cluster.Spec.Backup.VolumeSnapshot.SkipFencingOnQuiescentStandby = true
+		Expect(reconciler.canSkipTargetFencing(ctx, cluster, backup, primary)).To(BeFalse())
+	})
+
+	It("never skips fencing when the target has more than one volume", func(ctx context.Context) {
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				WalStorage: &apiv1.StorageConfiguration{},
+				Backup: &apiv1.BackupConfiguration{VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+					SkipFencingOnQuiescentStandby: true,
+				}},
+			},
+			Status: apiv1.ClusterStatus{CurrentPrimary: "cluster-example-1"},
+		}
+		reconciler := &BackupReconciler{}
+		standby := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-2"}}
+
+		Expect(reconciler.canSkipTargetFencing(ctx, cluster, &apiv1.Backup{}, standby)).To(BeFalse())
+	})
+})
+
+var _ = Describe("backup target of started volume snapshot backups", func() {
+	var (
+		reconciler *BackupReconciler
+		backup     *apiv1.Backup
+	)
+
+	BeforeEach(func() {
+		namespace := newFakeNamespace()
+		primary := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example-1",
+				Namespace: namespace,
+			},
+		}
+		backup = &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backup-example",
+				Namespace: namespace,
+			},
+			Spec: apiv1.BackupSpec{
+				Method: apiv1.BackupMethodVolumeSnapshot,
+				Target: apiv1.BackupTargetPrimary,
+			},
+			Status: apiv1.BackupStatus{
+				InstanceID: &apiv1.InstanceID{PodName: primary.Name},
+			},
+		}
+		reconciler = &BackupReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+				WithObjects(primary).
+				Build(),
+		}
+	})
+
+	It("keeps the assigned instance, even if it's not ready", func(ctx context.Context) {
+		pod, err := reconciler.getStartedSnapshotBackupTarget(ctx, backup)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pod).ToNot(BeNil())
+		Expect(pod.Name).To(Equal("cluster-example-1"))
+	})
+
+	It("elects a new target when the backup has not been started", func(ctx context.Context) {
+		backup.Status.InstanceID = nil
+		Expect(reconciler.getStartedSnapshotBackupTarget(ctx, backup)).To(BeNil())
+	})
+
+	It("elects a new target for the barmanObjectStore backups", func(ctx context.Context) {
+		backup.Spec.Method = apiv1.BackupMethodBarmanObjectStore
+		Expect(reconciler.getStartedSnapshotBackupTarget(ctx, backup)).To(BeNil())
+	})
+})
+
+var _ = Describe("backup standby lag", func() {
+	newStandbyStatus := func(name string) postgres.PostgresqlStatus {
+		return postgres.PostgresqlStatus{
+			Pod:        &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}},
+			IsPodReady: true,
+		}
+	}
+
+	// The primary is at 0/5000000, cluster-example-2 is 4096 bytes behind,
+	// cluster-example-3 is 16MB behind and cluster-example-4 isn't streaming
+	statusList := postgres.PostgresqlStatusList{Items: []postgres.PostgresqlStatus{
+		{
+			Pod:        &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-1"}},
+			IsPrimary:  true,
+			IsPodReady: true,
+			CurrentLsn: "0/5000000",
+			ReplicationInfo: postgres.PgStatReplicationList{
+				{ApplicationName: "cluster-example-2", ReplayLsn: "0/4FFF000"},
+				{ApplicationName: "cluster-example-3", ReplayLsn: "0/4000000"},
+			},
+		},
+		newStandbyStatus("cluster-example-2"),
+		newStandbyStatus("cluster-example-3"),
+		newStandbyStatus("cluster-example-4"),
+	}}
+
+	It("computes the lag of the standbys from the replication info of the primary", func() {
+		lag, known := getStandbyReplicationLag(statusList, "cluster-example-2")
+		Expect(known).To(BeTrue())
+		Expect(lag).To(BeEquivalentTo(4096))
+
+		lag, known = getStandbyReplicationLag(statusList, "cluster-example-3")
+		Expect(known).To(BeTrue())
+		Expect(lag).To(BeEquivalentTo(16 * 1024 * 1024))
+
+		_, known = getStandbyReplicationLag(statusList, "cluster-example-4")
+		Expect(known).To(BeFalse())
+	})
+
+	It("doesn't know the lag when the primary status is not available", func() {
+		_, known := getStandbyReplicationLag(postgres.PostgresqlStatusList{Items: statusList.Items[1:]},
+			"cluster-example-2")
+		Expect(known).To(BeFalse())
+	})
+
+	It("elects the least lagging standby below the threshold", func() {
+		pod := getFreshestStandby(statusList, 1024*1024)
+		Expect(pod).ToNot(BeNil())
+		Expect(pod.Name).To(Equal("cluster-example-2"))
+	})
+
+	It("doesn't elect any standby when all of them are above the threshold", func() {
+		Expect(getFreshestStandby(statusList, 1024)).To(BeNil())
+	})
+
+	It("doesn't elect standbys which are not ready", func() {
+		items := append([]postgres.PostgresqlStatus{}, statusList.Items...)
+		items[1].IsPodReady = false
+		pod := getFreshestStandby(postgres.PostgresqlStatusList{Items: items}, 32*1024*1024)
+		Expect(pod).ToNot(BeNil())
+		Expect(pod.Name).To(Equal("cluster-example-3"))
+	})
+
+	It("defaults the policy to fail", func() {
+		maxStandbyLag := &apiv1.VolumeSnapshotMaxStandbyLag{MaxLagBytes: 1024}
+		Expect(maxStandbyLag.GetPolicy()).To(Equal(apiv1.StandbyLagPolicyFail))
+	})
+})
+
+var _ = Describe("backup coalescing", func() {
+	start := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	newBackup := func(name string, offset time.Duration, method apiv1.BackupMethod) apiv1.Backup {
+		return apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(start.Add(offset)),
+			},
+			Spec: apiv1.BackupSpec{
+				Cluster: apiv1.LocalObjectReference{Name: "cluster-example"},
+				Method:  method,
+			},
+		}
+	}
+
+	It("coalesces rapid duplicates into the first backup", func() {
+		backups := []apiv1.Backup{
+			newBackup("backup-1", 0, apiv1.BackupMethodVolumeSnapshot),
+			newBackup("backup-2", 10*time.Second, apiv1.BackupMethodVolumeSnapshot),
+			newBackup("backup-3", 20*time.Second, apiv1.BackupMethodVolumeSnapshot),
+		}
+
+		Expect(findCoalescingBackup(&backups[0], backups, time.Minute)).To(BeNil())
+		Expect(findCoalescingBackup(&backups[1], backups, time.Minute).Name).To(Equal("backup-1"))
+		Expect(findCoalescingBackup(&backups[2], backups, time.Minute).Name).To(Equal("backup-1"))
+	})
+
+	It("doesn't coalesce backups outside of the window", func() {
+		backups := []apiv1.Backup{
+			newBackup("backup-1", 0, apiv1.BackupMethodVolumeSnapshot),
+			newBackup("backup-2", 2*time.Minute, apiv1.BackupMethodVolumeSnapshot),
+		}
+
+		Expect(findCoalescingBackup(&backups[1], backups, time.Minute)).To(BeNil())
+	})
+
+	It("doesn't coalesce backups using different methods", func() {
+		backups := []apiv1.Backup{
+			newBackup("backup-1", 0, apiv1.BackupMethodBarmanObjectStore),
+			newBackup("backup-2", 10*time.Second, apiv1.BackupMethodVolumeSnapshot),
+		}
+
+		Expect(findCoalescingBackup(&backups[1], backups, time.Minute)).To(BeNil())
+	})
+
+	It("doesn't coalesce backups of different clusters", func() {
+		backups := []apiv1.Backup{
+			newBackup("backup-1", 0, apiv1.BackupMethodVolumeSnapshot),
+			newBackup("backup-2", 10*time.Second, apiv1.BackupMethodVolumeSnapshot),
+		}
+		backups[0].Spec.Cluster.Name = "another-cluster"
+
+		Expect(findCoalescingBackup(&backups[1], backups, time.Minute)).To(BeNil())
+	})
+
+	It("ignores failed and coalesced backups", func() {
+		backups := []apiv1.Backup{
+			newBackup("backup-1", 0, apiv1.BackupMethodVolumeSnapshot),
+			newBackup("backup-2", 10*time.Second, apiv1.BackupMethodVolumeSnapshot),
+			newBackup("backup-3", 20*time.Second, apiv1.BackupMethodVolumeSnapshot),
+		}
+		backups[0].Status.Phase = apiv1.BackupPhaseFailed
+		backups[1].Status.SetAsCoalesced("backup-0")
+
+		Expect(findCoalescingBackup(&backups[2], backups, time.Minute)).To(BeNil())
+	})
+
+	It("never coalesces dry-runs", func() {
+		backups := []apiv1.Backup{
+			newBackup("backup-1", 0, apiv1.BackupMethodVolumeSnapshot),
+			newBackup("backup-2", 10*time.Second, apiv1.BackupMethodVolumeSnapshot),
+			newBackup("backup-3", 20*time.Second, apiv1.BackupMethodVolumeSnapshot),
+		}
+		backups[0].Spec.DryRun = true
+		backups[2].Spec.DryRun = true
+
+		Expect(findCoalescingBackup(&backups[1], backups, time.Minute)).To(BeNil())
+		Expect(findCoalescingBackup(&backups[2], backups, time.Minute)).To(BeNil())
+	})
+
+	It("refuses dry-runs not supported by the backup", func() {
+		cluster := &apiv1.Cluster{Spec: apiv1.ClusterSpec{Backup: &apiv1.BackupConfiguration{
+			VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{},
+		}}}
+		backup := newBackup("backup-1", 0, apiv1.BackupMethodVolumeSnapshot)
+		Expect(getDryRunUnsupportedReason(cluster, &backup)).To(BeEmpty())
+
+		backup.Spec.DryRun = true
+		Expect(getDryRunUnsupportedReason(cluster, &backup)).To(BeEmpty())
+
+		backup.Spec.Method = apiv1.BackupMethodBarmanObjectStore
+		Expect(getDryRunUnsupportedReason(cluster, &backup)).To(ContainSubstring("volumeSnapshot"))
+	})
+
+	It("breaks the ties between backups requested at the same time by name", func() {
+		backups := []apiv1.Backup{
+			newBackup("backup-b", 0, apiv1.BackupMethodVolumeSnapshot),
+			newBackup("backup-a", 0, apiv1.BackupMethodVolumeSnapshot),
+		}
+
+		Expect(findCoalescingBackup(&backups[1], backups, time.Minute)).To(BeNil())
+		Expect(findCoalescingBackup(&backups[0], backups, time.Minute).Name).To(Equal("backup-a"))
+	})
+
+	It("considers coalesced backups as done", func() {
+		backup := newBackup("backup-2", 0, apiv1.BackupMethodVolumeSnapshot)
+		backup.Status.SetAsCoalesced("backup-1")
+		Expect(backup.Status.IsDone()).To(BeTrue())
+		Expect(backup.Status.CoalescedInto).To(Equal("backup-1"))
+	})
+
+	It("is disabled by default", func() {
+		Expect((&apiv1.BackupConfiguration{}).GetCoalesceWindow()).To(BeZero())
+		Expect((&apiv1.BackupConfiguration{CoalesceWindow: 30}).GetCoalesceWindow()).To(Equal(30 * time.Second))
+	})
+})
+
+var _ = Describe("backup completed event", func() {
+	const namespace = "default"
+
+	var (
+		cluster    *apiv1.Cluster
+		backup     *apiv1.Backup
+		recorder   *record.FakeRecorder
+		reconciler *BackupReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: namespace},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{CompletedEvent: true},
+			},
+		}
+
+		startedAt := metav1.NewTime(time.Now().Add(-2 * time.Minute).Truncate(time.Second))
+		stoppedAt := metav1.NewTime(startedAt.Add(98 * time.Second))
+		backup = &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup-example", Namespace: namespace},
+			Spec: apiv1.BackupSpec{
+				Cluster: apiv1.LocalObjectReference{Name: "cluster-example"},
+				Method:  apiv1.BackupMethodVolumeSnapshot,
+			},
+			Status: apiv1.BackupStatus{
+				Method:    apiv1.BackupMethodVolumeSnapshot,
+				Phase:     apiv1.BackupPhaseCompleted,
+				StartedAt: &startedAt,
+				StoppedAt: &stoppedAt,
+				BeginLSN:  "0/6000028",
+				EndLSN:    "0/6000100",
+				BackupSnapshotStatus: apiv1.BackupSnapshotStatus{
+					Snapshots: []string{"backup-example", "backup-example-wal"},
+				},
+			},
+		}
+	})
+
+	buildReconciler := func() {
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &BackupReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+				WithObjects(cluster, backup).
+				WithStatusSubresource(backup).
+				Build(),
+			Recorder: recorder,
+		}
+	}
+
+	It("records the event with the JSON summary of the backup, once", func(ctx context.Context) {
+		buildReconciler()
+
+		Expect(reconciler.recordBackupCompletedEvent(ctx, backup)).To(Succeed())
+		Expect(recorder.Events).To(HaveLen(1))
+
+		event := <-recorder.Events
+		Expect(event).To(HavePrefix("Normal BackupCompleted "))
+
+		var payload notification.Payload
+		Expect(json.Unmarshal([]byte(strings.TrimPrefix(event, "Normal BackupCompleted ")), &payload)).
+			To(Succeed())
+		Expect(payload.BackupName).To(Equal("backup-example"))
+		Expect(payload.ClusterName).To(Equal("cluster-example"))
+		Expect(payload.Phase).To(BeEquivalentTo(apiv1.BackupPhaseCompleted))
+		Expect(payload.Snapshots).To(ConsistOf("backup-example", "backup-example-wal"))
+		Expect(payload.BeginLSN).To(Equal("0/6000028"))
+		Expect(payload.EndLSN).To(Equal("0/6000100"))
+		Expect(payload.Duration).To(Equal("1m38s"))
+
+		var updatedBackup apiv1.Backup
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(backup), &updatedBackup)).To(Succeed())
+		Expect(meta.IsStatusConditionTrue(updatedBackup.Status.Conditions,
+			string(apiv1.ConditionBackupCompletedEventRecorded))).To(BeTrue())
+
+		Expect(reconciler.recordBackupCompletedEvent(ctx, &updatedBackup)).To(Succeed())
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("doesn't record the event when not enabled", func(ctx context.Context) {
+		cluster.Spec.Backup.CompletedEvent = false
+		buildReconciler()
+
+		Expect(reconciler.recordBackupCompletedEvent(ctx, backup)).To(Succeed())
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("doesn't record the event for failed backups", func(ctx context.Context) {
+		backup.Status.Phase = apiv1.BackupPhaseFailed
+		buildReconciler()
+
+		Expect(reconciler.recordBackupCompletedEvent(ctx, backup)).To(Succeed())
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("doesn't record the event for the backups completed long ago", func(ctx context.Context) {
+		stoppedAt := metav1.NewTime(time.Now().Add(-2 * backupNotificationMaxDelay))
+		backup.Status.StoppedAt = &stoppedAt
+		buildReconciler()
+
+		Expect(reconciler.recordBackupCompletedEvent(ctx, backup)).To(Succeed())
+		Expect(recorder.Events).To(BeEmpty())
+	})
+})
+
+var _ = Describe("backup dry-run with fenced instances", func() {
+	const namespace = "default"
+
+	var (
+		cluster   *apiv1.Cluster
+		backup    *apiv1.Backup
+		targetPod *corev1.Pod
+	)
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example",
+				Namespace: namespace,
+				Annotations: map[string]string{
+					utils.FencedInstanceAnnotation: `["cluster-example-3"]`,
+				},
+			},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{ClassName: "csi-snapclass"},
+				},
+			},
+		}
+		backup = &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup-example", Namespace: namespace},
+			Spec: apiv1.BackupSpec{
+				Cluster: apiv1.LocalObjectReference{Name: "cluster-example"},
+				Method:  apiv1.BackupMethodVolumeSnapshot,
+				DryRun:  true,
+			},
+		}
+		targetPod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-2", Namespace: namespace},
+		}
+	})
+
+	reconcileDryRun := func(ctx context.Context) apiv1.Backup {
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example-2",
+				Namespace: namespace,
+				Labels:    map[string]string{utils.PvcRoleLabelName: string(utils.PVCRolePgData)},
+			},
+		}
+		snapshotClass := &storagesnapshotv1.VolumeSnapshotClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "csi-snapclass"},
+		}
+		reconciler := &BackupReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+				WithObjects(cluster, backup, targetPod, pvc, snapshotClass).
+				WithStatusSubresource(backup).
+				Build(),
+			Recorder: record.NewFakeRecorder(10),
+		}
+
+		_, err := reconciler.reconcileSnapshotDryRun(ctx, targetPod, cluster, backup)
+		Expect(err).ToNot(HaveOccurred())
+
+		var result apiv1.Backup
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(backup), &result)).To(Succeed())
+		return result
+	}
+
+	It("fails when another instance is fenced", func(ctx context.Context) {
+		result := reconcileDryRun(ctx)
+		Expect(result.Status.Phase).To(BeEquivalentTo(apiv1.BackupPhaseFailed))
+	})
+
+	It("tolerates the other fenced instances when configured", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.TolerateFencedInstances = true
+		result := reconcileDryRun(ctx)
+		Expect(result.Status.Phase).To(BeEquivalentTo(apiv1.BackupPhaseValidated))
+		Expect(result.Status.BackupSnapshotStatus.DryRunSnapshots).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,291 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/backup/volumesnapshot"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/persistentvolumeclaim"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+const (
+	// remoteClientTimeout is the timeout of the requests to the
+	// Kubernetes API of the remote target of a snapshot backup
+	remoteClientTimeout = 10 * time.Second
+
+	// remoteRetryInterval is how long to wait before reaching again
+	// an unreachable remote target of a snapshot backup
+	remoteRetryInterval = 30 * time.Second
+)
+
+// remoteClientFactory creates the client and the configuration used to
+// reach the Kubernetes cluster of the remote target of the snapshot
+// backups of a cluster
+type remoteClientFactory func(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+) (client.Client, *rest.Config, error)
+
+// newRemoteClient is the remoteClientFactory using the kubeconfig
+// stored in the Secret referenced by the remote target
+func newRemoteClient(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+) (client.Client, *rest.Config, error) {
+	secretSelector := cluster.Spec.Backup.VolumeSnapshot.RemoteTarget.KubeconfigSecret
+
+	var secret corev1.Secret
+	if err := cli.Get(
+		ctx,
+		client.ObjectKey{Namespace: cluster.Namespace, Name: secretSelector.Name},
+		&secret,
+	); err != nil {
+		return nil, nil, fmt.Errorf("while getting the kubeconfig secret %s: %w", secretSelector.Name, err)
+	}
+
+	kubeconfig, ok := secret.Data[secretSelector.Key]
+	if !ok {
+		return nil, nil, fmt.Errorf("missing key %s in the kubeconfig secret %s", secretSelector.Key, secretSelector.Name)
+	}
+
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("while parsing the kubeconfig: %w", err)
+	}
+	config.Timeout = remoteClientTimeout
+
+	remoteClient, err := client.New(config, client.Options{Scheme: cli.Scheme()})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return remoteClient, config, nil
+}
+
+// isRemoteConnectivityError detects if an error is caused by the
+// Kubernetes API of the remote target not being reachable
+func isRemoteConnectivityError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		isErrorRetryable(err) ||
+		apierrs.IsTimeout(err) ||
+		apierrs.IsServiceUnavailable(err) ||
+		apierrs.IsTooManyRequests(err)
+}
+
+// startRemoteSnapshotBackup executes a snapshot backup of the designated
+// primary of a replica cluster running in a different Kubernetes cluster.
+// The Backup stays in this cluster, while the snapshots are taken in the
+// remote one
+func (r *BackupReconciler) startRemoteSnapshotBackup(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+) (*ctrl.Result, error) {
+	contextLogger := log.FromContext(ctx)
+	target := cluster.Spec.Backup.VolumeSnapshot.RemoteTarget
+
+	// Validate we don't have other running backups
+	var clusterBackups apiv1.BackupList
+	if err := r.List(
+		ctx,
+		&clusterBackups,
+		client.MatchingFields{clusterName: cluster.Name},
+	); err != nil {
+		return nil, err
+	}
+	if !clusterBackups.CanExecuteBackup(backup.Name) {
+		contextLogger.Info(
+			"A backup is already in progress or waiting to be started, retrying",
+			"targetBackup", backup.Name,
+		)
+		return &ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	remoteClient, remoteConfig, err := r.remoteClients(ctx, r.Client, cluster)
+	if err != nil {
+		return r.deferRemoteSnapshotBackup(ctx, backup, err)
+	}
+
+	var remoteCluster apiv1.Cluster
+	err = remoteClient.Get(
+		ctx,
+		client.ObjectKey{Namespace: target.GetNamespace(cluster.Namespace), Name: target.ClusterName},
+		&remoteCluster,
+	)
+	if apierrs.IsNotFound(err) {
+		return nil, r.failRemoteSnapshotBackup(ctx, backup,
+			fmt.Errorf("cannot find the remote cluster %s", target.ClusterName))
+	}
+	if err != nil {
+		return r.deferRemoteSnapshotBackup(ctx, backup, err)
+	}
+
+	if !remoteCluster.IsReplica() {
+		return nil, r.failRemoteSnapshotBackup(ctx, backup,
+			fmt.Errorf("the remote cluster %s is not a replica cluster", target.ClusterName))
+	}
+
+	// The designated primary is elected when the backup starts, and kept
+	// until its completion
+	targetPodName := remoteCluster.Status.CurrentPrimary
+	if backup.Status.InstanceID != nil && backup.Status.Phase != apiv1.BackupPhasePending {
+		targetPodName = backup.Status.InstanceID.PodName
+	}
+
+	var targetPod corev1.Pod
+	if err := remoteClient.Get(
+		ctx,
+		client.ObjectKey{Namespace: remoteCluster.Namespace, Name: targetPodName},
+		&targetPod,
+	); err != nil {
+		return r.deferRemoteSnapshotBackup(ctx, backup,
+			fmt.Errorf("while getting the designated primary %s: %w", targetPodName, err))
+	}
+
+	if len(backup.Status.Phase) == 0 || backup.Status.Phase == apiv1.BackupPhasePending {
+		if !utils.IsPodReady(targetPod) {
+			return r.deferRemoteSnapshotBackup(ctx, backup,
+				fmt.Errorf("the designated primary %s is not ready", targetPod.Name))
+		}
+
+		backup.Status.SetAsStarted(&targetPod, apiv1.BackupMethodVolumeSnapshot)
+		backup.Status.SetReplicaSourceCluster(&remoteCluster)
+		// given that we use only kubernetes resources we can use the backup name as ID
+		backup.Status.BackupID = backup.Name
+		if err := postgres.PatchBackupStatusAndRetry(ctx, r.Client, backup); err != nil {
+			return nil, err
+		}
+		r.Recorder.Eventf(backup, "Normal", "Starting",
+			"Starting backup of the remote cluster %v on instance %v", remoteCluster.Name, targetPod.Name)
+	}
+
+	pvcs, err := persistentvolumeclaim.GetInstancePVCs(ctx, remoteClient, targetPod.Name, remoteCluster.Namespace)
+	if err != nil {
+		return r.deferRemoteSnapshotBackup(ctx, backup, fmt.Errorf("cannot get PVCs: %w", err))
+	}
+
+	ephemeralPVCs, err := persistentvolumeclaim.GetPodEphemeralPVCs(ctx, remoteClient, &targetPod)
+	if err != nil {
+		return r.deferRemoteSnapshotBackup(ctx, backup, fmt.Errorf("cannot get ephemeral volume PVCs: %w", err))
+	}
+	pvcs = append(pvcs, ephemeralPVCs...)
+
+	// The snapshots are taken with the backup configuration of this
+	// cluster, while fencing applies to the remote one
+	remoteView := remoteCluster.DeepCopy()
+	remoteView.Spec.Backup = cluster.Spec.Backup.DeepCopy()
+
+	requeueBackoff := cluster.Spec.Backup.VolumeSnapshot.RequeueBackoff
+	executor := volumesnapshot.
+		NewExecutorBuilder(remoteClient, r.Recorder).
+		BackupClient(r.Client).
+		Remote(remoteConfig).
+		FenceInstance(true).
+		ReadyTimeout(cluster.Spec.Backup.VolumeSnapshot.GetReadyTimeout()).
+		RequeueBackoff(requeueBackoff.GetInterval(), requeueBackoff.GetMaxInterval()).
+		TracerProvider(otel.GetTracerProvider()).
+		Build()
+
+	res, err := executor.Execute(ctx, remoteView, backup, &targetPod, pvcs)
+	if err != nil && isRemoteConnectivityError(err) {
+		// the fencing requested so far is kept, and bounded by the
+		// maximum fence duration
+		return r.deferRemoteSnapshotBackup(ctx, backup, err)
+	}
+	if err != nil {
+		contextLogger.Error(err, "while executing remote snapshot backup")
+		if failErr := r.failRemoteSnapshotBackup(ctx, backup, err); failErr != nil {
+			return nil, failErr
+		}
+		return nil, executor.EnsurePodIsUnfenced(ctx, remoteView, backup, &targetPod)
+	}
+
+	if res != nil {
+		return res, nil
+	}
+
+	backup.Status.SetAsCompleted()
+	snapshots, err := volumesnapshot.GetBackupVolumeSnapshots(ctx, remoteClient, remoteCluster.Namespace, backup.Name)
+	if err != nil {
+		return r.deferRemoteSnapshotBackup(ctx, backup, err)
+	}
+
+	backup.Status.BackupSnapshotStatus.SetSnapshotList(snapshots)
+
+	return nil, postgres.PatchBackupStatusAndRetry(ctx, r.Client, backup)
+}
+
+// deferRemoteSnapshotBackup retries later a snapshot backup whose remote
+// target can't be reached or is not ready. A backup not started yet is
+// marked as pending, and the backup fails once its timeout is exceeded
+func (r *BackupReconciler) deferRemoteSnapshotBackup(
+	ctx context.Context,
+	backup *apiv1.Backup,
+	err error,
+) (*ctrl.Result, error) {
+	if backup.IsTimeoutExceeded(time.Now()) {
+		return nil, r.failRemoteSnapshotBackup(ctx, backup,
+			fmt.Errorf("backup not completed within its timeout of %s: %w", backup.GetTimeout(), err))
+	}
+
+	log.FromContext(ctx).Info("Cannot reach the remote target, will retry",
+		"err", err.Error(), "retryAfter", remoteRetryInterval)
+	r.Recorder.Eventf(backup, "Warning", "RemoteTargetUnreachable",
+		"Cannot reach the remote target, will retry in %s: %v", remoteRetryInterval, err)
+
+	if len(backup.Status.Phase) != 0 && backup.Status.Phase != apiv1.BackupPhasePending {
+		return &ctrl.Result{RequeueAfter: remoteRetryInterval}, nil
+	}
+
+	origBackup := backup.DeepCopy()
+	backup.Status.Phase = apiv1.BackupPhasePending
+	return &ctrl.Result{RequeueAfter: remoteRetryInterval},
+		r.Status().Patch(ctx, backup, client.MergeFrom(origBackup))
+}
+
+// failRemoteSnapshotBackup marks as failed a snapshot backup of a remote
+// target
+func (r *BackupReconciler) failRemoteSnapshotBackup(
+	ctx context.Context,
+	backup *apiv1.Backup,
+	err error,
+) error {
+	r.Recorder.Eventf(backup, "Warning", "Error", "remote snapshot backup failed: %v", err)
+	origBackup := backup.DeepCopy()
+	backup.Status.SetAsFailed(fmt.Errorf("can't execute remote snapshot backup: %w", err))
+	return r.Status().Patch(ctx, backup, client.MergeFrom(origBackup))
+}
@@ -0,0 +1,183 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/persistentvolumeclaim"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("remote snapshot backups", func() {
+	const (
+		namespace       = "default"
+		remoteNamespace = "remote"
+		remotePrimary   = "cluster-replica-1"
+	)
+
+	var (
+		cluster      *apiv1.Cluster
+		backup       *apiv1.Backup
+		remoteClient client.Client
+		recorder     *record.FakeRecorder
+		reconciler   *BackupReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: namespace},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						ClassName: "csi-hostpath-snapclass",
+						RemoteTarget: &apiv1.VolumeSnapshotRemoteTarget{
+							KubeconfigSecret: apiv1.SecretKeySelector{
+								LocalObjectReference: apiv1.LocalObjectReference{Name: "remote-kubeconfig"},
+								Key:                  "kubeconfig",
+							},
+							ClusterName: "cluster-replica",
+							Namespace:   remoteNamespace,
+						},
+					},
+				},
+			},
+		}
+		backup = &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup-example", Namespace: namespace},
+			Spec: apiv1.BackupSpec{
+				Cluster: apiv1.LocalObjectReference{Name: cluster.Name},
+				Method:  apiv1.BackupMethodVolumeSnapshot,
+			},
+		}
+
+		remoteCluster := &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-replica", Namespace: remoteNamespace},
+			Spec: apiv1.ClusterSpec{
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{Enabled: true, Source: "cluster-example"},
+			},
+			Status: apiv1.ClusterStatus{CurrentPrimary: remotePrimary},
+		}
+		remotePod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: remotePrimary, Namespace: remoteNamespace},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{
+					{Type: corev1.ContainersReady, Status: corev1.ConditionTrue},
+				},
+				ContainerStatuses: []corev1.ContainerStatus{{ContainerID: "container-id"}},
+			},
+		}
+		remotePVC := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      persistentvolumeclaim.GetName(remotePrimary, utils.PVCRolePgData),
+				Namespace: remoteNamespace,
+				Labels:    map[string]string{utils.PvcRoleLabelName: string(utils.PVCRolePgData)},
+			},
+		}
+
+		scheme := schemeBuilder.BuildWithAllKnownScheme()
+		remoteClient = fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithObjects(remoteCluster, remotePod, remotePVC).
+			Build()
+		recorder = record.NewFakeRecorder(120)
+		reconciler = &BackupReconciler{
+			Client: fakeClientWithIndexAdapter{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithObjects(cluster, backup).
+					WithStatusSubresource(backup).
+					Build(),
+			},
+			Recorder: recorder,
+			remoteClients: func(context.Context, client.Client, *apiv1.Cluster) (client.Client, *rest.Config, error) {
+				return remoteClient, &rest.Config{}, nil
+			},
+		}
+	})
+
+	It("starts the backup on the designated primary of the remote cluster", func(ctx context.Context) {
+		res, err := reconciler.startRemoteSnapshotBackup(ctx, cluster, backup)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).ToNot(BeNil())
+
+		var storedBackup apiv1.Backup
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(backup), &storedBackup)).To(Succeed())
+		Expect(storedBackup.Status.Phase).To(Equal(apiv1.BackupPhaseStarted))
+		Expect(storedBackup.Status.InstanceID.PodName).To(Equal(remotePrimary))
+		Expect(storedBackup.Status.ReplicaSourceCluster).To(Equal("cluster-example"))
+
+		// the designated primary is fenced in the remote cluster
+		var remoteCluster apiv1.Cluster
+		Expect(remoteClient.Get(
+			ctx, client.ObjectKey{Namespace: remoteNamespace, Name: "cluster-replica"}, &remoteCluster,
+		)).To(Succeed())
+		fencedInstances, err := utils.GetFencedInstances(remoteCluster.Annotations)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fencedInstances.Has(remotePrimary)).To(BeTrue())
+	})
+
+	It("retries later when the remote cluster is not reachable", func(ctx context.Context) {
+		reconciler.remoteClients = func(context.Context, client.Client, *apiv1.Cluster) (
+			client.Client, *rest.Config, error,
+		) {
+			return nil, nil, &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+		}
+
+		res, err := reconciler.startRemoteSnapshotBackup(ctx, cluster, backup)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(remoteRetryInterval))
+
+		var storedBackup apiv1.Backup
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(backup), &storedBackup)).To(Succeed())
+		Expect(storedBackup.Status.Phase).To(Equal(apiv1.BackupPhasePending))
+		Expect(recorder.Events).To(Receive(ContainSubstring("RemoteTargetUnreachable")))
+	})
+
+	It("fails the backup when the remote cluster doesn't exist", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.RemoteTarget.ClusterName = "missing"
+
+		res, err := reconciler.startRemoteSnapshotBackup(ctx, cluster, backup)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).To(BeNil())
+
+		var storedBackup apiv1.Backup
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(backup), &storedBackup)).To(Succeed())
+		Expect(storedBackup.Status.Phase).To(Equal(apiv1.BackupPhaseFailed))
+	})
+
+	It("recognizes the connectivity errors", func() {
+		Expect(isRemoteConnectivityError(&net.OpError{Op: "dial", Err: errors.New("refused")})).To(BeTrue())
+		Expect(isRemoteConnectivityError(apierrs.NewServiceUnavailable("down"))).To(BeTrue())
+		Expect(isRemoteConnectivityError(errors.New("snapshot class not found"))).To(BeFalse())
+	})
+})
@@ -45,6 +45,7 @@ import (
 	"github.com/cloudnative-pg/cloudnative-pg/internal/configuration"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/backup/volumesnapshot"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/hibernation"
 	instanceReconciler "github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/instance"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/persistentvolumeclaim"
@@ -251,6 +252,42 @@ func (r *ClusterReconciler) reconcile(ctx context.Context, cluster *apiv1.Cluste
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, registerPhaseErr
 	}
 
+	// Remove any fencing request left behind by a volume snapshot backup
+	// that has been deleted before unfencing its target instance
+	if _, ok := cluster.Annotations[utils.BackupFenceOriginAnnotationName]; ok {
+		if err := volumesnapshot.RemoveStaleBackupFences(ctx, r.Client, cluster); err != nil {
+			contextLogger.Error(err, "while removing stale backup fences")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Delete the volume snapshot backups not retained anymore by the retention policies
+	if err := volumesnapshot.EnforceRetentionPolicy(ctx, r.Client, cluster); err != nil {
+		contextLogger.Error(err, "while enforcing the volume snapshot retention policy")
+		return ctrl.Result{}, err
+	}
+
+	// Keep the deletion policy of the volume snapshot contents as configured
+	if err := volumesnapshot.ReconcileDeletionPolicy(ctx, r.Client, r.Recorder, cluster); err != nil {
+		contextLogger.Error(err, "while reconciling the volume snapshot contents deletion policy")
+		return ctrl.Result{}, err
+	}
+
+	// Keep the labels required on the volume snapshots in place
+	if err := volumesnapshot.ReconcileRequiredLabels(ctx, r.Client, cluster); err != nil {
+		contextLogger.Error(err, "while reconciling the labels required on the volume snapshots")
+		return ctrl.Result{}, err
+	}
+
+	// Re-seed the replica cluster if its designated primary can't follow the source anymore
+	if res, err := r.reconcileReplicaReseed(ctx, cluster, resources); res != nil || err != nil {
+		if err != nil {
+			contextLogger.Error(err, "while re-seeding the replica cluster")
+			return ctrl.Result{}, err
+		}
+		return *res, nil
+	}
+
 	// Verify the architecture of all the instances and update the OnlineUpdateEnabled
 	// field in the status
 	onlineUpdateEnabled := configuration.Current.EnableInstanceManagerInplaceUpdates
@@ -287,7 +324,7 @@ func (r *ClusterReconciler) reconcile(ctx context.Context, cluster *apiv1.Cluste
 		hasHTTPStatus := mostAdvancedInstance.HasHTTPStatus()
 		isPodReady := mostAdvancedInstance.IsPodReady
 
-		if hasHTTPStatus && !isPodReady {
+		if hasHTTPStatus && !isPodReady && !isReadinessGatedOnStreaming(cluster, mostAdvancedInstance) {
 			// The readiness probe status from the Kubelet is not updated, so
 			// we need to wait for it to be refreshed
 			contextLogger.Info(
@@ -381,6 +418,10 @@ func (r *ClusterReconciler) handleSwitchover(
 			contextLogger.Info("Waiting for all WAL receivers to be down to elect a new primary")
 			return &ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 		}
+		if err == ErrWaitingOnManualFailover {
+			contextLogger.Info("Waiting for a replica to be manually promoted to designated primary")
+			return &ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		}
 		contextLogger.Info("Cannot update target primary: operation cannot be fulfilled. "+
 			"An immediate retry will be scheduled",
 			"cluster", cluster.Name)
@@ -1044,6 +1085,20 @@ func (r *ClusterReconciler) mapConfigMapsToClusters() handler.MapFunc {
 	}
 }
 
+// isReadinessGatedOnStreaming checks whether the passed instance is the
+// designated primary of a replica cluster which is not ready because it
+// doesn't stream from the source, or is too far behind it, as requested by
+// the replica cluster configuration. Its readiness probe is expected to fail
+// until the streaming resumes and the designated primary catches up
+func isReadinessGatedOnStreaming(cluster *apiv1.Cluster, status postgres.PostgresqlStatus) bool {
+	if !cluster.IsReplica() || status.Pod == nil || status.Pod.Name != cluster.Status.CurrentPrimary {
+		return false
+	}
+
+	return (cluster.Spec.ReplicaCluster.ReadinessRequiresStreaming && !status.IsWalReceiverActive) ||
+		status.IsReplicaClusterCatchingUp
+}
+
 // filterClustersUsingConfigMap returns a list of reconcile.Request for the clusters
 // that reference the secret
 func filterClustersUsingSecret(
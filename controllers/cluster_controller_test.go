@@ -22,6 +22,7 @@ import (
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -66,6 +67,54 @@ var _ = Describe("Filtering cluster", func() {
 	})
 })
 
+var _ = Describe("readiness of the designated primary gated on streaming", func() {
+	var cluster *apiv1.Cluster
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{
+					Source:                     "source",
+					Enabled:                    true,
+					ReadinessRequiresStreaming: true,
+				},
+			},
+			Status: apiv1.ClusterStatus{CurrentPrimary: "cluster-example-1"},
+		}
+	})
+
+	newStatus := func(podName string, walReceiverActive bool) postgres.PostgresqlStatus {
+		return postgres.PostgresqlStatus{
+			Pod:                 &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: podName}},
+			IsWalReceiverActive: walReceiverActive,
+		}
+	}
+
+	It("detects the designated primary not streaming from the source", func() {
+		Expect(isReadinessGatedOnStreaming(cluster, newStatus("cluster-example-1", false))).To(BeTrue())
+	})
+
+	It("ignores the designated primary streaming from the source", func() {
+		Expect(isReadinessGatedOnStreaming(cluster, newStatus("cluster-example-1", true))).To(BeFalse())
+	})
+
+	It("ignores the replicas of the replica cluster", func() {
+		Expect(isReadinessGatedOnStreaming(cluster, newStatus("cluster-example-2", false))).To(BeFalse())
+	})
+
+	It("ignores the designated primary when the readiness is not gated", func() {
+		cluster.Spec.ReplicaCluster.ReadinessRequiresStreaming = false
+		Expect(isReadinessGatedOnStreaming(cluster, newStatus("cluster-example-1", false))).To(BeFalse())
+	})
+
+	It("detects the designated primary catching up with the source", func() {
+		cluster.Spec.ReplicaCluster.ReadinessRequiresStreaming = false
+		status := newStatus("cluster-example-1", true)
+		status.IsReplicaClusterCatchingUp = true
+		Expect(isReadinessGatedOnStreaming(cluster, status)).To(BeTrue())
+	})
+})
+
 var _ = Describe("Updating target primary", func() {
 	It("selects the new target primary right away", func() {
 		ctx := context.TODO()
@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"time"
@@ -42,6 +43,7 @@ import (
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/certs"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/backup/volumesnapshot"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/persistentvolumeclaim"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/resources"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/specs"
@@ -895,7 +897,8 @@ func (r *ClusterReconciler) createPrimaryInstance(
 ) (ctrl.Result, error) {
 	contextLogger := log.FromContext(ctx)
 
-	if cluster.Status.LatestGeneratedNode != 0 {
+	isReseeding := cluster.Status.ReplicaReseed != nil && cluster.Status.ReplicaReseed.InProgress
+	if cluster.Status.LatestGeneratedNode != 0 && !isReseeding {
 		// We are we creating a new blank primary when we had previously generated
 		// other nodes, and we don't have any PVC to reuse?
 		// This can happen when:
@@ -952,8 +955,49 @@ func (r *ClusterReconciler) createPrimaryInstance(
 		}
 
 		if cluster.Spec.Bootstrap.Recovery.VolumeSnapshots != nil {
+			if err := volumesnapshot.VerifyRecoverySnapshots(ctx, r.Client, cluster); err != nil {
+				switch {
+				case errors.Is(err, volumesnapshot.ErrMetadataChecksumMismatch):
+					contextLogger.Error(err, "Refusing to bootstrap from a volume snapshot with tampered metadata")
+					r.Recorder.Event(cluster, "Warning", "SnapshotChecksumMismatch", err.Error())
+					return ctrl.Result{RequeueAfter: time.Minute}, nil
+				case errors.Is(err, volumesnapshot.ErrInconsistentSnapshots) &&
+					cluster.Spec.Bootstrap.Recovery.Source != "":
+					// the WAL archive of the recovery source fills the gap between the snapshots
+					contextLogger.Info("Volume snapshots not consistent at the same LSN, relying on the WAL archive",
+						"reason", err.Error())
+					r.Recorder.Event(cluster, "Warning", "SnapshotLSNMismatch", err.Error())
+				case errors.Is(err, volumesnapshot.ErrInconsistentSnapshots):
+					contextLogger.Error(err, "Refusing to bootstrap from volume snapshots not consistent at the same LSN")
+					r.Recorder.Event(cluster, "Warning", "SnapshotLSNMismatch", err.Error())
+					return ctrl.Result{RequeueAfter: time.Minute}, nil
+				default:
+					return ctrl.Result{}, err
+				}
+			}
+
+			if err := volumesnapshot.VerifyRecoveryPostgresVersion(ctx, r.Client, cluster); err != nil {
+				if !errors.Is(err, volumesnapshot.ErrIncompatiblePostgresVersion) {
+					return ctrl.Result{}, err
+				}
+				contextLogger.Error(err, "Refusing to bootstrap from a volume snapshot of another PostgreSQL version")
+				r.Recorder.Event(cluster, "Warning", "SnapshotPostgresVersionMismatch", err.Error())
+				return ctrl.Result{RequeueAfter: time.Minute}, nil
+			}
+
 			r.Recorder.Event(cluster, "Normal", "CreatingInstance", "Primary instance (from volumeSnapshots)")
-			job = specs.CreatePrimaryJobViaRestoreSnapshot(*cluster, nodeSerial, backup)
+			requiredExtensions, errExtensions := volumesnapshot.GetRequiredExtensions(ctx, r.Client, cluster)
+			if errExtensions != nil {
+				contextLogger.Error(errExtensions, "while reading the extensions recorded in the volume snapshot")
+			}
+			// the snapshots taken online can't be restored without their backup label
+			backupLabel, tablespaceMap, errLabel := volumesnapshot.GetBackupLabel(ctx, r.Client, cluster)
+			if errLabel != nil {
+				return ctrl.Result{}, fmt.Errorf("while reading the backup label recorded in the volume snapshot: %w",
+					errLabel)
+			}
+			job = specs.CreatePrimaryJobViaRestoreSnapshot(
+				*cluster, nodeSerial, backup, requiredExtensions, backupLabel, tablespaceMap)
 			break
 		}
 
@@ -0,0 +1,223 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/specs"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// setReplicaReseedStatus tracks since when the designated primary of a
+// replica cluster configured for automatic re-seed is stalled, and detects
+// the end of a re-seed. The status is removed when the automatic re-seed is
+// not enabled. It relies on the status of the source being already updated
+func setReplicaReseedStatus(cluster *apiv1.Cluster, statuses postgres.PostgresqlStatusList, now time.Time) {
+	if !cluster.IsReplica() || cluster.Spec.ReplicaCluster.AutomaticReseed == nil {
+		cluster.Status.ReplicaReseed = nil
+		return
+	}
+
+	var status apiv1.ReplicaReseedStatus
+	if cluster.Status.ReplicaReseed != nil {
+		status = *cluster.Status.ReplicaReseed
+	}
+
+	designatedPrimary := getDesignatedPrimaryStatus(cluster, statuses)
+	if status.InProgress && isReseededPrimaryReady(status, designatedPrimary) {
+		status.InProgress = false
+	}
+
+	stalledLSN, stalled := isDesignatedPrimaryStalled(cluster, designatedPrimary)
+	switch {
+	case status.InProgress || !stalled:
+		status.StalledSince = ""
+		status.StalledLSN = ""
+	case status.StalledLSN != string(stalledLSN):
+		status.StalledSince = now.Format(time.RFC3339)
+		status.StalledLSN = string(stalledLSN)
+	}
+
+	cluster.Status.ReplicaReseed = &status
+}
+
+// getDesignatedPrimaryStatus returns the status of the designated primary
+// of the passed cluster, or nil if it is not available
+func getDesignatedPrimaryStatus(
+	cluster *apiv1.Cluster,
+	statuses postgres.PostgresqlStatusList,
+) *postgres.PostgresqlStatus {
+	for i := range statuses.Items {
+		item := &statuses.Items[i]
+		if item.Pod != nil && item.Pod.Name == cluster.Status.CurrentPrimary {
+			return item
+		}
+	}
+
+	return nil
+}
+
+// isReseededPrimaryReady tells whether the designated primary has been
+// bootstrapped again by the re-seed in progress and is ready. The instance
+// which existed before the re-seed may still report its status while being
+// deleted, and is recognized by having been created before the re-seed
+func isReseededPrimaryReady(
+	status apiv1.ReplicaReseedStatus,
+	designatedPrimary *postgres.PostgresqlStatus,
+) bool {
+	if designatedPrimary == nil || designatedPrimary.Error != nil || designatedPrimary.Pod == nil {
+		return false
+	}
+
+	lastReseed, err := time.Parse(time.RFC3339, status.LastReseedTime)
+	if err != nil {
+		return false
+	}
+
+	pod := designatedPrimary.Pod
+	return !pod.CreationTimestamp.Time.Before(lastReseed) && utils.IsPodReady(*pod)
+}
+
+// isDesignatedPrimaryStalled tells whether the designated primary is not
+// following a reachable source which is ahead of it, returning the last LSN
+// it replayed. A designated primary which paused streaming on purpose is
+// never considered stalled
+func isDesignatedPrimaryStalled(
+	cluster *apiv1.Cluster,
+	designatedPrimary *postgres.PostgresqlStatus,
+) (postgres.LSN, bool) {
+	if designatedPrimary == nil || designatedPrimary.Error != nil {
+		return "", false
+	}
+
+	if designatedPrimary.IsWalReceiverActive || designatedPrimary.IsReplicaStreamingPaused {
+		return "", false
+	}
+
+	sourceStatus := cluster.Status.SourceStatus
+	if sourceStatus == nil || !sourceStatus.Reachable || sourceStatus.CaughtUp || sourceStatus.LagBytes == 0 {
+		return "", false
+	}
+
+	return designatedPrimary.ReplayLsn, true
+}
+
+// isReplicaReseedDue tells whether the designated primary of the passed
+// replica cluster has been stalled for longer than the configured timeout,
+// and the minimum interval from the last re-seed has passed
+func isReplicaReseedDue(cluster *apiv1.Cluster, now time.Time) bool {
+	if !cluster.IsReplica() ||
+		cluster.Spec.ReplicaCluster.AutomaticReseed == nil ||
+		cluster.Status.ReplicaReseed == nil {
+		return false
+	}
+
+	configuration := cluster.Spec.ReplicaCluster.AutomaticReseed
+	status := cluster.Status.ReplicaReseed
+	if status.InProgress || status.StalledSince == "" {
+		return false
+	}
+
+	stalledSince, err := time.Parse(time.RFC3339, status.StalledSince)
+	if err != nil || now.Sub(stalledSince) < configuration.GetStalledTimeout() {
+		return false
+	}
+
+	if status.LastReseedTime == "" {
+		return true
+	}
+
+	lastReseed, err := time.Parse(time.RFC3339, status.LastReseedTime)
+	return err == nil && now.Sub(lastReseed) >= configuration.GetMinInterval()
+}
+
+// reconcileReplicaReseed re-seeds the replica cluster when its designated
+// primary has been stalled for longer than the configured timeout, by
+// deleting every instance together with its PVCs. The designated primary
+// is then bootstrapped again like the first instance of a new cluster
+func (r *ClusterReconciler) reconcileReplicaReseed(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	resources *managedResources,
+) (*ctrl.Result, error) {
+	now := time.Now()
+	if !isReplicaReseedDue(cluster, now) {
+		return nil, nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+	contextLogger.Warning("The designated primary is stalled, re-seeding the replica cluster",
+		"stalledSince", cluster.Status.ReplicaReseed.StalledSince,
+		"stalledLSN", cluster.Status.ReplicaReseed.StalledLSN)
+	r.Recorder.Eventf(cluster, "Warning", "ReplicaReseed",
+		"The designated primary is stalled at LSN %s since %s, re-seeding the replica cluster",
+		cluster.Status.ReplicaReseed.StalledLSN, cluster.Status.ReplicaReseed.StalledSince)
+
+	// The status is updated before deleting anything, so that the re-seed
+	// is never repeated before the minimum interval, even when interrupted
+	origCluster := cluster.DeepCopy()
+	cluster.Status.ReplicaReseed = &apiv1.ReplicaReseedStatus{
+		LastReseedTime: now.Format(time.RFC3339),
+		InProgress:     true,
+	}
+	if err := r.Status().Patch(ctx, cluster, client.MergeFrom(origCluster)); err != nil {
+		return nil, err
+	}
+
+	for _, instanceName := range getInstanceNames(cluster.Name, resources) {
+		contextLogger.Info("Deleting instance to re-seed the replica cluster", "instance", instanceName)
+		if err := r.ensureInstanceIsDeleted(ctx, cluster, instanceName); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ctrl.Result{RequeueAfter: time.Second}, nil
+}
+
+// getInstanceNames returns the names of the instances having either
+// a Pod or a PVC among the passed resources
+func getInstanceNames(clusterName string, resources *managedResources) []string {
+	var result []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+
+	for _, pod := range resources.instances.Items {
+		add(pod.Name)
+	}
+	for _, pvc := range resources.pvcs.Items {
+		serial, err := specs.GetNodeSerial(pvc.ObjectMeta)
+		if err != nil {
+			continue
+		}
+		add(specs.GetInstanceName(clusterName, serial))
+	}
+
+	return result
+}
@@ -0,0 +1,230 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("replica cluster automatic re-seed", func() {
+	start := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	newCluster := func() *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-replica"},
+			Spec: apiv1.ClusterSpec{
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{
+					Enabled:            true,
+					Source:             "source",
+					ReportSourceStatus: true,
+					AutomaticReseed: &apiv1.ReplicaReseedConfiguration{
+						StalledTimeout: 600,
+						MinInterval:    3600,
+					},
+				},
+			},
+			Status: apiv1.ClusterStatus{
+				CurrentPrimary: "cluster-replica-1",
+				TargetPrimary:  "cluster-replica-1",
+				SourceStatus: &apiv1.ReplicaSourceStatus{
+					Reachable:  true,
+					IsPrimary:  true,
+					CurrentLSN: "0/9000000",
+					LagBytes:   0x3000000,
+				},
+			},
+		}
+	}
+	designatedPrimary := func(replayLSN postgres.LSN, walReceiverActive bool) postgres.PostgresqlStatusList {
+		return postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				{
+					Pod:                 &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-replica-1"}},
+					ReplayLsn:           replayLSN,
+					IsWalReceiverActive: walReceiverActive,
+				},
+			},
+		}
+	}
+
+	It("tracks since when the designated primary is stalled", func() {
+		cluster := newCluster()
+
+		setReplicaReseedStatus(cluster, designatedPrimary("0/6000000", false), start)
+		Expect(cluster.Status.ReplicaReseed.StalledLSN).To(Equal("0/6000000"))
+		Expect(cluster.Status.ReplicaReseed.StalledSince).To(Equal(start.Format(time.RFC3339)))
+
+		// the stall keeps its beginning while the LSN doesn't move
+		setReplicaReseedStatus(cluster, designatedPrimary("0/6000000", false), start.Add(time.Minute))
+		Expect(cluster.Status.ReplicaReseed.StalledSince).To(Equal(start.Format(time.RFC3339)))
+
+		// replaying WAL, for example from the archive, restarts it
+		setReplicaReseedStatus(cluster, designatedPrimary("0/6100000", false), start.Add(2*time.Minute))
+		Expect(cluster.Status.ReplicaReseed.StalledLSN).To(Equal("0/6100000"))
+		Expect(cluster.Status.ReplicaReseed.StalledSince).To(Equal(start.Add(2 * time.Minute).Format(time.RFC3339)))
+	})
+
+	It("doesn't consider stalled a designated primary which is streaming", func() {
+		cluster := newCluster()
+
+		setReplicaReseedStatus(cluster, designatedPrimary("0/6000000", true), start)
+		Expect(cluster.Status.ReplicaReseed.StalledSince).To(BeEmpty())
+	})
+
+	It("doesn't consider stalled a designated primary which paused streaming", func() {
+		cluster := newCluster()
+		statuses := designatedPrimary("0/6000000", false)
+		statuses.Items[0].IsReplicaStreamingPaused = true
+
+		setReplicaReseedStatus(cluster, statuses, start)
+		Expect(cluster.Status.ReplicaReseed.StalledSince).To(BeEmpty())
+	})
+
+	It("doesn't consider stalled a designated primary when the source is unreachable", func() {
+		cluster := newCluster()
+		cluster.Status.SourceStatus = &apiv1.ReplicaSourceStatus{Error: "connection refused"}
+
+		setReplicaReseedStatus(cluster, designatedPrimary("0/6000000", false), start)
+		Expect(cluster.Status.ReplicaReseed.StalledSince).To(BeEmpty())
+	})
+
+	It("doesn't consider stalled a designated primary which caught up with the source", func() {
+		cluster := newCluster()
+		cluster.Status.SourceStatus.CaughtUp = true
+		cluster.Status.SourceStatus.LagBytes = 0
+
+		setReplicaReseedStatus(cluster, designatedPrimary("0/9000000", false), start)
+		Expect(cluster.Status.ReplicaReseed.StalledSince).To(BeEmpty())
+	})
+
+	It("doesn't consider stalled a designated primary whose status is not available", func() {
+		cluster := newCluster()
+		statuses := designatedPrimary("0/6000000", false)
+		statuses.Items[0].Error = errors.New("connection refused")
+
+		setReplicaReseedStatus(cluster, statuses, start)
+		Expect(cluster.Status.ReplicaReseed.StalledSince).To(BeEmpty())
+	})
+
+	It("re-seeds only after the stalled timeout", func() {
+		cluster := newCluster()
+		setReplicaReseedStatus(cluster, designatedPrimary("0/6000000", false), start)
+
+		Expect(isReplicaReseedDue(cluster, start.Add(5*time.Minute))).To(BeFalse())
+		Expect(isReplicaReseedDue(cluster, start.Add(10*time.Minute))).To(BeTrue())
+	})
+
+	It("doesn't re-seed again before the minimum interval", func() {
+		cluster := newCluster()
+		setReplicaReseedStatus(cluster, designatedPrimary("0/6000000", false), start)
+		cluster.Status.ReplicaReseed.LastReseedTime = start.Add(-30 * time.Minute).Format(time.RFC3339)
+
+		Expect(isReplicaReseedDue(cluster, start.Add(10*time.Minute))).To(BeFalse())
+		Expect(isReplicaReseedDue(cluster, start.Add(30*time.Minute))).To(BeTrue())
+	})
+
+	It("doesn't re-seed while a re-seed is in progress", func() {
+		cluster := newCluster()
+		cluster.Status.ReplicaReseed = &apiv1.ReplicaReseedStatus{
+			LastReseedTime: start.Add(-2 * time.Hour).Format(time.RFC3339),
+			InProgress:     true,
+		}
+
+		setReplicaReseedStatus(cluster, postgres.PostgresqlStatusList{}, start)
+		Expect(cluster.Status.ReplicaReseed.InProgress).To(BeTrue())
+		Expect(isReplicaReseedDue(cluster, start.Add(time.Hour))).To(BeFalse())
+
+		// the instance existing before the re-seed is still reporting its status
+		statuses := designatedPrimary("0/9000000", true)
+		statuses.Items[0].Pod.CreationTimestamp = metav1.NewTime(start.Add(-3 * time.Hour))
+		statuses.Items[0].Pod.Status.Conditions = []corev1.PodCondition{
+			{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+		}
+		setReplicaReseedStatus(cluster, statuses, start)
+		Expect(cluster.Status.ReplicaReseed.InProgress).To(BeTrue())
+
+		// the designated primary has been bootstrapped again, but is not ready yet
+		statuses.Items[0].Pod.CreationTimestamp = metav1.NewTime(start.Add(-time.Hour))
+		statuses.Items[0].Pod.Status.Conditions = nil
+		setReplicaReseedStatus(cluster, statuses, start)
+		Expect(cluster.Status.ReplicaReseed.InProgress).To(BeTrue())
+
+		// the re-seed is completed once the new designated primary is ready
+		statuses.Items[0].Pod.Status.Conditions = []corev1.PodCondition{
+			{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+		}
+		setReplicaReseedStatus(cluster, statuses, start)
+		Expect(cluster.Status.ReplicaReseed.InProgress).To(BeFalse())
+		Expect(cluster.Status.ReplicaReseed.LastReseedTime).ToNot(BeEmpty())
+	})
+
+	It("removes the status when disabled", func() {
+		cluster := newCluster()
+		setReplicaReseedStatus(cluster, designatedPrimary("0/6000000", false), start)
+		Expect(cluster.Status.ReplicaReseed).ToNot(BeNil())
+
+		cluster.Spec.ReplicaCluster.AutomaticReseed = nil
+		setReplicaReseedStatus(cluster, designatedPrimary("0/6000000", false), start)
+		Expect(cluster.Status.ReplicaReseed).To(BeNil())
+		Expect(isReplicaReseedDue(cluster, start.Add(24*time.Hour))).To(BeFalse())
+	})
+
+	It("defaults the timeouts", func() {
+		configuration := &apiv1.ReplicaReseedConfiguration{}
+		Expect(configuration.GetStalledTimeout()).To(Equal(30 * time.Minute))
+		Expect(configuration.GetMinInterval()).To(Equal(24 * time.Hour))
+
+		configuration.MinInterval = 60
+		Expect(configuration.GetMinInterval()).To(Equal(time.Hour))
+	})
+
+	It("finds the instances to be deleted from the Pods and the PVCs", func() {
+		resources := &managedResources{
+			instances: corev1.PodList{
+				Items: []corev1.Pod{
+					{ObjectMeta: metav1.ObjectMeta{Name: "cluster-replica-1"}},
+				},
+			},
+			pvcs: corev1.PersistentVolumeClaimList{
+				Items: []corev1.PersistentVolumeClaim{
+					{ObjectMeta: metav1.ObjectMeta{
+						Name:        "cluster-replica-1",
+						Annotations: map[string]string{utils.ClusterSerialAnnotationName: "1"},
+					}},
+					{ObjectMeta: metav1.ObjectMeta{
+						Name:        "cluster-replica-2",
+						Annotations: map[string]string{utils.ClusterSerialAnnotationName: "2"},
+					}},
+				},
+			},
+		}
+
+		Expect(getInstanceNames("cluster-replica", resources)).To(
+			ConsistOf("cluster-replica-1", "cluster-replica-2"))
+	})
+})
@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strings"
+	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -247,6 +249,8 @@ func (r *ClusterReconciler) updateResourceStatus(
 		cluster,
 		resources.instances.Items,
 	)
+	setBackupProtectionCondition(cluster, time.Now())
+	setHASlotPrefixes(cluster)
 
 	// Count jobs
 	newJobs := int32(len(resources.jobs.Items))
@@ -336,6 +340,75 @@ func (r *ClusterReconciler) updateResourceStatus(
 	return nil
 }
 
+// setHASlotPrefixes records the prefix currently used for the HA replication
+// slots at the end of the list of the prefixes used over time, so that the
+// primary can remove the slots left behind by a change of the prefix
+func setHASlotPrefixes(cluster *apiv1.Cluster) {
+	if cluster.Spec.ReplicationSlots == nil || !cluster.Spec.ReplicationSlots.HighAvailability.GetEnabled() {
+		return
+	}
+
+	currentPrefix := cluster.Spec.ReplicationSlots.HighAvailability.GetSlotPrefix()
+	prefixes := cluster.Status.HASlotPrefixes
+	if len(prefixes) > 0 && prefixes[len(prefixes)-1] == currentPrefix {
+		return
+	}
+
+	updatedPrefixes := make([]string, 0, len(prefixes)+1)
+	for _, prefix := range prefixes {
+		if prefix != currentPrefix {
+			updatedPrefixes = append(updatedPrefixes, prefix)
+		}
+	}
+	updatedPrefixes = append(updatedPrefixes, currentPrefix)
+
+	if len(updatedPrefixes) > apiv1.MaxHASlotPrefixes {
+		updatedPrefixes = updatedPrefixes[len(updatedPrefixes)-apiv1.MaxHASlotPrefixes:]
+	}
+
+	cluster.Status.HASlotPrefixes = updatedPrefixes
+}
+
+// setBackupProtectionCondition sets the BackupProtected condition of the
+// cluster, depending on the age of the last successful backup. The condition
+// is reported only when the backup protection check has been enabled
+func setBackupProtectionCondition(cluster *apiv1.Cluster, now time.Time) {
+	// An invalid maximum age is refused by the validating webhook
+	maxAge, _ := cluster.Spec.Backup.GetBackupProtectionMaxAge()
+
+	// The conditions are copied to let the caller detect the change
+	conditions := make([]metav1.Condition, len(cluster.Status.Conditions))
+	copy(conditions, cluster.Status.Conditions)
+
+	if maxAge == 0 {
+		if meta.FindStatusCondition(conditions, string(apiv1.ConditionBackupProtected)) != nil {
+			meta.RemoveStatusCondition(&conditions, string(apiv1.ConditionBackupProtected))
+			cluster.Status.Conditions = conditions
+		}
+		return
+	}
+
+	condition := metav1.Condition{
+		Type:    string(apiv1.ConditionBackupProtected),
+		Status:  metav1.ConditionFalse,
+		Reason:  string(apiv1.ConditionReasonNoRecentBackup),
+		Message: fmt.Sprintf("No successful backup in the last %s", cluster.Spec.Backup.BackupProtectionMaxAge),
+	}
+
+	lastSuccessfulBackup, err := time.Parse(time.RFC3339, cluster.Status.LastSuccessfulBackup)
+	if err == nil && now.Sub(lastSuccessfulBackup) <= maxAge {
+		condition = metav1.Condition{
+			Type:    string(apiv1.ConditionBackupProtected),
+			Status:  metav1.ConditionTrue,
+			Reason:  string(apiv1.ConditionReasonRecentBackupAvailable),
+			Message: fmt.Sprintf("Last successful backup completed at %s", cluster.Status.LastSuccessfulBackup),
+		}
+	}
+
+	meta.SetStatusCondition(&conditions, condition)
+	cluster.Status.Conditions = conditions
+}
+
 // removeConditionsWithInvalidReason will remove every condition which has a not valid
 // reason from the K8s API point-of-view
 func (r *ClusterReconciler) removeConditionsWithInvalidReason(ctx context.Context, cluster *apiv1.Cluster) error {
@@ -764,12 +837,333 @@ func (r *ClusterReconciler) updateClusterStatusThatRequiresInstancesState(
 		}
 	}
 
+	setReplicaStreamingStatus(cluster, statuses)
+	setReplicaSourceStatus(cluster, statuses)
+	if previousTimeline, changed := setSourceTimelineID(cluster, statuses); changed && previousTimeline != 0 &&
+		cluster.Status.SourceTimelineID != 0 {
+		r.Recorder.Eventf(cluster, "Warning", "SourceTimelineChanged",
+			"The source of the replica cluster switched from timeline %d to %d",
+			previousTimeline, cluster.Status.SourceTimelineID)
+	}
+	setReplicaLocalArchiveStatus(cluster, statuses)
+	if previousSource, changed := setReplicaActiveSource(cluster, statuses); changed && previousSource != "" &&
+		cluster.Status.ReplicaActiveSource != "" {
+		r.Recorder.Eventf(cluster, "Warning", "ReplicaSourceSwitched",
+			"The designated primary switched from the source %s to %s",
+			previousSource, cluster.Status.ReplicaActiveSource)
+	}
+	setReplicaReseedStatus(cluster, statuses, time.Now())
+	if err := r.setSourceBackupFreshness(ctx, cluster, time.Now()); err != nil {
+		return err
+	}
+	if changed := setSourceWalLevelCondition(cluster, statuses); changed {
+		if condition := meta.FindStatusCondition(cluster.Status.Conditions,
+			string(apiv1.ConditionSourceWalLevelValid)); condition != nil && condition.Status == metav1.ConditionFalse {
+			r.Recorder.Event(cluster, "Warning", string(apiv1.ConditionReasonSourceWalLevelNotLogical),
+				condition.Message)
+		}
+	}
+
+	if invalidatedSlots, changed := setReplicationSlotsCondition(cluster, statuses); changed &&
+		len(invalidatedSlots) > 0 {
+		r.Recorder.Eventf(cluster, "Warning", string(apiv1.ConditionReasonReplicationSlotInvalidated),
+			"Replication slots invalidated, the standbys using them need to be re-seeded: %s",
+			strings.Join(invalidatedSlots, ", "))
+	}
+
 	if !reflect.DeepEqual(existingClusterStatus, cluster.Status) {
 		return r.Status().Update(ctx, cluster)
 	}
 	return nil
 }
 
+// setReplicaStreamingStatus reports in the cluster status whether the
+// designated primary paused streaming from the source of the replica cluster,
+// together with the LSN at which it is serving read-only queries
+func setReplicaStreamingStatus(cluster *apiv1.Cluster, statuses postgres.PostgresqlStatusList) {
+	cluster.Status.ReplicaStreamingPaused = false
+	cluster.Status.ReplicaStreamingPausedLSN = ""
+
+	for _, item := range statuses.Items {
+		if item.IsReplicaStreamingPaused {
+			cluster.Status.ReplicaStreamingPaused = true
+			cluster.Status.ReplicaStreamingPausedLSN = string(item.ReplayLsn)
+		}
+	}
+}
+
+// setReplicaSourceStatus reports in the cluster status the status of the
+// source of the replica cluster, as probed by the designated primary. The
+// last reported status is kept while the designated primary doesn't report
+// it, and is removed when the cluster doesn't request it anymore
+func setReplicaSourceStatus(cluster *apiv1.Cluster, statuses postgres.PostgresqlStatusList) {
+	if !cluster.IsReplica() || !cluster.Spec.ReplicaCluster.ReportSourceStatus {
+		cluster.Status.SourceStatus = nil
+		return
+	}
+
+	for _, item := range statuses.Items {
+		if item.SourceStatus == nil {
+			continue
+		}
+
+		sourceStatus := &apiv1.ReplicaSourceStatus{
+			Reachable:  item.SourceStatus.Reachable,
+			IsPrimary:  item.SourceStatus.IsPrimary,
+			CurrentLSN: string(item.SourceStatus.CurrentLsn),
+			WalLevel:   item.SourceStatus.WalLevel,
+			Error:      item.SourceStatus.Error,
+		}
+		if item.SourceStatus.Reachable {
+			if lag, ok := getSourceReplayLag(item.SourceStatus.CurrentLsn, item.ReplayLsn); ok {
+				sourceStatus.CaughtUp = lag == 0
+				sourceStatus.LagBytes = lag
+			}
+		}
+		cluster.Status.SourceStatus = sourceStatus
+		return
+	}
+}
+
+// setSourceTimelineID reports in the cluster status the timeline of the
+// source of the replica cluster, as probed by the designated primary. The
+// last known timeline is kept while the source is not reachable. It returns
+// the previous timeline and whether it has been changed, as a timeline switch
+// means that the source has been promoted
+func setSourceTimelineID(cluster *apiv1.Cluster, statuses postgres.PostgresqlStatusList) (int, bool) {
+	previousTimeline := cluster.Status.SourceTimelineID
+	if !cluster.IsReplica() || !cluster.Spec.ReplicaCluster.ReportSourceStatus {
+		cluster.Status.SourceTimelineID = 0
+		return previousTimeline, previousTimeline != 0
+	}
+
+	for _, item := range statuses.Items {
+		if item.SourceStatus != nil && item.SourceStatus.Reachable && item.SourceStatus.TimelineID != 0 {
+			cluster.Status.SourceTimelineID = item.SourceStatus.TimelineID
+			break
+		}
+	}
+
+	return previousTimeline, previousTimeline != cluster.Status.SourceTimelineID
+}
+
+// setReplicaLocalArchiveStatus reports in the cluster status the status of
+// the WAL archiving of the designated primary, when the replica cluster
+// maintains its own WAL archive. The last reported status is kept while the
+// designated primary doesn't report it
+func setReplicaLocalArchiveStatus(cluster *apiv1.Cluster, statuses postgres.PostgresqlStatusList) {
+	if !cluster.IsReplica() || !cluster.Spec.ReplicaCluster.LocalArchive {
+		cluster.Status.LocalArchiveStatus = nil
+		return
+	}
+
+	for _, item := range statuses.Items {
+		if item.Pod == nil || item.Pod.Name != cluster.Status.CurrentPrimary {
+			continue
+		}
+
+		cluster.Status.LocalArchiveStatus = &apiv1.ReplicaLocalArchiveStatus{
+			Archiving:           item.IsArchivingWAL,
+			LastArchivedWAL:     item.LastArchivedWAL,
+			LastArchivedWALTime: item.LastArchivedWALTime,
+			LastFailedWAL:       item.LastFailedWAL,
+			LastFailedWALTime:   item.LastFailedWALTime,
+		}
+		return
+	}
+}
+
+// setSourceBackupFreshness reports in the cluster status whether the source
+// of the replica cluster, when it is a Cluster managed by this operator, has
+// a successful backup more recent than the configured maximum age. A source
+// Cluster which is not found has no fresh backup
+func (r *ClusterReconciler) setSourceBackupFreshness(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	now time.Time,
+) error {
+	if !cluster.IsReplica() || cluster.Spec.ReplicaCluster.SourceBackupCheck == nil {
+		cluster.Status.SourceBackupFresh = nil
+		cluster.Status.SourceLastSuccessfulBackup = ""
+		return nil
+	}
+
+	check := cluster.Spec.ReplicaCluster.SourceBackupCheck
+	// An invalid maximum age is refused by the CRD validation
+	maxAge, _ := check.GetMaxAge()
+
+	var source apiv1.Cluster
+	err := r.Get(ctx, client.ObjectKey{
+		Namespace: check.GetNamespace(cluster.Namespace),
+		Name:      check.GetClusterName(cluster.Spec.ReplicaCluster.Source),
+	}, &source)
+	if err != nil && !apierrs.IsNotFound(err) {
+		return err
+	}
+
+	cluster.Status.SourceLastSuccessfulBackup = source.Status.LastSuccessfulBackup
+	lastSuccessfulBackup, err := time.Parse(time.RFC3339, source.Status.LastSuccessfulBackup)
+	fresh := err == nil && now.Sub(lastSuccessfulBackup) <= maxAge
+	cluster.Status.SourceBackupFresh = &fresh
+	return nil
+}
+
+// setReplicaActiveSource reports in the cluster status the source the
+// designated primary of a replica cluster with fallback sources streams
+// from. Returns the previously reported source, and whether it changed
+func setReplicaActiveSource(cluster *apiv1.Cluster, statuses postgres.PostgresqlStatusList) (string, bool) {
+	previousSource := cluster.Status.ReplicaActiveSource
+	if !cluster.IsReplica() || len(cluster.Spec.ReplicaCluster.FallbackSources) == 0 {
+		cluster.Status.ReplicaActiveSource = ""
+		return previousSource, previousSource != ""
+	}
+
+	for _, item := range statuses.Items {
+		if item.ActiveReplicaSource != "" {
+			cluster.Status.ReplicaActiveSource = item.ActiveReplicaSource
+			break
+		}
+	}
+
+	return previousSource, previousSource != cluster.Status.ReplicaActiveSource
+}
+
+// getSourceReplayLag computes the amount of WAL, in bytes, the designated
+// primary still needs to replay to reach the passed LSN of the source. As
+// the source is probed before collecting the status of the designated
+// primary, the latter may be ahead: this is reported as no lag. The second
+// value is false when any of the LSNs is unknown
+func getSourceReplayLag(sourceLSN, replayLSN postgres.LSN) (int64, bool) {
+	source, err := sourceLSN.Parse()
+	if err != nil {
+		return 0, false
+	}
+
+	replay, err := replayLSN.Parse()
+	if err != nil {
+		return 0, false
+	}
+
+	if replay >= source {
+		return 0, true
+	}
+
+	return source - replay, true
+}
+
+// setSourceWalLevelCondition sets the SourceWalLevelValid condition of a
+// replica cluster requiring logical decoding, depending on the `wal_level`
+// of the source reported by the designated primary. The condition is left
+// untouched if the source couldn't be probed, and removed when logical
+// decoding is not required. It returns whether the condition changed
+func setSourceWalLevelCondition(cluster *apiv1.Cluster, statuses postgres.PostgresqlStatusList) bool {
+	conditionType := string(apiv1.ConditionSourceWalLevelValid)
+	if !cluster.IsReplica() || !cluster.Spec.ReplicaCluster.LogicalDecoding {
+		if meta.FindStatusCondition(cluster.Status.Conditions, conditionType) == nil {
+			return false
+		}
+		conditions := make([]metav1.Condition, len(cluster.Status.Conditions))
+		copy(conditions, cluster.Status.Conditions)
+		meta.RemoveStatusCondition(&conditions, conditionType)
+		cluster.Status.Conditions = conditions
+		return true
+	}
+
+	var walLevel string
+	for _, item := range statuses.Items {
+		if item.SourceStatus != nil && item.SourceStatus.Reachable && item.SourceStatus.WalLevel != "" {
+			walLevel = item.SourceStatus.WalLevel
+			break
+		}
+	}
+	if walLevel == "" {
+		return false
+	}
+
+	condition := metav1.Condition{
+		Type:    conditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  string(apiv1.ConditionReasonSourceWalLevelLogical),
+		Message: "The source runs with wal_level set to logical",
+	}
+	if walLevel != "logical" {
+		condition = metav1.Condition{
+			Type:   conditionType,
+			Status: metav1.ConditionFalse,
+			Reason: string(apiv1.ConditionReasonSourceWalLevelNotLogical),
+			Message: fmt.Sprintf("The source runs with wal_level set to %s, "+
+				"logical decoding requires it to be set to logical", walLevel),
+		}
+	}
+
+	previousCondition := meta.FindStatusCondition(cluster.Status.Conditions, conditionType)
+	if previousCondition != nil && previousCondition.Status == condition.Status &&
+		previousCondition.Message == condition.Message {
+		return false
+	}
+
+	// The conditions are copied to let the caller detect the change
+	conditions := make([]metav1.Condition, len(cluster.Status.Conditions))
+	copy(conditions, cluster.Status.Conditions)
+	meta.SetStatusCondition(&conditions, condition)
+	cluster.Status.Conditions = conditions
+
+	return true
+}
+
+// setReplicationSlotsCondition sets the ReplicationSlotsValid condition of the
+// cluster, depending on the replication slots reported by the primary instance.
+// A slot is invalidated by PostgreSQL when the WAL files it requires exceed
+// max_slot_wal_keep_size. The condition is left untouched if the primary didn't
+// report its status. It returns the names of the invalidated slots, together
+// with a flag telling whether the condition changed
+func setReplicationSlotsCondition(
+	cluster *apiv1.Cluster,
+	statuses postgres.PostgresqlStatusList,
+) ([]string, bool) {
+	var primaryStatus *postgres.PostgresqlStatus
+	for idx := range statuses.Items {
+		if statuses.Items[idx].IsPrimary && statuses.Items[idx].Error == nil {
+			primaryStatus = &statuses.Items[idx]
+			break
+		}
+	}
+	if primaryStatus == nil {
+		return nil, false
+	}
+
+	invalidatedSlots := primaryStatus.ReplicationSlotsInfo.GetInvalidatedSlotNames()
+	condition := metav1.Condition{
+		Type:    string(apiv1.ConditionReplicationSlotsValid),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(apiv1.ConditionReasonReplicationSlotsValid),
+		Message: "No replication slot has been invalidated",
+	}
+	if len(invalidatedSlots) > 0 {
+		condition = metav1.Condition{
+			Type:   string(apiv1.ConditionReplicationSlotsValid),
+			Status: metav1.ConditionFalse,
+			Reason: string(apiv1.ConditionReasonReplicationSlotInvalidated),
+			Message: fmt.Sprintf("Replication slots invalidated by max_slot_wal_keep_size: %s",
+				strings.Join(invalidatedSlots, ", ")),
+		}
+	}
+
+	previousCondition := meta.FindStatusCondition(cluster.Status.Conditions, condition.Type)
+	if previousCondition != nil && previousCondition.Status == condition.Status &&
+		previousCondition.Message == condition.Message {
+		return invalidatedSlots, false
+	}
+
+	// The conditions are copied to let the caller detect the change
+	conditions := make([]metav1.Condition, len(cluster.Status.Conditions))
+	copy(conditions, cluster.Status.Conditions)
+	meta.SetStatusCondition(&conditions, condition)
+	cluster.Status.Conditions = conditions
+
+	return invalidatedSlots, true
+}
+
 // getPodsTopology returns a map with all the information about the pods topology
 func getPodsTopology(
 	ctx context.Context,
@@ -18,14 +18,21 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	v1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/certs"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/persistentvolumeclaim"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -166,3 +173,682 @@ var _ = Describe("cluster_status unit tests", func() {
 		})
 	})
 })
+
+var _ = Describe("backup protection condition", func() {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	newCluster := func(maxAge, lastSuccessfulBackup string) *v1.Cluster {
+		return &v1.Cluster{
+			Spec: v1.ClusterSpec{
+				Backup: &v1.BackupConfiguration{
+					BackupProtectionMaxAge: maxAge,
+				},
+			},
+			Status: v1.ClusterStatus{
+				LastSuccessfulBackup: lastSuccessfulBackup,
+			},
+		}
+	}
+
+	It("is not reported when the backup protection is not enabled", func() {
+		cluster := newCluster("", now.Format(time.RFC3339))
+		setBackupProtectionCondition(cluster, now)
+		Expect(meta.FindStatusCondition(cluster.Status.Conditions, string(v1.ConditionBackupProtected))).To(BeNil())
+	})
+
+	It("is removed when the backup protection is disabled", func() {
+		cluster := newCluster("1d", now.Format(time.RFC3339))
+		setBackupProtectionCondition(cluster, now)
+		Expect(cluster.Status.Conditions).To(HaveLen(1))
+
+		cluster.Spec.Backup.BackupProtectionMaxAge = ""
+		setBackupProtectionCondition(cluster, now)
+		Expect(cluster.Status.Conditions).To(BeEmpty())
+	})
+
+	It("is true when there's a recent successful backup", func() {
+		cluster := newCluster("1d", now.Add(-2*time.Hour).Format(time.RFC3339))
+		setBackupProtectionCondition(cluster, now)
+
+		condition := meta.FindStatusCondition(cluster.Status.Conditions, string(v1.ConditionBackupProtected))
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(string(v1.ConditionReasonRecentBackupAvailable)))
+	})
+
+	It("is false when the last successful backup is too old", func() {
+		cluster := newCluster("1d", now.Add(-25*time.Hour).Format(time.RFC3339))
+		setBackupProtectionCondition(cluster, now)
+
+		condition := meta.FindStatusCondition(cluster.Status.Conditions, string(v1.ConditionBackupProtected))
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(string(v1.ConditionReasonNoRecentBackup)))
+	})
+
+	It("is false when there are no successful backups", func() {
+		cluster := newCluster("1d", "")
+		setBackupProtectionCondition(cluster, now)
+
+		condition := meta.FindStatusCondition(cluster.Status.Conditions, string(v1.ConditionBackupProtected))
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(string(v1.ConditionReasonNoRecentBackup)))
+	})
+})
+
+var _ = Describe("source backup freshness", func() {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	newReplicaCluster := func() *v1.Cluster {
+		return &v1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-dr", Namespace: "default"},
+			Spec: v1.ClusterSpec{
+				ReplicaCluster: &v1.ReplicaClusterConfiguration{
+					Enabled: true,
+					Source:  "cluster-example",
+					SourceBackupCheck: &v1.ReplicaSourceBackupCheck{
+						MaxAge: "1d",
+					},
+				},
+			},
+		}
+	}
+
+	newReconciler := func(lastSuccessfulBackup string) *ClusterReconciler {
+		source := &v1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Status: v1.ClusterStatus{
+				LastSuccessfulBackup: lastSuccessfulBackup,
+			},
+		}
+		return &ClusterReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+				WithObjects(source).
+				WithStatusSubresource(source).
+				Build(),
+		}
+	}
+
+	It("is fresh when the source has a recent successful backup", func(ctx SpecContext) {
+		lastSuccessfulBackup := now.Add(-2 * time.Hour).Format(time.RFC3339)
+		cluster := newReplicaCluster()
+		Expect(newReconciler(lastSuccessfulBackup).setSourceBackupFreshness(ctx, cluster, now)).To(Succeed())
+		Expect(cluster.Status.SourceBackupFresh).To(HaveValue(BeTrue()))
+		Expect(cluster.Status.SourceLastSuccessfulBackup).To(Equal(lastSuccessfulBackup))
+	})
+
+	It("is not fresh when the last successful backup of the source is too old", func(ctx SpecContext) {
+		cluster := newReplicaCluster()
+		Expect(newReconciler(now.Add(-25*time.Hour).Format(time.RFC3339)).
+			setSourceBackupFreshness(ctx, cluster, now)).To(Succeed())
+		Expect(cluster.Status.SourceBackupFresh).To(HaveValue(BeFalse()))
+	})
+
+	It("is not fresh when the source has no successful backups", func(ctx SpecContext) {
+		cluster := newReplicaCluster()
+		Expect(newReconciler("").setSourceBackupFreshness(ctx, cluster, now)).To(Succeed())
+		Expect(cluster.Status.SourceBackupFresh).To(HaveValue(BeFalse()))
+		Expect(cluster.Status.SourceLastSuccessfulBackup).To(BeEmpty())
+	})
+
+	It("is not fresh when the source Cluster is not found", func(ctx SpecContext) {
+		cluster := newReplicaCluster()
+		cluster.Spec.ReplicaCluster.SourceBackupCheck.ClusterName = "cluster-missing"
+		Expect(newReconciler(now.Format(time.RFC3339)).setSourceBackupFreshness(ctx, cluster, now)).To(Succeed())
+		Expect(cluster.Status.SourceBackupFresh).To(HaveValue(BeFalse()))
+	})
+
+	It("looks for the source Cluster in the configured namespace", func(ctx SpecContext) {
+		cluster := newReplicaCluster()
+		cluster.Spec.ReplicaCluster.SourceBackupCheck.Namespace = "other"
+		Expect(newReconciler(now.Format(time.RFC3339)).setSourceBackupFreshness(ctx, cluster, now)).To(Succeed())
+		Expect(cluster.Status.SourceBackupFresh).To(HaveValue(BeFalse()))
+	})
+
+	It("is not reported when the check is not enabled", func(ctx SpecContext) {
+		cluster := newReplicaCluster()
+		Expect(newReconciler(now.Format(time.RFC3339)).setSourceBackupFreshness(ctx, cluster, now)).To(Succeed())
+		Expect(cluster.Status.SourceBackupFresh).ToNot(BeNil())
+
+		cluster.Spec.ReplicaCluster.SourceBackupCheck = nil
+		Expect(newReconciler(now.Format(time.RFC3339)).setSourceBackupFreshness(ctx, cluster, now)).To(Succeed())
+		Expect(cluster.Status.SourceBackupFresh).To(BeNil())
+		Expect(cluster.Status.SourceLastSuccessfulBackup).To(BeEmpty())
+	})
+})
+
+var _ = Describe("replica streaming status", func() {
+	It("reports the LSN where the designated primary paused streaming", func() {
+		cluster := &v1.Cluster{}
+		statuses := postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				{ReplayLsn: "0/5000060", IsReplicaStreamingPaused: true},
+				{ReplayLsn: "0/4000000"},
+			},
+		}
+
+		setReplicaStreamingStatus(cluster, statuses)
+		Expect(cluster.Status.ReplicaStreamingPaused).To(BeTrue())
+		Expect(cluster.Status.ReplicaStreamingPausedLSN).To(Equal("0/5000060"))
+	})
+
+	It("clears the status when streaming is resumed", func() {
+		cluster := &v1.Cluster{
+			Status: v1.ClusterStatus{
+				ReplicaStreamingPaused:    true,
+				ReplicaStreamingPausedLSN: "0/5000060",
+			},
+		}
+		statuses := postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				{ReplayLsn: "0/6000000"},
+			},
+		}
+
+		setReplicaStreamingStatus(cluster, statuses)
+		Expect(cluster.Status.ReplicaStreamingPaused).To(BeFalse())
+		Expect(cluster.Status.ReplicaStreamingPausedLSN).To(BeEmpty())
+	})
+})
+
+var _ = Describe("replica source status", func() {
+	newCluster := func() *v1.Cluster {
+		return &v1.Cluster{
+			Spec: v1.ClusterSpec{
+				ReplicaCluster: &v1.ReplicaClusterConfiguration{
+					Enabled:            true,
+					Source:             "source",
+					ReportSourceStatus: true,
+				},
+			},
+		}
+	}
+
+	It("reports a reachable source", func() {
+		cluster := newCluster()
+		statuses := postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				{SourceStatus: &postgres.SourceStatus{Reachable: true, IsPrimary: true, CurrentLsn: "0/7000000"}},
+				{ReplayLsn: "0/6000000"},
+			},
+		}
+
+		setReplicaSourceStatus(cluster, statuses)
+		Expect(cluster.Status.SourceStatus).To(Equal(&v1.ReplicaSourceStatus{
+			Reachable:  true,
+			IsPrimary:  true,
+			CurrentLSN: "0/7000000",
+		}))
+	})
+
+	It("reports a designated primary which caught up with the source", func() {
+		cluster := newCluster()
+		statuses := postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				{
+					SourceStatus: &postgres.SourceStatus{Reachable: true, IsPrimary: true, CurrentLsn: "0/7000000"},
+					ReplayLsn:    "0/7000000",
+				},
+			},
+		}
+
+		setReplicaSourceStatus(cluster, statuses)
+		Expect(cluster.Status.SourceStatus.CaughtUp).To(BeTrue())
+		Expect(cluster.Status.SourceStatus.LagBytes).To(BeZero())
+	})
+
+	It("reports a designated primary lagging behind the source", func() {
+		cluster := newCluster()
+		statuses := postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				{
+					SourceStatus: &postgres.SourceStatus{Reachable: true, IsPrimary: true, CurrentLsn: "0/7000000"},
+					ReplayLsn:    "0/6FF0000",
+				},
+			},
+		}
+
+		setReplicaSourceStatus(cluster, statuses)
+		Expect(cluster.Status.SourceStatus.CaughtUp).To(BeFalse())
+		Expect(cluster.Status.SourceStatus.LagBytes).To(BeEquivalentTo(0x10000))
+	})
+
+	It("computes the replay lag of the designated primary", func() {
+		Expect(getSourceReplayLag("1/0", "0/FFFFFF00")).To(BeEquivalentTo(0x100))
+		Expect(getSourceReplayLag("0/7000000", "0/7000100")).To(BeZero())
+
+		_, ok := getSourceReplayLag("0/7000000", "")
+		Expect(ok).To(BeFalse())
+		_, ok = getSourceReplayLag("", "0/7000000")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("reports an unreachable source", func() {
+		cluster := newCluster()
+		cluster.Status.SourceStatus = &v1.ReplicaSourceStatus{Reachable: true, IsPrimary: true}
+		statuses := postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				{SourceStatus: &postgres.SourceStatus{Error: "connection refused"}},
+			},
+		}
+
+		setReplicaSourceStatus(cluster, statuses)
+		Expect(cluster.Status.SourceStatus).To(Equal(&v1.ReplicaSourceStatus{Error: "connection refused"}))
+	})
+
+	It("keeps the last status while the designated primary doesn't report it", func() {
+		cluster := newCluster()
+		cluster.Status.SourceStatus = &v1.ReplicaSourceStatus{Reachable: true, CurrentLSN: "0/7000000"}
+
+		setReplicaSourceStatus(cluster, postgres.PostgresqlStatusList{})
+		Expect(cluster.Status.SourceStatus).ToNot(BeNil())
+		Expect(cluster.Status.SourceStatus.CurrentLSN).To(Equal("0/7000000"))
+	})
+
+	It("removes the status when not requested anymore", func() {
+		cluster := newCluster()
+		cluster.Spec.ReplicaCluster.ReportSourceStatus = false
+		cluster.Status.SourceStatus = &v1.ReplicaSourceStatus{Reachable: true}
+
+		setReplicaSourceStatus(cluster, postgres.PostgresqlStatusList{})
+		Expect(cluster.Status.SourceStatus).To(BeNil())
+	})
+})
+
+var _ = Describe("local archive status of the replica cluster", func() {
+	newCluster := func() *v1.Cluster {
+		return &v1.Cluster{
+			Spec: v1.ClusterSpec{
+				ReplicaCluster: &v1.ReplicaClusterConfiguration{
+					Enabled:      true,
+					Source:       "source",
+					LocalArchive: true,
+				},
+			},
+			Status: v1.ClusterStatus{
+				CurrentPrimary: "cluster-example-1",
+			},
+		}
+	}
+
+	instance := func(name string, status postgres.PostgresqlStatus) postgres.PostgresqlStatus {
+		status.Pod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		return status
+	}
+
+	It("reports the archiving status of the designated primary", func() {
+		cluster := newCluster()
+		statuses := postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				instance("cluster-example-2", postgres.PostgresqlStatus{LastFailedWAL: "000000010000000000000001"}),
+				instance("cluster-example-1", postgres.PostgresqlStatus{
+					IsArchivingWAL:      true,
+					LastArchivedWAL:     "000000010000000000000005",
+					LastArchivedWALTime: "2023-10-04T10:00:00Z",
+				}),
+			},
+		}
+
+		setReplicaLocalArchiveStatus(cluster, statuses)
+		Expect(cluster.Status.LocalArchiveStatus).To(Equal(&v1.ReplicaLocalArchiveStatus{
+			Archiving:           true,
+			LastArchivedWAL:     "000000010000000000000005",
+			LastArchivedWALTime: "2023-10-04T10:00:00Z",
+		}))
+	})
+
+	It("reports the archiving failures of the designated primary", func() {
+		cluster := newCluster()
+		statuses := postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				instance("cluster-example-1", postgres.PostgresqlStatus{
+					LastArchivedWAL:   "000000010000000000000005",
+					LastFailedWAL:     "000000010000000000000006",
+					LastFailedWALTime: "2023-10-04T10:05:00Z",
+				}),
+			},
+		}
+
+		setReplicaLocalArchiveStatus(cluster, statuses)
+		Expect(cluster.Status.LocalArchiveStatus.Archiving).To(BeFalse())
+		Expect(cluster.Status.LocalArchiveStatus.LastFailedWAL).To(Equal("000000010000000000000006"))
+	})
+
+	It("keeps the last status while the designated primary doesn't report it", func() {
+		cluster := newCluster()
+		cluster.Status.LocalArchiveStatus = &v1.ReplicaLocalArchiveStatus{
+			Archiving:       true,
+			LastArchivedWAL: "000000010000000000000005",
+		}
+
+		setReplicaLocalArchiveStatus(cluster, postgres.PostgresqlStatusList{})
+		Expect(cluster.Status.LocalArchiveStatus).ToNot(BeNil())
+		Expect(cluster.Status.LocalArchiveStatus.LastArchivedWAL).To(Equal("000000010000000000000005"))
+	})
+
+	It("removes the status when local archiving is disabled", func() {
+		cluster := newCluster()
+		cluster.Spec.ReplicaCluster.LocalArchive = false
+		cluster.Status.LocalArchiveStatus = &v1.ReplicaLocalArchiveStatus{Archiving: true}
+
+		setReplicaLocalArchiveStatus(cluster, postgres.PostgresqlStatusList{})
+		Expect(cluster.Status.LocalArchiveStatus).To(BeNil())
+	})
+})
+
+var _ = Describe("timeline of the source of the replica cluster", func() {
+	newCluster := func() *v1.Cluster {
+		return &v1.Cluster{
+			Spec: v1.ClusterSpec{
+				ReplicaCluster: &v1.ReplicaClusterConfiguration{
+					Enabled:            true,
+					Source:             "cluster-example",
+					ReportSourceStatus: true,
+				},
+			},
+		}
+	}
+
+	It("reports the timeline of the source", func() {
+		cluster := newCluster()
+		statuses := postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				{},
+				{SourceStatus: &postgres.SourceStatus{Reachable: true, TimelineID: 2}},
+			},
+		}
+
+		previousTimeline, changed := setSourceTimelineID(cluster, statuses)
+		Expect(changed).To(BeTrue())
+		Expect(previousTimeline).To(BeZero())
+		Expect(cluster.Status.SourceTimelineID).To(Equal(2))
+	})
+
+	It("detects a timeline switch of the source", func() {
+		cluster := newCluster()
+		cluster.Status.SourceTimelineID = 2
+		statuses := postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				{SourceStatus: &postgres.SourceStatus{Reachable: true, TimelineID: 3}},
+			},
+		}
+
+		previousTimeline, changed := setSourceTimelineID(cluster, statuses)
+		Expect(changed).To(BeTrue())
+		Expect(previousTimeline).To(Equal(2))
+		Expect(cluster.Status.SourceTimelineID).To(Equal(3))
+	})
+
+	It("keeps the last timeline while the source is unreachable", func() {
+		cluster := newCluster()
+		cluster.Status.SourceTimelineID = 2
+		statuses := postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				{SourceStatus: &postgres.SourceStatus{Error: "connection refused"}},
+			},
+		}
+
+		_, changed := setSourceTimelineID(cluster, statuses)
+		Expect(changed).To(BeFalse())
+		Expect(cluster.Status.SourceTimelineID).To(Equal(2))
+	})
+
+	It("removes the timeline when the source status is not reported anymore", func() {
+		cluster := newCluster()
+		cluster.Spec.ReplicaCluster.ReportSourceStatus = false
+		cluster.Status.SourceTimelineID = 2
+
+		setSourceTimelineID(cluster, postgres.PostgresqlStatusList{})
+		Expect(cluster.Status.SourceTimelineID).To(BeZero())
+	})
+})
+
+var _ = Describe("active source of the replica cluster", func() {
+	newCluster := func() *v1.Cluster {
+		return &v1.Cluster{
+			Spec: v1.ClusterSpec{
+				ReplicaCluster: &v1.ReplicaClusterConfiguration{
+					Enabled:         true,
+					Source:          "source-a",
+					FallbackSources: []string{"source-b"},
+				},
+			},
+		}
+	}
+
+	It("reports the source the designated primary streams from", func() {
+		cluster := newCluster()
+		statuses := postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				{ActiveReplicaSource: "source-a"},
+				{},
+			},
+		}
+
+		previousSource, changed := setReplicaActiveSource(cluster, statuses)
+		Expect(changed).To(BeTrue())
+		Expect(previousSource).To(BeEmpty())
+		Expect(cluster.Status.ReplicaActiveSource).To(Equal("source-a"))
+	})
+
+	It("detects a switch to a fallback source", func() {
+		cluster := newCluster()
+		cluster.Status.ReplicaActiveSource = "source-a"
+		statuses := postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{{ActiveReplicaSource: "source-b"}},
+		}
+
+		previousSource, changed := setReplicaActiveSource(cluster, statuses)
+		Expect(changed).To(BeTrue())
+		Expect(previousSource).To(Equal("source-a"))
+		Expect(cluster.Status.ReplicaActiveSource).To(Equal("source-b"))
+	})
+
+	It("keeps the last source while the designated primary doesn't report it", func() {
+		cluster := newCluster()
+		cluster.Status.ReplicaActiveSource = "source-b"
+
+		_, changed := setReplicaActiveSource(cluster, postgres.PostgresqlStatusList{})
+		Expect(changed).To(BeFalse())
+		Expect(cluster.Status.ReplicaActiveSource).To(Equal("source-b"))
+	})
+
+	It("removes the source without fallback sources", func() {
+		cluster := newCluster()
+		cluster.Spec.ReplicaCluster.FallbackSources = nil
+		cluster.Status.ReplicaActiveSource = "source-b"
+
+		setReplicaActiveSource(cluster, postgres.PostgresqlStatusList{})
+		Expect(cluster.Status.ReplicaActiveSource).To(BeEmpty())
+	})
+})
+
+var _ = Describe("source wal_level condition", func() {
+	newCluster := func() *v1.Cluster {
+		return &v1.Cluster{
+			Spec: v1.ClusterSpec{
+				ReplicaCluster: &v1.ReplicaClusterConfiguration{
+					Enabled:         true,
+					Source:          "source",
+					LogicalDecoding: true,
+				},
+			},
+		}
+	}
+	sourceWithWalLevel := func(walLevel string) postgres.PostgresqlStatusList {
+		return postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				{SourceStatus: &postgres.SourceStatus{Reachable: true, WalLevel: walLevel}},
+			},
+		}
+	}
+
+	It("is true when the source runs with wal_level logical", func() {
+		cluster := newCluster()
+
+		Expect(setSourceWalLevelCondition(cluster, sourceWithWalLevel("logical"))).To(BeTrue())
+		condition := meta.FindStatusCondition(cluster.Status.Conditions, string(v1.ConditionSourceWalLevelValid))
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+
+		Expect(setSourceWalLevelCondition(cluster, sourceWithWalLevel("logical"))).To(BeFalse())
+	})
+
+	It("is false when the source runs with wal_level replica", func() {
+		cluster := newCluster()
+
+		Expect(setSourceWalLevelCondition(cluster, sourceWithWalLevel("replica"))).To(BeTrue())
+		condition := meta.FindStatusCondition(cluster.Status.Conditions, string(v1.ConditionSourceWalLevelValid))
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(string(v1.ConditionReasonSourceWalLevelNotLogical)))
+		Expect(condition.Message).To(ContainSubstring("replica"))
+	})
+
+	It("is left untouched when the source can't be probed", func() {
+		cluster := newCluster()
+		statuses := postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				{SourceStatus: &postgres.SourceStatus{Error: "connection refused"}},
+			},
+		}
+
+		Expect(setSourceWalLevelCondition(cluster, statuses)).To(BeFalse())
+		Expect(cluster.Status.Conditions).To(BeEmpty())
+	})
+
+	It("is removed when logical decoding is not required anymore", func() {
+		cluster := newCluster()
+		Expect(setSourceWalLevelCondition(cluster, sourceWithWalLevel("replica"))).To(BeTrue())
+
+		cluster.Spec.ReplicaCluster.LogicalDecoding = false
+		Expect(setSourceWalLevelCondition(cluster, sourceWithWalLevel("replica"))).To(BeTrue())
+		Expect(meta.FindStatusCondition(cluster.Status.Conditions,
+			string(v1.ConditionSourceWalLevelValid))).To(BeNil())
+	})
+})
+
+var _ = Describe("replication slots condition", func() {
+	newStatuses := func(slots ...postgres.PgReplicationSlot) postgres.PostgresqlStatusList {
+		return postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				{IsPrimary: true, ReplicationSlotsInfo: slots},
+				{IsPrimary: false},
+			},
+		}
+	}
+
+	It("is true when no slot has been invalidated", func() {
+		cluster := &v1.Cluster{}
+		invalidated, changed := setReplicationSlotsCondition(cluster, newStatuses(
+			postgres.PgReplicationSlot{SlotName: "_cnpg_cluster_example_2", WalStatus: "reserved"},
+		))
+		Expect(invalidated).To(BeEmpty())
+		Expect(changed).To(BeTrue())
+
+		condition := meta.FindStatusCondition(cluster.Status.Conditions, string(v1.ConditionReplicationSlotsValid))
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(string(v1.ConditionReasonReplicationSlotsValid)))
+	})
+
+	It("is false when a slot has been invalidated", func() {
+		cluster := &v1.Cluster{}
+		invalidated, changed := setReplicationSlotsCondition(cluster, newStatuses(
+			postgres.PgReplicationSlot{SlotName: "_cnpg_cluster_example_2", WalStatus: "reserved"},
+			postgres.PgReplicationSlot{SlotName: "_cnpg_cluster_example_3", WalStatus: postgres.SlotWalStatusLost},
+		))
+		Expect(invalidated).To(ConsistOf("_cnpg_cluster_example_3"))
+		Expect(changed).To(BeTrue())
+
+		condition := meta.FindStatusCondition(cluster.Status.Conditions, string(v1.ConditionReplicationSlotsValid))
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(string(v1.ConditionReasonReplicationSlotInvalidated)))
+		Expect(condition.Message).To(ContainSubstring("_cnpg_cluster_example_3"))
+	})
+
+	It("doesn't report a change when the same slots are still invalidated", func() {
+		cluster := &v1.Cluster{}
+		statuses := newStatuses(
+			postgres.PgReplicationSlot{SlotName: "_cnpg_cluster_example_3", WalStatus: postgres.SlotWalStatusLost},
+		)
+		_, changed := setReplicationSlotsCondition(cluster, statuses)
+		Expect(changed).To(BeTrue())
+
+		invalidated, changed := setReplicationSlotsCondition(cluster, statuses)
+		Expect(invalidated).To(ConsistOf("_cnpg_cluster_example_3"))
+		Expect(changed).To(BeFalse())
+	})
+
+	It("is left untouched when the primary didn't report its status", func() {
+		cluster := &v1.Cluster{}
+		_, changed := setReplicationSlotsCondition(cluster, newStatuses(
+			postgres.PgReplicationSlot{SlotName: "_cnpg_cluster_example_3", WalStatus: postgres.SlotWalStatusLost},
+		))
+		Expect(changed).To(BeTrue())
+
+		statuses := postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				{IsPrimary: true, Error: fmt.Errorf("connection refused")},
+			},
+		}
+		_, changed = setReplicationSlotsCondition(cluster, statuses)
+		Expect(changed).To(BeFalse())
+
+		condition := meta.FindStatusCondition(cluster.Status.Conditions, string(v1.ConditionReplicationSlotsValid))
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+	})
+})
+
+var _ = Describe("HA replication slot prefixes", func() {
+	newCluster := func(prefix string, prefixes ...string) *v1.Cluster {
+		enabled := true
+		return &v1.Cluster{
+			Spec: v1.ClusterSpec{
+				ReplicationSlots: &v1.ReplicationSlotsConfiguration{
+					HighAvailability: &v1.ReplicationSlotsHAConfiguration{
+						Enabled:    &enabled,
+						SlotPrefix: prefix,
+					},
+				},
+			},
+			Status: v1.ClusterStatus{HASlotPrefixes: prefixes},
+		}
+	}
+
+	It("records the current prefix", func() {
+		cluster := newCluster("")
+		setHASlotPrefixes(cluster)
+		Expect(cluster.Status.HASlotPrefixes).To(Equal([]string{v1.DefaultReplicationSlotsHASlotPrefix}))
+		Expect(cluster.GetStaleHASlotPrefixes()).To(BeEmpty())
+	})
+
+	It("keeps track of the previous prefixes", func() {
+		cluster := newCluster("_new_", "_cnpg_")
+		setHASlotPrefixes(cluster)
+		Expect(cluster.Status.HASlotPrefixes).To(Equal([]string{"_cnpg_", "_new_"}))
+		Expect(cluster.GetStaleHASlotPrefixes()).To(Equal([]string{"_cnpg_"}))
+
+		cluster.Spec.ReplicationSlots.HighAvailability.SlotPrefix = "_cnpg_"
+		setHASlotPrefixes(cluster)
+		Expect(cluster.Status.HASlotPrefixes).To(Equal([]string{"_new_", "_cnpg_"}))
+		Expect(cluster.GetStaleHASlotPrefixes()).To(Equal([]string{"_new_"}))
+	})
+
+	It("limits the number of prefixes kept", func() {
+		cluster := newCluster("_f_", "_a_", "_b_", "_c_", "_d_", "_e_")
+		setHASlotPrefixes(cluster)
+		Expect(cluster.Status.HASlotPrefixes).To(Equal([]string{"_b_", "_c_", "_d_", "_e_", "_f_"}))
+	})
+
+	It("doesn't record anything when the HA replication slots are disabled", func() {
+		cluster := newCluster("_new_", "_cnpg_")
+		cluster.Spec.ReplicationSlots.HighAvailability.Enabled = nil
+		setHASlotPrefixes(cluster)
+		Expect(cluster.Status.HASlotPrefixes).To(Equal([]string{"_cnpg_"}))
+	})
+})
@@ -40,6 +40,10 @@ var ErrWalReceiversRunning = fmt.Errorf("wal receivers are still running")
 // elapsed yet
 var ErrWaitingOnFailOverDelay = fmt.Errorf("current primary isn't healthy, waiting for the delay before triggering a failover") //nolint: lll
 
+// ErrWaitingOnManualFailover is raised when the designated primary of a replica cluster
+// isn't healthy, and a replica needs to be promoted by the user
+var ErrWaitingOnManualFailover = fmt.Errorf("current designated primary isn't healthy, waiting for a manual failover")
+
 // updateTargetPrimaryFromPods sets the name of the target primary from the Pods status if needed
 // this function will return the name of the new primary selected for promotion
 func (r *ClusterReconciler) updateTargetPrimaryFromPods(
@@ -284,6 +288,13 @@ func (r *ClusterReconciler) updateTargetPrimaryFromPodsReplicaCluster(
 		return "", err
 	}
 
+	if cluster.IsDesignatedPrimaryFailoverManual() {
+		r.Recorder.Eventf(cluster, "Warning", "ManualFailoverRequired",
+			"Current designated primary %v isn't healthy, waiting for a replica to be promoted",
+			cluster.Status.TargetPrimary)
+		return "", ErrWaitingOnManualFailover
+	}
+
 	// The designated primary is not correctly working, and we need to elect a new one
 	// but before doing that we need to wait for all the WAL receivers to be
 	// terminated. This is needed to avoid losing the WAL data that is being received
@@ -292,19 +303,49 @@ func (r *ClusterReconciler) updateTargetPrimaryFromPodsReplicaCluster(
 		return "", ErrWalReceiversRunning
 	}
 
+	candidate := getDesignatedPrimaryCandidate(status)
+	if candidate == nil {
+		contextLogger.Info("Current target primary isn't healthy, " +
+			"but there is no healthy replica to be promoted to designated primary")
+		status.LogStatus(ctx)
+		return "", nil
+	}
+
 	contextLogger.Info("Current target primary isn't healthy, failing over",
-		"newPrimary", status.Items[0].Pod.Name)
+		"newPrimary", candidate.Pod.Name,
+		"receivedLsn", candidate.ReceivedLsn,
+		"replayLsn", candidate.ReplayLsn)
 	status.LogStatus(ctx)
 	contextLogger.Debug("Cluster status before failover", "instances", resources.instances)
 	r.Recorder.Eventf(cluster, "Normal", "FailingOver",
 		"Current target primary isn't healthy, failing over from %v to %v",
-		cluster.Status.TargetPrimary, status.Items[0].Pod.Name)
+		cluster.Status.TargetPrimary, candidate.Pod.Name)
 	if err := r.RegisterPhase(ctx, cluster, apiv1.PhaseFailOver,
-		fmt.Sprintf("Failing over to %v", status.Items[0].Pod.Name)); err != nil {
+		fmt.Sprintf("Failing over to %v", candidate.Pod.Name)); err != nil {
 		return "", err
 	}
 
-	return status.Items[0].Pod.Name, r.setPrimaryInstance(ctx, cluster, status.Items[0].Pod.Name)
+	return candidate.Pod.Name, r.setPrimaryInstance(ctx, cluster, candidate.Pod.Name)
+}
+
+// getDesignatedPrimaryCandidate gets the replica to be promoted to designated
+// primary, which is the most advanced one among the healthy replicas that
+// reported their LSN. The new designated primary will resume streaming
+// from the source starting from its replay LSN, so that no WAL data that
+// was received by the failed designated primary and replicated is lost
+func getDesignatedPrimaryCandidate(status postgres.PostgresqlStatusList) *postgres.PostgresqlStatus {
+	// The status list is already sorted by received and replayed LSN,
+	// the most advanced replica first
+	for idx := range status.Items {
+		item := &status.Items[idx]
+		if item.Error != nil || item.IsPrimary || item.ReplayLsn == "" {
+			continue
+		}
+
+		return item
+	}
+
+	return nil
 }
 
 // GetPodsNotOnPrimaryNode filters out only pods that are not on the same node as the primary one
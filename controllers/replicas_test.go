@@ -17,10 +17,16 @@ limitations under the License.
 package controllers
 
 import (
+	"context"
+	"errors"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
 
@@ -151,3 +157,107 @@ var _ = Describe("Check pods not on primary node", func() {
 		Expect(GetPodsNotOnPrimaryNode(statusList2, &statusList2.Items[0]).Items).ToNot(BeEmpty())
 	})
 })
+
+var _ = Describe("designated primary failover", func() {
+	var (
+		cluster    *apiv1.Cluster
+		reconciler *ClusterReconciler
+		statusList postgres.PostgresqlStatusList
+	)
+
+	newStatus := func(name string, receivedLsn, replayLsn postgres.LSN) postgres.PostgresqlStatus {
+		return postgres.PostgresqlStatus{
+			Pod:         &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}},
+			ReceivedLsn: receivedLsn,
+			ReplayLsn:   replayLsn,
+		}
+	}
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{
+					Source:  "source",
+					Enabled: true,
+				},
+			},
+			Status: apiv1.ClusterStatus{
+				CurrentPrimary: "cluster-example-1",
+				TargetPrimary:  "cluster-example-1",
+			},
+		}
+
+		// The designated primary "cluster-example-1" failed and is
+		// not reporting its status anymore
+		statusList = postgres.PostgresqlStatusList{
+			Items: []postgres.PostgresqlStatus{
+				newStatus("cluster-example-3", "0/6000000", "0/6000000"),
+				newStatus("cluster-example-2", "0/5000000", "0/5000000"),
+			},
+		}
+
+		reconciler = &ClusterReconciler{
+			Client: fake.NewClientBuilder().
+				WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+				WithObjects(cluster).
+				WithStatusSubresource(cluster).
+				Build(),
+			Recorder: record.NewFakeRecorder(120),
+		}
+	})
+
+	It("promotes the most advanced healthy replica with the automatic policy", func(ctx context.Context) {
+		selectedPrimary, err := reconciler.updateTargetPrimaryFromPodsReplicaCluster(
+			ctx, cluster, statusList, &managedResources{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(selectedPrimary).To(Equal("cluster-example-3"))
+		Expect(cluster.Status.TargetPrimary).To(Equal("cluster-example-3"))
+		Expect(cluster.Status.Phase).To(Equal(apiv1.PhaseFailOver))
+	})
+
+	It("waits for the user to promote a replica with the manual policy", func(ctx context.Context) {
+		cluster.Spec.ReplicaCluster.DesignatedPrimaryFailover = apiv1.DesignatedPrimaryFailoverManual
+
+		selectedPrimary, err := reconciler.updateTargetPrimaryFromPodsReplicaCluster(
+			ctx, cluster, statusList, &managedResources{})
+		Expect(errors.Is(err, ErrWaitingOnManualFailover)).To(BeTrue())
+		Expect(selectedPrimary).To(BeEmpty())
+		Expect(cluster.Status.TargetPrimary).To(Equal("cluster-example-1"))
+	})
+
+	It("accepts the replica promoted by the user with the manual policy", func(ctx context.Context) {
+		cluster.Spec.ReplicaCluster.DesignatedPrimaryFailover = apiv1.DesignatedPrimaryFailoverManual
+		cluster.Status.TargetPrimary = "cluster-example-2"
+
+		selectedPrimary, err := reconciler.updateTargetPrimaryFromPodsReplicaCluster(
+			ctx, cluster, statusList, &managedResources{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(selectedPrimary).To(BeEmpty())
+	})
+
+	It("never promotes a replica that is not healthy or didn't report its LSN", func() {
+		failing := newStatus("cluster-example-4", "0/7000000", "0/7000000")
+		failing.Error = errors.New("connection refused")
+		statusList.Items = append(
+			[]postgres.PostgresqlStatus{failing, newStatus("cluster-example-5", "", "")},
+			statusList.Items...)
+
+		candidate := getDesignatedPrimaryCandidate(statusList)
+		Expect(candidate).ToNot(BeNil())
+		Expect(candidate.Pod.Name).To(Equal("cluster-example-3"))
+	})
+
+	It("doesn't fail over when there is no candidate", func(ctx context.Context) {
+		statusList.Items = []postgres.PostgresqlStatus{newStatus("cluster-example-2", "", "")}
+
+		selectedPrimary, err := reconciler.updateTargetPrimaryFromPodsReplicaCluster(
+			ctx, cluster, statusList, &managedResources{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(selectedPrimary).To(BeEmpty())
+		Expect(cluster.Status.TargetPrimary).To(Equal("cluster-example-1"))
+	})
+})
@@ -131,6 +131,18 @@ func ReconcileScheduledBackup(
 	now := time.Now()
 	origScheduled := scheduledBackup.DeepCopy()
 
+	pauseReason, err := getScheduledBackupPauseReason(ctx, cli, scheduledBackup)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if scheduledBackup.Status.LastCheckTime == nil && scheduledBackup.IsImmediate() && pauseReason != "" {
+		// The immediate backup is deferred, rather than skipped, until
+		// the cluster is set up
+		contextLogger.Info("Deferring the immediate backup", "reason", pauseReason)
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+
 	if scheduledBackup.Status.LastCheckTime == nil {
 		// This is the first time we check this schedule,
 		// let's wait until the first job will be actually
@@ -168,9 +180,101 @@ func ReconcileScheduledBackup(
 		return ctrl.Result{RequeueAfter: nextTime.Sub(now)}, nil
 	}
 
+	if pauseReason != "" {
+		return skipBackup(ctx, event, cli, scheduledBackup, nextTime, now, schedule, pauseReason)
+	}
+
 	return createBackup(ctx, event, cli, scheduledBackup, nextTime, now, schedule, false)
 }
 
+// getScheduledBackupPauseReason gets why the passed scheduled backup is
+// paused, as its cluster is being bootstrapped or restored. Returns an
+// empty string when the backups can be taken
+func getScheduledBackupPauseReason(
+	ctx context.Context,
+	cli client.Client,
+	scheduledBackup *apiv1.ScheduledBackup,
+) (string, error) {
+	if !scheduledBackup.IsPausedDuringBootstrap() {
+		return "", nil
+	}
+
+	var cluster apiv1.Cluster
+	err := cli.Get(
+		ctx,
+		types.NamespacedName{Name: scheduledBackup.Spec.Cluster.Name, Namespace: scheduledBackup.Namespace},
+		&cluster,
+	)
+	if apierrs.IsNotFound(err) {
+		// the missing cluster is reported by the backup
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return getClusterBootstrapPauseReason(&cluster), nil
+}
+
+// getClusterBootstrapPauseReason gets why the backups of the passed cluster
+// need to be skipped, as it is being bootstrapped or restored. Returns an
+// empty string when the cluster is set up
+func getClusterBootstrapPauseReason(cluster *apiv1.Cluster) string {
+	switch {
+	case cluster.Status.Phase == "" || cluster.Status.Phase == apiv1.PhaseFirstPrimary:
+		return "the cluster is being bootstrapped"
+	case cluster.Status.Phase == apiv1.PhasePostRestoreFailed:
+		return "the restore of the cluster didn't complete"
+	case cluster.Status.CurrentPrimary == "":
+		return "the cluster has no primary yet"
+	default:
+		return ""
+	}
+}
+
+// skipBackup skips a scheduled backup whose cluster is being bootstrapped
+// or restored, recording the reason in the ScheduledBackup status
+func skipBackup(
+	ctx context.Context,
+	event record.EventRecorder,
+	cli client.Client,
+	scheduledBackup *apiv1.ScheduledBackup,
+	backupTime time.Time,
+	now time.Time,
+	schedule cron.Schedule,
+	reason string,
+) (ctrl.Result, error) {
+	contextLogger := log.FromContext(ctx)
+
+	origScheduled := scheduledBackup.DeepCopy()
+	nextBackupTime := schedule.Next(now)
+	scheduledBackup.Status.LastCheckTime = &metav1.Time{
+		Time: now,
+	}
+	scheduledBackup.Status.NextScheduleTime = &metav1.Time{
+		Time: nextBackupTime,
+	}
+	scheduledBackup.Status.LastSkippedTime = &metav1.Time{
+		Time: backupTime,
+	}
+	scheduledBackup.Status.LastSkipReason = reason
+
+	if err := cli.Status().Patch(ctx, scheduledBackup, client.MergeFrom(origScheduled)); err != nil {
+		if apierrs.IsConflict(err) {
+			// Retry later, the cache is stale
+			contextLogger.Debug("Conflict while updating scheduled backup", "error", err)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	contextLogger.Info("Skipping the scheduled backup", "backupTime", backupTime, "reason", reason)
+	event.Eventf(scheduledBackup, "Warning", "BackupSkipped",
+		"Skipped the backup scheduled by %v, as %s. Next backup scheduled by %v",
+		backupTime, reason, nextBackupTime)
+	return ctrl.Result{RequeueAfter: nextBackupTime.Sub(now)}, nil
+}
+
 // createBackup creates a scheduled backup for a backuptime, updating the ScheduledBackup accordingly
 func createBackup(
 	ctx context.Context,
@@ -0,0 +1,140 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("scheduled backups during a restore", func() {
+	const namespace = "default"
+
+	var (
+		cluster         *apiv1.Cluster
+		scheduledBackup *apiv1.ScheduledBackup
+		recorder        *record.FakeRecorder
+	)
+
+	newClient := func() client.Client {
+		return fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, scheduledBackup).
+			WithStatusSubresource(scheduledBackup).
+			Build()
+	}
+
+	countBackups := func(ctx context.Context, cli client.Client) int {
+		var backups apiv1.BackupList
+		Expect(cli.List(ctx, &backups, client.InNamespace(namespace))).To(Succeed())
+		return len(backups.Items)
+	}
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: namespace},
+			Status: apiv1.ClusterStatus{
+				Phase:          apiv1.PhaseFirstPrimary,
+				CurrentPrimary: "",
+			},
+		}
+		scheduledBackup = &apiv1.ScheduledBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "scheduled-backup", Namespace: namespace},
+			Spec: apiv1.ScheduledBackupSpec{
+				Schedule: "0 0 0 * * *",
+				Cluster:  apiv1.LocalObjectReference{Name: cluster.Name},
+			},
+			Status: apiv1.ScheduledBackupStatus{
+				LastCheckTime: &metav1.Time{Time: time.Now().Add(-48 * time.Hour)},
+			},
+		}
+		recorder = record.NewFakeRecorder(120)
+	})
+
+	It("skips the backup while the cluster is being bootstrapped", func(ctx context.Context) {
+		cli := newClient()
+
+		res, err := ReconcileScheduledBackup(ctx, recorder, cli, scheduledBackup)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(countBackups(ctx, cli)).To(BeZero())
+
+		var result apiv1.ScheduledBackup
+		Expect(cli.Get(ctx, client.ObjectKeyFromObject(scheduledBackup), &result)).To(Succeed())
+		Expect(result.Status.LastSkippedTime).ToNot(BeNil())
+		Expect(result.Status.LastSkipReason).To(Equal("the cluster is being bootstrapped"))
+		Expect(result.Status.LastScheduleTime).To(BeNil())
+		Expect(result.Status.NextScheduleTime).ToNot(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring("BackupSkipped")))
+	})
+
+	It("skips the backup when the post-restore SQL failed", func(ctx context.Context) {
+		cluster.Status.Phase = apiv1.PhasePostRestoreFailed
+		cluster.Status.CurrentPrimary = "cluster-example-1"
+		cli := newClient()
+
+		_, err := ReconcileScheduledBackup(ctx, recorder, cli, scheduledBackup)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(countBackups(ctx, cli)).To(BeZero())
+		Expect(scheduledBackup.Status.LastSkipReason).To(Equal("the restore of the cluster didn't complete"))
+	})
+
+	It("resumes the backups once the cluster is healthy", func(ctx context.Context) {
+		cluster.Status.Phase = apiv1.PhaseHealthy
+		cluster.Status.CurrentPrimary = "cluster-example-1"
+		cli := newClient()
+
+		_, err := ReconcileScheduledBackup(ctx, recorder, cli, scheduledBackup)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(countBackups(ctx, cli)).To(Equal(1))
+		Expect(scheduledBackup.Status.LastScheduleTime).ToNot(BeNil())
+	})
+
+	It("takes the backup during the bootstrap when pausing is disabled", func(ctx context.Context) {
+		scheduledBackup.Spec.PauseDuringBootstrap = ptr.To(false)
+		cli := newClient()
+
+		_, err := ReconcileScheduledBackup(ctx, recorder, cli, scheduledBackup)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(countBackups(ctx, cli)).To(Equal(1))
+		Expect(scheduledBackup.Status.LastSkippedTime).To(BeNil())
+	})
+
+	It("defers the immediate backup until the cluster is set up", func(ctx context.Context) {
+		scheduledBackup.Spec.Immediate = ptr.To(true)
+		scheduledBackup.Status.LastCheckTime = nil
+		cli := newClient()
+
+		res, err := ReconcileScheduledBackup(ctx, recorder, cli, scheduledBackup)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(time.Minute))
+		Expect(countBackups(ctx, cli)).To(BeZero())
+		Expect(scheduledBackup.Status.LastCheckTime).To(BeNil())
+	})
+})
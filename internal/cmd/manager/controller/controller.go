@@ -180,6 +180,17 @@ func RunController(
 
 	setupLog.Info("Operator configuration loaded", "configuration", configuration.Current)
 
+	shutdownTracing, err := setupTracing(configuration.Current.TracingExporter)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		return err
+	}
+	defer func() {
+		if shutdownErr := shutdownTracing(context.Background()); shutdownErr != nil {
+			setupLog.Error(shutdownErr, "unable to flush the pending spans")
+		}
+	}()
+
 	discoveryClient, err := utils.GetDiscoveryClient()
 	if err != nil {
 		return err
@@ -0,0 +1,83 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/cloudnative-pg/cloudnative-pg/internal/configuration"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// setupTracing registers the global OpenTelemetry tracer provider of the
+// operator, exporting the spans through the passed exporter. Tracing is
+// disabled when no exporter is set. The returned function flushes the
+// pending spans and must be called when the operator exits
+func setupTracing(exporterName string) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	switch exporterName {
+	case "":
+		return func(context.Context) error { return nil }, nil
+	case configuration.TracingExporterLog:
+		exporter = &logSpanExporter{logger: log.WithName("tracing")}
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter: %q", exporterName)
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(provider)
+	setupLog.Info("Tracing enabled", "exporter", exporterName)
+	return provider.Shutdown, nil
+}
+
+// logSpanExporter writes the spans in the operator log
+type logSpanExporter struct {
+	logger log.Logger
+}
+
+// ExportSpans implements sdktrace.SpanExporter
+func (exporter *logSpanExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	for _, span := range spans {
+		attributes := make(map[string]string, len(span.Attributes()))
+		for _, attribute := range span.Attributes() {
+			attributes[string(attribute.Key)] = attribute.Value.Emit()
+		}
+
+		exporter.logger.Info("Span",
+			"name", span.Name(),
+			"traceID", span.SpanContext().TraceID().String(),
+			"spanID", span.SpanContext().SpanID().String(),
+			"parentSpanID", span.Parent().SpanID().String(),
+			"startTime", span.StartTime(),
+			"endTime", span.EndTime(),
+			"duration", span.EndTime().Sub(span.StartTime()).String(),
+			"status", span.Status().Code.String(),
+			"statusDescription", span.Status().Description,
+			"attributes", attributes,
+		)
+	}
+	return nil
+}
+
+// Shutdown implements sdktrace.SpanExporter
+func (exporter *logSpanExporter) Shutdown(context.Context) error {
+	return nil
+}
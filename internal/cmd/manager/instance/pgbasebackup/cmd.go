@@ -136,15 +136,45 @@ func (env *CloneInfo) bootstrapUsingPgbasebackup(ctx context.Context) error {
 			return err
 		}
 	}
-	err = postgres.ClonePgData(connectionString, env.info.PgData, env.info.PgWal)
-	if err != nil {
-		return err
+
+	// The bootstrap of a replica cluster can be resumed from the PGDATA
+	// left by an interrupted attempt, as the missing WALs will be streamed
+	// from the source once the instance is started
+	resumed := false
+	if cluster.IsReplica() {
+		if resumed, err = env.info.ResumeOrCleanPartialBootstrap(ctx, string(cluster.UID)); err != nil {
+			return err
+		}
+	}
+
+	if !resumed {
+		err = postgres.ClonePgData(connectionString, env.info.PgData, env.info.PgWal)
+		if err != nil {
+			return err
+		}
 	}
 
 	if cluster.IsReplica() {
+		if err := env.info.MarkBaseBackupCompleted(); err != nil {
+			return err
+		}
+
+		if err := env.info.ValidateSourceDataChecksums(ctx, &cluster, connectionString); err != nil {
+			return err
+		}
+
 		// TODO: Using a replication slot on replica cluster is not supported (yet?)
-		_, err = postgres.UpdateReplicaConfiguration(env.info.PgData, connectionString, "")
-		return err
+		if _, err = postgres.UpdateReplicaConfiguration(env.info.PgData, connectionString, "",
+			cluster.Spec.ReplicaCluster.GetTargetTimeline()); err != nil {
+			return err
+		}
+
+		if _, err = postgres.UpdateReplicaRecoveryTarget(
+			env.info.PgData, cluster.GetPendingReplicaRecoveryTarget()); err != nil {
+			return err
+		}
+
+		return env.info.RemoveBootstrapMarks()
 	}
 
 	return env.configureInstanceAsNewPrimary(ctx, &cluster)
@@ -79,10 +79,17 @@ func NewCmd() *cobra.Command {
 }
 
 func restoreSubCommand(ctx context.Context, info postgres.InitInfo) error {
-	err := info.VerifyPGData()
+	// The PGDATA left by an interrupted bootstrap is checked by the restore
+	// process, as the bootstrap of a replica cluster can be resumed from it
+	interrupted, err := info.HasInterruptedBootstrap()
 	if err != nil {
 		return err
 	}
+	if !interrupted {
+		if err := info.VerifyPGData(); err != nil {
+			return err
+		}
+	}
 
 	err = info.Restore(ctx)
 	if err != nil {
@@ -36,6 +36,9 @@ func NewCmd() *cobra.Command {
 	var namespace string
 	var pgData string
 	var pgWal string
+	var requiredExtensions []string
+	var backupLabel []byte
+	var tablespaceMap []byte
 
 	cmd := &cobra.Command{
 		Use:           "restoresnapshot [flags]",
@@ -50,13 +53,15 @@ func NewCmd() *cobra.Command {
 			ctx := cmd.Context()
 
 			info := postgres.InitInfo{
-				ClusterName: clusterName,
-				Namespace:   namespace,
-				PgData:      pgData,
-				PgWal:       pgWal,
+				ClusterName:       clusterName,
+				Namespace:         namespace,
+				PgData:            pgData,
+				PgWal:             pgWal,
+				BackupLabelFile:   backupLabel,
+				TablespaceMapFile: tablespaceMap,
 			}
 
-			return execute(ctx, info)
+			return execute(ctx, info, requiredExtensions)
 		},
 		PostRunE: func(cmd *cobra.Command, args []string) error {
 			if err := istio.TryInvokeQuitEndpoint(cmd.Context()); err != nil {
@@ -73,11 +78,19 @@ func NewCmd() *cobra.Command {
 		"the cluster")
 	cmd.Flags().StringVar(&pgData, "pg-data", os.Getenv("PGDATA"), "The PGDATA to be restored")
 	cmd.Flags().StringVar(&pgWal, "pg-wal", "", "The PGWAL to be restored")
+	cmd.Flags().StringSliceVar(&requiredExtensions, "required-extensions", nil, "The extensions "+
+		"installed in the snapshotted cluster, to be checked against the ones available in the image")
+	cmd.Flags().BytesBase64Var(&backupLabel, "backup-label", nil, "The base64 encoded backup label "+
+		"of the snapshot, when it has been taken online")
+	cmd.Flags().BytesBase64Var(&tablespaceMap, "tablespace-map", nil, "The base64 encoded tablespace "+
+		"map of the snapshot, when it has been taken online")
 
 	return cmd
 }
 
-func execute(ctx context.Context, info postgres.InitInfo) error {
+func execute(ctx context.Context, info postgres.InitInfo, requiredExtensions []string) error {
+	postgres.CheckRequiredExtensions(requiredExtensions)
+
 	typedClient, err := management.NewControllerRuntimeClient()
 	if err != nil {
 		return err
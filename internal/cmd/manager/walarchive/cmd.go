@@ -149,6 +149,11 @@ func run(
 			)
 			return nil
 		}
+
+		if cluster.Spec.ReplicaCluster.LocalArchive && cluster.IsLocalArchiveSharedWithSource() {
+			return fmt.Errorf("refusing to archive WAL file %s in the WAL archive of the source %s",
+				walName, cluster.Spec.ReplicaCluster.Source)
+		}
 	}
 
 	maxParallel := 1
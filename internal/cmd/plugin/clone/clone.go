@@ -0,0 +1,375 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/plugin"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/backup/volumesnapshot"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// cloneOptions are the options that are provided to the clone
+// cnpg command
+type cloneOptions struct {
+	clusterName       string
+	targetClusterName string
+	targetNamespace   string
+	instances         int
+	backupName        string
+	timeout           time.Duration
+}
+
+// cloneCommand represents the `clone` command
+type cloneCommand struct {
+	ctx     context.Context
+	options cloneOptions
+}
+
+// newCloneCommand creates a new `clone` command
+func newCloneCommand(ctx context.Context, options cloneOptions) *cloneCommand {
+	return &cloneCommand{
+		ctx:     ctx,
+		options: options,
+	}
+}
+
+// execute executes the `clone` command
+func (c *cloneCommand) execute() error {
+	var cluster apiv1.Cluster
+	if err := plugin.Client.Get(
+		c.ctx,
+		types.NamespacedName{Name: c.options.clusterName, Namespace: plugin.Namespace},
+		&cluster,
+	); err != nil {
+		return fmt.Errorf("while getting cluster %s: %w", c.options.clusterName, err)
+	}
+
+	if cluster.Spec.Backup == nil || cluster.Spec.Backup.VolumeSnapshot == nil {
+		return fmt.Errorf("cluster %s has no volume snapshot backup configuration", cluster.Name)
+	}
+
+	if err := c.ensureTargetClusterDoesNotExistStep(); err != nil {
+		return err
+	}
+
+	backup, err := c.createBackupStep()
+	if err != nil {
+		return err
+	}
+	c.printAdvancement(fmt.Sprintf("backup/%s created, waiting for it to be completed", backup.Name))
+
+	if err := c.waitForBackupStep(backup); err != nil {
+		return err
+	}
+	c.printAdvancement(fmt.Sprintf("backup/%s completed", backup.Name))
+
+	clone, err := c.createClusterFromBackupStep(backup)
+	if err != nil {
+		return err
+	}
+	c.printAdvancement(fmt.Sprintf("cluster/%s created in namespace %s", clone.Name, clone.Namespace))
+
+	return nil
+}
+
+// ensureTargetClusterDoesNotExistStep checks that the cluster to be created
+// is not already present in the target namespace
+func (c *cloneCommand) ensureTargetClusterDoesNotExistStep() error {
+	var cluster apiv1.Cluster
+	err := plugin.Client.Get(
+		c.ctx,
+		types.NamespacedName{Name: c.options.targetClusterName, Namespace: c.options.targetNamespace},
+		&cluster,
+	)
+	if err == nil {
+		return fmt.Errorf("cluster %s already exists in namespace %s, cannot proceed with the clone",
+			c.options.targetClusterName, c.options.targetNamespace)
+	}
+	if !apierrs.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// createBackupStep requests a volume snapshot backup of the source cluster
+func (c *cloneCommand) createBackupStep() (*apiv1.Backup, error) {
+	backup := &apiv1.Backup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: plugin.Namespace,
+			Name:      c.options.backupName,
+		},
+		Spec: apiv1.BackupSpec{
+			Cluster: apiv1.LocalObjectReference{
+				Name: c.options.clusterName,
+			},
+			Method: apiv1.BackupMethodVolumeSnapshot,
+		},
+	}
+
+	if err := plugin.Client.Create(c.ctx, backup); err != nil {
+		return nil, err
+	}
+
+	return backup, nil
+}
+
+// waitForBackupStep waits for the passed backup to be completed
+func (c *cloneCommand) waitForBackupStep(backup *apiv1.Backup) error {
+	return wait.PollUntilContextTimeout(c.ctx, 5*time.Second, c.options.timeout, true,
+		func(ctx context.Context) (bool, error) {
+			if err := plugin.Client.Get(ctx, client.ObjectKeyFromObject(backup), backup); err != nil {
+				return false, err
+			}
+
+			switch backup.Status.Phase {
+			case apiv1.BackupPhaseCompleted:
+				return true, nil
+			case apiv1.BackupPhaseFailed:
+				return false, fmt.Errorf("backup %s failed: %s", backup.Name, backup.Status.Error)
+			case apiv1.BackupPhaseCoalesced:
+				return false, fmt.Errorf("backup %s coalesced into backup %s", backup.Name, backup.Status.CoalescedInto)
+			default:
+				return false, nil
+			}
+		})
+}
+
+// createClusterFromBackupStep creates the new cluster, bootstrapping it
+// from the volume snapshots taken by the passed backup
+func (c *cloneCommand) createClusterFromBackupStep(backup *apiv1.Backup) (*apiv1.Cluster, error) {
+	snapshotList, err := volumesnapshot.GetBackupVolumeSnapshots(c.ctx, plugin.Client, backup.Namespace, backup.Name)
+	if err != nil {
+		return nil, err
+	}
+	reusedSnapshots, err := volumesnapshot.GetReusedVolumeSnapshots(c.ctx, plugin.Client, backup.Namespace, backup)
+	if err != nil {
+		return nil, err
+	}
+	snapshotList = append(snapshotList, reusedSnapshots...)
+
+	snapshots := make(map[utils.PVCRole]storagesnapshotv1.VolumeSnapshot, len(snapshotList))
+	for _, snapshot := range snapshotList {
+		snapshots[utils.PVCRole(snapshot.Labels[utils.PvcRoleLabelName])] = snapshot
+	}
+
+	dataSnapshot, ok := snapshots[utils.PVCRolePgData]
+	if !ok {
+		return nil, fmt.Errorf("backup %s has no PG_DATA volume snapshot", backup.Name)
+	}
+
+	// Every snapshot of the backup is annotated with the same cluster manifest
+	template, err := getClusterFromSnapshotAnnotation(dataSnapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	// A VolumeSnapshot can only be restored in its own namespace, so we
+	// need to import the underlying snapshots in the target namespace
+	if c.options.targetNamespace != backup.Namespace {
+		for role, snapshot := range snapshots {
+			importedSnapshot, err := c.importSnapshotStep(snapshot)
+			if err != nil {
+				return nil, err
+			}
+			snapshots[role] = *importedSnapshot
+		}
+	}
+
+	cluster := buildClonedCluster(template, c.options, snapshots)
+	if err := plugin.Client.Create(c.ctx, cluster); err != nil {
+		return nil, err
+	}
+
+	return cluster, nil
+}
+
+// importSnapshotStep creates, in the target namespace, a VolumeSnapshot
+// pointing to the same storage snapshot of the passed one
+func (c *cloneCommand) importSnapshotStep(
+	snapshot storagesnapshotv1.VolumeSnapshot,
+) (*storagesnapshotv1.VolumeSnapshot, error) {
+	if snapshot.Status == nil || snapshot.Status.BoundVolumeSnapshotContentName == nil {
+		return nil, fmt.Errorf("volume snapshot %s is not bound to a VolumeSnapshotContent", snapshot.Name)
+	}
+
+	var content storagesnapshotv1.VolumeSnapshotContent
+	if err := plugin.Client.Get(
+		c.ctx,
+		types.NamespacedName{Name: *snapshot.Status.BoundVolumeSnapshotContentName},
+		&content,
+	); err != nil {
+		return nil, err
+	}
+
+	importedContent, importedSnapshot, err := buildImportedSnapshot(snapshot, content, c.options.targetNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := plugin.Client.Create(c.ctx, importedContent); err != nil {
+		return nil, err
+	}
+
+	if err := plugin.Client.Create(c.ctx, importedSnapshot); err != nil {
+		return nil, err
+	}
+
+	return importedSnapshot, nil
+}
+
+func (c *cloneCommand) printAdvancement(msg string) {
+	fmt.Println(msg)
+}
+
+// getClusterFromSnapshotAnnotation gets the cluster manifest stored
+// in the passed volume snapshot
+func getClusterFromSnapshotAnnotation(snapshot storagesnapshotv1.VolumeSnapshot) (apiv1.Cluster, error) {
+	var cluster apiv1.Cluster
+	rawCluster, ok := snapshot.Annotations[utils.ClusterManifestAnnotationName]
+	if !ok {
+		return cluster, fmt.Errorf("missing %s annotation, from volume snapshot: %s",
+			utils.ClusterManifestAnnotationName, snapshot.Name)
+	}
+
+	if err := json.Unmarshal([]byte(rawCluster), &cluster); err != nil {
+		return cluster, fmt.Errorf("while decoding the cluster manifest of volume snapshot %s: %w",
+			snapshot.Name, err)
+	}
+
+	return cluster, nil
+}
+
+// buildImportedSnapshot builds a pre-provisioned VolumeSnapshotContent
+// and the VolumeSnapshot bound to it, pointing to the same storage snapshot
+// of the passed VolumeSnapshot. The VolumeSnapshotContent is retained
+// when the new VolumeSnapshot is deleted, to preserve the original snapshot
+func buildImportedSnapshot(
+	snapshot storagesnapshotv1.VolumeSnapshot,
+	content storagesnapshotv1.VolumeSnapshotContent,
+	targetNamespace string,
+) (*storagesnapshotv1.VolumeSnapshotContent, *storagesnapshotv1.VolumeSnapshot, error) {
+	if content.Status == nil || content.Status.SnapshotHandle == nil {
+		return nil, nil, fmt.Errorf("volume snapshot content %s has no snapshot handle", content.Name)
+	}
+
+	contentName := fmt.Sprintf("%s-%s", targetNamespace, snapshot.Name)
+
+	importedContent := &storagesnapshotv1.VolumeSnapshotContent{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: contentName,
+		},
+		Spec: storagesnapshotv1.VolumeSnapshotContentSpec{
+			VolumeSnapshotRef: corev1.ObjectReference{
+				Name:      snapshot.Name,
+				Namespace: targetNamespace,
+			},
+			DeletionPolicy:          storagesnapshotv1.VolumeSnapshotContentRetain,
+			Driver:                  content.Spec.Driver,
+			VolumeSnapshotClassName: content.Spec.VolumeSnapshotClassName,
+			Source: storagesnapshotv1.VolumeSnapshotContentSource{
+				SnapshotHandle: ptr.To(*content.Status.SnapshotHandle),
+			},
+			SourceVolumeMode: content.Spec.SourceVolumeMode,
+		},
+	}
+
+	importedSnapshot := &storagesnapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        snapshot.Name,
+			Namespace:   targetNamespace,
+			Annotations: snapshot.Annotations,
+			Labels: map[string]string{
+				utils.PvcRoleLabelName: snapshot.Labels[utils.PvcRoleLabelName],
+			},
+		},
+		Spec: storagesnapshotv1.VolumeSnapshotSpec{
+			Source: storagesnapshotv1.VolumeSnapshotSource{
+				VolumeSnapshotContentName: ptr.To(contentName),
+			},
+			VolumeSnapshotClassName: snapshot.Spec.VolumeSnapshotClassName,
+		},
+	}
+
+	return importedContent, importedSnapshot, nil
+}
+
+// buildClonedCluster builds a standalone cluster from the manifest of the
+// source one, bootstrapping it from the passed volume snapshots. The
+// replica cluster and backup configurations of the source are removed,
+// together with the external clusters, so that the new cluster is
+// fully independent
+func buildClonedCluster(
+	template apiv1.Cluster,
+	options cloneOptions,
+	snapshots map[utils.PVCRole]storagesnapshotv1.VolumeSnapshot,
+) *apiv1.Cluster {
+	cluster := &apiv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      options.targetClusterName,
+			Namespace: options.targetNamespace,
+		},
+		Spec: *template.Spec.DeepCopy(),
+	}
+
+	if options.instances > 0 {
+		cluster.Spec.Instances = options.instances
+	}
+
+	cluster.Spec.ReplicaCluster = nil
+	cluster.Spec.ExternalClusters = nil
+	cluster.Spec.Backup = nil
+
+	recovery := &apiv1.BootstrapRecovery{
+		Database: template.GetApplicationDatabaseName(),
+		Owner:    template.GetApplicationDatabaseOwner(),
+		VolumeSnapshots: &apiv1.DataSource{
+			Storage: volumeSnapshotReference(snapshots[utils.PVCRolePgData].Name),
+		},
+	}
+	if walSnapshot, ok := snapshots[utils.PVCRolePgWal]; ok {
+		walStorage := volumeSnapshotReference(walSnapshot.Name)
+		recovery.VolumeSnapshots.WalStorage = &walStorage
+	}
+	cluster.Spec.Bootstrap = &apiv1.BootstrapConfiguration{
+		Recovery: recovery,
+	}
+
+	return cluster
+}
+
+func volumeSnapshotReference(name string) corev1.TypedLocalObjectReference {
+	return corev1.TypedLocalObjectReference{
+		APIGroup: ptr.To(storagesnapshotv1.GroupName),
+		Kind:     "VolumeSnapshot",
+		Name:     name,
+	}
+}
@@ -0,0 +1,274 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clone
+
+import (
+	"context"
+	"encoding/json"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/plugin"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("clone", func() {
+	var (
+		source  *apiv1.Cluster
+		backup  *apiv1.Backup
+		objects []storagesnapshotv1.VolumeSnapshot
+	)
+
+	newSnapshot := func(name string, role utils.PVCRole, rawCluster string) storagesnapshotv1.VolumeSnapshot {
+		return storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "prod",
+				Labels: map[string]string{
+					utils.ClusterLabelName:    "cluster-prod",
+					utils.BackupNameLabelName: "backup-clone",
+					utils.PvcRoleLabelName:    string(role),
+				},
+				Annotations: map[string]string{
+					utils.ClusterManifestAnnotationName: rawCluster,
+				},
+			},
+			Spec: storagesnapshotv1.VolumeSnapshotSpec{
+				Source: storagesnapshotv1.VolumeSnapshotSource{
+					PersistentVolumeClaimName: ptr.To(name),
+				},
+				VolumeSnapshotClassName: ptr.To("csi-snapclass"),
+			},
+			Status: &storagesnapshotv1.VolumeSnapshotStatus{
+				BoundVolumeSnapshotContentName: ptr.To("snapcontent-" + name),
+				ReadyToUse:                     ptr.To(true),
+			},
+		}
+	}
+
+	newContent := func(snapshotName string) *storagesnapshotv1.VolumeSnapshotContent {
+		return &storagesnapshotv1.VolumeSnapshotContent{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "snapcontent-" + snapshotName,
+			},
+			Spec: storagesnapshotv1.VolumeSnapshotContentSpec{
+				VolumeSnapshotRef: corev1.ObjectReference{
+					Name:      snapshotName,
+					Namespace: "prod",
+				},
+				DeletionPolicy:          storagesnapshotv1.VolumeSnapshotContentDelete,
+				Driver:                  "csi.example.com",
+				VolumeSnapshotClassName: ptr.To("csi-snapclass"),
+			},
+			Status: &storagesnapshotv1.VolumeSnapshotContentStatus{
+				SnapshotHandle: ptr.To("handle-" + snapshotName),
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		source = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-prod",
+				Namespace: "prod",
+			},
+			Spec: apiv1.ClusterSpec{
+				Instances: 3,
+				ImageName: "ghcr.io/cloudnative-pg/postgresql:16.0",
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					InitDB: &apiv1.BootstrapInitDB{
+						Database: "shop",
+						Owner:    "shop",
+					},
+				},
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{
+					Source:  "cluster-origin",
+					Enabled: true,
+				},
+				ExternalClusters: []apiv1.ExternalCluster{
+					{
+						Name: "cluster-origin",
+						ConnectionParameters: map[string]string{
+							"host": "cluster-origin-rw",
+						},
+					},
+				},
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						ClassName: "csi-snapclass",
+					},
+				},
+			},
+		}
+
+		rawCluster, err := json.Marshal(source)
+		Expect(err).ToNot(HaveOccurred())
+
+		backup = &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backup-clone",
+				Namespace: "prod",
+			},
+			Status: apiv1.BackupStatus{
+				Phase: apiv1.BackupPhaseCompleted,
+			},
+		}
+
+		objects = []storagesnapshotv1.VolumeSnapshot{
+			newSnapshot("cluster-prod-1", utils.PVCRolePgData, string(rawCluster)),
+			newSnapshot("cluster-prod-1-wal", utils.PVCRolePgWal, string(rawCluster)),
+		}
+	})
+
+	setupClient := func() {
+		builder := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(source, backup)
+		for idx := range objects {
+			builder = builder.WithObjects(&objects[idx], newContent(objects[idx].Name))
+		}
+		plugin.Namespace = "prod"
+		plugin.Client = builder.Build()
+	}
+
+	It("builds a standalone cluster applying the overrides", func() {
+		clone := buildClonedCluster(
+			*source,
+			cloneOptions{
+				targetClusterName: "cluster-test",
+				targetNamespace:   "test",
+				instances:         1,
+			},
+			map[utils.PVCRole]storagesnapshotv1.VolumeSnapshot{
+				utils.PVCRolePgData: objects[0],
+				utils.PVCRolePgWal:  objects[1],
+			},
+		)
+
+		Expect(clone.Name).To(Equal("cluster-test"))
+		Expect(clone.Namespace).To(Equal("test"))
+		Expect(clone.Spec.Instances).To(Equal(1))
+		Expect(clone.Spec.ImageName).To(Equal(source.Spec.ImageName))
+		Expect(clone.Spec.ReplicaCluster).To(BeNil())
+		Expect(clone.Spec.ExternalClusters).To(BeEmpty())
+		Expect(clone.Spec.Backup).To(BeNil())
+		Expect(clone.Spec.Bootstrap.InitDB).To(BeNil())
+		Expect(clone.Spec.Bootstrap.Recovery.Database).To(Equal("shop"))
+		Expect(clone.Spec.Bootstrap.Recovery.Owner).To(Equal("shop"))
+		Expect(clone.Spec.Bootstrap.Recovery.VolumeSnapshots.Storage.Name).To(Equal("cluster-prod-1"))
+		Expect(clone.Spec.Bootstrap.Recovery.VolumeSnapshots.WalStorage.Name).To(Equal("cluster-prod-1-wal"))
+
+		By("not changing the source cluster", func() {
+			Expect(source.Spec.ReplicaCluster).ToNot(BeNil())
+			Expect(source.Spec.Bootstrap.InitDB).ToNot(BeNil())
+		})
+	})
+
+	It("keeps the number of instances of the source when not overridden", func() {
+		clone := buildClonedCluster(
+			*source,
+			cloneOptions{targetClusterName: "cluster-test", targetNamespace: "prod"},
+			map[utils.PVCRole]storagesnapshotv1.VolumeSnapshot{utils.PVCRolePgData: objects[0]},
+		)
+		Expect(clone.Spec.Instances).To(Equal(3))
+		Expect(clone.Spec.Bootstrap.Recovery.VolumeSnapshots.WalStorage).To(BeNil())
+	})
+
+	It("creates the clone in the namespace of the source", func(ctx context.Context) {
+		setupClient()
+		cmd := newCloneCommand(ctx, cloneOptions{
+			clusterName:       "cluster-prod",
+			targetClusterName: "cluster-test",
+			targetNamespace:   "prod",
+			instances:         1,
+		})
+
+		clone, err := cmd.createClusterFromBackupStep(backup)
+		Expect(err).ToNot(HaveOccurred())
+
+		var created apiv1.Cluster
+		Expect(plugin.Client.Get(ctx, types.NamespacedName{Name: "cluster-test", Namespace: "prod"}, &created)).
+			To(Succeed())
+		Expect(created.Spec.Instances).To(Equal(1))
+		Expect(created.Spec.ReplicaCluster).To(BeNil())
+		Expect(clone.Spec.Bootstrap.Recovery.VolumeSnapshots.Storage.Name).To(Equal("cluster-prod-1"))
+
+		var contents storagesnapshotv1.VolumeSnapshotContentList
+		Expect(plugin.Client.List(ctx, &contents)).To(Succeed())
+		Expect(contents.Items).To(HaveLen(2))
+	})
+
+	It("imports the snapshots when cloning in another namespace", func(ctx context.Context) {
+		setupClient()
+		cmd := newCloneCommand(ctx, cloneOptions{
+			clusterName:       "cluster-prod",
+			targetClusterName: "cluster-test",
+			targetNamespace:   "test",
+		})
+
+		_, err := cmd.createClusterFromBackupStep(backup)
+		Expect(err).ToNot(HaveOccurred())
+
+		var created apiv1.Cluster
+		Expect(plugin.Client.Get(ctx, types.NamespacedName{Name: "cluster-test", Namespace: "test"}, &created)).
+			To(Succeed())
+		Expect(created.Spec.Instances).To(Equal(3))
+
+		var importedSnapshot storagesnapshotv1.VolumeSnapshot
+		Expect(plugin.Client.Get(ctx, types.NamespacedName{Name: "cluster-prod-1", Namespace: "test"},
+			&importedSnapshot)).To(Succeed())
+		Expect(importedSnapshot.Spec.Source.VolumeSnapshotContentName).To(Equal(ptr.To("test-cluster-prod-1")))
+		Expect(importedSnapshot.Labels).ToNot(HaveKey(utils.ClusterLabelName))
+
+		var importedContent storagesnapshotv1.VolumeSnapshotContent
+		Expect(plugin.Client.Get(ctx, types.NamespacedName{Name: "test-cluster-prod-1"}, &importedContent)).
+			To(Succeed())
+		Expect(importedContent.Spec.DeletionPolicy).To(Equal(storagesnapshotv1.VolumeSnapshotContentRetain))
+		Expect(importedContent.Spec.Source.SnapshotHandle).To(Equal(ptr.To("handle-cluster-prod-1")))
+		Expect(importedContent.Spec.VolumeSnapshotRef.Namespace).To(Equal("test"))
+
+		var walSnapshot storagesnapshotv1.VolumeSnapshot
+		Expect(plugin.Client.Get(ctx, types.NamespacedName{Name: "cluster-prod-1-wal", Namespace: "test"},
+			&walSnapshot)).To(Succeed())
+	})
+
+	It("refuses to overwrite an existing cluster", func(ctx context.Context) {
+		setupClient()
+		cmd := newCloneCommand(ctx, cloneOptions{
+			clusterName:       "cluster-prod",
+			targetClusterName: "cluster-prod",
+			targetNamespace:   "prod",
+		})
+		Expect(cmd.ensureTargetClusterDoesNotExistStep()).ToNot(Succeed())
+	})
+
+	It("fails when the snapshot has no cluster manifest", func() {
+		delete(objects[0].Annotations, utils.ClusterManifestAnnotationName)
+		_, err := getClusterFromSnapshotAnnotation(objects[0])
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,86 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clone
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/plugin"
+)
+
+// NewCmd creates the new "clone" subcommand
+func NewCmd() *cobra.Command {
+	var options cloneOptions
+
+	cloneCmd := &cobra.Command{
+		Use:   "clone [cluster] [new-cluster]",
+		Short: "Take a volume snapshot backup of a cluster and create a new independent cluster from it",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.clusterName = args[0]
+			options.targetClusterName = args[1]
+
+			if len(options.backupName) == 0 {
+				options.backupName = fmt.Sprintf(
+					"%s-clone-%s",
+					options.clusterName,
+					time.Now().Format("20060102150405"))
+			}
+
+			if len(options.targetNamespace) == 0 {
+				options.targetNamespace = plugin.Namespace
+			}
+
+			if options.instances < 0 {
+				return fmt.Errorf("instances: %d is not a valid number of instances", options.instances)
+			}
+
+			return newCloneCommand(cmd.Context(), options).execute()
+		},
+	}
+
+	cloneCmd.Flags().StringVar(
+		&options.targetNamespace,
+		"target-namespace",
+		"",
+		"The namespace where the new cluster will be created, defaults to the namespace of [cluster]",
+	)
+	cloneCmd.Flags().IntVar(
+		&options.instances,
+		"instances",
+		0,
+		"The number of instances of the new cluster, defaults to the one of [cluster]",
+	)
+	cloneCmd.Flags().StringVar(
+		&options.backupName,
+		"backup-name",
+		"",
+		"The name of the Backup resource that will be created, "+
+			"defaults to \"[cluster]-clone-[current_timestamp]\"",
+	)
+	cloneCmd.Flags().DurationVar(
+		&options.timeout,
+		"timeout",
+		time.Hour,
+		"The maximum time to wait for the backup to be completed",
+	)
+
+	return cloneCmd
+}
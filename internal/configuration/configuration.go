@@ -88,8 +88,22 @@ type Data struct {
 	// CreateAnyService is true when the user wants the operator to create
 	// the <cluster-name>-any service. Defaults to false.
 	CreateAnyService bool `json:"createAnyService" env:"CREATE_ANY_SERVICE"`
+
+	// TracingExporter is the exporter of the OpenTelemetry spans emitted
+	// by the operator. The only supported value is "log", and tracing is
+	// disabled when empty. Defaults to empty.
+	TracingExporter string `json:"tracingExporter" env:"TRACING_EXPORTER"`
+
+	// SnapshotOwnerReference is the owner reference of the volume snapshots
+	// when neither the backup nor the cluster set one. The supported values
+	// are "none", "cluster" and "backup". Defaults to empty, i.e. "none".
+	SnapshotOwnerReference string `json:"snapshotOwnerReference" env:"SNAPSHOT_OWNER_REFERENCE"`
 }
 
+// TracingExporterLog is the value of TracingExporter making the operator
+// write the OpenTelemetry spans it emits in its log
+const TracingExporterLog = "log"
+
 // Current is the configuration used by the operator
 var Current = NewConfiguration()
 
@@ -43,6 +43,7 @@ import (
 	"github.com/cloudnative-pg/cloudnative-pg/internal/management/controller/slots/reconciler"
 	"github.com/cloudnative-pg/cloudnative-pg/internal/management/utils"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/certs"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/conditions"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/configfile"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman/archiver"
@@ -208,6 +209,12 @@ func (r *InstanceReconciler) Reconcile(
 	}
 	restarted = restarted || restartedInplace
 
+	restartedForTarget, err := r.reconcileReplicaRecoveryTarget(ctx, cluster)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	restarted = restarted || restartedForTarget
+
 	// from now on the database can be assumed as running
 
 	if reloadNeeded && !restarted {
@@ -228,6 +235,8 @@ func (r *InstanceReconciler) Reconcile(
 		return reconcile.Result{}, fmt.Errorf("cannot reconcile database configurations: %w", err)
 	}
 
+	r.reconcilePrewarm(cluster)
+
 	// Extremely important.
 	// It could happen that current primary is reconciled before all the topology is extracted by the operator.
 	// We should detect that and schedule the instance manager for another run otherwise we will end up having
@@ -241,6 +250,17 @@ func (r *InstanceReconciler) Reconcile(
 		return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
 	}
 
+	// The presence of the standby.signal file is verified while refreshing
+	// the replica configuration, and so is applied the outcome of the probes
+	// of the source run in the background, so we need to reconcile periodically
+	interval := cluster.GetStandbySignalCheckInterval()
+	if r.instance.IsSourceProbeEnabled() && (interval == 0 || interval > postgresManagement.SourceProbeInterval) {
+		interval = postgresManagement.SourceProbeInterval
+	}
+	if interval > 0 {
+		return reconcile.Result{RequeueAfter: interval}, nil
+	}
+
 	return reconcile.Result{}, nil
 }
 
@@ -273,6 +293,42 @@ func (r *InstanceReconciler) restartPrimaryInplaceIfRequested(
 	return false, nil
 }
 
+// reconcileReplicaRecoveryTarget makes the designated primary of a replica
+// cluster resume following the source once the replay has been paused at its
+// recovery target. The recovery target settings are only applied at startup,
+// so PostgreSQL is restarted without them
+func (r *InstanceReconciler) reconcileReplicaRecoveryTarget(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+) (bool, error) {
+	target := cluster.GetPendingReplicaRecoveryTarget()
+	if target == nil || cluster.Status.CurrentPrimary != r.instance.PodName {
+		return false, nil
+	}
+
+	paused, err := r.instance.IsWALReplayPaused()
+	if err != nil || !paused {
+		return false, err
+	}
+
+	log.FromContext(ctx).Info("The designated primary reached its recovery target, resuming following the source",
+		"target", target)
+	if err := conditions.Patch(ctx, r.client, cluster, &metav1.Condition{
+		Type:    string(apiv1.ConditionReplicaRecoveryTargetReached),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(apiv1.ConditionReasonReplicaRecoveryTargetReached),
+		Message: "The designated primary replayed the WAL up to its recovery target",
+	}); err != nil {
+		return false, err
+	}
+
+	if _, err := postgresManagement.UpdateReplicaRecoveryTarget(r.instance.PgData, nil); err != nil {
+		return false, err
+	}
+
+	return true, r.instance.RequestAndWaitRestartSmartFast()
+}
+
 func (r *InstanceReconciler) refreshConfigurationFiles(
 	ctx context.Context,
 	cluster *apiv1.Cluster,
@@ -1056,11 +1112,23 @@ func (r *InstanceReconciler) reconcilePrimary(ctx context.Context, cluster *apiv
 
 	// If I'm not the primary, let's promote myself
 	if !isPrimary {
+		// The designated primary of a replica cluster is promoted when
+		// the cluster is detached from its source
+		detached := cluster.Spec.ReplicaCluster != nil && cluster.Status.CurrentPrimary == r.instance.PodName
+
 		cluster.LogTimestampsWithMessage(ctx, "Setting myself as primary")
 		if err := r.handlePromotion(ctx, cluster); err != nil {
 			return false, err
 		}
 		restarted = true
+
+		if detached {
+			// The promotion already happened, and won't be detected again:
+			// the slots which couldn't be dropped are left to the user
+			if err := r.instance.DropDetachedReplicationSlots(ctx, cluster); err != nil {
+				log.FromContext(ctx).Error(err, "while dropping the replication slots inherited from the source")
+			}
+		}
 	}
 
 	// if the currentPrimary doesn't match the PodName we set the correct value.
@@ -1130,6 +1198,16 @@ func (r *InstanceReconciler) reconcileDesignatedPrimary(
 	return changed, r.client.Status().Patch(ctx, cluster, client.MergeFrom(oldCluster))
 }
 
+// reconcilePrewarm loads the configured relations in the shared buffers
+// once the designated primary of a replica cluster is up
+func (r *InstanceReconciler) reconcilePrewarm(cluster *apiv1.Cluster) {
+	if !cluster.IsReplica() || cluster.Status.CurrentPrimary != r.instance.PodName {
+		return
+	}
+
+	r.instance.StartPrewarm(cluster.Spec.ReplicaCluster.Prewarm)
+}
+
 // waitForWalReceiverDown wait until the wal receiver is down, and it's used
 // to grab all the WAL files from a replica
 func (r *InstanceReconciler) waitForWalReceiverDown() error {
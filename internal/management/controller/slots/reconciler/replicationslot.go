@@ -19,6 +19,7 @@ package reconciler
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -40,9 +41,10 @@ func ReconcileReplicationSlots(
 		return reconcile.Result{}, nil
 	}
 
-	// if the replication slots feature was deactivated, ensure any existing
-	// replication slots get cleaned up
-	if !cluster.Spec.ReplicationSlots.HighAvailability.GetEnabled() {
+	// if the replication slots feature was deactivated, or the standbys are
+	// using temporary slots, ensure any existing replication slots get cleaned up
+	if !cluster.Spec.ReplicationSlots.HighAvailability.GetEnabled() ||
+		cluster.Spec.ReplicationSlots.UsesTemporarySlots() {
 		return dropReplicationSlots(ctx, manager, cluster)
 	}
 
@@ -110,13 +112,63 @@ func reconcilePrimaryReplicationSlots(
 		}
 	}
 
-	if needToReschedule {
+	staleSlotsActive, err := dropStaleReplicationSlots(ctx, manager, cluster, expectedSlots)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if needToReschedule || staleSlotsActive {
 		return reconcile.Result{RequeueAfter: time.Second}, nil
 	}
 
 	return reconcile.Result{}, nil
 }
 
+// dropStaleReplicationSlots drops the inactive HA replication slots named
+// after a prefix previously used by the cluster, which otherwise would
+// retain WAL files forever. Returns true if any stale slot is still active
+func dropStaleReplicationSlots(
+	ctx context.Context,
+	manager infrastructure.Manager,
+	cluster *apiv1.Cluster,
+	expectedSlots map[string]bool,
+) (bool, error) {
+	contextLogger := log.FromContext(ctx)
+	currentPrefix := cluster.Spec.ReplicationSlots.HighAvailability.GetSlotPrefix()
+
+	activeSlots := false
+	for _, prefix := range cluster.GetStaleHASlotPrefixes() {
+		config := cluster.Spec.ReplicationSlots.DeepCopy()
+		config.HighAvailability.SlotPrefix = prefix
+
+		slots, err := manager.List(ctx, config)
+		if err != nil {
+			return false, fmt.Errorf("listing stale replication slots: %w", err)
+		}
+
+		for _, slot := range slots.Items {
+			// The current prefix may begin with a stale one
+			if expectedSlots[slot.SlotName] || strings.HasPrefix(slot.SlotName, currentPrefix) {
+				continue
+			}
+
+			if slot.Active {
+				contextLogger.Info("Skipping deletion of stale replication slot because it is active",
+					"slot", slot.SlotName)
+				activeSlots = true
+				continue
+			}
+
+			contextLogger.Info("Deleting stale replication slot", "slot", slot.SlotName, "prefix", prefix)
+			if err := manager.Delete(ctx, slot); err != nil {
+				return false, fmt.Errorf("failure deleting stale replication slot %q: %w", slot.SlotName, err)
+			}
+		}
+	}
+
+	return activeSlots, nil
+}
+
 func dropReplicationSlots(
 	ctx context.Context,
 	manager infrastructure.Manager,
@@ -19,6 +19,7 @@ package reconciler
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"k8s.io/utils/ptr"
@@ -62,7 +63,7 @@ func (fk fakeReplicationSlotManager) Update(_ context.Context, _ infrastructure.
 
 func (fk fakeReplicationSlotManager) List(
 	_ context.Context,
-	_ *apiv1.ReplicationSlotsConfiguration,
+	config *apiv1.ReplicationSlotsConfiguration,
 ) (infrastructure.ReplicationSlotList, error) {
 	var slotList infrastructure.ReplicationSlotList
 	if fk.triggerListError {
@@ -70,6 +71,9 @@ func (fk fakeReplicationSlotManager) List(
 	}
 
 	for slot := range fk.replicationSlots {
+		if !strings.HasPrefix(slot.name, config.HighAvailability.GetSlotPrefix()) {
+			continue
+		}
 		slotList.Items = append(slotList.Items, infrastructure.ReplicationSlot{
 			SlotName:   slot.name,
 			RestartLSN: "",
@@ -214,3 +218,64 @@ var _ = Describe("dropReplicationSlots", func() {
 		Expect(fakeManager.replicationSlots).NotTo(HaveKey(fakeSlot{name: "slot1", active: false}))
 	})
 })
+
+var _ = Describe("Stale HA replication slots", func() {
+	const oldPrefix = "_old_"
+
+	It("removes the inactive slots named after a previous prefix", func(ctx context.Context) {
+		fakeSlotManager := fakeReplicationSlotManager{
+			replicationSlots: map[fakeSlot]bool{
+				{name: oldPrefix + "instance2"}:  true,
+				{name: oldPrefix + "instance3"}:  true,
+				{name: slotPrefix + "instance2"}: true,
+				{name: "user_slot"}:              true,
+			},
+		}
+
+		cluster := makeClusterWithInstanceNames([]string{"instance1", "instance2", "instance3"}, "instance1")
+		cluster.Status.HASlotPrefixes = []string{oldPrefix, slotPrefix}
+
+		res, err := ReconcileReplicationSlots(ctx, "instance1", fakeSlotManager, &cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeZero())
+		Expect(fakeSlotManager.replicationSlots).To(HaveLen(3))
+		Expect(fakeSlotManager.replicationSlots).To(HaveKey(fakeSlot{name: slotPrefix + "instance2"}))
+		Expect(fakeSlotManager.replicationSlots).To(HaveKey(fakeSlot{name: slotPrefix + "instance3"}))
+		Expect(fakeSlotManager.replicationSlots).To(HaveKey(fakeSlot{name: "user_slot"}))
+	})
+
+	It("keeps the active stale slots and retries later", func(ctx context.Context) {
+		fakeSlotManager := fakeReplicationSlotManager{
+			replicationSlots: map[fakeSlot]bool{
+				{name: oldPrefix + "instance2", active: true}: true,
+				{name: slotPrefix + "instance2"}:              true,
+			},
+		}
+
+		cluster := makeClusterWithInstanceNames([]string{"instance1", "instance2"}, "instance1")
+		cluster.Status.HASlotPrefixes = []string{oldPrefix, slotPrefix}
+
+		res, err := ReconcileReplicationSlots(ctx, "instance1", fakeSlotManager, &cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.RequeueAfter).ToNot(BeZero())
+		Expect(fakeSlotManager.replicationSlots).To(HaveKey(fakeSlot{name: oldPrefix + "instance2", active: true}))
+	})
+
+	It("never removes the current slots when the current prefix extends a previous one", func(ctx context.Context) {
+		fakeSlotManager := fakeReplicationSlotManager{
+			replicationSlots: map[fakeSlot]bool{
+				{name: "_cnpg_instance2"}:     true,
+				{name: "_cnpg_new_instance2"}: true,
+			},
+		}
+
+		cluster := makeClusterWithInstanceNames([]string{"instance1", "instance2"}, "instance1")
+		cluster.Spec.ReplicationSlots.HighAvailability.SlotPrefix = "_cnpg_new_"
+		cluster.Status.HASlotPrefixes = []string{"_cnpg_", "_cnpg_new_"}
+
+		_, err := ReconcileReplicationSlots(ctx, "instance1", fakeSlotManager, &cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeSlotManager.replicationSlots).To(HaveLen(1))
+		Expect(fakeSlotManager.replicationSlots).To(HaveKey(fakeSlot{name: "_cnpg_new_instance2"}))
+	})
+})
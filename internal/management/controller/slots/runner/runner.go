@@ -61,9 +61,11 @@ func (sr *Replicator) Start(ctx context.Context) error {
 			case <-ticker.C:
 			}
 
-			// If replication is disabled stop the timer,
-			// the process will resume through the wakeUp channel if necessary
-			if config == nil || config.HighAvailability == nil || !config.HighAvailability.GetEnabled() {
+			// If replication is disabled, or there are no permanent slots
+			// to synchronize, stop the timer, the process will resume
+			// through the wakeUp channel if necessary
+			if config == nil || config.HighAvailability == nil || !config.HighAvailability.GetEnabled() ||
+				config.UsesTemporarySlots() {
 				ticker.Stop()
 				// we set updateInterval to 0 to make sure the Ticker will be reset
 				// if the feature is enabled again
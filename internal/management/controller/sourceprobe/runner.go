@@ -0,0 +1,67 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sourceprobe contains the runner periodically probing the source
+// of a replica cluster from its designated primary
+package sourceprobe
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres"
+)
+
+// A Prober is a runner that periodically probes the source of a replica
+// cluster, so that the reconciliation loop never waits for the source
+type Prober struct {
+	instance *postgres.Instance
+	interval time.Duration
+}
+
+// NewProber creates a new source Prober
+func NewProber(instance *postgres.Instance) *Prober {
+	return &Prober{
+		instance: instance,
+		interval: postgres.SourceProbeInterval,
+	}
+}
+
+// Start starts running the source Prober
+func (p *Prober) Start(ctx context.Context) error {
+	contextLog := log.FromContext(ctx).WithName("SourceProber")
+	ticker := time.NewTicker(p.interval)
+	defer func() {
+		ticker.Stop()
+		contextLog.Info("Terminated source prober loop")
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if !p.instance.IsSourceProbeEnabled() {
+			continue
+		}
+
+		contextLog.Trace("Probing the source of the replica cluster")
+		p.instance.ProbeSource(ctx)
+	}
+}
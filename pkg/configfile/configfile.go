@@ -21,7 +21,9 @@ package configfile
 import (
 	"fmt"
 	"math"
+	"sort"
 	"strings"
+	"unicode"
 
 	"github.com/lib/pq"
 
@@ -78,8 +80,7 @@ func UpdateConfigurationContents(content string, options map[string]string) (str
 			continue
 		}
 
-		kv := strings.SplitN(trimLine, "=", 2)
-		key := strings.TrimSpace(kv[0])
+		key, _ := splitOption(trimLine)
 
 		// If we find a line containing one of the option we have to manage,
 		// we replace it with the provided content
@@ -96,10 +97,16 @@ func UpdateConfigurationContents(content string, options map[string]string) (str
 		resultContent = append(resultContent, line)
 	}
 
-	// Append missing options to the end of the file
-	for key, value := range options {
+	// Append missing options to the end of the file, in a stable order
+	// so that the same options always produce the same content
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
 		if !foundKeys.Has(key) {
-			resultContent = append(resultContent, key+" = "+pq.QuoteLiteral(value))
+			resultContent = append(resultContent, key+" = "+pq.QuoteLiteral(options[key]))
 		}
 	}
 
@@ -119,12 +126,11 @@ func RemoveOptionFromConfigurationContents(content string, option string) string
 			continue
 		}
 
-		kv := strings.SplitN(trimLine, "=", 2)
-		key := strings.TrimSpace(kv[0])
+		key, _ := splitOption(trimLine)
 
 		// If we find a line containing the input option,
 		// we skip it
-		if key == option {
+		if strings.EqualFold(key, option) {
 			continue
 		}
 
@@ -133,3 +139,96 @@ func RemoveOptionFromConfigurationContents(content string, option string) string
 
 	return strings.Join(resultContent, "\n") + "\n"
 }
+
+// ParseConfigurationContents parses a configuration file whose content is
+// passed, returning the effective value of each option. As PostgreSQL does,
+// the option names are case-insensitive and the last occurrence of an
+// option overrides the previous ones
+func ParseConfigurationContents(content string) map[string]string {
+	result := make(map[string]string)
+	for _, line := range splitLines(content) {
+		trimLine := strings.TrimSpace(line)
+		if len(trimLine) == 0 || trimLine[0] == '#' {
+			continue
+		}
+
+		key, rawValue := splitOption(trimLine)
+		result[key] = parseOptionValue(rawValue)
+	}
+
+	return result
+}
+
+// splitOption splits a configuration line into the lowercase name of the
+// option and its raw value. The name can be followed by an equal sign or
+// just by whitespace
+func splitOption(trimLine string) (string, string) {
+	nameEnd := strings.IndexFunc(trimLine, func(r rune) bool {
+		return r == '=' || unicode.IsSpace(r)
+	})
+	if nameEnd == -1 {
+		return strings.ToLower(trimLine), ""
+	}
+
+	rawValue := strings.TrimSpace(trimLine[nameEnd:])
+	rawValue = strings.TrimSpace(strings.TrimPrefix(rawValue, "="))
+	return strings.ToLower(trimLine[:nameEnd]), rawValue
+}
+
+// parseOptionValue gets the value of an option from its raw content,
+// removing the quotes and the trailing comments
+func parseOptionValue(rawValue string) string {
+	// pq.QuoteLiteral uses the escape string syntax when the
+	// value contains a backslash
+	if strings.HasPrefix(rawValue, "E'") || strings.HasPrefix(rawValue, "e'") {
+		rawValue = rawValue[1:]
+	}
+
+	if !strings.HasPrefix(rawValue, "'") {
+		end := strings.IndexFunc(rawValue, func(r rune) bool {
+			return r == '#' || unicode.IsSpace(r)
+		})
+		if end == -1 {
+			return rawValue
+		}
+		return rawValue[:end]
+	}
+
+	var value strings.Builder
+	for i := 1; i < len(rawValue); i++ {
+		c := rawValue[i]
+		switch {
+		case c == '\'' && i+1 < len(rawValue) && rawValue[i+1] == '\'':
+			value.WriteByte('\'')
+			i++
+		case c == '\'':
+			return value.String()
+		case c == '\\' && i+1 < len(rawValue):
+			i++
+			value.WriteByte(unescapeOptionByte(rawValue[i]))
+		default:
+			value.WriteByte(c)
+		}
+	}
+
+	return value.String()
+}
+
+// unescapeOptionByte gets the byte represented by a backslash escape
+// sequence inside a quoted value
+func unescapeOptionByte(c byte) byte {
+	switch c {
+	case 'b':
+		return '\b'
+	case 'f':
+		return '\f'
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	default:
+		return c
+	}
+}
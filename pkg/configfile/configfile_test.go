@@ -199,3 +199,44 @@ var _ = Describe("Remove configuration files option", func() {
 		Expect(updatedContent).To(Equal(wantedContent))
 	})
 })
+
+var _ = Describe("Parse configuration files", func() {
+	It("gets the effective value of every option", func() {
+		content := "# Do not edit this file manually!\n" +
+			"primary_conninfo = 'host=someHost1'\n" +
+			"Primary_Conninfo 'host=someHost2'\n" +
+			"work_mem=64MB # set by hand\n" +
+			"restore_command = 'cp ''/archive/%f'' %p'\n" +
+			"primary_slot_name = E'slot\\\\name'\n"
+
+		Expect(ParseConfigurationContents(content)).To(Equal(map[string]string{
+			"primary_conninfo":  "host=someHost2",
+			"work_mem":          "64MB",
+			"restore_command":   "cp '/archive/%f' %p",
+			"primary_slot_name": `slot\name`,
+		}))
+	})
+
+	It("reads back the values written by UpdateConfigurationContents", func() {
+		options := map[string]string{
+			"primary_conninfo": `host=someHost password='sec\ret'`,
+			"restore_command":  "cp /archive/%f %p",
+		}
+
+		content, err := UpdateConfigurationContents("", options)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ParseConfigurationContents(content)).To(Equal(options))
+	})
+
+	It("replaces the options written with a different case or separator", func() {
+		initialContent := "Primary_Conninfo 'host=someHost1'\n" +
+			"work_mem = '64MB'\n"
+
+		updatedContent, err := UpdateConfigurationContents(initialContent, map[string]string{
+			"primary_conninfo": "host=someHost2",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(updatedContent).To(Equal("primary_conninfo = 'host=someHost2'\n" +
+			"work_mem = '64MB'\n"))
+	})
+})
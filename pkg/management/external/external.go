@@ -30,13 +30,16 @@ import (
 // ConfigureConnectionToServer creates a connection string to the external
 // server, using the configuration inside the cluster and dumping the secret when
 // needed. This function will return a connection string, the name of the pgpass file
-// to be used, and an error state
+// to be used, and an error state. The secrets are read from the namespace of
+// the cluster, passed as namespace, unless the external cluster refers to
+// secrets in another namespace
 func ConfigureConnectionToServer(
 	ctx context.Context, client ctrl.Client,
 	namespace string, server *apiv1.ExternalCluster,
 ) (string, string, error) {
 	connectionParameters := make(map[string]string, len(server.ConnectionParameters))
 	pgpassfile := ""
+	namespace = server.GetSecretsNamespace(namespace)
 
 	for key, value := range server.ConnectionParameters {
 		connectionParameters[key] = value
@@ -0,0 +1,61 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"context"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("connection to an external server", func() {
+	It("honors the port of the external server", func(ctx context.Context) {
+		server := apiv1.ExternalCluster{
+			Name: "source",
+			ConnectionParameters: map[string]string{
+				"host":   "gateway.example.com",
+				"port":   "15432",
+				"user":   "streaming_replica",
+				"dbname": "postgres",
+			},
+		}
+
+		connectionString, pgpassfile, err := ConfigureConnectionToServer(ctx, nil, "default", &server)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pgpassfile).To(BeEmpty())
+		Expect(connectionString).To(Equal(
+			"dbname='postgres' host='gateway.example.com' port='15432' user='streaming_replica'"))
+	})
+
+	It("honors a list of ports when multiple hosts are specified", func(ctx context.Context) {
+		server := apiv1.ExternalCluster{
+			Name: "source",
+			ConnectionParameters: map[string]string{
+				"host": "gateway-a.example.com,gateway-b.example.com",
+				"port": "15432,25432",
+			},
+		}
+
+		connectionString, _, err := ConfigureConnectionToServer(ctx, nil, "default", &server)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(connectionString).To(Equal(
+			"host='gateway-a.example.com,gateway-b.example.com' port='15432,25432'"))
+	})
+})
@@ -140,3 +140,13 @@ func CreatePgPassFile(serverName, password string) (string, error) {
 
 	return f.Name(), nil
 }
+
+// RemovePgPassFile removes the pgpass file created to connect to the passed
+// server, if it exists
+func RemovePgPassFile(serverName string) error {
+	err := os.Remove(path.Join(getExternalSecretsPath(), serverName, "pgpass"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// transactionRateSampleInterval is the time elapsing between the two
+// samples of the transaction counters used to compute the transaction rate
+const transactionRateSampleInterval = 2 * time.Second
+
+// GetTransactionRate measures the number of transactions per second
+// executed by the instance, sampling the counters of pg_stat_database
+func (instance *Instance) GetTransactionRate(ctx context.Context) (float64, error) {
+	superUserDB, err := instance.GetSuperUserDB()
+	if err != nil {
+		return 0, err
+	}
+
+	firstCount, err := getTransactionCount(superUserDB)
+	if err != nil {
+		return 0, err
+	}
+	firstSample := time.Now()
+
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-time.After(transactionRateSampleInterval):
+	}
+
+	secondCount, err := getTransactionCount(superUserDB)
+	if err != nil {
+		return 0, err
+	}
+
+	return computeTransactionRate(firstCount, secondCount, time.Since(firstSample)), nil
+}
+
+// getTransactionCount returns the number of transactions committed
+// or rolled back in every database of the instance
+func getTransactionCount(db *sql.DB) (int64, error) {
+	var count int64
+	row := db.QueryRow(
+		"SELECT COALESCE(SUM(xact_commit + xact_rollback), 0)::bigint FROM pg_catalog.pg_stat_database")
+	if err := row.Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// computeTransactionRate computes the transactions per second between two samples
+// of the transaction counters. A decreasing counter means that the statistics have
+// been reset between the two samples, and no rate can be computed
+func computeTransactionRate(firstCount, secondCount int64, elapsed time.Duration) float64 {
+	if secondCount < firstCount || elapsed <= 0 {
+		return 0
+	}
+	return float64(secondCount-firstCount) / elapsed.Seconds()
+}
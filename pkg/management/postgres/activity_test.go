@@ -0,0 +1,54 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("transaction rate", func() {
+	It("reads the number of transactions of every database", func() {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		defer func() {
+			_ = db.Close()
+		}()
+
+		mock.ExpectQuery("SELECT COALESCE\\(SUM\\(xact_commit \\+ xact_rollback\\), 0\\)").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1234))
+
+		count, err := getTransactionCount(db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(BeEquivalentTo(1234))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("computes the transactions per second between two samples", func() {
+		Expect(computeTransactionRate(1000, 1400, 2*time.Second)).To(BeNumerically("==", 200))
+		Expect(computeTransactionRate(1000, 1000, 2*time.Second)).To(BeZero())
+	})
+
+	It("reports no activity when the statistics have been reset", func() {
+		Expect(computeTransactionRate(1000, 10, 2*time.Second)).To(BeZero())
+		Expect(computeTransactionRate(1000, 1400, 0)).To(BeZero())
+	})
+})
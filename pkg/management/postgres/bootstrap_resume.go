@@ -0,0 +1,172 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// baseBackupCompletedFileName is the name of the file created in PGDATA once
+// the base backup of a replica cluster bootstrap has been completely copied.
+// Its presence tells that an interrupted bootstrap can be resumed
+const baseBackupCompletedFileName = "cnpg_base_backup_completed"
+
+// bootstrapInProgressFileName is the name of the file created next to PGDATA
+// when a replica cluster bootstrap starts writing it. It contains the
+// identifier of the bootstrap, and its presence tells that the PGDATA has
+// been written by an interrupted attempt of that bootstrap
+const bootstrapInProgressFileName = "cnpg_bootstrap_in_progress"
+
+// getPartialPgDataState checks whether the PGDATA left by an interrupted
+// bootstrap contains a complete copy of the base backup. When it doesn't,
+// the reason is returned
+func getPartialPgDataState(pgData string) (resumable bool, reason string, err error) {
+	requiredFiles := []struct {
+		name   string
+		reason string
+	}{
+		{
+			name:   baseBackupCompletedFileName,
+			reason: "the copy of the base backup was interrupted",
+		},
+		{
+			name:   "PG_VERSION",
+			reason: "PG_VERSION is missing",
+		},
+		{
+			name:   path.Join("global", "pg_control"),
+			reason: "the control file is missing",
+		},
+	}
+
+	for _, requiredFile := range requiredFiles {
+		exists, err := fileutils.FileExists(path.Join(pgData, requiredFile.name))
+		if err != nil {
+			return false, "", err
+		}
+		if !exists {
+			return false, requiredFile.reason, nil
+		}
+	}
+
+	return true, "", nil
+}
+
+// getBootstrapMarkPath returns the path of the file recording that a
+// bootstrap is in progress. It is stored next to PGDATA, as the latter
+// may not exist yet or may be removed when the bootstrap starts over
+func (info InitInfo) getBootstrapMarkPath() string {
+	return path.Join(path.Dir(info.PgData), bootstrapInProgressFileName)
+}
+
+// getBootstrapInProgress returns the identifier of the bootstrap which
+// has been interrupted while writing PGDATA, if any
+func (info InitInfo) getBootstrapInProgress() (string, error) {
+	content, err := fileutils.ReadFile(info.getBootstrapMarkPath())
+	return string(content), err
+}
+
+// HasInterruptedBootstrap checks whether a bootstrap has been interrupted
+// while writing PGDATA, in which case the existing PGDATA may be resumed
+// or cleaned by the bootstrap process
+func (info InitInfo) HasInterruptedBootstrap() (bool, error) {
+	bootstrapID, err := info.getBootstrapInProgress()
+	return bootstrapID != "", err
+}
+
+// ResumeOrCleanPartialBootstrap checks whether a PGDATA has been left by an
+// interrupted attempt of the replica cluster bootstrap identified by the
+// passed ID and, in that case, whether it can be used to resume the bootstrap
+// by fetching or streaming the remaining WALs. A PGDATA that can't be safely
+// used is removed, together with the WAL directory, so that the bootstrap can
+// start over. A PGDATA which hasn't been written by this bootstrap is never
+// touched, and makes the bootstrap fail
+func (info InitInfo) ResumeOrCleanPartialBootstrap(ctx context.Context, bootstrapID string) (bool, error) {
+	contextLogger := log.FromContext(ctx)
+
+	bootstrapInProgress, err := info.getBootstrapInProgress()
+	if err != nil {
+		return false, err
+	}
+
+	pgDataExists, err := fileutils.FileExists(info.PgData)
+	if err != nil {
+		return false, err
+	}
+
+	if bootstrapInProgress != bootstrapID {
+		if pgDataExists {
+			contextLogger.Info("Found a PGDATA not written by this bootstrap, refusing to touch it",
+				"pgdata", info.PgData)
+			return false, info.VerifyPGData()
+		}
+
+		// This is a new bootstrap, which can be resumed from now on
+		_, err := fileutils.WriteStringToFile(info.getBootstrapMarkPath(), bootstrapID)
+		return false, err
+	}
+
+	if pgDataExists {
+		resumable, reason, err := getPartialPgDataState(info.PgData)
+		if err != nil {
+			return false, err
+		}
+		if resumable {
+			contextLogger.Info("Found the PGDATA of an interrupted bootstrap, resuming it",
+				"pgdata", info.PgData)
+			return true, nil
+		}
+
+		contextLogger.Warning("Found the PGDATA of an interrupted bootstrap which can't be resumed, "+
+			"removing it to start a clean bootstrap",
+			"pgdata", info.PgData,
+			"reason", reason)
+		if err := os.RemoveAll(info.PgData); err != nil {
+			return false, fmt.Errorf("while removing the partial PGDATA: %w", err)
+		}
+	}
+
+	if info.PgWal != "" {
+		if err := os.RemoveAll(info.PgWal); err != nil {
+			return false, fmt.Errorf("while removing the partial WAL directory: %w", err)
+		}
+	}
+
+	return false, nil
+}
+
+// MarkBaseBackupCompleted records in PGDATA that the base backup has been
+// completely copied, so that an interrupted bootstrap can be resumed
+func (info InitInfo) MarkBaseBackupCompleted() error {
+	return fileutils.CreateEmptyFile(path.Join(info.PgData, baseBackupCompletedFileName))
+}
+
+// RemoveBootstrapMarks removes the files recording the progress of the
+// bootstrap, once it is over
+func (info InitInfo) RemoveBootstrapMarks() error {
+	if err := fileutils.RemoveFile(path.Join(info.PgData, baseBackupCompletedFileName)); err != nil {
+		return err
+	}
+
+	return fileutils.RemoveFile(info.getBootstrapMarkPath())
+}
@@ -0,0 +1,137 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("resuming an interrupted bootstrap", func() {
+	const bootstrapID = "d5a5c5f3-6d1f-4a47-8f0c-5e0d1c4b6a3e"
+
+	var info InitInfo
+
+	createPgData := func(files ...string) {
+		for _, file := range files {
+			Expect(fileutils.EnsureParentDirectoryExist(path.Join(info.PgData, file))).To(Succeed())
+			Expect(fileutils.CreateEmptyFile(path.Join(info.PgData, file))).To(Succeed())
+		}
+	}
+
+	// startBootstrap simulates a bootstrap which has been interrupted
+	// after having started writing PGDATA
+	startBootstrap := func(ctx context.Context) {
+		resumed, err := info.ResumeOrCleanPartialBootstrap(ctx, bootstrapID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resumed).To(BeFalse())
+	}
+
+	BeforeEach(func() {
+		tempDir := GinkgoT().TempDir()
+		info = InitInfo{
+			PgData: path.Join(tempDir, "data", "pgdata"),
+			PgWal:  path.Join(tempDir, "wal", "pg_wal"),
+		}
+		Expect(os.MkdirAll(path.Dir(info.PgData), 0o700)).To(Succeed())
+	})
+
+	It("starts a new bootstrap when there's no PGDATA", func(ctx context.Context) {
+		resumed, err := info.ResumeOrCleanPartialBootstrap(ctx, bootstrapID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resumed).To(BeFalse())
+		Expect(info.HasInterruptedBootstrap()).To(BeTrue())
+	})
+
+	It("resumes the bootstrap when the base backup has been completely copied", func(ctx context.Context) {
+		startBootstrap(ctx)
+		createPgData("PG_VERSION", path.Join("global", "pg_control"))
+		Expect(info.MarkBaseBackupCompleted()).To(Succeed())
+
+		resumed, err := info.ResumeOrCleanPartialBootstrap(ctx, bootstrapID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resumed).To(BeTrue())
+		Expect(fileutils.FileExists(path.Join(info.PgData, "PG_VERSION"))).To(BeTrue())
+	})
+
+	It("removes the PGDATA when the copy of the base backup was interrupted", func(ctx context.Context) {
+		startBootstrap(ctx)
+		createPgData("PG_VERSION", path.Join("global", "pg_control"))
+		Expect(os.MkdirAll(info.PgWal, 0o700)).To(Succeed())
+
+		resumed, err := info.ResumeOrCleanPartialBootstrap(ctx, bootstrapID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resumed).To(BeFalse())
+		Expect(fileutils.FileExists(info.PgData)).To(BeFalse())
+		Expect(fileutils.FileExists(info.PgWal)).To(BeFalse())
+		Expect(info.HasInterruptedBootstrap()).To(BeTrue())
+	})
+
+	It("removes the PGDATA when the control file is missing", func(ctx context.Context) {
+		startBootstrap(ctx)
+		createPgData("PG_VERSION")
+		Expect(info.MarkBaseBackupCompleted()).To(Succeed())
+
+		resumable, reason, err := getPartialPgDataState(info.PgData)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resumable).To(BeFalse())
+		Expect(reason).To(ContainSubstring("control file"))
+
+		resumed, err := info.ResumeOrCleanPartialBootstrap(ctx, bootstrapID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resumed).To(BeFalse())
+		Expect(fileutils.FileExists(info.PgData)).To(BeFalse())
+	})
+
+	It("never touches a PGDATA not written by the bootstrap", func(ctx context.Context) {
+		createPgData("PG_VERSION")
+		Expect(os.MkdirAll(info.PgWal, 0o700)).To(Succeed())
+
+		_, err := info.ResumeOrCleanPartialBootstrap(ctx, bootstrapID)
+		Expect(err).To(HaveOccurred())
+		Expect(fileutils.FileExists(path.Join(info.PgData, "PG_VERSION"))).To(BeTrue())
+		Expect(fileutils.FileExists(info.PgWal)).To(BeTrue())
+		Expect(info.HasInterruptedBootstrap()).To(BeFalse())
+	})
+
+	It("never touches a PGDATA written by another bootstrap", func(ctx context.Context) {
+		startBootstrap(ctx)
+		createPgData("PG_VERSION")
+
+		_, err := info.ResumeOrCleanPartialBootstrap(ctx, "another-bootstrap")
+		Expect(err).To(HaveOccurred())
+		Expect(fileutils.FileExists(path.Join(info.PgData, "PG_VERSION"))).To(BeTrue())
+	})
+
+	It("removes the marks once the bootstrap is over", func(ctx context.Context) {
+		startBootstrap(ctx)
+		createPgData("PG_VERSION", path.Join("global", "pg_control"))
+		Expect(info.MarkBaseBackupCompleted()).To(Succeed())
+		Expect(info.RemoveBootstrapMarks()).To(Succeed())
+
+		resumable, _, err := getPartialPgDataState(info.PgData)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resumable).To(BeFalse())
+		Expect(info.HasInterruptedBootstrap()).To(BeFalse())
+	})
+})
@@ -197,27 +197,57 @@ func quoteHbaLiteral(literal string) string {
 }
 
 // UpdateReplicaConfiguration updates the postgresql.auto.conf or recovery.conf file for the proper version
-// of PostgreSQL, using the specified connection string to connect to the primary server
-func UpdateReplicaConfiguration(pgData, primaryConnInfo, slotName string) (changed bool, err error) {
+// of PostgreSQL, using the specified connection string to connect to the primary server and following
+// the specified timeline, or the latest one if empty
+func UpdateReplicaConfiguration(pgData, primaryConnInfo, slotName, targetTimeline string) (changed bool, err error) {
 	major, err := postgresutils.GetMajorVersion(pgData)
 	if err != nil {
 		return false, err
 	}
 
 	if major < 12 {
-		return configureRecoveryConfFile(pgData, primaryConnInfo, slotName)
+		return configureRecoveryConfFile(pgData, primaryConnInfo, slotName, targetTimeline)
 	}
 
 	if err := createStandbySignal(pgData); err != nil {
 		return false, err
 	}
 
-	return configurePostgresAutoConfFile(pgData, primaryConnInfo, slotName)
+	return configurePostgresAutoConfFile(pgData, primaryConnInfo, slotName, targetTimeline)
+}
+
+// pauseReplicaStreaming removes the connection string to the primary server
+// from the postgresql.auto.conf or recovery.conf file, so that the WAL receiver
+// is stopped while the rest of the replication settings are kept
+func pauseReplicaStreaming(pgData, slotName string) (changed bool, err error) {
+	major, err := postgresutils.GetMajorVersion(pgData)
+	if err != nil {
+		return false, err
+	}
+
+	if major < 12 {
+		return configureRecoveryConfFile(pgData, "", slotName, "")
+	}
+
+	targetFile := path.Join(pgData, "postgresql.auto.conf")
+	changed, err = configfile.UpdatePostgresConfigurationFile(
+		targetFile,
+		map[string]string{},
+		"primary_conninfo",
+	)
+	if err != nil {
+		return false, err
+	}
+	if changed {
+		log.Info("Removed primary_conninfo from postgresql.auto.conf file")
+	}
+
+	return changed, nil
 }
 
 // configureRecoveryConfFile configures replication in the recovery.conf file
 // for PostgreSQL 11 and earlier
-func configureRecoveryConfFile(pgData, primaryConnInfo, slotName string) (changed bool, err error) {
+func configureRecoveryConfFile(pgData, primaryConnInfo, slotName, targetTimeline string) (changed bool, err error) {
 	targetFile := path.Join(pgData, "recovery.conf")
 
 	options := map[string]string{
@@ -225,7 +255,7 @@ func configureRecoveryConfFile(pgData, primaryConnInfo, slotName string) (change
 		"restore_command": fmt.Sprintf(
 			"/controller/manager wal-restore --log-destination %s/%s.json %%f %%p",
 			postgres.LogPath, postgres.LogFileName),
-		"recovery_target_timeline": "latest",
+		"recovery_target_timeline": getRecoveryTargetTimeline(targetTimeline),
 	}
 
 	if slotName != "" {
@@ -254,14 +284,14 @@ func configureRecoveryConfFile(pgData, primaryConnInfo, slotName string) (change
 
 // configurePostgresAutoConfFile configures replication in the postgresql.auto.conf file
 // for PostgreSQL 12 and newer
-func configurePostgresAutoConfFile(pgData, primaryConnInfo, slotName string) (changed bool, err error) {
+func configurePostgresAutoConfFile(pgData, primaryConnInfo, slotName, targetTimeline string) (changed bool, err error) {
 	targetFile := path.Join(pgData, "postgresql.auto.conf")
 
 	options := map[string]string{
 		"restore_command": fmt.Sprintf(
 			"/controller/manager wal-restore --log-destination %s/%s.json %%f %%p",
 			postgres.LogPath, postgres.LogFileName),
-		"recovery_target_timeline": "latest",
+		"recovery_target_timeline": getRecoveryTargetTimeline(targetTimeline),
 		"primary_slot_name":        slotName,
 	}
 
@@ -269,6 +299,14 @@ func configurePostgresAutoConfFile(pgData, primaryConnInfo, slotName string) (ch
 		options["primary_conninfo"] = primaryConnInfo
 	}
 
+	// The file may contain settings added by hand, and the replication
+	// ones may have been written differently from how we would write
+	// them: we only rewrite it when the effective values are different
+	applied, err := isConfigurationApplied(targetFile, options)
+	if err != nil || applied {
+		return false, err
+	}
+
 	changed, err = configfile.UpdatePostgresConfigurationFile(targetFile, options)
 	if err != nil {
 		return false, err
@@ -281,6 +319,108 @@ func configurePostgresAutoConfFile(pgData, primaryConnInfo, slotName string) (ch
 	return changed, nil
 }
 
+// isConfigurationApplied checks whether every passed option has already
+// the wanted effective value in the configuration file, regardless of the
+// way it is written
+func isConfigurationApplied(targetFile string, options map[string]string) (bool, error) {
+	content, err := fileutils.ReadFile(targetFile)
+	if err != nil {
+		return false, fmt.Errorf("error while reading content of %v: %w", targetFile, err)
+	}
+
+	currentOptions := configfile.ParseConfigurationContents(string(content))
+	for key, value := range options {
+		if currentValue, ok := currentOptions[key]; !ok || currentValue != value {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// UpdateReplicaRecoveryTarget pins the replay of a designated primary to the
+// passed recovery target, pausing it when the target is reached. A nil target
+// removes the settings, which are only applied when PostgreSQL is restarted
+func UpdateReplicaRecoveryTarget(pgData string, target *apiv1.ReplicaRecoveryTarget) (changed bool, err error) {
+	major, err := postgresutils.GetMajorVersion(pgData)
+	if err != nil {
+		return false, err
+	}
+
+	targetFile := path.Join(pgData, "postgresql.auto.conf")
+	if major < 12 {
+		targetFile = path.Join(pgData, "recovery.conf")
+	}
+
+	options := map[string]string{}
+	if target != nil {
+		options = target.BuildPostgresOptions()
+	}
+
+	changed, err = configfile.UpdatePostgresConfigurationFile(
+		targetFile,
+		options,
+		"recovery_target_time",
+		"recovery_target_lsn",
+		"recovery_target_action",
+	)
+	if err != nil {
+		return false, err
+	}
+
+	if changed {
+		log.Info("Updated the recovery target of the designated primary", "target", target)
+	}
+
+	return changed, nil
+}
+
+// configureTemporaryReplicationSlot makes the WAL receiver stream through
+// a temporary replication slot, which is dropped when the standby
+// disconnects, in place of a permanent one
+func configureTemporaryReplicationSlot(pgData string, enabled bool) (changed bool, err error) {
+	major, err := postgresutils.GetMajorVersion(pgData)
+	if err != nil {
+		return false, err
+	}
+
+	// wal_receiver_create_temp_slot is only available since PostgreSQL 13
+	if major < 13 {
+		return false, nil
+	}
+
+	options := map[string]string{}
+	if enabled {
+		options["wal_receiver_create_temp_slot"] = "on"
+	}
+
+	targetFile := path.Join(pgData, "postgresql.auto.conf")
+	changed, err = configfile.UpdatePostgresConfigurationFile(
+		targetFile,
+		options,
+		"wal_receiver_create_temp_slot",
+	)
+	if err != nil {
+		return false, err
+	}
+
+	if changed {
+		log.Info("Updated temporary replication slot settings in postgresql.auto.conf file",
+			"enabled", enabled)
+	}
+
+	return changed, nil
+}
+
+// getRecoveryTargetTimeline gets the value of recovery_target_timeline
+// for the passed target timeline, following the latest one if empty
+func getRecoveryTargetTimeline(targetTimeline string) string {
+	if targetTimeline == "" {
+		return apiv1.ReplicaTargetTimelineLatest
+	}
+	return targetTimeline
+}
+
 // removeSignalFiles removes the PostgreSQL signal files to get
 // a cleanup up pgdata. This is useful after having restored
 // a PVC snapshot
@@ -312,6 +452,10 @@ func removeArchiveModeFromPostgresAutoConf(pgData string) (changed bool, err err
 		return false, fmt.Errorf("error while reading content of %v: %w", targetFile, err)
 	}
 
+	if _, found := configfile.ParseConfigurationContents(string(currentContent))["archive_mode"]; !found {
+		return false, nil
+	}
+
 	updatedContent := configfile.RemoveOptionFromConfigurationContents(string(currentContent), "archive_mode")
 	return fileutils.WriteStringToFile(targetFile, updatedContent)
 }
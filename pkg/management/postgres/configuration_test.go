@@ -18,10 +18,13 @@ package postgres
 
 import (
 	"fmt"
+	"os"
+	"path"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 
 	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 
@@ -113,3 +116,165 @@ var _ = Describe("testing the building of the ldap config string", func() {
 			ldapSearchFilter, ldapSearchAttribute)))
 	})
 })
+
+var _ = Describe("replica configuration", func() {
+	var pgData string
+
+	writePgVersion := func(version string) {
+		Expect(os.WriteFile(path.Join(pgData, "PG_VERSION"), []byte(version+"\n"), 0o600)).To(Succeed())
+	}
+
+	readFile := func(name string) string {
+		content, err := os.ReadFile(path.Join(pgData, name)) // #nosec
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	BeforeEach(func() {
+		pgData = GinkgoT().TempDir()
+		Expect(os.WriteFile(path.Join(pgData, "postgresql.auto.conf"), nil, 0o600)).To(Succeed())
+		Expect(os.WriteFile(path.Join(pgData, "recovery.conf"), nil, 0o600)).To(Succeed())
+	})
+
+	It("follows the latest timeline by default", func() {
+		writePgVersion("16")
+		changed, err := UpdateReplicaConfiguration(pgData, "host=source", "", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		Expect(readFile("postgresql.auto.conf")).To(ContainSubstring("recovery_target_timeline = 'latest'"))
+		Expect(path.Join(pgData, "standby.signal")).To(BeAnExistingFile())
+	})
+
+	It("pins the replica to a specific timeline", func() {
+		writePgVersion("16")
+		_, err := UpdateReplicaConfiguration(pgData, "host=source", "", "3")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readFile("postgresql.auto.conf")).To(ContainSubstring("recovery_target_timeline = '3'"))
+
+		changed, err := UpdateReplicaConfiguration(pgData, "host=source", "", apiv1.ReplicaTargetTimelineLatest)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		Expect(readFile("postgresql.auto.conf")).To(ContainSubstring("recovery_target_timeline = 'latest'"))
+	})
+
+	It("preserves the settings added by hand and doesn't rewrite equivalent ones", func() {
+		writePgVersion("16")
+		_, err := UpdateReplicaConfiguration(pgData, "host=source", "_cnpg_slot", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		handWritten := "work_mem = '64MB'\n" +
+			strings.Replace(readFile("postgresql.auto.conf"),
+				"primary_conninfo = 'host=source'", "Primary_Conninfo 'host=source' # edited", 1)
+		Expect(os.WriteFile(path.Join(pgData, "postgresql.auto.conf"), []byte(handWritten), 0o600)).To(Succeed())
+
+		changed, err := UpdateReplicaConfiguration(pgData, "host=source", "_cnpg_slot", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+		Expect(readFile("postgresql.auto.conf")).To(Equal(handWritten))
+
+		changed, err = UpdateReplicaConfiguration(pgData, "host=new-source", "_cnpg_slot", "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		autoConf := readFile("postgresql.auto.conf")
+		Expect(autoConf).To(ContainSubstring("work_mem = '64MB'"))
+		Expect(strings.Count(strings.ToLower(autoConf), "primary_conninfo")).To(Equal(1))
+		Expect(autoConf).To(ContainSubstring("primary_conninfo = 'host=new-source'"))
+	})
+
+	It("pins the replica to a specific timeline in recovery.conf", func() {
+		writePgVersion("11")
+		_, err := UpdateReplicaConfiguration(pgData, "host=source", "", "3")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readFile("recovery.conf")).To(ContainSubstring("recovery_target_timeline = '3'"))
+	})
+
+	It("streams through a permanent replication slot by default", func() {
+		writePgVersion("16")
+		instance := &Instance{PgData: pgData, PodName: "cluster-example-2", ClusterName: "cluster-example"}
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				ReplicationSlots: &apiv1.ReplicationSlotsConfiguration{
+					HighAvailability: &apiv1.ReplicationSlotsHAConfiguration{
+						Enabled: ptr.To(true),
+					},
+				},
+			},
+		}
+
+		_, err := instance.writeReplicaConfigurationForReplica(cluster)
+		Expect(err).ToNot(HaveOccurred())
+		autoConf := readFile("postgresql.auto.conf")
+		Expect(autoConf).To(ContainSubstring("primary_slot_name = '_cnpg_cluster_example_2'"))
+		Expect(autoConf).ToNot(ContainSubstring("wal_receiver_create_temp_slot"))
+	})
+
+	It("streams through a temporary replication slot when requested", func() {
+		writePgVersion("16")
+		instance := &Instance{PgData: pgData, PodName: "cluster-example-2", ClusterName: "cluster-example"}
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				ReplicationSlots: &apiv1.ReplicationSlotsConfiguration{
+					HighAvailability: &apiv1.ReplicationSlotsHAConfiguration{
+						Enabled:  ptr.To(true),
+						SlotType: apiv1.ReplicationSlotTypeTemporary,
+					},
+				},
+			},
+		}
+
+		_, err := instance.writeReplicaConfigurationForReplica(cluster)
+		Expect(err).ToNot(HaveOccurred())
+		autoConf := readFile("postgresql.auto.conf")
+		Expect(autoConf).To(ContainSubstring("primary_slot_name = ''"))
+		Expect(autoConf).To(ContainSubstring("wal_receiver_create_temp_slot = 'on'"))
+
+		By("switching back to permanent slots", func() {
+			cluster.Spec.ReplicationSlots.HighAvailability.SlotType = apiv1.ReplicationSlotTypePermanent
+			changed, err := instance.writeReplicaConfigurationForReplica(cluster)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			autoConf := readFile("postgresql.auto.conf")
+			Expect(autoConf).To(ContainSubstring("primary_slot_name = '_cnpg_cluster_example_2'"))
+			Expect(autoConf).ToNot(ContainSubstring("wal_receiver_create_temp_slot"))
+		})
+	})
+
+	It("defaults the target timeline of the replica clusters", func() {
+		var replicaCluster *apiv1.ReplicaClusterConfiguration
+		Expect(replicaCluster.GetTargetTimeline()).To(Equal(apiv1.ReplicaTargetTimelineLatest))
+
+		replicaCluster = &apiv1.ReplicaClusterConfiguration{TargetTimeline: "2"}
+		Expect(replicaCluster.GetTargetTimeline()).To(Equal("2"))
+	})
+
+	It("pins the designated primary to its recovery target until it is reached", func() {
+		writePgVersion("16")
+		_, err := UpdateReplicaConfiguration(pgData, "host=source", "", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		changed, err := UpdateReplicaRecoveryTarget(pgData, &apiv1.ReplicaRecoveryTarget{
+			TargetTime: "2023-10-01T12:00:00Z",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		autoConf := readFile("postgresql.auto.conf")
+		Expect(autoConf).To(ContainSubstring("recovery_target_time = '2023-10-01 12:00:00.000000Z'"))
+		Expect(autoConf).To(ContainSubstring("recovery_target_action = 'pause'"))
+		Expect(autoConf).To(ContainSubstring("primary_conninfo = 'host=source'"))
+
+		changed, err = UpdateReplicaRecoveryTarget(pgData, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		autoConf = readFile("postgresql.auto.conf")
+		Expect(autoConf).ToNot(ContainSubstring("recovery_target_time"))
+		Expect(autoConf).ToNot(ContainSubstring("recovery_target_action"))
+		Expect(autoConf).To(ContainSubstring("primary_conninfo = 'host=source'"))
+	})
+
+	It("pins the designated primary to a recovery LSN in recovery.conf", func() {
+		writePgVersion("11")
+		_, err := UpdateReplicaRecoveryTarget(pgData, &apiv1.ReplicaRecoveryTarget{TargetLSN: "0/6000028"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(readFile("recovery.conf")).To(ContainSubstring("recovery_target_lsn = '0/6000028'"))
+	})
+})
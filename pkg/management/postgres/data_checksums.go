@@ -0,0 +1,148 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// pgControldataChecksumVersionKey is the key of the pg_controldata output
+// containing the version of the data page checksums, zero when disabled
+const pgControldataChecksumVersionKey = "Data page checksum version"
+
+// ErrDataChecksumsMismatch is raised when the data checksums setting of
+// a replica cluster differs from the one of its source
+var ErrDataChecksumsMismatch = errors.New("data checksums setting mismatch between the source and the replica cluster")
+
+// getLocalDataChecksums checks whether the data checksums are enabled in
+// the passed PGDATA, through pg_controldata. It is a variable to allow the
+// unit tests to replace it
+var getLocalDataChecksums = func(pgData string) (bool, error) {
+	var stdoutBuffer bytes.Buffer
+	var stderrBuffer bytes.Buffer
+	pgControlDataCmd := exec.Command(pgControlDataName, "-D", pgData) // #nosec G204
+	pgControlDataCmd.Stdout = &stdoutBuffer
+	pgControlDataCmd.Stderr = &stderrBuffer
+	pgControlDataCmd.Env = append(pgControlDataCmd.Env, "LANG=C", "LC_MESSAGES=C")
+	if err := pgControlDataCmd.Run(); err != nil {
+		return false, fmt.Errorf("while executing pg_controldata: %w (stderr: %s)", err, stderrBuffer.String())
+	}
+
+	return parseDataChecksumsFromControldata(stdoutBuffer.String())
+}
+
+// getSourceDataChecksums checks whether the data checksums are enabled in
+// the source reachable with the passed connection string. It is a variable
+// to allow the unit tests to replace it
+var getSourceDataChecksums = func(ctx context.Context, connectionString string) (bool, error) {
+	db, err := sql.Open("pgx", connectionString+" connect_timeout=5")
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	var dataChecksums string
+	if err := db.QueryRowContext(ctx, "SHOW data_checksums").Scan(&dataChecksums); err != nil {
+		return false, err
+	}
+
+	return dataChecksums == "on", nil
+}
+
+// parseDataChecksumsFromControldata checks whether the data checksums are
+// enabled, given the output of pg_controldata
+func parseDataChecksumsFromControldata(pgControldata string) (bool, error) {
+	for _, line := range strings.Split(pgControldata, "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found || strings.TrimSpace(key) != pgControldataChecksumVersionKey {
+			continue
+		}
+
+		version, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return false, fmt.Errorf("while parsing the data page checksum version: %w", err)
+		}
+		return version > 0, nil
+	}
+
+	return false, fmt.Errorf("missing %q in the pg_controldata output", pgControldataChecksumVersionKey)
+}
+
+// ValidateSourceDataChecksums checks, when requested by the replica cluster
+// configuration, that the data checksums setting of the bootstrapped PGDATA
+// matches the one of the source reachable with the passed connection string
+func (info InitInfo) ValidateSourceDataChecksums(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	connectionString string,
+) error {
+	if !cluster.IsReplica() || !cluster.Spec.ReplicaCluster.ValidateDataChecksums {
+		return nil
+	}
+
+	localDataChecksums, err := getLocalDataChecksums(info.PgData)
+	if err != nil {
+		return fmt.Errorf("while checking the local data checksums setting: %w", err)
+	}
+
+	sourceDataChecksums, err := getSourceDataChecksums(ctx, connectionString)
+	if err != nil {
+		return fmt.Errorf("while checking the data checksums setting of the source %s: %w",
+			cluster.Spec.ReplicaCluster.Source, err)
+	}
+
+	if localDataChecksums != sourceDataChecksums {
+		return fmt.Errorf("%w: data checksums are %s in the source %s, and %s in the replica cluster",
+			ErrDataChecksumsMismatch,
+			formatDataChecksums(sourceDataChecksums),
+			cluster.Spec.ReplicaCluster.Source,
+			formatDataChecksums(localDataChecksums))
+	}
+
+	log.FromContext(ctx).Info("Data checksums setting matches the one of the source",
+		"source", cluster.Spec.ReplicaCluster.Source,
+		"dataChecksums", formatDataChecksums(localDataChecksums))
+	return nil
+}
+
+// withPassfile adds the passed password file, if any, to a connection string
+func withPassfile(connectionString, pgpass string) string {
+	if pgpass == "" {
+		return connectionString
+	}
+	return fmt.Sprintf("%v passfile=%v", connectionString, pgpass)
+}
+
+// formatDataChecksums describes a data checksums setting
+func formatDataChecksums(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
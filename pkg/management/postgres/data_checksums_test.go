@@ -0,0 +1,111 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("data checksums of the replica cluster", func() {
+	var (
+		cluster             *apiv1.Cluster
+		localDataChecksums  bool
+		sourceDataChecksums bool
+		sourceErr           error
+	)
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{
+					Source:                "source",
+					Enabled:               true,
+					ValidateDataChecksums: true,
+				},
+			},
+		}
+		sourceErr = nil
+
+		originalGetLocalDataChecksums := getLocalDataChecksums
+		originalGetSourceDataChecksums := getSourceDataChecksums
+		getLocalDataChecksums = func(string) (bool, error) {
+			return localDataChecksums, nil
+		}
+		getSourceDataChecksums = func(context.Context, string) (bool, error) {
+			return sourceDataChecksums, sourceErr
+		}
+		DeferCleanup(func() {
+			getLocalDataChecksums = originalGetLocalDataChecksums
+			getSourceDataChecksums = originalGetSourceDataChecksums
+		})
+	})
+
+	It("accepts matching settings", func(ctx context.Context) {
+		localDataChecksums, sourceDataChecksums = true, true
+		Expect(InitInfo{}.ValidateSourceDataChecksums(ctx, cluster, "host=source-rw")).To(Succeed())
+
+		localDataChecksums, sourceDataChecksums = false, false
+		Expect(InitInfo{}.ValidateSourceDataChecksums(ctx, cluster, "host=source-rw")).To(Succeed())
+	})
+
+	It("refuses a replica cluster without the data checksums of the source", func(ctx context.Context) {
+		localDataChecksums, sourceDataChecksums = false, true
+		err := InitInfo{}.ValidateSourceDataChecksums(ctx, cluster, "host=source-rw")
+		Expect(err).To(MatchError(ErrDataChecksumsMismatch))
+		Expect(err.Error()).To(ContainSubstring("enabled in the source source, and disabled in the replica cluster"))
+	})
+
+	It("refuses a replica cluster with data checksums the source doesn't have", func(ctx context.Context) {
+		localDataChecksums, sourceDataChecksums = true, false
+		Expect(InitInfo{}.ValidateSourceDataChecksums(ctx, cluster, "host=source-rw")).
+			To(MatchError(ErrDataChecksumsMismatch))
+	})
+
+	It("fails when the source can't be queried", func(ctx context.Context) {
+		sourceErr = errors.New("connection refused")
+		err := InitInfo{}.ValidateSourceDataChecksums(ctx, cluster, "host=source-rw")
+		Expect(err).To(HaveOccurred())
+		Expect(errors.Is(err, ErrDataChecksumsMismatch)).To(BeFalse())
+	})
+
+	It("doesn't validate anything when not requested", func(ctx context.Context) {
+		localDataChecksums, sourceDataChecksums = true, false
+		cluster.Spec.ReplicaCluster.ValidateDataChecksums = false
+		Expect(InitInfo{}.ValidateSourceDataChecksums(ctx, cluster, "host=source-rw")).To(Succeed())
+	})
+
+	It("parses the data page checksum version from pg_controldata", func() {
+		enabled, err := parseDataChecksumsFromControldata(
+			"Database cluster state:               in production\n" +
+				"Data page checksum version:           1\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(enabled).To(BeTrue())
+
+		enabled, err = parseDataChecksumsFromControldata("Data page checksum version:           0\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(enabled).To(BeFalse())
+
+		_, err = parseDataChecksumsFromControldata("Database cluster state:               in production\n")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,130 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/configfile"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/external"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	postgresutils "github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/utils"
+)
+
+// removeDetachedSourceConfiguration removes from a primary detached from
+// the source of its former replica cluster the connection to that source,
+// that is the stale primary_conninfo and the pgpass file it referenced
+func removeDetachedSourceConfiguration(ctx context.Context, pgData, source string) (changed bool, err error) {
+	major, err := postgresutils.GetMajorVersion(pgData)
+	if err != nil {
+		return false, err
+	}
+
+	// Before PostgreSQL 12 the replication configuration lives in the
+	// recovery.conf file, which is renamed by the promotion
+	if major >= 12 {
+		changed, err = configfile.UpdatePostgresConfigurationFile(
+			path.Join(pgData, "postgresql.auto.conf"),
+			map[string]string{},
+			"primary_conninfo",
+			"primary_slot_name",
+		)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if err := external.RemovePgPassFile(source); err != nil {
+		return changed, fmt.Errorf("while removing the pgpass file of the source %s: %w", source, err)
+	}
+
+	if changed {
+		log.FromContext(ctx).Info("Removed the connection to the source of the detached replica cluster",
+			"source", source)
+	}
+
+	return changed, nil
+}
+
+// DropDetachedReplicationSlots drops, when requested by the replica cluster
+// configuration, the inactive physical replication slots inherited from the
+// source by a primary which has just been detached from it. The HA
+// replication slots of the cluster are managed elsewhere, and never dropped
+// here
+func (instance *Instance) DropDetachedReplicationSlots(ctx context.Context, cluster *apiv1.Cluster) error {
+	if cluster.Spec.ReplicaCluster.GetDetachedSlots() != apiv1.DetachedSlotsDrop {
+		return nil
+	}
+
+	db, err := instance.GetSuperUserDB()
+	if err != nil {
+		return err
+	}
+
+	var haSlotPrefix string
+	if cluster.Spec.ReplicationSlots != nil {
+		haSlotPrefix = cluster.Spec.ReplicationSlots.HighAvailability.GetSlotPrefix()
+	} else {
+		haSlotPrefix = apiv1.DefaultReplicationSlotsHASlotPrefix
+	}
+
+	return dropDetachedReplicationSlots(ctx, db, haSlotPrefix)
+}
+
+// dropDetachedReplicationSlots drops the inactive physical replication
+// slots whose name doesn't begin with the passed HA prefix
+func dropDetachedReplicationSlots(ctx context.Context, db *sql.DB, haSlotPrefix string) error {
+	contextLogger := log.FromContext(ctx)
+
+	rows, err := db.QueryContext(
+		ctx,
+		`SELECT slot_name FROM pg_catalog.pg_replication_slots
+			WHERE NOT temporary AND NOT active AND slot_type = 'physical' AND NOT slot_name ^@ $1`,
+		haSlotPrefix,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var slotNames []string
+	for rows.Next() {
+		var slotName string
+		if err := rows.Scan(&slotName); err != nil {
+			return err
+		}
+		slotNames = append(slotNames, slotName)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, slotName := range slotNames {
+		contextLogger.Info("Dropping the replication slot inherited from the source", "slot", slotName)
+		if _, err := db.ExecContext(ctx, "SELECT pg_catalog.pg_drop_replication_slot($1)", slotName); err != nil {
+			return fmt.Errorf("while dropping the replication slot %s: %w", slotName, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/external"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("detaching a replica cluster from its source", func() {
+	var (
+		instance         *Instance
+		cluster          *apiv1.Cluster
+		postgresAutoConf string
+		pgpassFile       string
+	)
+
+	BeforeEach(func() {
+		tempDir, err := os.MkdirTemp("", "detach")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = os.RemoveAll(tempDir)
+		})
+
+		pgData := filepath.Join(tempDir, "pgdata")
+		Expect(os.MkdirAll(pgData, 0o700)).To(Succeed())
+		instance = &Instance{
+			PgData:  pgData,
+			PodName: "cluster-example-1",
+		}
+		postgresAutoConf = filepath.Join(pgData, "postgresql.auto.conf")
+
+		_, err = fileutils.WriteStringToFile(filepath.Join(pgData, "PG_VERSION"), "14")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(postgresAutoConf,
+			"primary_conninfo = 'host=source-rw passfile=/controller/external/source/pgpass'\n"+
+				"primary_slot_name = '_cnpg_cluster_example_1'\n"+
+				"work_mem = '8MB'\n")
+		Expect(err).ToNot(HaveOccurred())
+
+		originalExternalSecretsPath := external.CustomExternalSecretsPath
+		external.CustomExternalSecretsPath = filepath.Join(tempDir, "external")
+		DeferCleanup(func() {
+			external.CustomExternalSecretsPath = originalExternalSecretsPath
+		})
+		pgpassFile, err = external.CreatePgPassFile("source", "secret")
+		Expect(err).ToNot(HaveOccurred())
+
+		cluster = &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{
+					Source:  "source",
+					Enabled: false,
+				},
+			},
+			Status: apiv1.ClusterStatus{
+				CurrentPrimary: "cluster-example-1",
+				TargetPrimary:  "cluster-example-1",
+			},
+		}
+	})
+
+	It("clears the connection to the source once promoted", func(ctx context.Context) {
+		changed, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+
+		content, err := fileutils.ReadFile(postgresAutoConf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).ToNot(ContainSubstring("primary_conninfo"))
+		Expect(string(content)).ToNot(ContainSubstring("primary_slot_name"))
+		Expect(string(content)).To(ContainSubstring("work_mem"))
+		Expect(fileutils.FileExists(pgpassFile)).To(BeFalse())
+
+		changed, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+	})
+
+	It("keeps the connection to the source while in replica mode", func(ctx context.Context) {
+		cluster.Spec.ReplicaCluster.Enabled = true
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fileutils.FileExists(pgpassFile)).To(BeTrue())
+	})
+
+	It("drops the inactive slots not managed for high availability", func(ctx context.Context) {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+
+		mock.ExpectQuery("SELECT slot_name FROM pg_catalog.pg_replication_slots").
+			WithArgs(apiv1.DefaultReplicationSlotsHASlotPrefix).
+			WillReturnRows(sqlmock.NewRows([]string{"slot_name"}).
+				AddRow("source_standby").
+				AddRow("archiver"))
+		mock.ExpectExec("SELECT pg_catalog.pg_drop_replication_slot").
+			WithArgs("source_standby").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("SELECT pg_catalog.pg_drop_replication_slot").
+			WithArgs("archiver").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		Expect(dropDetachedReplicationSlots(ctx, db, apiv1.DefaultReplicationSlotsHASlotPrefix)).To(Succeed())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("keeps the slots by default", func(ctx context.Context) {
+		Expect(cluster.Spec.ReplicaCluster.GetDetachedSlots()).To(Equal(apiv1.DetachedSlotsKeep))
+		Expect(instance.DropDetachedReplicationSlots(ctx, cluster)).To(Succeed())
+	})
+})
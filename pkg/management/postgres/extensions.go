@@ -0,0 +1,193 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// GetInstalledExtensions returns the extensions installed in the
+// databases accepting connections, sorted by name and version
+func (instance *Instance) GetInstalledExtensions() ([]apiv1.InstalledExtension, error) {
+	superUserDB, err := instance.GetSuperUserDB()
+	if err != nil {
+		return nil, err
+	}
+
+	databases, err := getConnectableDatabases(superUserDB)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []apiv1.InstalledExtension
+	for _, dbname := range databases {
+		db, err := instance.ConnectionPool().Connection(dbname)
+		if err != nil {
+			return nil, fmt.Errorf("while connecting to database %q: %w", dbname, err)
+		}
+
+		extensions, err := getDatabaseExtensions(db)
+		if err != nil {
+			return nil, fmt.Errorf("while listing the extensions of database %q: %w", dbname, err)
+		}
+
+		result = mergeInstalledExtensions(result, extensions)
+	}
+
+	return result, nil
+}
+
+// getConnectableDatabases returns the names of the non-template
+// databases accepting connections
+func getConnectableDatabases(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(
+		"SELECT datname FROM pg_catalog.pg_database WHERE datallowconn AND NOT datistemplate")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var databases []string
+	for rows.Next() {
+		var dbname string
+		if err := rows.Scan(&dbname); err != nil {
+			return nil, err
+		}
+		databases = append(databases, dbname)
+	}
+
+	return databases, rows.Err()
+}
+
+// getDatabaseExtensions returns the extensions installed in the
+// database the passed connection points to
+func getDatabaseExtensions(db *sql.DB) ([]apiv1.InstalledExtension, error) {
+	rows, err := db.Query("SELECT extname, extversion FROM pg_catalog.pg_extension")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var extensions []apiv1.InstalledExtension
+	for rows.Next() {
+		var extension apiv1.InstalledExtension
+		if err := rows.Scan(&extension.Name, &extension.Version); err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, extension)
+	}
+
+	return extensions, rows.Err()
+}
+
+// mergeInstalledExtensions adds the passed extensions to the list, skipping
+// the ones already there with the same version, and keeps it sorted
+func mergeInstalledExtensions(
+	list []apiv1.InstalledExtension,
+	extensions []apiv1.InstalledExtension,
+) []apiv1.InstalledExtension {
+	for _, extension := range extensions {
+		found := false
+		for _, item := range list {
+			if item == extension {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, extension)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		return list[i].Version < list[j].Version
+	})
+
+	return list
+}
+
+// CheckRequiredExtensions warns about the extensions installed in the
+// restored data whose control file is not shipped with the PostgreSQL
+// binaries of this image. The check is best-effort and never fails
+func CheckRequiredExtensions(extensions []string) {
+	if len(extensions) == 0 {
+		return
+	}
+
+	shareDir, err := getPgShareDir()
+	if err != nil {
+		log.Warning("Cannot detect the extensions available in the image, skipping the check",
+			"err", err.Error())
+		return
+	}
+
+	missingExtensions, err := findMissingExtensions(shareDir, extensions)
+	if err != nil {
+		log.Warning("Cannot detect the extensions available in the image, skipping the check",
+			"err", err.Error())
+		return
+	}
+
+	if len(missingExtensions) > 0 {
+		log.Warning("The image is missing some extensions installed in the restored cluster",
+			"missingExtensions", missingExtensions)
+	}
+}
+
+// getPgShareDir returns the share directory of the PostgreSQL installation
+func getPgShareDir() (string, error) {
+	out, err := exec.Command(pgConfigName, "--sharedir").Output() // #nosec
+	if err != nil {
+		return "", fmt.Errorf("while executing %s: %w", pgConfigName, err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// findMissingExtensions returns the names of the passed extensions whose
+// control file cannot be found in the passed share directory
+func findMissingExtensions(shareDir string, extensions []string) ([]string, error) {
+	var missingExtensions []string
+	for _, extension := range extensions {
+		controlFile := path.Join(shareDir, "extension", extension+".control")
+		exists, err := fileutils.FileExists(controlFile)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			missingExtensions = append(missingExtensions, extension)
+		}
+	}
+
+	return missingExtensions, nil
+}
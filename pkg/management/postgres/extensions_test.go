@@ -0,0 +1,112 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"os"
+	"path"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("installed extensions", func() {
+	extensionColumns := []string{"extname", "extversion"}
+
+	It("lists the databases accepting connections", func() {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+
+		mock.ExpectQuery("SELECT datname FROM pg_catalog.pg_database").
+			WillReturnRows(sqlmock.NewRows([]string{"datname"}).
+				AddRow("postgres").
+				AddRow("app"))
+
+		databases, err := getConnectableDatabases(db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(databases).To(Equal([]string{"postgres", "app"}))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("lists the extensions installed in a database", func() {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+
+		mock.ExpectQuery("SELECT extname, extversion FROM pg_catalog.pg_extension").
+			WillReturnRows(sqlmock.NewRows(extensionColumns).
+				AddRow("plpgsql", "1.0").
+				AddRow("postgis", "3.4.0").
+				AddRow("pg_stat_statements", "1.10"))
+
+		extensions, err := getDatabaseExtensions(db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extensions).To(Equal([]apiv1.InstalledExtension{
+			{Name: "plpgsql", Version: "1.0"},
+			{Name: "postgis", Version: "3.4.0"},
+			{Name: "pg_stat_statements", Version: "1.10"},
+		}))
+	})
+
+	It("merges the extensions of several databases", func() {
+		postgresExtensions := []apiv1.InstalledExtension{
+			{Name: "plpgsql", Version: "1.0"},
+			{Name: "pg_stat_statements", Version: "1.10"},
+		}
+		appExtensions := []apiv1.InstalledExtension{
+			{Name: "postgis", Version: "3.4.0"},
+			{Name: "plpgsql", Version: "1.0"},
+			{Name: "pgcrypto", Version: "1.3"},
+		}
+		legacyExtensions := []apiv1.InstalledExtension{
+			{Name: "plpgsql", Version: "1.0"},
+			{Name: "postgis", Version: "3.3.2"},
+		}
+
+		var extensions []apiv1.InstalledExtension
+		extensions = mergeInstalledExtensions(extensions, postgresExtensions)
+		extensions = mergeInstalledExtensions(extensions, appExtensions)
+		extensions = mergeInstalledExtensions(extensions, legacyExtensions)
+
+		Expect(extensions).To(Equal([]apiv1.InstalledExtension{
+			{Name: "pg_stat_statements", Version: "1.10"},
+			{Name: "pgcrypto", Version: "1.3"},
+			{Name: "plpgsql", Version: "1.0"},
+			{Name: "postgis", Version: "3.3.2"},
+			{Name: "postgis", Version: "3.4.0"},
+		}))
+	})
+
+	It("detects the extensions missing from the share directory", func() {
+		shareDir := GinkgoT().TempDir()
+		Expect(os.MkdirAll(path.Join(shareDir, "extension"), 0o700)).To(Succeed())
+		for _, name := range []string{"plpgsql", "pg_stat_statements", "pgcrypto"} {
+			controlFile := path.Join(shareDir, "extension", name+".control")
+			Expect(os.WriteFile(controlFile, []byte("# "+name), 0o600)).To(Succeed())
+		}
+
+		missingExtensions, err := findMissingExtensions(
+			shareDir,
+			[]string{"pg_stat_statements", "pgcrypto", "plpgsql", "postgis", "timescaledb"},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(missingExtensions).To(Equal([]string{"postgis", "timescaledb"}))
+	})
+})
@@ -94,6 +94,11 @@ type InitInfo struct {
 	// PostInitApplicationSQLRefsFolder is the folder which contains a bunch
 	// of SQL files to be executed just after having configured a new instance
 	PostInitApplicationSQLRefsFolder string
+
+	// The backup label and the tablespace map of the volume snapshot
+	// being restored, when it has been taken online
+	BackupLabelFile   []byte
+	TablespaceMapFile []byte
 }
 
 // VerifyPGData verifies if the passed configuration is OK, otherwise it returns an error
@@ -354,7 +359,7 @@ func (info InitInfo) Bootstrap(ctx context.Context) error {
 	if postgresVersion >= 120000 {
 		primaryConnInfo := info.GetPrimaryConnInfo()
 		slotName := cluster.GetSlotNameFromInstanceName(info.PodName)
-		_, err = configurePostgresAutoConfFile(info.PgData, primaryConnInfo, slotName)
+		_, err = configurePostgresAutoConfFile(info.PgData, primaryConnInfo, slotName, "")
 		if err != nil {
 			return fmt.Errorf("while configuring replica: %w", err)
 		}
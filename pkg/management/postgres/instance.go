@@ -26,6 +26,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/blang/semver"
@@ -56,6 +57,7 @@ const (
 	pgIsReady         = "pg_isready"
 	pgCtlTimeout      = "40000000" // greater than one year in seconds, big enough to simulate an infinite timeout
 	pgControlDataName = "pg_controldata"
+	pgConfigName      = "pg_config"
 
 	pqPingOk         = 0 // server is accepting connections
 	pqPingReject     = 1 // server is alive but rejecting connections
@@ -179,6 +181,58 @@ type Instance struct {
 	// fenced entails mightBeUnavailable ( entails as in logical consequence)
 	fenced atomic.Bool
 
+	// replicaStreamingPaused specifies whether the designated primary of a
+	// replica cluster has paused streaming from an unreachable source
+	replicaStreamingPaused atomic.Bool
+
+	// readinessRequiresStreaming specifies whether the designated primary
+	// of a replica cluster is ready only while streaming from the source
+	readinessRequiresStreaming atomic.Bool
+
+	// readinessMaxLagBytes is the maximum lag of the designated primary of
+	// a replica cluster behind its source for it to be ready, zero when the
+	// readiness doesn't depend on the lag
+	readinessMaxLagBytes atomic.Int64
+
+	// replicaClusterCaughtUp specifies whether the last measured lag of the
+	// designated primary of a replica cluster is within readinessMaxLagBytes
+	replicaClusterCaughtUp atomic.Bool
+
+	// replicaSourceConnection is the connection the designated primary of
+	// a replica cluster uses to stream from its source, nil while not streaming
+	replicaSourceConnection atomic.Pointer[replicaSourceConnection]
+
+	// activeReplicaSource is the name of the source the designated primary
+	// of a replica cluster streams from, when fallback sources are defined
+	activeReplicaSource atomic.Pointer[string]
+
+	// sourceStatus is the last status of the source of a replica cluster,
+	// as probed by the designated primary
+	sourceStatus atomic.Pointer[postgres.SourceStatus]
+
+	// sourceProbeConfiguration tells how the source of a replica cluster
+	// needs to be probed in the background, nil if it doesn't need to
+	sourceProbeConfiguration atomic.Pointer[sourceProbeConfiguration]
+
+	// sourceProbeResult is the outcome of the last background probe of the
+	// source of a replica cluster
+	sourceProbeResult atomic.Pointer[sourceProbeResult]
+
+	// replicaClusterLag is how far the designated primary of a replica
+	// cluster is behind its source, as measured by the last probe
+	replicaClusterLag atomic.Pointer[ReplicaClusterLag]
+
+	// prewarmStarted specifies whether the configured relations have been
+	// loaded in the shared buffers by the designated primary
+	prewarmStarted atomic.Bool
+
+	// onlineBackup is the online backup executed by the instance through
+	// the PostgreSQL low level API, nil if there is none
+	onlineBackup *onlineBackupSession
+
+	// onlineBackupMutex guards onlineBackup
+	onlineBackupMutex sync.Mutex
+
 	// slotsReplicatorChan is used to send replication slot configuration to the slot replicator
 	slotsReplicatorChan chan *apiv1.ReplicationSlotsConfiguration
 
@@ -191,6 +245,27 @@ func (instance *Instance) IsFenced() bool {
 	return instance.fenced.Load()
 }
 
+// IsReplicaStreamingPaused checks whether the designated primary has
+// paused streaming from the source of the replica cluster
+func (instance *Instance) IsReplicaStreamingPaused() bool {
+	return instance.replicaStreamingPaused.Load()
+}
+
+// GetActiveReplicaSource returns the name of the source the designated
+// primary streams from, empty if the replica cluster has no fallback sources
+func (instance *Instance) GetActiveReplicaSource() string {
+	if source := instance.activeReplicaSource.Load(); source != nil {
+		return *source
+	}
+	return ""
+}
+
+// GetSourceStatus returns the last status of the source of the replica
+// cluster probed by the designated primary, nil if not probed
+func (instance *Instance) GetSourceStatus() *postgres.SourceStatus {
+	return instance.sourceStatus.Load()
+}
+
 // CanCheckReadiness checks whether the instance should be checked for readiness
 func (instance *Instance) CanCheckReadiness() bool {
 	return instance.canCheckReadiness.Load()
@@ -620,7 +695,7 @@ func (instance *Instance) IsPrimary() (bool, error) {
 func (instance *Instance) Demote(cluster *apiv1.Cluster) error {
 	log.Info("Demoting instance", "pgpdata", instance.PgData)
 	slotName := cluster.GetSlotNameFromInstanceName(instance.PodName)
-	_, err := UpdateReplicaConfiguration(instance.PgData, instance.GetPrimaryConnInfo(), slotName)
+	_, err := UpdateReplicaConfiguration(instance.PgData, instance.GetPrimaryConnInfo(), slotName, "")
 	return err
 }
 
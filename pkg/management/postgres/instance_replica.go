@@ -18,14 +18,72 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"strings"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/configfile"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/external"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	postgresutils "github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/utils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/stringset"
 )
 
+// isSourceReachable checks whether we can connect to the source of a replica
+// cluster using the passed connection string. It is a variable to allow
+// the unit tests to replace it
+var isSourceReachable = func(ctx context.Context, connectionString string) bool {
+	db, err := sql.Open("pgx", connectionString+" connect_timeout=5")
+	if err != nil {
+		return false
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	return db.PingContext(ctx) == nil
+}
+
+// probeSource queries the status of the source of a replica cluster using
+// the passed connection string. It is a variable to allow the unit tests
+// to replace it
+var probeSource = func(ctx context.Context, connectionString string) *postgres.SourceStatus {
+	db, err := sql.Open("pgx", connectionString+" connect_timeout=5")
+	if err != nil {
+		return &postgres.SourceStatus{Error: err.Error()}
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	// The timeline of a primary is the one of the WAL file being written,
+	// while the one of a standby is the timeline of the WAL it receives,
+	// which is unknown when it is not streaming
+	status := &postgres.SourceStatus{}
+	row := db.QueryRowContext(ctx,
+		`SELECT
+			NOT pg_is_in_recovery(),
+			(CASE WHEN pg_is_in_recovery()
+				THEN COALESCE(pg_last_wal_replay_lsn(), '0/0')
+				ELSE pg_current_wal_lsn()
+			END)::text,
+			current_setting('wal_level'),
+			(CASE WHEN pg_is_in_recovery()
+				THEN COALESCE((SELECT received_tli FROM pg_catalog.pg_stat_wal_receiver), 0)
+				ELSE ('x' || substr(pg_walfile_name(pg_current_wal_lsn()), 1, 8))::bit(32)::int
+			END)`)
+	if err := row.Scan(&status.IsPrimary, &status.CurrentLsn, &status.WalLevel, &status.TimelineID); err != nil {
+		return &postgres.SourceStatus{Error: err.Error()}
+	}
+
+	status.Reachable = true
+	return status
+}
+
 // RefreshReplicaConfiguration writes the PostgreSQL correct
 // replication configuration for connecting to the right primary server,
 // depending on the cluster replica mode
@@ -39,9 +97,14 @@ func (instance *Instance) RefreshReplicaConfiguration(
 	// and fall back on the value defined in "custom.conf".
 	// TODO: Removed this code together the RemoveArchiveModeFromPostgresAutoConf function
 	// TODO: when enough time passed since 1.12 release
-	changed, err = removeArchiveModeFromPostgresAutoConf(instance.PgData)
+	archiveModeRemoved, err := removeArchiveModeFromPostgresAutoConf(instance.PgData)
 	if err != nil {
-		return changed, err
+		return archiveModeRemoved, err
+	}
+
+	standbySignalRecreated, err := instance.ensureStandbySignal(ctx, cluster)
+	if err != nil {
+		return false, err
 	}
 
 	primary, err := instance.IsPrimary()
@@ -49,20 +112,94 @@ func (instance *Instance) RefreshReplicaConfiguration(
 		return false, err
 	}
 
+	isDesignatedPrimary := cluster.IsReplica() && cluster.Status.TargetPrimary == instance.PodName
+	if primary || !isDesignatedPrimary {
+		// Only a designated primary can pause streaming from the source
+		instance.replicaStreamingPaused.Store(false)
+		instance.readinessRequiresStreaming.Store(false)
+		instance.readinessMaxLagBytes.Store(0)
+		instance.replicaClusterCaughtUp.Store(false)
+		instance.activeReplicaSource.Store(nil)
+		instance.replicaSourceConnection.Store(nil)
+		instance.sourceStatus.Store(nil)
+		instance.configureSourceProbe(nil)
+	}
+
 	if primary {
+		if cluster.Spec.ReplicaCluster != nil && !cluster.IsReplica() {
+			changed, err = removeDetachedSourceConfiguration(ctx, instance.PgData, cluster.Spec.ReplicaCluster.Source)
+			return changed || archiveModeRemoved, err
+		}
+		return archiveModeRemoved, nil
+	}
+
+	if isDesignatedPrimary {
+		instance.readinessRequiresStreaming.Store(cluster.Spec.ReplicaCluster.ReadinessRequiresStreaming)
+		instance.readinessMaxLagBytes.Store(cluster.Spec.ReplicaCluster.ReadinessMaxLagBytes)
+		changed, err = instance.writeReplicaConfigurationForDesignatedPrimary(ctx, cli, cluster)
+	} else {
+		changed, err = instance.writeReplicaConfigurationForReplica(cluster)
+	}
+
+	return changed || archiveModeRemoved || standbySignalRecreated, err
+}
+
+// isStandbyExpected checks whether the instance running in the passed pod
+// is expected to be a standby, according to the status of the cluster
+func isStandbyExpected(cluster *apiv1.Cluster, podName string) bool {
+	if cluster.IsReplica() {
+		// Even the designated primary is a standby of the source cluster
+		return true
+	}
+
+	return podName != cluster.Status.CurrentPrimary && podName != cluster.Status.TargetPrimary
+}
+
+// ensureStandbySignal re-creates the standby.signal file when it is missing
+// from the PGDATA of an instance which is expected to be a standby, as
+// PostgreSQL would be promoted on the next restart otherwise. The check is
+// only done when enabled in the cluster. It returns whether the file has been
+// re-created, in which case the replica configuration needs to be refreshed
+func (instance *Instance) ensureStandbySignal(ctx context.Context, cluster *apiv1.Cluster) (bool, error) {
+	if cluster.GetStandbySignalCheckInterval() == 0 || !isStandbyExpected(cluster, instance.PodName) {
 		return false, nil
 	}
 
-	if cluster.IsReplica() && cluster.Status.TargetPrimary == instance.PodName {
-		return instance.writeReplicaConfigurationForDesignatedPrimary(ctx, cli, cluster)
+	major, err := postgresutils.GetMajorVersion(instance.PgData)
+	if err != nil {
+		return false, err
+	}
+	if major < 12 {
+		// Before PostgreSQL 12 there's no standby.signal file
+		return false, nil
+	}
+
+	primary, err := instance.IsPrimary()
+	if err != nil || !primary {
+		return false, err
+	}
+
+	log.FromContext(ctx).Warning(
+		"The standby.signal file is missing from the PGDATA of a standby, re-creating it "+
+			"to prevent an accidental promotion",
+		"pgdata", instance.PgData)
+	if err := createStandbySignal(instance.PgData); err != nil {
+		return false, err
 	}
 
-	return instance.writeReplicaConfigurationForReplica(cluster)
+	return true, nil
 }
 
 func (instance *Instance) writeReplicaConfigurationForReplica(cluster *apiv1.Cluster) (changed bool, err error) {
 	slotName := cluster.GetSlotNameFromInstanceName(instance.PodName)
-	return UpdateReplicaConfiguration(instance.PgData, instance.GetPrimaryConnInfo(), slotName)
+	changed, err = UpdateReplicaConfiguration(instance.PgData, instance.GetPrimaryConnInfo(), slotName, "")
+	if err != nil {
+		return changed, err
+	}
+
+	tempSlotChanged, err := configureTemporaryReplicationSlot(
+		instance.PgData, cluster.Spec.ReplicationSlots.UsesTemporarySlots())
+	return changed || tempSlotChanged, err
 }
 
 func (instance *Instance) writeReplicaConfigurationForDesignatedPrimary(
@@ -75,18 +212,325 @@ func (instance *Instance) writeReplicaConfigurationForDesignatedPrimary(
 		return false, fmt.Errorf("missing external cluster")
 	}
 
-	connectionString, pgpassfile, err := external.ConfigureConnectionToServer(
-		ctx, cli, instance.Namespace, &server)
+	connectionString, err := instance.getSourceConnectionString(ctx, cli, &server, cluster.Spec.ReplicaCluster)
 	if err != nil {
 		return false, err
 	}
 
+	fallbacks, err := instance.getFallbackSources(ctx, cli, cluster)
+	if err != nil {
+		return false, err
+	}
+
+	// The source is probed in the background, as the probes would block the
+	// reconciliation loop, and the last outcome is applied here
+	target := sourceProbeTarget{
+		connectionString:  connectionString,
+		orderHosts:        cluster.Spec.ReplicaCluster.OrderSourceHostsByHealth,
+		checkReachability: cluster.IsReplicaStreamingPausable(),
+		reportStatus:      isSourceStatusRequired(cluster.Spec.ReplicaCluster),
+		fallbackSources:   strings.Join(cluster.Spec.ReplicaCluster.FallbackSources, ","),
+	}
+	if target.orderHosts || target.checkReachability || target.reportStatus || len(fallbacks) > 0 {
+		instance.configureSourceProbe(&sourceProbeConfiguration{target: target, server: server, fallbacks: fallbacks})
+	} else {
+		instance.configureSourceProbe(nil)
+	}
+	result := instance.getSourceProbeResult(target)
+
+	if result != nil {
+		instance.sourceStatus.Store(result.status)
+	} else {
+		instance.sourceStatus.Store(nil)
+	}
+
+	activeSource := server.Name
+	if len(fallbacks) > 0 {
+		activeSource = instance.selectActiveReplicaSource(ctx, cluster.Spec.ReplicaCluster.GetSources(), result)
+	} else {
+		instance.activeReplicaSource.Store(nil)
+	}
+
+	for _, fallback := range fallbacks {
+		if fallback.server.Name == activeSource {
+			connectionString = fallback.connectionString
+		}
+	}
+
+	if activeSource == server.Name && result != nil && result.orderedServer != nil {
+		log.FromContext(ctx).Debug("Moving the reachable hosts of the source first",
+			"source", server.Name,
+			"host", result.orderedServer.ConnectionParameters["host"])
+		connectionString, err = instance.getSourceConnectionString(
+			ctx, cli, result.orderedServer, cluster.Spec.ReplicaCluster)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	slotName := cluster.GetReplicaSourceSlotName(instance.PodName)
+
+	// With fallback sources, the streaming is paused only when none of
+	// them is reachable
+	if result != nil && target.checkReachability && !result.reachable && result.reachableSource == "" {
+		instance.replicaSourceConnection.Store(nil)
+		return instance.pauseReplicaStreaming(ctx, slotName)
+	}
+
+	if instance.replicaStreamingPaused.Load() {
+		log.FromContext(ctx).Info("Resuming streaming from the source of the replica cluster",
+			"source", activeSource)
+		instance.replicaStreamingPaused.Store(false)
+	}
+
+	instance.replicaSourceConnection.Store(&replicaSourceConnection{
+		connectionString: connectionString,
+		slotName:         slotName,
+		applicationName:  cluster.Name,
+	})
+
+	changed, err = UpdateReplicaConfiguration(instance.PgData, connectionString, slotName,
+		cluster.Spec.ReplicaCluster.GetTargetTimeline())
+	if err != nil {
+		return changed, err
+	}
+
+	targetChanged, err := UpdateReplicaRecoveryTarget(instance.PgData, cluster.GetPendingReplicaRecoveryTarget())
+	return changed || targetChanged, err
+}
+
+// isSourceStatusRequired checks whether the status of the source needs to
+// be probed, to be reported or to make decisions based on it
+func isSourceStatusRequired(replicaCluster *apiv1.ReplicaClusterConfiguration) bool {
+	return replicaCluster.ReportSourceStatus || replicaCluster.LogicalDecoding ||
+		replicaCluster.ReadinessMaxLagBytes > 0
+}
+
+// getFallbackSources builds the connections to the fallback sources of
+// the replica cluster, in order
+func (instance *Instance) getFallbackSources(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+) ([]sourceProbeFallback, error) {
+	fallbacks := make([]sourceProbeFallback, 0, len(cluster.Spec.ReplicaCluster.FallbackSources))
+	for _, name := range cluster.Spec.ReplicaCluster.FallbackSources {
+		server, ok := cluster.ExternalCluster(name)
+		if !ok {
+			return nil, fmt.Errorf("missing external cluster %s", name)
+		}
+
+		connectionString, err := instance.getSourceConnectionString(ctx, cli, &server, cluster.Spec.ReplicaCluster)
+		if err != nil {
+			return nil, fmt.Errorf("while connecting to the fallback source %s: %w", name, err)
+		}
+
+		fallbacks = append(fallbacks, sourceProbeFallback{server: server, connectionString: connectionString})
+	}
+
+	return fallbacks, nil
+}
+
+// selectActiveReplicaSource chooses, among the passed sources, the one the
+// designated primary streams from, that is the first reachable one according
+// to the last probe. When none is reachable, or the sources have not been
+// probed yet, the designated primary keeps streaming from the current one
+func (instance *Instance) selectActiveReplicaSource(
+	ctx context.Context,
+	sources []string,
+	result *sourceProbeResult,
+) string {
+	current := instance.GetActiveReplicaSource()
+	if !stringset.From(sources).Has(current) {
+		current = sources[0]
+	}
+
+	selected := current
+	if result != nil && result.reachableSource != "" {
+		selected = result.reachableSource
+	}
+
+	if selected != current {
+		log.FromContext(ctx).Info("Switching the source of the replica cluster",
+			"from", current,
+			"to", selected)
+	}
+
+	instance.activeReplicaSource.Store(&selected)
+	return selected
+}
+
+// pauseReplicaStreaming stops the designated primary from streaming from
+// an unreachable source, letting it serve read-only queries at the last
+// replayed LSN. While the streaming is paused, the replication configuration
+// is not refreshed
+func (instance *Instance) pauseReplicaStreaming(ctx context.Context, slotName string) (changed bool, err error) {
+	if instance.replicaStreamingPaused.Load() {
+		return false, nil
+	}
+
+	log.FromContext(ctx).Info("The source of the replica cluster is not reachable, pausing streaming")
+	changed, err = pauseReplicaStreaming(instance.PgData, slotName)
+	if err != nil {
+		return changed, err
+	}
+
+	instance.replicaStreamingPaused.Store(true)
+	return changed, nil
+}
+
+// getSourceConnectionString builds the connection string to be used to
+// stream from the passed external cluster, using the channel binding and
+// the source standby preference of the replica cluster configuration
+func (instance *Instance) getSourceConnectionString(
+	ctx context.Context,
+	cli client.Client,
+	server *apiv1.ExternalCluster,
+	replicaCluster *apiv1.ReplicaClusterConfiguration,
+) (string, error) {
+	connectionString, pgpassfile, err := external.ConfigureConnectionToServer(
+		ctx, cli, instance.Namespace, server)
+	if err != nil {
+		return "", err
+	}
+
 	if pgpassfile != "" {
 		connectionString = fmt.Sprintf("%v passfile=%v",
 			connectionString,
 			pgpassfile)
 	}
 
-	slotName := cluster.GetSlotNameFromInstanceName(instance.PodName)
-	return UpdateReplicaConfiguration(instance.PgData, connectionString, slotName)
+	if replicaCluster.ChannelBinding == "" && !replicaCluster.PreferSourceStandby {
+		return connectionString, nil
+	}
+
+	major, err := postgresutils.GetMajorVersion(instance.PgData)
+	if err != nil {
+		return "", err
+	}
+
+	if replicaCluster.ChannelBinding != "" {
+		connectionString = withChannelBinding(ctx, connectionString, replicaCluster.ChannelBinding, major)
+	}
+	if replicaCluster.PreferSourceStandby {
+		connectionString = withSourceStandbyPreference(ctx, connectionString, major)
+	}
+
+	return connectionString, nil
+}
+
+// withSourceStandbyPreference makes the passed connection string prefer the
+// standby hosts, so that the designated primary streams from a standby of the
+// source when one is available. The preference is ignored when the libpq of
+// the passed PostgreSQL major version doesn't support it, as the connection
+// would be refused otherwise
+func withSourceStandbyPreference(ctx context.Context, connectionString string, major int) string {
+	// target_session_attrs accepts prefer-standby only since PostgreSQL 14
+	if major < 14 {
+		log.FromContext(ctx).Warning(
+			"Streaming from a standby of the source is not supported by this PostgreSQL version, "+
+				"streaming from the first available host",
+			"majorVersion", major)
+		return connectionString
+	}
+
+	return connectionString + " " + configfile.CreateConnectionString(map[string]string{
+		"target_session_attrs": "prefer-standby",
+	})
+}
+
+// withChannelBinding adds the requested channel binding to the passed
+// connection string. The channel binding is ignored when the libpq of the
+// passed PostgreSQL major version doesn't support it, as the connection
+// would be refused otherwise
+func withChannelBinding(
+	ctx context.Context,
+	connectionString string,
+	channelBinding apiv1.ChannelBindingMode,
+	major int,
+) string {
+	// channel_binding is only available since PostgreSQL 13
+	if major < 13 {
+		log.FromContext(ctx).Warning("Channel binding is not supported by this PostgreSQL version, ignoring it",
+			"channelBinding", channelBinding,
+			"majorVersion", major)
+		return connectionString
+	}
+
+	return connectionString + " " + configfile.CreateConnectionString(map[string]string{
+		"channel_binding": string(channelBinding),
+	})
+}
+
+// orderSourceHostsByHealth probes each of the hosts listed in the connection
+// parameters of the source, and returns a copy of it where the reachable hosts
+// come before the unreachable ones. The configured order is kept between hosts
+// having the same health, and the ports are reordered together with the hosts
+// when one port per host is specified. The returned flag tells whether the
+// order of the hosts has been changed
+func orderSourceHostsByHealth(
+	ctx context.Context,
+	connectionString string,
+	server apiv1.ExternalCluster,
+) (apiv1.ExternalCluster, bool) {
+	hosts := strings.Split(server.ConnectionParameters["host"], ",")
+	if len(hosts) < 2 {
+		return server, false
+	}
+
+	var ports []string
+	if rawPorts, ok := server.ConnectionParameters["port"]; ok {
+		ports = strings.Split(rawPorts, ",")
+	}
+	hasPortPerHost := len(ports) == len(hosts)
+
+	var reachable, unreachable []int
+	for idx, host := range hosts {
+		probeParameters := map[string]string{"host": strings.TrimSpace(host)}
+		if hasPortPerHost {
+			probeParameters["port"] = strings.TrimSpace(ports[idx])
+		}
+
+		// libpq gives precedence to the last occurrence of a parameter
+		probeConnectionString := connectionString + " " + configfile.CreateConnectionString(probeParameters)
+		if isSourceReachable(ctx, probeConnectionString) {
+			reachable = append(reachable, idx)
+		} else {
+			unreachable = append(unreachable, idx)
+		}
+	}
+
+	if len(reachable) == 0 || len(unreachable) == 0 {
+		return server, false
+	}
+
+	order := make([]int, 0, len(hosts))
+	order = append(order, reachable...)
+	order = append(order, unreachable...)
+	reordered := false
+	orderedHosts := make([]string, len(hosts))
+	orderedPorts := make([]string, len(ports))
+	for position, idx := range order {
+		reordered = reordered || position != idx
+		orderedHosts[position] = hosts[idx]
+		if hasPortPerHost {
+			orderedPorts[position] = ports[idx]
+		}
+	}
+
+	if !reordered {
+		return server, false
+	}
+
+	connectionParameters := make(map[string]string, len(server.ConnectionParameters))
+	for key, value := range server.ConnectionParameters {
+		connectionParameters[key] = value
+	}
+	connectionParameters["host"] = strings.Join(orderedHosts, ",")
+	if hasPortPerHost {
+		connectionParameters["port"] = strings.Join(orderedPorts, ",")
+	}
+	server.ConnectionParameters = connectionParameters
+
+	return server, true
 }
@@ -0,0 +1,959 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("pausing streaming in a replica cluster", func() {
+	var (
+		instance         *Instance
+		cluster          *apiv1.Cluster
+		postgresAutoConf string
+		sourceReachable  bool
+	)
+
+	BeforeEach(func() {
+		tempDir, err := os.MkdirTemp("", "replica")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = os.RemoveAll(tempDir)
+		})
+
+		instance = &Instance{
+			PgData:  tempDir,
+			PodName: "cluster-example-1",
+		}
+		postgresAutoConf = filepath.Join(tempDir, "postgresql.auto.conf")
+
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "PG_VERSION"), "14")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "standby.signal"), "")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(postgresAutoConf, "")
+		Expect(err).ToNot(HaveOccurred())
+
+		cluster = &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{
+					Source:                            "source",
+					Enabled:                           true,
+					PauseStreamingOnSourceMaintenance: true,
+				},
+				ExternalClusters: []apiv1.ExternalCluster{
+					{
+						Name: "source",
+						ConnectionParameters: map[string]string{
+							"host": "source-rw",
+							"user": "streaming_replica",
+						},
+					},
+				},
+			},
+			Status: apiv1.ClusterStatus{
+				TargetPrimary: "cluster-example-1",
+			},
+		}
+
+		sourceReachable = true
+		originalIsSourceReachable := isSourceReachable
+		isSourceReachable = func(context.Context, string) bool {
+			return sourceReachable
+		}
+		DeferCleanup(func() {
+			isSourceReachable = originalIsSourceReachable
+		})
+	})
+
+	readPostgresAutoConf := func() string {
+		content, err := fileutils.ReadFile(postgresAutoConf)
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	It("streams from the source while it is reachable", func(ctx context.Context) {
+		changed, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		Expect(instance.IsReplicaStreamingPaused()).To(BeFalse())
+		Expect(readPostgresAutoConf()).To(ContainSubstring("primary_conninfo"))
+	})
+
+	It("pauses streaming when the source is not reachable and resumes it afterwards", func(ctx context.Context) {
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("entering the paused mode", func() {
+			sourceReachable = false
+			instance.ProbeSource(ctx)
+			changed, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(instance.IsReplicaStreamingPaused()).To(BeTrue())
+			Expect(readPostgresAutoConf()).ToNot(ContainSubstring("primary_conninfo"))
+		})
+
+		By("not reconfiguring the instance while paused", func() {
+			changed, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeFalse())
+			Expect(instance.IsReplicaStreamingPaused()).To(BeTrue())
+		})
+
+		By("resuming streaming when the source is back", func() {
+			sourceReachable = true
+			instance.ProbeSource(ctx)
+			changed, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(instance.IsReplicaStreamingPaused()).To(BeFalse())
+			Expect(readPostgresAutoConf()).To(ContainSubstring("primary_conninfo"))
+		})
+	})
+
+	It("gates the readiness of the designated primary on streaming when requested", func(ctx context.Context) {
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.readinessRequiresStreaming.Load()).To(BeFalse())
+
+		cluster.Spec.ReplicaCluster.ReadinessRequiresStreaming = true
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.readinessRequiresStreaming.Load()).To(BeTrue())
+
+		cluster.Status.TargetPrimary = "cluster-example-2"
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.readinessRequiresStreaming.Load()).To(BeFalse())
+	})
+
+	It("never pauses streaming when the option is disabled", func(ctx context.Context) {
+		cluster.Spec.ReplicaCluster.PauseStreamingOnSourceMaintenance = false
+		sourceReachable = false
+
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.IsSourceProbeEnabled()).To(BeFalse())
+		instance.ProbeSource(ctx)
+
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.IsReplicaStreamingPaused()).To(BeFalse())
+		Expect(readPostgresAutoConf()).To(ContainSubstring("primary_conninfo"))
+	})
+
+	It("never probes the source while refreshing the configuration", func(ctx context.Context) {
+		sourceReachable = false
+
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.IsSourceProbeEnabled()).To(BeTrue())
+		Expect(instance.IsReplicaStreamingPaused()).To(BeFalse())
+		Expect(readPostgresAutoConf()).To(ContainSubstring("primary_conninfo"))
+	})
+
+	It("stops probing the source when not the designated primary anymore", func(ctx context.Context) {
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.IsSourceProbeEnabled()).To(BeTrue())
+
+		cluster.Status.TargetPrimary = "cluster-example-2"
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.IsSourceProbeEnabled()).To(BeFalse())
+	})
+})
+
+var _ = Describe("channel binding of the connection to the source", func() {
+	It("adds the channel binding to the connection string", func(ctx context.Context) {
+		Expect(withChannelBinding(ctx, "host=source-rw user=streaming_replica", apiv1.ChannelBindingRequire, 16)).
+			To(Equal("host=source-rw user=streaming_replica channel_binding='require'"))
+		Expect(withChannelBinding(ctx, "host=source-rw", apiv1.ChannelBindingPrefer, 13)).
+			To(Equal("host=source-rw channel_binding='prefer'"))
+	})
+
+	It("ignores the channel binding when not supported by PostgreSQL", func(ctx context.Context) {
+		Expect(withChannelBinding(ctx, "host=source-rw", apiv1.ChannelBindingRequire, 12)).
+			To(Equal("host=source-rw"))
+	})
+
+	It("writes the channel binding in the replication configuration", func(ctx context.Context) {
+		tempDir, err := os.MkdirTemp("", "replica")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = os.RemoveAll(tempDir)
+		})
+
+		instance := &Instance{
+			PgData:  tempDir,
+			PodName: "cluster-example-1",
+		}
+		postgresAutoConf := filepath.Join(tempDir, "postgresql.auto.conf")
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "PG_VERSION"), "14")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "standby.signal"), "")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(postgresAutoConf, "")
+		Expect(err).ToNot(HaveOccurred())
+
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{
+					Source:         "source",
+					Enabled:        true,
+					ChannelBinding: apiv1.ChannelBindingRequire,
+				},
+				ExternalClusters: []apiv1.ExternalCluster{
+					{
+						Name: "source",
+						ConnectionParameters: map[string]string{
+							"host": "source-rw",
+							"user": "streaming_replica",
+						},
+					},
+				},
+			},
+			Status: apiv1.ClusterStatus{
+				TargetPrimary: "cluster-example-1",
+			},
+		}
+
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		content, err := fileutils.ReadFile(postgresAutoConf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(ContainSubstring("channel_binding=''require''"))
+	})
+})
+
+var _ = Describe("streaming from a standby of the source", func() {
+	It("prefers the standby hosts of the source", func(ctx context.Context) {
+		Expect(withSourceStandbyPreference(ctx, "host=source-ro,source-rw user=streaming_replica", 16)).
+			To(Equal("host=source-ro,source-rw user=streaming_replica target_session_attrs='prefer-standby'"))
+	})
+
+	It("ignores the preference when not supported by PostgreSQL", func(ctx context.Context) {
+		Expect(withSourceStandbyPreference(ctx, "host=source-ro,source-rw", 13)).
+			To(Equal("host=source-ro,source-rw"))
+	})
+
+	It("writes the preference in the replication configuration", func(ctx context.Context) {
+		tempDir, err := os.MkdirTemp("", "replica")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = os.RemoveAll(tempDir)
+		})
+
+		instance := &Instance{
+			PgData:  tempDir,
+			PodName: "cluster-example-1",
+		}
+		postgresAutoConf := filepath.Join(tempDir, "postgresql.auto.conf")
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "PG_VERSION"), "16")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "standby.signal"), "")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(postgresAutoConf, "")
+		Expect(err).ToNot(HaveOccurred())
+
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{
+					Source:              "source",
+					Enabled:             true,
+					PreferSourceStandby: true,
+					ChannelBinding:      apiv1.ChannelBindingPrefer,
+				},
+				ExternalClusters: []apiv1.ExternalCluster{
+					{
+						Name: "source",
+						ConnectionParameters: map[string]string{
+							"host": "source-ro,source-rw",
+							"user": "streaming_replica",
+						},
+					},
+				},
+			},
+			Status: apiv1.ClusterStatus{
+				TargetPrimary: "cluster-example-1",
+			},
+		}
+
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		content, err := fileutils.ReadFile(postgresAutoConf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(ContainSubstring("target_session_attrs=''prefer-standby''"))
+		Expect(string(content)).To(ContainSubstring("channel_binding=''prefer''"))
+	})
+})
+
+var _ = Describe("probing the source of a replica cluster", func() {
+	var (
+		instance     *Instance
+		cluster      *apiv1.Cluster
+		sourceStatus *postgres.SourceStatus
+	)
+
+	BeforeEach(func() {
+		tempDir := GinkgoT().TempDir()
+		instance = &Instance{
+			PgData:  tempDir,
+			PodName: "cluster-example-1",
+		}
+		_, err := fileutils.WriteStringToFile(filepath.Join(tempDir, "PG_VERSION"), "14")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "standby.signal"), "")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "postgresql.auto.conf"), "")
+		Expect(err).ToNot(HaveOccurred())
+
+		cluster = &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{
+					Source:             "source",
+					Enabled:            true,
+					ReportSourceStatus: true,
+				},
+				ExternalClusters: []apiv1.ExternalCluster{
+					{
+						Name: "source",
+						ConnectionParameters: map[string]string{
+							"host": "source-rw",
+							"user": "streaming_replica",
+						},
+					},
+				},
+			},
+			Status: apiv1.ClusterStatus{
+				TargetPrimary: "cluster-example-1",
+			},
+		}
+
+		originalProbeSource := probeSource
+		probeSource = func(context.Context, string) *postgres.SourceStatus {
+			return sourceStatus
+		}
+		originalGetReplayLSN := getReplayLSN
+		getReplayLSN = func(context.Context, *Instance) (postgres.LSN, error) {
+			return "0/6000000", nil
+		}
+		DeferCleanup(func() {
+			probeSource = originalProbeSource
+			getReplayLSN = originalGetReplayLSN
+		})
+	})
+
+	It("never probes the source while refreshing the configuration", func(ctx context.Context) {
+		sourceStatus = &postgres.SourceStatus{Reachable: true}
+
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.IsSourceProbeEnabled()).To(BeTrue())
+		Expect(instance.GetSourceStatus()).To(BeNil())
+	})
+
+	It("reports a reachable source", func(ctx context.Context) {
+		sourceStatus = &postgres.SourceStatus{Reachable: true, IsPrimary: true, CurrentLsn: "0/7000000"}
+
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		instance.ProbeSource(ctx)
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.GetSourceStatus()).To(Equal(sourceStatus))
+	})
+
+	It("reports an unreachable source", func(ctx context.Context) {
+		sourceStatus = &postgres.SourceStatus{Error: "connection refused"}
+
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		instance.ProbeSource(ctx)
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.GetSourceStatus()).ToNot(BeNil())
+		Expect(instance.GetSourceStatus().Reachable).To(BeFalse())
+		Expect(instance.GetSourceStatus().Error).To(Equal("connection refused"))
+	})
+
+	It("doesn't probe the source when not requested", func(ctx context.Context) {
+		sourceStatus = &postgres.SourceStatus{Reachable: true}
+		cluster.Spec.ReplicaCluster.ReportSourceStatus = false
+
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		instance.ProbeSource(ctx)
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.GetSourceStatus()).To(BeNil())
+	})
+
+	It("probes the source when logical decoding is required", func(ctx context.Context) {
+		sourceStatus = &postgres.SourceStatus{Reachable: true, WalLevel: "replica"}
+		cluster.Spec.ReplicaCluster.ReportSourceStatus = false
+		cluster.Spec.ReplicaCluster.LogicalDecoding = true
+
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		instance.ProbeSource(ctx)
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.GetSourceStatus()).To(Equal(sourceStatus))
+	})
+
+	It("measures the lag behind a reachable source", func(ctx context.Context) {
+		sourceStatus = &postgres.SourceStatus{Reachable: true, IsPrimary: true, CurrentLsn: "0/7000000"}
+
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		instance.ProbeSource(ctx)
+		Expect(instance.GetReplicaClusterLag()).To(Equal(&ReplicaClusterLag{Source: "source", Bytes: 0x1000000}))
+	})
+
+	It("gates the readiness on the lag behind the source", func(ctx context.Context) {
+		sourceStatus = &postgres.SourceStatus{Reachable: true, IsPrimary: true, CurrentLsn: "0/7000000"}
+		cluster.Spec.ReplicaCluster.ReportSourceStatus = false
+		cluster.Spec.ReplicaCluster.ReadinessMaxLagBytes = 0x100000
+
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.IsReplicaClusterCatchingUp()).To(BeTrue())
+
+		instance.ProbeSource(ctx)
+		Expect(instance.IsReplicaClusterCatchingUp()).To(BeTrue())
+
+		sourceStatus = &postgres.SourceStatus{Reachable: true, IsPrimary: true, CurrentLsn: "0/6080000"}
+		instance.ProbeSource(ctx)
+		Expect(instance.IsReplicaClusterCatchingUp()).To(BeFalse())
+
+		// the last decision is kept while the lag can't be measured
+		sourceStatus = &postgres.SourceStatus{Error: "connection refused"}
+		instance.ProbeSource(ctx)
+		Expect(instance.IsReplicaClusterCatchingUp()).To(BeFalse())
+	})
+
+	It("doesn't gate the readiness on the lag when not the designated primary", func(ctx context.Context) {
+		cluster.Spec.ReplicaCluster.ReadinessMaxLagBytes = 0x100000
+		cluster.Status.TargetPrimary = "cluster-example-2"
+
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.IsReplicaClusterCatchingUp()).To(BeFalse())
+	})
+
+	It("doesn't measure the lag behind an unreachable source", func(ctx context.Context) {
+		sourceStatus = &postgres.SourceStatus{Error: "connection refused"}
+
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		instance.ProbeSource(ctx)
+		Expect(instance.GetReplicaClusterLag()).To(BeNil())
+	})
+
+	It("stops measuring the lag when not the designated primary anymore", func(ctx context.Context) {
+		sourceStatus = &postgres.SourceStatus{Reachable: true, CurrentLsn: "0/7000000"}
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		instance.ProbeSource(ctx)
+		Expect(instance.GetReplicaClusterLag()).ToNot(BeNil())
+
+		cluster.Status.TargetPrimary = "cluster-example-2"
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.GetReplicaClusterLag()).To(BeNil())
+	})
+
+	It("stops reporting the source status when not the designated primary anymore", func(ctx context.Context) {
+		sourceStatus = &postgres.SourceStatus{Reachable: true}
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		instance.ProbeSource(ctx)
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.GetSourceStatus()).ToNot(BeNil())
+
+		cluster.Status.TargetPrimary = "cluster-example-2"
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.GetSourceStatus()).To(BeNil())
+	})
+})
+
+var _ = Describe("promoting a replica to designated primary", func() {
+	It("makes the new designated primary stream from the source", func(ctx context.Context) {
+		tempDir, err := os.MkdirTemp("", "replica")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = os.RemoveAll(tempDir)
+		})
+
+		instance := &Instance{
+			PgData:      tempDir,
+			PodName:     "cluster-example-2",
+			ClusterName: "cluster-example",
+		}
+		postgresAutoConf := filepath.Join(tempDir, "postgresql.auto.conf")
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "PG_VERSION"), "14")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "standby.signal"), "")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(postgresAutoConf, "")
+		Expect(err).ToNot(HaveOccurred())
+
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{
+					Source:  "source",
+					Enabled: true,
+				},
+				ExternalClusters: []apiv1.ExternalCluster{
+					{
+						Name: "source",
+						ConnectionParameters: map[string]string{
+							"host": "source-rw",
+							"user": "streaming_replica",
+						},
+					},
+				},
+			},
+			Status: apiv1.ClusterStatus{
+				TargetPrimary: "cluster-example-1",
+			},
+		}
+
+		By("streaming from the designated primary while being a replica", func() {
+			_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+			Expect(err).ToNot(HaveOccurred())
+			content, err := fileutils.ReadFile(postgresAutoConf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring("host=cluster-example-rw"))
+		})
+
+		By("streaming from the source after the failover", func() {
+			cluster.Status.TargetPrimary = "cluster-example-2"
+			changed, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			content, err := fileutils.ReadFile(postgresAutoConf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring("source-rw"))
+			Expect(string(content)).ToNot(ContainSubstring("host=cluster-example-rw"))
+		})
+	})
+})
+
+var _ = Describe("falling back to other sources of a replica cluster", func() {
+	var (
+		instance         *Instance
+		cluster          *apiv1.Cluster
+		postgresAutoConf string
+		unreachableHosts map[string]bool
+	)
+
+	BeforeEach(func() {
+		tempDir, err := os.MkdirTemp("", "replica")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = os.RemoveAll(tempDir)
+		})
+
+		instance = &Instance{
+			PgData:  tempDir,
+			PodName: "cluster-example-1",
+		}
+		postgresAutoConf = filepath.Join(tempDir, "postgresql.auto.conf")
+
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "PG_VERSION"), "14")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "standby.signal"), "")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(postgresAutoConf, "")
+		Expect(err).ToNot(HaveOccurred())
+
+		cluster = &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{
+					Source:          "source-a",
+					FallbackSources: []string{"source-b"},
+					Enabled:         true,
+				},
+				ExternalClusters: []apiv1.ExternalCluster{
+					{
+						Name: "source-a",
+						ConnectionParameters: map[string]string{
+							"host": "source-a-rw",
+							"user": "streaming_replica",
+						},
+					},
+					{
+						Name: "source-b",
+						ConnectionParameters: map[string]string{
+							"host": "source-b-rw",
+							"user": "streaming_replica",
+						},
+					},
+				},
+			},
+			Status: apiv1.ClusterStatus{
+				TargetPrimary: "cluster-example-1",
+			},
+		}
+
+		unreachableHosts = make(map[string]bool)
+		originalIsSourceReachable := isSourceReachable
+		isSourceReachable = func(_ context.Context, connectionString string) bool {
+			for host := range unreachableHosts {
+				if strings.Contains(connectionString, fmt.Sprintf("host='%s'", host)) {
+					return false
+				}
+			}
+			return true
+		}
+		DeferCleanup(func() {
+			isSourceReachable = originalIsSourceReachable
+		})
+	})
+
+	readPostgresAutoConf := func() string {
+		content, err := fileutils.ReadFile(postgresAutoConf)
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	It("streams from the source while it is reachable", func(ctx context.Context) {
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.IsSourceProbeEnabled()).To(BeTrue())
+
+		instance.ProbeSource(ctx)
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.GetActiveReplicaSource()).To(Equal("source-a"))
+		Expect(readPostgresAutoConf()).To(ContainSubstring("source-a-rw"))
+	})
+
+	It("switches to the fallback source and back", func(ctx context.Context) {
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		By("falling back when the source is not reachable", func() {
+			unreachableHosts["source-a-rw"] = true
+			instance.ProbeSource(ctx)
+			changed, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(instance.GetActiveReplicaSource()).To(Equal("source-b"))
+			Expect(readPostgresAutoConf()).To(ContainSubstring("source-b-rw"))
+		})
+
+		By("keeping the fallback source while none is reachable", func() {
+			unreachableHosts["source-b-rw"] = true
+			instance.ProbeSource(ctx)
+			changed, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeFalse())
+			Expect(instance.GetActiveReplicaSource()).To(Equal("source-b"))
+		})
+
+		By("going back to the source when it is reachable again", func() {
+			delete(unreachableHosts, "source-a-rw")
+			instance.ProbeSource(ctx)
+			changed, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(instance.GetActiveReplicaSource()).To(Equal("source-a"))
+			Expect(readPostgresAutoConf()).To(ContainSubstring("source-a-rw"))
+		})
+	})
+
+	It("pauses streaming only when no source is reachable", func(ctx context.Context) {
+		cluster.Spec.ReplicaCluster.PauseStreamingOnSourceMaintenance = true
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		unreachableHosts["source-a-rw"] = true
+		instance.ProbeSource(ctx)
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.IsReplicaStreamingPaused()).To(BeFalse())
+
+		unreachableHosts["source-b-rw"] = true
+		instance.ProbeSource(ctx)
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.IsReplicaStreamingPaused()).To(BeTrue())
+	})
+
+	It("doesn't report the active source without fallback sources", func(ctx context.Context) {
+		_, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.GetActiveReplicaSource()).To(Equal("source-a"))
+
+		cluster.Spec.ReplicaCluster.FallbackSources = nil
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.GetActiveReplicaSource()).To(BeEmpty())
+		Expect(instance.IsSourceProbeEnabled()).To(BeFalse())
+	})
+})
+
+var _ = Describe("ordering the source hosts by health", func() {
+	var (
+		server           apiv1.ExternalCluster
+		unreachableHosts map[string]bool
+	)
+
+	BeforeEach(func() {
+		server = apiv1.ExternalCluster{
+			Name: "source",
+			ConnectionParameters: map[string]string{
+				"host": "source-a,source-b,source-c",
+				"port": "5432,5433,5434",
+				"user": "streaming_replica",
+			},
+		}
+
+		unreachableHosts = make(map[string]bool)
+		originalIsSourceReachable := isSourceReachable
+		isSourceReachable = func(_ context.Context, connectionString string) bool {
+			for host := range unreachableHosts {
+				if strings.Contains(connectionString, fmt.Sprintf(" host='%s'", host)) {
+					return false
+				}
+			}
+			return true
+		}
+		DeferCleanup(func() {
+			isSourceReachable = originalIsSourceReachable
+		})
+	})
+
+	It("keeps the configured order while every host is reachable", func(ctx context.Context) {
+		orderedServer, reordered := orderSourceHostsByHealth(ctx, "user='streaming_replica'", server)
+		Expect(reordered).To(BeFalse())
+		Expect(orderedServer.ConnectionParameters).To(Equal(server.ConnectionParameters))
+	})
+
+	It("moves the unreachable hosts at the end of the list", func(ctx context.Context) {
+		unreachableHosts["source-a"] = true
+
+		orderedServer, reordered := orderSourceHostsByHealth(ctx, "user='streaming_replica'", server)
+		Expect(reordered).To(BeTrue())
+		Expect(orderedServer.ConnectionParameters["host"]).To(Equal("source-b,source-c,source-a"))
+		Expect(orderedServer.ConnectionParameters["port"]).To(Equal("5433,5434,5432"))
+		Expect(orderedServer.ConnectionParameters["user"]).To(Equal("streaming_replica"))
+		Expect(server.ConnectionParameters["host"]).To(Equal("source-a,source-b,source-c"))
+	})
+
+	It("follows the health changes of the hosts", func(ctx context.Context) {
+		unreachableHosts["source-a"] = true
+		unreachableHosts["source-b"] = true
+		orderedServer, reordered := orderSourceHostsByHealth(ctx, "user='streaming_replica'", server)
+		Expect(reordered).To(BeTrue())
+		Expect(orderedServer.ConnectionParameters["host"]).To(Equal("source-c,source-a,source-b"))
+
+		delete(unreachableHosts, "source-a")
+		orderedServer, reordered = orderSourceHostsByHealth(ctx, "user='streaming_replica'", server)
+		Expect(reordered).To(BeTrue())
+		Expect(orderedServer.ConnectionParameters["host"]).To(Equal("source-a,source-c,source-b"))
+
+		delete(unreachableHosts, "source-b")
+		_, reordered = orderSourceHostsByHealth(ctx, "user='streaming_replica'", server)
+		Expect(reordered).To(BeFalse())
+	})
+
+	It("keeps the ports untouched when they are not specified per host", func(ctx context.Context) {
+		server.ConnectionParameters["port"] = "5432"
+		unreachableHosts["source-a"] = true
+
+		orderedServer, reordered := orderSourceHostsByHealth(ctx, "user='streaming_replica'", server)
+		Expect(reordered).To(BeTrue())
+		Expect(orderedServer.ConnectionParameters["host"]).To(Equal("source-b,source-c,source-a"))
+		Expect(orderedServer.ConnectionParameters["port"]).To(Equal("5432"))
+	})
+
+	It("writes the reordered hosts in the replication configuration", func(ctx context.Context) {
+		tempDir, err := os.MkdirTemp("", "replica")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = os.RemoveAll(tempDir)
+		})
+
+		instance := &Instance{
+			PgData:  tempDir,
+			PodName: "cluster-example-1",
+		}
+		postgresAutoConf := filepath.Join(tempDir, "postgresql.auto.conf")
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "PG_VERSION"), "14")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "standby.signal"), "")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(postgresAutoConf, "")
+		Expect(err).ToNot(HaveOccurred())
+
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{
+					Source:                   "source",
+					Enabled:                  true,
+					OrderSourceHostsByHealth: true,
+				},
+				ExternalClusters: []apiv1.ExternalCluster{server},
+			},
+			Status: apiv1.ClusterStatus{
+				TargetPrimary: "cluster-example-1",
+			},
+		}
+
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		content, err := fileutils.ReadFile(postgresAutoConf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(ContainSubstring("source-a,source-b,source-c"))
+
+		unreachableHosts["source-a"] = true
+		instance.ProbeSource(ctx)
+		changed, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		content, err = fileutils.ReadFile(postgresAutoConf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(ContainSubstring("source-b,source-c,source-a"))
+	})
+})
+
+var _ = Describe("verifying the presence of standby.signal", func() {
+	var (
+		instance      *Instance
+		cluster       *apiv1.Cluster
+		standbySignal string
+	)
+
+	BeforeEach(func() {
+		tempDir := GinkgoT().TempDir()
+		instance = &Instance{
+			PgData:      tempDir,
+			PodName:     "cluster-example-2",
+			ClusterName: "cluster-example",
+		}
+		standbySignal = filepath.Join(tempDir, "standby.signal")
+
+		_, err := fileutils.WriteStringToFile(filepath.Join(tempDir, "PG_VERSION"), "14")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "postgresql.auto.conf"), "")
+		Expect(err).ToNot(HaveOccurred())
+
+		cluster = &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				StandbySignalCheckInterval: 30,
+			},
+			Status: apiv1.ClusterStatus{
+				CurrentPrimary: "cluster-example-1",
+				TargetPrimary:  "cluster-example-1",
+			},
+		}
+	})
+
+	It("re-creates the signal file missing from a standby", func(ctx context.Context) {
+		changed, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		Expect(fileutils.FileExists(standbySignal)).To(BeTrue())
+	})
+
+	It("re-creates the signal file missing from the designated primary", func(ctx context.Context) {
+		instance.PodName = "cluster-example-1"
+		cluster.Spec.ReplicaCluster = &apiv1.ReplicaClusterConfiguration{
+			Source:  "source",
+			Enabled: true,
+		}
+
+		recreated, err := instance.ensureStandbySignal(ctx, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recreated).To(BeTrue())
+		Expect(fileutils.FileExists(standbySignal)).To(BeTrue())
+	})
+
+	It("doesn't touch a standby having the signal file", func(ctx context.Context) {
+		_, err := fileutils.WriteStringToFile(standbySignal, "")
+		Expect(err).ToNot(HaveOccurred())
+
+		recreated, err := instance.ensureStandbySignal(ctx, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recreated).To(BeFalse())
+	})
+
+	It("doesn't create the signal file in the primary", func(ctx context.Context) {
+		instance.PodName = "cluster-example-1"
+
+		changed, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+		Expect(fileutils.FileExists(standbySignal)).To(BeFalse())
+	})
+
+	It("doesn't create the signal file in the instance being promoted", func(ctx context.Context) {
+		cluster.Status.TargetPrimary = "cluster-example-2"
+
+		recreated, err := instance.ensureStandbySignal(ctx, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(recreated).To(BeFalse())
+		Expect(fileutils.FileExists(standbySignal)).To(BeFalse())
+	})
+
+	It("doesn't verify the signal file when the check is disabled", func(ctx context.Context) {
+		cluster.Spec.StandbySignalCheckInterval = 0
+
+		changed, err := instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+		Expect(fileutils.FileExists(standbySignal)).To(BeFalse())
+	})
+})
+
+var _ = Describe("lag of the replica cluster behind its source", func() {
+	It("is the WAL still to be replayed", func() {
+		Expect(getReplicaClusterLag("source",
+			&postgres.SourceStatus{Reachable: true, CurrentLsn: "0/7000060"}, "0/7000000")).
+			To(Equal(&ReplicaClusterLag{Source: "source", Bytes: 0x60}))
+	})
+
+	It("is zero when the replica cluster caught up", func() {
+		Expect(getReplicaClusterLag("source",
+			&postgres.SourceStatus{Reachable: true, CurrentLsn: "0/7000000"}, "0/7000060")).
+			To(Equal(&ReplicaClusterLag{Source: "source"}))
+	})
+
+	It("is unknown when the source is not reachable or its LSN is invalid", func() {
+		Expect(getReplicaClusterLag("source", &postgres.SourceStatus{Error: "refused"}, "0/7000000")).To(BeNil())
+		Expect(getReplicaClusterLag("source",
+			&postgres.SourceStatus{Reachable: true, CurrentLsn: "garbage"}, "0/7000000")).To(BeNil())
+	})
+})
@@ -90,6 +90,6 @@ func (info InitInfo) Join(cluster *apiv1.Cluster) error {
 	}
 
 	slotName := cluster.GetSlotNameFromInstanceName(info.PodName)
-	_, err = UpdateReplicaConfiguration(info.PgData, info.GetPrimaryConnInfo(), slotName)
+	_, err = UpdateReplicaConfiguration(info.PgData, info.GetPrimaryConnInfo(), slotName, "")
 	return err
 }
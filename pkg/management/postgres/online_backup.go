@@ -0,0 +1,249 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+)
+
+// ErrOnlineBackupInProgress is raised when an online backup is requested
+// while the instance is executing another one
+var ErrOnlineBackupInProgress = errors.New("another online backup is in progress")
+
+// ErrOnlineBackupNotFound is raised when an online backup which is not
+// being executed by the instance is stopped
+var ErrOnlineBackupNotFound = errors.New("online backup not found")
+
+// onlineBackupSession is an online backup executed by the instance.
+// PostgreSQL ties the backup to the session that started it, so a
+// dedicated connection is kept open until the backup is stopped
+type onlineBackupSession struct {
+	conn           *sql.Conn
+	pgMajorVersion uint64
+	status         postgres.OnlineBackupStatus
+
+	// stopRequested is true when the backup has been stopped while
+	// starting, and will be stopped as soon as it is started
+	stopRequested  bool
+	waitForArchive bool
+}
+
+// isTerminated checks whether the online backup completed or failed
+func (session *onlineBackupSession) isTerminated() bool {
+	return session.status.Phase == postgres.OnlineBackupPhaseCompleted ||
+		session.status.Phase == postgres.OnlineBackupPhaseFailed
+}
+
+// close closes the connection of the online backup, which aborts the
+// backup mode in PostgreSQL if it has not been stopped
+func (session *onlineBackupSession) close() {
+	if err := session.conn.Close(); err != nil {
+		log.Warning("Error while closing the connection of the online backup",
+			"backupName", session.status.BackupName, "err", err.Error())
+	}
+}
+
+// StartOnlineBackup starts, in background, an online backup through the
+// PostgreSQL low level API for base backups. Starting the backup being
+// executed again is a no-op, while a terminated backup is replaced
+func (instance *Instance) StartOnlineBackup(request postgres.OnlineBackupRequest) error {
+	instance.onlineBackupMutex.Lock()
+	defer instance.onlineBackupMutex.Unlock()
+
+	if session := instance.onlineBackup; session != nil {
+		switch {
+		case session.status.BackupName == request.BackupName:
+			return nil
+		case !session.isTerminated():
+			return fmt.Errorf("%w: %s", ErrOnlineBackupInProgress, session.status.BackupName)
+		}
+	}
+
+	pgVersion, err := instance.GetPgVersion()
+	if err != nil {
+		return err
+	}
+
+	db, err := instance.GetSuperUserDB()
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+
+	session := &onlineBackupSession{
+		conn:           conn,
+		pgMajorVersion: pgVersion.Major,
+		status: postgres.OnlineBackupStatus{
+			BackupName: request.BackupName,
+			Phase:      postgres.OnlineBackupPhaseStarting,
+		},
+	}
+	instance.onlineBackup = session
+
+	// pg_backup_start waits for a checkpoint, which may take a long time
+	go instance.executeOnlineBackupStart(session, request.ImmediateCheckpoint)
+	return nil
+}
+
+// StopOnlineBackup stops, in background, the online backup being executed
+// by the instance. A backup still starting is stopped as soon as it is
+// started, while stopping a backup already stopping or terminated is a no-op
+func (instance *Instance) StopOnlineBackup(request postgres.OnlineBackupRequest) error {
+	instance.onlineBackupMutex.Lock()
+	defer instance.onlineBackupMutex.Unlock()
+
+	session := instance.onlineBackup
+	if session == nil || session.status.BackupName != request.BackupName {
+		return fmt.Errorf("%w: %s", ErrOnlineBackupNotFound, request.BackupName)
+	}
+
+	switch session.status.Phase {
+	case postgres.OnlineBackupPhaseStarting:
+		session.stopRequested = true
+		session.waitForArchive = request.WaitForArchive
+	case postgres.OnlineBackupPhaseStarted:
+		session.status.Phase = postgres.OnlineBackupPhaseStopping
+		// pg_backup_stop may wait for the WAL files to be archived
+		go instance.executeOnlineBackupStop(session, request.WaitForArchive)
+	}
+
+	return nil
+}
+
+// GetOnlineBackupStatus gets the status of the online backup executed
+// by the instance, or nil if there is none
+func (instance *Instance) GetOnlineBackupStatus() *postgres.OnlineBackupStatus {
+	instance.onlineBackupMutex.Lock()
+	defer instance.onlineBackupMutex.Unlock()
+
+	if instance.onlineBackup == nil {
+		return nil
+	}
+
+	status := instance.onlineBackup.status
+	return &status
+}
+
+// executeOnlineBackupStart puts the instance in backup mode
+func (instance *Instance) executeOnlineBackupStart(session *onlineBackupSession, immediateCheckpoint bool) {
+	beginLSN, err := startOnlineBackup(
+		context.Background(), session.conn, session.pgMajorVersion,
+		session.status.BackupName, immediateCheckpoint)
+
+	instance.onlineBackupMutex.Lock()
+	defer instance.onlineBackupMutex.Unlock()
+
+	if err != nil {
+		log.Error(err, "while starting the online backup", "backupName", session.status.BackupName)
+		session.status.Phase = postgres.OnlineBackupPhaseFailed
+		session.status.Error = err.Error()
+		session.close()
+		return
+	}
+
+	log.Info("Online backup started", "backupName", session.status.BackupName, "beginLSN", beginLSN)
+	session.status.Phase = postgres.OnlineBackupPhaseStarted
+	session.status.BeginLSN = beginLSN
+
+	if session.stopRequested {
+		session.status.Phase = postgres.OnlineBackupPhaseStopping
+		go instance.executeOnlineBackupStop(session, session.waitForArchive)
+	}
+}
+
+// executeOnlineBackupStop takes the instance out of backup mode, and
+// collects the backup label
+func (instance *Instance) executeOnlineBackupStop(session *onlineBackupSession, waitForArchive bool) {
+	result, err := stopOnlineBackup(context.Background(), session.conn, session.pgMajorVersion, waitForArchive)
+
+	instance.onlineBackupMutex.Lock()
+	defer instance.onlineBackupMutex.Unlock()
+	defer session.close()
+
+	if err != nil {
+		log.Error(err, "while stopping the online backup", "backupName", session.status.BackupName)
+		session.status.Phase = postgres.OnlineBackupPhaseFailed
+		session.status.Error = err.Error()
+		return
+	}
+
+	log.Info("Online backup stopped", "backupName", session.status.BackupName, "endLSN", result.EndLSN)
+	session.status.Phase = postgres.OnlineBackupPhaseCompleted
+	session.status.EndLSN = result.EndLSN
+	session.status.BackupLabelFile = result.BackupLabelFile
+	session.status.TablespaceMapFile = result.TablespaceMapFile
+}
+
+// startOnlineBackup starts a non-exclusive online backup in the passed
+// session, returning the LSN where the backup starts
+func startOnlineBackup(
+	ctx context.Context,
+	conn *sql.Conn,
+	pgMajorVersion uint64,
+	label string,
+	immediateCheckpoint bool,
+) (postgres.LSN, error) {
+	query := "SELECT pg_catalog.pg_backup_start(label => $1, fast => $2)"
+	if pgMajorVersion < 15 {
+		query = "SELECT pg_catalog.pg_start_backup(label => $1, fast => $2, exclusive => false)"
+	}
+
+	var beginLSN string
+	if err := conn.QueryRowContext(ctx, query, label, immediateCheckpoint).Scan(&beginLSN); err != nil {
+		return "", err
+	}
+
+	return postgres.LSN(beginLSN), nil
+}
+
+// stopOnlineBackup stops the non-exclusive online backup started in the
+// passed session, returning the LSN where the backup ends and the content
+// of the backup label and of the tablespace map
+func stopOnlineBackup(
+	ctx context.Context,
+	conn *sql.Conn,
+	pgMajorVersion uint64,
+	waitForArchive bool,
+) (*postgres.OnlineBackupStatus, error) {
+	query := "SELECT lsn, labelfile, spcmapfile FROM pg_catalog.pg_backup_stop(wait_for_archive => $1)"
+	if pgMajorVersion < 15 {
+		query = "SELECT lsn, labelfile, spcmapfile " +
+			"FROM pg_catalog.pg_stop_backup(exclusive => false, wait_for_archive => $1)"
+	}
+
+	var endLSN, labelFile string
+	var tablespaceMapFile sql.NullString
+	if err := conn.QueryRowContext(ctx, query, waitForArchive).Scan(&endLSN, &labelFile, &tablespaceMapFile); err != nil {
+		return nil, err
+	}
+
+	return &postgres.OnlineBackupStatus{
+		EndLSN:            postgres.LSN(endLSN),
+		BackupLabelFile:   []byte(labelFile),
+		TablespaceMapFile: []byte(tablespaceMapFile.String),
+	}, nil
+}
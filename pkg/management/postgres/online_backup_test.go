@@ -0,0 +1,143 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("online backups", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+		conn *sql.Conn
+	)
+
+	BeforeEach(func(ctx context.Context) {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		conn, err = db.Conn(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = conn.Close()
+			_ = db.Close()
+		})
+	})
+
+	It("starts the backup with pg_backup_start", func(ctx context.Context) {
+		mock.ExpectQuery("SELECT pg_catalog.pg_backup_start").
+			WithArgs("backup-example", true).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_backup_start"}).AddRow("0/2000028"))
+
+		beginLSN, err := startOnlineBackup(ctx, conn, 16, "backup-example", true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(beginLSN).To(Equal(postgres.LSN("0/2000028")))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("starts a non-exclusive backup before PostgreSQL 15", func(ctx context.Context) {
+		mock.ExpectQuery("SELECT pg_catalog.pg_start_backup\\(.*exclusive => false\\)").
+			WithArgs("backup-example", false).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_start_backup"}).AddRow("0/2000028"))
+
+		_, err := startOnlineBackup(ctx, conn, 14, "backup-example", false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("stops the backup collecting the backup label", func(ctx context.Context) {
+		mock.ExpectQuery("FROM pg_catalog.pg_backup_stop").
+			WithArgs(true).
+			WillReturnRows(sqlmock.NewRows([]string{"lsn", "labelfile", "spcmapfile"}).
+				AddRow("0/2000100", "START WAL LOCATION: 0/2000028", "16385 /tablespaces/tbs"))
+
+		result, err := stopOnlineBackup(ctx, conn, 16, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.EndLSN).To(Equal(postgres.LSN("0/2000100")))
+		Expect(string(result.BackupLabelFile)).To(Equal("START WAL LOCATION: 0/2000028"))
+		Expect(string(result.TablespaceMapFile)).To(Equal("16385 /tablespaces/tbs"))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("stops a backup without tablespaces before PostgreSQL 15", func(ctx context.Context) {
+		mock.ExpectQuery("FROM pg_catalog.pg_stop_backup\\(exclusive => false").
+			WithArgs(false).
+			WillReturnRows(sqlmock.NewRows([]string{"lsn", "labelfile", "spcmapfile"}).
+				AddRow("0/2000100", "START WAL LOCATION: 0/2000028", nil))
+
+		result, err := stopOnlineBackup(ctx, conn, 14, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.TablespaceMapFile).To(BeEmpty())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("refuses to stop an unknown backup", func() {
+		instance := &Instance{}
+		err := instance.StopOnlineBackup(postgres.OnlineBackupRequest{
+			Action:     postgres.OnlineBackupActionStop,
+			BackupName: "backup-example",
+		})
+		Expect(err).To(MatchError(ErrOnlineBackupNotFound))
+		Expect(instance.GetOnlineBackupStatus()).To(BeNil())
+	})
+
+	It("stops a backup still starting once it is started", func() {
+		instance := &Instance{
+			onlineBackup: &onlineBackupSession{
+				status: postgres.OnlineBackupStatus{
+					BackupName: "backup-example",
+					Phase:      postgres.OnlineBackupPhaseStarting,
+				},
+			},
+		}
+
+		Expect(instance.StopOnlineBackup(postgres.OnlineBackupRequest{
+			Action:         postgres.OnlineBackupActionStop,
+			BackupName:     "backup-example",
+			WaitForArchive: true,
+		})).To(Succeed())
+		Expect(instance.onlineBackup.stopRequested).To(BeTrue())
+		Expect(instance.onlineBackup.waitForArchive).To(BeTrue())
+		Expect(instance.GetOnlineBackupStatus().Phase).To(Equal(postgres.OnlineBackupPhaseStarting))
+	})
+
+	It("refuses to start a backup while another one is in progress", func() {
+		instance := &Instance{
+			onlineBackup: &onlineBackupSession{
+				status: postgres.OnlineBackupStatus{
+					BackupName: "backup-other",
+					Phase:      postgres.OnlineBackupPhaseStarted,
+				},
+			},
+		}
+
+		err := instance.StartOnlineBackup(postgres.OnlineBackupRequest{
+			Action:     postgres.OnlineBackupActionStart,
+			BackupName: "backup-example",
+		})
+		Expect(err).To(MatchError(ErrOnlineBackupInProgress))
+	})
+})
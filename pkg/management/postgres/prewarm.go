@@ -0,0 +1,96 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// errPrewarmUnavailable is raised when the pg_prewarm extension is not
+// installed in the database containing the relations to be loaded
+var errPrewarmUnavailable = errors.New("the pg_prewarm extension is not installed")
+
+// StartPrewarm loads, in background, the configured relations in the shared
+// buffers through pg_prewarm. The relations are loaded once in the life of
+// the instance manager, and the calls following the first one are no-ops
+func (instance *Instance) StartPrewarm(configuration *apiv1.PrewarmConfiguration) {
+	if configuration == nil || !instance.prewarmStarted.CompareAndSwap(false, true) {
+		return
+	}
+
+	// loading big relations may take a long time
+	go func() {
+		db, err := instance.ConnectionPool().Connection(configuration.Database)
+		if err != nil {
+			log.Warning("Cannot connect to the database to prewarm, skipping",
+				"database", configuration.Database, "err", err.Error())
+			return
+		}
+
+		blocks, err := prewarmRelations(context.Background(), db, configuration.Relations)
+		switch {
+		case errors.Is(err, errPrewarmUnavailable):
+			log.Warning("The pg_prewarm extension is not installed, skipping the prewarm",
+				"database", configuration.Database)
+		case err != nil:
+			log.Warning("Error while prewarming the relations",
+				"database", configuration.Database, "err", err.Error())
+		default:
+			log.Info("Relations loaded in the shared buffers",
+				"database", configuration.Database, "blocks", blocks)
+		}
+	}()
+}
+
+// prewarmRelations loads the passed relations in the shared buffers, skipping
+// the ones which cannot be loaded, and returns the number of blocks loaded
+func prewarmRelations(ctx context.Context, db *sql.DB, relations []string) (int64, error) {
+	var installed bool
+	if err := db.QueryRowContext(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_extension WHERE extname = 'pg_prewarm')",
+	).Scan(&installed); err != nil {
+		return 0, err
+	}
+	if !installed {
+		return 0, errPrewarmUnavailable
+	}
+
+	var total int64
+	for _, relation := range relations {
+		var blocks int64
+		if err := db.QueryRowContext(
+			ctx,
+			"SELECT pg_prewarm($1::regclass)",
+			relation,
+		).Scan(&blocks); err != nil {
+			log.Warning("Cannot prewarm the relation, skipping",
+				"relation", relation, "err", err.Error())
+			continue
+		}
+
+		log.Debug("Relation loaded in the shared buffers", "relation", relation, "blocks", blocks)
+		total += blocks
+	}
+
+	return total, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("prewarm", func() {
+	const extensionQuery = "SELECT EXISTS \\(SELECT 1 FROM pg_catalog.pg_extension WHERE extname = 'pg_prewarm'\\)"
+	const prewarmQuery = "SELECT pg_prewarm\\(\\$1::regclass\\)"
+
+	It("loads the relations in the shared buffers", func(ctx context.Context) {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		defer func() {
+			_ = db.Close()
+		}()
+
+		mock.ExpectQuery(extensionQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery(prewarmQuery).WithArgs("public.orders").
+			WillReturnRows(sqlmock.NewRows([]string{"pg_prewarm"}).AddRow(120))
+		mock.ExpectQuery(prewarmQuery).WithArgs("public.orders_pkey").
+			WillReturnRows(sqlmock.NewRows([]string{"pg_prewarm"}).AddRow(30))
+
+		blocks, err := prewarmRelations(ctx, db, []string{"public.orders", "public.orders_pkey"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blocks).To(BeEquivalentTo(150))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("skips the relations which cannot be loaded", func(ctx context.Context) {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		defer func() {
+			_ = db.Close()
+		}()
+
+		mock.ExpectQuery(extensionQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectQuery(prewarmQuery).WithArgs("missing").
+			WillReturnError(errors.New(`relation "missing" does not exist`))
+		mock.ExpectQuery(prewarmQuery).WithArgs("public.orders").
+			WillReturnRows(sqlmock.NewRows([]string{"pg_prewarm"}).AddRow(120))
+
+		blocks, err := prewarmRelations(ctx, db, []string{"missing", "public.orders"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blocks).To(BeEquivalentTo(120))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("doesn't load anything when pg_prewarm is not installed", func(ctx context.Context) {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		defer func() {
+			_ = db.Close()
+		}()
+
+		mock.ExpectQuery(extensionQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+		_, err = prewarmRelations(ctx, db, []string{"public.orders"})
+		Expect(err).To(MatchError(errPrewarmUnavailable))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+})
@@ -59,15 +59,47 @@ func (instance *Instance) IsServerReady() error {
 		return err
 	}
 
-	return superUserDB.Ping()
+	if err := superUserDB.Ping(); err != nil {
+		return err
+	}
+
+	if instance.readinessRequiresStreaming.Load() {
+		if err := checkWALReceiverStreaming(superUserDB); err != nil {
+			return err
+		}
+	}
+
+	if instance.IsReplicaClusterCatchingUp() {
+		return fmt.Errorf("the designated primary is catching up with the source")
+	}
+
+	return nil
+}
+
+// checkWALReceiverStreaming checks whether the WAL receiver of the
+// designated primary of a replica cluster is streaming from the source
+func checkWALReceiverStreaming(db *sql.DB) error {
+	active, err := isWALReceiverActive(db)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return fmt.Errorf("the designated primary is not streaming from the source")
+	}
+
+	return nil
 }
 
 // GetStatus Extract the status of this PostgreSQL database
 func (instance *Instance) GetStatus() (result *postgres.PostgresqlStatus, err error) {
 	result = &postgres.PostgresqlStatus{
-		Pod:                    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: instance.PodName}},
-		InstanceManagerVersion: versions.Version,
-		MightBeUnavailable:     instance.MightBeUnavailable(),
+		Pod:                        &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: instance.PodName}},
+		InstanceManagerVersion:     versions.Version,
+		MightBeUnavailable:         instance.MightBeUnavailable(),
+		IsReplicaStreamingPaused:   instance.IsReplicaStreamingPaused(),
+		ActiveReplicaSource:        instance.GetActiveReplicaSource(),
+		SourceStatus:               instance.GetSourceStatus(),
+		IsReplicaClusterCatchingUp: instance.IsReplicaClusterCatchingUp(),
 	}
 
 	// this deferred function may override the error returned. Take extra care.
@@ -497,19 +529,40 @@ func (instance *Instance) fillStatusFromReplica(result *postgres.PostgresqlStatu
 	return nil
 }
 
+// IsWALReplayPaused checks whether the replay of the WAL is paused, either
+// on request or because the recovery target has been reached
+func (instance *Instance) IsWALReplayPaused() (bool, error) {
+	superUserDB, err := instance.GetSuperUserDB()
+	if err != nil {
+		return false, err
+	}
+
+	var paused bool
+	if err := superUserDB.QueryRow("SELECT pg_is_wal_replay_paused()").Scan(&paused); err != nil {
+		return false, err
+	}
+
+	return paused, nil
+}
+
 // IsWALReceiverActive check if the WAL receiver process is active by looking
 // at the number of records in the `pg_stat_wal_receiver` table
 func (instance *Instance) IsWALReceiverActive() (bool, error) {
-	var result bool
-
 	superUserDB, err := instance.GetSuperUserDB()
 	if err != nil {
 		return false, err
 	}
 
-	row := superUserDB.QueryRow("SELECT COUNT(*) FROM pg_stat_wal_receiver")
-	err = row.Scan(&result)
-	if err != nil {
+	return isWALReceiverActive(superUserDB)
+}
+
+// isWALReceiverActive check if the WAL receiver process is active, using
+// the passed connection
+func isWALReceiverActive(db *sql.DB) (bool, error) {
+	var result bool
+
+	row := db.QueryRow("SELECT COUNT(*) FROM pg_stat_wal_receiver")
+	if err := row.Scan(&result); err != nil {
 		return false, err
 	}
 
@@ -86,4 +86,19 @@ var _ = Describe("probes", func() {
 		Expect(status.LastFailedWALTime).To(Equal("2021-05-05 12:00:00"))
 		Expect(status.IsArchivingWAL).To(BeFalse())
 	})
+
+	It("checks whether the WAL receiver is streaming", func() {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM pg_stat_wal_receiver").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		Expect(checkWALReceiverStreaming(db)).To(Succeed())
+
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM pg_stat_wal_receiver").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		Expect(checkWALReceiverStreaming(db)).To(MatchError(ContainSubstring("not streaming")))
+
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
 })
@@ -78,6 +78,32 @@ var (
 	}
 )
 
+// writeBackupLabel writes the backup label and the tablespace map of a
+// volume snapshot taken online in the data directory, for the recovery
+// to start from the beginning of the backup and to reach its end
+func (info InitInfo) writeBackupLabel() error {
+	if len(info.BackupLabelFile) == 0 {
+		return nil
+	}
+
+	log.Info("Writing the backup label of the volume snapshot taken online")
+	if _, err := fileutils.WriteFileAtomic(
+		path.Join(info.PgData, "backup_label"), info.BackupLabelFile, 0o600); err != nil {
+		return fmt.Errorf("while writing the backup label: %w", err)
+	}
+
+	if len(info.TablespaceMapFile) == 0 {
+		return nil
+	}
+
+	if _, err := fileutils.WriteFileAtomic(
+		path.Join(info.PgData, "tablespace_map"), info.TablespaceMapFile, 0o600); err != nil {
+		return fmt.Errorf("while writing the tablespace map: %w", err)
+	}
+
+	return nil
+}
+
 // RestoreSnapshot restores a PostgreSQL cluster from a volumeSnapshot
 func (info InitInfo) RestoreSnapshot(ctx context.Context, cli client.Client) error {
 	cluster, err := info.loadCluster(ctx, cli)
@@ -90,11 +116,18 @@ func (info InitInfo) RestoreSnapshot(ctx context.Context, cli client.Client) err
 		return err
 	}
 
-	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
-		cluster.Spec.Bootstrap.Recovery.Source == "" {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	if err := info.writeBackupLabel(); err != nil {
+		return err
+	}
+
+	if cluster.Spec.Bootstrap.Recovery.Source == "" {
 		// We are recovering from an existing PVC snapshot, we
 		// don't need to invoke the recovery job
-		return nil
+		return info.prepareSnapshotForPostRestore(ctx, cli, cluster)
 	}
 
 	log.Info("Recovering from volume snapshot",
@@ -122,14 +155,23 @@ func (info InitInfo) RestoreSnapshot(ctx context.Context, cli client.Client) err
 			return fmt.Errorf("missing external cluster: %v", cluster.Spec.ReplicaCluster.Source)
 		}
 
-		connectionString, _, err := external.ConfigureConnectionToServer(
+		connectionString, pgpass, err := external.ConfigureConnectionToServer(
 			ctx, cli, info.Namespace, &server)
 		if err != nil {
 			return err
 		}
 
+		if err := info.ValidateSourceDataChecksums(ctx, cluster, withPassfile(connectionString, pgpass)); err != nil {
+			return err
+		}
+
 		// TODO: Using a replication slot on replica cluster is not supported (yet?)
-		_, err = UpdateReplicaConfiguration(info.PgData, connectionString, "")
+		if _, err = UpdateReplicaConfiguration(info.PgData, connectionString, "",
+			cluster.Spec.ReplicaCluster.GetTargetTimeline()); err != nil {
+			return err
+		}
+
+		_, err = UpdateReplicaRecoveryTarget(info.PgData, cluster.GetPendingReplicaRecoveryTarget())
 		return err
 	}
 
@@ -141,7 +183,42 @@ func (info InitInfo) RestoreSnapshot(ctx context.Context, cli client.Client) err
 		return err
 	}
 
-	return info.ConfigureInstanceAfterRestore(ctx, cluster, env)
+	if err := info.ConfigureInstanceAfterRestore(ctx, cluster, env); err != nil {
+		return err
+	}
+
+	return info.executePostRestoreApplicationSQL(ctx, cli, cluster, env)
+}
+
+// prepareSnapshotForPostRestore starts the instance restored from a PVC
+// snapshot, without a recovery source, only when post-restore queries
+// need to be executed
+func (info InitInfo) prepareSnapshotForPostRestore(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+) error {
+	if len(cluster.Spec.Bootstrap.Recovery.PostRestoreApplicationSQL) == 0 {
+		return nil
+	}
+
+	if err := removeSignalFiles(info.PgData); err != nil {
+		return fmt.Errorf("error while cleaning up the signal files: %w", err)
+	}
+
+	if _, err := info.restoreCustomWalDir(ctx); err != nil {
+		return err
+	}
+
+	if err := info.WriteInitialPostgresqlConf(cluster); err != nil {
+		return err
+	}
+
+	if err := info.WriteRestoreHbaConf(); err != nil {
+		return err
+	}
+
+	return info.executePostRestoreApplicationSQL(ctx, cli, cluster, nil)
 }
 
 // createBackupObjectForSnapshotRestore creates a fake Backup object that can be used during the
@@ -214,6 +291,19 @@ func (info InitInfo) Restore(ctx context.Context) error {
 		info.ApplicationDatabase = cluster.GetApplicationDatabaseName()
 	}
 
+	// The bootstrap of a replica cluster can be resumed from the PGDATA
+	// left by an interrupted attempt, as the missing WALs will be fetched
+	// from the source once the instance is started
+	resumed := false
+	if cluster.IsReplica() {
+		resumed, err = info.ResumeOrCleanPartialBootstrap(ctx, string(cluster.UID))
+	} else {
+		err = info.VerifyPGData()
+	}
+	if err != nil {
+		return err
+	}
+
 	// Before starting the restore we check if the archive destination is safe to use
 	// otherwise, we stop creating the cluster
 	err = info.checkBackupDestination(ctx, typedClient, cluster)
@@ -227,12 +317,20 @@ func (info InitInfo) Restore(ctx context.Context) error {
 		return err
 	}
 
-	if err := info.ensureArchiveContainsLastCheckpointRedoWAL(ctx, cluster, env, backup); err != nil {
-		return err
-	}
+	if !resumed {
+		if err := info.ensureArchiveContainsLastCheckpointRedoWAL(ctx, cluster, env, backup); err != nil {
+			return err
+		}
 
-	if err := info.restoreDataDir(backup, env); err != nil {
-		return err
+		if err := info.restoreDataDir(backup, env); err != nil {
+			return err
+		}
+
+		if cluster.IsReplica() {
+			if err := info.MarkBaseBackupCompleted(); err != nil {
+				return err
+			}
+		}
 	}
 
 	if _, err := info.restoreCustomWalDir(ctx); err != nil {
@@ -249,15 +347,27 @@ func (info InitInfo) Restore(ctx context.Context) error {
 			return fmt.Errorf("missing external cluster: %v", cluster.Spec.ReplicaCluster.Source)
 		}
 
-		connectionString, _, err := external.ConfigureConnectionToServer(
+		connectionString, pgpass, err := external.ConfigureConnectionToServer(
 			ctx, typedClient, info.Namespace, &server)
 		if err != nil {
 			return err
 		}
 
+		if err := info.ValidateSourceDataChecksums(ctx, cluster, withPassfile(connectionString, pgpass)); err != nil {
+			return err
+		}
+
 		// TODO: Using a replication slot on replica cluster is not supported (yet?)
-		_, err = UpdateReplicaConfiguration(info.PgData, connectionString, "")
-		return err
+		if _, err = UpdateReplicaConfiguration(info.PgData, connectionString, "",
+			cluster.Spec.ReplicaCluster.GetTargetTimeline()); err != nil {
+			return err
+		}
+
+		if _, err = UpdateReplicaRecoveryTarget(info.PgData, cluster.GetPendingReplicaRecoveryTarget()); err != nil {
+			return err
+		}
+
+		return info.RemoveBootstrapMarks()
 	}
 
 	if err := info.WriteRestoreHbaConf(); err != nil {
@@ -785,7 +895,7 @@ func (info InitInfo) ConfigureInstanceAfterRestore(ctx context.Context, cluster
 	if majorVersion >= 12 {
 		primaryConnInfo := info.GetPrimaryConnInfo()
 		slotName := cluster.GetSlotNameFromInstanceName(info.PodName)
-		_, err = configurePostgresAutoConfFile(info.PgData, primaryConnInfo, slotName)
+		_, err = configurePostgresAutoConfFile(info.PgData, primaryConnInfo, slotName, "")
 		if err != nil {
 			return fmt.Errorf("while configuring replica: %w", err)
 		}
@@ -807,6 +917,94 @@ func (info InitInfo) ConfigureInstanceAfterRestore(ctx context.Context, cluster
 	})
 }
 
+// executePostRestoreApplicationSQL starts the restored instance and executes the
+// post-restore queries in the application database. If they fail, the cluster
+// is kept in the post-restore failed phase, waiting for a manual intervention
+func (info InitInfo) executePostRestoreApplicationSQL(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+	env []string,
+) error {
+	contextLogger := log.FromContext(ctx)
+
+	queries := cluster.Spec.Bootstrap.Recovery.PostRestoreApplicationSQL
+	if len(queries) == 0 {
+		return nil
+	}
+
+	databaseName := cluster.GetApplicationDatabaseName()
+	if databaseName == "" {
+		databaseName = apiv1.DefaultApplicationDatabaseName
+	}
+
+	instance := info.GetInstance()
+	instance.Env = env
+
+	contextLogger.Info("Executing post-restore SQL instructions", "database", databaseName)
+	err := instance.WithActiveInstance(func() error {
+		db, err := instance.GetSuperUserDB()
+		if err != nil {
+			return err
+		}
+
+		if err := waitUntilRecoveryFinishes(db); err != nil {
+			return fmt.Errorf("while waiting for PostgreSQL to stop recovery mode: %w", err)
+		}
+
+		appDB, err := instance.ConnectionPool().Connection(databaseName)
+		if err != nil {
+			return fmt.Errorf("could not get connection to ApplicationDatabase: %w", err)
+		}
+
+		return executePostRestoreQueries(appDB, queries)
+	})
+	if err == nil {
+		return nil
+	}
+
+	contextLogger.Error(err, "while executing the post-restore SQL instructions")
+	if phaseErr := registerPostRestoreFailure(ctx, cli, cluster, err); phaseErr != nil {
+		contextLogger.Error(phaseErr, "while registering the post-restore failure")
+	}
+
+	return err
+}
+
+// executePostRestoreQueries runs the post-restore queries in a single
+// transaction, so that a failure leaves the restored data untouched
+func executePostRestoreQueries(db *sql.DB, queries []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for idx, sqlQuery := range queries {
+		log.Debug("Executing post-restore query", "sqlQuery", sqlQuery)
+		if _, err := tx.Exec(sqlQuery); err != nil {
+			return fmt.Errorf("while executing post-restore query #%d: %w", idx, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// registerPostRestoreFailure moves the cluster into the post-restore failed phase
+func registerPostRestoreFailure(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+	postRestoreErr error,
+) error {
+	origCluster := cluster.DeepCopy()
+	cluster.Status.Phase = apiv1.PhasePostRestoreFailed
+	cluster.Status.PhaseReason = postRestoreErr.Error()
+	return cli.Status().Patch(ctx, cluster, client.MergeFrom(origCluster))
+}
+
 // GetPrimaryConnInfo returns the DSN to reach the primary
 func (info InitInfo) GetPrimaryConnInfo() string {
 	return buildPrimaryConnInfo(info.ClusterName+"-rw", info.PodName)
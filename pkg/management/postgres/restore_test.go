@@ -18,12 +18,19 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/thoas/go-funk"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/strings/slices"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -148,3 +155,106 @@ var _ = Describe("testing restore InitInfo methods", func() {
 		Expect(chg).To(BeFalse())
 	})
 })
+
+var _ = Describe("post-restore SQL", func() {
+	queries := []string{
+		"UPDATE customers SET email = md5(email)",
+		"DELETE FROM sessions",
+	}
+
+	It("executes the queries in a single transaction", func() {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE customers SET email = md5\\(email\\)").
+			WillReturnResult(sqlmock.NewResult(0, 10))
+		mock.ExpectExec("DELETE FROM sessions").
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+
+		Expect(executePostRestoreQueries(db, queries)).To(Succeed())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("rolls back the transaction when a query fails", func() {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE customers SET email = md5\\(email\\)").
+			WillReturnError(errors.New("relation \"customers\" does not exist"))
+		mock.ExpectRollback()
+
+		err = executePostRestoreQueries(db, queries)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("post-restore query #0"))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("moves the cluster into the post-restore failed phase", func(ctx context.Context) {
+		cluster := &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "default"},
+			Status: apiv1.ClusterStatus{
+				Phase: apiv1.PhaseFirstPrimary,
+			},
+		}
+		cli := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster).
+			WithStatusSubresource(cluster).
+			Build()
+
+		Expect(registerPostRestoreFailure(ctx, cli, cluster, errors.New("query failed"))).To(Succeed())
+
+		var updatedCluster apiv1.Cluster
+		Expect(cli.Get(ctx, client.ObjectKeyFromObject(cluster), &updatedCluster)).To(Succeed())
+		Expect(updatedCluster.Status.Phase).To(Equal(apiv1.PhasePostRestoreFailed))
+		Expect(updatedCluster.Status.PhaseReason).To(Equal("query failed"))
+	})
+
+	It("does nothing when no post-restore query is configured", func(ctx context.Context) {
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{},
+				},
+			},
+		}
+		Expect(InitInfo{}.executePostRestoreApplicationSQL(ctx, nil, cluster, nil)).To(Succeed())
+	})
+})
+
+var _ = Describe("backup label of a volume snapshot taken online", func() {
+	var pgData string
+
+	BeforeEach(func() {
+		pgData = GinkgoT().TempDir()
+	})
+
+	It("writes the backup label and the tablespace map in the data directory", func() {
+		info := InitInfo{
+			PgData:            pgData,
+			BackupLabelFile:   []byte("START WAL LOCATION: 0/2000028"),
+			TablespaceMapFile: []byte("16385 /tablespaces/tbs"),
+		}
+		Expect(info.writeBackupLabel()).To(Succeed())
+
+		backupLabel, err := fileutils.ReadFile(path.Join(pgData, "backup_label"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(backupLabel)).To(Equal("START WAL LOCATION: 0/2000028"))
+
+		tablespaceMap, err := fileutils.ReadFile(path.Join(pgData, "tablespace_map"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(tablespaceMap)).To(Equal("16385 /tablespaces/tbs"))
+	})
+
+	It("writes nothing for a snapshot taken offline", func() {
+		info := InitInfo{PgData: pgData}
+		Expect(info.writeBackupLabel()).To(Succeed())
+
+		exists, err := fileutils.FileExists(path.Join(pgData, "backup_label"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exists).To(BeFalse())
+	})
+})
@@ -0,0 +1,277 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+)
+
+// SourceProbeInterval is the interval at which the designated primary of a
+// replica cluster probes its source, when required by the configuration
+const SourceProbeInterval = 30 * time.Second
+
+// sourceProbeTarget identifies what needs to be probed on the source of a
+// replica cluster. The outcome of a probe is only applied to the same target
+type sourceProbeTarget struct {
+	// connectionString is used to connect to the source
+	connectionString string
+
+	// orderHosts is true when the hosts of the source need to be ordered
+	// by health
+	orderHosts bool
+
+	// checkReachability is true when the streaming is paused while the
+	// source is not reachable
+	checkReachability bool
+
+	// reportStatus is true when the status of the source is reported
+	reportStatus bool
+
+	// fallbackSources are the comma-separated names of the sources the
+	// designated primary falls back to, empty when there are none
+	fallbackSources string
+}
+
+// sourceProbeConfiguration tells how the source of a replica cluster needs
+// to be probed by its designated primary
+type sourceProbeConfiguration struct {
+	target sourceProbeTarget
+
+	// server is the external cluster used as the source
+	server apiv1.ExternalCluster
+
+	// fallbacks are the sources the designated primary falls back to,
+	// in order, when the source is not reachable
+	fallbacks []sourceProbeFallback
+}
+
+// sourceProbeFallback is a source the designated primary of a replica
+// cluster falls back to
+type sourceProbeFallback struct {
+	// server is the external cluster used as the fallback source
+	server apiv1.ExternalCluster
+
+	// connectionString is used to connect to the fallback source
+	connectionString string
+}
+
+// sourceProbeResult is the outcome of the probe of the source of a replica
+// cluster
+type sourceProbeResult struct {
+	// target is what has been probed
+	target sourceProbeTarget
+
+	// orderedServer is the source with the reachable hosts moved first,
+	// nil when the order of the hosts doesn't need to change
+	orderedServer *apiv1.ExternalCluster
+
+	// reachable is true when a connection to the source could be established
+	reachable bool
+
+	// reachableSource is the name of the first reachable source among the
+	// source and its fallbacks, empty when none is reachable or there are
+	// no fallbacks
+	reachableSource string
+
+	// status is the status of the source, nil when not requested
+	status *postgres.SourceStatus
+}
+
+// ReplicaClusterLag is how far the designated primary of a replica cluster
+// is behind its source, as measured by the last probe of the source
+type ReplicaClusterLag struct {
+	// Source is the name of the external cluster used as the source
+	Source string
+
+	// Bytes is the amount of WAL the designated primary still needs
+	// to replay to reach the current LSN of the source
+	Bytes int64
+}
+
+// getReplayLSN gets the last WAL location replayed by the passed instance.
+// It is a variable to allow the unit tests to replace it
+var getReplayLSN = func(ctx context.Context, instance *Instance) (postgres.LSN, error) {
+	db, err := instance.GetSuperUserDB()
+	if err != nil {
+		return "", err
+	}
+
+	var replayLSN postgres.LSN
+	row := db.QueryRowContext(ctx, "SELECT COALESCE(pg_last_wal_replay_lsn(), '0/0')::text")
+	if err := row.Scan(&replayLSN); err != nil {
+		return "", err
+	}
+
+	return replayLSN, nil
+}
+
+// getReplicaClusterLag computes the lag of the designated primary behind
+// its source, given the status of the source and the LSN replayed locally.
+// Returns nil when the source is not reachable or the lag can't be computed
+func getReplicaClusterLag(
+	sourceName string,
+	status *postgres.SourceStatus,
+	replayLSN postgres.LSN,
+) *ReplicaClusterLag {
+	if status == nil || !status.Reachable {
+		return nil
+	}
+
+	source, err := status.CurrentLsn.Parse()
+	if err != nil {
+		return nil
+	}
+
+	replay, err := replayLSN.Parse()
+	if err != nil {
+		return nil
+	}
+
+	lag := &ReplicaClusterLag{Source: sourceName}
+	if source > replay {
+		lag.Bytes = source - replay
+	}
+
+	return lag
+}
+
+// GetReplicaClusterLag returns how far the designated primary of a replica
+// cluster is behind its source, nil if not measured
+func (instance *Instance) GetReplicaClusterLag() *ReplicaClusterLag {
+	return instance.replicaClusterLag.Load()
+}
+
+// IsSourceProbeEnabled checks whether the source of the replica cluster
+// needs to be periodically probed by this instance
+func (instance *Instance) IsSourceProbeEnabled() bool {
+	return instance.sourceProbeConfiguration.Load() != nil
+}
+
+// ProbeSource probes the source of the replica cluster as required by the
+// last refresh of the replica configuration, and stores the outcome to be
+// applied by the following refresh. The probe opens blocking connections,
+// so it is meant to run outside the reconciliation loop
+func (instance *Instance) ProbeSource(ctx context.Context) {
+	config := instance.sourceProbeConfiguration.Load()
+	if config == nil {
+		instance.sourceProbeResult.Store(nil)
+		instance.replicaClusterLag.Store(nil)
+		return
+	}
+
+	result := &sourceProbeResult{target: config.target}
+	if config.target.orderHosts {
+		if orderedServer, reordered := orderSourceHostsByHealth(
+			ctx, config.target.connectionString, config.server); reordered {
+			result.orderedServer = &orderedServer
+		}
+	}
+	if config.target.checkReachability || len(config.fallbacks) > 0 {
+		result.reachable = isSourceReachable(ctx, config.target.connectionString)
+	}
+	if len(config.fallbacks) > 0 {
+		result.reachableSource = getFirstReachableSource(ctx, config, result.reachable)
+	}
+	if config.target.reportStatus {
+		result.status = probeSource(ctx, config.target.connectionString)
+		instance.measureReplicaClusterLag(ctx, config.server.Name, result.status)
+	} else {
+		instance.replicaClusterLag.Store(nil)
+	}
+
+	instance.sourceProbeResult.Store(result)
+}
+
+// getFirstReachableSource gets the name of the first reachable source
+// among the source and its fallbacks, in order, given whether the source
+// is reachable. Returns an empty string when none is reachable
+func getFirstReachableSource(ctx context.Context, config *sourceProbeConfiguration, sourceReachable bool) string {
+	if sourceReachable {
+		return config.server.Name
+	}
+
+	for _, fallback := range config.fallbacks {
+		if isSourceReachable(ctx, fallback.connectionString) {
+			return fallback.server.Name
+		}
+	}
+
+	return ""
+}
+
+// measureReplicaClusterLag stores the lag of this instance behind the
+// source whose status has just been probed. The lag is exported as a
+// metric, and gates the readiness of the instance when requested. While
+// the lag can't be measured, the last readiness decision is kept
+func (instance *Instance) measureReplicaClusterLag(
+	ctx context.Context,
+	sourceName string,
+	status *postgres.SourceStatus,
+) {
+	if status == nil || !status.Reachable {
+		instance.replicaClusterLag.Store(nil)
+		return
+	}
+
+	replayLSN, err := getReplayLSN(ctx, instance)
+	if err != nil {
+		log.FromContext(ctx).Debug("Cannot get the replayed LSN, not measuring the replica cluster lag",
+			"err", err.Error())
+		instance.replicaClusterLag.Store(nil)
+		return
+	}
+
+	lag := getReplicaClusterLag(sourceName, status, replayLSN)
+	instance.replicaClusterLag.Store(lag)
+	if lag != nil {
+		instance.replicaClusterCaughtUp.Store(lag.Bytes <= instance.readinessMaxLagBytes.Load())
+	}
+}
+
+// IsReplicaClusterCatchingUp checks whether this instance is the designated
+// primary of a replica cluster whose readiness depends on its lag behind
+// the source, and which is too far behind it, or its lag has not been
+// measured yet
+func (instance *Instance) IsReplicaClusterCatchingUp() bool {
+	return instance.readinessMaxLagBytes.Load() > 0 && !instance.replicaClusterCaughtUp.Load()
+}
+
+// configureSourceProbe sets how the source needs to be probed, nil if it
+// doesn't need to
+func (instance *Instance) configureSourceProbe(config *sourceProbeConfiguration) {
+	instance.sourceProbeConfiguration.Store(config)
+	if config == nil {
+		instance.sourceProbeResult.Store(nil)
+		instance.replicaClusterLag.Store(nil)
+	}
+}
+
+// getSourceProbeResult returns the last outcome of the probe of the passed
+// target, nil if it has not been probed yet
+func (instance *Instance) getSourceProbeResult(target sourceProbeTarget) *sourceProbeResult {
+	result := instance.sourceProbeResult.Load()
+	if result == nil || result.target != target {
+		return nil
+	}
+
+	return result
+}
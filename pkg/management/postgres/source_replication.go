@@ -0,0 +1,92 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+)
+
+// ErrNotStreamingFromSource is raised when the source of the replica cluster
+// is queried by an instance which is not streaming from it
+var ErrNotStreamingFromSource = errors.New("the instance is not streaming from the source of the replica cluster")
+
+// replicaSourceConnection is the connection the designated primary
+// of a replica cluster uses to stream from its source
+type replicaSourceConnection struct {
+	connectionString string
+	slotName         string
+	applicationName  string
+}
+
+// GetSourceReplicationStatus queries the source of the replica cluster
+// for the position of this designated primary, as reported by the
+// pg_stat_replication view of the source
+func (instance *Instance) GetSourceReplicationStatus(ctx context.Context) (*apiv1.SourceReplicationStatus, error) {
+	connection := instance.replicaSourceConnection.Load()
+	if connection == nil {
+		return nil, ErrNotStreamingFromSource
+	}
+
+	db, err := sql.Open("pgx", connection.connectionString+" connect_timeout=5")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	return getSourceReplicationStatus(ctx, db, connection.slotName, connection.applicationName)
+}
+
+// getSourceReplicationStatus finds, in the pg_stat_replication view of the
+// source, the WAL sender of the designated primary. The designated primary
+// is recognized by its replication slot, or by its application name when
+// the slot is not in use
+func getSourceReplicationStatus(
+	ctx context.Context,
+	db *sql.DB,
+	slotName string,
+	applicationName string,
+) (*apiv1.SourceReplicationStatus, error) {
+	row := db.QueryRowContext(ctx,
+		`SELECT
+			COALESCE(r.sent_lsn::text, ''),
+			COALESCE(r.flush_lsn::text, ''),
+			COALESCE(r.replay_lsn::text, '')
+		FROM pg_catalog.pg_stat_replication r
+		LEFT JOIN pg_catalog.pg_replication_slots s ON s.active_pid = r.pid
+		WHERE s.slot_name = $1 OR r.application_name = $2
+		ORDER BY s.slot_name IS NULL
+		LIMIT 1`,
+		slotName, applicationName)
+
+	var status apiv1.SourceReplicationStatus
+	err := row.Scan(&status.SentLSN, &status.FlushLSN, &status.ReplayLSN)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: no WAL sender found in the source", ErrNotStreamingFromSource)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
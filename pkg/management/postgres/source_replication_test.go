@@ -0,0 +1,122 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("position of the designated primary as seen by the source", func() {
+	columns := []string{"sent_lsn", "flush_lsn", "replay_lsn"}
+
+	It("reads the WAL sender of the designated primary from a reachable source", func(ctx context.Context) {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+
+		mock.ExpectQuery("FROM pg_catalog.pg_stat_replication").
+			WithArgs("_cnpg_cluster_example_1", "cluster-example").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("0/7000148", "0/7000148", "0/7000060"))
+
+		status, err := getSourceReplicationStatus(ctx, db, "_cnpg_cluster_example_1", "cluster-example")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status).To(Equal(&apiv1.SourceReplicationStatus{
+			SentLSN:   "0/7000148",
+			FlushLSN:  "0/7000148",
+			ReplayLSN: "0/7000060",
+		}))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("fails when the designated primary is not streaming from the source", func(ctx context.Context) {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+
+		mock.ExpectQuery("FROM pg_catalog.pg_stat_replication").
+			WillReturnError(sql.ErrNoRows)
+
+		_, err = getSourceReplicationStatus(ctx, db, "_cnpg_cluster_example_1", "cluster-example")
+		Expect(err).To(MatchError(ErrNotStreamingFromSource))
+	})
+
+	It("knows the source connection only while streaming from it", func(ctx context.Context) {
+		tempDir, err := os.MkdirTemp("", "replica")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = os.RemoveAll(tempDir)
+		})
+
+		instance := &Instance{
+			PgData:  tempDir,
+			PodName: "cluster-example-1",
+		}
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "PG_VERSION"), "14")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "standby.signal"), "")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = fileutils.WriteStringToFile(filepath.Join(tempDir, "postgresql.auto.conf"), "")
+		Expect(err).ToNot(HaveOccurred())
+
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{
+					Source:  "source",
+					Enabled: true,
+				},
+				ExternalClusters: []apiv1.ExternalCluster{
+					{
+						Name: "source",
+						ConnectionParameters: map[string]string{
+							"host": "source-rw",
+							"user": "streaming_replica",
+						},
+					},
+				},
+			},
+			Status: apiv1.ClusterStatus{
+				TargetPrimary: "cluster-example-1",
+			},
+		}
+		cluster.Name = "cluster-example"
+
+		_, err = instance.GetSourceReplicationStatus(ctx)
+		Expect(err).To(MatchError(ErrNotStreamingFromSource))
+
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		connection := instance.replicaSourceConnection.Load()
+		Expect(connection).ToNot(BeNil())
+		Expect(connection.connectionString).To(ContainSubstring("source-rw"))
+		Expect(connection.slotName).To(Equal(cluster.GetSlotNameFromInstanceName("cluster-example-1")))
+		Expect(connection.applicationName).To(Equal("cluster-example"))
+
+		cluster.Status.TargetPrimary = "cluster-example-2"
+		_, err = instance.RefreshReplicaConfiguration(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instance.replicaSourceConnection.Load()).To(BeNil())
+	})
+})
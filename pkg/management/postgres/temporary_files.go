@@ -0,0 +1,84 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// ErrInstanceNotStopped is raised when the temporary files are requested
+// to be removed while PostgreSQL may be using them
+var ErrInstanceNotStopped = errors.New("the instance is not fenced, or PostgreSQL is still running")
+
+// CleanTemporaryFiles removes the content of PGDATA which is disposable
+// once PostgreSQL has been stopped: the temporary files of the queries,
+// including the ones in the tablespaces, and the temporary statistics.
+// To be safe, it only works on fenced instances whose PostgreSQL server
+// has been shut down
+func (instance *Instance) CleanTemporaryFiles() error {
+	if !instance.IsFenced() {
+		return ErrInstanceNotStopped
+	}
+
+	postmasterRunning, err := fileutils.FileExists(filepath.Join(instance.PgData, PostgresqlPidFile))
+	if err != nil {
+		return err
+	}
+	if postmasterRunning {
+		return ErrInstanceNotStopped
+	}
+
+	return cleanTemporaryFiles(instance.PgData)
+}
+
+// cleanTemporaryFiles empties the directories containing the temporary
+// files and statistics inside the passed PGDATA
+func cleanTemporaryFiles(pgData string) error {
+	directories := []string{
+		filepath.Join(pgData, "base", "pgsql_tmp"),
+		filepath.Join(pgData, "pg_stat_tmp"),
+	}
+
+	// Each tablespace has its own directory for temporary files,
+	// inside the version specific subdirectory
+	tablespaceDirectories, err := filepath.Glob(filepath.Join(pgData, "pg_tblspc", "*", "PG_*", "pgsql_tmp"))
+	if err != nil {
+		return err
+	}
+	directories = append(directories, tablespaceDirectories...)
+
+	for _, directory := range directories {
+		exists, err := fileutils.FileExists(directory)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+
+		log.Info("Removing temporary files", "directory", directory)
+		if err := fileutils.RemoveDirectoryContent(directory); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
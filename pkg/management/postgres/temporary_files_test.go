@@ -0,0 +1,87 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Temporary files cleanup", func() {
+	var (
+		instance *Instance
+		pgData   string
+	)
+
+	createFile := func(elements ...string) string {
+		fileName := filepath.Join(append([]string{pgData}, elements...)...)
+		Expect(os.MkdirAll(filepath.Dir(fileName), 0o700)).To(Succeed())
+		Expect(os.WriteFile(fileName, []byte("content"), 0o600)).To(Succeed())
+		return fileName
+	}
+
+	BeforeEach(func() {
+		pgData = GinkgoT().TempDir()
+		instance = &Instance{PgData: pgData}
+	})
+
+	It("removes only the temporary files and statistics", func() {
+		tempFile := createFile("base", "pgsql_tmp", "pgsql_tmp1234.0")
+		tempSet := createFile("base", "pgsql_tmp", "pgsql_tmp1234.1.fileset", "o0of1.p0.0")
+		statFile := createFile("pg_stat_tmp", "global.stat")
+		tablespaceTempFile := createFile("pg_tblspc", "16385", "PG_16_202307071", "pgsql_tmp", "pgsql_tmp42.0")
+		relationFile := createFile("base", "1", "1259")
+		tablespaceRelationFile := createFile("pg_tblspc", "16385", "PG_16_202307071", "5", "16386")
+		controlFile := createFile("global", "pg_control")
+
+		instance.SetFencing(true)
+		Expect(instance.CleanTemporaryFiles()).To(Succeed())
+
+		Expect(tempFile).ToNot(BeAnExistingFile())
+		Expect(filepath.Dir(tempSet)).ToNot(BeADirectory())
+		Expect(statFile).ToNot(BeAnExistingFile())
+		Expect(tablespaceTempFile).ToNot(BeAnExistingFile())
+
+		Expect(filepath.Join(pgData, "base", "pgsql_tmp")).To(BeADirectory())
+		Expect(filepath.Join(pgData, "pg_stat_tmp")).To(BeADirectory())
+		Expect(relationFile).To(BeAnExistingFile())
+		Expect(tablespaceRelationFile).To(BeAnExistingFile())
+		Expect(controlFile).To(BeAnExistingFile())
+	})
+
+	It("tolerates missing temporary directories", func() {
+		instance.SetFencing(true)
+		Expect(instance.CleanTemporaryFiles()).To(Succeed())
+	})
+
+	It("refuses to clean an instance which is not fenced", func() {
+		tempFile := createFile("base", "pgsql_tmp", "pgsql_tmp1234.0")
+		Expect(instance.CleanTemporaryFiles()).To(MatchError(ErrInstanceNotStopped))
+		Expect(tempFile).To(BeAnExistingFile())
+	})
+
+	It("refuses to clean an instance where PostgreSQL is still running", func() {
+		tempFile := createFile("base", "pgsql_tmp", "pgsql_tmp1234.0")
+		createFile(PostgresqlPidFile)
+		instance.SetFencing(true)
+		Expect(instance.CleanTemporaryFiles()).To(MatchError(ErrInstanceNotStopped))
+		Expect(tempFile).To(BeAnExistingFile())
+	})
+})
@@ -68,6 +68,7 @@ type metrics struct {
 	FencingOn                    prometheus.Gauge
 	PgStatWalMetrics             PgStatWalMetrics
 	NodesUsed                    prometheus.Gauge
+	ReplicaClusterLag            *prometheus.GaugeVec
 }
 
 // PgStatWalMetrics is available from PG14+
@@ -197,6 +198,12 @@ func newMetrics() *metrics {
 				"implying the absence of High Availability (HA). Ideally this value " +
 				"should match the number of instances in the cluster.",
 		}),
+		ReplicaClusterLag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: PrometheusNamespace,
+			Name:      "replica_cluster_lag_bytes",
+			Help: "Bytes of WAL the designated primary of a replica cluster needs to replay " +
+				"to reach the current LSN of its source",
+		}, []string{"source"}),
 		PgStatWalMetrics: PgStatWalMetrics{
 			WalRecords: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 				Namespace: PrometheusNamespace,
@@ -275,6 +282,7 @@ func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
 	e.Metrics.LastFailedBackupTimestamp.Describe(ch)
 	e.Metrics.LastAvailableBackupTimestamp.Describe(ch)
 	e.Metrics.NodesUsed.Describe(ch)
+	e.Metrics.ReplicaClusterLag.Describe(ch)
 
 	if e.queries != nil {
 		e.queries.Describe(ch)
@@ -313,6 +321,7 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 	e.Metrics.LastFailedBackupTimestamp.Collect(ch)
 	e.Metrics.LastAvailableBackupTimestamp.Collect(ch)
 	e.Metrics.NodesUsed.Collect(ch)
+	e.Metrics.ReplicaClusterLag.Collect(ch)
 
 	if version, _ := e.instance.GetPgVersion(); version.Major >= 14 {
 		e.Metrics.PgStatWalMetrics.WalSync.Collect(ch)
@@ -380,6 +389,7 @@ func (e *Exporter) collectPgMetrics(ch chan<- prometheus.Metric) {
 	}
 
 	e.collectNodesUsed()
+	e.collectReplicaClusterLag()
 
 	// metrics collected only on primary server
 	if isPrimary {
@@ -489,6 +499,19 @@ func (e *Exporter) collectNodesUsed() {
 	e.Metrics.NodesUsed.Set(float64(cluster.Status.Topology.NodesUsed))
 }
 
+// collectReplicaClusterLag exports the lag of the designated primary of a
+// replica cluster behind its source, as measured while probing the source
+func (e *Exporter) collectReplicaClusterLag() {
+	e.Metrics.ReplicaClusterLag.Reset()
+
+	lag := e.instance.GetReplicaClusterLag()
+	if lag == nil {
+		return
+	}
+
+	e.Metrics.ReplicaClusterLag.WithLabelValues(lag.Source).Set(float64(lag.Bytes))
+}
+
 func (e *Exporter) collectFromPrimaryLastFailedBackupTimestamp() {
 	const errorLabel = "Collect.LastFailedBackupTimestamp"
 	e.setTimestampMetric(e.Metrics.LastFailedBackupTimestamp, errorLabel, func(cluster *apiv1.Cluster) string {
@@ -19,6 +19,7 @@ package webserver
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -30,6 +31,7 @@ import (
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/upgrade"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/url"
+	pg "github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
 )
 
 type remoteWebserverEndpoints struct {
@@ -57,6 +59,11 @@ func NewRemoteWebServer(
 	serveMux.HandleFunc(url.PathReady, endpoints.isServerReady)
 	serveMux.HandleFunc(url.PathPgStatus, endpoints.pgStatus)
 	serveMux.HandleFunc(url.PathPGControlData, endpoints.pgControlData)
+	serveMux.HandleFunc(url.PathPgExtensions, endpoints.pgExtensions)
+	serveMux.HandleFunc(url.PathPgTransactionRate, endpoints.pgTransactionRate)
+	serveMux.HandleFunc(url.PathPgCleanTemporaryFiles, endpoints.pgCleanTemporaryFiles)
+	serveMux.HandleFunc(url.PathPgSourceReplication, endpoints.pgSourceReplication)
+	serveMux.HandleFunc(url.PathPgOnlineBackup, endpoints.pgOnlineBackup)
 	serveMux.HandleFunc(url.PathUpdate, endpoints.updateInstanceManager(cancelFunc, exitedConditions))
 
 	server := &http.Server{
@@ -156,6 +163,162 @@ func (ws *remoteWebserverEndpoints) pgControlData(w http.ResponseWriter, _ *http
 	_, _ = w.Write(res)
 }
 
+func (ws *remoteWebserverEndpoints) pgExtensions(w http.ResponseWriter, _ *http.Request) {
+	extensions, err := ws.instance.GetInstalledExtensions()
+	if err != nil {
+		log.Info(
+			"Instance extensions endpoint failing",
+			"err", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(extensions)
+	if err != nil {
+		log.Info(
+			"Internal error marshalling extensions response",
+			"err", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(res)
+}
+
+func (ws *remoteWebserverEndpoints) pgSourceReplication(w http.ResponseWriter, r *http.Request) {
+	status, err := ws.instance.GetSourceReplicationStatus(r.Context())
+	if err != nil {
+		log.Info(
+			"Instance source replication endpoint failing",
+			"err", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(status)
+	if err != nil {
+		log.Info(
+			"Internal error marshalling source replication response",
+			"err", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(res)
+}
+
+func (ws *remoteWebserverEndpoints) pgTransactionRate(w http.ResponseWriter, r *http.Request) {
+	transactionRate, err := ws.instance.GetTransactionRate(r.Context())
+	if err != nil {
+		log.Info(
+			"Instance transaction rate endpoint failing",
+			"err", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(transactionRate)
+	if err != nil {
+		log.Info(
+			"Internal error marshalling transaction rate response",
+			"err", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(res)
+}
+
+// pgCleanTemporaryFiles removes the temporary files of a fenced instance
+func (ws *remoteWebserverEndpoints) pgCleanTemporaryFiles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "wrong method used", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := ws.instance.CleanTemporaryFiles(); err != nil {
+		log.Info(
+			"Instance temporary files cleanup endpoint failing",
+			"err", err.Error())
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, postgres.ErrInstanceNotStopped) {
+			statusCode = http.StatusConflict
+		}
+		http.Error(w, err.Error(), statusCode)
+		return
+	}
+
+	_, _ = fmt.Fprint(w, "OK")
+}
+
+// pgOnlineBackup reports the status of the online backup of the instance
+// with the GET method, and starts or stops it with the POST method
+func (ws *remoteWebserverEndpoints) pgOnlineBackup(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		status := ws.instance.GetOnlineBackupStatus()
+		if status == nil {
+			http.Error(w, "no online backup", http.StatusNotFound)
+			return
+		}
+
+		res, err := json.Marshal(status)
+		if err != nil {
+			log.Info(
+				"Internal error marshalling online backup status",
+				"err", err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(res)
+
+	case http.MethodPost:
+		var request pg.OnlineBackupRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		switch request.Action {
+		case pg.OnlineBackupActionStart:
+			err = ws.instance.StartOnlineBackup(request)
+		case pg.OnlineBackupActionStop:
+			err = ws.instance.StopOnlineBackup(request)
+		default:
+			http.Error(w, fmt.Sprintf("unknown action %q", request.Action), http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			log.Info(
+				"Instance online backup endpoint failing",
+				"action", request.Action,
+				"backupName", request.BackupName,
+				"err", err.Error())
+			statusCode := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, postgres.ErrOnlineBackupInProgress):
+				statusCode = http.StatusConflict
+			case errors.Is(err, postgres.ErrOnlineBackupNotFound):
+				statusCode = http.StatusNotFound
+			}
+			http.Error(w, err.Error(), statusCode)
+			return
+		}
+
+		_, _ = fmt.Fprint(w, "OK")
+
+	default:
+		http.Error(w, "wrong method used", http.StatusMethodNotAllowed)
+	}
+}
+
 // updateInstanceManager replace the instance with one in the
 // new binary
 func (ws *remoteWebserverEndpoints) updateInstanceManager(
@@ -40,6 +40,21 @@ const (
 	// PathPGControlData is the URL path for PostgreSQL pg_controldata output
 	PathPGControlData string = "/pg/controldata"
 
+	// PathPgExtensions is the URL path for the list of installed PostgreSQL extensions
+	PathPgExtensions string = "/pg/extensions"
+
+	// PathPgTransactionRate is the URL path for the transactions per second executed by PostgreSQL
+	PathPgTransactionRate string = "/pg/transactionrate"
+
+	// PathPgCleanTemporaryFiles is the URL path to remove the temporary files of a fenced instance
+	PathPgCleanTemporaryFiles string = "/pg/cleantemporaryfiles"
+
+	// PathPgSourceReplication is the URL path for the position of the designated primary as seen by the source
+	PathPgSourceReplication string = "/pg/sourcereplication"
+
+	// PathPgOnlineBackup is the URL path to start, stop and monitor the online backups
+	PathPgOnlineBackup string = "/pg/onlinebackup"
+
 	// PathPgStatus is the URL path for PostgreSQL Status
 	PathPgStatus string = "/pg/status"
 
@@ -0,0 +1,72 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+// OnlineBackupPhase is the phase of an online backup, executed by the
+// instance manager through the PostgreSQL low level API for base backups
+type OnlineBackupPhase string
+
+const (
+	// OnlineBackupPhaseStarting means that pg_backup_start is running
+	OnlineBackupPhaseStarting OnlineBackupPhase = "starting"
+
+	// OnlineBackupPhaseStarted means that the instance is in backup mode,
+	// and its volumes can be copied
+	OnlineBackupPhaseStarted OnlineBackupPhase = "started"
+
+	// OnlineBackupPhaseStopping means that pg_backup_stop is running
+	OnlineBackupPhaseStopping OnlineBackupPhase = "stopping"
+
+	// OnlineBackupPhaseCompleted means that pg_backup_stop completed, and
+	// the backup label has been returned
+	OnlineBackupPhaseCompleted OnlineBackupPhase = "completed"
+
+	// OnlineBackupPhaseFailed means that the online backup failed
+	OnlineBackupPhaseFailed OnlineBackupPhase = "failed"
+)
+
+// OnlineBackupAction is the action requested to the instance manager
+// on an online backup
+type OnlineBackupAction string
+
+const (
+	// OnlineBackupActionStart starts the online backup
+	OnlineBackupActionStart OnlineBackupAction = "start"
+
+	// OnlineBackupActionStop stops the online backup
+	OnlineBackupActionStop OnlineBackupAction = "stop"
+)
+
+// OnlineBackupRequest is the request to start or stop an online backup
+type OnlineBackupRequest struct {
+	Action              OnlineBackupAction `json:"action"`
+	BackupName          string             `json:"backupName"`
+	ImmediateCheckpoint bool               `json:"immediateCheckpoint,omitempty"`
+	WaitForArchive      bool               `json:"waitForArchive,omitempty"`
+}
+
+// OnlineBackupStatus is the status of the online backup executed by
+// the instance manager
+type OnlineBackupStatus struct {
+	BackupName        string            `json:"backupName"`
+	Phase             OnlineBackupPhase `json:"phase"`
+	BeginLSN          LSN               `json:"beginLSN,omitempty"`
+	EndLSN            LSN               `json:"endLSN,omitempty"`
+	BackupLabelFile   []byte            `json:"backupLabelFile,omitempty"`
+	TablespaceMapFile []byte            `json:"tablespaceMapFile,omitempty"`
+	Error             string            `json:"error,omitempty"`
+}
@@ -40,12 +40,25 @@ type PostgresqlStatus struct {
 	IsPgRewindRunning         bool        `json:"isPgRewindRunning"`
 	MightBeUnavailable        bool        `json:"mightBeUnavailable"`
 	IsArchivingWAL            bool        `json:"isArchivingWAL,omitempty"`
+	IsReplicaStreamingPaused  bool        `json:"isReplicaStreamingPaused,omitempty"`
 	Node                      string      `json:"node"`
 	Pod                       *corev1.Pod `json:"pod"`
 	TotalInstanceSize         string      `json:"totalInstanceSize"`
 	// populated when MightBeUnavailable reported a healthy status even if it found an error
 	MightBeUnavailableMaskedError string `json:"mightBeUnavailableMaskedError,omitempty"`
 
+	// The name of the source the designated primary streams from, when
+	// the replica cluster has fallback sources
+	ActiveReplicaSource string `json:"activeReplicaSource,omitempty"`
+
+	// The status of the source of the replica cluster, as probed by the
+	// designated primary when requested
+	SourceStatus *SourceStatus `json:"sourceStatus,omitempty"`
+
+	// Whether the designated primary of the replica cluster is not ready
+	// because it is too far behind the source
+	IsReplicaClusterCatchingUp bool `json:"isReplicaClusterCatchingUp,omitempty"`
+
 	// Archiver status
 
 	LastArchivedWAL     string `json:"lastArchivedWAL,omitempty"`
@@ -152,6 +165,28 @@ type PgReplicationSlot struct {
 // PgReplicationSlotList is a list of PgReplicationSlot reported by the primary instance
 type PgReplicationSlotList []PgReplicationSlot
 
+// SlotWalStatusLost is the value of the wal_status column of pg_replication_slots
+// for slots which have been invalidated, as the WAL files they required have been
+// removed, i.e. because of max_slot_wal_keep_size
+const SlotWalStatusLost = "lost"
+
+// IsInvalidated checks whether the replication slot has been invalidated
+// and cannot be used anymore to stream the changes
+func (slot PgReplicationSlot) IsInvalidated() bool {
+	return slot.WalStatus == SlotWalStatusLost
+}
+
+// GetInvalidatedSlotNames returns the names of the invalidated replication slots
+func (list PgReplicationSlotList) GetInvalidatedSlotNames() []string {
+	var result []string
+	for _, slot := range list {
+		if slot.IsInvalidated() {
+			result = append(result, slot.SlotName)
+		}
+	}
+	return result
+}
+
 // Len implements sort.Interface extracting the length of the list
 func (list PgStatReplicationList) Len() int {
 	return len(list)
@@ -194,6 +229,17 @@ func (list PgStatReplicationList) Less(i, j int) bool {
 	return list[i].ApplicationName < list[j].ApplicationName
 }
 
+// SourceStatus is the status of the source of a replica cluster, as
+// probed by its designated primary
+type SourceStatus struct {
+	Reachable  bool   `json:"reachable"`
+	IsPrimary  bool   `json:"isPrimary,omitempty"`
+	CurrentLsn LSN    `json:"currentLsn,omitempty"`
+	WalLevel   string `json:"walLevel,omitempty"`
+	TimelineID int    `json:"timelineID,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
 // PostgresqlStatusList is a list of PostgreSQL status received from the Pods
 // that can be sorted considering the replication status
 type PostgresqlStatusList struct {
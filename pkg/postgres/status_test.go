@@ -191,3 +191,25 @@ var _ = Describe("PostgreSQL status real", func() {
 		})
 	})
 })
+
+var _ = Describe("replication slots", func() {
+	It("detects the invalidated slots", func() {
+		slots := PgReplicationSlotList{
+			{SlotName: "_cnpg_sandbox_2", WalStatus: "reserved"},
+			{SlotName: "_cnpg_sandbox_3", WalStatus: SlotWalStatusLost},
+			{SlotName: "_cnpg_sandbox_4", WalStatus: "unreserved"},
+			{SlotName: "logical", WalStatus: SlotWalStatusLost},
+		}
+		Expect(slots[1].IsInvalidated()).To(BeTrue())
+		Expect(slots[2].IsInvalidated()).To(BeFalse())
+		Expect(slots.GetInvalidatedSlotNames()).To(Equal([]string{"_cnpg_sandbox_3", "logical"}))
+	})
+
+	It("reports no invalidated slots when every slot is usable", func() {
+		slots := PgReplicationSlotList{
+			{SlotName: "_cnpg_sandbox_2", WalStatus: "reserved"},
+			{SlotName: "_cnpg_sandbox_3", WalStatus: "extended"},
+		}
+		Expect(slots.GetInvalidatedSlotNames()).To(BeEmpty())
+	})
+})
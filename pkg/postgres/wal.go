@@ -149,6 +149,20 @@ func (segment Segment) Name() string {
 	return fmt.Sprintf("%08X%08X%08X", segment.Tli, segment.Log, segment.Seg)
 }
 
+// EndLSN gets the LSN immediately following the last byte contained in
+// this segment, which is the position a server replaying this segment
+// will reach. If segmentSize == nil, wal_segment_size=DefaultWALSegmentSize
+// is assumed.
+func (segment Segment) EndLSN(segmentSize *int64) LSN {
+	walSegmentSize := DefaultWALSegmentSize
+	if segmentSize != nil {
+		walSegmentSize = *segmentSize
+	}
+
+	position := int64(segment.Log)<<32 + (int64(segment.Seg)+1)*walSegmentSize
+	return LSN(fmt.Sprintf("%X/%X", position>>32, position&0xFFFFFFFF))
+}
+
 // WalSegmentsPerFile is the number of WAL Segments in a WAL File
 func WalSegmentsPerFile(walSegmentSize int64) int32 {
 	// Given that segment section is represented by 8 hex characters,
@@ -188,6 +188,40 @@ var _ = Describe("Segment name parsing and generation", func() {
 				test.start.Name(), test.size, test.version, test.walSize)
 		}
 	})
+
+	It("can compute the LSN where a segment ends", func() {
+		walSize64MB := int64(1 << 26)
+
+		tests := []struct {
+			segment Segment
+			walSize *int64
+			result  LSN
+		}{
+			{
+				segment: MustSegmentFromName("000000010000000000000000"),
+				result:  "0/1000000",
+			},
+			{
+				segment: MustSegmentFromName("0000000100000001000000FD"),
+				result:  "1/FE000000",
+			},
+			{
+				segment: MustSegmentFromName("0000000100000001000000FF"),
+				result:  "2/0",
+			},
+			{
+				segment: MustSegmentFromName("000000020000000A00000003"),
+				walSize: &walSize64MB,
+				result:  "A/10000000",
+			},
+		}
+
+		for _, test := range tests {
+			Expect(test.segment.EndLSN(test.walSize)).To(
+				Equal(test.result),
+				"segment=%v walSize=%v", test.segment.Name(), test.walSize)
+		}
+	})
 })
 
 var _ = Describe("WAL files checking", func() {
@@ -0,0 +1,255 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// SignatureHeader is the HTTP header containing the signature of the payload
+const SignatureHeader = "X-CNPG-Signature"
+
+// ErrForbiddenTarget is raised when the webhook resolves to an address
+// the operator is not allowed to connect to
+var ErrForbiddenTarget = errors.New("the backup notification webhook resolves to a forbidden address")
+
+// isAllowedTarget checks whether the operator can deliver notifications to
+// the passed IP address. The loopback and link-local addresses are refused,
+// as they would expose the operator itself and the cloud provider metadata
+// endpoints. It is a variable to allow the unit tests to replace it
+var isAllowedTarget = func(ip net.IP) bool {
+	return !ip.IsLoopback() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsUnspecified()
+}
+
+// Payload is the JSON document describing the outcome of a backup
+type Payload struct {
+	// The name of the backup
+	BackupName string `json:"backupName"`
+
+	// The namespace of the backup
+	Namespace string `json:"namespace"`
+
+	// The name of the backed up cluster
+	ClusterName string `json:"clusterName"`
+
+	// The backup method
+	Method apiv1.BackupMethod `json:"method,omitempty"`
+
+	// The phase of the backup, i.e. completed or failed
+	Phase apiv1.BackupPhase `json:"phase"`
+
+	// The error message of a failed backup
+	Error string `json:"error,omitempty"`
+
+	// When the backup was started
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// When the backup was terminated
+	StoppedAt *metav1.Time `json:"stoppedAt,omitempty"`
+
+	// The volume snapshots taken by the backup
+	Snapshots []string `json:"snapshots,omitempty"`
+
+	// The LSN where the backup starts
+	BeginLSN string `json:"beginLSN,omitempty"`
+
+	// The LSN where the backup ends
+	EndLSN string `json:"endLSN,omitempty"`
+
+	// How long the backup took, from its start to its termination
+	Duration string `json:"duration,omitempty"`
+}
+
+// statusError is raised when the webhook replies with an unexpected status code
+type statusError struct {
+	statusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("backup notification webhook replied with status code %d", e.statusCode)
+}
+
+// NewPayload creates the notification payload for a backup
+func NewPayload(backup *apiv1.Backup) Payload {
+	var duration string
+	if backup.Status.StartedAt != nil && backup.Status.StoppedAt != nil {
+		duration = backup.Status.StoppedAt.Sub(backup.Status.StartedAt.Time).Round(time.Second).String()
+	}
+
+	return Payload{
+		BackupName:  backup.Name,
+		Namespace:   backup.Namespace,
+		ClusterName: backup.Spec.Cluster.Name,
+		Method:      backup.Status.Method,
+		Phase:       backup.Status.Phase,
+		Error:       backup.Status.Error,
+		StartedAt:   backup.Status.StartedAt,
+		StoppedAt:   backup.Status.StoppedAt,
+		Snapshots:   backup.Status.BackupSnapshotStatus.Snapshots,
+		BeginLSN:    backup.Status.BeginLSN,
+		EndLSN:      backup.Status.EndLSN,
+		Duration:    duration,
+	}
+}
+
+// Sign computes the signature of a payload given the signing key,
+// in the form `sha256=<hex digest>`
+func Sign(key []byte, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notify delivers the outcome of a backup to the webhook configured in the
+// cluster, if any. A single attempt is made, and the returned error tells
+// through IsRetryable whether the delivery is worth retrying
+func Notify(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+) error {
+	if cluster.Spec.Backup == nil || cluster.Spec.Backup.Notification == nil {
+		return nil
+	}
+	configuration := cluster.Spec.Backup.Notification
+
+	var signingKey []byte
+	if configuration.SigningSecret != nil {
+		var secret corev1.Secret
+		if err := cli.Get(
+			ctx,
+			client.ObjectKey{Namespace: cluster.Namespace, Name: configuration.SigningSecret.Name},
+			&secret,
+		); err != nil {
+			return fmt.Errorf("while getting the backup notification signing secret: %w", err)
+		}
+
+		var ok bool
+		if signingKey, ok = secret.Data[configuration.SigningSecret.Key]; !ok {
+			return fmt.Errorf("missing key %q in the backup notification signing secret %s",
+				configuration.SigningSecret.Key, configuration.SigningSecret.Name)
+		}
+	}
+
+	return send(ctx, configuration, signingKey, NewPayload(backup))
+}
+
+// newHTTPClient creates the HTTP client used to deliver the notifications.
+// It never follows redirects, and refuses to connect to the addresses not
+// allowed by isAllowedTarget, whatever the host name resolves to
+func newHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isAllowedTarget(ip) {
+				return fmt.Errorf("%w: %s", ErrForbiddenTarget, host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// send POSTs the payload to the webhook
+func send(
+	ctx context.Context,
+	configuration *apiv1.BackupNotificationConfiguration,
+	signingKey []byte,
+	payload Payload,
+) error {
+	contextLogger := log.FromContext(ctx)
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, configuration.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if signingKey != nil {
+		req.Header.Set(SignatureHeader, Sign(signingKey, body))
+	}
+
+	resp, err := newHTTPClient(configuration.GetTimeout()).Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			contextLogger.Error(err, "while closing body")
+		}
+	}()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &statusError{statusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// IsRetryable checks if a failed delivery is worth retrying, that is
+// for every failure but the ones rejected by the webhook as client errors
+// and the ones targeting a forbidden address
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrForbiddenTarget) {
+		return false
+	}
+
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.statusCode >= 500 || statusErr.statusCode == http.StatusTooManyRequests
+	}
+	return true
+}
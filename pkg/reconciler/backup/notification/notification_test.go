@@ -0,0 +1,218 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// receivedRequest is a request received by the fake webhook
+type receivedRequest struct {
+	body      []byte
+	signature string
+}
+
+var _ = Describe("Backup notification", func() {
+	const namespace = "default"
+
+	defaultIsAllowedTarget := isAllowedTarget
+
+	var (
+		lock       sync.Mutex
+		received   []receivedRequest
+		statusCode int
+		server     *httptest.Server
+		cluster    *apiv1.Cluster
+		backup     *apiv1.Backup
+	)
+
+	BeforeEach(func() {
+		// The fake webhook listens on the loopback interface
+		originalIsAllowedTarget := isAllowedTarget
+		isAllowedTarget = func(net.IP) bool {
+			return true
+		}
+		DeferCleanup(func() {
+			isAllowedTarget = originalIsAllowedTarget
+		})
+
+		received = nil
+		statusCode = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			Expect(err).ToNot(HaveOccurred())
+
+			lock.Lock()
+			defer lock.Unlock()
+			received = append(received, receivedRequest{body: body, signature: r.Header.Get(SignatureHeader)})
+			w.WriteHeader(statusCode)
+		}))
+		DeferCleanup(server.Close)
+
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: namespace},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					Notification: &apiv1.BackupNotificationConfiguration{
+						URL: server.URL,
+						SigningSecret: &apiv1.SecretKeySelector{
+							LocalObjectReference: apiv1.LocalObjectReference{Name: "notification-secret"},
+							Key:                  "key",
+						},
+					},
+				},
+			},
+		}
+
+		startedAt := metav1.NewTime(time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC))
+		stoppedAt := metav1.NewTime(startedAt.Add(time.Minute))
+		backup = &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup-example", Namespace: namespace},
+			Spec: apiv1.BackupSpec{
+				Cluster: apiv1.LocalObjectReference{Name: "cluster-example"},
+			},
+			Status: apiv1.BackupStatus{
+				Method:    apiv1.BackupMethodVolumeSnapshot,
+				Phase:     apiv1.BackupPhaseCompleted,
+				StartedAt: &startedAt,
+				StoppedAt: &stoppedAt,
+				BackupSnapshotStatus: apiv1.BackupSnapshotStatus{
+					Snapshots: []string{"backup-example", "backup-example-wal"},
+				},
+			},
+		}
+	})
+
+	It("POSTs the signed payload to the webhook", func(ctx context.Context) {
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "notification-secret", Namespace: namespace},
+				Data:       map[string][]byte{"key": []byte("s3cr3t")},
+			}).
+			Build()
+
+		Expect(Notify(ctx, cli, cluster, backup)).To(Succeed())
+		Expect(received).To(HaveLen(1))
+
+		var payload Payload
+		Expect(json.Unmarshal(received[0].body, &payload)).To(Succeed())
+		Expect(payload.BackupName).To(Equal("backup-example"))
+		Expect(payload.Namespace).To(Equal(namespace))
+		Expect(payload.ClusterName).To(Equal("cluster-example"))
+		Expect(payload.Method).To(Equal(apiv1.BackupMethodVolumeSnapshot))
+		Expect(payload.Phase).To(BeEquivalentTo(apiv1.BackupPhaseCompleted))
+		Expect(payload.Snapshots).To(ConsistOf("backup-example", "backup-example-wal"))
+		Expect(payload.StoppedAt.Time).To(BeTemporally("==", backup.Status.StoppedAt.Time))
+
+		Expect(received[0].signature).To(Equal(Sign([]byte("s3cr3t"), received[0].body)))
+	})
+
+	It("includes the LSNs and the duration of the backup in the payload", func() {
+		backup.Status.BeginLSN = "0/6000028"
+		backup.Status.EndLSN = "0/6000100"
+
+		payload := NewPayload(backup)
+		Expect(payload.BeginLSN).To(Equal("0/6000028"))
+		Expect(payload.EndLSN).To(Equal("0/6000100"))
+		Expect(payload.Duration).To(Equal("1m0s"))
+
+		backup.Status.StoppedAt = nil
+		Expect(NewPayload(backup).Duration).To(BeEmpty())
+	})
+
+	It("fails when the signing secret is missing", func(ctx context.Context) {
+		cli := fake.NewClientBuilder().WithScheme(schemeBuilder.BuildWithAllKnownScheme()).Build()
+		Expect(Notify(ctx, cli, cluster, backup)).ToNot(Succeed())
+		Expect(received).To(BeEmpty())
+	})
+
+	It("doesn't sign the payload without a signing secret", func(ctx context.Context) {
+		err := send(ctx, cluster.Spec.Backup.Notification, nil, NewPayload(backup))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(received).To(HaveLen(1))
+		Expect(received[0].signature).To(BeEmpty())
+	})
+
+	It("makes a single attempt when the webhook fails, to be retried", func(ctx context.Context) {
+		statusCode = http.StatusServiceUnavailable
+		err := send(ctx, cluster.Spec.Backup.Notification, nil, NewPayload(backup))
+		Expect(err).To(HaveOccurred())
+		Expect(IsRetryable(err)).To(BeTrue())
+		Expect(received).To(HaveLen(1))
+	})
+
+	It("doesn't retry when the webhook rejects the payload", func(ctx context.Context) {
+		statusCode = http.StatusBadRequest
+		err := send(ctx, cluster.Spec.Backup.Notification, nil, NewPayload(backup))
+		Expect(err).To(HaveOccurred())
+		Expect(IsRetryable(err)).To(BeFalse())
+		Expect(received).To(HaveLen(1))
+	})
+
+	It("doesn't follow redirects", func(ctx context.Context) {
+		redirectingServer := httptest.NewServer(http.RedirectHandler(server.URL, http.StatusFound))
+		DeferCleanup(redirectingServer.Close)
+
+		configuration := &apiv1.BackupNotificationConfiguration{URL: redirectingServer.URL}
+		err := send(ctx, configuration, nil, NewPayload(backup))
+		Expect(err).To(HaveOccurred())
+		Expect(IsRetryable(err)).To(BeFalse())
+		Expect(received).To(BeEmpty())
+	})
+
+	It("refuses to notify the loopback and link-local addresses", func(ctx context.Context) {
+		isAllowedTarget = defaultIsAllowedTarget
+
+		err := send(ctx, cluster.Spec.Backup.Notification, nil, NewPayload(backup))
+		Expect(err).To(MatchError(ErrForbiddenTarget))
+		Expect(IsRetryable(err)).To(BeFalse())
+		Expect(received).To(BeEmpty())
+	})
+
+	It("gives up when the webhook doesn't reply in time", func(ctx context.Context) {
+		slowServer := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}))
+		DeferCleanup(slowServer.Close)
+
+		configuration := &apiv1.BackupNotificationConfiguration{URL: slowServer.URL, Timeout: 1}
+		err := send(ctx, configuration, nil, NewPayload(backup))
+		Expect(err).To(HaveOccurred())
+		Expect(IsRetryable(err)).To(BeTrue())
+	})
+})
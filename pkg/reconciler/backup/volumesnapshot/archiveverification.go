@@ -0,0 +1,207 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// archiveVerificationInterval is how often the WAL archive is checked
+// while waiting for the WAL file needed by the snapshots to be archived
+const archiveVerificationInterval = 10 * time.Second
+
+// startArchiveVerification records in the backup status the WAL file needed
+// by its snapshots, so that the next reconciliation loops wait for it to be
+// archived before completing the backup. The status is patched immediately,
+// together with the changes made to it in the previous steps, as the waiting
+// spans multiple reconciliation loops. The verification is skipped when
+// the WAL file can't be detected
+func (se *Reconciler) startArchiveVerification(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	origBackup *apiv1.Backup,
+	backup *apiv1.Backup,
+	snapshots []storagesnapshotv1.VolumeSnapshot,
+	now time.Time,
+) (*ctrl.Result, error) {
+	contextLogger := log.FromContext(ctx)
+
+	if !cluster.Spec.Backup.IsBarmanBackupConfigured() {
+		return nil, nil
+	}
+
+	// the WAL archive status is exposed by the instance manager, which is
+	// not reachable when the primary runs in a different Kubernetes cluster
+	if se.remote {
+		contextLogger.Info("Cannot verify the WAL archive of a remote instance, skipping the verification")
+		return nil, nil
+	}
+
+	requiredWAL, err := getRequiredWAL(cluster, snapshots)
+	if err != nil {
+		contextLogger.Warning("Cannot detect the WAL file needed by the snapshots, skipping the verification",
+			"err", err.Error())
+		se.recorder.Eventf(backup, "Warning", "ArchiveVerification",
+			"Cannot detect the WAL file needed by the snapshots: %v", err)
+		return nil, nil
+	}
+
+	startedAt := metav1.NewTime(now)
+	backup.Status.BackupSnapshotStatus.RequiredWAL = requiredWAL
+	backup.Status.BackupSnapshotStatus.ArchiveWaitStartedAt = &startedAt
+	if err := se.backupCli.Status().Patch(ctx, backup, client.MergeFrom(origBackup)); err != nil {
+		return nil, err
+	}
+
+	contextLogger.Info("Waiting for the WAL file needed by the snapshots to be archived",
+		"requiredWAL", requiredWAL)
+	return se.verifyArchive(ctx, cluster, backup, now)
+}
+
+// verifyArchive checks whether the WAL file needed by the snapshots of the
+// backup has been archived, requeuing until it is or until the configured
+// timeout is exceeded
+func (se *Reconciler) verifyArchive(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	now time.Time,
+) (*ctrl.Result, error) {
+	contextLogger := log.FromContext(ctx)
+
+	lastArchivedWAL, err := se.getLastArchivedWAL(ctx, cluster)
+	if err != nil {
+		contextLogger.Info("Cannot detect the WAL archive status from the primary instance",
+			"primary", cluster.Status.CurrentPrimary, "err", err.Error())
+	}
+
+	return checkArchive(cluster, backup, lastArchivedWAL, now)
+}
+
+// checkArchive checks whether the WAL file needed by the snapshots of the
+// backup has been archived, given the last archived WAL file. An empty last
+// archived WAL file means that the WAL archive status is not known
+func checkArchive(
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	lastArchivedWAL string,
+	now time.Time,
+) (*ctrl.Result, error) {
+	requiredWAL := backup.Status.BackupSnapshotStatus.RequiredWAL
+	archived, err := isWALArchived(requiredWAL, lastArchivedWAL)
+	if err != nil {
+		return nil, err
+	}
+	if archived {
+		return nil, nil
+	}
+
+	var verification *apiv1.VolumeSnapshotArchiveVerification
+	if cluster.Spec.Backup != nil && cluster.Spec.Backup.VolumeSnapshot != nil {
+		verification = cluster.Spec.Backup.VolumeSnapshot.ArchiveVerification
+	}
+
+	startedAt := backup.Status.BackupSnapshotStatus.ArchiveWaitStartedAt
+	if timeout := verification.GetTimeout(); timeout > 0 && startedAt != nil && now.Sub(startedAt.Time) > timeout {
+		return nil, fmt.Errorf("the WAL file %s needed by the snapshots was not archived within %s",
+			requiredWAL, timeout)
+	}
+
+	return &ctrl.Result{RequeueAfter: archiveVerificationInterval}, nil
+}
+
+// getRequiredWAL gets the name of the WAL file containing the checkpoint the
+// passed snapshots are consistent at, as recorded in the pg_controldata
+// output of the PG_DATA snapshot
+func getRequiredWAL(cluster *apiv1.Cluster, snapshots []storagesnapshotv1.VolumeSnapshot) (string, error) {
+	for i := range snapshots {
+		if utils.PVCRole(snapshots[i].Labels[utils.PvcRoleLabelName]) != utils.PVCRolePgData {
+			continue
+		}
+
+		pgControldata := snapshots[i].Annotations[utils.PgControldataAnnotationName]
+		if pgControldata == "" {
+			return "", fmt.Errorf("missing pg_controldata output in volume snapshot %s", snapshots[i].Name)
+		}
+
+		timeline, err := strconv.ParseInt(getPgControldataValue(pgControldata, pgControldataTimelineKey), 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("while parsing the timeline of volume snapshot %s: %w", snapshots[i].Name, err)
+		}
+
+		lsn, err := postgres.LSN(getConsistentLSN(pgControldata)).Parse()
+		if err != nil {
+			return "", fmt.Errorf("while parsing the consistent LSN of volume snapshot %s: %w", snapshots[i].Name, err)
+		}
+
+		walSegmentSize := postgres.DefaultWALSegmentSize
+		if size := getWALSegmentSize(cluster); size != nil {
+			walSegmentSize = *size
+		}
+
+		segment := postgres.Segment{
+			Tli: int32(timeline),
+			Log: int32(lsn >> 32),
+			Seg: int32((lsn & 0xFFFFFFFF) / walSegmentSize),
+		}
+		return segment.Name(), nil
+	}
+
+	return "", errors.New("no PG_DATA volume snapshot")
+}
+
+// isWALArchived checks whether the required WAL file has been archived,
+// given the last archived one. A WAL file of a later timeline includes the
+// content of the previous timelines, up to the point where it was created
+func isWALArchived(requiredWAL, lastArchivedWAL string) (bool, error) {
+	if lastArchivedWAL == "" {
+		return false, nil
+	}
+
+	required, err := postgres.SegmentFromName(requiredWAL)
+	if err != nil {
+		return false, fmt.Errorf("while parsing the required WAL %s: %w", requiredWAL, err)
+	}
+
+	lastArchived, err := postgres.SegmentFromName(lastArchivedWAL)
+	if err != nil {
+		return false, fmt.Errorf("while parsing the last archived WAL %s: %w", lastArchivedWAL, err)
+	}
+
+	if lastArchived.Tli < required.Tli {
+		return false, nil
+	}
+
+	if lastArchived.Log != required.Log {
+		return lastArchived.Log > required.Log, nil
+	}
+	return lastArchived.Seg >= required.Seg, nil
+}
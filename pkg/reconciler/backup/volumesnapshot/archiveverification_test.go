@@ -0,0 +1,153 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WAL archive verification", func() {
+	var cluster *apiv1.Cluster
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						ArchiveVerification: &apiv1.VolumeSnapshotArchiveVerification{
+							Timeout: 60,
+						},
+					},
+				},
+			},
+		}
+	})
+
+	newSnapshot := func(role utils.PVCRole, pgControldata string) storagesnapshotv1.VolumeSnapshot {
+		return storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "cluster-example-1-" + string(role),
+				Labels:      map[string]string{utils.PvcRoleLabelName: string(role)},
+				Annotations: map[string]string{utils.PgControldataAnnotationName: pgControldata},
+			},
+		}
+	}
+
+	Context("detecting the WAL file needed by the snapshots", func() {
+		const pgControldata = "Database cluster state:               shut down\n" +
+			"Latest checkpoint location:           1/5000028\n" +
+			"Latest checkpoint's REDO location:    1/5000028\n" +
+			"Latest checkpoint's TimeLineID:       3\n"
+
+		It("uses the checkpoint of the PG_DATA snapshot", func() {
+			requiredWAL, err := getRequiredWAL(cluster, []storagesnapshotv1.VolumeSnapshot{
+				newSnapshot(utils.PVCRolePgWal, ""),
+				newSnapshot(utils.PVCRolePgData, pgControldata),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requiredWAL).To(Equal("000000030000000100000005"))
+		})
+
+		It("takes into account a custom WAL segment size", func() {
+			cluster.Spec.Bootstrap = &apiv1.BootstrapConfiguration{
+				InitDB: &apiv1.BootstrapInitDB{
+					WalSegmentSize: 64,
+				},
+			}
+
+			requiredWAL, err := getRequiredWAL(cluster, []storagesnapshotv1.VolumeSnapshot{
+				newSnapshot(utils.PVCRolePgData, pgControldata),
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requiredWAL).To(Equal("000000030000000100000001"))
+		})
+
+		It("fails without the pg_controldata output", func() {
+			_, err := getRequiredWAL(cluster, []storagesnapshotv1.VolumeSnapshot{
+				newSnapshot(utils.PVCRolePgData, ""),
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	DescribeTable("detecting whether the WAL file has been archived",
+		func(lastArchivedWAL string, expected bool) {
+			archived, err := isWALArchived("000000030000000100000005", lastArchivedWAL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(archived).To(Equal(expected))
+		},
+		Entry("nothing archived", "", false),
+		Entry("previous segment", "000000030000000100000004", false),
+		Entry("same segment", "000000030000000100000005", true),
+		Entry("next log", "000000030000000200000000", true),
+		Entry("later timeline", "000000040000000100000006", true),
+		Entry("previous timeline", "000000020000000100000009", false),
+	)
+
+	Context("waiting for the WAL file to be archived", func() {
+		var (
+			backup *apiv1.Backup
+			now    time.Time
+		)
+
+		BeforeEach(func() {
+			now = time.Now()
+			backup = &apiv1.Backup{
+				Status: apiv1.BackupStatus{
+					BackupSnapshotStatus: apiv1.BackupSnapshotStatus{
+						RequiredWAL:          "000000030000000100000005",
+						ArchiveWaitStartedAt: &metav1.Time{Time: now.Add(-30 * time.Second)},
+					},
+				},
+			}
+		})
+
+		It("completes once the WAL file has been archived", func() {
+			res, err := checkArchive(cluster, backup, "000000030000000100000005", now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(BeNil())
+		})
+
+		It("requeues while the WAL file hasn't been archived", func() {
+			res, err := checkArchive(cluster, backup, "000000030000000100000004", now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).ToNot(BeNil())
+			Expect(res.RequeueAfter).To(Equal(archiveVerificationInterval))
+		})
+
+		It("fails when the timeout is exceeded", func() {
+			_, err := checkArchive(cluster, backup, "", now.Add(time.Minute))
+			Expect(err).To(MatchError(ContainSubstring("not archived within 1m0s")))
+		})
+
+		It("waits forever without a timeout", func() {
+			cluster.Spec.Backup.VolumeSnapshot.ArchiveVerification.Timeout = 0
+			res, err := checkArchive(cluster, backup, "", now.Add(time.Hour))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).ToNot(BeNil())
+		})
+	})
+})
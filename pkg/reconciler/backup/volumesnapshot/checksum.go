@@ -0,0 +1,151 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// ErrMetadataChecksumMismatch is raised when the metadata of a volume
+// snapshot doesn't match the checksum computed when it was taken
+var ErrMetadataChecksumMismatch = errors.New("volume snapshot metadata checksum mismatch")
+
+// checksummedAnnotations is the list of the annotations of a volume
+// snapshot that are covered by the metadata checksum
+var checksummedAnnotations = []string{
+	utils.PgControldataAnnotationName,
+	utils.ClusterManifestAnnotationName,
+	utils.InstalledExtensionsAnnotationName,
+	utils.BackupNodeAnnotationName,
+	utils.ConsistentLSNAnnotationName,
+	utils.PostgresMajorVersionAnnotationName,
+	utils.PostgresMinorVersionAnnotationName,
+	utils.BackupLabelFileAnnotationName,
+	utils.TablespaceMapFileAnnotationName,
+}
+
+// checksummedLabels is the list of the labels of a volume snapshot
+// that are covered by the metadata checksum
+var checksummedLabels = []string{
+	utils.BackupNameLabelName,
+}
+
+// computeMetadataChecksum computes the SHA-256 checksum of the metadata
+// recorded in the passed volume snapshot, i.e. the pg_controldata output
+// and the backup metadata
+func computeMetadataChecksum(snapshot *storagesnapshotv1.VolumeSnapshot) (string, error) {
+	metadata := make(map[string]string, len(checksummedAnnotations)+len(checksummedLabels))
+	for _, name := range checksummedAnnotations {
+		if value, ok := snapshot.Annotations[name]; ok {
+			metadata["annotation:"+name] = value
+		}
+	}
+	for _, name := range checksummedLabels {
+		if value, ok := snapshot.Labels[name]; ok {
+			metadata["label:"+name] = value
+		}
+	}
+
+	// the keys of the map are sorted when encoding it, making
+	// the result deterministic
+	rawMetadata, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256(rawMetadata)), nil
+}
+
+// verifyMetadataChecksum checks the metadata of the passed volume snapshot
+// against the checksum recorded when it was taken. Snapshots without
+// a checksum, like the ones taken by older versions of the operator,
+// are accepted
+func verifyMetadataChecksum(snapshot *storagesnapshotv1.VolumeSnapshot) error {
+	expected, ok := snapshot.Annotations[utils.SnapshotMetadataChecksumAnnotationName]
+	if !ok {
+		return nil
+	}
+
+	actual, err := computeMetadataChecksum(snapshot)
+	if err != nil {
+		return err
+	}
+
+	if actual != expected {
+		return fmt.Errorf("%w: volume snapshot %s", ErrMetadataChecksumMismatch, snapshot.Name)
+	}
+
+	return nil
+}
+
+// VerifyRecoverySnapshots checks the metadata checksum of the volume
+// snapshots used to bootstrap the passed cluster, and that they were
+// consistent at the same LSN. The snapshots that can't be found are
+// skipped, as they are reported elsewhere, and so are the PVCs used
+// as a data source
+func VerifyRecoverySnapshots(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+) error {
+	if cluster.Spec.Bootstrap == nil ||
+		cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.VolumeSnapshots == nil {
+		return nil
+	}
+
+	volumeSnapshots := cluster.Spec.Bootstrap.Recovery.VolumeSnapshots
+	references := []corev1.TypedLocalObjectReference{volumeSnapshots.Storage}
+	if volumeSnapshots.WalStorage != nil {
+		references = append(references, *volumeSnapshots.WalStorage)
+	}
+
+	snapshots := make([]storagesnapshotv1.VolumeSnapshot, 0, len(references))
+	for _, reference := range references {
+		if reference.Kind != "VolumeSnapshot" {
+			continue
+		}
+
+		var snapshot storagesnapshotv1.VolumeSnapshot
+		err := cli.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: reference.Name}, &snapshot)
+		if apierrs.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := verifyMetadataChecksum(&snapshot); err != nil {
+			return err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return verifySnapshotsConsistency(snapshots)
+}
@@ -0,0 +1,143 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("volume snapshot metadata checksum", func() {
+	newSnapshot := func() *storagesnapshotv1.VolumeSnapshot {
+		return &storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backup-data",
+				Namespace: "default",
+				Labels: map[string]string{
+					utils.BackupNameLabelName: "backup",
+				},
+				Annotations: map[string]string{
+					utils.PgControldataAnnotationName:   "Latest checkpoint's REDO location:    0/6000028\n",
+					utils.ClusterManifestAnnotationName: `{"metadata":{"name":"cluster-example"}}`,
+				},
+			},
+		}
+	}
+
+	It("is deterministic", func() {
+		first, err := computeMetadataChecksum(newSnapshot())
+		Expect(err).ToNot(HaveOccurred())
+		second, err := computeMetadataChecksum(newSnapshot())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(first).To(HaveLen(64))
+		Expect(first).To(Equal(second))
+	})
+
+	It("ignores the metadata not covered by the checksum", func() {
+		snapshot := newSnapshot()
+		checksum, err := computeMetadataChecksum(snapshot)
+		Expect(err).ToNot(HaveOccurred())
+
+		snapshot.Annotations["example.com/owner"] = "team"
+		Expect(computeMetadataChecksum(snapshot)).To(Equal(checksum))
+	})
+
+	It("verifies untouched metadata", func() {
+		snapshot := newSnapshot()
+		checksum, err := computeMetadataChecksum(snapshot)
+		Expect(err).ToNot(HaveOccurred())
+		snapshot.Annotations[utils.SnapshotMetadataChecksumAnnotationName] = checksum
+
+		Expect(verifyMetadataChecksum(snapshot)).To(Succeed())
+	})
+
+	It("detects tampered pg_controldata", func() {
+		snapshot := newSnapshot()
+		checksum, err := computeMetadataChecksum(snapshot)
+		Expect(err).ToNot(HaveOccurred())
+		snapshot.Annotations[utils.SnapshotMetadataChecksumAnnotationName] = checksum
+
+		snapshot.Annotations[utils.PgControldataAnnotationName] = "Latest checkpoint's REDO location:    0/7000028\n"
+		Expect(verifyMetadataChecksum(snapshot)).To(MatchError(ErrMetadataChecksumMismatch))
+	})
+
+	It("detects a tampered backup name", func() {
+		snapshot := newSnapshot()
+		checksum, err := computeMetadataChecksum(snapshot)
+		Expect(err).ToNot(HaveOccurred())
+		snapshot.Annotations[utils.SnapshotMetadataChecksumAnnotationName] = checksum
+
+		snapshot.Labels[utils.BackupNameLabelName] = "another-backup"
+		Expect(verifyMetadataChecksum(snapshot)).To(MatchError(ErrMetadataChecksumMismatch))
+	})
+
+	It("accepts snapshots without a checksum", func() {
+		Expect(verifyMetadataChecksum(newSnapshot())).To(Succeed())
+	})
+
+	It("verifies the snapshots used to bootstrap a cluster", func(ctx context.Context) {
+		snapshot := newSnapshot()
+		checksum, err := computeMetadataChecksum(snapshot)
+		Expect(err).ToNot(HaveOccurred())
+		snapshot.Annotations[utils.SnapshotMetadataChecksumAnnotationName] = checksum
+		snapshot.Annotations[utils.ClusterManifestAnnotationName] = `{"metadata":{"name":"tampered"}}`
+
+		cluster := &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-restore",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						VolumeSnapshots: &apiv1.DataSource{
+							Storage: corev1.TypedLocalObjectReference{
+								APIGroup: ptr.To(storagesnapshotv1.GroupName),
+								Kind:     "VolumeSnapshot",
+								Name:     "backup-data",
+							},
+							WalStorage: &corev1.TypedLocalObjectReference{
+								APIGroup: ptr.To(storagesnapshotv1.GroupName),
+								Kind:     "VolumeSnapshot",
+								Name:     "backup-wal",
+							},
+						},
+					},
+				},
+			},
+		}
+
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(snapshot).
+			Build()
+
+		Expect(VerifyRecoverySnapshots(ctx, cli, cluster)).To(MatchError(ErrMetadataChecksumMismatch))
+	})
+})
@@ -0,0 +1,85 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+const (
+	// pgControldataStateKey is the key of the pg_controldata output
+	// containing the state of the database cluster
+	pgControldataStateKey = "Database cluster state"
+
+	// pgControldataCheckpointLocationKey is the key of the pg_controldata
+	// output containing the location of the latest checkpoint
+	pgControldataCheckpointLocationKey = "Latest checkpoint location"
+)
+
+// ErrInconsistentSnapshots is raised when the volume snapshots used
+// together were not consistent at the same LSN
+var ErrInconsistentSnapshots = errors.New("volume snapshots not consistent at the same LSN")
+
+// getConsistentLSN gets the LSN at which a PVC snapshotted with the passed
+// pg_controldata output is consistent. When the instance is fenced, and thus
+// cleanly shut down, this is the location of the shutdown checkpoint, while
+// for an online snapshot this is the REDO location of the latest checkpoint,
+// from which the crash recovery of the snapshot starts
+func getConsistentLSN(pgControldata string) string {
+	if isShutDown(pgControldata) {
+		return getPgControldataValue(pgControldata, pgControldataCheckpointLocationKey)
+	}
+
+	return getCheckpointRedoLocation(pgControldata)
+}
+
+// isShutDown checks whether the passed pg_controldata output belongs
+// to a cleanly shut down instance
+func isShutDown(pgControldata string) bool {
+	return strings.HasPrefix(getPgControldataValue(pgControldata, pgControldataStateKey), "shut down")
+}
+
+// verifySnapshotsConsistency checks that the passed volume snapshots
+// were consistent at the same LSN. The snapshots without a recorded
+// LSN, like the ones taken by older versions of the operator, are skipped
+func verifySnapshotsConsistency(snapshots []storagesnapshotv1.VolumeSnapshot) error {
+	var referenceName, referenceLSN string
+	for i := range snapshots {
+		lsn, ok := snapshots[i].Annotations[utils.ConsistentLSNAnnotationName]
+		if !ok {
+			continue
+		}
+
+		if referenceLSN == "" {
+			referenceName, referenceLSN = snapshots[i].Name, lsn
+			continue
+		}
+
+		if lsn != referenceLSN {
+			return fmt.Errorf("%w: volume snapshot %s is consistent at %s, while %s is consistent at %s",
+				ErrInconsistentSnapshots, snapshots[i].Name, lsn, referenceName, referenceLSN)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"errors"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("volume snapshot consistent LSN", func() {
+	const fencedPgControldata = "pg_control version number:            1300\n" +
+		"Database cluster state:               shut down in recovery\n" +
+		"Latest checkpoint location:           0/7000060\n" +
+		"Latest checkpoint's REDO location:    0/7000028\n"
+
+	const onlinePgControldata = "pg_control version number:            1300\n" +
+		"Database cluster state:               in archive recovery\n" +
+		"Latest checkpoint location:           0/6000060\n" +
+		"Latest checkpoint's REDO location:    0/6000028\n"
+
+	newSnapshot := func(name, lsn string) storagesnapshotv1.VolumeSnapshot {
+		snapshot := storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Annotations: map[string]string{},
+			},
+		}
+		if lsn != "" {
+			snapshot.Annotations[utils.ConsistentLSNAnnotationName] = lsn
+		}
+		return snapshot
+	}
+
+	It("uses the shutdown checkpoint of a fenced instance", func() {
+		Expect(getConsistentLSN(fencedPgControldata)).To(Equal("0/7000060"))
+	})
+
+	It("uses the REDO location of the latest checkpoint of a running instance", func() {
+		Expect(getConsistentLSN(onlinePgControldata)).To(Equal("0/6000028"))
+		Expect(getConsistentLSN("")).To(BeEmpty())
+	})
+
+	It("records the same LSN on every snapshot taken while fenced", func(ctx context.Context) {
+		cluster := &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						PgControldataContainer: "tools",
+					},
+				},
+			},
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-1"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "tools"}},
+			},
+		}
+		reconciler := &Reconciler{
+			executor: func(context.Context, corev1.Pod, string, ...string) (string, error) {
+				return fencedPgControldata, nil
+			},
+		}
+
+		for _, name := range []string{"backup-data", "backup-wal"} {
+			snapshot := newSnapshot(name, "")
+			snapshot.Labels = map[string]string{}
+			Expect(reconciler.enrichSnapshot(ctx, &snapshot, &apiv1.Backup{}, cluster, pod)).To(Succeed())
+			Expect(snapshot.Annotations).To(HaveKeyWithValue(utils.ConsistentLSNAnnotationName, "0/7000060"))
+			Expect(verifyMetadataChecksum(&snapshot)).To(Succeed())
+		}
+	})
+
+	It("labels the snapshots with the schedule which triggered the backup", func(ctx context.Context) {
+		cluster := &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						PgControldataContainer: "tools",
+					},
+				},
+			},
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-1"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "tools"}},
+			},
+		}
+		reconciler := &Reconciler{
+			executor: func(context.Context, corev1.Pod, string, ...string) (string, error) {
+				return fencedPgControldata, nil
+			},
+		}
+
+		scheduledBackup := &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "daily-1696118400",
+				Labels: map[string]string{utils.ParentScheduledBackupLabelName: "daily"},
+			},
+		}
+		snapshot := newSnapshot("backup-data", "")
+		snapshot.Labels = map[string]string{}
+		Expect(reconciler.enrichSnapshot(ctx, &snapshot, scheduledBackup, cluster, pod)).To(Succeed())
+		Expect(snapshot.Labels).To(HaveKeyWithValue(utils.ParentScheduledBackupLabelName, "daily"))
+		Expect(snapshot.Labels).To(HaveKeyWithValue(utils.BackupOriginLabelName, string(utils.BackupOriginScheduled)))
+
+		onDemandBackup := &apiv1.Backup{ObjectMeta: metav1.ObjectMeta{Name: "on-demand"}}
+		snapshot = newSnapshot("backup-data", "")
+		snapshot.Labels = map[string]string{}
+		Expect(reconciler.enrichSnapshot(ctx, &snapshot, onDemandBackup, cluster, pod)).To(Succeed())
+		Expect(snapshot.Labels).ToNot(HaveKey(utils.ParentScheduledBackupLabelName))
+	})
+
+	It("reports the snapshots taken without the control data", func(ctx context.Context) {
+		cluster := &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						PgControldataContainer: "tools",
+					},
+				},
+			},
+		}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-1"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "tools"}},
+			},
+		}
+		recorder := record.NewFakeRecorder(10)
+		reconciler := &Reconciler{
+			recorder: recorder,
+			executor: func(context.Context, corev1.Pod, string, ...string) (string, error) {
+				return "", errors.New("command terminated with exit code 1")
+			},
+		}
+
+		snapshot := newSnapshot("backup-data", "")
+		snapshot.Labels = map[string]string{}
+		Expect(reconciler.enrichSnapshot(ctx, &snapshot, &apiv1.Backup{}, cluster, pod)).To(Succeed())
+		Expect(snapshot.Annotations).ToNot(HaveKey(utils.PgControldataAnnotationName))
+		Expect(recorder.Events).To(Receive(ContainSubstring("PgControldataUnavailable")))
+
+		cluster.Spec.Backup.VolumeSnapshot.RequireControlData = true
+		snapshot = newSnapshot("backup-data", "")
+		snapshot.Labels = map[string]string{}
+		err := reconciler.enrichSnapshot(ctx, &snapshot, &apiv1.Backup{}, cluster, pod)
+		Expect(err).To(MatchError(ContainSubstring("exit code 1")))
+		Expect(recorder.Events).To(Receive(ContainSubstring("PgControldataUnavailable")))
+	})
+
+	It("accepts snapshots consistent at the same LSN", func() {
+		Expect(verifySnapshotsConsistency([]storagesnapshotv1.VolumeSnapshot{
+			newSnapshot("backup-data", "0/7000060"),
+			newSnapshot("backup-wal", "0/7000060"),
+		})).To(Succeed())
+	})
+
+	It("skips the snapshots without a recorded LSN", func() {
+		Expect(verifySnapshotsConsistency([]storagesnapshotv1.VolumeSnapshot{
+			newSnapshot("backup-data", "0/7000060"),
+			newSnapshot("backup-wal", ""),
+		})).To(Succeed())
+	})
+
+	It("detects snapshots consistent at different LSNs", func(ctx context.Context) {
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(
+				ptr.To(newSnapshot("backup-data", "0/7000060")),
+				ptr.To(newSnapshot("backup-wal", "0/6000028")),
+			).
+			Build()
+
+		cluster := &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-restore", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						VolumeSnapshots: &apiv1.DataSource{
+							Storage: corev1.TypedLocalObjectReference{
+								APIGroup: ptr.To(storagesnapshotv1.GroupName),
+								Kind:     "VolumeSnapshot",
+								Name:     "backup-data",
+							},
+							WalStorage: &corev1.TypedLocalObjectReference{
+								APIGroup: ptr.To(storagesnapshotv1.GroupName),
+								Kind:     "VolumeSnapshot",
+								Name:     "backup-wal",
+							},
+						},
+					},
+				},
+			},
+		}
+
+		Expect(VerifyRecoverySnapshots(ctx, cli, cluster)).To(MatchError(ErrInconsistentSnapshots))
+	})
+})
@@ -0,0 +1,92 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/specs"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// podExecutor executes a command inside a container of a Pod,
+// returning its standard output
+type podExecutor func(ctx context.Context, pod corev1.Pod, containerName string, command ...string) (string, error)
+
+// execInPod is the podExecutor running commands through the Kubernetes API
+// of the cluster where the operator runs
+func execInPod(ctx context.Context, pod corev1.Pod, containerName string, command ...string) (string, error) {
+	return newPodExecutor(ctrl.GetConfigOrDie())(ctx, pod, containerName, command...)
+}
+
+// newPodExecutor creates a podExecutor running commands through the
+// Kubernetes API reached with the passed configuration
+func newPodExecutor(config *rest.Config) podExecutor {
+	return func(ctx context.Context, pod corev1.Pod, containerName string, command ...string) (string, error) {
+		clientInterface, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return "", err
+		}
+
+		stdout, _, err := utils.ExecCommand(ctx, clientInterface, config, pod, containerName, nil, command...)
+		return stdout, err
+	}
+}
+
+// getPgControlData gets the output of pg_controldata for the PGDATA of the
+// target Pod, running it inside the configured container or, when no
+// container is configured, requesting it to the instance manager
+func (se *Reconciler) getPgControlData(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	targetPod *corev1.Pod,
+) (string, error) {
+	containerName := cluster.Spec.Backup.VolumeSnapshot.PgControldataContainer
+	switch {
+	case containerName == "" && se.remote:
+		// the instance manager of a remote instance is not reachable,
+		// but the PostgreSQL container has pg_controldata too
+		containerName = specs.PostgresContainerName
+	case containerName == "":
+		return se.instanceStatusClient.GetPgControlDataFromInstance(ctx, targetPod)
+	}
+
+	if err := ensureContainerExists(targetPod, containerName); err != nil {
+		return "", err
+	}
+
+	return se.executor(ctx, *targetPod, containerName, "pg_controldata", specs.PgDataPath)
+}
+
+// ensureContainerExists checks if the Pod has a container with the passed name
+func ensureContainerExists(pod *corev1.Pod, containerName string) error {
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == containerName {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: cannot find container %q in pod %s to run pg_controldata",
+		utils.ErrorContainerNotFound, containerName, pod.Name)
+}
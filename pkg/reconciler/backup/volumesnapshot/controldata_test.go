@@ -0,0 +1,99 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/specs"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("pg_controldata during the snapshot enrichment", func() {
+	var (
+		cluster    *apiv1.Cluster
+		pod        *corev1.Pod
+		reconciler *Reconciler
+		executions []string
+	)
+
+	BeforeEach(func() {
+		executions = nil
+		cluster = &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						PgControldataContainer: "tools",
+					},
+				},
+			},
+		}
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-1"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: specs.PostgresContainerName},
+					{Name: "tools"},
+					{Name: "sidecar"},
+				},
+			},
+		}
+		reconciler = &Reconciler{
+			executor: func(_ context.Context, _ corev1.Pod, containerName string, command ...string) (string, error) {
+				executions = append(executions, containerName)
+				Expect(command).To(Equal([]string{"pg_controldata", specs.PgDataPath}))
+				return "pg_control version number: 1300\n", nil
+			},
+		}
+	})
+
+	It("runs pg_controldata in the configured container", func(ctx context.Context) {
+		data, err := reconciler.getPgControlData(ctx, cluster, pod)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(ContainSubstring("pg_control version number"))
+		Expect(executions).To(Equal([]string{"tools"}))
+	})
+
+	It("fails when the configured container is not in the pod", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.PgControldataContainer = "missing"
+		_, err := reconciler.getPgControlData(ctx, cluster, pod)
+		Expect(err).To(MatchError(utils.ErrorContainerNotFound))
+		Expect(executions).To(BeEmpty())
+	})
+
+	It("runs pg_controldata in the PostgreSQL container of a remote instance", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.PgControldataContainer = ""
+		reconciler.remote = true
+		data, err := reconciler.getPgControlData(ctx, cluster, pod)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(ContainSubstring("pg_control version number"))
+		Expect(executions).To(Equal([]string{specs.PostgresContainerName}))
+	})
+
+	It("finds the containers of a pod", func() {
+		Expect(ensureContainerExists(pod, "sidecar")).To(Succeed())
+		Expect(ensureContainerExists(pod, specs.PostgresContainerName)).To(Succeed())
+		Expect(ensureContainerExists(pod, "other")).ToNot(Succeed())
+	})
+})
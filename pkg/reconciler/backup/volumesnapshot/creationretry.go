@@ -0,0 +1,106 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"regexp"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// createVolumeSnapshot creates the passed VolumeSnapshot. When configured in
+// the cluster, the creation is retried with an exponential backoff as long
+// as it fails with a recognized transient error
+func (se *Reconciler) createVolumeSnapshot(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	snapshot *storagesnapshotv1.VolumeSnapshot,
+) error {
+	creationRetry := cluster.Spec.Backup.VolumeSnapshot.CreationRetry
+	if creationRetry == nil {
+		return se.cli.Create(ctx, snapshot)
+	}
+
+	// Invalid patterns are refused by the validating webhook
+	patterns, err := creationRetry.CompileErrorPatterns()
+	if err != nil {
+		return err
+	}
+
+	return se.createVolumeSnapshotWithRetry(ctx, snapshot, getCreationRetryBackoff(creationRetry), patterns)
+}
+
+// getCreationRetryBackoff gets the backoff of the creation of the volume
+// snapshots, whose steps are the attempts to create a volume snapshot
+func getCreationRetryBackoff(creationRetry *apiv1.VolumeSnapshotCreationRetry) wait.Backoff {
+	return wait.Backoff{
+		Duration: creationRetry.GetBackoff(),
+		Factor:   2,
+		Jitter:   0.1,
+		Steps:    creationRetry.GetMaxAttempts(),
+	}
+}
+
+// createVolumeSnapshotWithRetry creates the passed VolumeSnapshot, retrying
+// with the passed backoff while the creation fails with an error matching
+// one of the passed patterns. The last error is returned when the attempts
+// are exhausted
+func (se *Reconciler) createVolumeSnapshotWithRetry(
+	ctx context.Context,
+	snapshot *storagesnapshotv1.VolumeSnapshot,
+	backoff wait.Backoff,
+	patterns []*regexp.Regexp,
+) error {
+	contextLogger := log.FromContext(ctx).WithValues("volumeSnapshotName", snapshot.Name)
+
+	attempt := 0
+	isTransient := func(err error) bool {
+		// the creation is not retried when the backup has been aborted,
+		// i.e. because the creation of another snapshot failed
+		if ctx.Err() != nil || !isTransientCreationError(err, patterns) {
+			return false
+		}
+
+		contextLogger.Info("Transient error while creating the VolumeSnapshot",
+			"attempt", attempt, "maxAttempts", backoff.Steps, "err", err.Error())
+		return true
+	}
+
+	return retry.OnError(backoff, isTransient, func() error {
+		attempt++
+		return se.cli.Create(ctx, snapshot)
+	})
+}
+
+// isTransientCreationError checks whether the error raised while creating
+// a volume snapshot matches one of the patterns of the transient errors
+func isTransientCreationError(err error, patterns []*regexp.Regexp) bool {
+	message := err.Error()
+	for _, pattern := range patterns {
+		if pattern.MatchString(message) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,186 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// flakyCreateClient fails the first creations of the volume snapshots
+// with the passed error
+type flakyCreateClient struct {
+	client.Client
+	err      error
+	failures int
+	attempts *int
+}
+
+func (f flakyCreateClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if _, ok := obj.(*storagesnapshotv1.VolumeSnapshot); ok {
+		*f.attempts++
+		if *f.attempts <= f.failures {
+			return f.err
+		}
+	}
+	return f.Client.Create(ctx, obj, opts...)
+}
+
+var _ = Describe("retry of the snapshot creation", func() {
+	var (
+		cluster   *apiv1.Cluster
+		backup    *apiv1.Backup
+		targetPod *corev1.Pod
+		pvc       *corev1.PersistentVolumeClaim
+		cli       client.Client
+		attempts  int
+	)
+
+	fastBackoff := wait.Backoff{Duration: time.Millisecond, Factor: 2, Steps: 3}
+
+	newSnapshot := func() *storagesnapshotv1.VolumeSnapshot {
+		return &storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-1-snapshot", Namespace: "default"},
+		}
+	}
+
+	newExecutor := func(err error, failures int) *Reconciler {
+		return NewExecutorBuilder(
+			flakyCreateClient{Client: cli, err: err, failures: failures, attempts: &attempts},
+			record.NewFakeRecorder(10),
+		).Build()
+	}
+
+	defaultPatterns := func() []*regexp.Regexp {
+		patterns, err := (&apiv1.VolumeSnapshotCreationRetry{}).CompileErrorPatterns()
+		Expect(err).ToNot(HaveOccurred())
+		return patterns
+	}
+
+	BeforeEach(func() {
+		attempts = 0
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{ClassName: "csi-snapclass"},
+				},
+			},
+		}
+		backup = &apiv1.Backup{ObjectMeta: metav1.ObjectMeta{Name: "backup-example", Namespace: "default"}}
+		targetPod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-1", Namespace: "default"}}
+		pvc = &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example-1",
+				Namespace: "default",
+				Labels:    map[string]string{utils.PvcRoleLabelName: string(utils.PVCRolePgData)},
+			},
+		}
+		cli = fake.NewClientBuilder().WithScheme(schemeBuilder.BuildWithAllKnownScheme()).Build()
+	})
+
+	It("retries the creation failing with a transient error", func(ctx context.Context) {
+		executor := newExecutor(errors.New("rate limit exceeded, please retry"), 2)
+
+		err := executor.createVolumeSnapshotWithRetry(ctx, newSnapshot(), fastBackoff, defaultPatterns())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(attempts).To(Equal(3))
+
+		var snapshots storagesnapshotv1.VolumeSnapshotList
+		Expect(cli.List(ctx, &snapshots)).To(Succeed())
+		Expect(snapshots.Items).To(HaveLen(1))
+	})
+
+	It("doesn't retry the creation failing with a permanent error", func(ctx context.Context) {
+		executor := newExecutor(errors.New("volume snapshot class not found"), 2)
+
+		err := executor.createVolumeSnapshotWithRetry(ctx, newSnapshot(), fastBackoff, defaultPatterns())
+		Expect(err).To(MatchError("volume snapshot class not found"))
+		Expect(attempts).To(Equal(1))
+	})
+
+	It("gives up when the attempts are exhausted", func(ctx context.Context) {
+		executor := newExecutor(errors.New("Throttling: request rate exceeded"), 10)
+
+		err := executor.createVolumeSnapshotWithRetry(ctx, newSnapshot(), fastBackoff, defaultPatterns())
+		Expect(err).To(MatchError(ContainSubstring("Throttling")))
+		Expect(attempts).To(Equal(3))
+	})
+
+	It("doesn't retry once the backup is aborted", func(ctx context.Context) {
+		executor := newExecutor(errors.New("too many requests"), 2)
+		abortedCtx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err := executor.createVolumeSnapshotWithRetry(abortedCtx, newSnapshot(), fastBackoff, defaultPatterns())
+		Expect(err).To(HaveOccurred())
+		Expect(attempts).To(Equal(1))
+	})
+
+	It("recognizes the transient errors through the configured patterns", func() {
+		creationRetry := &apiv1.VolumeSnapshotCreationRetry{ErrorPatterns: []string{"^quota .* busy$"}}
+		patterns, err := creationRetry.CompileErrorPatterns()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(isTransientCreationError(errors.New("Quota service busy"), patterns)).To(BeTrue())
+		Expect(isTransientCreationError(errors.New("rate limit exceeded"), patterns)).To(BeFalse())
+	})
+
+	It("doesn't retry the creation when not configured", func(ctx context.Context) {
+		executor := newExecutor(errors.New("rate limit exceeded"), 1)
+
+		err := executor.createSnapshot(ctx, cluster, backup, targetPod, pvc, nil, "suffix")
+		Expect(err).To(MatchError(ContainSubstring("rate limit exceeded")))
+		Expect(attempts).To(Equal(1))
+	})
+
+	It("retries the creation of the snapshot of a PVC when configured", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.CreationRetry = &apiv1.VolumeSnapshotCreationRetry{MaxAttempts: 2}
+		executor := newExecutor(errors.New("rate limit exceeded"), 1)
+
+		Expect(executor.createSnapshot(ctx, cluster, backup, targetPod, pvc, nil, "suffix")).To(Succeed())
+		Expect(attempts).To(Equal(2))
+	})
+
+	It("uses the configured attempts and backoff", func() {
+		backoff := getCreationRetryBackoff(&apiv1.VolumeSnapshotCreationRetry{MaxAttempts: 4, Backoff: 3})
+		Expect(backoff.Steps).To(Equal(4))
+		Expect(backoff.Duration).To(Equal(3 * time.Second))
+		Expect(backoff.Factor).To(BeEquivalentTo(2))
+
+		backoff = getCreationRetryBackoff(&apiv1.VolumeSnapshotCreationRetry{})
+		Expect(backoff.Steps).To(Equal(5))
+		Expect(backoff.Duration).To(Equal(time.Second))
+	})
+})
@@ -0,0 +1,148 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"fmt"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// ReconcileDeletionPolicy detects the VolumeSnapshotContents, bound to the
+// volume snapshots taken by the backups of the cluster, whose deletion
+// policy differs from the configured one. Depending on the configured
+// action, the expected deletion policy is restored or the drift is
+// reported through an event
+func ReconcileDeletionPolicy(
+	ctx context.Context,
+	cli client.Client,
+	recorder record.EventRecorder,
+	cluster *apiv1.Cluster,
+) error {
+	if cluster.Spec.Backup == nil || cluster.Spec.Backup.VolumeSnapshot == nil {
+		return nil
+	}
+
+	config := cluster.Spec.Backup.VolumeSnapshot
+	if config.DeletionPolicy == "" {
+		return nil
+	}
+
+	var list storagesnapshotv1.VolumeSnapshotList
+	if err := cli.List(
+		ctx,
+		&list,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{utils.ClusterLabelName: cluster.Name},
+		client.HasLabels{utils.BackupNameLabelName},
+	); err != nil {
+		return err
+	}
+
+	contents, err := getBoundContents(ctx, cli, list.Items)
+	if err != nil {
+		return err
+	}
+
+	contextLogger := log.FromContext(ctx)
+	expectedPolicy := storagesnapshotv1.DeletionPolicy(config.DeletionPolicy)
+	driftedContents := getDriftedContents(contents, expectedPolicy)
+	for i := range driftedContents {
+		content := &driftedContents[i]
+		if config.GetDeletionPolicyDriftAction() == apiv1.DeletionPolicyDriftActionReport {
+			contextLogger.Info("Deletion policy drift detected on VolumeSnapshotContent",
+				"volumeSnapshotContent", content.Name,
+				"deletionPolicy", content.Spec.DeletionPolicy,
+				"expectedDeletionPolicy", expectedPolicy)
+			recorder.Eventf(cluster, "Warning", "DeletionPolicyDrift",
+				"VolumeSnapshotContent %s has deletion policy %s instead of %s",
+				content.Name, content.Spec.DeletionPolicy, expectedPolicy)
+			continue
+		}
+
+		contextLogger.Info("Restoring the deletion policy of VolumeSnapshotContent",
+			"volumeSnapshotContent", content.Name,
+			"deletionPolicy", content.Spec.DeletionPolicy,
+			"expectedDeletionPolicy", expectedPolicy)
+		origContent := content.DeepCopy()
+		content.Spec.DeletionPolicy = expectedPolicy
+		if err := cli.Patch(ctx, content, client.MergeFrom(origContent)); err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("while restoring the deletion policy of VolumeSnapshotContent %s: %w",
+				content.Name, err)
+		}
+		recorder.Eventf(cluster, "Normal", "DeletionPolicyRestored",
+			"Restored deletion policy %s on VolumeSnapshotContent %s", expectedPolicy, content.Name)
+	}
+
+	return nil
+}
+
+// getBoundContents gets the VolumeSnapshotContents bound to the passed
+// volume snapshots, skipping the ones not bound yet or not existing anymore
+func getBoundContents(
+	ctx context.Context,
+	cli client.Client,
+	snapshots []storagesnapshotv1.VolumeSnapshot,
+) ([]storagesnapshotv1.VolumeSnapshotContent, error) {
+	contents := make([]storagesnapshotv1.VolumeSnapshotContent, 0, len(snapshots))
+	for i := range snapshots {
+		status := snapshots[i].Status
+		if status == nil || status.BoundVolumeSnapshotContentName == nil {
+			continue
+		}
+
+		var content storagesnapshotv1.VolumeSnapshotContent
+		if err := cli.Get(
+			ctx,
+			client.ObjectKey{Name: *status.BoundVolumeSnapshotContentName},
+			&content,
+		); err != nil {
+			if apierrs.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("while getting VolumeSnapshotContent %s: %w",
+				*status.BoundVolumeSnapshotContentName, err)
+		}
+		contents = append(contents, content)
+	}
+
+	return contents, nil
+}
+
+// getDriftedContents gets the VolumeSnapshotContents whose deletion policy
+// differs from the expected one
+func getDriftedContents(
+	contents []storagesnapshotv1.VolumeSnapshotContent,
+	expectedPolicy storagesnapshotv1.DeletionPolicy,
+) []storagesnapshotv1.VolumeSnapshotContent {
+	var driftedContents []storagesnapshotv1.VolumeSnapshotContent
+	for i := range contents {
+		if contents[i].Spec.DeletionPolicy != expectedPolicy {
+			driftedContents = append(driftedContents, contents[i])
+		}
+	}
+
+	return driftedContents
+}
@@ -0,0 +1,138 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("volume snapshot contents deletion policy", func() {
+	newSnapshot := func(name string) *storagesnapshotv1.VolumeSnapshot {
+		return &storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels: map[string]string{
+					utils.ClusterLabelName:    "cluster-example",
+					utils.BackupNameLabelName: "backup-example",
+				},
+			},
+			Status: &storagesnapshotv1.VolumeSnapshotStatus{
+				BoundVolumeSnapshotContentName: ptr.To(name + "-content"),
+			},
+		}
+	}
+
+	newContent := func(name string, policy storagesnapshotv1.DeletionPolicy) *storagesnapshotv1.VolumeSnapshotContent {
+		return &storagesnapshotv1.VolumeSnapshotContent{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-content"},
+			Spec:       storagesnapshotv1.VolumeSnapshotContentSpec{DeletionPolicy: policy},
+		}
+	}
+
+	newCluster := func(action apiv1.DeletionPolicyDriftAction) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						DeletionPolicy:            string(storagesnapshotv1.VolumeSnapshotContentRetain),
+						DeletionPolicyDriftAction: action,
+					},
+				},
+			},
+		}
+	}
+
+	getPolicy := func(ctx context.Context, cli client.Client, name string) storagesnapshotv1.DeletionPolicy {
+		var content storagesnapshotv1.VolumeSnapshotContent
+		Expect(cli.Get(ctx, client.ObjectKey{Name: name + "-content"}, &content)).To(Succeed())
+		return content.Spec.DeletionPolicy
+	}
+
+	newFakeClient := func() client.Client {
+		return fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(
+				newSnapshot("consistent"),
+				newContent("consistent", storagesnapshotv1.VolumeSnapshotContentRetain),
+				newSnapshot("drifted"),
+				newContent("drifted", storagesnapshotv1.VolumeSnapshotContentDelete),
+				newSnapshot("unbound-content"),
+			).
+			Build()
+	}
+
+	It("detects only the drifted contents", func() {
+		contents := []storagesnapshotv1.VolumeSnapshotContent{
+			*newContent("consistent", storagesnapshotv1.VolumeSnapshotContentRetain),
+			*newContent("drifted", storagesnapshotv1.VolumeSnapshotContentDelete),
+		}
+		drifted := getDriftedContents(contents, storagesnapshotv1.VolumeSnapshotContentRetain)
+		Expect(drifted).To(HaveLen(1))
+		Expect(drifted[0].Name).To(Equal("drifted-content"))
+
+		Expect(getDriftedContents(contents[:1], storagesnapshotv1.VolumeSnapshotContentRetain)).To(BeEmpty())
+	})
+
+	It("restores the expected deletion policy of the drifted contents", func(ctx context.Context) {
+		cli := newFakeClient()
+		recorder := record.NewFakeRecorder(10)
+
+		Expect(ReconcileDeletionPolicy(ctx, cli, recorder, newCluster(""))).To(Succeed())
+		Expect(getPolicy(ctx, cli, "drifted")).To(Equal(storagesnapshotv1.VolumeSnapshotContentRetain))
+		Expect(getPolicy(ctx, cli, "consistent")).To(Equal(storagesnapshotv1.VolumeSnapshotContentRetain))
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(ContainSubstring("DeletionPolicyRestored"))
+	})
+
+	It("only reports the drifted contents when configured to", func(ctx context.Context) {
+		cli := newFakeClient()
+		recorder := record.NewFakeRecorder(10)
+
+		Expect(ReconcileDeletionPolicy(ctx, cli, recorder,
+			newCluster(apiv1.DeletionPolicyDriftActionReport))).To(Succeed())
+		Expect(getPolicy(ctx, cli, "drifted")).To(Equal(storagesnapshotv1.VolumeSnapshotContentDelete))
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(ContainSubstring("DeletionPolicyDrift"))
+	})
+
+	It("does nothing when no deletion policy is configured", func(ctx context.Context) {
+		cli := newFakeClient()
+		recorder := record.NewFakeRecorder(10)
+		cluster := newCluster("")
+		cluster.Spec.Backup.VolumeSnapshot.DeletionPolicy = ""
+
+		Expect(ReconcileDeletionPolicy(ctx, cli, recorder, cluster)).To(Succeed())
+		Expect(getPolicy(ctx, cli, "drifted")).To(Equal(storagesnapshotv1.VolumeSnapshotContentDelete))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+})
@@ -0,0 +1,94 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"fmt"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/stringset"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// CheckDriverHealth checks, on a best-effort basis, if the CSI drivers
+// serving the volume snapshot classes of the cluster are registered on the
+// node running the target Pod. An empty string is returned when the drivers
+// are healthy or their health cannot be detected, otherwise the reason why
+// the drivers are considered degraded is returned
+func CheckDriverHealth(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+	targetPod *corev1.Pod,
+) string {
+	contextLogger := log.FromContext(ctx)
+
+	if cluster.Spec.Backup == nil || cluster.Spec.Backup.VolumeSnapshot == nil ||
+		targetPod.Spec.NodeName == "" {
+		return ""
+	}
+
+	var drivers []string
+	snapshotConfig := cluster.Spec.Backup.VolumeSnapshot
+	for _, role := range []utils.PVCRole{utils.PVCRolePgData, utils.PVCRolePgWal} {
+		classNamePtr := getSnapshotClassName(snapshotConfig, role, isPrimaryTarget(cluster, targetPod))
+		if classNamePtr == nil {
+			continue
+		}
+		className := *classNamePtr
+
+		var snapshotClass storagesnapshotv1.VolumeSnapshotClass
+		if err := cli.Get(ctx, types.NamespacedName{Name: className}, &snapshotClass); err != nil {
+			contextLogger.Info("Cannot get the volume snapshot class, skipping CSI driver health check",
+				"className", className, "err", err.Error())
+			continue
+		}
+		drivers = append(drivers, snapshotClass.Driver)
+	}
+
+	if len(drivers) == 0 {
+		return ""
+	}
+
+	var csiNode storagev1.CSINode
+	if err := cli.Get(ctx, types.NamespacedName{Name: targetPod.Spec.NodeName}, &csiNode); err != nil {
+		contextLogger.Info("Cannot get the CSI node, skipping CSI driver health check",
+			"nodeName", targetPod.Spec.NodeName, "err", err.Error())
+		return ""
+	}
+
+	registeredDrivers := stringset.New()
+	for _, driver := range csiNode.Spec.Drivers {
+		registeredDrivers.Put(driver.Name)
+	}
+
+	for _, driver := range drivers {
+		if !registeredDrivers.Has(driver) {
+			return fmt.Sprintf("CSI driver %s is not registered on node %s", driver, targetPod.Spec.NodeName)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,120 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CSI driver health check", func() {
+	var (
+		cluster       *apiv1.Cluster
+		pod           *corev1.Pod
+		snapshotClass *storagesnapshotv1.VolumeSnapshotClass
+	)
+
+	newCSINode := func(drivers ...string) *storagev1.CSINode {
+		csiNode := &storagev1.CSINode{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-1",
+			},
+		}
+		for _, driver := range drivers {
+			csiNode.Spec.Drivers = append(csiNode.Spec.Drivers, storagev1.CSINodeDriver{
+				Name:   driver,
+				NodeID: "node-1",
+			})
+		}
+		return csiNode
+	}
+
+	newClient := func(objects ...client.Object) client.Client {
+		return fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(objects...).
+			Build()
+	}
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						ClassName: "csi-snapclass",
+					},
+				},
+			},
+		}
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example-1",
+				Namespace: "default",
+			},
+			Spec: corev1.PodSpec{
+				NodeName: "node-1",
+			},
+		}
+		snapshotClass = &storagesnapshotv1.VolumeSnapshotClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "csi-snapclass",
+			},
+			Driver:         "csi.example.com",
+			DeletionPolicy: storagesnapshotv1.VolumeSnapshotContentDelete,
+		}
+	})
+
+	It("reports a healthy driver registered on the node", func(ctx context.Context) {
+		cli := newClient(snapshotClass, newCSINode("other.example.com", "csi.example.com"))
+		Expect(CheckDriverHealth(ctx, cli, cluster, pod)).To(BeEmpty())
+	})
+
+	It("reports a degraded driver not registered on the node", func(ctx context.Context) {
+		cli := newClient(snapshotClass, newCSINode("other.example.com"))
+		Expect(CheckDriverHealth(ctx, cli, cluster, pod)).To(
+			Equal("CSI driver csi.example.com is not registered on node node-1"))
+	})
+
+	It("skips the check when the snapshot class is not known", func(ctx context.Context) {
+		cli := newClient(newCSINode())
+		Expect(CheckDriverHealth(ctx, cli, cluster, pod)).To(BeEmpty())
+
+		cluster.Spec.Backup.VolumeSnapshot.ClassName = ""
+		Expect(CheckDriverHealth(ctx, cli, cluster, pod)).To(BeEmpty())
+	})
+
+	It("skips the check when the CSI node is not known", func(ctx context.Context) {
+		cli := newClient(snapshotClass)
+		Expect(CheckDriverHealth(ctx, cli, cluster, pod)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,172 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/stringset"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// isDefaultSnapshotClassAnnotationName is the annotation marking the
+// default volume snapshot class of a CSI driver
+const isDefaultSnapshotClassAnnotationName = "snapshot.storage.kubernetes.io/is-default-class"
+
+// executeDryRun validates the backup without taking any snapshot nor fencing
+// the target instance: every PVC of the instance must exist, its snapshot
+// class must resolve, and the instance must be fenceable. The snapshots that
+// would be taken are recorded in the status of the backup
+func (se *Reconciler) executeDryRun(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	targetPod *corev1.Pod,
+	pvcs []corev1.PersistentVolumeClaim,
+) error {
+	if err := ensurePVCsAreSnapshottable(pvcs); err != nil {
+		return err
+	}
+
+	if err := ensureExpectedPVCsExist(cluster, targetPod, pvcs); err != nil {
+		return err
+	}
+
+	if _, fencedPVCs := splitPVCsByFencingRequirement(cluster, pvcs); se.shouldFence && len(fencedPVCs) > 0 {
+		if err := ensurePodCanBeFenced(cluster, targetPod, se.tolerateFencedInstances); err != nil {
+			return err
+		}
+	}
+
+	snapshotSuffix := fmt.Sprintf("%d", time.Now().Unix())
+	snapshotConfig := cluster.Spec.Backup.VolumeSnapshot
+	dryRunSnapshots := make([]apiv1.DryRunSnapshot, 0, len(pvcs))
+	for i := range pvcs {
+		className, err := se.resolveSnapshotClassName(
+			ctx, &pvcs[i], getPVCSnapshotClassName(snapshotConfig, &pvcs[i], isPrimaryTarget(cluster, targetPod)))
+		if err != nil {
+			return fmt.Errorf("while resolving the snapshot class of PVC %s: %w", pvcs[i].Name, err)
+		}
+
+		dryRunSnapshots = append(dryRunSnapshots, apiv1.DryRunSnapshot{
+			PVCName:      pvcs[i].Name,
+			SnapshotName: se.getSnapshotName(pvcs[i].Name, snapshotSuffix),
+			ClassName:    className,
+		})
+	}
+
+	se.recorder.Eventf(backup, "Normal", "DryRun",
+		"Validated the snapshot backup of %d PVCs of Pod %s", len(dryRunSnapshots), targetPod.Name)
+	backup.Status.BackupSnapshotStatus.DryRunSnapshots = dryRunSnapshots
+	return nil
+}
+
+// ensureExpectedPVCsExist checks that every PVC the target instance
+// is expected to have, given the cluster storage, is going to be snapshotted
+func ensureExpectedPVCsExist(
+	cluster *apiv1.Cluster,
+	targetPod *corev1.Pod,
+	pvcs []corev1.PersistentVolumeClaim,
+) error {
+	expectedPVCs := []string{targetPod.Name}
+	if cluster.ShouldCreateWalArchiveVolume() {
+		expectedPVCs = append(expectedPVCs, targetPod.Name+apiv1.WalArchiveVolumeSuffix)
+	}
+
+	existingPVCs := stringset.New()
+	for i := range pvcs {
+		if pvcs[i].DeletionTimestamp.IsZero() {
+			existingPVCs.Put(pvcs[i].Name)
+		}
+	}
+
+	for _, name := range expectedPVCs {
+		if !existingPVCs.Has(name) {
+			return fmt.Errorf("missing PVC %s of Pod %s", name, targetPod.Name)
+		}
+	}
+
+	return nil
+}
+
+// ensurePodCanBeFenced checks that the target instance could be fenced
+// by the backup, as fencing is refused when other instances are fenced
+// unless they are explicitly tolerated
+func ensurePodCanBeFenced(cluster *apiv1.Cluster, targetPod *corev1.Pod, tolerateFencedInstances bool) error {
+	if _, err := checkFencedInstances(cluster, targetPod.Name, tolerateFencedInstances); err != nil {
+		return err
+	}
+
+	if !utils.IsPodActive(*targetPod) {
+		return fmt.Errorf("pod %s is not active and can't be fenced", targetPod.Name)
+	}
+
+	return nil
+}
+
+// resolveSnapshotClassName checks that the passed volume snapshot class
+// exists. When no class is selected, the snapshot of the PVC would use the
+// default class of the CSI driver of its storage class, which must exist
+func (se *Reconciler) resolveSnapshotClassName(
+	ctx context.Context,
+	pvc *corev1.PersistentVolumeClaim,
+	className *string,
+) (string, error) {
+	if className != nil {
+		var snapshotClass storagesnapshotv1.VolumeSnapshotClass
+		if err := se.cli.Get(ctx, types.NamespacedName{Name: *className}, &snapshotClass); err != nil {
+			if apierrs.IsNotFound(err) {
+				return "", fmt.Errorf("volume snapshot class %s not found", *className)
+			}
+			return "", err
+		}
+		return *className, nil
+	}
+
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return "", errors.New("no volume snapshot class selected, and no storage class to find the default one")
+	}
+
+	var storageClass storagev1.StorageClass
+	if err := se.cli.Get(ctx, types.NamespacedName{Name: *pvc.Spec.StorageClassName}, &storageClass); err != nil {
+		return "", fmt.Errorf("while getting storage class %s: %w", *pvc.Spec.StorageClassName, err)
+	}
+
+	var snapshotClasses storagesnapshotv1.VolumeSnapshotClassList
+	if err := se.cli.List(ctx, &snapshotClasses); err != nil {
+		return "", err
+	}
+
+	for _, snapshotClass := range snapshotClasses.Items {
+		if snapshotClass.Driver == storageClass.Provisioner &&
+			snapshotClass.Annotations[isDefaultSnapshotClassAnnotationName] == "true" {
+			return snapshotClass.Name, nil
+		}
+	}
+
+	return "", fmt.Errorf("no default volume snapshot class for the CSI driver %s", storageClass.Provisioner)
+}
@@ -0,0 +1,208 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("volume snapshot backup dry-run", func() {
+	var (
+		cluster   *apiv1.Cluster
+		backup    *apiv1.Backup
+		targetPod *corev1.Pod
+		pvcs      []corev1.PersistentVolumeClaim
+		objects   []client.Object
+	)
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				WalStorage: &apiv1.StorageConfiguration{},
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						ClassName: "csi-snapclass",
+					},
+				},
+			},
+		}
+		backup = &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backup-example",
+				Namespace: "default",
+			},
+			Spec: apiv1.BackupSpec{
+				Method: apiv1.BackupMethodVolumeSnapshot,
+				DryRun: true,
+			},
+		}
+		targetPod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example-2",
+				Namespace: "default",
+			},
+		}
+		pvcs = []corev1.PersistentVolumeClaim{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-example-2",
+					Namespace: "default",
+					Labels: map[string]string{
+						utils.PvcRoleLabelName: string(utils.PVCRolePgData),
+					},
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{StorageClassName: ptr.To("standard")},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-example-2-wal",
+					Namespace: "default",
+					Labels: map[string]string{
+						utils.PvcRoleLabelName: string(utils.PVCRolePgWal),
+					},
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{StorageClassName: ptr.To("standard")},
+			},
+		}
+		objects = []client.Object{
+			cluster, backup, targetPod,
+			&storagesnapshotv1.VolumeSnapshotClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "csi-snapclass"},
+				Driver:     "csi.example.com",
+			},
+		}
+	})
+
+	execute := func(ctx context.Context) (client.Client, error) {
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(objects...).
+			WithStatusSubresource(backup).
+			Build()
+		executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+			FenceInstance(true).
+			DryRun(true).
+			Build()
+
+		res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(res).To(BeNil())
+		return cli, err
+	}
+
+	It("reports the snapshots without taking them nor fencing the instance", func(ctx context.Context) {
+		cli, err := execute(ctx)
+		Expect(err).ToNot(HaveOccurred())
+
+		snapshots := backup.Status.BackupSnapshotStatus.DryRunSnapshots
+		Expect(snapshots).To(HaveLen(2))
+		Expect(snapshots[0].PVCName).To(Equal("cluster-example-2"))
+		Expect(snapshots[0].SnapshotName).To(HavePrefix("cluster-example-2-"))
+		Expect(snapshots[0].ClassName).To(Equal("csi-snapclass"))
+		Expect(snapshots[1].PVCName).To(Equal("cluster-example-2-wal"))
+
+		existingSnapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(existingSnapshots).To(BeEmpty())
+
+		var current apiv1.Cluster
+		Expect(cli.Get(ctx, client.ObjectKeyFromObject(cluster), &current)).To(Succeed())
+		fencedInstances, err := utils.GetFencedInstances(current.Annotations)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fencedInstances.Len()).To(BeZero())
+	})
+
+	It("fails when a PVC of the instance is missing", func(ctx context.Context) {
+		pvcs = pvcs[:1]
+		_, err := execute(ctx)
+		Expect(err).To(MatchError(ContainSubstring("missing PVC cluster-example-2-wal")))
+	})
+
+	It("fails when the snapshot class doesn't exist", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.ClassName = "missing"
+		_, err := execute(ctx)
+		Expect(err).To(MatchError(ContainSubstring("volume snapshot class missing not found")))
+	})
+
+	It("resolves the default snapshot class of the storage class driver", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.ClassName = ""
+		objects = append(objects,
+			&storagev1.StorageClass{
+				ObjectMeta:  metav1.ObjectMeta{Name: "standard"},
+				Provisioner: "csi.example.com",
+			},
+			&storagesnapshotv1.VolumeSnapshotClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "default-snapclass",
+					Annotations: map[string]string{isDefaultSnapshotClassAnnotationName: "true"},
+				},
+				Driver: "csi.example.com",
+			},
+		)
+
+		_, err := execute(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(backup.Status.BackupSnapshotStatus.DryRunSnapshots[0].ClassName).To(Equal("default-snapclass"))
+	})
+
+	It("fails when there's no default snapshot class", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.ClassName = ""
+		objects = append(objects, &storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "standard"},
+			Provisioner: "csi.example.com",
+		})
+
+		_, err := execute(ctx)
+		Expect(err).To(MatchError(ContainSubstring("no default volume snapshot class")))
+	})
+
+	It("fails when other instances are fenced", func(ctx context.Context) {
+		Expect(utils.AddFencedInstance("cluster-example-1", &cluster.ObjectMeta)).To(Succeed())
+		_, err := execute(ctx)
+		Expect(err).To(MatchError(ContainSubstring("fenced instances")))
+	})
+
+	It("succeeds when other instances are fenced but tolerated", func(ctx context.Context) {
+		Expect(utils.AddFencedInstance("cluster-example-1", &cluster.ObjectMeta)).To(Succeed())
+		Expect(ensurePodCanBeFenced(cluster, targetPod, true)).To(Succeed())
+	})
+
+	It("fails when the instance is not active", func(ctx context.Context) {
+		targetPod.Status.Phase = corev1.PodFailed
+		_, err := execute(ctx)
+		Expect(err).To(MatchError(ContainSubstring("can't be fenced")))
+	})
+})
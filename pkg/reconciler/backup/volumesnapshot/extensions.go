@@ -0,0 +1,90 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// GetRequiredExtensions returns the names of the extensions that were
+// installed in the cluster when the PG_DATA volume snapshot used to
+// bootstrap the passed cluster was taken. An empty list is returned
+// when the snapshot doesn't carry this information
+func GetRequiredExtensions(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+) ([]string, error) {
+	if cluster.Spec.Bootstrap == nil ||
+		cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.VolumeSnapshots == nil {
+		return nil, nil
+	}
+
+	var snapshot storagesnapshotv1.VolumeSnapshot
+	err := cli.Get(
+		ctx,
+		client.ObjectKey{
+			Namespace: cluster.Namespace,
+			Name:      cluster.Spec.Bootstrap.Recovery.VolumeSnapshots.Storage.Name,
+		},
+		&snapshot,
+	)
+	if apierrs.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	extensions, err := getSnapshotExtensions(&snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(extensions))
+	for _, extension := range extensions {
+		names = append(names, extension.Name)
+	}
+
+	return names, nil
+}
+
+// getSnapshotExtensions parses the list of extensions recorded
+// in the passed volume snapshot
+func getSnapshotExtensions(snapshot *storagesnapshotv1.VolumeSnapshot) ([]apiv1.InstalledExtension, error) {
+	rawExtensions, ok := snapshot.Annotations[utils.InstalledExtensionsAnnotationName]
+	if !ok {
+		return nil, nil
+	}
+
+	var extensions []apiv1.InstalledExtension
+	if err := json.Unmarshal([]byte(rawExtensions), &extensions); err != nil {
+		return nil, fmt.Errorf("while decoding the extensions of volume snapshot %s: %w", snapshot.Name, err)
+	}
+
+	return extensions, nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("required extensions", func() {
+	var cluster *apiv1.Cluster
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-restore",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						VolumeSnapshots: &apiv1.DataSource{
+							Storage: corev1.TypedLocalObjectReference{
+								APIGroup: ptr.To(storagesnapshotv1.GroupName),
+								Kind:     "VolumeSnapshot",
+								Name:     "backup-data",
+							},
+						},
+					},
+				},
+			},
+		}
+	})
+
+	newSnapshot := func(annotations map[string]string) *storagesnapshotv1.VolumeSnapshot {
+		return &storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "backup-data",
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+		}
+	}
+
+	It("returns the extensions recorded in the volume snapshot", func(ctx context.Context) {
+		snapshot := newSnapshot(map[string]string{
+			utils.InstalledExtensionsAnnotationName: `[` +
+				`{"name":"pg_stat_statements","version":"1.10"},` +
+				`{"name":"pgcrypto","version":"1.3"},` +
+				`{"name":"plpgsql","version":"1.0"},` +
+				`{"name":"postgis","version":"3.4.0"}]`,
+		})
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(snapshot).
+			Build()
+
+		extensions, err := GetRequiredExtensions(ctx, cli, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extensions).To(Equal([]string{"pg_stat_statements", "pgcrypto", "plpgsql", "postgis"}))
+	})
+
+	It("returns no extension when the volume snapshot doesn't record them", func(ctx context.Context) {
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(newSnapshot(nil)).
+			Build()
+
+		extensions, err := GetRequiredExtensions(ctx, cli, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extensions).To(BeEmpty())
+	})
+
+	It("returns no extension when the volume snapshot is missing", func(ctx context.Context) {
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			Build()
+
+		extensions, err := GetRequiredExtensions(ctx, cli, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extensions).To(BeEmpty())
+	})
+
+	It("fails when the recorded extensions cannot be decoded", func(ctx context.Context) {
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(newSnapshot(map[string]string{
+				utils.InstalledExtensionsAnnotationName: "not-json",
+			})).
+			Build()
+
+		_, err := GetRequiredExtensions(ctx, cli, cluster)
+		Expect(err).To(HaveOccurred())
+	})
+})
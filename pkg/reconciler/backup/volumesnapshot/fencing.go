@@ -0,0 +1,212 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// errFencedInstances is raised when the target instance of a backup can't
+// be fenced because of the other instances fenced in the cluster
+var errFencedInstances = errors.New("cannot execute volume snapshot on a cluster that has fenced instances")
+
+// checkFencedInstances checks that the target instance can be fenced by a
+// backup, given the instances already fenced in the cluster, and reports
+// whether the target instance is already fenced. Unless other fenced
+// instances are tolerated, the target instance must be the only fenced one.
+// Fencing the whole cluster is never tolerated, as the fencing of the
+// target instance couldn't be removed when the backup is completed
+func checkFencedInstances(cluster *apiv1.Cluster, targetPodName string, tolerateOthers bool) (bool, error) {
+	fencedInstances, err := utils.GetFencedInstances(cluster.Annotations)
+	if err != nil {
+		return false, fmt.Errorf("could not check if cluster is fenced: %v", err)
+	}
+
+	if fencedInstances.Has(utils.FenceAllServers) {
+		return false, errFencedInstances
+	}
+
+	alreadyFenced := fencedInstances.Has(targetPodName)
+	if !tolerateOthers && (fencedInstances.Len() > 1 || (fencedInstances.Len() == 1 && !alreadyFenced)) {
+		return false, errFencedInstances
+	}
+
+	return alreadyFenced, nil
+}
+
+// RemoveStaleBackupFences removes the fencing requests issued by volume
+// snapshot backups that don't exist anymore, so that an instance is not
+// left fenced when its backup has been deleted while running.
+// Only the fencing of the instances recorded as fenced by such backups is
+// removed: fencing requests that were not issued by a backup, or that have
+// been taken over by the user, are never touched.
+func RemoveStaleBackupFences(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+) error {
+	contextLogger := log.FromContext(ctx)
+
+	fenceOrigins, err := utils.GetBackupFenceOrigins(cluster.Annotations)
+	if err != nil {
+		return err
+	}
+	if len(fenceOrigins) == 0 {
+		return nil
+	}
+
+	fencedInstances, err := utils.GetFencedInstances(cluster.Annotations)
+	if err != nil {
+		return err
+	}
+
+	staleFences := false
+	for instanceName, backupName := range fenceOrigins {
+		var backup apiv1.Backup
+		err := cli.Get(ctx, types.NamespacedName{Name: backupName, Namespace: cluster.Namespace}, &backup)
+		if err == nil {
+			continue
+		}
+		if !apierrs.IsNotFound(err) {
+			return err
+		}
+
+		contextLogger.Info("Removing the fencing requested by a backup that doesn't exist anymore",
+			"instance", instanceName, "backup", backupName)
+		fencedInstances.Delete(instanceName)
+		delete(fenceOrigins, instanceName)
+		staleFences = true
+	}
+
+	if !staleFences {
+		return nil
+	}
+
+	origCluster := cluster.DeepCopy()
+	if err := utils.SetFencedInstances(&cluster.ObjectMeta, fencedInstances); err != nil {
+		return err
+	}
+	if err := utils.SetBackupFenceOrigins(&cluster.ObjectMeta, fenceOrigins); err != nil {
+		return err
+	}
+
+	return cli.Patch(ctx, cluster, client.MergeFrom(origCluster))
+}
+
+// isFenceDurationExceeded checks whether the target instance of the backup
+// has been fenced for longer than the maximum fence duration of the cluster
+func isFenceDurationExceeded(cluster *apiv1.Cluster, backup *apiv1.Backup, now time.Time) bool {
+	maxFenceDuration := cluster.Spec.Backup.VolumeSnapshot.GetMaxFenceDuration()
+	fencedAt := backup.Status.BackupSnapshotStatus.FencedAt
+	if maxFenceDuration == 0 || fencedAt == nil {
+		return false
+	}
+
+	return now.Sub(fencedAt.Time) > maxFenceDuration
+}
+
+// setFenceDuration records in the backup status how long the target
+// instance has been fenced
+func setFenceDuration(backup *apiv1.Backup, now time.Time) {
+	fencedAt := backup.Status.BackupSnapshotStatus.FencedAt
+	if fencedAt == nil {
+		return
+	}
+
+	backup.Status.BackupSnapshotStatus.FenceDuration = now.Sub(fencedAt.Time).Round(time.Second).String()
+}
+
+// recordFenceStart stores in the backup status when the fencing of the
+// target instance has been requested. The status is patched immediately,
+// as the fencing spans multiple reconciliation loops
+func (se *Reconciler) recordFenceStart(ctx context.Context, backup *apiv1.Backup, now time.Time) error {
+	origBackup := backup.DeepCopy()
+	fencedAt := metav1.NewTime(now)
+	backup.Status.BackupSnapshotStatus.FencedAt = &fencedAt
+	return se.backupCli.Status().Patch(ctx, backup, client.MergeFrom(origBackup))
+}
+
+// isRetryableError checks whether the passed error is transient, in which
+// case the backup controller retries the backup instead of failing it
+func isRetryableError(err error) bool {
+	return apierrs.IsServerTimeout(err) || apierrs.IsConflict(err) || apierrs.IsInternalError(err)
+}
+
+// unfenceOnError unfences the target instance of a backup which failed
+// after requesting its fencing. A failure in unfencing the instance is
+// logged, and doesn't replace the error which made the backup fail
+func (se *Reconciler) unfenceOnError(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	targetPod *corev1.Pod,
+	backupErr error,
+) {
+	contextLogger := log.FromContext(ctx).WithValues("podName", targetPod.Name)
+	contextLogger.Info("Unfencing the instance after an error in the snapshot backup",
+		"backupError", backupErr.Error())
+
+	if err := se.EnsurePodIsUnfenced(ctx, cluster, backup, targetPod); err != nil {
+		contextLogger.Error(err, "while unfencing the instance after an error in the snapshot backup")
+		se.recorder.Eventf(backup, "Warning", "UnfencePod",
+			"Cannot un-fence Pod %v after an error in the snapshot backup: %v", targetPod.Name, err)
+	}
+}
+
+// failOnFenceDurationExceeded gives up on the backup whose target instance
+// has been fenced for too long, deleting the snapshots taken so far. The
+// caller is expected to fail the backup and unfence the instance
+func (se *Reconciler) failOnFenceDurationExceeded(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	snapshots []storagesnapshotv1.VolumeSnapshot,
+	now time.Time,
+) error {
+	maxFenceDuration := cluster.Spec.Backup.VolumeSnapshot.GetMaxFenceDuration()
+	log.FromContext(ctx).Info("Maximum fence duration exceeded, giving up the backup to unfence the instance",
+		"maxFenceDuration", maxFenceDuration)
+	se.recorder.Eventf(backup, "Warning", "FenceDurationExceeded",
+		"Instance fenced for longer than %s, giving up the backup", maxFenceDuration)
+
+	origBackup := backup.DeepCopy()
+	setFenceDuration(backup, now)
+	backup.Status.BackupSnapshotStatus.FenceDurationExceeded = true
+	if err := se.backupCli.Status().Patch(ctx, backup, client.MergeFrom(origBackup)); err != nil {
+		return err
+	}
+
+	if err := se.deleteSnapshots(ctx, snapshots); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("instance fenced for longer than the maximum fence duration of %s", maxFenceDuration)
+}
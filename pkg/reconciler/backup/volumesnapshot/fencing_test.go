@@ -0,0 +1,312 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stale backup fences removal", func() {
+	const namespace = "default"
+
+	newCluster := func(fencedInstances []string, fenceOrigins map[string]string) *apiv1.Cluster {
+		rawFencedInstances, err := json.Marshal(fencedInstances)
+		Expect(err).ToNot(HaveOccurred())
+		rawFenceOrigins, err := json.Marshal(fenceOrigins)
+		Expect(err).ToNot(HaveOccurred())
+
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example",
+				Namespace: namespace,
+				Annotations: map[string]string{
+					utils.FencedInstanceAnnotation:        string(rawFencedInstances),
+					utils.BackupFenceOriginAnnotationName: string(rawFenceOrigins),
+				},
+			},
+		}
+	}
+
+	newBackup := func(name string) *apiv1.Backup {
+		return &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+		}
+	}
+
+	getCluster := func(ctx context.Context, cli client.Client) *apiv1.Cluster {
+		var cluster apiv1.Cluster
+		err := cli.Get(ctx, types.NamespacedName{Name: "cluster-example", Namespace: namespace}, &cluster)
+		Expect(err).ToNot(HaveOccurred())
+		return &cluster
+	}
+
+	It("removes only the fences requested by missing backups", func(ctx context.Context) {
+		cluster := newCluster(
+			[]string{"cluster-example-1", "cluster-example-2", "cluster-example-3"},
+			map[string]string{
+				"cluster-example-1": "existing-backup",
+				"cluster-example-2": "deleted-backup",
+			},
+		)
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, newBackup("existing-backup")).
+			Build()
+
+		err := RemoveStaleBackupFences(ctx, cli, cluster)
+		Expect(err).ToNot(HaveOccurred())
+
+		updatedCluster := getCluster(ctx, cli)
+		fencedInstances, err := utils.GetFencedInstances(updatedCluster.Annotations)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fencedInstances.ToList()).To(ConsistOf("cluster-example-1", "cluster-example-3"))
+
+		fenceOrigins, err := utils.GetBackupFenceOrigins(updatedCluster.Annotations)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fenceOrigins).To(Equal(map[string]string{"cluster-example-1": "existing-backup"}))
+	})
+
+	It("never removes the fences requested by users", func(ctx context.Context) {
+		cluster := newCluster(
+			[]string{utils.FenceAllServers},
+			map[string]string{
+				"cluster-example-2": "deleted-backup",
+			},
+		)
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster).
+			Build()
+
+		err := RemoveStaleBackupFences(ctx, cli, cluster)
+		Expect(err).ToNot(HaveOccurred())
+
+		updatedCluster := getCluster(ctx, cli)
+		fencedInstances, err := utils.GetFencedInstances(updatedCluster.Annotations)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fencedInstances.ToList()).To(ConsistOf(utils.FenceAllServers))
+		Expect(updatedCluster.Annotations).ToNot(HaveKey(utils.BackupFenceOriginAnnotationName))
+	})
+
+	It("does nothing when every fencing backup still exists", func(ctx context.Context) {
+		cluster := newCluster(
+			[]string{"cluster-example-1"},
+			map[string]string{
+				"cluster-example-1": "existing-backup",
+			},
+		)
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, newBackup("existing-backup")).
+			Build()
+
+		err := RemoveStaleBackupFences(ctx, cli, cluster)
+		Expect(err).ToNot(HaveOccurred())
+
+		updatedCluster := getCluster(ctx, cli)
+		Expect(updatedCluster.Annotations).To(Equal(cluster.Annotations))
+	})
+})
+
+var _ = Describe("Unfencing a Pod fenced by a backup", func() {
+	const namespace = "default"
+
+	var (
+		cli      client.Client
+		recorder *record.FakeRecorder
+		pod      *corev1.Pod
+		backup   *apiv1.Backup
+	)
+
+	newCluster := func(annotations map[string]string) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "cluster-example",
+				Namespace:   namespace,
+				Annotations: annotations,
+			},
+		}
+	}
+
+	getCluster := func(ctx context.Context) *apiv1.Cluster {
+		var cluster apiv1.Cluster
+		err := cli.Get(ctx, types.NamespacedName{Name: "cluster-example", Namespace: namespace}, &cluster)
+		Expect(err).ToNot(HaveOccurred())
+		return &cluster
+	}
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example-1",
+				Namespace: namespace,
+			},
+		}
+		backup = &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backup-one",
+				Namespace: namespace,
+			},
+		}
+	})
+
+	It("can be safely run more than once", func(ctx context.Context) {
+		cluster := newCluster(map[string]string{
+			utils.FencedInstanceAnnotation:        `["cluster-example-1"]`,
+			utils.BackupFenceOriginAnnotationName: `{"cluster-example-1":"backup-one"}`,
+		})
+		cli = fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, pod).
+			Build()
+		reconciler := NewExecutorBuilder(cli, recorder).FenceInstance(true).Build()
+
+		Expect(reconciler.EnsurePodIsUnfenced(ctx, cluster, backup, pod)).To(Succeed())
+		updatedCluster := getCluster(ctx)
+		Expect(updatedCluster.Annotations).ToNot(HaveKey(utils.FencedInstanceAnnotation))
+		Expect(updatedCluster.Annotations).ToNot(HaveKey(utils.BackupFenceOriginAnnotationName))
+		Expect(recorder.Events).To(Receive(ContainSubstring("Un-fencing Pod")))
+
+		Expect(reconciler.EnsurePodIsUnfenced(ctx, updatedCluster, backup, pod)).To(Succeed())
+		Expect(getCluster(ctx).Annotations).To(Equal(updatedCluster.Annotations))
+		Expect(recorder.Events).ToNot(Receive())
+	})
+
+	It("doesn't remove a fence which has not been requested by the backup", func(ctx context.Context) {
+		cluster := newCluster(map[string]string{
+			utils.FencedInstanceAnnotation: `["cluster-example-1"]`,
+		})
+		cli = fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, pod).
+			Build()
+		reconciler := NewExecutorBuilder(cli, recorder).FenceInstance(true).Build()
+
+		Expect(reconciler.EnsurePodIsUnfenced(ctx, cluster, backup, pod)).To(Succeed())
+		Expect(getCluster(ctx).Annotations).To(HaveKeyWithValue(utils.FencedInstanceAnnotation, `["cluster-example-1"]`))
+		Expect(recorder.Events).To(Receive(ContainSubstring("has not been fenced by this backup")))
+	})
+})
+
+var _ = Describe("Fencing the target Pod of a backup", func() {
+	const namespace = "default"
+
+	var (
+		cli     client.Client
+		cluster *apiv1.Cluster
+		pod     *corev1.Pod
+		backup  *apiv1.Backup
+	)
+
+	getFencedInstances := func(ctx context.Context) []string {
+		var updatedCluster apiv1.Cluster
+		err := cli.Get(ctx, types.NamespacedName{Name: "cluster-example", Namespace: namespace}, &updatedCluster)
+		Expect(err).ToNot(HaveOccurred())
+		fencedInstances, err := utils.GetFencedInstances(updatedCluster.Annotations)
+		Expect(err).ToNot(HaveOccurred())
+		return fencedInstances.ToList()
+	}
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example",
+				Namespace: namespace,
+				Annotations: map[string]string{
+					utils.FencedInstanceAnnotation: `["cluster-example-2"]`,
+				},
+			},
+		}
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example-3",
+				Namespace: namespace,
+			},
+		}
+		backup = &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backup-one",
+				Namespace: namespace,
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		cli = fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, pod).
+			Build()
+	})
+
+	It("refuses to fence the target Pod when other instances are fenced", func(ctx context.Context) {
+		reconciler := NewExecutorBuilder(cli, record.NewFakeRecorder(10)).FenceInstance(true).Build()
+
+		err := reconciler.ensurePodIsFenced(ctx, cluster, backup, pod.Name)
+		Expect(err).To(MatchError(errFencedInstances))
+		Expect(getFencedInstances(ctx)).To(Equal([]string{"cluster-example-2"}))
+	})
+
+	It("fences the target Pod next to the tolerated fenced instances", func(ctx context.Context) {
+		reconciler := NewExecutorBuilder(cli, record.NewFakeRecorder(10)).
+			FenceInstance(true).
+			TolerateFencedInstances(true).
+			Build()
+
+		Expect(reconciler.ensurePodIsFenced(ctx, cluster, backup, pod.Name)).To(Succeed())
+		Expect(getFencedInstances(ctx)).To(ConsistOf("cluster-example-2", "cluster-example-3"))
+
+		var updatedCluster apiv1.Cluster
+		Expect(cli.Get(ctx, client.ObjectKeyFromObject(cluster), &updatedCluster)).To(Succeed())
+		Expect(reconciler.EnsurePodIsUnfenced(ctx, &updatedCluster, backup, pod)).To(Succeed())
+		Expect(getFencedInstances(ctx)).To(Equal([]string{"cluster-example-2"}))
+	})
+
+	When("the whole cluster is fenced", func() {
+		BeforeEach(func() {
+			cluster.Annotations[utils.FencedInstanceAnnotation] = `["*"]`
+		})
+
+		It("refuses to fence the target Pod even if fenced instances are tolerated", func(ctx context.Context) {
+			reconciler := NewExecutorBuilder(cli, record.NewFakeRecorder(10)).
+				FenceInstance(true).
+				TolerateFencedInstances(true).
+				Build()
+
+			err := reconciler.ensurePodIsFenced(ctx, cluster, backup, pod.Name)
+			Expect(err).To(MatchError(errFencedInstances))
+		})
+	})
+})
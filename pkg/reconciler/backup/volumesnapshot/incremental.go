@@ -0,0 +1,166 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// getParentSnapshots finds, for the passed PVCs whose snapshot class
+// supports incremental snapshots, the snapshot taken by the most recent
+// completed backup of the cluster, which the new snapshots are chained to.
+// This is done on a best-effort basis, and the PVCs without a parent
+// snapshot get a full snapshot. Returns the parent snapshots by PVC name
+func (se *Reconciler) getParentSnapshots(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	targetPod *corev1.Pod,
+	pvcs []corev1.PersistentVolumeClaim,
+) map[string]*storagesnapshotv1.VolumeSnapshot {
+	contextLogger := log.FromContext(ctx)
+
+	snapshotConfig := cluster.Spec.Backup.VolumeSnapshot
+	if !snapshotConfig.Incremental || len(pvcs) == 0 {
+		return nil
+	}
+
+	var backupList apiv1.BackupList
+	if err := se.backupCli.List(ctx, &backupList, client.InNamespace(backup.Namespace)); err != nil {
+		contextLogger.Info("Cannot list the backups, taking full snapshots", "err", err.Error())
+		return nil
+	}
+
+	previousBackup := getLatestCompletedSnapshotBackup(cluster, backup, backupList.Items)
+	if previousBackup == nil {
+		return nil
+	}
+
+	candidates, err := GetBackupVolumeSnapshots(ctx, se.cli, pvcs[0].Namespace, previousBackup.Name)
+	if err != nil {
+		contextLogger.Info("Cannot list the snapshots of the previous backup, taking full snapshots",
+			"backupName", previousBackup.Name, "err", err.Error())
+		return nil
+	}
+
+	result := make(map[string]*storagesnapshotv1.VolumeSnapshot)
+	supportedClasses := make(map[string]bool)
+	for i := range pvcs {
+		pvc := &pvcs[i]
+		className := getPVCSnapshotClassName(snapshotConfig, pvc, isPrimaryTarget(cluster, targetPod))
+		if className == nil {
+			continue
+		}
+
+		supported, ok := supportedClasses[*className]
+		if !ok {
+			supported = se.isIncrementalSnapshotClass(ctx, *className)
+			supportedClasses[*className] = supported
+		}
+		if !supported {
+			contextLogger.Info("The snapshot class doesn't support incremental snapshots, taking a full snapshot",
+				"pvcName", pvc.Name, "className", *className)
+			continue
+		}
+
+		if parent := findParentSnapshot(pvc.Name, candidates); parent != nil {
+			result[pvc.Name] = parent
+		}
+	}
+
+	return result
+}
+
+// isIncrementalSnapshotClass checks whether the passed volume snapshot
+// class declares that its CSI driver supports incremental snapshots
+func (se *Reconciler) isIncrementalSnapshotClass(ctx context.Context, className string) bool {
+	var snapshotClass storagesnapshotv1.VolumeSnapshotClass
+	if err := se.cli.Get(ctx, types.NamespacedName{Name: className}, &snapshotClass); err != nil {
+		log.FromContext(ctx).Info("Cannot get the volume snapshot class, taking a full snapshot",
+			"className", className, "err", err.Error())
+		return false
+	}
+
+	return snapshotClass.Annotations[utils.IncrementalSnapshotsAnnotationName] == "true"
+}
+
+// getLatestCompletedSnapshotBackup gets, among the passed backups, the
+// volume snapshot backup of the cluster which completed last, excluding
+// the passed one
+func getLatestCompletedSnapshotBackup(
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	backups []apiv1.Backup,
+) *apiv1.Backup {
+	completedBackups := getCompletedSnapshotBackups(cluster, backups)
+
+	var result *apiv1.Backup
+	for i := range completedBackups {
+		candidate := &completedBackups[i]
+		if candidate.Name == backup.Name || candidate.Status.StoppedAt == nil {
+			continue
+		}
+		if result == nil || candidate.Status.StoppedAt.After(result.Status.StoppedAt.Time) {
+			result = candidate
+		}
+	}
+
+	return result
+}
+
+// findParentSnapshot finds, among the passed candidates, the ready snapshot
+// of the given PVC an incremental snapshot can be chained to
+func findParentSnapshot(
+	pvcName string,
+	candidates []storagesnapshotv1.VolumeSnapshot,
+) *storagesnapshotv1.VolumeSnapshot {
+	for i := range candidates {
+		snapshot := &candidates[i]
+
+		source := snapshot.Spec.Source.PersistentVolumeClaimName
+		switch {
+		case source == nil || *source != pvcName:
+			continue
+		case !snapshot.DeletionTimestamp.IsZero():
+			continue
+		case snapshot.Status == nil || snapshot.Status.ReadyToUse == nil || !*snapshot.Status.ReadyToUse:
+			continue
+		}
+
+		return snapshot
+	}
+
+	return nil
+}
+
+// setParentSnapshot records in the passed snapshot the parent snapshot
+// it is chained to
+func setParentSnapshot(snapshot, parent *storagesnapshotv1.VolumeSnapshot) {
+	snapshot.Annotations[utils.ParentSnapshotAnnotationName] = parent.Name
+	if contentName := parent.Status.BoundVolumeSnapshotContentName; contentName != nil {
+		snapshot.Annotations[utils.ParentSnapshotContentAnnotationName] = *contentName
+	}
+}
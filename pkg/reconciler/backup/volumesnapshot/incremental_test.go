@@ -0,0 +1,183 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("incremental snapshots", func() {
+	var (
+		cluster       *apiv1.Cluster
+		backup        *apiv1.Backup
+		targetPod     *corev1.Pod
+		pvcs          []corev1.PersistentVolumeClaim
+		snapshotClass *storagesnapshotv1.VolumeSnapshotClass
+	)
+
+	newCompletedBackup := func(name string, stoppedAt time.Time) *apiv1.Backup {
+		return &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: apiv1.BackupSpec{
+				Cluster: apiv1.LocalObjectReference{Name: "cluster-example"},
+				Method:  apiv1.BackupMethodVolumeSnapshot,
+			},
+			Status: apiv1.BackupStatus{
+				Phase:     apiv1.BackupPhaseCompleted,
+				StoppedAt: ptr.To(metav1.NewTime(stoppedAt)),
+			},
+		}
+	}
+
+	newSnapshot := func(name, backupName, pvcName string, ready bool) *storagesnapshotv1.VolumeSnapshot {
+		return &storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels: map[string]string{
+					utils.ClusterLabelName:    "cluster-example",
+					utils.BackupNameLabelName: backupName,
+				},
+			},
+			Spec: storagesnapshotv1.VolumeSnapshotSpec{
+				Source: storagesnapshotv1.VolumeSnapshotSource{PersistentVolumeClaimName: ptr.To(pvcName)},
+			},
+			Status: &storagesnapshotv1.VolumeSnapshotStatus{
+				ReadyToUse:                     ptr.To(ready),
+				BoundVolumeSnapshotContentName: ptr.To("snapcontent-" + name),
+			},
+		}
+	}
+
+	newExecutor := func(objects ...client.Object) *Reconciler {
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(objects...).
+			Build()
+		return NewExecutorBuilder(cli, record.NewFakeRecorder(10)).Build()
+	}
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						ClassName:   "csi-snapclass",
+						Incremental: true,
+					},
+				},
+			},
+		}
+		backup = &apiv1.Backup{ObjectMeta: metav1.ObjectMeta{Name: "backup-new", Namespace: "default"}}
+		targetPod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-1", Namespace: "default"}}
+		pvcs = []corev1.PersistentVolumeClaim{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-example-1",
+					Namespace: "default",
+					Labels:    map[string]string{utils.PvcRoleLabelName: string(utils.PVCRolePgData)},
+				},
+			},
+		}
+		snapshotClass = &storagesnapshotv1.VolumeSnapshotClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "csi-snapclass",
+				Annotations: map[string]string{utils.IncrementalSnapshotsAnnotationName: "true"},
+			},
+			Driver:         "csi.example.com",
+			DeletionPolicy: storagesnapshotv1.VolumeSnapshotContentDelete,
+		}
+	})
+
+	It("chains the snapshots to the ones of the last completed backup", func(ctx context.Context) {
+		now := time.Now()
+		executor := newExecutor(
+			snapshotClass,
+			newCompletedBackup("backup-old", now.Add(-48*time.Hour)),
+			newCompletedBackup("backup-last", now.Add(-24*time.Hour)),
+			newSnapshot("snapshot-old", "backup-old", "cluster-example-1", true),
+			newSnapshot("snapshot-last", "backup-last", "cluster-example-1", true),
+		)
+
+		parents := executor.getParentSnapshots(ctx, cluster, backup, targetPod, pvcs)
+		Expect(parents).To(HaveKey("cluster-example-1"))
+		Expect(parents["cluster-example-1"].Name).To(Equal("snapshot-last"))
+	})
+
+	It("takes full snapshots when the driver doesn't support incremental snapshots", func(ctx context.Context) {
+		snapshotClass.Annotations = nil
+		executor := newExecutor(
+			snapshotClass,
+			newCompletedBackup("backup-last", time.Now().Add(-24*time.Hour)),
+			newSnapshot("snapshot-last", "backup-last", "cluster-example-1", true),
+		)
+
+		Expect(executor.getParentSnapshots(ctx, cluster, backup, targetPod, pvcs)).To(BeEmpty())
+	})
+
+	It("takes full snapshots when there's no ready snapshot of a previous backup", func(ctx context.Context) {
+		executor := newExecutor(
+			snapshotClass,
+			newCompletedBackup("backup-last", time.Now().Add(-24*time.Hour)),
+			newSnapshot("snapshot-last", "backup-last", "cluster-example-1", false),
+		)
+		Expect(executor.getParentSnapshots(ctx, cluster, backup, targetPod, pvcs)).To(BeEmpty())
+
+		executor = newExecutor(snapshotClass)
+		Expect(executor.getParentSnapshots(ctx, cluster, backup, targetPod, pvcs)).To(BeEmpty())
+	})
+
+	It("doesn't chain the snapshots when not enabled", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.Incremental = false
+		executor := newExecutor(
+			snapshotClass,
+			newCompletedBackup("backup-last", time.Now().Add(-24*time.Hour)),
+			newSnapshot("snapshot-last", "backup-last", "cluster-example-1", true),
+		)
+		Expect(executor.getParentSnapshots(ctx, cluster, backup, targetPod, pvcs)).To(BeNil())
+	})
+
+	It("records the parent in the new snapshot", func(ctx context.Context) {
+		executor := newExecutor()
+		parent := newSnapshot("snapshot-last", "backup-last", "cluster-example-1", true)
+		Expect(executor.createSnapshot(ctx, cluster, backup, targetPod, &pvcs[0], parent, "suffix")).To(Succeed())
+
+		var snapshot storagesnapshotv1.VolumeSnapshot
+		Expect(executor.cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "cluster-example-1-suffix"},
+			&snapshot)).To(Succeed())
+		Expect(snapshot.Annotations).To(HaveKeyWithValue(utils.ParentSnapshotAnnotationName, "snapshot-last"))
+		Expect(snapshot.Annotations).To(HaveKeyWithValue(
+			utils.ParentSnapshotContentAnnotationName, "snapcontent-snapshot-last"))
+	})
+})
@@ -0,0 +1,121 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"fmt"
+	"strings"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// backupTimestampFormat is the format of the time replacing the backup
+// timestamp placeholder, which is valid in a label value
+const backupTimestampFormat = "20060102T150405Z"
+
+// systemMetadataPrefixes is the list of prefixes of the labels and annotations
+// that are managed by Kubernetes or by the operator, and that are never
+// inherited by the snapshots
+var systemMetadataPrefixes = []string{
+	utils.MetadataNamespace + "/",
+	"kubectl.kubernetes.io/",
+	"kubernetes.io/",
+	"k8s.io/",
+}
+
+// getInheritedMetadata returns the entries of the passed metadata whose key
+// starts with one of the given prefixes, skipping the system ones
+func getInheritedMetadata(metadata map[string]string, prefixes []string) map[string]string {
+	result := make(map[string]string)
+	if len(prefixes) == 0 {
+		return result
+	}
+
+	for key, value := range metadata {
+		if hasAnyPrefix(key, systemMetadataPrefixes) {
+			continue
+		}
+
+		if hasAnyPrefix(key, prefixes) {
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+// getPlaceholderValues gets the values replacing the placeholders in the
+// labels and annotations of the passed snapshot
+func getPlaceholderValues(
+	vs *storagesnapshotv1.VolumeSnapshot,
+	backup *apiv1.Backup,
+	cluster *apiv1.Cluster,
+	targetPod *corev1.Pod,
+) map[string]string {
+	startedAt := backup.CreationTimestamp.Time
+	if backup.Status.StartedAt != nil {
+		startedAt = backup.Status.StartedAt.Time
+	}
+
+	return map[string]string{
+		apiv1.VolumeSnapshotPlaceholderClusterName:      cluster.Name,
+		apiv1.VolumeSnapshotPlaceholderClusterNamespace: cluster.Namespace,
+		apiv1.VolumeSnapshotPlaceholderBackupName:       backup.Name,
+		apiv1.VolumeSnapshotPlaceholderBackupTimestamp:  startedAt.UTC().Format(backupTimestampFormat),
+		apiv1.VolumeSnapshotPlaceholderInstanceName:     targetPod.Name,
+		apiv1.VolumeSnapshotPlaceholderInstanceRole:     string(getSnapshotSourceRole(cluster, targetPod)),
+		apiv1.VolumeSnapshotPlaceholderPVCRole:          vs.Labels[utils.PvcRoleLabelName],
+	}
+}
+
+// expandConfiguredMetadata replaces the placeholders in the values of the
+// passed metadata coming from the configured ones. The values overridden
+// by other sources, such as the required labels, are left untouched
+func expandConfiguredMetadata(
+	metadata map[string]string,
+	configured map[string]string,
+	values map[string]string,
+) error {
+	for key, template := range configured {
+		if metadata[key] != template {
+			continue
+		}
+
+		value, err := utils.ExpandPlaceholders(template, values)
+		if err != nil {
+			return fmt.Errorf("while expanding %s: %w", key, err)
+		}
+		metadata[key] = value
+	}
+
+	return nil
+}
+
+// hasAnyPrefix checks if the passed key starts with one of the given prefixes
+func hasAnyPrefix(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
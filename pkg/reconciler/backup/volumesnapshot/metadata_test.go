@@ -0,0 +1,118 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshot metadata inheritance", func() {
+	clusterMetadata := map[string]string{
+		"example.com/team":                                 "dba",
+		"example.com/cost-center":                          "42",
+		"billing.example.com/project":                      "pg",
+		"unrelated":                                        "value",
+		utils.ClusterLabelName:                             "cluster-example",
+		utils.MetadataNamespace + "/example.com":           "operator",
+		"kubectl.kubernetes.io/last-applied-configuration": "{}",
+	}
+
+	It("doesn't inherit anything when no prefix is given", func() {
+		Expect(getInheritedMetadata(clusterMetadata, nil)).To(BeEmpty())
+	})
+
+	It("inherits only the keys matching the designated prefixes", func() {
+		Expect(getInheritedMetadata(clusterMetadata, []string{"example.com/", "billing."})).To(Equal(
+			map[string]string{
+				"example.com/team":            "dba",
+				"example.com/cost-center":     "42",
+				"billing.example.com/project": "pg",
+			},
+		))
+	})
+
+	It("never inherits the system keys", func() {
+		Expect(getInheritedMetadata(clusterMetadata, []string{""})).To(Equal(
+			map[string]string{
+				"example.com/team":            "dba",
+				"example.com/cost-center":     "42",
+				"billing.example.com/project": "pg",
+				"unrelated":                   "value",
+			},
+		))
+	})
+})
+
+var _ = Describe("Snapshot metadata placeholders", func() {
+	var values map[string]string
+
+	BeforeEach(func() {
+		cluster := &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Status:     apiv1.ClusterStatus{CurrentPrimary: "cluster-example-1"},
+		}
+		backup := &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup-example"},
+			Status: apiv1.BackupStatus{
+				StartedAt: &metav1.Time{Time: time.Date(2024, 1, 10, 12, 30, 0, 0, time.UTC)},
+			},
+		}
+		vs := &storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{utils.PvcRoleLabelName: string(utils.PVCRolePgWal)},
+			},
+		}
+		targetPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-2"}}
+		values = getPlaceholderValues(vs, backup, cluster, targetPod)
+	})
+
+	It("replaces the placeholders with the metadata of the snapshot", func() {
+		metadata := map[string]string{
+			"policy": "${cluster.namespace}-${cluster.name}",
+			"source": "${instance.name}-${instance.role}",
+			"volume": "${backup.name}-${pvc.role}-${backup.timestamp}",
+		}
+		Expect(expandConfiguredMetadata(metadata, metadata, values)).To(Succeed())
+		Expect(metadata).To(Equal(map[string]string{
+			"policy": "default-cluster-example",
+			"source": "cluster-example-2-standby",
+			"volume": "backup-example-PG_WAL-20240110T123000Z",
+		}))
+	})
+
+	It("leaves untouched the values overridden by other sources", func() {
+		metadata := map[string]string{"policy": "gold"}
+		Expect(expandConfiguredMetadata(metadata, map[string]string{"policy": "${cluster.name}"}, values)).To(Succeed())
+		Expect(metadata).To(HaveKeyWithValue("policy", "gold"))
+	})
+
+	It("refuses the unknown placeholders", func() {
+		metadata := map[string]string{"policy": "${cluster.uid}"}
+		err := expandConfiguredMetadata(metadata, metadata, values)
+		Expect(err).To(MatchError(ContainSubstring("unknown placeholder ${cluster.uid}")))
+	})
+})
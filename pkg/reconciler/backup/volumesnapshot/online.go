@@ -0,0 +1,275 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// onlineBackupPollInterval is how often the operator checks whether
+// the instance completed pg_backup_start or pg_backup_stop
+const onlineBackupPollInterval = 5 * time.Second
+
+// onlineBackupClient drives the online backups through the
+// instance manager of the target instance
+type onlineBackupClient interface {
+	GetOnlineBackupStatusFromInstance(
+		ctx context.Context,
+		pod *corev1.Pod,
+	) (*postgres.OnlineBackupStatus, error)
+	RequestOnlineBackupInInstance(
+		ctx context.Context,
+		pod *corev1.Pod,
+		request postgres.OnlineBackupRequest,
+	) error
+}
+
+// executeOnline takes the snapshots of the target instance while it is in
+// backup mode, instead of fencing it. PostgreSQL ties the backup mode to
+// the session which started it, so the backup is started and stopped by
+// the instance manager while the operator polls its progress
+func (se *Reconciler) executeOnline(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	targetPod *corev1.Pod,
+	pvcs []corev1.PersistentVolumeClaim,
+	snapshots []storagesnapshotv1.VolumeSnapshot,
+) (*ctrl.Result, error) {
+	contextLogger := log.FromContext(ctx).WithValues("podName", targetPod.Name)
+	onlineConfig := cluster.Spec.Backup.VolumeSnapshot.OnlineConfiguration
+
+	status, err := se.onlineBackupClient.GetOnlineBackupStatusFromInstance(ctx, targetPod)
+	if err != nil {
+		return nil, fmt.Errorf("while getting the online backup status: %w", err)
+	}
+	if status != nil && status.BackupName != backup.Name {
+		// this is a terminated online backup of a previous backup
+		status = nil
+	}
+
+	// Step 1: put the instance in backup mode
+	switch {
+	case status == nil && len(snapshots) == 0:
+		se.recorder.Eventf(backup, "Normal", "StartOnlineBackup",
+			"Starting the online backup in Pod %v", targetPod.Name)
+		if err := se.onlineBackupClient.RequestOnlineBackupInInstance(ctx, targetPod, postgres.OnlineBackupRequest{
+			Action:              postgres.OnlineBackupActionStart,
+			BackupName:          backup.Name,
+			ImmediateCheckpoint: onlineConfig.GetImmediateCheckpoint(),
+		}); err != nil {
+			return nil, fmt.Errorf("while starting the online backup: %w", err)
+		}
+		return &ctrl.Result{RequeueAfter: onlineBackupPollInterval}, nil
+
+	case status == nil:
+		// the backup mode ends together with the session that started it,
+		// i.e. when the instance is restarted, and the snapshots taken
+		// meanwhile can't be restored
+		if err := se.deleteSnapshots(ctx, snapshots); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("the target instance is not in backup mode anymore")
+
+	case status.Phase == postgres.OnlineBackupPhaseFailed:
+		return nil, fmt.Errorf("online backup failed in the target instance: %s", status.Error)
+
+	case status.Phase == postgres.OnlineBackupPhaseStarting:
+		contextLogger.Info("Waiting for the online backup to be started")
+		return &ctrl.Result{RequeueAfter: onlineBackupPollInterval}, nil
+	}
+
+	// Step 2: snapshot the PVCs while the instance is in backup mode
+	if pendingPVCs := getPVCsWithoutSnapshot(pvcs, snapshots); len(pendingPVCs) > 0 {
+		if status.Phase != postgres.OnlineBackupPhaseStarted {
+			return nil, fmt.Errorf("cannot take the snapshots of an online backup in phase %s", status.Phase)
+		}
+		if err := se.ensurePreSnapshotCommandsExecuted(ctx, cluster, backup, targetPod); err != nil {
+			return nil, err
+		}
+		if err := se.setProgressCondition(ctx, backup, apiv1.ConditionBackupSnapshotCreating); err != nil {
+			return nil, err
+		}
+
+		createStartedAt := time.Now()
+		err = se.createSnapshotPVCGroupStep(ctx, cluster, pendingPVCs, backup, targetPod)
+		se.tracePhase(ctx, tracingPhaseCreate, cluster, backup, len(pendingPVCs), createStartedAt, err)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ctrl.Result{RequeueAfter: se.requeueInterval}, nil
+	}
+
+	switch status.Phase {
+	case postgres.OnlineBackupPhaseStarted:
+		// Step 3: wait for the snapshots to be ready, as the instance
+		// must stay in backup mode until they are cut
+		if err := se.setProgressCondition(ctx, backup, apiv1.ConditionBackupSnapshotWaiting); err != nil {
+			return nil, err
+		}
+		res, err := se.waitSnapshotToBeReadyStep(ctx, backup, snapshots)
+		if res != nil && err == nil {
+			return res, nil
+		}
+		se.tracePhase(ctx, tracingPhaseWait, cluster, backup, len(snapshots),
+			getWaitStartTime(backup, snapshots), err)
+		if err != nil {
+			return nil, err
+		}
+
+		// Step 4: take the instance out of backup mode
+		if err := se.setProgressCondition(ctx, backup, apiv1.ConditionBackupFinalizing); err != nil {
+			return nil, err
+		}
+		se.recorder.Eventf(backup, "Normal", "StopOnlineBackup",
+			"Stopping the online backup in Pod %v", targetPod.Name)
+		if err := se.onlineBackupClient.RequestOnlineBackupInInstance(ctx, targetPod, postgres.OnlineBackupRequest{
+			Action:         postgres.OnlineBackupActionStop,
+			BackupName:     backup.Name,
+			WaitForArchive: onlineConfig.GetWaitForArchive(),
+		}); err != nil {
+			return nil, fmt.Errorf("while stopping the online backup: %w", err)
+		}
+		return &ctrl.Result{RequeueAfter: onlineBackupPollInterval}, nil
+
+	case postgres.OnlineBackupPhaseStopping:
+		contextLogger.Info("Waiting for the online backup to be stopped")
+		return &ctrl.Result{RequeueAfter: onlineBackupPollInterval}, nil
+	}
+
+	// Step 5: record the backup label, needed to restore the snapshots,
+	// how much data they captured, and how far the WAL archive extends
+	if err := se.recordOnlineBackupResult(ctx, backup, snapshots, status); err != nil {
+		return nil, err
+	}
+	setTotalSize(backup, snapshots)
+	se.setLastArchivedLSN(ctx, cluster, backup, false)
+
+	return nil, nil
+}
+
+// recordOnlineBackupResult stores the result of pg_backup_stop in the
+// status of the backup and in the annotations of its snapshots. The
+// snapshots taken online are consistent once the WAL up to the end of
+// the backup has been replayed
+func (se *Reconciler) recordOnlineBackupResult(
+	ctx context.Context,
+	backup *apiv1.Backup,
+	snapshots []storagesnapshotv1.VolumeSnapshot,
+	status *postgres.OnlineBackupStatus,
+) error {
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		origSnapshot := snapshot.DeepCopy()
+		if snapshot.Annotations == nil {
+			snapshot.Annotations = map[string]string{}
+		}
+
+		snapshot.Annotations[utils.ConsistentLSNAnnotationName] = string(status.EndLSN)
+		if utils.PVCRole(snapshot.Labels[utils.PvcRoleLabelName]) == utils.PVCRolePgData {
+			snapshot.Annotations[utils.BackupLabelFileAnnotationName] = string(status.BackupLabelFile)
+			if len(status.TablespaceMapFile) > 0 {
+				snapshot.Annotations[utils.TablespaceMapFileAnnotationName] = string(status.TablespaceMapFile)
+			}
+		}
+
+		// the checksum must cover the metadata we just added
+		checksum, err := computeMetadataChecksum(snapshot)
+		if err != nil {
+			return err
+		}
+		snapshot.Annotations[utils.SnapshotMetadataChecksumAnnotationName] = checksum
+
+		if err := se.cli.Patch(ctx, snapshot, client.MergeFrom(origSnapshot)); err != nil {
+			return fmt.Errorf("while annotating VolumeSnapshot %s: %w", snapshot.Name, err)
+		}
+	}
+
+	backup.Status.BeginLSN = string(status.BeginLSN)
+	backup.Status.EndLSN = string(status.EndLSN)
+	backup.Status.BackupSnapshotStatus.BackupLabelFile = status.BackupLabelFile
+	backup.Status.BackupSnapshotStatus.TablespaceMapFile = status.TablespaceMapFile
+	return nil
+}
+
+// AbortOnlineBackup takes the target instance of a failed online backup
+// out of backup mode. This is done on a best-effort basis, and calling
+// this function when the instance is not in backup mode is safe
+func (se *Reconciler) AbortOnlineBackup(
+	ctx context.Context,
+	backup *apiv1.Backup,
+	targetPod *corev1.Pod,
+) {
+	if err := se.onlineBackupClient.RequestOnlineBackupInInstance(ctx, targetPod, postgres.OnlineBackupRequest{
+		Action:     postgres.OnlineBackupActionStop,
+		BackupName: backup.Name,
+	}); err != nil {
+		log.FromContext(ctx).Info("Cannot stop the online backup of the failed backup",
+			"podName", targetPod.Name, "err", err.Error())
+	}
+}
+
+// GetBackupLabel gets the backup label and the tablespace map recorded in
+// the PG_DATA volume snapshot used to bootstrap the passed cluster, which
+// must be written in the data directory when the snapshot has been taken
+// online. Empty values are returned for the snapshots taken offline
+func GetBackupLabel(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+) (backupLabel string, tablespaceMap string, err error) {
+	if cluster.Spec.Bootstrap == nil ||
+		cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.VolumeSnapshots == nil {
+		return "", "", nil
+	}
+
+	var snapshot storagesnapshotv1.VolumeSnapshot
+	err = cli.Get(
+		ctx,
+		client.ObjectKey{
+			Namespace: cluster.Namespace,
+			Name:      cluster.Spec.Bootstrap.Recovery.VolumeSnapshots.Storage.Name,
+		},
+		&snapshot,
+	)
+	if apierrs.IsNotFound(err) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	return snapshot.Annotations[utils.BackupLabelFileAnnotationName],
+		snapshot.Annotations[utils.TablespaceMapFileAnnotationName],
+		nil
+}
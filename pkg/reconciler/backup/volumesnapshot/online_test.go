@@ -0,0 +1,322 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeOnlineBackupClient is an instance manager executing the online
+// backups as instructed by the test
+type fakeOnlineBackupClient struct {
+	status   *postgres.OnlineBackupStatus
+	requests []postgres.OnlineBackupRequest
+}
+
+func (f *fakeOnlineBackupClient) GetOnlineBackupStatusFromInstance(
+	context.Context,
+	*corev1.Pod,
+) (*postgres.OnlineBackupStatus, error) {
+	return f.status, nil
+}
+
+func (f *fakeOnlineBackupClient) RequestOnlineBackupInInstance(
+	_ context.Context,
+	_ *corev1.Pod,
+	request postgres.OnlineBackupRequest,
+) error {
+	f.requests = append(f.requests, request)
+	return nil
+}
+
+var _ = Describe("online volume snapshot backups", func() {
+	var (
+		cluster        *apiv1.Cluster
+		backup         *apiv1.Backup
+		targetPod      *corev1.Pod
+		pvcs           []corev1.PersistentVolumeClaim
+		objects        []client.Object
+		instanceClient *fakeOnlineBackupClient
+	)
+
+	newSnapshot := func(pvc corev1.PersistentVolumeClaim) *storagesnapshotv1.VolumeSnapshot {
+		return &storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pvc.Name + "-1700000000",
+				Namespace: "default",
+				Labels: map[string]string{
+					utils.BackupNameLabelName: backup.Name,
+					utils.PvcRoleLabelName:    pvc.Labels[utils.PvcRoleLabelName],
+				},
+				Annotations: map[string]string{},
+			},
+			Spec: storagesnapshotv1.VolumeSnapshotSpec{
+				Source: storagesnapshotv1.VolumeSnapshotSource{
+					PersistentVolumeClaimName: ptr.To(pvc.Name),
+				},
+			},
+			Status: &storagesnapshotv1.VolumeSnapshotStatus{ReadyToUse: ptr.To(true)},
+		}
+	}
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				WalStorage: &apiv1.StorageConfiguration{},
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						ClassName: "csi-snapclass",
+						Online:    true,
+						OnlineConfiguration: &apiv1.OnlineConfiguration{
+							ImmediateCheckpoint: true,
+						},
+					},
+				},
+			},
+		}
+		backup = &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backup-example",
+				Namespace: "default",
+			},
+			Spec: apiv1.BackupSpec{
+				Method: apiv1.BackupMethodVolumeSnapshot,
+			},
+			Status: apiv1.BackupStatus{
+				BackupSnapshotStatus: apiv1.BackupSnapshotStatus{Online: true},
+			},
+		}
+		targetPod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example-2",
+				Namespace: "default",
+			},
+		}
+		pvcs = []corev1.PersistentVolumeClaim{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-example-2",
+					Namespace: "default",
+					Labels: map[string]string{
+						utils.PvcRoleLabelName: string(utils.PVCRolePgData),
+					},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-example-2-wal",
+					Namespace: "default",
+					Labels: map[string]string{
+						utils.PvcRoleLabelName: string(utils.PVCRolePgWal),
+					},
+				},
+			},
+		}
+		objects = []client.Object{cluster, backup, targetPod}
+		instanceClient = &fakeOnlineBackupClient{}
+	})
+
+	newExecutor := func() (client.Client, *Reconciler) {
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(objects...).
+			WithStatusSubresource(backup).
+			Build()
+		executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+			FenceInstance(true).
+			Build()
+		executor.onlineBackupClient = instanceClient
+		return cli, executor
+	}
+
+	It("puts the instance in backup mode before taking the snapshots", func(ctx context.Context) {
+		cli, executor := newExecutor()
+
+		res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).ToNot(BeNil())
+		Expect(instanceClient.requests).To(Equal([]postgres.OnlineBackupRequest{{
+			Action:              postgres.OnlineBackupActionStart,
+			BackupName:          backup.Name,
+			ImmediateCheckpoint: true,
+		}}))
+
+		snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(BeEmpty())
+	})
+
+	It("waits for the backup mode to be started", func(ctx context.Context) {
+		instanceClient.status = &postgres.OnlineBackupStatus{
+			BackupName: backup.Name,
+			Phase:      postgres.OnlineBackupPhaseStarting,
+		}
+		cli, executor := newExecutor()
+
+		res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).ToNot(BeNil())
+		Expect(instanceClient.requests).To(BeEmpty())
+
+		snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(BeEmpty())
+	})
+
+	It("ignores the terminated online backup of a previous backup", func(ctx context.Context) {
+		instanceClient.status = &postgres.OnlineBackupStatus{
+			BackupName: "backup-previous",
+			Phase:      postgres.OnlineBackupPhaseCompleted,
+		}
+		_, executor := newExecutor()
+
+		_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceClient.requests).To(HaveLen(1))
+		Expect(instanceClient.requests[0].Action).To(Equal(postgres.OnlineBackupActionStart))
+	})
+
+	It("snapshots every PVC without fencing the instance", func(ctx context.Context) {
+		instanceClient.status = &postgres.OnlineBackupStatus{
+			BackupName: backup.Name,
+			Phase:      postgres.OnlineBackupPhaseStarted,
+			BeginLSN:   "0/2000028",
+		}
+		cli, executor := newExecutor()
+
+		res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).ToNot(BeNil())
+
+		snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(HaveLen(2))
+
+		var current apiv1.Cluster
+		Expect(cli.Get(ctx, client.ObjectKeyFromObject(cluster), &current)).To(Succeed())
+		fencedInstances, err := utils.GetFencedInstances(current.Annotations)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fencedInstances.Len()).To(BeZero())
+	})
+
+	It("stops the backup mode once the snapshots are ready", func(ctx context.Context) {
+		instanceClient.status = &postgres.OnlineBackupStatus{
+			BackupName: backup.Name,
+			Phase:      postgres.OnlineBackupPhaseStarted,
+		}
+		objects = append(objects, newSnapshot(pvcs[0]), newSnapshot(pvcs[1]))
+		_, executor := newExecutor()
+
+		res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).ToNot(BeNil())
+		Expect(instanceClient.requests).To(Equal([]postgres.OnlineBackupRequest{{
+			Action:         postgres.OnlineBackupActionStop,
+			BackupName:     backup.Name,
+			WaitForArchive: true,
+		}}))
+	})
+
+	It("records the backup label in the PG_DATA snapshot", func(ctx context.Context) {
+		instanceClient.status = &postgres.OnlineBackupStatus{
+			BackupName:      backup.Name,
+			Phase:           postgres.OnlineBackupPhaseCompleted,
+			BeginLSN:        "0/2000028",
+			EndLSN:          "0/2000100",
+			BackupLabelFile: []byte("START WAL LOCATION: 0/2000028"),
+		}
+		objects = append(objects, newSnapshot(pvcs[0]), newSnapshot(pvcs[1]))
+		cli, executor := newExecutor()
+
+		res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).To(BeNil())
+		Expect(backup.Status.BeginLSN).To(Equal("0/2000028"))
+		Expect(backup.Status.EndLSN).To(Equal("0/2000100"))
+		Expect(string(backup.Status.BackupSnapshotStatus.BackupLabelFile)).
+			To(Equal("START WAL LOCATION: 0/2000028"))
+
+		snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(HaveLen(2))
+		Expect(verifySnapshotsConsistency(snapshots)).To(Succeed())
+		for i := range snapshots {
+			Expect(snapshots[i].Annotations).To(HaveKeyWithValue(utils.ConsistentLSNAnnotationName, "0/2000100"))
+			Expect(verifyMetadataChecksum(&snapshots[i])).To(Succeed())
+			if snapshots[i].Labels[utils.PvcRoleLabelName] == string(utils.PVCRolePgData) {
+				Expect(snapshots[i].Annotations).To(HaveKeyWithValue(
+					utils.BackupLabelFileAnnotationName, "START WAL LOCATION: 0/2000028"))
+			} else {
+				Expect(snapshots[i].Annotations).ToNot(HaveKey(utils.BackupLabelFileAnnotationName))
+			}
+		}
+	})
+
+	It("fails when the instance left the backup mode", func(ctx context.Context) {
+		objects = append(objects, newSnapshot(pvcs[0]), newSnapshot(pvcs[1]))
+		cli, executor := newExecutor()
+
+		_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).To(MatchError(ContainSubstring("not in backup mode anymore")))
+
+		snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(BeEmpty())
+	})
+
+	It("fails when the online backup failed in the instance", func(ctx context.Context) {
+		instanceClient.status = &postgres.OnlineBackupStatus{
+			BackupName: backup.Name,
+			Phase:      postgres.OnlineBackupPhaseFailed,
+			Error:      "connection reset",
+		}
+		_, executor := newExecutor()
+
+		_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).To(MatchError(ContainSubstring("connection reset")))
+	})
+
+	It("aborts the online backup of a failed backup", func(ctx context.Context) {
+		_, executor := newExecutor()
+
+		executor.AbortOnlineBackup(ctx, backup, targetPod)
+		Expect(instanceClient.requests).To(Equal([]postgres.OnlineBackupRequest{{
+			Action:     postgres.OnlineBackupActionStop,
+			BackupName: backup.Name,
+		}}))
+	})
+})
@@ -0,0 +1,100 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/stringset"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// CheckSnapshotterResponsiveness checks that the external-snapshotter is
+// responsive before the target instance of a backup is fenced, so that a
+// backup which can't create its snapshots fails without fencing the
+// instance. The volume snapshot classes used by the backup must be listed
+// within the configured timeout, and the snapshot controller must have
+// set the status of the existing snapshots of the cluster. A nil error
+// is returned when the snapshotter is responsive
+func CheckSnapshotterResponsiveness(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+	targetPod *corev1.Pod,
+) error {
+	snapshotConfig := cluster.Spec.Backup.VolumeSnapshot
+	preflightCheck := snapshotConfig.PreflightCheck
+
+	checkCtx, cancel := context.WithTimeout(ctx, preflightCheck.GetTimeout())
+	defer cancel()
+
+	var snapshotClasses storagesnapshotv1.VolumeSnapshotClassList
+	if err := cli.List(checkCtx, &snapshotClasses); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %v listing the volume snapshot classes",
+				preflightCheck.GetTimeout())
+		}
+		return fmt.Errorf("while listing the volume snapshot classes: %w", err)
+	}
+
+	existingClasses := stringset.New()
+	for _, snapshotClass := range snapshotClasses.Items {
+		existingClasses.Put(snapshotClass.Name)
+	}
+
+	for _, role := range []utils.PVCRole{utils.PVCRolePgData, utils.PVCRolePgWal} {
+		className := getSnapshotClassName(snapshotConfig, role, isPrimaryTarget(cluster, targetPod))
+		if className != nil && !existingClasses.Has(*className) {
+			return fmt.Errorf("volume snapshot class %s not found", *className)
+		}
+	}
+
+	var snapshots storagesnapshotv1.VolumeSnapshotList
+	if err := cli.List(
+		checkCtx,
+		&snapshots,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{utils.ClusterLabelName: cluster.Name},
+	); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %v listing the volume snapshots",
+				preflightCheck.GetTimeout())
+		}
+		return fmt.Errorf("while listing the volume snapshots: %w", err)
+	}
+
+	// the snapshot controller sets the status of a new snapshot as soon as
+	// it processes it, even when the snapshot can't be created
+	threshold := preflightCheck.GetStalledSnapshotThreshold()
+	for _, snapshot := range snapshots.Items {
+		if snapshot.Status == nil && time.Since(snapshot.CreationTimestamp.Time) > threshold {
+			return fmt.Errorf(
+				"the snapshot controller did not process VolumeSnapshot %s in %v, it may not be running",
+				snapshot.Name, threshold)
+		}
+	}
+
+	return nil
+}
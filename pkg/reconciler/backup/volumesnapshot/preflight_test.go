@@ -0,0 +1,136 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// hangingListClient simulates an unresponsive API service, waiting for
+// the context to be cancelled before listing anything
+type hangingListClient struct {
+	client.Client
+}
+
+func (h hangingListClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+var _ = Describe("snapshotter pre-flight check", func() {
+	var (
+		cluster       *apiv1.Cluster
+		pod           *corev1.Pod
+		snapshotClass *storagesnapshotv1.VolumeSnapshotClass
+	)
+
+	newClient := func(objects ...client.Object) client.Client {
+		return fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(objects...).
+			Build()
+	}
+
+	newSnapshot := func(name string, age time.Duration, status *storagesnapshotv1.VolumeSnapshotStatus) client.Object {
+		return &storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				Labels:            map[string]string{utils.ClusterLabelName: "cluster-example"},
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			},
+			Status: status,
+		}
+	}
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						ClassName: "csi-snapclass",
+						PreflightCheck: &apiv1.VolumeSnapshotPreflightCheck{
+							Timeout:                  1,
+							StalledSnapshotThreshold: 60,
+						},
+					},
+				},
+			},
+		}
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example-1",
+				Namespace: "default",
+			},
+		}
+		snapshotClass = &storagesnapshotv1.VolumeSnapshotClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "csi-snapclass",
+			},
+			Driver:         "csi.example.com",
+			DeletionPolicy: storagesnapshotv1.VolumeSnapshotContentDelete,
+		}
+	})
+
+	It("passes when the snapshotter is responsive", func(ctx context.Context) {
+		cli := newClient(
+			snapshotClass,
+			newSnapshot("old-snapshot", time.Hour, &storagesnapshotv1.VolumeSnapshotStatus{
+				ReadyToUse: ptr.To(true),
+			}),
+			newSnapshot("new-snapshot", time.Second, nil),
+		)
+		Expect(CheckSnapshotterResponsiveness(ctx, cli, cluster, pod)).To(Succeed())
+	})
+
+	It("fails when the volume snapshot classes can't be listed in time", func(ctx context.Context) {
+		cli := hangingListClient{Client: newClient(snapshotClass)}
+		err := CheckSnapshotterResponsiveness(ctx, cli, cluster, pod)
+		Expect(err).To(MatchError(ContainSubstring("timed out after 1s")))
+	})
+
+	It("fails when the snapshot class of the backup doesn't exist", func(ctx context.Context) {
+		cli := newClient()
+		err := CheckSnapshotterResponsiveness(ctx, cli, cluster, pod)
+		Expect(err).To(MatchError("volume snapshot class csi-snapclass not found"))
+	})
+
+	It("fails when the snapshot controller is not processing the snapshots", func(ctx context.Context) {
+		cli := newClient(snapshotClass, newSnapshot("stalled-snapshot", 5*time.Minute, nil))
+		err := CheckSnapshotterResponsiveness(ctx, cli, cluster, pod)
+		Expect(err).To(MatchError(ContainSubstring("did not process VolumeSnapshot stalled-snapshot")))
+	})
+})
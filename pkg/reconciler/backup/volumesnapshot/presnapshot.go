@@ -0,0 +1,68 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/specs"
+)
+
+// ensurePreSnapshotCommandsExecuted executes the configured pre-snapshot
+// commands in the target instance, once per backup. They must run while
+// PostgreSQL is still running, that is before the instance is fenced.
+// A failing command fails the backup, unless it is marked as best-effort.
+// The status is patched immediately, so that the commands are not
+// executed again by the next reconciliation loops
+func (se *Reconciler) ensurePreSnapshotCommandsExecuted(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	targetPod *corev1.Pod,
+) error {
+	commands := cluster.Spec.Backup.VolumeSnapshot.PreSnapshotCommands
+	if len(commands) == 0 || backup.Status.BackupSnapshotStatus.PreSnapshotCommandsExecuted {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx).WithValues("podName", targetPod.Name)
+	for i, command := range commands {
+		contextLogger.Info("Executing the pre-snapshot command", "sql", command.SQL)
+		if _, err := se.executor(ctx, *targetPod, specs.PostgresContainerName,
+			"psql", "-X", "-U", "postgres", "-d", "postgres", "-v", "ON_ERROR_STOP=1",
+			"-c", command.SQL); err != nil {
+			if !command.BestEffort {
+				return fmt.Errorf("while executing the pre-snapshot command #%d: %w", i+1, err)
+			}
+
+			contextLogger.Warning("Cannot execute the best-effort pre-snapshot command",
+				"sql", command.SQL, "err", err.Error())
+			se.recorder.Eventf(backup, "Warning", "PreSnapshotCommand",
+				"Cannot execute the pre-snapshot command #%d: %v", i+1, err)
+		}
+	}
+
+	origBackup := backup.DeepCopy()
+	backup.Status.BackupSnapshotStatus.PreSnapshotCommandsExecuted = true
+	return se.backupCli.Status().Patch(ctx, backup, client.MergeFrom(origBackup))
+}
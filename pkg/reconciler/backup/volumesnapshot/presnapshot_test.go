@@ -0,0 +1,122 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("pre-snapshot commands", func() {
+	var (
+		cluster    *apiv1.Cluster
+		backup     *apiv1.Backup
+		targetPod  *corev1.Pod
+		recorder   *record.FakeRecorder
+		executed   []string
+		failingSQL string
+		reconciler *Reconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						PreSnapshotCommands: []apiv1.VolumeSnapshotPreSnapshotCommand{
+							{SQL: "CHECKPOINT"},
+							{SQL: "SELECT app.flush()", BestEffort: true},
+						},
+					},
+				},
+			},
+		}
+		backup = &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup-example", Namespace: "default"},
+		}
+		targetPod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-1", Namespace: "default"},
+		}
+		recorder = record.NewFakeRecorder(10)
+		executed = nil
+		failingSQL = ""
+
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(backup).
+			WithStatusSubresource(backup).
+			Build()
+		reconciler = &Reconciler{
+			backupCli: cli,
+			recorder:  recorder,
+			executor: func(_ context.Context, _ corev1.Pod, _ string, command ...string) (string, error) {
+				sql := command[len(command)-1]
+				executed = append(executed, sql)
+				if sql == failingSQL {
+					return "", errors.New("ERROR: function app.flush() does not exist")
+				}
+				return "", nil
+			},
+		}
+	})
+
+	It("executes the commands in order, only once", func(ctx context.Context) {
+		Expect(reconciler.ensurePreSnapshotCommandsExecuted(ctx, cluster, backup, targetPod)).To(Succeed())
+		Expect(executed).To(Equal([]string{"CHECKPOINT", "SELECT app.flush()"}))
+
+		var storedBackup apiv1.Backup
+		Expect(reconciler.backupCli.Get(ctx, client.ObjectKeyFromObject(backup), &storedBackup)).To(Succeed())
+		Expect(storedBackup.Status.BackupSnapshotStatus.PreSnapshotCommandsExecuted).To(BeTrue())
+
+		Expect(reconciler.ensurePreSnapshotCommandsExecuted(ctx, cluster, backup, targetPod)).To(Succeed())
+		Expect(executed).To(HaveLen(2))
+	})
+
+	It("fails when a command which is not best-effort fails", func(ctx context.Context) {
+		failingSQL = "CHECKPOINT"
+		err := reconciler.ensurePreSnapshotCommandsExecuted(ctx, cluster, backup, targetPod)
+		Expect(err).To(MatchError(ContainSubstring("pre-snapshot command #1")))
+		Expect(executed).To(Equal([]string{"CHECKPOINT"}))
+		Expect(backup.Status.BackupSnapshotStatus.PreSnapshotCommandsExecuted).To(BeFalse())
+	})
+
+	It("reports the failure of a best-effort command through an event", func(ctx context.Context) {
+		failingSQL = "SELECT app.flush()"
+		Expect(reconciler.ensurePreSnapshotCommandsExecuted(ctx, cluster, backup, targetPod)).To(Succeed())
+		Expect(backup.Status.BackupSnapshotStatus.PreSnapshotCommandsExecuted).To(BeTrue())
+		Expect(recorder.Events).To(Receive(ContainSubstring("PreSnapshotCommand")))
+	})
+
+	It("does nothing without commands", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.PreSnapshotCommands = nil
+		Expect(reconciler.ensurePreSnapshotCommandsExecuted(ctx, cluster, backup, targetPod)).To(Succeed())
+		Expect(executed).To(BeEmpty())
+	})
+})
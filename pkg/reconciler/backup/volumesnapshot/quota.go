@@ -0,0 +1,207 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// volumeSnapshotCountQuota is the ResourceQuota resource limiting the
+// number of VolumeSnapshots in a namespace
+const volumeSnapshotCountQuota corev1.ResourceName = "count/volumesnapshots.snapshot.storage.k8s.io"
+
+// SnapshotQuota is the snapshot quota exposed by a CSI driver
+type SnapshotQuota struct {
+	// Limit is the maximum number of snapshots which can be taken
+	Limit int64
+
+	// Used is the number of snapshots already taken
+	Used int64
+}
+
+// QuotaProvider gets the snapshot quota of the volumes served by a CSI
+// driver, i.e. by querying the API of the cloud provider
+type QuotaProvider interface {
+	// GetSnapshotQuota gets the snapshot quota available to the snapshots
+	// taken through the passed class in the passed namespace. A nil quota
+	// is returned when the quota is not exposed
+	GetSnapshotQuota(
+		ctx context.Context,
+		cli client.Client,
+		snapshotClass *storagesnapshotv1.VolumeSnapshotClass,
+		namespace string,
+	) (*SnapshotQuota, error)
+}
+
+var (
+	quotaProvidersMutex sync.RWMutex
+	quotaProviders      = make(map[string]QuotaProvider)
+)
+
+// RegisterQuotaProvider registers the quota provider of a CSI driver,
+// replacing the existing one. A nil provider unregisters it
+func RegisterQuotaProvider(driver string, provider QuotaProvider) {
+	quotaProvidersMutex.Lock()
+	defer quotaProvidersMutex.Unlock()
+
+	if provider == nil {
+		delete(quotaProviders, driver)
+		return
+	}
+	quotaProviders[driver] = provider
+}
+
+// getQuotaProvider gets the quota provider of a CSI driver, if any
+func getQuotaProvider(driver string) QuotaProvider {
+	quotaProvidersMutex.RLock()
+	defer quotaProvidersMutex.RUnlock()
+
+	return quotaProviders[driver]
+}
+
+// CheckSnapshotQuota checks that the snapshots of the passed PVCs fit within
+// the quota of the namespace, set through a ResourceQuota, and within the
+// quota of the CSI drivers which have a registered quota provider. The
+// quotas which are not available are skipped. A nil error is returned
+// when the snapshots fit, or their fitting cannot be detected
+func CheckSnapshotQuota(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+	targetPod *corev1.Pod,
+	pvcs []corev1.PersistentVolumeClaim,
+) error {
+	if err := checkNamespaceSnapshotQuota(ctx, cli, cluster.Namespace, len(pvcs)); err != nil {
+		return err
+	}
+
+	snapshotsByClass := make(map[string]int64)
+	for i := range pvcs {
+		className := getPVCSnapshotClassName(
+			cluster.Spec.Backup.VolumeSnapshot, &pvcs[i], isPrimaryTarget(cluster, targetPod))
+		if className != nil {
+			snapshotsByClass[*className]++
+		}
+	}
+
+	classNames := make([]string, 0, len(snapshotsByClass))
+	for className := range snapshotsByClass {
+		classNames = append(classNames, className)
+	}
+	sort.Strings(classNames)
+
+	for _, className := range classNames {
+		if err := checkDriverSnapshotQuota(
+			ctx, cli, className, cluster.Namespace, snapshotsByClass[className]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkNamespaceSnapshotQuota checks that the passed number of snapshots
+// fits within the ResourceQuotas of the namespace
+func checkNamespaceSnapshotQuota(
+	ctx context.Context,
+	cli client.Client,
+	namespace string,
+	requested int,
+) error {
+	var resourceQuotas corev1.ResourceQuotaList
+	if err := cli.List(ctx, &resourceQuotas, client.InNamespace(namespace)); err != nil {
+		log.FromContext(ctx).Info("Cannot list the resource quotas, skipping the namespace snapshot quota check",
+			"err", err.Error())
+		return nil
+	}
+
+	for _, resourceQuota := range resourceQuotas.Items {
+		hard, ok := resourceQuota.Status.Hard[volumeSnapshotCountQuota]
+		if !ok {
+			continue
+		}
+		used := resourceQuota.Status.Used[volumeSnapshotCountQuota]
+		if available := getAvailableSnapshots(hard.Value(), used.Value()); int64(requested) > available {
+			return fmt.Errorf("resource quota %s allows %d more volume snapshots, while %d are needed",
+				resourceQuota.Name, available, requested)
+		}
+	}
+
+	return nil
+}
+
+// checkDriverSnapshotQuota checks that the passed number of snapshots fits
+// within the quota of the CSI driver serving the passed class, when it has
+// a registered quota provider
+func checkDriverSnapshotQuota(
+	ctx context.Context,
+	cli client.Client,
+	className string,
+	namespace string,
+	requested int64,
+) error {
+	contextLogger := log.FromContext(ctx)
+
+	var snapshotClass storagesnapshotv1.VolumeSnapshotClass
+	if err := cli.Get(ctx, types.NamespacedName{Name: className}, &snapshotClass); err != nil {
+		contextLogger.Info("Cannot get the volume snapshot class, skipping the snapshot quota check",
+			"className", className, "err", err.Error())
+		return nil
+	}
+
+	provider := getQuotaProvider(snapshotClass.Driver)
+	if provider == nil {
+		return nil
+	}
+
+	quota, err := provider.GetSnapshotQuota(ctx, cli, &snapshotClass, namespace)
+	if err != nil {
+		contextLogger.Info("Cannot get the snapshot quota of the CSI driver, skipping the check",
+			"driver", snapshotClass.Driver, "err", err.Error())
+		return nil
+	}
+	if quota == nil {
+		return nil
+	}
+
+	if available := getAvailableSnapshots(quota.Limit, quota.Used); requested > available {
+		return fmt.Errorf("the snapshot quota of CSI driver %s allows %d more snapshots, while %d are needed",
+			snapshotClass.Driver, available, requested)
+	}
+
+	return nil
+}
+
+// getAvailableSnapshots gets how many snapshots can still be taken
+// within a quota
+func getAvailableSnapshots(limit, used int64) int64 {
+	if used >= limit {
+		return 0
+	}
+	return limit - used
+}
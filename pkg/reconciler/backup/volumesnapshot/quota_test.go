@@ -0,0 +1,179 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"errors"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeQuotaProvider is a quota provider returning a fixed quota
+type fakeQuotaProvider struct {
+	quota *SnapshotQuota
+	err   error
+}
+
+func (f fakeQuotaProvider) GetSnapshotQuota(
+	_ context.Context,
+	_ client.Client,
+	_ *storagesnapshotv1.VolumeSnapshotClass,
+	_ string,
+) (*SnapshotQuota, error) {
+	return f.quota, f.err
+}
+
+var _ = Describe("snapshot quota check", func() {
+	const driver = "csi.example.com"
+
+	var (
+		cluster       *apiv1.Cluster
+		pod           *corev1.Pod
+		pvcs          []corev1.PersistentVolumeClaim
+		snapshotClass *storagesnapshotv1.VolumeSnapshotClass
+	)
+
+	newClient := func(objects ...client.Object) client.Client {
+		return fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(objects...).
+			Build()
+	}
+
+	newResourceQuota := func(hard, used int64) *corev1.ResourceQuota {
+		return &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "snapshots",
+				Namespace: "default",
+			},
+			Status: corev1.ResourceQuotaStatus{
+				Hard: corev1.ResourceList{
+					volumeSnapshotCountQuota: *resource.NewQuantity(hard, resource.DecimalSI),
+				},
+				Used: corev1.ResourceList{
+					volumeSnapshotCountQuota: *resource.NewQuantity(used, resource.DecimalSI),
+				},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						ClassName:          "csi-snapclass",
+						CheckSnapshotQuota: true,
+					},
+				},
+			},
+		}
+		pod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example-1",
+				Namespace: "default",
+			},
+		}
+		pvcs = []corev1.PersistentVolumeClaim{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "cluster-example-1",
+					Labels: map[string]string{utils.PvcRoleLabelName: string(utils.PVCRolePgData)},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "cluster-example-1-wal",
+					Labels: map[string]string{utils.PvcRoleLabelName: string(utils.PVCRolePgWal)},
+				},
+			},
+		}
+		snapshotClass = &storagesnapshotv1.VolumeSnapshotClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "csi-snapclass",
+			},
+			Driver:         driver,
+			DeletionPolicy: storagesnapshotv1.VolumeSnapshotContentDelete,
+		}
+
+		DeferCleanup(func() {
+			RegisterQuotaProvider(driver, nil)
+		})
+	})
+
+	It("passes when no quota is available", func(ctx context.Context) {
+		cli := newClient(snapshotClass)
+		Expect(CheckSnapshotQuota(ctx, cli, cluster, pod, pvcs)).To(Succeed())
+	})
+
+	It("passes when the snapshots fit within the resource quota", func(ctx context.Context) {
+		cli := newClient(snapshotClass, newResourceQuota(10, 8))
+		Expect(CheckSnapshotQuota(ctx, cli, cluster, pod, pvcs)).To(Succeed())
+	})
+
+	It("fails when the snapshots exceed the resource quota", func(ctx context.Context) {
+		cli := newClient(snapshotClass, newResourceQuota(10, 9))
+		Expect(CheckSnapshotQuota(ctx, cli, cluster, pod, pvcs)).To(
+			MatchError("resource quota snapshots allows 1 more volume snapshots, while 2 are needed"))
+	})
+
+	It("passes when the snapshots fit within the quota of the driver", func(ctx context.Context) {
+		RegisterQuotaProvider(driver, fakeQuotaProvider{quota: &SnapshotQuota{Limit: 100, Used: 98}})
+		cli := newClient(snapshotClass)
+		Expect(CheckSnapshotQuota(ctx, cli, cluster, pod, pvcs)).To(Succeed())
+	})
+
+	It("fails when the snapshots exceed the quota of the driver", func(ctx context.Context) {
+		RegisterQuotaProvider(driver, fakeQuotaProvider{quota: &SnapshotQuota{Limit: 100, Used: 100}})
+		cli := newClient(snapshotClass)
+		Expect(CheckSnapshotQuota(ctx, cli, cluster, pod, pvcs)).To(
+			MatchError("the snapshot quota of CSI driver csi.example.com allows 0 more snapshots, while 2 are needed"))
+	})
+
+	It("skips the quota of the driver when it is not available", func(ctx context.Context) {
+		cli := newClient(snapshotClass)
+
+		RegisterQuotaProvider(driver, fakeQuotaProvider{})
+		Expect(CheckSnapshotQuota(ctx, cli, cluster, pod, pvcs)).To(Succeed())
+
+		RegisterQuotaProvider(driver, fakeQuotaProvider{err: errors.New("quota API unavailable")})
+		Expect(CheckSnapshotQuota(ctx, cli, cluster, pod, pvcs)).To(Succeed())
+	})
+
+	It("skips the quota of the driver when the snapshot class is not known", func(ctx context.Context) {
+		RegisterQuotaProvider(driver, fakeQuotaProvider{quota: &SnapshotQuota{Limit: 1, Used: 1}})
+		cli := newClient()
+		Expect(CheckSnapshotQuota(ctx, cli, cluster, pod, pvcs)).To(Succeed())
+	})
+})
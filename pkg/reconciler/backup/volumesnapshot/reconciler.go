@@ -21,30 +21,67 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	"go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
-	"k8s.io/utils/strings/slices"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/resources"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/resources/instance"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/stringset"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
 )
 
 // Reconciler is an object capable of executing a volume snapshot on a running cluster
 type Reconciler struct {
 	cli                  client.Client
+	backupCli            client.Client
+	liveReader           client.Reader
 	shouldFence          bool
 	recorder             record.EventRecorder
 	instanceStatusClient *instance.StatusClient
+	instanceStatusGetter func(ctx context.Context, pods corev1.PodList) postgres.PostgresqlStatusList
+	executor             podExecutor
+	tracer               trace.Tracer
+	readyTimeout         time.Duration
+
+	// requeueInterval is the interval between the reconciliation loops
+	// waiting for an operation, which grows with the waiting time up
+	// to maxRequeueInterval
+	requeueInterval    time.Duration
+	maxRequeueInterval time.Duration
+
+	// remote is true when the target instance runs in a different
+	// Kubernetes cluster, where its instance manager is not reachable
+	remote bool
+
+	// dryRun is true when the backup is only validated, without
+	// fencing the instance nor taking any snapshot
+	dryRun bool
+
+	// tolerateFencedInstances is true when the target instance can be
+	// fenced even if other instances of the cluster are already fenced
+	tolerateFencedInstances bool
+
+	// temporaryFilesCleaner removes the temporary files of a fenced instance
+	temporaryFilesCleaner func(ctx context.Context, pod *corev1.Pod) error
+
+	// onlineBackupClient puts the target instance in backup mode when
+	// the snapshots are taken online
+	onlineBackupClient onlineBackupClient
 }
 
 // ExecutorBuilder is a struct capable of creating a Reconciler
@@ -57,11 +94,21 @@ func NewExecutorBuilder(
 	cli client.Client,
 	recorder record.EventRecorder,
 ) *ExecutorBuilder {
+	instanceStatusClient := instance.NewStatusClient()
 	return &ExecutorBuilder{
 		executor: Reconciler{
-			cli:                  cli,
-			recorder:             recorder,
-			instanceStatusClient: instance.NewStatusClient(),
+			cli:                   cli,
+			backupCli:             cli,
+			liveReader:            cli,
+			recorder:              recorder,
+			instanceStatusClient:  instanceStatusClient,
+			instanceStatusGetter:  instanceStatusClient.GetStatusFromInstances,
+			executor:              execInPod,
+			tracer:                trace.NewNoopTracerProvider().Tracer(tracerName),
+			temporaryFilesCleaner: instanceStatusClient.CleanTemporaryFilesInInstance,
+			onlineBackupClient:    instanceStatusClient,
+			requeueInterval:       defaultRequeueInterval,
+			maxRequeueInterval:    defaultMaxRequeueInterval,
 		},
 	}
 }
@@ -72,6 +119,81 @@ func (e *ExecutorBuilder) FenceInstance(fence bool) *ExecutorBuilder {
 	return e
 }
 
+// TolerateFencedInstances instructs the Reconciler to fence the target
+// instance even when other instances of the cluster are already fenced,
+// for example a standby under maintenance. By default, the snapshot backup
+// is refused when any instance other than the target one is fenced
+func (e *ExecutorBuilder) TolerateFencedInstances(tolerate bool) *ExecutorBuilder {
+	e.executor.tolerateFencedInstances = tolerate
+	return e
+}
+
+// TracerProvider sets the OpenTelemetry tracer provider used to emit the spans
+// of the backup phases. By default, no span is emitted
+func (e *ExecutorBuilder) TracerProvider(provider trace.TracerProvider) *ExecutorBuilder {
+	e.executor.tracer = provider.Tracer(tracerName)
+	return e
+}
+
+// LiveReader sets the reader used to bypass the informer cache when a
+// VolumeSnapshot has been waiting for too long, to detect a stale cache.
+// By default, the same client used for every other operation is used
+func (e *ExecutorBuilder) LiveReader(reader client.Reader) *ExecutorBuilder {
+	if reader != nil {
+		e.executor.liveReader = reader
+	}
+	return e
+}
+
+// ReadyTimeout sets the maximum time a VolumeSnapshot can take, since its
+// creation, to be ready to use. By default, the snapshots are waited for
+// without any limit
+func (e *ExecutorBuilder) ReadyTimeout(timeout time.Duration) *ExecutorBuilder {
+	e.executor.readyTimeout = timeout
+	return e
+}
+
+// RequeueBackoff sets the interval between the reconciliation loops waiting
+// for the target instance to be fenced or for the snapshots to be ready. The
+// interval starts from the initial one and grows with the waiting time, up
+// to maxInterval: passing the same value twice makes the interval fixed.
+// By default, the interval starts from 10 seconds and grows up to one minute
+func (e *ExecutorBuilder) RequeueBackoff(initial, maxInterval time.Duration) *ExecutorBuilder {
+	if initial > 0 {
+		e.executor.requeueInterval = initial
+		e.executor.maxRequeueInterval = maxInterval
+	}
+	return e
+}
+
+// BackupClient sets the client used to patch the status of the Backup,
+// when it doesn't live in the same Kubernetes cluster as the target
+// instance. By default, the same client used for every other operation is used
+func (e *ExecutorBuilder) BackupClient(cli client.Client) *ExecutorBuilder {
+	if cli != nil {
+		e.executor.backupCli = cli
+	}
+	return e
+}
+
+// Remote configures the Reconciler to snapshot an instance running in a
+// different Kubernetes cluster, whose API is reached with the passed
+// configuration. As the instance manager is not reachable, `pg_controldata`
+// is run in the PostgreSQL container and the temporary files are not removed
+func (e *ExecutorBuilder) Remote(config *rest.Config) *ExecutorBuilder {
+	e.executor.remote = true
+	e.executor.executor = newPodExecutor(config)
+	return e
+}
+
+// DryRun configures the Reconciler to only validate the backup, reporting
+// the snapshots that would be taken without fencing the instance nor
+// creating any VolumeSnapshot
+func (e *ExecutorBuilder) DryRun(dryRun bool) *ExecutorBuilder {
+	e.executor.dryRun = dryRun
+	return e
+}
+
 // Build returns the Reconciler instance
 func (e *ExecutorBuilder) Build() *Reconciler {
 	return &e.executor
@@ -88,8 +210,38 @@ func (se *Reconciler) enrichSnapshot(
 	snapshotConfig := *cluster.Spec.Backup.VolumeSnapshot
 
 	vs.Labels[utils.BackupNameLabelName] = backup.Name
+	vs.Labels[utils.BackupOriginLabelName] = string(backup.GetOrigin())
 
-	switch snapshotConfig.SnapshotOwnerReference {
+	// the source instance tells which node served the backup when auditing it
+	vs.Labels[utils.SnapshotSourcePodLabelName] = targetPod.Name
+	vs.Labels[utils.SnapshotSourceRoleLabelName] = string(getSnapshotSourceRole(cluster, targetPod))
+
+	// the configured labels and annotations may refer to the metadata of
+	// the cluster, of the backup and of the snapshot itself
+	placeholderValues := getPlaceholderValues(vs, backup, cluster, targetPod)
+	if err := expandConfiguredMetadata(vs.Labels, snapshotConfig.Labels, placeholderValues); err != nil {
+		return fmt.Errorf("in the labels of VolumeSnapshot %s: %w", vs.Name, err)
+	}
+	if err := expandConfiguredMetadata(vs.Annotations, snapshotConfig.Annotations, placeholderValues); err != nil {
+		return fmt.Errorf("in the annotations of VolumeSnapshot %s: %w", vs.Name, err)
+	}
+
+	// the schedule allows correlating the snapshots with the ScheduledBackup
+	// which triggered them, for example to apply a schedule-specific retention
+	if scheduleName := backup.Labels[utils.ParentScheduledBackupLabelName]; scheduleName != "" {
+		vs.Labels[utils.ParentScheduledBackupLabelName] = scheduleName
+	} else {
+		delete(vs.Labels, utils.ParentScheduledBackupLabelName)
+	}
+
+	ownerReference := backup.GetSnapshotOwnerReference(cluster)
+	if se.remote {
+		// the resources of this Kubernetes cluster can't own the
+		// snapshots created in the remote one
+		ownerReference = apiv1.ShapshotOwnerReferenceNone
+	}
+
+	switch ownerReference {
 	case apiv1.SnapshotOwnerReferenceCluster:
 		cluster.SetInheritedDataAndOwnership(&vs.ObjectMeta)
 	case apiv1.SnapshotOwnerReferenceBackup:
@@ -99,10 +251,26 @@ func (se *Reconciler) enrichSnapshot(
 	}
 
 	// we grab the pg_controldata just before creating the snapshot
-	if data, err := se.instanceStatusClient.GetPgControlDataFromInstance(ctx, targetPod); err == nil {
+	if data, err := se.getPgControlData(ctx, cluster, targetPod); err == nil {
 		vs.Annotations[utils.PgControldataAnnotationName] = data
+		if lsn := getConsistentLSN(data); lsn != "" {
+			vs.Annotations[utils.ConsistentLSNAnnotationName] = lsn
+		}
 	} else {
 		contextLogger.Error(err, "while querying for pg_controldata")
+		if errors.Is(err, utils.ErrorContainerNotFound) {
+			se.recorder.Eventf(backup, "Warning", "PgControldataContainer", "Cannot run pg_controldata: %v", err)
+		} else {
+			se.recorder.Eventf(backup, "Warning", "PgControldataUnavailable",
+				"Cannot capture pg_controldata before creating VolumeSnapshot %s, "+
+					"its consistency can't be validated at restore time: %v", vs.Name, err)
+		}
+
+		// without the control data, the snapshot can't be validated when
+		// restoring it, and strict users prefer a failed backup
+		if snapshotConfig.RequireControlData {
+			return fmt.Errorf("while capturing pg_controldata for VolumeSnapshot %s: %w", vs.Name, err)
+		}
 	}
 
 	rawCluster, err := json.Marshal(cluster)
@@ -112,60 +280,378 @@ func (se *Reconciler) enrichSnapshot(
 
 	vs.Annotations[utils.ClusterManifestAnnotationName] = string(rawCluster)
 
+	// the PostgreSQL version is used to validate the restores
+	if version, err := getPodPostgresVersion(targetPod); err == nil {
+		setSnapshotPostgresVersion(vs, version)
+	} else {
+		contextLogger.Info("Cannot detect the PostgreSQL version, not recording it in the snapshot",
+			"error", err.Error())
+	}
+
+	// the node helps correlating slow snapshots with the underlying storage
+	if targetPod.Spec.NodeName != "" {
+		vs.Annotations[utils.BackupNodeAnnotationName] = targetPod.Spec.NodeName
+	}
+
+	// the extensions have been collected when the backup was started,
+	// as the instance may be fenced by now
+	if extensions := backup.Status.BackupSnapshotStatus.Extensions; len(extensions) > 0 {
+		rawExtensions, err := json.Marshal(extensions)
+		if err != nil {
+			return err
+		}
+		vs.Annotations[utils.InstalledExtensionsAnnotationName] = string(rawExtensions)
+	}
+
+	// the checksum must be computed after every other metadata has been set
+	checksum, err := computeMetadataChecksum(vs)
+	if err != nil {
+		return err
+	}
+	vs.Annotations[utils.SnapshotMetadataChecksumAnnotationName] = checksum
+
 	return nil
 }
 
-// Execute the volume snapshot of the given cluster instance
+// Execute the volume snapshot of the given cluster instance. Any error
+// ending the backup once the fencing of the target instance has been
+// requested makes the instance unfenced, not to leave it offline until
+// manual intervention. The retryable errors leave the instance fenced, as
+// the backup goes on in the next reconciliation loop
 func (se *Reconciler) Execute(
 	ctx context.Context,
 	cluster *apiv1.Cluster,
 	backup *apiv1.Backup,
 	targetPod *corev1.Pod,
 	pvcs []corev1.PersistentVolumeClaim,
+) (*ctrl.Result, error) {
+	res, err := se.execute(ctx, cluster, backup, targetPod, pvcs)
+	if err != nil && !isRetryableError(err) && backup.Status.BackupSnapshotStatus.FencedAt != nil {
+		se.unfenceOnError(ctx, cluster, backup, targetPod, err)
+	}
+	return res, err
+}
+
+func (se *Reconciler) execute(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	targetPod *corev1.Pod,
+	pvcs []corev1.PersistentVolumeClaim,
 ) (*ctrl.Result, error) {
 	contextLogger := log.FromContext(ctx).WithValues("podName", targetPod.Name)
 
-	// Step 1: fencing
-	if se.shouldFence {
-		contextLogger.Debug("Checking pre-requisites")
-		if err := se.ensurePodIsFenced(ctx, cluster, backup, targetPod.Name); err != nil {
-			return nil, err
-		}
+	if se.dryRun {
+		return nil, se.executeDryRun(ctx, cluster, backup, targetPod, pvcs)
+	}
 
-		if res, err := se.waitForPodToBeFenced(ctx, targetPod); res != nil || err != nil {
-			return res, err
-		}
+	if err := ensurePVCsAreSnapshottable(pvcs); err != nil {
+		return nil, err
 	}
 
-	// Step 2: create snapshot
 	volumeSnapshots, err := GetBackupVolumeSnapshots(ctx, se.cli, cluster.Namespace, backup.Name)
 	if err != nil {
 		return nil, err
 	}
-	if len(volumeSnapshots) == 0 {
-		// we execute the snapshots only if we don't find any
-		if err := se.createSnapshotPVCGroupStep(ctx, cluster, pvcs, backup, targetPod); err != nil {
+
+	// The snapshots reused from previous backups are part of this one,
+	// but they are never deleted by it
+	reusedSnapshots, err := GetReusedVolumeSnapshots(ctx, se.cli, cluster.Namespace, backup)
+	if err != nil {
+		return nil, err
+	}
+	backupSnapshots := append(append([]storagesnapshotv1.VolumeSnapshot{}, volumeSnapshots...), reusedSnapshots...)
+
+	// The whole execution is bounded by the timeout of the backup, after
+	// which the snapshots taken so far are of no use
+	if backup.IsTimeoutExceeded(time.Now()) {
+		contextLogger.Info("Backup timeout exceeded, deleting the snapshots taken so far",
+			"timeout", backup.GetTimeout())
+		if err := se.deleteSnapshots(ctx, volumeSnapshots); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("backup not completed within its timeout of %s", backup.GetTimeout())
+	}
+
+	if backup.Status.BackupSnapshotStatus.Online {
+		return se.executeOnline(ctx, cluster, backup, targetPod, pvcs, volumeSnapshots)
+	}
+
+	// Step 6, resumed: the snapshots are ready and the instance has
+	// been unfenced, only the archiving of the WAL file is left
+	if backup.Status.BackupSnapshotStatus.RequiredWAL != "" {
+		return se.verifyArchive(ctx, cluster, backup, time.Now())
+	}
+
+	// The fenced portion of the backup is bounded too, trading the backup
+	// for the availability of the target instance
+	if isFenceDurationExceeded(cluster, backup, time.Now()) {
+		return nil, se.failOnFenceDurationExceeded(ctx, cluster, backup, volumeSnapshots, time.Now())
+	}
+
+	onlinePVCs, fencedPVCs := splitPVCsByFencingRequirement(cluster, pvcs)
+	if !se.shouldFence {
+		onlinePVCs, fencedPVCs = pvcs, nil
+	}
+
+	// Step 1: snapshot the PVCs not requiring fencing while the instance is running
+	if pendingPVCs := getPVCsWithoutSnapshot(onlinePVCs, backupSnapshots); len(pendingPVCs) > 0 {
+		if err := se.ensurePreSnapshotCommandsExecuted(ctx, cluster, backup, targetPod); err != nil {
+			return nil, err
+		}
+		if err := se.setProgressCondition(ctx, backup, apiv1.ConditionBackupSnapshotCreating); err != nil {
+			return nil, err
+		}
+
+		createStartedAt := time.Now()
+		err = se.createSnapshotPVCGroupStep(ctx, cluster, pendingPVCs, backup, targetPod)
+		se.tracePhase(ctx, tracingPhaseCreate, cluster, backup, len(pendingPVCs), createStartedAt, err)
+		if err != nil {
 			return nil, err
 		}
 
 		// let's stop this reconciliation loop and wait for
 		// the external snapshot controller to catch this new
 		// request
-		return &ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		return &ctrl.Result{RequeueAfter: se.requeueInterval}, nil
+	}
+
+	// Step 2: fencing, only if any PVC requires it
+	if len(fencedPVCs) > 0 {
+		contextLogger.Debug("Checking pre-requisites")
+		var res *ctrl.Result
+		if backup.Status.BackupSnapshotStatus.FencedAt == nil {
+			// the pre-snapshot commands need PostgreSQL to be running
+			if err := se.ensurePreSnapshotCommandsExecuted(ctx, cluster, backup, targetPod); err != nil {
+				return nil, err
+			}
+			err = se.setProgressCondition(ctx, backup, apiv1.ConditionBackupFencing)
+			if err == nil {
+				err = se.recordFenceStart(ctx, backup, time.Now())
+			}
+		}
+		if err == nil {
+			err = se.ensurePodIsFenced(ctx, cluster, backup, targetPod.Name)
+		}
+		if err == nil {
+			res, err = se.waitForPodToBeFenced(ctx, backup, targetPod)
+		}
+		if err != nil {
+			se.tracePhase(ctx, tracingPhaseFence, cluster, backup, len(fencedPVCs),
+				getFencingStartTime(backup), err)
+			return nil, err
+		}
+		if res != nil {
+			return res, nil
+		}
+	}
+
+	// Step 3: snapshot the PVCs requiring fencing
+	if pendingPVCs := getPVCsWithoutSnapshot(fencedPVCs, backupSnapshots); len(pendingPVCs) > 0 {
+		// the instance has just been fenced: this is where the fencing
+		// phase ends
+		se.tracePhase(ctx, tracingPhaseFence, cluster, backup, len(fencedPVCs),
+			getFencingStartTime(backup), nil)
+		if err := se.setProgressCondition(ctx, backup, apiv1.ConditionBackupSnapshotCreating); err != nil {
+			return nil, err
+		}
+
+		if reuseWindow := cluster.Spec.Backup.VolumeSnapshot.GetReuseWindow(); reuseWindow > 0 {
+			pendingPVCs, err = se.reuseFreshSnapshots(ctx, cluster, backup, targetPod, pendingPVCs, reuseWindow)
+			if err != nil {
+				return nil, err
+			}
+			if len(pendingPVCs) == 0 {
+				return &ctrl.Result{RequeueAfter: se.requeueInterval}, nil
+			}
+		}
+
+		if cluster.Spec.Backup.VolumeSnapshot.CleanTemporaryFiles && !se.remote {
+			se.cleanTemporaryFiles(ctx, backup, targetPod)
+		}
+
+		createStartedAt := time.Now()
+		err = se.createSnapshotPVCGroupStep(ctx, cluster, pendingPVCs, backup, targetPod)
+		se.tracePhase(ctx, tracingPhaseCreate, cluster, backup, len(pendingPVCs), createStartedAt, err)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ctrl.Result{RequeueAfter: se.requeueInterval}, nil
 	}
 
-	// Step 3: wait for snapshots to be ready
-	if res, err := se.waitSnapshotToBeReadyStep(ctx, volumeSnapshots); res != nil || err != nil {
+	// Step 4: wait for snapshots to be ready
+	if err := se.setProgressCondition(ctx, backup, apiv1.ConditionBackupSnapshotWaiting); err != nil {
+		return nil, err
+	}
+	res, err := se.waitSnapshotToBeReadyStep(ctx, backup, backupSnapshots)
+	if res != nil && err == nil {
+		// still waiting, the phase isn't over yet
+		return res, nil
+	}
+	se.tracePhase(ctx, tracingPhaseWait, cluster, backup, len(backupSnapshots),
+		getWaitStartTime(backup, volumeSnapshots), err)
+	if err != nil {
 		return res, err
 	}
 
-	if err := se.EnsurePodIsUnfenced(ctx, cluster, backup, targetPod); err != nil {
+	// Step 5: record where the recovery from the snapshots starts, how
+	// much data they captured, and how far the WAL archive extends
+	if err := se.setProgressCondition(ctx, backup, apiv1.ConditionBackupFinalizing); err != nil {
 		return nil, err
 	}
+	origBackup := backup.DeepCopy()
+	setBeginLSN(backup, backupSnapshots)
+	setTotalSize(backup, backupSnapshots)
+
+	if len(fencedPVCs) > 0 {
+		unfenceStartedAt := time.Now()
+		err = se.EnsurePodIsUnfenced(ctx, cluster, backup, targetPod)
+		se.tracePhase(ctx, tracingPhaseUnfence, cluster, backup, len(fencedPVCs), unfenceStartedAt, err)
+		if err != nil {
+			return nil, err
+		}
+		setFenceDuration(backup, time.Now())
+	}
+
+	// the status of the WAL archive is reported by the primary instance,
+	// which can only be queried once it's not fenced anymore
+	se.setLastArchivedLSN(ctx, cluster, backup, len(fencedPVCs) > 0 && isPrimaryTarget(cluster, targetPod))
+
+	// Step 6: wait for the WAL file needed by the snapshots to be archived
+	if cluster.Spec.Backup.VolumeSnapshot.ArchiveVerification != nil {
+		return se.startArchiveVerification(ctx, cluster, origBackup, backup, backupSnapshots, time.Now())
+	}
 
 	return nil, nil
 }
 
+// setProgressCondition reports that the backup entered the passed phase
+// through its conditions. The status is patched immediately, as a phase
+// usually spans multiple reconciliation loops, but only when the conditions
+// have been changed
+func (se *Reconciler) setProgressCondition(
+	ctx context.Context,
+	backup *apiv1.Backup,
+	phase apiv1.BackupConditionType,
+) error {
+	origBackup := backup.DeepCopy()
+	if !backup.Status.SetProgressCondition(phase) {
+		return nil
+	}
+	return se.backupCli.Status().Patch(ctx, backup, client.MergeFrom(origBackup))
+}
+
+// cleanTemporaryFiles removes the temporary files of the fenced instance, to
+// reduce the size of the snapshots. As this is only an optimization, a failure
+// doesn't prevent the snapshots from being taken
+func (se *Reconciler) cleanTemporaryFiles(ctx context.Context, backup *apiv1.Backup, targetPod *corev1.Pod) {
+	if err := se.temporaryFilesCleaner(ctx, targetPod); err != nil {
+		log.FromContext(ctx).Warning("Cannot remove the temporary files before taking the snapshots",
+			"podName", targetPod.Name, "err", err.Error())
+		se.recorder.Eventf(backup, "Warning", "CleanTemporaryFiles",
+			"Cannot remove the temporary files before taking the snapshots: %v", err)
+	}
+}
+
+// getSnapshotClassName gets the volume snapshot class to be used for a PVC
+// with the passed role, depending on whether the backup is taken from the
+// primary instance or from a standby. Returns nil to use the default class
+func getSnapshotClassName(
+	snapshotConfig *apiv1.VolumeSnapshotConfiguration,
+	role utils.PVCRole,
+	primaryTarget bool,
+) *string {
+	if role == utils.PVCRolePgWal && snapshotConfig.WalClassName != "" {
+		return &snapshotConfig.WalClassName
+	}
+
+	if !primaryTarget && snapshotConfig.StandbyClassName != "" {
+		return &snapshotConfig.StandbyClassName
+	}
+
+	// this is the default value if nothing else was assigned
+	if snapshotConfig.ClassName != "" {
+		return &snapshotConfig.ClassName
+	}
+
+	return nil
+}
+
+// getPVCSnapshotClassName gets the volume snapshot class to be used for
+// the passed PVC. The class selected by the PVC annotation, if any, is
+// preferred over the ones set in the cluster
+func getPVCSnapshotClassName(
+	snapshotConfig *apiv1.VolumeSnapshotConfiguration,
+	pvc *corev1.PersistentVolumeClaim,
+	primaryTarget bool,
+) *string {
+	if className := pvc.Annotations[utils.VolumeSnapshotClassAnnotationName]; className != "" {
+		return &className
+	}
+
+	return getSnapshotClassName(snapshotConfig, utils.PVCRole(pvc.Labels[utils.PvcRoleLabelName]), primaryTarget)
+}
+
+// isPrimaryTarget checks if the backup is taken from the primary instance
+func isPrimaryTarget(cluster *apiv1.Cluster, targetPod *corev1.Pod) bool {
+	return cluster.Status.CurrentPrimary == targetPod.Name
+}
+
+// getSnapshotSourceRole gets the role of the target pod of the backup
+func getSnapshotSourceRole(cluster *apiv1.Cluster, targetPod *corev1.Pod) utils.SnapshotSourceRole {
+	if isPrimaryTarget(cluster, targetPod) {
+		return utils.SnapshotSourceRolePrimary
+	}
+	return utils.SnapshotSourceRoleStandby
+}
+
+// splitPVCsByFencingRequirement splits the passed PVCs between the ones that
+// can be snapshotted while the instance is running and the ones requiring
+// the instance to be fenced, depending on the cluster configuration
+func splitPVCsByFencingRequirement(
+	cluster *apiv1.Cluster,
+	pvcs []corev1.PersistentVolumeClaim,
+) (onlinePVCs []corev1.PersistentVolumeClaim, fencedPVCs []corev1.PersistentVolumeClaim) {
+	var snapshotConfig *apiv1.VolumeSnapshotConfiguration
+	if cluster.Spec.Backup != nil {
+		snapshotConfig = cluster.Spec.Backup.VolumeSnapshot
+	}
+
+	for i := range pvcs {
+		if snapshotConfig.IsFencingRequired(pvcs[i].Labels[utils.PvcRoleLabelName]) {
+			fencedPVCs = append(fencedPVCs, pvcs[i])
+		} else {
+			onlinePVCs = append(onlinePVCs, pvcs[i])
+		}
+	}
+
+	return onlinePVCs, fencedPVCs
+}
+
+// getPVCsWithoutSnapshot returns the PVCs that have not been
+// snapshotted yet, given the snapshots taken by the backup
+func getPVCsWithoutSnapshot(
+	pvcs []corev1.PersistentVolumeClaim,
+	snapshots []storagesnapshotv1.VolumeSnapshot,
+) []corev1.PersistentVolumeClaim {
+	snapshottedPVCs := stringset.New()
+	for i := range snapshots {
+		if source := snapshots[i].Spec.Source.PersistentVolumeClaimName; source != nil {
+			snapshottedPVCs.Put(*source)
+		}
+	}
+
+	var result []corev1.PersistentVolumeClaim
+	for i := range pvcs {
+		if !snapshottedPVCs.Has(pvcs[i].Name) {
+			result = append(result, pvcs[i])
+		}
+	}
+
+	return result
+}
+
 // ensurePodIsFenced checks if the preconditions for the execution of this step are
 // met or not. If they are not met, it will return an error
 func (se *Reconciler) ensurePodIsFenced(
@@ -174,22 +660,18 @@ func (se *Reconciler) ensurePodIsFenced(
 	backup *apiv1.Backup,
 	targetPodName string,
 ) error {
-	fencedInstances, err := utils.GetFencedInstances(cluster.Annotations)
+	alreadyFenced, err := checkFencedInstances(cluster, targetPodName, se.tolerateFencedInstances)
 	if err != nil {
-		return fmt.Errorf("could not check if cluster is fenced: %v", err)
+		return err
 	}
 
-	if slices.Equal(fencedInstances.ToList(), []string{targetPodName}) {
+	if alreadyFenced {
 		// We already requested the target Pod to be fenced
 		return nil
 	}
 
-	if fencedInstances.Len() != 0 {
-		return errors.New("cannot execute volume snapshot on a cluster that has fenced instances")
-	}
-
-	// The list of fenced instances is empty, so we need to request
-	// fencing for the target pod
+	// The target pod is not fenced yet, so we need to request
+	// fencing for it
 	se.recorder.Eventf(backup, "Normal", "FencePod",
 		"Requesting fencing for Pod %v", targetPodName)
 
@@ -199,14 +681,16 @@ func (se *Reconciler) ensurePodIsFenced(
 		cluster.Name,
 		cluster.Namespace,
 		targetPodName,
-		utils.AddFencedInstance,
+		utils.AddFencedInstanceForBackup(backup.Name),
 	); !errors.Is(err, utils.ErrorServerAlreadyFenced) {
 		return err
 	}
 	return nil
 }
 
-// EnsurePodIsUnfenced removes the fencing status from the cluster
+// EnsurePodIsUnfenced removes the fencing status from the cluster. Only the
+// fencing requested by the passed backup is removed, and calling this function
+// again on an already unfenced Pod is safe
 func (se *Reconciler) EnsurePodIsUnfenced(
 	ctx context.Context,
 	cluster *apiv1.Cluster,
@@ -216,19 +700,27 @@ func (se *Reconciler) EnsurePodIsUnfenced(
 	contextLogger := log.FromContext(ctx)
 	contextLogger.Info("Unfencing Pod")
 
-	if err := resources.ApplyFenceFunc(
+	err := resources.ApplyFenceFunc(
 		ctx,
 		se.cli,
 		cluster.Name,
 		cluster.Namespace,
 		targetPod.Name,
-		utils.RemoveFencedInstance,
-	); err != nil {
+		utils.RemoveFencedInstanceForBackup(backup.Name),
+	)
+	switch {
+	case errors.Is(err, utils.ErrorServerAlreadyUnfenced):
+		contextLogger.Info("Pod already unfenced")
+		return nil
+	case errors.Is(err, utils.ErrorInstanceNotFencedByBackup):
+		contextLogger.Info("Pod not fenced by this backup, leaving it fenced")
+		se.recorder.Eventf(backup, "Warning", "UnfencePod",
+			"Not un-fencing Pod %v, as it has not been fenced by this backup", targetPod.Name)
+		return nil
+	case err != nil:
 		return err
 	}
 
-	// The list of fenced instances is empty, so we need to request
-	// fencing for the target pod
 	se.recorder.Eventf(backup, "Normal", "UnfencePod",
 		"Un-fencing Pod %v", targetPod.Name)
 	return nil
@@ -237,6 +729,7 @@ func (se *Reconciler) EnsurePodIsUnfenced(
 // waitForPodToBeFenced waits for the target Pod to be shut down
 func (se *Reconciler) waitForPodToBeFenced(
 	ctx context.Context,
+	backup *apiv1.Backup,
 	targetPod *corev1.Pod,
 ) (*ctrl.Result, error) {
 	contextLogger := log.FromContext(ctx)
@@ -249,7 +742,7 @@ func (se *Reconciler) waitForPodToBeFenced(
 	ready := utils.IsPodReady(pod)
 	if ready {
 		contextLogger.Info("Waiting for target Pod to not be ready, retrying", "podName", targetPod.Name)
-		return &ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		return &ctrl.Result{RequeueAfter: se.getRequeueInterval(getFencingStartTime(backup), time.Now())}, nil
 	}
 	return nil, nil
 }
@@ -265,13 +758,80 @@ func (se *Reconciler) createSnapshotPVCGroupStep(
 ) error {
 	snapshotSuffix := fmt.Sprintf("%d", time.Now().Unix())
 
+	// The snapshots are requested concurrently to shorten the time the
+	// instance stays fenced, and the first failure aborts the others
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the parents of incremental snapshots are looked up before the
+	// snapshots are taken, as they must be the ones of a previous backup
+	parentSnapshots := se.getParentSnapshots(ctx, cluster, backup, targetPod, pvcs)
+
+	results := make([]error, len(pvcs))
+	var waitGroup sync.WaitGroup
 	for i := range pvcs {
 		se.recorder.Eventf(backup, "Normal", "CreateSnapshot",
 			"Creating VolumeSnapshot for PVC %v", pvcs[i].Name)
 
-		err := se.createSnapshot(ctx, cluster, backup, targetPod, &pvcs[i], snapshotSuffix)
-		if err != nil {
-			return err
+		waitGroup.Add(1)
+		go func(pvcIndex int) {
+			defer waitGroup.Done()
+			results[pvcIndex] = se.createSnapshot(ctx, cluster, backup, targetPod, &pvcs[pvcIndex],
+				parentSnapshots[pvcs[pvcIndex].Name], snapshotSuffix)
+			if results[pvcIndex] != nil {
+				cancel()
+			}
+		}(i)
+	}
+	waitGroup.Wait()
+
+	return aggregateSnapshotErrors(results)
+}
+
+// aggregateSnapshotErrors aggregates the errors of the creation of the
+// snapshots, leaving out the ones caused by the abort of the other creations
+func aggregateSnapshotErrors(results []error) error {
+	var errs []error
+	var abortErrs []error
+	for _, err := range results {
+		switch {
+		case err == nil:
+			continue
+		case errors.Is(err, context.Canceled):
+			abortErrs = append(abortErrs, err)
+		default:
+			errs = append(errs, err)
+		}
+	}
+
+	// the parent context could have been canceled too
+	if len(errs) == 0 {
+		errs = abortErrs
+	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		// a single error keeps its type, i.e. to be detected as retryable
+		return errs[0]
+	default:
+		return utilerrors.NewAggregate(errs)
+	}
+}
+
+// ensurePVCsAreSnapshottable checks that we know the role of every PVC
+// to be snapshotted, including the ones backing generic ephemeral volumes,
+// as the role is needed to restore the snapshots
+func ensurePVCsAreSnapshottable(pvcs []corev1.PersistentVolumeClaim) error {
+	for i := range pvcs {
+		switch utils.PVCRole(pvcs[i].Labels[utils.PvcRoleLabelName]) {
+		case utils.PVCRolePgData, utils.PVCRolePgWal:
+			continue
+		default:
+			return fmt.Errorf(
+				"cannot take a snapshot of PVC %s: missing or unknown %s label",
+				pvcs[i].Name, utils.PvcRoleLabelName)
 		}
 	}
 
@@ -281,43 +841,69 @@ func (se *Reconciler) createSnapshotPVCGroupStep(
 // waitSnapshotToBeReadyStep waits for every PVC snapshot to be ready to use
 func (se *Reconciler) waitSnapshotToBeReadyStep(
 	ctx context.Context,
+	backup *apiv1.Backup,
 	snapshots []storagesnapshotv1.VolumeSnapshot,
 ) (*ctrl.Result, error) {
 	for i := range snapshots {
-		if res, err := se.waitSnapshot(ctx, &snapshots[i]); res != nil || err != nil {
-			return res, err
+		res, err := se.waitSnapshot(ctx, &snapshots[i])
+		if err != nil {
+			return nil, err
+		}
+		if res == nil {
+			continue
+		}
+
+		// A stuck CSI driver would otherwise keep the backup, and
+		// possibly the fenced instance, waiting forever
+		if isSnapshotReadyTimeoutExceeded(&snapshots[i], se.readyTimeout, time.Now()) {
+			se.recorder.Eventf(backup, "Warning", "SnapshotReadyTimeout",
+				"VolumeSnapshot %s not ready within %s", snapshots[i].Name, se.readyTimeout)
+			return nil, fmt.Errorf("VolumeSnapshot %s not ready within the timeout of %s",
+				snapshots[i].Name, se.readyTimeout)
 		}
+		return res, nil
 	}
 
 	return nil, nil
 }
 
+// isSnapshotReadyTimeoutExceeded checks whether the passed snapshot has
+// been created more than the ready timeout before the passed time. A zero
+// timeout is never exceeded
+func isSnapshotReadyTimeoutExceeded(
+	snapshot *storagesnapshotv1.VolumeSnapshot,
+	readyTimeout time.Duration,
+	now time.Time,
+) bool {
+	if readyTimeout <= 0 || snapshot.CreationTimestamp.IsZero() {
+		return false
+	}
+
+	return now.Sub(snapshot.CreationTimestamp.Time) > readyTimeout
+}
+
 // createSnapshot creates a VolumeSnapshot resource for the given PVC and
-// add it to the command status
+// add it to the command status. The snapshot is chained to the passed
+// parent snapshot, if any
 func (se *Reconciler) createSnapshot(
 	ctx context.Context,
 	cluster *apiv1.Cluster,
 	backup *apiv1.Backup,
 	targetPod *corev1.Pod,
 	pvc *corev1.PersistentVolumeClaim,
+	parentSnapshot *storagesnapshotv1.VolumeSnapshot,
 	snapshotSuffix string,
 ) error {
 	snapshotConfig := *cluster.Spec.Backup.VolumeSnapshot
 	name := se.getSnapshotName(pvc.Name, snapshotSuffix)
-	var snapshotClassName *string
-	role := utils.PVCRole(pvc.Labels[utils.PvcRoleLabelName])
-	if role == utils.PVCRolePgWal && snapshotConfig.WalClassName != "" {
-		snapshotClassName = &snapshotConfig.WalClassName
-	}
-
-	// this is the default value if nothing else was assigned
-	if snapshotClassName == nil && snapshotConfig.ClassName != "" {
-		snapshotClassName = &snapshotConfig.ClassName
-	}
+	snapshotClassName := getPVCSnapshotClassName(&snapshotConfig, pvc, isPrimaryTarget(cluster, targetPod))
 
 	labels := pvc.Labels
+	utils.MergeMap(labels, getInheritedMetadata(cluster.Labels, snapshotConfig.InheritedLabelPrefixes))
 	utils.MergeMap(labels, snapshotConfig.Labels)
+	utils.MergeMap(labels, getRequiredLabels(&snapshotConfig))
 	annotations := pvc.Annotations
+	utils.MergeMap(annotations, getInheritedMetadata(cluster.Annotations, snapshotConfig.InheritedAnnotationPrefixes))
 	utils.MergeMap(annotations, snapshotConfig.Annotations)
 
 	snapshot := storagesnapshotv1.VolumeSnapshot{
@@ -340,12 +926,23 @@ func (se *Reconciler) createSnapshot(
 	if snapshot.Annotations == nil {
 		snapshot.Annotations = map[string]string{}
 	}
+	if parentSnapshot != nil {
+		setParentSnapshot(&snapshot, parentSnapshot)
+	}
 
 	if err := se.enrichSnapshot(ctx, &snapshot, backup, cluster, targetPod); err != nil {
 		return err
 	}
 
-	err := se.cli.Create(ctx, &snapshot)
+	err := se.createVolumeSnapshot(ctx, cluster, &snapshot)
+	if apierrs.IsAlreadyExists(err) {
+		// The snapshot was created by a previous reconciliation loop but
+		// the informer cache didn't receive it yet
+		log.FromContext(ctx).Info(
+			"VolumeSnapshot already exists, the cache is not up to date",
+			"volumeSnapshotName", snapshot.Name)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("while creating VolumeSnapshot %s: %w", snapshot.Name, err)
 	}
@@ -364,16 +961,85 @@ func (se *Reconciler) waitSnapshot(
 	if info.Error != nil {
 		return nil, info.Error
 	}
+	now := time.Now()
+	interval := se.getRequeueInterval(snapshot.CreationTimestamp.Time, now)
+	if info.Running && isSnapshotCacheSuspect(snapshot, now, interval) {
+		// The snapshot is taking longer than expected: before waiting
+		// again, ensure we are not looking at a stale copy from the cache
+		var liveSnapshot storagesnapshotv1.VolumeSnapshot
+		if err := se.liveReader.Get(ctx, client.ObjectKeyFromObject(snapshot), &liveSnapshot); err != nil {
+			return nil, fmt.Errorf("while reading VolumeSnapshot %s: %w", snapshot.Name, err)
+		}
+		*snapshot = liveSnapshot
+
+		info = parseVolumeSnapshotInfo(snapshot)
+		if info.Error != nil {
+			return nil, info.Error
+		}
+	}
 	if info.Running {
 		contextLogger.Info(
 			"Waiting for VolumeSnapshot to be ready to use",
-			"volumeSnapshotName", snapshot.Name)
-		return &ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+			"volumeSnapshotName", snapshot.Name,
+			"requeueAfter", interval)
+		return &ctrl.Result{RequeueAfter: interval}, nil
 	}
 
 	return nil, nil
 }
 
+// snapshotCacheStalenessThreshold is how long a VolumeSnapshot can stay
+// not ready in the informer cache before we verify it with a live read
+const snapshotCacheStalenessThreshold = 30 * time.Second
+
+// snapshotCacheMaxVerificationInterval is the longest interval between two
+// live reads of a VolumeSnapshot which is not ready yet
+const snapshotCacheMaxVerificationInterval = 5 * time.Minute
+
+// isSnapshotCacheSuspect checks if the cached copy of a not ready snapshot
+// is worth a live read from the API server. The snapshot is verified once
+// it is older than snapshotCacheStalenessThreshold, and then with an
+// exponential back-off capped at snapshotCacheMaxVerificationInterval: the
+// live read happens only in the first wait interval after each of these
+// deadlines, so that a slow snapshot doesn't cost a live read on every
+// reconciliation loop. The wait interval is the one between the current
+// reconciliation loop and the next one
+func isSnapshotCacheSuspect(
+	snapshot *storagesnapshotv1.VolumeSnapshot,
+	now time.Time,
+	waitInterval time.Duration,
+) bool {
+	age := now.Sub(snapshot.CreationTimestamp.Time)
+	if age < snapshotCacheStalenessThreshold {
+		return false
+	}
+
+	deadline := snapshotCacheStalenessThreshold
+	for deadline*2 <= age && deadline*2 <= snapshotCacheMaxVerificationInterval {
+		deadline *= 2
+	}
+
+	sinceDeadline := age - deadline
+	if deadline*2 > snapshotCacheMaxVerificationInterval {
+		sinceDeadline %= snapshotCacheMaxVerificationInterval
+	}
+	return sinceDeadline < waitInterval
+}
+
+// deleteSnapshots deletes the passed volume snapshots
+func (se *Reconciler) deleteSnapshots(
+	ctx context.Context,
+	snapshots []storagesnapshotv1.VolumeSnapshot,
+) error {
+	for i := range snapshots {
+		if err := se.cli.Delete(ctx, &snapshots[i]); err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("while deleting VolumeSnapshot %s: %w", snapshots[i].Name, err)
+		}
+	}
+
+	return nil
+}
+
 // getSnapshotName gets the snapshot name for a certain PVC
 func (se *Reconciler) getSnapshotName(pvcName string, snapshotSuffix string) string {
 	return fmt.Sprintf("%s-%s", pvcName, snapshotSuffix)
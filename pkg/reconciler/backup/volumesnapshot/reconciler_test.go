@@ -0,0 +1,1051 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// conflictingStatusClient fails every patch of the status of the
+// objects with a conflict
+type conflictingStatusClient struct {
+	client.Client
+}
+
+func (c conflictingStatusClient) Status() client.SubResourceWriter {
+	return conflictingStatusWriter{SubResourceWriter: c.Client.Status()}
+}
+
+type conflictingStatusWriter struct {
+	client.SubResourceWriter
+}
+
+func (conflictingStatusWriter) Patch(
+	_ context.Context,
+	obj client.Object,
+	_ client.Patch,
+	_ ...client.SubResourcePatchOption,
+) error {
+	return apierrs.NewConflict(schema.GroupResource{Resource: "backups"}, obj.GetName(),
+		errors.New("the object has been modified"))
+}
+
+var _ = Describe("PVCs to be snapshotted", func() {
+	newPVC := func(name string, labels map[string]string) corev1.PersistentVolumeClaim {
+		return corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: labels,
+			},
+		}
+	}
+
+	It("accepts the WAL PVCs backing generic ephemeral volumes", func() {
+		pvcs := []corev1.PersistentVolumeClaim{
+			newPVC("cluster-example-1", map[string]string{
+				utils.PvcRoleLabelName: string(utils.PVCRolePgData),
+			}),
+			newPVC("cluster-example-1-pg-wal", map[string]string{
+				utils.PvcRoleLabelName: string(utils.PVCRolePgWal),
+			}),
+		}
+		Expect(ensurePVCsAreSnapshottable(pvcs)).To(Succeed())
+	})
+
+	It("refuses the ephemeral volume PVCs without a role", func() {
+		pvcs := []corev1.PersistentVolumeClaim{
+			newPVC("cluster-example-1", map[string]string{
+				utils.PvcRoleLabelName: string(utils.PVCRolePgData),
+			}),
+			newPVC("cluster-example-1-scratch", nil),
+		}
+		err := ensurePVCsAreSnapshottable(pvcs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("cluster-example-1-scratch"))
+	})
+})
+
+var _ = Describe("fencing requirements of the PVC roles", func() {
+	var (
+		cluster   *apiv1.Cluster
+		backup    *apiv1.Backup
+		targetPod *corev1.Pod
+		pvcs      []corev1.PersistentVolumeClaim
+	)
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						ClassName: "csi-snapclass",
+					},
+				},
+			},
+		}
+		backup = &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backup-example",
+				Namespace: "default",
+			},
+		}
+		targetPod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example-2",
+				Namespace: "default",
+			},
+		}
+		pvcs = []corev1.PersistentVolumeClaim{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-example-2",
+					Namespace: "default",
+					Labels: map[string]string{
+						utils.PvcRoleLabelName: string(utils.PVCRolePgData),
+					},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-example-2-wal",
+					Namespace: "default",
+					Labels: map[string]string{
+						utils.PvcRoleLabelName: string(utils.PVCRolePgWal),
+					},
+				},
+			},
+		}
+	})
+
+	pvcNames := func(pvcs []corev1.PersistentVolumeClaim) []string {
+		names := make([]string, len(pvcs))
+		for i := range pvcs {
+			names[i] = pvcs[i].Name
+		}
+		return names
+	}
+
+	It("requires fencing for every role by default", func() {
+		onlinePVCs, fencedPVCs := splitPVCsByFencingRequirement(cluster, pvcs)
+		Expect(onlinePVCs).To(BeEmpty())
+		Expect(pvcNames(fencedPVCs)).To(Equal([]string{"cluster-example-2", "cluster-example-2-wal"}))
+	})
+
+	It("splits the PVCs when the fencing requirements are mixed", func() {
+		cluster.Spec.Backup.VolumeSnapshot.FencingRequirements = []apiv1.VolumeSnapshotFencingRequirement{
+			{Role: string(utils.PVCRolePgData), FencingRequired: true},
+			{Role: string(utils.PVCRolePgWal), FencingRequired: false},
+		}
+		onlinePVCs, fencedPVCs := splitPVCsByFencingRequirement(cluster, pvcs)
+		Expect(pvcNames(onlinePVCs)).To(Equal([]string{"cluster-example-2-wal"}))
+		Expect(pvcNames(fencedPVCs)).To(Equal([]string{"cluster-example-2"}))
+	})
+
+	It("detects the PVCs not snapshotted yet", func() {
+		snapshots := []storagesnapshotv1.VolumeSnapshot{
+			{
+				Spec: storagesnapshotv1.VolumeSnapshotSpec{
+					Source: storagesnapshotv1.VolumeSnapshotSource{
+						PersistentVolumeClaimName: ptr.To("cluster-example-2-wal"),
+					},
+				},
+			},
+		}
+		Expect(pvcNames(getPVCsWithoutSnapshot(pvcs, snapshots))).To(Equal([]string{"cluster-example-2"}))
+		Expect(getPVCsWithoutSnapshot(pvcs[1:], snapshots)).To(BeEmpty())
+	})
+
+	It("snapshots the online PVCs before fencing the instance", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.FencingRequirements = []apiv1.VolumeSnapshotFencingRequirement{
+			{Role: string(utils.PVCRolePgWal), FencingRequired: false},
+		}
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, backup, targetPod).
+			WithStatusSubresource(backup).
+			Build()
+		executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+			FenceInstance(true).
+			Build()
+
+		getSnapshottedPVCs := func() []string {
+			snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+			Expect(err).ToNot(HaveOccurred())
+			result := make([]string, 0, len(snapshots))
+			for i := range snapshots {
+				result = append(result, *snapshots[i].Spec.Source.PersistentVolumeClaimName)
+			}
+			return result
+		}
+
+		getFencedInstances := func() []string {
+			var current apiv1.Cluster
+			Expect(cli.Get(ctx, client.ObjectKeyFromObject(cluster), &current)).To(Succeed())
+			fencedInstances, err := utils.GetFencedInstances(current.Annotations)
+			Expect(err).ToNot(HaveOccurred())
+			return fencedInstances.ToList()
+		}
+
+		By("taking the snapshot of the WAL PVC without fencing", func() {
+			res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).ToNot(BeNil())
+			Expect(getSnapshottedPVCs()).To(ConsistOf("cluster-example-2-wal"))
+			Expect(getFencedInstances()).To(BeEmpty())
+		})
+
+		By("fencing the instance and taking the snapshot of the data PVC", func() {
+			res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).ToNot(BeNil())
+			Expect(getSnapshottedPVCs()).To(ConsistOf("cluster-example-2-wal", "cluster-example-2"))
+			Expect(getFencedInstances()).To(Equal([]string{"cluster-example-2"}))
+		})
+	})
+
+	It("keeps the instance fenced on a retryable error", func(ctx context.Context) {
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, backup, targetPod).
+			WithStatusSubresource(backup).
+			Build()
+
+		By("fencing the instance and taking the snapshots", func() {
+			executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+				FenceInstance(true).
+				Build()
+			res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).ToNot(BeNil())
+			Expect(backup.Status.BackupSnapshotStatus.FencedAt).ToNot(BeNil())
+		})
+
+		By("failing to patch the status of the backup with a conflict", func() {
+			executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+				FenceInstance(true).
+				BackupClient(conflictingStatusClient{Client: cli}).
+				Build()
+			_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(apierrs.IsConflict(err)).To(BeTrue())
+		})
+
+		var current apiv1.Cluster
+		Expect(cli.Get(ctx, client.ObjectKeyFromObject(cluster), &current)).To(Succeed())
+		fencedInstances, err := utils.GetFencedInstances(current.Annotations)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fencedInstances.ToList()).To(Equal([]string{"cluster-example-2"}))
+	})
+
+	It("reports the phases of the backup in its conditions", func(ctx context.Context) {
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, backup, targetPod).
+			WithStatusSubresource(backup).
+			Build()
+		executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+			FenceInstance(true).
+			Build()
+
+		res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).ToNot(BeNil())
+
+		var current apiv1.Backup
+		Expect(cli.Get(ctx, client.ObjectKeyFromObject(backup), &current)).To(Succeed())
+		fencing := meta.FindStatusCondition(current.Status.Conditions, string(apiv1.ConditionBackupFencing))
+		Expect(fencing).ToNot(BeNil())
+		Expect(fencing.Status).To(Equal(metav1.ConditionFalse))
+		Expect(fencing.Reason).To(Equal(string(apiv1.ConditionReasonPhaseCompleted)))
+		Expect(meta.IsStatusConditionTrue(current.Status.Conditions,
+			string(apiv1.ConditionBackupSnapshotCreating))).To(BeTrue())
+		Expect(meta.FindStatusCondition(current.Status.Conditions,
+			string(apiv1.ConditionBackupSnapshotWaiting))).To(BeNil())
+	})
+
+	It("records the node of the target pod in the snapshots", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.FencingRequirements = []apiv1.VolumeSnapshotFencingRequirement{
+			{Role: string(utils.PVCRolePgWal), FencingRequired: false},
+		}
+		targetPod.Spec.NodeName = "worker-1"
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, backup, targetPod).
+			WithStatusSubresource(backup).
+			Build()
+		executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+			FenceInstance(true).
+			Build()
+
+		_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).ToNot(HaveOccurred())
+
+		snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(HaveLen(1))
+		Expect(snapshots[0].Annotations).To(HaveKeyWithValue(utils.BackupNodeAnnotationName, "worker-1"))
+		Expect(verifyMetadataChecksum(&snapshots[0])).To(Succeed())
+	})
+
+	It("labels the snapshots with the target pod and its role", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.FencingRequirements = []apiv1.VolumeSnapshotFencingRequirement{
+			{Role: string(utils.PVCRolePgWal), FencingRequired: false},
+		}
+		cluster.Status.CurrentPrimary = "cluster-example-1"
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, backup, targetPod).
+			WithStatusSubresource(backup).
+			Build()
+		executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+			FenceInstance(true).
+			Build()
+
+		_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).ToNot(HaveOccurred())
+
+		snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(HaveLen(1))
+		Expect(snapshots[0].Labels).To(HaveKeyWithValue(utils.SnapshotSourcePodLabelName, "cluster-example-2"))
+		Expect(snapshots[0].Labels).To(HaveKeyWithValue(utils.SnapshotSourceRoleLabelName, "standby"))
+	})
+
+	It("tells the role of the target pod", func() {
+		cluster.Status.CurrentPrimary = "cluster-example-2"
+		Expect(getSnapshotSourceRole(cluster, targetPod)).To(Equal(utils.SnapshotSourceRolePrimary))
+
+		cluster.Status.CurrentPrimary = "cluster-example-1"
+		Expect(getSnapshotSourceRole(cluster, targetPod)).To(Equal(utils.SnapshotSourceRoleStandby))
+	})
+
+	It("adds the required labels to the snapshots", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.FencingRequirements = []apiv1.VolumeSnapshotFencingRequirement{
+			{Role: string(utils.PVCRolePgWal), FencingRequired: false},
+		}
+		cluster.Spec.Backup.VolumeSnapshot.RequiredLabels = map[string]string{
+			"scanner.example.com/include": "true",
+			utils.BackupNameLabelName:     "other-backup",
+		}
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, backup, targetPod).
+			WithStatusSubresource(backup).
+			Build()
+		executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+			FenceInstance(true).
+			Build()
+
+		_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).ToNot(HaveOccurred())
+
+		snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(HaveLen(1))
+		Expect(snapshots[0].Labels).To(HaveKeyWithValue("scanner.example.com/include", "true"))
+		Expect(snapshots[0].Labels).To(HaveKeyWithValue(utils.BackupNameLabelName, backup.Name))
+	})
+
+	It("cleans the temporary files before snapshotting the fenced PVCs", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.CleanTemporaryFiles = true
+		cluster.Spec.Backup.VolumeSnapshot.FencingRequirements = []apiv1.VolumeSnapshotFencingRequirement{
+			{Role: string(utils.PVCRolePgWal), FencingRequired: false},
+		}
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, backup, targetPod).
+			WithStatusSubresource(backup).
+			Build()
+		executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+			FenceInstance(true).
+			Build()
+
+		var snapshotsWhenCleaned [][]string
+		executor.temporaryFilesCleaner = func(ctx context.Context, pod *corev1.Pod) error {
+			Expect(pod.Name).To(Equal(targetPod.Name))
+			snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+			Expect(err).ToNot(HaveOccurred())
+			names := make([]string, 0, len(snapshots))
+			for i := range snapshots {
+				names = append(names, *snapshots[i].Spec.Source.PersistentVolumeClaimName)
+			}
+			snapshotsWhenCleaned = append(snapshotsWhenCleaned, names)
+			return nil
+		}
+
+		By("not cleaning while the instance is running", func() {
+			_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshotsWhenCleaned).To(BeEmpty())
+		})
+
+		By("cleaning once fenced, before snapshotting the data PVC", func() {
+			_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshotsWhenCleaned).To(Equal([][]string{{"cluster-example-2-wal"}}))
+
+			snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(HaveLen(2))
+		})
+	})
+
+	It("takes the snapshots even if the temporary files cannot be cleaned", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.CleanTemporaryFiles = true
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, backup, targetPod).
+			WithStatusSubresource(backup).
+			Build()
+		executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+			FenceInstance(true).
+			Build()
+		executor.temporaryFilesCleaner = func(context.Context, *corev1.Pod) error {
+			return fmt.Errorf("instance not stopped")
+		}
+
+		_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).ToNot(HaveOccurred())
+
+		snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(HaveLen(2))
+	})
+
+	It("never fences the instance when no role requires it", func(ctx context.Context) {
+		cluster.Spec.Backup.VolumeSnapshot.FencingRequirements = []apiv1.VolumeSnapshotFencingRequirement{
+			{Role: string(utils.PVCRolePgData), FencingRequired: false},
+			{Role: string(utils.PVCRolePgWal), FencingRequired: false},
+		}
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, backup, targetPod).
+			WithStatusSubresource(backup).
+			Build()
+		executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+			FenceInstance(true).
+			Build()
+
+		_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).ToNot(HaveOccurred())
+
+		snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(HaveLen(2))
+
+		var current apiv1.Cluster
+		Expect(cli.Get(ctx, client.ObjectKeyFromObject(cluster), &current)).To(Succeed())
+		Expect(current.Annotations).ToNot(HaveKey(utils.FencedInstanceAnnotation))
+	})
+})
+
+var _ = Describe("backup timeout", func() {
+	It("fails the backup and deletes the snapshots when exceeded while waiting for them", func(ctx context.Context) {
+		cluster := &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						ClassName: "csi-snapclass",
+						FencingRequirements: []apiv1.VolumeSnapshotFencingRequirement{
+							{Role: string(utils.PVCRolePgData), FencingRequired: false},
+						},
+					},
+				},
+			},
+		}
+		backup := &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup-example", Namespace: "default"},
+			Spec:       apiv1.BackupSpec{Timeout: 3600},
+			Status:     apiv1.BackupStatus{StartedAt: ptr.To(metav1.Now())},
+		}
+		targetPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-2", Namespace: "default"}}
+		pvcs := []corev1.PersistentVolumeClaim{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-example-2",
+					Namespace: "default",
+					Labels:    map[string]string{utils.PvcRoleLabelName: string(utils.PVCRolePgData)},
+				},
+			},
+		}
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, backup, targetPod).
+			WithStatusSubresource(backup).
+			Build()
+		executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+			FenceInstance(true).
+			Build()
+
+		By("taking the snapshots", func() {
+			res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).ToNot(BeNil())
+		})
+
+		By("waiting for the snapshots to be ready within the timeout", func() {
+			res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).ToNot(BeNil())
+		})
+
+		By("failing once the timeout is exceeded", func() {
+			backup.Status.StartedAt = ptr.To(metav1.NewTime(time.Now().Add(-2 * time.Hour)))
+			_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("timeout"))
+
+			snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(BeEmpty())
+		})
+	})
+
+	It("defaults to twelve hours", func() {
+		backup := &apiv1.Backup{
+			Status: apiv1.BackupStatus{StartedAt: ptr.To(metav1.NewTime(time.Now().Add(-11 * time.Hour)))},
+		}
+		Expect(backup.GetTimeout()).To(Equal(12 * time.Hour))
+		Expect(backup.IsTimeoutExceeded(time.Now())).To(BeFalse())
+		Expect(backup.IsTimeoutExceeded(time.Now().Add(2 * time.Hour))).To(BeTrue())
+	})
+
+	It("is never exceeded before the backup starts", func() {
+		backup := &apiv1.Backup{Spec: apiv1.BackupSpec{Timeout: 1}}
+		Expect(backup.IsTimeoutExceeded(time.Now())).To(BeFalse())
+	})
+})
+
+var _ = Describe("Snapshot class selection", func() {
+	var snapshotConfig *apiv1.VolumeSnapshotConfiguration
+
+	BeforeEach(func() {
+		snapshotConfig = &apiv1.VolumeSnapshotConfiguration{
+			ClassName:        "primary-class",
+			StandbyClassName: "standby-class",
+		}
+	})
+
+	It("uses the default class when targeting the primary", func() {
+		Expect(getSnapshotClassName(snapshotConfig, utils.PVCRolePgData, true)).To(HaveValue(Equal("primary-class")))
+		Expect(getSnapshotClassName(snapshotConfig, utils.PVCRolePgWal, true)).To(HaveValue(Equal("primary-class")))
+	})
+
+	It("uses the standby class when targeting a standby", func() {
+		Expect(getSnapshotClassName(snapshotConfig, utils.PVCRolePgData, false)).To(HaveValue(Equal("standby-class")))
+		Expect(getSnapshotClassName(snapshotConfig, utils.PVCRolePgWal, false)).To(HaveValue(Equal("standby-class")))
+	})
+
+	It("falls back to the default class without a standby class", func() {
+		snapshotConfig.StandbyClassName = ""
+		Expect(getSnapshotClassName(snapshotConfig, utils.PVCRolePgData, false)).To(HaveValue(Equal("primary-class")))
+	})
+
+	It("always uses the WAL class for the WAL PVCs", func() {
+		snapshotConfig.WalClassName = "wal-class"
+		Expect(getSnapshotClassName(snapshotConfig, utils.PVCRolePgWal, true)).To(HaveValue(Equal("wal-class")))
+		Expect(getSnapshotClassName(snapshotConfig, utils.PVCRolePgWal, false)).To(HaveValue(Equal("wal-class")))
+	})
+
+	It("uses the default class of the cluster when nothing is configured", func() {
+		Expect(getSnapshotClassName(&apiv1.VolumeSnapshotConfiguration{}, utils.PVCRolePgData, false)).To(BeNil())
+	})
+
+	It("prefers the class selected by the PVC annotation", func() {
+		snapshotConfig.WalClassName = "wal-class"
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "cluster-example-1-wal",
+				Labels:      map[string]string{utils.PvcRoleLabelName: string(utils.PVCRolePgWal)},
+				Annotations: map[string]string{utils.VolumeSnapshotClassAnnotationName: "tablespace-class"},
+			},
+		}
+		Expect(getPVCSnapshotClassName(snapshotConfig, pvc, true)).To(HaveValue(Equal("tablespace-class")))
+		Expect(getPVCSnapshotClassName(snapshotConfig, pvc, false)).To(HaveValue(Equal("tablespace-class")))
+
+		delete(pvc.Annotations, utils.VolumeSnapshotClassAnnotationName)
+		Expect(getPVCSnapshotClassName(snapshotConfig, pvc, true)).To(HaveValue(Equal("wal-class")))
+	})
+
+	It("detects whether the backup targets the primary", func() {
+		cluster := &apiv1.Cluster{Status: apiv1.ClusterStatus{CurrentPrimary: "cluster-example-1"}}
+		Expect(isPrimaryTarget(cluster, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-1"}})).
+			To(BeTrue())
+		Expect(isPrimaryTarget(cluster, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-2"}})).
+			To(BeFalse())
+	})
+})
+
+var _ = Describe("waiting for snapshots through the cache", func() {
+	newSnapshot := func(age time.Duration, ready bool) *storagesnapshotv1.VolumeSnapshot {
+		return &storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "cluster-example-1-snapshot",
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			},
+			Status: &storagesnapshotv1.VolumeSnapshotStatus{ReadyToUse: ptr.To(ready)},
+		}
+	}
+
+	newClient := func(snapshot *storagesnapshotv1.VolumeSnapshot) client.Client {
+		return fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(snapshot).
+			Build()
+	}
+
+	It("trusts the cache for the snapshots just created", func(ctx context.Context) {
+		cached := newSnapshot(time.Second, false)
+		executor := NewExecutorBuilder(newClient(cached), record.NewFakeRecorder(10)).
+			LiveReader(newClient(newSnapshot(time.Second, true))).
+			Build()
+
+		res, err := executor.waitSnapshot(ctx, cached)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).ToNot(BeNil())
+		Expect(*cached.Status.ReadyToUse).To(BeFalse())
+	})
+
+	It("falls back to a live read when the cached snapshot is late", func(ctx context.Context) {
+		cached := newSnapshot(time.Minute, false)
+		executor := NewExecutorBuilder(newClient(cached), record.NewFakeRecorder(10)).
+			LiveReader(newClient(newSnapshot(time.Minute, true))).
+			Build()
+
+		res, err := executor.waitSnapshot(ctx, cached)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).To(BeNil())
+		Expect(*cached.Status.ReadyToUse).To(BeTrue())
+	})
+
+	It("keeps waiting when the live snapshot is not ready either", func(ctx context.Context) {
+		cached := newSnapshot(time.Minute, false)
+		executor := NewExecutorBuilder(newClient(cached), record.NewFakeRecorder(10)).
+			LiveReader(newClient(newSnapshot(time.Minute, false))).
+			Build()
+
+		res, err := executor.waitSnapshot(ctx, cached)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).ToNot(BeNil())
+	})
+
+	It("backs off the live reads of the slow snapshots", func() {
+		created := time.Now()
+		snapshot := &storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)},
+		}
+		isSuspectAfter := func(age time.Duration) bool {
+			return isSnapshotCacheSuspect(snapshot, created.Add(age), defaultRequeueInterval)
+		}
+
+		Expect(isSuspectAfter(10 * time.Second)).To(BeFalse())
+		Expect(isSuspectAfter(30 * time.Second)).To(BeTrue())
+		Expect(isSuspectAfter(45 * time.Second)).To(BeFalse())
+		Expect(isSuspectAfter(65 * time.Second)).To(BeTrue())
+		Expect(isSuspectAfter(100 * time.Second)).To(BeFalse())
+		Expect(isSuspectAfter(125 * time.Second)).To(BeTrue())
+		Expect(isSuspectAfter(200 * time.Second)).To(BeFalse())
+		Expect(isSuspectAfter(245 * time.Second)).To(BeTrue())
+		Expect(isSuspectAfter(400 * time.Second)).To(BeFalse())
+		Expect(isSuspectAfter(545 * time.Second)).To(BeTrue())
+		Expect(isSuspectAfter(700 * time.Second)).To(BeFalse())
+		Expect(isSuspectAfter(845 * time.Second)).To(BeTrue())
+	})
+
+	It("fails when the snapshot doesn't exist anymore", func(ctx context.Context) {
+		cached := newSnapshot(time.Minute, false)
+		executor := NewExecutorBuilder(newClient(cached), record.NewFakeRecorder(10)).
+			LiveReader(fake.NewClientBuilder().WithScheme(schemeBuilder.BuildWithAllKnownScheme()).Build()).
+			Build()
+
+		_, err := executor.waitSnapshot(ctx, cached)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("fails when a snapshot is not ready within the ready timeout", func(ctx context.Context) {
+		cached := newSnapshot(10*time.Minute, false)
+		recorder := record.NewFakeRecorder(10)
+		executor := NewExecutorBuilder(newClient(cached), recorder).
+			LiveReader(newClient(newSnapshot(10*time.Minute, false))).
+			ReadyTimeout(5 * time.Minute).
+			Build()
+
+		_, err := executor.waitSnapshotToBeReadyStep(ctx, &apiv1.Backup{},
+			[]storagesnapshotv1.VolumeSnapshot{*cached})
+		Expect(err).To(MatchError(ContainSubstring("not ready within the timeout")))
+		Expect(recorder.Events).To(Receive(ContainSubstring("SnapshotReadyTimeout")))
+	})
+
+	It("keeps waiting for the snapshots without a ready timeout", func(ctx context.Context) {
+		cached := newSnapshot(10*time.Minute, false)
+		executor := NewExecutorBuilder(newClient(cached), record.NewFakeRecorder(10)).
+			LiveReader(newClient(newSnapshot(10*time.Minute, false))).
+			Build()
+
+		res, err := executor.waitSnapshotToBeReadyStep(ctx, &apiv1.Backup{},
+			[]storagesnapshotv1.VolumeSnapshot{*cached})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).ToNot(BeNil())
+	})
+
+	It("measures the ready timeout from the creation of the snapshot", func() {
+		now := time.Now()
+		snapshot := newSnapshot(0, false)
+		snapshot.CreationTimestamp = metav1.NewTime(now.Add(-time.Minute))
+
+		Expect(isSnapshotReadyTimeoutExceeded(snapshot, 0, now)).To(BeFalse())
+		Expect(isSnapshotReadyTimeoutExceeded(snapshot, 2*time.Minute, now)).To(BeFalse())
+		Expect(isSnapshotReadyTimeoutExceeded(snapshot, 30*time.Second, now)).To(BeTrue())
+	})
+
+	It("tolerates snapshots already created but missing from the cache", func(ctx context.Context) {
+		cluster := &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{ClassName: "csi-snapclass"},
+				},
+			},
+		}
+		backup := &apiv1.Backup{ObjectMeta: metav1.ObjectMeta{Name: "backup-example", Namespace: "default"}}
+		targetPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-1", Namespace: "default"}}
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example-1",
+				Namespace: "default",
+				Labels:    map[string]string{utils.PvcRoleLabelName: string(utils.PVCRolePgData)},
+			},
+		}
+		cli := fake.NewClientBuilder().WithScheme(schemeBuilder.BuildWithAllKnownScheme()).Build()
+		executor := NewExecutorBuilder(cli, record.NewFakeRecorder(10)).Build()
+
+		Expect(executor.createSnapshot(ctx, cluster, backup, targetPod, pvc, nil, "suffix")).To(Succeed())
+		Expect(executor.createSnapshot(ctx, cluster, backup, targetPod, pvc, nil, "suffix")).To(Succeed())
+	})
+})
+
+// failingCreateClient fails the creation of the snapshot of a PVC
+type failingCreateClient struct {
+	client.Client
+	failingPVC string
+}
+
+func (f failingCreateClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if snapshot, ok := obj.(*storagesnapshotv1.VolumeSnapshot); ok &&
+		*snapshot.Spec.Source.PersistentVolumeClaimName == f.failingPVC {
+		return errors.New("snapshot quota exceeded")
+	}
+	return f.Client.Create(ctx, obj, opts...)
+}
+
+var _ = Describe("concurrent snapshot creation", func() {
+	var (
+		cluster   *apiv1.Cluster
+		backup    *apiv1.Backup
+		targetPod *corev1.Pod
+		pvcs      []corev1.PersistentVolumeClaim
+		cli       client.Client
+		recorder  *record.FakeRecorder
+	)
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{ClassName: "csi-snapclass"},
+				},
+			},
+		}
+		backup = &apiv1.Backup{ObjectMeta: metav1.ObjectMeta{Name: "backup-example", Namespace: "default"}}
+		targetPod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-1", Namespace: "default"}}
+		pvcs = nil
+		for _, name := range []string{"cluster-example-1", "cluster-example-1-wal", "cluster-example-1-tbs-idx"} {
+			pvcs = append(pvcs, corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+					Labels:    map[string]string{utils.PvcRoleLabelName: string(utils.PVCRolePgData)},
+				},
+			})
+		}
+		cli = fake.NewClientBuilder().WithScheme(schemeBuilder.BuildWithAllKnownScheme()).Build()
+		recorder = record.NewFakeRecorder(10)
+	})
+
+	countCreateSnapshotEvents := func() int {
+		count := 0
+		for len(recorder.Events) > 0 {
+			if strings.Contains(<-recorder.Events, "CreateSnapshot") {
+				count++
+			}
+		}
+		return count
+	}
+
+	It("creates the snapshots of every PVC", func(ctx context.Context) {
+		executor := NewExecutorBuilder(cli, recorder).Build()
+
+		Expect(executor.createSnapshotPVCGroupStep(ctx, cluster, pvcs, backup, targetPod)).To(Succeed())
+
+		snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(HaveLen(3))
+		Expect(countCreateSnapshotEvents()).To(Equal(3))
+	})
+
+	It("surfaces the failure of a PVC as a single error", func(ctx context.Context) {
+		executor := NewExecutorBuilder(
+			failingCreateClient{Client: cli, failingPVC: "cluster-example-1-wal"},
+			recorder,
+		).Build()
+
+		err := executor.createSnapshotPVCGroupStep(ctx, cluster, pvcs, backup, targetPod)
+		Expect(err).To(MatchError(ContainSubstring("snapshot quota exceeded")))
+		Expect(countCreateSnapshotEvents()).To(Equal(3))
+	})
+
+	It("aggregates the errors of the PVCs, leaving out the aborted ones", func() {
+		first := errors.New("first failure")
+		second := errors.New("second failure")
+		aborted := fmt.Errorf("while creating VolumeSnapshot: %w", context.Canceled)
+
+		Expect(aggregateSnapshotErrors([]error{nil, nil})).To(Succeed())
+		Expect(aggregateSnapshotErrors([]error{nil, first, aborted})).To(Equal(first))
+		Expect(aggregateSnapshotErrors([]error{aborted, nil})).To(Equal(aborted))
+
+		err := aggregateSnapshotErrors([]error{first, aborted, second})
+		Expect(err).To(MatchError(ContainSubstring("first failure")))
+		Expect(err).To(MatchError(ContainSubstring("second failure")))
+		Expect(err.Error()).ToNot(ContainSubstring("canceled"))
+	})
+})
+
+var _ = Describe("maximum fence duration", func() {
+	var (
+		cluster   *apiv1.Cluster
+		backup    *apiv1.Backup
+		targetPod *corev1.Pod
+		pvcs      []corev1.PersistentVolumeClaim
+		cli       client.Client
+		executor  *Reconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						ClassName:        "csi-snapclass",
+						MaxFenceDuration: 60,
+					},
+				},
+			},
+		}
+		backup = &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup-example", Namespace: "default"},
+			Status:     apiv1.BackupStatus{StartedAt: ptr.To(metav1.Now())},
+		}
+		targetPod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-2", Namespace: "default"}}
+		pvcs = []corev1.PersistentVolumeClaim{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-example-2",
+					Namespace: "default",
+					Labels:    map[string]string{utils.PvcRoleLabelName: string(utils.PVCRolePgData)},
+				},
+			},
+		}
+		cli = fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, backup, targetPod).
+			WithStatusSubresource(backup).
+			Build()
+		executor = NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+			FenceInstance(true).
+			Build()
+	})
+
+	getFencedInstances := func(ctx context.Context) []string {
+		var current apiv1.Cluster
+		Expect(cli.Get(ctx, client.ObjectKeyFromObject(cluster), &current)).To(Succeed())
+		fencedInstances, err := utils.GetFencedInstances(current.Annotations)
+		Expect(err).ToNot(HaveOccurred())
+		return fencedInstances.ToList()
+	}
+
+	getBackupSnapshotStatus := func(ctx context.Context) apiv1.BackupSnapshotStatus {
+		var current apiv1.Backup
+		Expect(cli.Get(ctx, client.ObjectKeyFromObject(backup), &current)).To(Succeed())
+		return current.Status.BackupSnapshotStatus
+	}
+
+	It("is not enforced when not configured", func() {
+		cluster.Spec.Backup.VolumeSnapshot.MaxFenceDuration = 0
+		backup.Status.BackupSnapshotStatus.FencedAt = ptr.To(metav1.NewTime(time.Now().Add(-time.Hour)))
+		Expect(isFenceDurationExceeded(cluster, backup, time.Now())).To(BeFalse())
+	})
+
+	It("is not enforced before the instance is fenced", func() {
+		Expect(isFenceDurationExceeded(cluster, backup, time.Now().Add(time.Hour))).To(BeFalse())
+	})
+
+	It("records the fence duration when the backup completes", func(ctx context.Context) {
+		By("fencing the instance and taking the snapshot", func() {
+			res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).ToNot(BeNil())
+			Expect(getBackupSnapshotStatus(ctx).FencedAt).ToNot(BeNil())
+		})
+
+		By("completing the backup once the snapshot is ready", func() {
+			snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(HaveLen(1))
+			snapshots[0].Status = &storagesnapshotv1.VolumeSnapshotStatus{ReadyToUse: ptr.To(true)}
+			Expect(cli.Update(ctx, &snapshots[0])).To(Succeed())
+
+			res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(BeNil())
+			Expect(backup.Status.BackupSnapshotStatus.FenceDuration).ToNot(BeEmpty())
+			Expect(backup.Status.BackupSnapshotStatus.FenceDurationExceeded).To(BeFalse())
+			Expect(backup.Status.TotalSize).ToNot(BeNil())
+			Expect(backup.Status.TotalSize.SnapshotsWithoutSize).To(ConsistOf(snapshots[0].Name))
+		})
+	})
+
+	It("records the end of the WAL archive of a fenced primary once unfenced", func(ctx context.Context) {
+		cluster.Status.CurrentPrimary = targetPod.Name
+		cluster.Spec.Backup.BarmanObjectStore = &apiv1.BarmanObjectStoreConfiguration{
+			BarmanCredentials: apiv1.BarmanCredentials{AWS: &apiv1.S3Credentials{}},
+		}
+
+		// PostgreSQL is not running while the instance is fenced, and it
+		// takes a while to start once the instance has been unfenced
+		origBackoff := lastArchivedWALBackoff
+		lastArchivedWALBackoff = wait.Backoff{Steps: 3}
+		DeferCleanup(func() { lastArchivedWALBackoff = origBackoff })
+		attemptsAfterUnfencing := 0
+		executor.instanceStatusGetter = func(_ context.Context, pods corev1.PodList) postgres.PostgresqlStatusList {
+			status := postgres.PostgresqlStatus{Pod: &pods.Items[0], LastArchivedWAL: "000000010000000200000003"}
+			if len(getFencedInstances(ctx)) > 0 {
+				status.Error = errors.New("instance is fenced")
+			} else if attemptsAfterUnfencing++; attemptsAfterUnfencing == 1 {
+				status.Error = errors.New("instance is starting up")
+			}
+			return postgres.PostgresqlStatusList{Items: []postgres.PostgresqlStatus{status}}
+		}
+
+		By("fencing the instance and taking the snapshot", func() {
+			_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getFencedInstances(ctx)).To(ConsistOf(targetPod.Name))
+		})
+
+		By("completing the backup once the snapshot is ready", func() {
+			snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(HaveLen(1))
+			snapshots[0].Status = &storagesnapshotv1.VolumeSnapshotStatus{ReadyToUse: ptr.To(true)}
+			Expect(cli.Update(ctx, &snapshots[0])).To(Succeed())
+
+			res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(BeNil())
+			Expect(getFencedInstances(ctx)).To(BeEmpty())
+			Expect(attemptsAfterUnfencing).To(Equal(2))
+			Expect(backup.Status.BackupSnapshotStatus.LastArchivedLSN).To(Equal("2/4000000"))
+		})
+	})
+
+	It("fails the backup when the limit is hit", func(ctx context.Context) {
+		By("fencing the instance and taking the snapshot", func() {
+			res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).ToNot(BeNil())
+		})
+
+		By("giving up once the instance has been fenced for too long", func() {
+			backup.Status.BackupSnapshotStatus.FencedAt = ptr.To(metav1.NewTime(time.Now().Add(-2 * time.Minute)))
+			_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("maximum fence duration"))
+
+			status := getBackupSnapshotStatus(ctx)
+			Expect(status.FenceDurationExceeded).To(BeTrue())
+			Expect(status.FenceDuration).To(Equal("2m0s"))
+
+			snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(BeEmpty())
+			Expect(getFencedInstances(ctx)).To(BeEmpty())
+		})
+	})
+
+	It("unfences the instance when the snapshot fails after fencing", func(ctx context.Context) {
+		By("fencing the instance and taking the snapshot", func() {
+			_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getFencedInstances(ctx)).To(ConsistOf(targetPod.Name))
+		})
+
+		By("failing the backup when the snapshot fails", func() {
+			snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshots).To(HaveLen(1))
+			snapshots[0].Status = &storagesnapshotv1.VolumeSnapshotStatus{
+				Error: &storagesnapshotv1.VolumeSnapshotError{Message: ptr.To("driver failure")},
+			}
+			Expect(cli.Update(ctx, &snapshots[0])).To(Succeed())
+
+			_, err = executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).To(HaveOccurred())
+			Expect(getFencedInstances(ctx)).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,58 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import "time"
+
+// defaultRequeueInterval is the default interval between the reconciliation
+// loops waiting for the target instance to be fenced or for the snapshots
+// to be ready to use
+const defaultRequeueInterval = 10 * time.Second
+
+// defaultMaxRequeueInterval is the default longest interval between the
+// reconciliation loops waiting for a slow operation
+const defaultMaxRequeueInterval = time.Minute
+
+// requeueBackoffDivisor makes the interval between the reconciliation loops
+// grow to this fraction of the time spent waiting so far
+const requeueBackoffDivisor = 4
+
+// getRequeueInterval gets the interval before checking again an operation
+// the backup has been waiting for since the passed time. The interval starts
+// from the configured one and grows with the waiting time, up to the
+// configured maximum, so that the slow operations, like the snapshots of
+// large volumes, cost fewer reconciliation loops
+func (se *Reconciler) getRequeueInterval(startedAt time.Time, now time.Time) time.Duration {
+	maxInterval := se.maxRequeueInterval
+	if maxInterval < se.requeueInterval {
+		maxInterval = se.requeueInterval
+	}
+
+	if startedAt.IsZero() {
+		return se.requeueInterval
+	}
+
+	interval := now.Sub(startedAt) / requeueBackoffDivisor
+	switch {
+	case interval < se.requeueInterval:
+		return se.requeueInterval
+	case interval > maxInterval:
+		return maxInterval
+	default:
+		return interval
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"time"
+
+	"k8s.io/client-go/tools/record"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("requeue backoff", func() {
+	startedAt := time.Now()
+	intervalAfter := func(reconciler *Reconciler, elapsed time.Duration) time.Duration {
+		return reconciler.getRequeueInterval(startedAt, startedAt.Add(elapsed))
+	}
+
+	It("keeps the requeues short at the beginning, then widens them", func() {
+		reconciler := NewExecutorBuilder(nil, record.NewFakeRecorder(10)).Build()
+
+		Expect(intervalAfter(reconciler, 0)).To(Equal(10 * time.Second))
+		Expect(intervalAfter(reconciler, 30*time.Second)).To(Equal(10 * time.Second))
+		Expect(intervalAfter(reconciler, 2*time.Minute)).To(Equal(30 * time.Second))
+		Expect(intervalAfter(reconciler, 30*time.Minute)).To(Equal(time.Minute))
+	})
+
+	It("uses the initial interval when the start of the wait is unknown", func() {
+		reconciler := NewExecutorBuilder(nil, record.NewFakeRecorder(10)).Build()
+		Expect(reconciler.getRequeueInterval(time.Time{}, time.Now())).To(Equal(10 * time.Second))
+	})
+
+	It("can be configured", func() {
+		reconciler := NewExecutorBuilder(nil, record.NewFakeRecorder(10)).
+			RequeueBackoff(5*time.Second, 5*time.Minute).
+			Build()
+		Expect(intervalAfter(reconciler, 0)).To(Equal(5 * time.Second))
+		Expect(intervalAfter(reconciler, time.Hour)).To(Equal(5 * time.Minute))
+	})
+
+	It("can be made fixed", func() {
+		reconciler := NewExecutorBuilder(nil, record.NewFakeRecorder(10)).
+			RequeueBackoff(20*time.Second, 0).
+			Build()
+		Expect(intervalAfter(reconciler, 0)).To(Equal(20 * time.Second))
+		Expect(intervalAfter(reconciler, time.Hour)).To(Equal(20 * time.Second))
+	})
+})
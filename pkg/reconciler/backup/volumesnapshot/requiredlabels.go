@@ -0,0 +1,110 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"fmt"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// ReconcileRequiredLabels ensures that the volume snapshots taken by the
+// backups of the cluster carry the required labels, restoring the ones
+// which are missing or have been changed
+func ReconcileRequiredLabels(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+) error {
+	if cluster.Spec.Backup == nil || cluster.Spec.Backup.VolumeSnapshot == nil {
+		return nil
+	}
+
+	requiredLabels := getRequiredLabels(cluster.Spec.Backup.VolumeSnapshot)
+	if len(requiredLabels) == 0 {
+		return nil
+	}
+
+	var list storagesnapshotv1.VolumeSnapshotList
+	if err := cli.List(
+		ctx,
+		&list,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{utils.ClusterLabelName: cluster.Name},
+		client.HasLabels{utils.BackupNameLabelName},
+	); err != nil {
+		return err
+	}
+
+	contextLogger := log.FromContext(ctx)
+	for i := range list.Items {
+		snapshot := &list.Items[i]
+		missingLabels := getMissingRequiredLabels(snapshot.Labels, requiredLabels)
+		if len(missingLabels) == 0 {
+			continue
+		}
+
+		contextLogger.Info("Adding the required labels to VolumeSnapshot",
+			"volumeSnapshot", snapshot.Name,
+			"labels", missingLabels)
+		origSnapshot := snapshot.DeepCopy()
+		if snapshot.Labels == nil {
+			snapshot.Labels = map[string]string{}
+		}
+		utils.MergeMap(snapshot.Labels, missingLabels)
+		if err := cli.Patch(ctx, snapshot, client.MergeFrom(origSnapshot)); err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("while adding the required labels to VolumeSnapshot %s: %w",
+				snapshot.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// getRequiredLabels returns the labels required on the volume snapshots,
+// skipping the ones managed by Kubernetes or by the operator
+func getRequiredLabels(config *apiv1.VolumeSnapshotConfiguration) map[string]string {
+	result := make(map[string]string, len(config.RequiredLabels))
+	for key, value := range config.RequiredLabels {
+		if hasAnyPrefix(key, systemMetadataPrefixes) {
+			continue
+		}
+		result[key] = value
+	}
+
+	return result
+}
+
+// getMissingRequiredLabels returns the required labels which are
+// missing from the passed ones, or have a different value
+func getMissingRequiredLabels(labels, requiredLabels map[string]string) map[string]string {
+	result := make(map[string]string)
+	for key, value := range requiredLabels {
+		if currentValue, ok := labels[key]; !ok || currentValue != value {
+			result[key] = value
+		}
+	}
+
+	return result
+}
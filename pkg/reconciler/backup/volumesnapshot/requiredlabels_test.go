@@ -0,0 +1,155 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("labels required on volume snapshots", func() {
+	newSnapshot := func(name string, labels map[string]string) *storagesnapshotv1.VolumeSnapshot {
+		snapshot := &storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels: map[string]string{
+					utils.ClusterLabelName:    "cluster-example",
+					utils.BackupNameLabelName: "backup-example",
+				},
+			},
+		}
+		utils.MergeMap(snapshot.Labels, labels)
+		return snapshot
+	}
+
+	newCluster := func(requiredLabels map[string]string) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						RequiredLabels: requiredLabels,
+					},
+				},
+			},
+		}
+	}
+
+	getLabels := func(ctx context.Context, cli client.Client, name string) map[string]string {
+		var snapshot storagesnapshotv1.VolumeSnapshot
+		Expect(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, &snapshot)).To(Succeed())
+		return snapshot.Labels
+	}
+
+	It("never requires the labels managed by the operator", func() {
+		requiredLabels := getRequiredLabels(&apiv1.VolumeSnapshotConfiguration{
+			RequiredLabels: map[string]string{
+				"scanner.example.com/include": "true",
+				utils.BackupNameLabelName:     "other-backup",
+			},
+		})
+		Expect(requiredLabels).To(Equal(map[string]string{"scanner.example.com/include": "true"}))
+	})
+
+	It("detects the missing and changed labels", func() {
+		requiredLabels := map[string]string{
+			"scanner.example.com/include": "true",
+			"scanner.example.com/team":    "dba",
+		}
+		Expect(getMissingRequiredLabels(map[string]string{
+			"scanner.example.com/include": "true",
+			"scanner.example.com/team":    "ops",
+		}, requiredLabels)).To(Equal(map[string]string{"scanner.example.com/team": "dba"}))
+		Expect(getMissingRequiredLabels(requiredLabels, requiredLabels)).To(BeEmpty())
+	})
+
+	It("adds the required labels to the existing snapshots", func(ctx context.Context) {
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(
+				newSnapshot("compliant", map[string]string{"scanner.example.com/include": "true"}),
+				newSnapshot("missing", nil),
+				newSnapshot("changed", map[string]string{"scanner.example.com/include": "false"}),
+			).
+			Build()
+
+		cluster := newCluster(map[string]string{
+			"scanner.example.com/include": "true",
+			utils.BackupNameLabelName:     "other-backup",
+		})
+		Expect(ReconcileRequiredLabels(ctx, cli, cluster)).To(Succeed())
+
+		for _, name := range []string{"compliant", "missing", "changed"} {
+			labels := getLabels(ctx, cli, name)
+			Expect(labels).To(HaveKeyWithValue("scanner.example.com/include", "true"))
+			Expect(labels).To(HaveKeyWithValue(utils.BackupNameLabelName, "backup-example"))
+		}
+	})
+
+	It("only patches the snapshots taken by backups and lacking the required labels", func(ctx context.Context) {
+		notFromBackup := newSnapshot("not-from-backup", nil)
+		delete(notFromBackup.Labels, utils.BackupNameLabelName)
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(
+				newSnapshot("compliant", map[string]string{"scanner.example.com/include": "true"}),
+				newSnapshot("missing", nil),
+				notFromBackup,
+			).
+			Build()
+
+		getResourceVersion := func(name string) string {
+			var snapshot storagesnapshotv1.VolumeSnapshot
+			Expect(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, &snapshot)).To(Succeed())
+			return snapshot.ResourceVersion
+		}
+		resourceVersions := make(map[string]string)
+		for _, name := range []string{"compliant", "missing", "not-from-backup"} {
+			resourceVersions[name] = getResourceVersion(name)
+		}
+
+		cluster := newCluster(map[string]string{"scanner.example.com/include": "true"})
+		Expect(ReconcileRequiredLabels(ctx, cli, cluster)).To(Succeed())
+
+		Expect(getResourceVersion("compliant")).To(Equal(resourceVersions["compliant"]))
+		Expect(getResourceVersion("missing")).ToNot(Equal(resourceVersions["missing"]))
+		Expect(getResourceVersion("not-from-backup")).To(Equal(resourceVersions["not-from-backup"]))
+		Expect(getLabels(ctx, cli, "not-from-backup")).ToNot(HaveKey("scanner.example.com/include"))
+	})
+
+	It("does nothing when no label is required", func(ctx context.Context) {
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(newSnapshot("missing", nil)).
+			Build()
+
+		Expect(ReconcileRequiredLabels(ctx, cli, newCluster(nil))).To(Succeed())
+		Expect(getLabels(ctx, cli, "missing")).ToNot(HaveKey("scanner.example.com/include"))
+	})
+})
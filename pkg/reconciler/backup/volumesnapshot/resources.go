@@ -3,10 +3,12 @@ package volumesnapshot
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
 )
 
@@ -102,3 +104,26 @@ func parseVolumeSnapshotInfo(snapshot *storagesnapshotv1.VolumeSnapshot) volumeS
 		Running: false,
 	}
 }
+
+// GetReusedVolumeSnapshots gets the volume snapshots taken by previous
+// backups and reused by the passed one, from the given namespace
+func GetReusedVolumeSnapshots(
+	ctx context.Context,
+	cli client.Client,
+	namespace string,
+	backup *apiv1.Backup,
+) ([]storagesnapshotv1.VolumeSnapshot, error) {
+	reusedSnapshots := backup.Status.BackupSnapshotStatus.ReusedSnapshots
+	result := make([]storagesnapshotv1.VolumeSnapshot, len(reusedSnapshots))
+	for i, name := range reusedSnapshots {
+		if err := cli.Get(
+			ctx,
+			client.ObjectKey{Namespace: namespace, Name: name},
+			&result[i],
+		); err != nil {
+			return nil, fmt.Errorf("while getting the reused volume snapshot %s: %w", name, err)
+		}
+	}
+
+	return result, nil
+}
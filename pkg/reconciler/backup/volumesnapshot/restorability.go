@@ -0,0 +1,139 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+)
+
+// SetRestorableCondition updates the condition reporting whether a completed
+// volume snapshot backup can still be used to recover the cluster, given the
+// configured maximum restorable age and the backups available in the WAL
+// archive. The condition is removed when no maximum restorable age is set.
+// Returns true when the condition has been changed
+func SetRestorableCondition(
+	backup *apiv1.Backup,
+	cluster *apiv1.Cluster,
+	backups []apiv1.Backup,
+	now time.Time,
+) (bool, error) {
+	var configuration *apiv1.VolumeSnapshotConfiguration
+	if cluster.Spec.Backup != nil {
+		configuration = cluster.Spec.Backup.VolumeSnapshot
+	}
+
+	maxAge, err := configuration.GetMaxRestorableAge()
+	if err != nil {
+		return false, err
+	}
+
+	if maxAge == 0 {
+		if meta.FindStatusCondition(backup.Status.Conditions, string(apiv1.ConditionBackupRestorable)) == nil {
+			return false, nil
+		}
+		meta.RemoveStatusCondition(&backup.Status.Conditions, string(apiv1.ConditionBackupRestorable))
+		return true, nil
+	}
+
+	condition := getRestorableCondition(backup, maxAge, getFirstAvailableLSN(cluster, backups), now)
+	existing := meta.FindStatusCondition(backup.Status.Conditions, condition.Type)
+	if existing != nil &&
+		existing.Status == condition.Status &&
+		existing.Reason == condition.Reason &&
+		existing.Message == condition.Message {
+		return false, nil
+	}
+
+	meta.SetStatusCondition(&backup.Status.Conditions, condition)
+	return true, nil
+}
+
+// getRestorableCondition computes the restorable condition of a volume
+// snapshot backup at the passed time. An empty firstAvailableLSN means that
+// the beginning of the WAL archive is unknown and is not checked
+func getRestorableCondition(
+	backup *apiv1.Backup,
+	maxAge time.Duration,
+	firstAvailableLSN postgres.LSN,
+	now time.Time,
+) metav1.Condition {
+	condition := metav1.Condition{
+		Type:    string(apiv1.ConditionBackupRestorable),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(apiv1.ConditionReasonSnapshotRestorable),
+		Message: "The volume snapshot backup can be used to recover the cluster",
+	}
+
+	if backup.Status.StoppedAt != nil && now.Sub(backup.Status.StoppedAt.Time) > maxAge {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = string(apiv1.ConditionReasonSnapshotTooOld)
+		condition.Message = fmt.Sprintf("The volume snapshot backup is older than %s", maxAge)
+		return condition
+	}
+
+	beginLSN := postgres.LSN(backup.Status.BeginLSN)
+	if beginLSN != "" && firstAvailableLSN != "" && beginLSN.Less(firstAvailableLSN) {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = string(apiv1.ConditionReasonSnapshotWALsUnavailable)
+		condition.Message = fmt.Sprintf(
+			"The WAL archive starts at %s, after the beginning of the volume snapshot backup (%s)",
+			firstAvailableLSN, beginLSN)
+	}
+
+	return condition
+}
+
+// getFirstAvailableLSN gets the LSN from which the WAL archive of the cluster
+// is still available, that is the beginning of the oldest object store backup
+// not older than the first recoverability point. Returns an empty LSN when
+// this cannot be determined
+func getFirstAvailableLSN(cluster *apiv1.Cluster, backups []apiv1.Backup) postgres.LSN {
+	if cluster.Status.FirstRecoverabilityPoint == "" {
+		return ""
+	}
+
+	firstRecoverabilityPoint, err := time.Parse(time.RFC3339, cluster.Status.FirstRecoverabilityPoint)
+	if err != nil {
+		return ""
+	}
+
+	var result postgres.LSN
+	for i := range backups {
+		status := &backups[i].Status
+		if status.Method != apiv1.BackupMethodBarmanObjectStore ||
+			status.Phase != apiv1.BackupPhaseCompleted ||
+			status.StoppedAt == nil ||
+			status.StoppedAt.Time.Before(firstRecoverabilityPoint) ||
+			status.BeginLSN == "" {
+			continue
+		}
+
+		lsn := postgres.LSN(status.BeginLSN)
+		if result == "" || lsn.Less(result) {
+			result = lsn
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,120 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Volume snapshot restorability", func() {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	newBackup := func(method apiv1.BackupMethod, beginLSN string, stoppedAt time.Time) apiv1.Backup {
+		return apiv1.Backup{
+			Status: apiv1.BackupStatus{
+				Method:    method,
+				Phase:     apiv1.BackupPhaseCompleted,
+				BeginLSN:  beginLSN,
+				StoppedAt: &metav1.Time{Time: stoppedAt},
+			},
+		}
+	}
+
+	var cluster *apiv1.Cluster
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						MaxRestorableAge: "7d",
+					},
+				},
+			},
+			Status: apiv1.ClusterStatus{
+				FirstRecoverabilityPoint: now.Add(-72 * time.Hour).Format(time.RFC3339),
+			},
+		}
+	})
+
+	It("finds the first LSN available in the WAL archive", func() {
+		backups := []apiv1.Backup{
+			newBackup(apiv1.BackupMethodBarmanObjectStore, "0/5000000", now.Add(-24*time.Hour)),
+			newBackup(apiv1.BackupMethodBarmanObjectStore, "0/3000000", now.Add(-48*time.Hour)),
+			newBackup(apiv1.BackupMethodBarmanObjectStore, "0/1000000", now.Add(-96*time.Hour)),
+			newBackup(apiv1.BackupMethodVolumeSnapshot, "0/2000000", now.Add(-60*time.Hour)),
+		}
+		Expect(getFirstAvailableLSN(cluster, backups)).To(BeEquivalentTo("0/3000000"))
+
+		cluster.Status.FirstRecoverabilityPoint = ""
+		Expect(getFirstAvailableLSN(cluster, backups)).To(BeEmpty())
+	})
+
+	It("flags the snapshots older than the maximum restorable age", func() {
+		backup := newBackup(apiv1.BackupMethodVolumeSnapshot, "0/3000000", now.Add(-8*24*time.Hour))
+		condition := getRestorableCondition(&backup, 7*24*time.Hour, "", now)
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(string(apiv1.ConditionReasonSnapshotTooOld)))
+	})
+
+	It("flags the snapshots whose WALs are not in the archive anymore", func() {
+		backup := newBackup(apiv1.BackupMethodVolumeSnapshot, "0/3000000", now.Add(-24*time.Hour))
+		condition := getRestorableCondition(&backup, 7*24*time.Hour, "0/4000000", now)
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(string(apiv1.ConditionReasonSnapshotWALsUnavailable)))
+	})
+
+	It("considers restorable the recent snapshots covered by the WAL archive", func() {
+		backup := newBackup(apiv1.BackupMethodVolumeSnapshot, "0/5000000", now.Add(-24*time.Hour))
+		condition := getRestorableCondition(&backup, 7*24*time.Hour, "0/4000000", now)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(string(apiv1.ConditionReasonSnapshotRestorable)))
+	})
+
+	It("sets the condition only when it changes", func() {
+		backup := newBackup(apiv1.BackupMethodVolumeSnapshot, "0/5000000", now.Add(-24*time.Hour))
+
+		changed, err := SetRestorableCondition(&backup, cluster, nil, now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(backup.Status.Conditions, string(apiv1.ConditionBackupRestorable))).To(BeTrue())
+
+		changed, err = SetRestorableCondition(&backup, cluster, nil, now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeFalse())
+	})
+
+	It("removes the condition when no maximum restorable age is configured", func() {
+		backup := newBackup(apiv1.BackupMethodVolumeSnapshot, "0/5000000", now.Add(-24*time.Hour))
+		_, err := SetRestorableCondition(&backup, cluster, nil, now)
+		Expect(err).ToNot(HaveOccurred())
+
+		cluster.Spec.Backup.VolumeSnapshot.MaxRestorableAge = ""
+		changed, err := SetRestorableCondition(&backup, cluster, nil, now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(changed).To(BeTrue())
+		Expect(backup.Status.Conditions).To(BeEmpty())
+	})
+})
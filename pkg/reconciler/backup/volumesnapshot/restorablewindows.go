@@ -0,0 +1,242 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// pgControldataTimelineKey is the key of the pg_controldata output
+// containing the timeline of the latest checkpoint
+const pgControldataTimelineKey = "Latest checkpoint's TimeLineID"
+
+// RestorablePoint is a point to which a cluster can be recovered
+type RestorablePoint struct {
+	// LSN is the WAL location of the point
+	LSN postgres.LSN
+
+	// Time is the time of the point, zero when unknown
+	Time time.Time
+
+	// Timeline is the timeline of the point, zero when unknown
+	Timeline int
+}
+
+// RestorableWindow is a continuous range of points to which a cluster
+// can be recovered using its volume snapshot backups and the WAL archive
+type RestorableWindow struct {
+	// Start is the earliest point of the window
+	Start RestorablePoint
+
+	// End is the latest point of the window
+	End RestorablePoint
+
+	// Backups are the names of the backups the window can be recovered from
+	Backups []string
+}
+
+// WALArchiveEnd is the end of the WAL archive of a cluster, as reported
+// by the instance archiving the WAL files
+type WALArchiveEnd struct {
+	// LastArchivedWAL is the name of the last archived WAL file
+	LastArchivedWAL string
+
+	// LastArchivedWALTime is the time the last WAL file was archived
+	LastArchivedWALTime time.Time
+}
+
+// GetRestorableWindows computes the windows to which the cluster can be
+// recovered from the passed volume snapshot backups, using the pg_controldata
+// output recorded in their PG_DATA snapshots. Each completed backup can be
+// recovered to its consistency point, and to any later point reached by the
+// WAL archive when the archive contains the WAL files from the beginning of
+// the backup, on a timeline not older than the one of the backup. The
+// timelines are supposed to be a linear history, as it happens when they
+// are created by the failovers of the cluster. Overlapping windows are
+// merged, and the result is sorted by the starting point
+func GetRestorableWindows(
+	cluster *apiv1.Cluster,
+	backups []apiv1.Backup,
+	snapshots []storagesnapshotv1.VolumeSnapshot,
+	archiveEnd WALArchiveEnd,
+) ([]RestorableWindow, error) {
+	archiveEndPoint, err := getWALArchiveEndPoint(cluster, archiveEnd)
+	if err != nil {
+		return nil, err
+	}
+	firstAvailableLSN := getFirstAvailableLSN(cluster, backups)
+
+	windows := make([]RestorableWindow, 0, len(backups))
+	for i := range backups {
+		backup := &backups[i]
+		if backup.Status.Method != apiv1.BackupMethodVolumeSnapshot ||
+			backup.Status.Phase != apiv1.BackupPhaseCompleted {
+			continue
+		}
+
+		pgDataSnapshot := getPgDataSnapshot(backup.Name, snapshots)
+		if pgDataSnapshot == nil {
+			continue
+		}
+
+		start, beginLSN := getBackupRestorablePoint(backup, pgDataSnapshot)
+		if start.LSN == "" {
+			continue
+		}
+
+		window := RestorableWindow{
+			Start:   start,
+			End:     start,
+			Backups: []string{backup.Name},
+		}
+		if archiveEndPoint != nil &&
+			isWALArchiveAvailable(beginLSN, start, firstAvailableLSN, *archiveEndPoint) {
+			window.End = *archiveEndPoint
+		}
+		windows = append(windows, window)
+	}
+
+	return mergeRestorableWindows(windows), nil
+}
+
+// getWALArchiveEndPoint gets the latest point reached by the WAL archive
+// of the cluster, or nil if the cluster has no WAL archive
+func getWALArchiveEndPoint(cluster *apiv1.Cluster, archiveEnd WALArchiveEnd) (*RestorablePoint, error) {
+	if !cluster.Spec.Backup.IsBarmanBackupConfigured() || archiveEnd.LastArchivedWAL == "" {
+		return nil, nil
+	}
+
+	lsn, err := getLastArchivedLSN(cluster, archiveEnd.LastArchivedWAL)
+	if err != nil {
+		return nil, err
+	}
+
+	// the name has already been validated while computing the LSN
+	segment := postgres.MustSegmentFromName(archiveEnd.LastArchivedWAL)
+
+	return &RestorablePoint{
+		LSN:      postgres.LSN(lsn),
+		Time:     archiveEnd.LastArchivedWALTime,
+		Timeline: int(segment.Tli),
+	}, nil
+}
+
+// getPgDataSnapshot gets the PG_DATA snapshot of the passed backup
+func getPgDataSnapshot(
+	backupName string,
+	snapshots []storagesnapshotv1.VolumeSnapshot,
+) *storagesnapshotv1.VolumeSnapshot {
+	for i := range snapshots {
+		if snapshots[i].Labels[utils.BackupNameLabelName] == backupName &&
+			utils.PVCRole(snapshots[i].Labels[utils.PvcRoleLabelName]) == utils.PVCRolePgData {
+			return &snapshots[i]
+		}
+	}
+
+	return nil
+}
+
+// getBackupRestorablePoint gets the earliest point to which a backup can
+// be recovered, that is where its PG_DATA snapshot is consistent, together
+// with the LSN from which the recovery starts replaying the WAL files
+func getBackupRestorablePoint(
+	backup *apiv1.Backup,
+	pgDataSnapshot *storagesnapshotv1.VolumeSnapshot,
+) (RestorablePoint, postgres.LSN) {
+	pgControldata := pgDataSnapshot.Annotations[utils.PgControldataAnnotationName]
+
+	consistentLSN := pgDataSnapshot.Annotations[utils.ConsistentLSNAnnotationName]
+	if consistentLSN == "" {
+		consistentLSN = getConsistentLSN(pgControldata)
+	}
+
+	beginLSN := backup.Status.BeginLSN
+	if beginLSN == "" {
+		beginLSN = getCheckpointRedoLocation(pgControldata)
+	}
+
+	// the timeline is only used to check the WAL archive,
+	// so an unknown one is not a reason to discard the backup
+	timeline, _ := strconv.Atoi(getPgControldataValue(pgControldata, pgControldataTimelineKey))
+
+	point := RestorablePoint{
+		LSN:      postgres.LSN(consistentLSN),
+		Timeline: timeline,
+	}
+	if backup.Status.StoppedAt != nil {
+		point.Time = backup.Status.StoppedAt.Time
+	}
+
+	return point, postgres.LSN(beginLSN)
+}
+
+// isWALArchiveAvailable checks whether the WAL archive can be replayed
+// on top of a backup, from the LSN where its recovery begins up to the
+// end of the archive
+func isWALArchiveAvailable(
+	beginLSN postgres.LSN,
+	start RestorablePoint,
+	firstAvailableLSN postgres.LSN,
+	archiveEnd RestorablePoint,
+) bool {
+	if beginLSN != "" && firstAvailableLSN != "" && beginLSN.Less(firstAvailableLSN) {
+		return false
+	}
+
+	if start.Timeline != 0 && archiveEnd.Timeline < start.Timeline {
+		return false
+	}
+
+	return !archiveEnd.LSN.Less(start.LSN)
+}
+
+// mergeRestorableWindows sorts the passed windows by their starting
+// point, merging the overlapping ones
+func mergeRestorableWindows(windows []RestorableWindow) []RestorableWindow {
+	sort.SliceStable(windows, func(i, j int) bool {
+		return windows[i].Start.LSN.Less(windows[j].Start.LSN)
+	})
+
+	result := make([]RestorableWindow, 0, len(windows))
+	for _, window := range windows {
+		if len(result) == 0 {
+			result = append(result, window)
+			continue
+		}
+
+		last := &result[len(result)-1]
+		if last.End.LSN.Less(window.Start.LSN) {
+			result = append(result, window)
+			continue
+		}
+
+		last.Backups = append(last.Backups, window.Backups...)
+		if last.End.LSN.Less(window.End.LSN) {
+			last.End = window.End
+		}
+	}
+
+	return result
+}
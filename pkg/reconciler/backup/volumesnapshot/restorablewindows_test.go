@@ -0,0 +1,180 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"fmt"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Restorable windows", func() {
+	var (
+		cluster    *apiv1.Cluster
+		backups    []apiv1.Backup
+		snapshots  []storagesnapshotv1.VolumeSnapshot
+		archiveEnd WALArchiveEnd
+		now        time.Time
+	)
+
+	addBackup := func(name string, timeline int, lsn string, stoppedAt time.Time) {
+		backups = append(backups, apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: apiv1.BackupStatus{
+				Method:    apiv1.BackupMethodVolumeSnapshot,
+				Phase:     apiv1.BackupPhaseCompleted,
+				BeginLSN:  lsn,
+				StoppedAt: &metav1.Time{Time: stoppedAt},
+			},
+		})
+		snapshots = append(snapshots, storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					utils.BackupNameLabelName: name,
+					utils.PvcRoleLabelName:    string(utils.PVCRolePgData),
+				},
+				Annotations: map[string]string{
+					utils.PgControldataAnnotationName: fmt.Sprintf(
+						"Database cluster state:               shut down\n"+
+							"Latest checkpoint location:           %s\n"+
+							"Latest checkpoint's REDO location:    %s\n"+
+							"Latest checkpoint's TimeLineID:       %d\n",
+						lsn, lsn, timeline),
+				},
+			},
+		})
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+		backups = nil
+		snapshots = nil
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{},
+					BarmanObjectStore: &apiv1.BarmanObjectStoreConfiguration{
+						BarmanCredentials: apiv1.BarmanCredentials{
+							AWS: &apiv1.S3Credentials{},
+						},
+					},
+				},
+			},
+		}
+		archiveEnd = WALArchiveEnd{
+			LastArchivedWAL:     "000000020000000000000009",
+			LastArchivedWALTime: now,
+		}
+	})
+
+	It("merges the backups covered by the WAL archive in a single window", func() {
+		addBackup("backup-2", 2, "0/7000028", now.Add(-time.Hour))
+		addBackup("backup-1", 1, "0/3000028", now.Add(-2*time.Hour))
+
+		windows, err := GetRestorableWindows(cluster, backups, snapshots, archiveEnd)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(windows).To(HaveLen(1))
+		Expect(windows[0].Start).To(Equal(RestorablePoint{
+			LSN:      "0/3000028",
+			Time:     now.Add(-2 * time.Hour),
+			Timeline: 1,
+		}))
+		Expect(windows[0].End).To(Equal(RestorablePoint{
+			LSN:      "0/A000000",
+			Time:     now,
+			Timeline: 2,
+		}))
+		Expect(windows[0].Backups).To(Equal([]string{"backup-1", "backup-2"}))
+	})
+
+	It("only allows recovering to the backups without a WAL archive", func() {
+		cluster.Spec.Backup.BarmanObjectStore = nil
+		addBackup("backup-1", 1, "0/3000028", now.Add(-2*time.Hour))
+		addBackup("backup-2", 1, "0/7000028", now.Add(-time.Hour))
+
+		windows, err := GetRestorableWindows(cluster, backups, snapshots, archiveEnd)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(windows).To(HaveLen(2))
+		for _, window := range windows {
+			Expect(window.Start).To(Equal(window.End))
+		}
+		Expect(windows[0].Backups).To(Equal([]string{"backup-1"}))
+		Expect(windows[1].Backups).To(Equal([]string{"backup-2"}))
+	})
+
+	It("doesn't extend the backups on a timeline not reached by the WAL archive", func() {
+		archiveEnd.LastArchivedWAL = "000000010000000000000009"
+		addBackup("backup-1", 2, "0/3000028", now.Add(-time.Hour))
+
+		windows, err := GetRestorableWindows(cluster, backups, snapshots, archiveEnd)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(windows).To(HaveLen(1))
+		Expect(windows[0].End.LSN).To(Equal(postgres.LSN("0/3000028")))
+	})
+
+	It("doesn't extend the backups whose WAL files are not in the archive anymore", func() {
+		cluster.Status.FirstRecoverabilityPoint = now.Add(-90 * time.Minute).Format(time.RFC3339)
+		backups = append(backups, apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "barman-backup"},
+			Status: apiv1.BackupStatus{
+				Method:    apiv1.BackupMethodBarmanObjectStore,
+				Phase:     apiv1.BackupPhaseCompleted,
+				BeginLSN:  "0/5000028",
+				StoppedAt: &metav1.Time{Time: now.Add(-80 * time.Minute)},
+			},
+		})
+		addBackup("backup-1", 1, "0/3000028", now.Add(-2*time.Hour))
+		addBackup("backup-2", 2, "0/7000028", now.Add(-time.Hour))
+
+		windows, err := GetRestorableWindows(cluster, backups, snapshots, archiveEnd)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(windows).To(HaveLen(2))
+		Expect(windows[0].Backups).To(Equal([]string{"backup-1"}))
+		Expect(windows[0].End.LSN).To(Equal(postgres.LSN("0/3000028")))
+		Expect(windows[1].Backups).To(Equal([]string{"backup-2"}))
+		Expect(windows[1].End.LSN).To(Equal(postgres.LSN("0/A000000")))
+	})
+
+	It("skips the backups without the pg_controldata output", func() {
+		addBackup("backup-1", 1, "0/3000028", now.Add(-time.Hour))
+		delete(snapshots[0].Annotations, utils.PgControldataAnnotationName)
+
+		windows, err := GetRestorableWindows(cluster, backups, snapshots, archiveEnd)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(windows).To(BeEmpty())
+	})
+
+	It("fails when the last archived WAL can't be parsed", func() {
+		archiveEnd.LastArchivedWAL = "not-a-wal"
+		_, err := GetRestorableWindows(cluster, backups, snapshots, archiveEnd)
+		Expect(err).To(HaveOccurred())
+	})
+})
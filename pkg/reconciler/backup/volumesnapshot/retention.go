@@ -0,0 +1,519 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/stringset"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// EnforceRetentionPolicy deletes the completed volume snapshot backups of the
+// cluster that are not retained anymore, together with their snapshots. The
+// retention policies are applied independently to the snapshots of the PG_DATA
+// and of the PG_WAL PersistentVolumeClaims, and a backup is deleted once it is
+// retained by none of them. The expired PG_WAL snapshots of the retained
+// backups are deleted on their own, when not needed to restore the PG_DATA
+// snapshots. Backups that are not completed are never deleted, and neither
+// are the snapshots not belonging to a completed backup
+func EnforceRetentionPolicy(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+) error {
+	if cluster.Spec.Backup == nil || cluster.Spec.Backup.VolumeSnapshot == nil {
+		return nil
+	}
+
+	config := cluster.Spec.Backup.VolumeSnapshot
+	if config.Retention == nil && config.ManualRetention == nil && config.WalRetention == nil {
+		return nil
+	}
+
+	var backupList apiv1.BackupList
+	if err := cli.List(ctx, &backupList, client.InNamespace(cluster.Namespace)); err != nil {
+		return err
+	}
+
+	var snapshotList storagesnapshotv1.VolumeSnapshotList
+	if err := cli.List(
+		ctx,
+		&snapshotList,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{utils.ClusterLabelName: cluster.Name},
+		client.HasLabels{utils.BackupNameLabelName},
+	); err != nil {
+		return err
+	}
+
+	backupSnapshots := make(map[string][]storagesnapshotv1.VolumeSnapshot)
+	for i := range snapshotList.Items {
+		backupName := snapshotList.Items[i].Labels[utils.BackupNameLabelName]
+		backupSnapshots[backupName] = append(backupSnapshots[backupName], snapshotList.Items[i])
+	}
+
+	now := time.Now()
+	completedBackups := getCompletedSnapshotBackups(cluster, backupList.Items)
+	expiredBackups, err := getExpiredBackups(completedBackups, backupSnapshots, config, now)
+	if err != nil {
+		return err
+	}
+	expiredWalBackups, err := getBackupsWithPrunableWal(completedBackups, backupSnapshots, expiredBackups, config, now)
+	if err != nil {
+		return err
+	}
+
+	reusedSnapshots := getSnapshotsReusedByRetainedBackups(backupList.Items, expiredBackups)
+
+	contextLogger := log.FromContext(ctx)
+	for i := range expiredBackups {
+		backup := &expiredBackups[i]
+		contextLogger.Info("Deleting backup not retained by the retention policy, together with its snapshots",
+			"backup", backup.Name)
+
+		// The snapshots are deleted first, as they couldn't be found
+		// through the backup anymore
+		snapshots := backupSnapshots[backup.Name]
+		for j := range snapshots {
+			if reusedSnapshots.Has(snapshots[j].Name) {
+				contextLogger.Info("Keeping VolumeSnapshot reused by a retained backup",
+					"backup", backup.Name, "snapshot", snapshots[j].Name)
+				continue
+			}
+			if err := cli.Delete(ctx, &snapshots[j]); err != nil && !apierrs.IsNotFound(err) {
+				return fmt.Errorf("while deleting VolumeSnapshot %s: %w", snapshots[j].Name, err)
+			}
+		}
+
+		if err := cli.Delete(ctx, backup); err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("while deleting Backup %s: %w", backup.Name, err)
+		}
+	}
+
+	for i := range expiredWalBackups {
+		backup := &expiredWalBackups[i]
+		if err := pruneWalSnapshots(ctx, cli, backup, backupSnapshots[backup.Name], reusedSnapshots); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pruneWalSnapshots deletes the PG_WAL snapshots of the passed backup,
+// except the ones reused by the retained backups, and removes them from
+// the snapshot list of the backup
+func pruneWalSnapshots(
+	ctx context.Context,
+	cli client.Client,
+	backup *apiv1.Backup,
+	snapshots []storagesnapshotv1.VolumeSnapshot,
+	reusedSnapshots *stringset.Data,
+) error {
+	contextLogger := log.FromContext(ctx)
+
+	deletedSnapshots := stringset.New()
+	for i := range snapshots {
+		if snapshots[i].Labels[utils.PvcRoleLabelName] != string(utils.PVCRolePgWal) ||
+			reusedSnapshots.Has(snapshots[i].Name) {
+			continue
+		}
+
+		contextLogger.Info("Deleting PG_WAL VolumeSnapshot not retained by the WAL retention policy",
+			"backup", backup.Name, "snapshot", snapshots[i].Name)
+		if err := cli.Delete(ctx, &snapshots[i]); err != nil && !apierrs.IsNotFound(err) {
+			return fmt.Errorf("while deleting VolumeSnapshot %s: %w", snapshots[i].Name, err)
+		}
+		deletedSnapshots.Put(snapshots[i].Name)
+	}
+
+	if deletedSnapshots.Len() == 0 {
+		return nil
+	}
+
+	origBackup := backup.DeepCopy()
+	remainingSnapshots := make([]string, 0, len(backup.Status.BackupSnapshotStatus.Snapshots))
+	for _, name := range backup.Status.BackupSnapshotStatus.Snapshots {
+		if !deletedSnapshots.Has(name) {
+			remainingSnapshots = append(remainingSnapshots, name)
+		}
+	}
+	backup.Status.BackupSnapshotStatus.Snapshots = remainingSnapshots
+	if err := cli.Status().Patch(ctx, backup, client.MergeFrom(origBackup)); err != nil && !apierrs.IsNotFound(err) {
+		return fmt.Errorf("while updating the snapshot list of Backup %s: %w", backup.Name, err)
+	}
+
+	return nil
+}
+
+// getSnapshotsReusedByRetainedBackups gets the names of the snapshots
+// reused by the passed backups which are not expired, including the ones
+// still running. These snapshots are kept even when the backup that took
+// them is deleted
+func getSnapshotsReusedByRetainedBackups(backups []apiv1.Backup, expiredBackups []apiv1.Backup) *stringset.Data {
+	expiredBackupNames := stringset.New()
+	for i := range expiredBackups {
+		expiredBackupNames.Put(expiredBackups[i].Name)
+	}
+
+	result := stringset.New()
+	for i := range backups {
+		if expiredBackupNames.Has(backups[i].Name) {
+			continue
+		}
+		for _, name := range backups[i].Status.BackupSnapshotStatus.ReusedSnapshots {
+			result.Put(name)
+		}
+	}
+
+	return result
+}
+
+// getCompletedSnapshotBackups filters the completed volume snapshot backups
+// of the passed cluster
+func getCompletedSnapshotBackups(cluster *apiv1.Cluster, backups []apiv1.Backup) []apiv1.Backup {
+	var result []apiv1.Backup
+	for i := range backups {
+		if backups[i].Spec.Cluster.Name != cluster.Name ||
+			backups[i].Spec.Method != apiv1.BackupMethodVolumeSnapshot ||
+			backups[i].Status.Phase != apiv1.BackupPhaseCompleted {
+			continue
+		}
+		result = append(result, backups[i])
+	}
+
+	return result
+}
+
+// getExpiredBackups gets the list of the completed backups that are not
+// retained by the retention policies at the passed time, given the
+// snapshots taken by each of them
+func getExpiredBackups(
+	backups []apiv1.Backup,
+	backupSnapshots map[string][]storagesnapshotv1.VolumeSnapshot,
+	config *apiv1.VolumeSnapshotConfiguration,
+	now time.Time,
+) ([]apiv1.Backup, error) {
+	var dataBackups, walBackups []apiv1.Backup
+	for i := range backups {
+		// A backup whose snapshots are gone follows the PG_DATA retention
+		roles := getSnapshotRoles(backupSnapshots[backups[i].Name])
+		if roles.Len() == 0 || roles.Has(string(utils.PVCRolePgData)) {
+			dataBackups = append(dataBackups, backups[i])
+		}
+		if roles.Has(string(utils.PVCRolePgWal)) {
+			walBackups = append(walBackups, backups[i])
+		}
+	}
+
+	retainedBackups := stringset.New()
+	retainedData, expiredData, err := applyDataRetention(dataBackups, config, now)
+	if err != nil {
+		return nil, err
+	}
+	for i := range retainedData {
+		retainedBackups.Put(retainedData[i].Name)
+	}
+
+	// Without a specific retention, the PG_WAL snapshots follow
+	// the PG_DATA snapshots taken by the same backup
+	if config.WalRetention != nil {
+		retainedWal, _, err := applyRetention(walBackups, config.WalRetention, now)
+		if err != nil {
+			return nil, err
+		}
+		for i := range retainedWal {
+			retainedBackups.Put(retainedWal[i].Name)
+		}
+	}
+
+	var result []apiv1.Backup
+	for i := range expiredData {
+		if retainedBackups.Has(expiredData[i].Name) {
+			continue
+		}
+		result = append(result, expiredData[i])
+	}
+
+	return result, nil
+}
+
+// getBackupsWithPrunableWal gets the retained backups whose PG_WAL snapshots
+// are expired by the WAL retention policy, and can be deleted on their own as
+// they are not needed to restore the PG_DATA snapshots taken by the same
+// backups. The snapshots of the expired backups are deleted together with them
+func getBackupsWithPrunableWal(
+	backups []apiv1.Backup,
+	backupSnapshots map[string][]storagesnapshotv1.VolumeSnapshot,
+	expiredBackups []apiv1.Backup,
+	config *apiv1.VolumeSnapshotConfiguration,
+	now time.Time,
+) ([]apiv1.Backup, error) {
+	if config.WalRetention == nil {
+		return nil, nil
+	}
+
+	var walBackups []apiv1.Backup
+	for i := range backups {
+		if getSnapshotRoles(backupSnapshots[backups[i].Name]).Has(string(utils.PVCRolePgWal)) {
+			walBackups = append(walBackups, backups[i])
+		}
+	}
+
+	_, expiredWal, err := applyRetention(walBackups, config.WalRetention, now)
+	if err != nil {
+		return nil, err
+	}
+
+	expiredBackupNames := stringset.New()
+	for i := range expiredBackups {
+		expiredBackupNames.Put(expiredBackups[i].Name)
+	}
+
+	var result []apiv1.Backup
+	for i := range expiredWal {
+		if expiredBackupNames.Has(expiredWal[i].Name) || !isWalArchiveCovering(&expiredWal[i]) {
+			continue
+		}
+		result = append(result, expiredWal[i])
+	}
+
+	return result, nil
+}
+
+// isWalArchiveCovering checks whether the WAL archive contained, when the
+// backup was completed, the WAL files needed to restore its PG_DATA
+// snapshots without the PG_WAL ones, i.e. up to the checkpoint the offline
+// snapshots are consistent at, or up to the end of an online backup
+func isWalArchiveCovering(backup *apiv1.Backup) bool {
+	lastArchivedLSN := backup.Status.BackupSnapshotStatus.LastArchivedLSN
+	if lastArchivedLSN == "" || lastArchivedLSN == apiv1.BackupSnapshotNoWALArchive {
+		return false
+	}
+
+	requiredLSN := backup.Status.EndLSN
+	if requiredLSN == "" {
+		requiredLSN = backup.Status.BeginLSN
+	}
+	if requiredLSN == "" {
+		return false
+	}
+
+	return postgres.LSN(requiredLSN).Less(postgres.LSN(lastArchivedLSN))
+}
+
+// getSnapshotRoles gets the roles of the PersistentVolumeClaims of the
+// passed snapshots
+func getSnapshotRoles(snapshots []storagesnapshotv1.VolumeSnapshot) *stringset.Data {
+	result := stringset.New()
+	for i := range snapshots {
+		result.Put(snapshots[i].Labels[utils.PvcRoleLabelName])
+	}
+
+	return result
+}
+
+// applyDataRetention splits the passed backups between the ones whose
+// PG_DATA snapshots are retained and the expired ones. When a specific
+// retention policy is set for the manual backups, the backups are grouped
+// by their origin, and each group follows its own policy
+func applyDataRetention(
+	backups []apiv1.Backup,
+	config *apiv1.VolumeSnapshotConfiguration,
+	now time.Time,
+) (retained []apiv1.Backup, expired []apiv1.Backup, err error) {
+	if config.ManualRetention == nil {
+		return applyRetention(backups, config.Retention, now)
+	}
+
+	var scheduledBackups, manualBackups []apiv1.Backup
+	for i := range backups {
+		if backups[i].GetOrigin() == utils.BackupOriginScheduled {
+			scheduledBackups = append(scheduledBackups, backups[i])
+		} else {
+			manualBackups = append(manualBackups, backups[i])
+		}
+	}
+
+	retainedScheduled, expiredScheduled, err := applyRetention(scheduledBackups, config.Retention, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	retainedManual, expiredManual, err := applyRetention(manualBackups, config.ManualRetention, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return append(retainedScheduled, retainedManual...), append(expiredScheduled, expiredManual...), nil
+}
+
+// applyRetention splits the passed backups between the retained and the
+// expired ones, given a retention policy. Without a retention policy,
+// every backup is retained
+func applyRetention(
+	backups []apiv1.Backup,
+	retention *apiv1.VolumeSnapshotRetention,
+	now time.Time,
+) (retained []apiv1.Backup, expired []apiv1.Backup, err error) {
+	if retention == nil {
+		return backups, nil, nil
+	}
+
+	maxAge, err := retention.GetMaxAge()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The most recent backups come first
+	sorted := make([]apiv1.Backup, len(backups))
+	copy(sorted, backups)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return getBackupTime(&sorted[j]).Before(getBackupTime(&sorted[i]))
+	})
+
+	// The last backups of the time buckets are retained in addition to
+	// the ones within the limits, if any
+	bucketRetained := getTimeBucketRetainedBackups(sorted, retention)
+	limited := retention.MaxCount > 0 || maxAge > 0 || !retention.HasTimeBuckets()
+
+	for i := range sorted {
+		tooMany := retention.MaxCount > 0 && i >= retention.MaxCount
+		tooOld := maxAge > 0 && now.Sub(getBackupTime(&sorted[i])) > maxAge
+		withinLimits := limited && !tooMany && !tooOld
+		if withinLimits || bucketRetained.Has(sorted[i].Name) {
+			retained = append(retained, sorted[i])
+		} else {
+			expired = append(expired, sorted[i])
+		}
+	}
+
+	return retained, expired, nil
+}
+
+// getTimeBucketRetainedBackups gets the names of the backups retained as
+// the last ones of the daily, weekly, monthly and yearly time buckets, given
+// the backups sorted from the most recent one
+func getTimeBucketRetainedBackups(
+	sorted []apiv1.Backup,
+	retention *apiv1.VolumeSnapshotRetention,
+) *stringset.Data {
+	result := stringset.New()
+	buckets := []struct {
+		keep int
+		key  func(t time.Time) string
+	}{
+		{
+			keep: retention.KeepDaily,
+			key:  func(t time.Time) string { return t.Format("2006-01-02") },
+		},
+		{
+			keep: retention.KeepWeekly,
+			key: func(t time.Time) string {
+				year, week := t.ISOWeek()
+				return fmt.Sprintf("%d-W%02d", year, week)
+			},
+		},
+		{
+			keep: retention.KeepMonthly,
+			key:  func(t time.Time) string { return t.Format("2006-01") },
+		},
+		{
+			keep: retention.KeepYearly,
+			key:  func(t time.Time) string { return t.Format("2006") },
+		},
+	}
+
+	for _, bucket := range buckets {
+		for _, name := range getLastBackupPerTimeBucket(sorted, bucket.keep, bucket.key) {
+			result.Put(name)
+		}
+	}
+
+	return result
+}
+
+// getLastBackupPerTimeBucket gets the names of the last backups of the most
+// recent time buckets having a backup, given the backups sorted from the most
+// recent one. A backup which is not restorable is replaced by the last
+// restorable one of the same bucket, if any, so that every bucket can be
+// recovered from
+func getLastBackupPerTimeBucket(
+	sorted []apiv1.Backup,
+	keep int,
+	bucketKey func(t time.Time) string,
+) []string {
+	if keep <= 0 {
+		return nil
+	}
+
+	var keys []string
+	lastBackups := make(map[string]*apiv1.Backup)
+	for i := range sorted {
+		key := bucketKey(getBackupTime(&sorted[i]).UTC())
+		lastBackup, found := lastBackups[key]
+		switch {
+		case !found && len(keys) == keep:
+			// every older backup belongs to a bucket which is not retained
+			return getBackupNames(keys, lastBackups)
+		case !found:
+			keys = append(keys, key)
+			lastBackups[key] = &sorted[i]
+		case !isBackupRestorable(lastBackup) && isBackupRestorable(&sorted[i]):
+			lastBackups[key] = &sorted[i]
+		}
+	}
+
+	return getBackupNames(keys, lastBackups)
+}
+
+// getBackupNames gets the names of the backups of the passed buckets
+func getBackupNames(keys []string, backups map[string]*apiv1.Backup) []string {
+	result := make([]string, len(keys))
+	for i, key := range keys {
+		result[i] = backups[key].Name
+	}
+
+	return result
+}
+
+// isBackupRestorable tells whether a backup has not been flagged as not
+// restorable by the restorability checks
+func isBackupRestorable(backup *apiv1.Backup) bool {
+	return !meta.IsStatusConditionFalse(backup.Status.Conditions, string(apiv1.ConditionBackupRestorable))
+}
+
+// getBackupTime gets the time a backup has been taken at, which is when
+// it started, or when it was created if not available
+func getBackupTime(backup *apiv1.Backup) time.Time {
+	if backup.Status.StartedAt != nil {
+		return backup.Status.StartedAt.Time
+	}
+
+	return backup.CreationTimestamp.Time
+}
@@ -0,0 +1,531 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("volume snapshot retention", func() {
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	newSnapshot := func(backupName string, role utils.PVCRole) storagesnapshotv1.VolumeSnapshot {
+		suffix := "-data"
+		if role == utils.PVCRolePgWal {
+			suffix = "-wal"
+		}
+		return storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      backupName + suffix,
+				Namespace: "default",
+				Labels: map[string]string{
+					utils.ClusterLabelName:    "cluster-example",
+					utils.BackupNameLabelName: backupName,
+					utils.PvcRoleLabelName:    string(role),
+				},
+			},
+			Status: &storagesnapshotv1.VolumeSnapshotStatus{
+				ReadyToUse: ptr.To(true),
+			},
+		}
+	}
+
+	newBackup := func(name string, age time.Duration) apiv1.Backup {
+		return apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: apiv1.BackupSpec{
+				Cluster: apiv1.LocalObjectReference{Name: "cluster-example"},
+				Method:  apiv1.BackupMethodVolumeSnapshot,
+			},
+			Status: apiv1.BackupStatus{
+				Phase:     apiv1.BackupPhaseCompleted,
+				StartedAt: ptr.To(metav1.NewTime(now.Add(-age))),
+			},
+		}
+	}
+
+	// newBackups creates a completed backup per day, starting from the
+	// most recent one, each one having a PG_DATA and a PG_WAL snapshot
+	newBackups := func(count int) ([]apiv1.Backup, map[string][]storagesnapshotv1.VolumeSnapshot) {
+		var backups []apiv1.Backup
+		snapshots := make(map[string][]storagesnapshotv1.VolumeSnapshot)
+		for i := 0; i < count; i++ {
+			backupName := "backup-" + string(rune('a'+i))
+			age := time.Duration(i)*24*time.Hour + time.Hour
+			backups = append(backups, newBackup(backupName, age))
+			snapshots[backupName] = []storagesnapshotv1.VolumeSnapshot{
+				newSnapshot(backupName, utils.PVCRolePgData),
+				newSnapshot(backupName, utils.PVCRolePgWal),
+			}
+		}
+		return backups, snapshots
+	}
+
+	getNames := func(backups []apiv1.Backup) []string {
+		result := make([]string, len(backups))
+		for i := range backups {
+			result[i] = backups[i].Name
+		}
+		return result
+	}
+
+	It("retains everything without a retention policy", func() {
+		backups, snapshots := newBackups(3)
+		expired, err := getExpiredBackups(backups, snapshots, &apiv1.VolumeSnapshotConfiguration{}, now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expired).To(BeEmpty())
+	})
+
+	It("deletes the backups whose PG_DATA snapshots are expired by default", func() {
+		config := &apiv1.VolumeSnapshotConfiguration{
+			Retention: &apiv1.VolumeSnapshotRetention{MaxCount: 2},
+		}
+
+		backups, snapshots := newBackups(4)
+		expired, err := getExpiredBackups(backups, snapshots, config, now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getNames(expired)).To(ConsistOf("backup-c", "backup-d"))
+	})
+
+	It("retains the backups whose PG_WAL snapshots are retained", func() {
+		config := &apiv1.VolumeSnapshotConfiguration{
+			Retention:    &apiv1.VolumeSnapshotRetention{MaxAge: "1d"},
+			WalRetention: &apiv1.VolumeSnapshotRetention{MaxCount: 3},
+		}
+
+		backups, snapshots := newBackups(4)
+		expired, err := getExpiredBackups(backups, snapshots, config, now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getNames(expired)).To(ConsistOf("backup-d"))
+	})
+
+	It("retains the backups whose PG_DATA snapshots are retained", func() {
+		config := &apiv1.VolumeSnapshotConfiguration{
+			Retention:    &apiv1.VolumeSnapshotRetention{MaxCount: 3},
+			WalRetention: &apiv1.VolumeSnapshotRetention{MaxCount: 1},
+		}
+
+		backups, snapshots := newBackups(4)
+		expired, err := getExpiredBackups(backups, snapshots, config, now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getNames(expired)).To(ConsistOf("backup-d"))
+	})
+
+	It("deletes the backups whose snapshots are gone through the PG_DATA retention", func() {
+		config := &apiv1.VolumeSnapshotConfiguration{
+			Retention:    &apiv1.VolumeSnapshotRetention{MaxCount: 1},
+			WalRetention: &apiv1.VolumeSnapshotRetention{MaxCount: 2},
+		}
+
+		backups, snapshots := newBackups(2)
+		delete(snapshots, "backup-b")
+		expired, err := getExpiredBackups(backups, snapshots, config, now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getNames(expired)).To(ConsistOf("backup-b"))
+	})
+
+	Context("pruning the PG_WAL snapshots on their own", func() {
+		// setArchivedWAL records that the WAL archive contains the WAL
+		// files needed to restore the PG_DATA snapshots of the backup
+		setArchivedWAL := func(backup *apiv1.Backup) {
+			backup.Status.BeginLSN = "0/5000028"
+			backup.Status.BackupSnapshotStatus.LastArchivedLSN = "0/6000000"
+		}
+
+		It("prunes the expired PG_WAL snapshots of the retained PG_DATA ones", func() {
+			config := &apiv1.VolumeSnapshotConfiguration{
+				Retention:    &apiv1.VolumeSnapshotRetention{MaxCount: 3},
+				WalRetention: &apiv1.VolumeSnapshotRetention{MaxCount: 1},
+			}
+
+			backups, snapshots := newBackups(3)
+			for i := range backups {
+				setArchivedWAL(&backups[i])
+			}
+			expired, err := getExpiredBackups(backups, snapshots, config, now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(expired).To(BeEmpty())
+
+			prunable, err := getBackupsWithPrunableWal(backups, snapshots, expired, config, now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getNames(prunable)).To(ConsistOf("backup-b", "backup-c"))
+		})
+
+		It("keeps the PG_WAL snapshots needed by the retained PG_DATA ones", func() {
+			config := &apiv1.VolumeSnapshotConfiguration{
+				Retention:    &apiv1.VolumeSnapshotRetention{MaxCount: 3},
+				WalRetention: &apiv1.VolumeSnapshotRetention{MaxCount: 1},
+			}
+
+			backups, snapshots := newBackups(3)
+			setArchivedWAL(&backups[1])
+			backups[1].Status.BackupSnapshotStatus.LastArchivedLSN = "0/5000000"
+			backups[2].Status.BackupSnapshotStatus.LastArchivedLSN = apiv1.BackupSnapshotNoWALArchive
+
+			prunable, err := getBackupsWithPrunableWal(backups, snapshots, nil, config, now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(prunable).To(BeEmpty())
+		})
+
+		It("keeps the PG_WAL snapshots retained longer than the PG_DATA ones", func() {
+			config := &apiv1.VolumeSnapshotConfiguration{
+				Retention:    &apiv1.VolumeSnapshotRetention{MaxCount: 1},
+				WalRetention: &apiv1.VolumeSnapshotRetention{MaxCount: 3},
+			}
+
+			backups, snapshots := newBackups(4)
+			for i := range backups {
+				setArchivedWAL(&backups[i])
+			}
+			expired, err := getExpiredBackups(backups, snapshots, config, now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getNames(expired)).To(ConsistOf("backup-d"))
+
+			prunable, err := getBackupsWithPrunableWal(backups, snapshots, expired, config, now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(prunable).To(BeEmpty())
+		})
+
+		It("requires the WAL archive to reach the end of the online backups", func() {
+			backup := newBackup("backup-online", 0)
+			setArchivedWAL(&backup)
+			Expect(isWalArchiveCovering(&backup)).To(BeTrue())
+
+			backup.Status.EndLSN = "0/6000100"
+			Expect(isWalArchiveCovering(&backup)).To(BeFalse())
+		})
+
+		It("deletes the PG_WAL snapshots, keeping the backups", func(ctx context.Context) {
+			cluster := &apiv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+				Spec: apiv1.ClusterSpec{
+					Backup: &apiv1.BackupConfiguration{
+						VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+							Retention:    &apiv1.VolumeSnapshotRetention{MaxCount: 2},
+							WalRetention: &apiv1.VolumeSnapshotRetention{MaxCount: 1},
+						},
+					},
+				},
+			}
+
+			backups, snapshots := newBackups(2)
+			var objects []client.Object
+			for i := range backups {
+				setArchivedWAL(&backups[i])
+				backups[i].Status.BackupSnapshotStatus.Snapshots = []string{
+					backups[i].Name + "-data", backups[i].Name + "-wal",
+				}
+				objects = append(objects, &backups[i])
+				for j := range snapshots[backups[i].Name] {
+					objects = append(objects, &snapshots[backups[i].Name][j])
+				}
+			}
+			cli := fake.NewClientBuilder().
+				WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+				WithObjects(objects...).
+				WithStatusSubresource(&apiv1.Backup{}).
+				Build()
+
+			Expect(EnforceRetentionPolicy(ctx, cli, cluster)).To(Succeed())
+
+			var snapshotList storagesnapshotv1.VolumeSnapshotList
+			Expect(cli.List(ctx, &snapshotList)).To(Succeed())
+			snapshotNames := make([]string, len(snapshotList.Items))
+			for i := range snapshotList.Items {
+				snapshotNames[i] = snapshotList.Items[i].Name
+			}
+			Expect(snapshotNames).To(ConsistOf("backup-a-data", "backup-a-wal", "backup-b-data"))
+
+			var backup apiv1.Backup
+			Expect(cli.Get(ctx, client.ObjectKey{Namespace: "default", Name: "backup-b"}, &backup)).To(Succeed())
+			Expect(backup.Status.BackupSnapshotStatus.Snapshots).To(Equal([]string{"backup-b-data"}))
+		})
+	})
+
+	It("only considers the completed volume snapshot backups of the cluster", func() {
+		cluster := &apiv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster-example"}}
+		running := newBackup("running", time.Hour)
+		running.Status.Phase = apiv1.BackupPhaseRunning
+		barman := newBackup("barman", time.Hour)
+		barman.Spec.Method = apiv1.BackupMethodBarmanObjectStore
+		other := newBackup("other", time.Hour)
+		other.Spec.Cluster.Name = "other-cluster"
+		completed := newBackup("completed", time.Hour)
+
+		backups := getCompletedSnapshotBackups(cluster, []apiv1.Backup{running, barman, other, completed})
+		Expect(getNames(backups)).To(ConsistOf("completed"))
+	})
+
+	Context("with a specific retention for manual backups", func() {
+		// newMixedBackups creates a backup per day, alternating between
+		// scheduled and manual backups, the most recent one being scheduled
+		newMixedBackups := func(count int) ([]apiv1.Backup, map[string][]storagesnapshotv1.VolumeSnapshot) {
+			backups, snapshots := newBackups(count)
+			for i := range backups {
+				if i%2 == 0 {
+					backups[i].Labels = map[string]string{
+						utils.ParentScheduledBackupLabelName: "scheduled-backup",
+					}
+				}
+			}
+			return backups, snapshots
+		}
+
+		It("applies separate retention policies to scheduled and manual backups", func() {
+			config := &apiv1.VolumeSnapshotConfiguration{
+				Retention:       &apiv1.VolumeSnapshotRetention{MaxCount: 1},
+				ManualRetention: &apiv1.VolumeSnapshotRetention{MaxCount: 2},
+			}
+
+			// a, c, e are scheduled, b, d, f are manual
+			backups, snapshots := newMixedBackups(6)
+			expired, err := getExpiredBackups(backups, snapshots, config, now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getNames(expired)).To(ConsistOf("backup-c", "backup-e", "backup-f"))
+		})
+
+		It("never prunes the manual backups through the scheduled retention", func() {
+			config := &apiv1.VolumeSnapshotConfiguration{
+				Retention:       &apiv1.VolumeSnapshotRetention{MaxAge: "1d"},
+				ManualRetention: &apiv1.VolumeSnapshotRetention{MaxAge: "4w"},
+			}
+
+			backups, snapshots := newMixedBackups(4)
+			expired, err := getExpiredBackups(backups, snapshots, config, now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getNames(expired)).To(ConsistOf("backup-c"))
+		})
+
+		It("applies the retention to every backup when not specified", func() {
+			config := &apiv1.VolumeSnapshotConfiguration{
+				Retention: &apiv1.VolumeSnapshotRetention{MaxCount: 1},
+			}
+
+			backups, snapshots := newMixedBackups(2)
+			expired, err := getExpiredBackups(backups, snapshots, config, now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getNames(expired)).To(ConsistOf("backup-b"))
+		})
+	})
+
+	Context("with time buckets", func() {
+		// newDailyBackups creates a completed backup per day, at 11:00 UTC,
+		// starting from the one taken on Sunday, October 1st 2023
+		newDailyBackups := func(count int) []apiv1.Backup {
+			backups := make([]apiv1.Backup, count)
+			for i := range backups {
+				age := time.Duration(i)*24*time.Hour + time.Hour
+				backups[i] = newBackup(fmt.Sprintf("backup-%02d", i), age)
+			}
+			return backups
+		}
+
+		It("retains the last backup of each day", func() {
+			// two backups per day, at 11:00 and 23:00
+			var backups []apiv1.Backup
+			for i := 0; i < 8; i++ {
+				age := time.Duration(i)*12*time.Hour + time.Hour
+				backups = append(backups, newBackup(fmt.Sprintf("backup-%02d", i), age))
+			}
+
+			retained, expired, err := applyRetention(backups, &apiv1.VolumeSnapshotRetention{KeepDaily: 2}, now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getNames(retained)).To(ConsistOf("backup-00", "backup-01"))
+			Expect(expired).To(HaveLen(6))
+		})
+
+		It("applies the grandfather-father-son scheme", func() {
+			retention := &apiv1.VolumeSnapshotRetention{KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 2}
+
+			retained, expired, err := applyRetention(newDailyBackups(40), retention, now)
+			Expect(err).ToNot(HaveOccurred())
+			// the last 7 days, the Sundays of the last 4 ISO weeks and
+			// the last days of October and September
+			Expect(getNames(retained)).To(ConsistOf(
+				"backup-00", "backup-01", "backup-02", "backup-03", "backup-04", "backup-05", "backup-06",
+				"backup-07", "backup-14", "backup-21",
+			))
+			Expect(expired).To(HaveLen(30))
+		})
+
+		It("retains the time buckets in addition to the limits", func() {
+			retention := &apiv1.VolumeSnapshotRetention{MaxCount: 2, KeepWeekly: 2}
+
+			retained, _, err := applyRetention(newDailyBackups(10), retention, now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getNames(retained)).To(ConsistOf("backup-00", "backup-01", "backup-07"))
+		})
+
+		It("retains a restorable backup in place of the last one of the bucket", func() {
+			backups := newDailyBackups(7)
+			backups[0].Status.Conditions = []metav1.Condition{{
+				Type:   string(apiv1.ConditionBackupRestorable),
+				Status: metav1.ConditionFalse,
+			}}
+
+			retained, _, err := applyRetention(backups, &apiv1.VolumeSnapshotRetention{KeepWeekly: 1}, now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getNames(retained)).To(ConsistOf("backup-01"))
+		})
+	})
+
+	It("deletes the expired backups of the cluster together with their snapshots", func(ctx context.Context) {
+		cluster := &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						Retention: &apiv1.VolumeSnapshotRetention{MaxCount: 1},
+					},
+				},
+			},
+		}
+
+		backups, snapshots := newBackups(2)
+		running := newBackup("backup-running", 0)
+		running.Status.Phase = apiv1.BackupPhaseRunning
+		backups = append(backups, running)
+		snapshots["backup-running"] = []storagesnapshotv1.VolumeSnapshot{
+			newSnapshot("backup-running", utils.PVCRolePgWal),
+		}
+
+		var objects []client.Object
+		for i := range backups {
+			objects = append(objects, &backups[i])
+			for j := range snapshots[backups[i].Name] {
+				objects = append(objects, &snapshots[backups[i].Name][j])
+			}
+		}
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(objects...).
+			Build()
+
+		Expect(EnforceRetentionPolicy(ctx, cli, cluster)).To(Succeed())
+
+		var backupList apiv1.BackupList
+		Expect(cli.List(ctx, &backupList)).To(Succeed())
+		Expect(getNames(backupList.Items)).To(ConsistOf("backup-a", "backup-running"))
+
+		var snapshotList storagesnapshotv1.VolumeSnapshotList
+		Expect(cli.List(ctx, &snapshotList)).To(Succeed())
+		snapshotNames := make([]string, len(snapshotList.Items))
+		for i := range snapshotList.Items {
+			snapshotNames[i] = snapshotList.Items[i].Name
+		}
+		Expect(snapshotNames).To(ConsistOf("backup-a-data", "backup-a-wal", "backup-running-wal"))
+	})
+
+	DescribeTable("deleting the snapshots of the expired backups whatever their owner",
+		func(ctx context.Context, owner apiv1.SnapshotOwnerReference) {
+			cluster := &apiv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-example",
+					Namespace: "default",
+				},
+				Spec: apiv1.ClusterSpec{
+					Backup: &apiv1.BackupConfiguration{
+						VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+							SnapshotOwnerReference: owner,
+							Retention:              &apiv1.VolumeSnapshotRetention{MaxCount: 1},
+						},
+					},
+				},
+			}
+
+			backups, snapshots := newBackups(2)
+			var objects []client.Object
+			for i := range backups {
+				objects = append(objects, &backups[i])
+				for j := range snapshots[backups[i].Name] {
+					snapshot := &snapshots[backups[i].Name][j]
+					switch owner {
+					case apiv1.SnapshotOwnerReferenceCluster:
+						snapshot.OwnerReferences = []metav1.OwnerReference{{
+							APIVersion: apiv1.GroupVersion.String(),
+							Kind:       apiv1.ClusterKind,
+							Name:       cluster.Name,
+						}}
+					case apiv1.SnapshotOwnerReferenceBackup:
+						snapshot.OwnerReferences = []metav1.OwnerReference{{
+							APIVersion: apiv1.GroupVersion.String(),
+							Kind:       apiv1.BackupKind,
+							Name:       backups[i].Name,
+						}}
+					}
+					objects = append(objects, snapshot)
+				}
+			}
+
+			// The fake client has no garbage collector, so the snapshots
+			// which are left behind by the retention would still be found
+			cli := fake.NewClientBuilder().
+				WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+				WithObjects(objects...).
+				Build()
+
+			Expect(EnforceRetentionPolicy(ctx, cli, cluster)).To(Succeed())
+
+			var backupList apiv1.BackupList
+			Expect(cli.List(ctx, &backupList)).To(Succeed())
+			Expect(getNames(backupList.Items)).To(ConsistOf("backup-a"))
+
+			var snapshotList storagesnapshotv1.VolumeSnapshotList
+			Expect(cli.List(ctx, &snapshotList)).To(Succeed())
+			snapshotNames := make([]string, len(snapshotList.Items))
+			for i := range snapshotList.Items {
+				snapshotNames[i] = snapshotList.Items[i].Name
+			}
+			Expect(snapshotNames).To(ConsistOf("backup-a-data", "backup-a-wal"))
+		},
+		Entry("without owner", apiv1.ShapshotOwnerReferenceNone),
+		Entry("owned by the cluster", apiv1.SnapshotOwnerReferenceCluster),
+		Entry("owned by the backup", apiv1.SnapshotOwnerReferenceBackup),
+	)
+
+	It("keeps the snapshots reused by the retained backups", func() {
+		expired := newBackup("backup-expired", 0)
+		retained := newBackup("backup-retained", 0)
+		retained.Status.BackupSnapshotStatus.ReusedSnapshots = []string{"backup-old-data"}
+		expired.Status.BackupSnapshotStatus.ReusedSnapshots = []string{"backup-older-data"}
+
+		reused := getSnapshotsReusedByRetainedBackups(
+			[]apiv1.Backup{expired, retained}, []apiv1.Backup{expired})
+		Expect(reused.ToList()).To(ConsistOf("backup-old-data"))
+	})
+})
@@ -0,0 +1,166 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// reuseFreshSnapshots looks for the snapshots of the passed PVCs which were
+// taken by previous backups within the reuse window, while the target
+// instance was shut down at the same checkpoint where it is now. These
+// PVCs didn't change since then, and their snapshots are recorded as
+// reused by the backup. Returns the PVCs still needing a new snapshot
+func (se *Reconciler) reuseFreshSnapshots(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	targetPod *corev1.Pod,
+	pvcs []corev1.PersistentVolumeClaim,
+	reuseWindow time.Duration,
+) ([]corev1.PersistentVolumeClaim, error) {
+	contextLogger := log.FromContext(ctx)
+
+	// Without knowing where the fenced instance stopped, there's no way
+	// to tell whether its volumes changed
+	pgControldata, err := se.getPgControlData(ctx, cluster, targetPod)
+	if err != nil {
+		contextLogger.Info("Cannot get the pg_controldata output, not reusing any snapshot",
+			"podName", targetPod.Name, "err", err.Error())
+		return pvcs, nil
+	}
+	if !isShutDown(pgControldata) {
+		return pvcs, nil
+	}
+	consistentLSN := getConsistentLSN(pgControldata)
+
+	var candidates storagesnapshotv1.VolumeSnapshotList
+	if err := se.cli.List(
+		ctx,
+		&candidates,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{utils.ClusterLabelName: cluster.Name},
+		client.HasLabels{utils.BackupNameLabelName},
+	); err != nil {
+		return nil, err
+	}
+
+	var pendingPVCs []corev1.PersistentVolumeClaim
+	var reusedSnapshots []string
+	now := time.Now()
+	for i := range pvcs {
+		snapshot := findReusableSnapshot(pvcs[i].Name, backup.Name, candidates.Items, consistentLSN, reuseWindow, now)
+		if snapshot == nil {
+			pendingPVCs = append(pendingPVCs, pvcs[i])
+			continue
+		}
+
+		contextLogger.Info("Reusing the snapshot of an unchanged PVC",
+			"pvcName", pvcs[i].Name, "snapshotName", snapshot.Name, "consistentLSN", consistentLSN)
+		se.recorder.Eventf(backup, "Normal", "ReuseSnapshot",
+			"Reusing VolumeSnapshot %s for PVC %s, unchanged since %s",
+			snapshot.Name, pvcs[i].Name, consistentLSN)
+		reusedSnapshots = append(reusedSnapshots, snapshot.Name)
+	}
+
+	if len(reusedSnapshots) == 0 {
+		return pendingPVCs, nil
+	}
+
+	origBackup := backup.DeepCopy()
+	backup.Status.BackupSnapshotStatus.ReusedSnapshots = append(
+		backup.Status.BackupSnapshotStatus.ReusedSnapshots, reusedSnapshots...)
+	if err := se.backupCli.Status().Patch(ctx, backup, client.MergeFrom(origBackup)); err != nil {
+		return nil, err
+	}
+
+	return pendingPVCs, nil
+}
+
+// findReusableSnapshot finds, among the passed candidates, the most recent
+// snapshot of the given PVC which can be reused by a backup of an instance
+// shut down at the passed LSN. The snapshot must be ready, taken by another
+// backup within the reuse window, and consistent at the same LSN while
+// the instance was shut down, so that the PVC didn't change since then
+func findReusableSnapshot(
+	pvcName string,
+	backupName string,
+	candidates []storagesnapshotv1.VolumeSnapshot,
+	consistentLSN string,
+	reuseWindow time.Duration,
+	now time.Time,
+) *storagesnapshotv1.VolumeSnapshot {
+	if consistentLSN == "" {
+		return nil
+	}
+
+	var result *storagesnapshotv1.VolumeSnapshot
+	for i := range candidates {
+		snapshot := &candidates[i]
+
+		source := snapshot.Spec.Source.PersistentVolumeClaimName
+		switch {
+		case source == nil || *source != pvcName:
+			continue
+		case snapshot.Labels[utils.BackupNameLabelName] == backupName:
+			continue
+		case !snapshot.DeletionTimestamp.IsZero():
+			continue
+		case now.Sub(snapshot.CreationTimestamp.Time) > reuseWindow:
+			continue
+		case snapshot.Status == nil || snapshot.Status.ReadyToUse == nil || !*snapshot.Status.ReadyToUse:
+			continue
+		case snapshot.Annotations[utils.ConsistentLSNAnnotationName] != consistentLSN:
+			continue
+		case !isShutDown(snapshot.Annotations[utils.PgControldataAnnotationName]):
+			// an online snapshot can't prove the PVC didn't change
+			continue
+		case isOwnedByBackup(snapshot):
+			// the snapshot would be deleted together with the backup
+			// that took it
+			continue
+		case verifyMetadataChecksum(snapshot) != nil:
+			continue
+		}
+
+		if result == nil || snapshot.CreationTimestamp.After(result.CreationTimestamp.Time) {
+			result = snapshot
+		}
+	}
+
+	return result
+}
+
+// isOwnedByBackup checks whether the passed snapshot is owned by a Backup
+func isOwnedByBackup(snapshot *storagesnapshotv1.VolumeSnapshot) bool {
+	for _, ownerReference := range snapshot.OwnerReferences {
+		if ownerReference.Kind == apiv1.BackupKind {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,126 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("snapshot reuse", func() {
+	const (
+		pvcName       = "cluster-example-1"
+		consistentLSN = "0/7000060"
+		reuseWindow   = time.Hour
+	)
+
+	now := time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC)
+	shutDownControldata := "Database cluster state:               shut down\n" +
+		"Latest checkpoint location:           0/7000060\n"
+
+	newSnapshot := func(name string, age time.Duration) storagesnapshotv1.VolumeSnapshot {
+		return storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+				Labels:            map[string]string{utils.BackupNameLabelName: "backup-previous"},
+				Annotations: map[string]string{
+					utils.ConsistentLSNAnnotationName: consistentLSN,
+					utils.PgControldataAnnotationName: shutDownControldata,
+				},
+			},
+			Spec: storagesnapshotv1.VolumeSnapshotSpec{
+				Source: storagesnapshotv1.VolumeSnapshotSource{
+					PersistentVolumeClaimName: ptr.To(pvcName),
+				},
+			},
+			Status: &storagesnapshotv1.VolumeSnapshotStatus{ReadyToUse: ptr.To(true)},
+		}
+	}
+
+	find := func(candidates ...storagesnapshotv1.VolumeSnapshot) *storagesnapshotv1.VolumeSnapshot {
+		return findReusableSnapshot(pvcName, "backup-new", candidates, consistentLSN, reuseWindow, now)
+	}
+
+	It("reuses a fresh snapshot of the unchanged PVC", func() {
+		result := find(newSnapshot("snapshot", 10*time.Minute))
+		Expect(result).ToNot(BeNil())
+		Expect(result.Name).To(Equal("snapshot"))
+	})
+
+	It("reuses the most recent snapshot", func() {
+		result := find(newSnapshot("older", 30*time.Minute), newSnapshot("newer", 10*time.Minute))
+		Expect(result).ToNot(BeNil())
+		Expect(result.Name).To(Equal("newer"))
+	})
+
+	It("recreates the snapshot when the existing one is too old", func() {
+		Expect(find(newSnapshot("snapshot", 2*time.Hour))).To(BeNil())
+	})
+
+	It("recreates the snapshot when the PVC changed since the existing one", func() {
+		snapshot := newSnapshot("snapshot", 10*time.Minute)
+		snapshot.Annotations[utils.ConsistentLSNAnnotationName] = "0/6000028"
+		Expect(find(snapshot)).To(BeNil())
+	})
+
+	It("recreates the snapshot when the existing one was taken online", func() {
+		snapshot := newSnapshot("snapshot", 10*time.Minute)
+		snapshot.Annotations[utils.PgControldataAnnotationName] = "Database cluster state: in production\n"
+		Expect(find(snapshot)).To(BeNil())
+	})
+
+	It("recreates the snapshot when the existing one is not ready", func() {
+		snapshot := newSnapshot("snapshot", 10*time.Minute)
+		snapshot.Status.ReadyToUse = ptr.To(false)
+		Expect(find(snapshot)).To(BeNil())
+	})
+
+	It("recreates the snapshot when the existing one is owned by a backup", func() {
+		snapshot := newSnapshot("snapshot", 10*time.Minute)
+		snapshot.OwnerReferences = []metav1.OwnerReference{{Kind: apiv1.BackupKind, Name: "backup-previous"}}
+		Expect(find(snapshot)).To(BeNil())
+	})
+
+	It("ignores the snapshots of other PVCs", func() {
+		snapshot := newSnapshot("snapshot", 10*time.Minute)
+		snapshot.Spec.Source.PersistentVolumeClaimName = ptr.To("cluster-example-1-wal")
+		Expect(find(snapshot)).To(BeNil())
+	})
+
+	It("ignores the snapshots taken by the same backup", func() {
+		snapshot := newSnapshot("snapshot", 10*time.Minute)
+		snapshot.Labels[utils.BackupNameLabelName] = "backup-new"
+		Expect(find(snapshot)).To(BeNil())
+	})
+
+	It("recreates the snapshot when the consistent LSN is not known", func() {
+		Expect(findReusableSnapshot(
+			pvcName, "backup-new", []storagesnapshotv1.VolumeSnapshot{newSnapshot("snapshot", time.Minute)},
+			"", reuseWindow, now,
+		)).To(BeNil())
+	})
+})
@@ -0,0 +1,59 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"fmt"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+)
+
+// setTotalSize records inside the backup status the total size of the data
+// captured by the snapshots, as the sum of their restore sizes. The snapshots
+// not reporting their restore size are counted as zero, and listed
+func setTotalSize(backup *apiv1.Backup, snapshots []storagesnapshotv1.VolumeSnapshot) {
+	totalSize := &apiv1.BackupTotalSize{}
+	for i := range snapshots {
+		if snapshots[i].Status == nil || snapshots[i].Status.RestoreSize == nil {
+			totalSize.SnapshotsWithoutSize = append(totalSize.SnapshotsWithoutSize, snapshots[i].Name)
+			continue
+		}
+		totalSize.Bytes += snapshots[i].Status.RestoreSize.Value()
+	}
+	totalSize.Size = formatSize(totalSize.Bytes)
+
+	backup.Status.TotalSize = totalSize
+}
+
+// formatSize formats a size in bytes in a human-readable format, using the
+// binary units
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit && exp < 5; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,81 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("total size of the backup", func() {
+	newSnapshot := func(name string, restoreSize string) storagesnapshotv1.VolumeSnapshot {
+		snapshot := storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status:     &storagesnapshotv1.VolumeSnapshotStatus{},
+		}
+		if restoreSize != "" {
+			size := resource.MustParse(restoreSize)
+			snapshot.Status.RestoreSize = &size
+		}
+		return snapshot
+	}
+
+	It("sums the restore sizes of the snapshots", func() {
+		backup := &apiv1.Backup{}
+		setTotalSize(backup, []storagesnapshotv1.VolumeSnapshot{
+			newSnapshot("cluster-example-1", "1Gi"),
+			newSnapshot("cluster-example-1-wal", "512Mi"),
+		})
+
+		Expect(backup.Status.TotalSize).To(Equal(&apiv1.BackupTotalSize{
+			Bytes: 1610612736,
+			Size:  "1.5 GiB",
+		}))
+	})
+
+	It("counts the snapshots without a restore size as zero", func() {
+		backup := &apiv1.Backup{}
+		withoutStatus := newSnapshot("cluster-example-1-tbs-idx", "")
+		withoutStatus.Status = nil
+		setTotalSize(backup, []storagesnapshotv1.VolumeSnapshot{
+			newSnapshot("cluster-example-1", "2Gi"),
+			newSnapshot("cluster-example-1-wal", ""),
+			withoutStatus,
+		})
+
+		Expect(backup.Status.TotalSize.Bytes).To(BeEquivalentTo(2 * 1024 * 1024 * 1024))
+		Expect(backup.Status.TotalSize.Size).To(Equal("2.0 GiB"))
+		Expect(backup.Status.TotalSize.SnapshotsWithoutSize).To(Equal([]string{
+			"cluster-example-1-wal",
+			"cluster-example-1-tbs-idx",
+		}))
+	})
+
+	It("formats the sizes with binary units", func() {
+		Expect(formatSize(0)).To(Equal("0 B"))
+		Expect(formatSize(1023)).To(Equal("1023 B"))
+		Expect(formatSize(1536)).To(Equal("1.5 KiB"))
+		Expect(formatSize(10 * 1024 * 1024)).To(Equal("10.0 MiB"))
+		Expect(formatSize(3 * 1024 * 1024 * 1024 * 1024)).To(Equal("3.0 TiB"))
+	})
+})
@@ -0,0 +1,101 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+)
+
+// tracerName is the name of the OpenTelemetry tracer emitting the spans of
+// the volume snapshot backups
+const tracerName = "github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/backup/volumesnapshot"
+
+// The phases of a volume snapshot backup which are traced
+const (
+	tracingPhaseFence   = "fence"
+	tracingPhaseCreate  = "create"
+	tracingPhaseWait    = "wait"
+	tracingPhaseUnfence = "unfence"
+)
+
+// tracePhase emits the span tracing a phase of the backup, involving the
+// passed number of PersistentVolumeClaims. The span is emitted once, when
+// the phase is over, as a phase usually spans many reconciliation loops:
+// it starts at the passed time and records the error which made the phase
+// fail, if any
+func (se *Reconciler) tracePhase(
+	ctx context.Context,
+	phase string,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	pvcCount int,
+	startedAt time.Time,
+	err error,
+) {
+	_, span := se.tracer.Start(
+		ctx,
+		"volumesnapshot."+phase,
+		trace.WithTimestamp(startedAt),
+		trace.WithAttributes(
+			attribute.String("cnpg.backup.name", backup.Name),
+			attribute.String("cnpg.backup.namespace", backup.Namespace),
+			attribute.String("cnpg.backup.method", string(apiv1.BackupMethodVolumeSnapshot)),
+			attribute.String("cnpg.cluster.name", cluster.Name),
+			attribute.Int("cnpg.backup.pvcCount", pvcCount),
+		),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// getFencingStartTime gets the time when the fencing of the target
+// instance started, defaulting to the current time
+func getFencingStartTime(backup *apiv1.Backup) time.Time {
+	if fencedAt := backup.Status.BackupSnapshotStatus.FencedAt; fencedAt != nil {
+		return fencedAt.Time
+	}
+	return time.Now()
+}
+
+// getWaitStartTime gets the time when the operator started waiting for the
+// snapshots to be ready, which is when the last one of them was created
+func getWaitStartTime(backup *apiv1.Backup, snapshots []storagesnapshotv1.VolumeSnapshot) time.Time {
+	var startedAt time.Time
+	for i := range snapshots {
+		if created := snapshots[i].CreationTimestamp.Time; created.After(startedAt) {
+			startedAt = created
+		}
+	}
+	if startedAt.IsZero() && backup.Status.StartedAt != nil {
+		startedAt = backup.Status.StartedAt.Time
+	}
+	if startedAt.IsZero() {
+		startedAt = time.Now()
+	}
+	return startedAt
+}
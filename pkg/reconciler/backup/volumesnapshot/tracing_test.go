@@ -0,0 +1,173 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("tracing of the snapshot backups", func() {
+	var (
+		cluster   *apiv1.Cluster
+		backup    *apiv1.Backup
+		targetPod *corev1.Pod
+		pvcs      []corev1.PersistentVolumeClaim
+		exporter  *tracetest.InMemoryExporter
+		cli       client.Client
+	)
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{
+						ClassName: "csi-snapclass",
+					},
+				},
+			},
+		}
+		backup = &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "backup-example",
+				Namespace: "default",
+			},
+		}
+		targetPod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example-2",
+				Namespace: "default",
+			},
+		}
+		pvcs = []corev1.PersistentVolumeClaim{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cluster-example-2",
+					Namespace: "default",
+					Labels: map[string]string{
+						utils.PvcRoleLabelName: string(utils.PVCRolePgData),
+					},
+				},
+			},
+		}
+		exporter = tracetest.NewInMemoryExporter()
+		cli = fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(cluster, backup, targetPod).
+			WithStatusSubresource(backup).
+			Build()
+	})
+
+	// getSpanNames returns the names of the exported spans, and clears them
+	getSpanNames := func() []string {
+		spans := exporter.GetSpans()
+		names := make([]string, len(spans))
+		for i := range spans {
+			names[i] = spans[i].Name
+		}
+		exporter.Reset()
+		return names
+	}
+
+	markSnapshotsReady := func(ctx context.Context) {
+		snapshots, err := GetBackupVolumeSnapshots(ctx, cli, "default", backup.Name)
+		Expect(err).ToNot(HaveOccurred())
+		for i := range snapshots {
+			snapshots[i].Status = &storagesnapshotv1.VolumeSnapshotStatus{ReadyToUse: ptr.To(true)}
+			Expect(cli.Update(ctx, &snapshots[i])).To(Succeed())
+		}
+	}
+
+	It("emits a single span for each phase of the backup", func(ctx context.Context) {
+		executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+			FenceInstance(true).
+			TracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))).
+			Build()
+
+		By("fencing the instance and taking the snapshots", func() {
+			_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(getSpanNames()).To(Equal([]string{"volumesnapshot.fence", "volumesnapshot.create"}))
+		})
+
+		By("waiting for the snapshots to be ready", func() {
+			res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).ToNot(BeNil())
+			Expect(getSpanNames()).To(BeEmpty())
+		})
+
+		By("unfencing the instance once the snapshots are ready", func() {
+			markSnapshotsReady(ctx)
+			res, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res).To(BeNil())
+			Expect(getSpanNames()).To(Equal([]string{"volumesnapshot.wait", "volumesnapshot.unfence"}))
+		})
+	})
+
+	It("describes the backup in the attributes of the spans", func(ctx context.Context) {
+		executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+			FenceInstance(false).
+			TracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))).
+			Build()
+
+		_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).ToNot(HaveOccurred())
+
+		spans := exporter.GetSpans()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Name).To(Equal("volumesnapshot.create"))
+		Expect(spans[0].Attributes).To(ContainElements(
+			attribute.String("cnpg.backup.name", "backup-example"),
+			attribute.String("cnpg.backup.method", string(apiv1.BackupMethodVolumeSnapshot)),
+			attribute.String("cnpg.cluster.name", "cluster-example"),
+			attribute.Int("cnpg.backup.pvcCount", 1),
+		))
+	})
+
+	It("emits no span by default", func(ctx context.Context) {
+		executor := NewExecutorBuilder(cli, record.NewFakeRecorder(100)).
+			FenceInstance(true).
+			Build()
+
+		_, err := executor.Execute(ctx, cluster, backup, targetPod, pvcs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(exporter.GetSpans()).To(BeEmpty())
+	})
+})
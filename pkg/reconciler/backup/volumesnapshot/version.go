@@ -0,0 +1,191 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/specs"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// ErrIncompatiblePostgresVersion is raised when a volume snapshot was
+// taken with a PostgreSQL version that can't be restored by the image
+// of the cluster being bootstrapped
+var ErrIncompatiblePostgresVersion = errors.New("incompatible PostgreSQL version")
+
+// postgresVersion is a PostgreSQL version, as detected from the tag of an
+// image. Only the versions starting from PostgreSQL 10 are supported
+type postgresVersion struct {
+	major int
+
+	// minor is nil when the tag of the image doesn't specify it
+	minor *int
+}
+
+// String implements fmt.Stringer
+func (version postgresVersion) String() string {
+	if version.minor == nil {
+		return strconv.Itoa(version.major)
+	}
+	return fmt.Sprintf("%d.%d", version.major, *version.minor)
+}
+
+// getImagePostgresVersion detects the PostgreSQL version from the tag of
+// the passed image
+func getImagePostgresVersion(imageName string) (postgresVersion, error) {
+	tag := utils.GetImageTag(imageName)
+	version, err := postgres.GetPostgresVersionFromTag(tag)
+	if err != nil {
+		return postgresVersion{}, err
+	}
+	if version < 100000 {
+		return postgresVersion{}, fmt.Errorf("unsupported PostgreSQL version in tag %s", tag)
+	}
+
+	result := postgresVersion{major: version / 10000}
+	// a tag like "16-bookworm" doesn't tell the minor version
+	if versionOnly, _, _ := strings.Cut(tag, "-"); strings.Contains(versionOnly, ".") {
+		minor := version % 100
+		result.minor = &minor
+	}
+	return result, nil
+}
+
+// getPodPostgresVersion detects the PostgreSQL version from the image the
+// PostgreSQL container of the passed pod is running
+func getPodPostgresVersion(pod *corev1.Pod) (postgresVersion, error) {
+	imageName, err := specs.GetPostgresImageName(*pod)
+	if err != nil {
+		return postgresVersion{}, err
+	}
+	return getImagePostgresVersion(imageName)
+}
+
+// setSnapshotPostgresVersion records the passed PostgreSQL version in the
+// volume snapshot. The minor version is recorded only when known
+func setSnapshotPostgresVersion(snapshot *storagesnapshotv1.VolumeSnapshot, version postgresVersion) {
+	snapshot.Annotations[utils.PostgresMajorVersionAnnotationName] = strconv.Itoa(version.major)
+	if version.minor != nil {
+		snapshot.Annotations[utils.PostgresMinorVersionAnnotationName] = strconv.Itoa(*version.minor)
+	}
+}
+
+// getSnapshotPostgresVersion returns the PostgreSQL version recorded in the
+// volume snapshot. False is returned when the snapshot doesn't carry this
+// information
+func getSnapshotPostgresVersion(snapshot *storagesnapshotv1.VolumeSnapshot) (postgresVersion, bool, error) {
+	rawMajor, hasMajor := snapshot.Annotations[utils.PostgresMajorVersionAnnotationName]
+	if !hasMajor {
+		return postgresVersion{}, false, nil
+	}
+
+	major, err := strconv.Atoi(rawMajor)
+	if err != nil {
+		return postgresVersion{}, false, fmt.Errorf(
+			"while parsing the PostgreSQL major version of volume snapshot %s: %w", snapshot.Name, err)
+	}
+	version := postgresVersion{major: major}
+
+	if rawMinor, hasMinor := snapshot.Annotations[utils.PostgresMinorVersionAnnotationName]; hasMinor {
+		minor, err := strconv.Atoi(rawMinor)
+		if err != nil {
+			return postgresVersion{}, false, fmt.Errorf(
+				"while parsing the PostgreSQL minor version of volume snapshot %s: %w", snapshot.Name, err)
+		}
+		version.minor = &minor
+	}
+
+	return version, true, nil
+}
+
+// checkRestorableVersion checks if data written by the source PostgreSQL
+// version can be restored by the target one: the major version must be the
+// same, and the minor version can't go backwards. The minor versions are
+// compared only when both of them are known
+func checkRestorableVersion(sourceVersion, targetVersion postgresVersion) error {
+	switch {
+	case sourceVersion.major != targetVersion.major:
+		return fmt.Errorf("%w: cannot restore data from PostgreSQL %s with PostgreSQL %s, "+
+			"the major version is different", ErrIncompatiblePostgresVersion,
+			sourceVersion, targetVersion)
+	case sourceVersion.minor != nil && targetVersion.minor != nil &&
+		*targetVersion.minor < *sourceVersion.minor:
+		return fmt.Errorf("%w: cannot restore data from PostgreSQL %s with PostgreSQL %s, "+
+			"minor version downgrades are not allowed", ErrIncompatiblePostgresVersion,
+			sourceVersion, targetVersion)
+	}
+
+	return nil
+}
+
+// VerifyRecoveryPostgresVersion checks that the PostgreSQL version recorded
+// in the PG_DATA volume snapshot used to bootstrap the passed cluster can be
+// restored by the image of the cluster. Snapshots without this information,
+// like the ones taken by older versions of the operator, are accepted, and
+// so are images whose version can't be detected
+func VerifyRecoveryPostgresVersion(
+	ctx context.Context,
+	cli client.Client,
+	cluster *apiv1.Cluster,
+) error {
+	if cluster.Spec.Bootstrap == nil ||
+		cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.VolumeSnapshots == nil ||
+		cluster.Spec.Bootstrap.Recovery.VolumeSnapshots.Storage.Kind != "VolumeSnapshot" {
+		return nil
+	}
+
+	targetVersion, err := getImagePostgresVersion(cluster.GetImageName())
+	if err != nil {
+		return nil
+	}
+
+	var snapshot storagesnapshotv1.VolumeSnapshot
+	err = cli.Get(
+		ctx,
+		client.ObjectKey{
+			Namespace: cluster.Namespace,
+			Name:      cluster.Spec.Bootstrap.Recovery.VolumeSnapshots.Storage.Name,
+		},
+		&snapshot,
+	)
+	if apierrs.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	sourceVersion, ok, err := getSnapshotPostgresVersion(&snapshot)
+	if err != nil || !ok {
+		return err
+	}
+
+	return checkRestorableVersion(sourceVersion, targetVersion)
+}
@@ -0,0 +1,182 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/specs"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PostgreSQL version of the snapshots", func() {
+	newSnapshot := func(annotations map[string]string) *storagesnapshotv1.VolumeSnapshot {
+		return &storagesnapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "backup-data",
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+		}
+	}
+
+	newCluster := func(imageName string) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-restore", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				ImageName: imageName,
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						VolumeSnapshots: &apiv1.DataSource{
+							Storage: corev1.TypedLocalObjectReference{
+								APIGroup: ptr.To(storagesnapshotv1.GroupName),
+								Kind:     "VolumeSnapshot",
+								Name:     "backup-data",
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	verify := func(ctx context.Context, snapshot *storagesnapshotv1.VolumeSnapshot, imageName string) error {
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(snapshot).
+			Build()
+		return VerifyRecoveryPostgresVersion(ctx, cli, newCluster(imageName))
+	}
+
+	It("records the major and minor version", func() {
+		snapshot := newSnapshot(map[string]string{})
+		setSnapshotPostgresVersion(snapshot, postgresVersion{major: 16, minor: ptr.To(2)})
+		Expect(snapshot.Annotations).To(HaveKeyWithValue(utils.PostgresMajorVersionAnnotationName, "16"))
+		Expect(snapshot.Annotations).To(HaveKeyWithValue(utils.PostgresMinorVersionAnnotationName, "2"))
+
+		version, ok, err := getSnapshotPostgresVersion(snapshot)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(version.String()).To(Equal("16.2"))
+	})
+
+	It("records only the major version when the minor one is unknown", func() {
+		snapshot := newSnapshot(map[string]string{})
+		setSnapshotPostgresVersion(snapshot, postgresVersion{major: 16})
+		Expect(snapshot.Annotations).To(HaveKeyWithValue(utils.PostgresMajorVersionAnnotationName, "16"))
+		Expect(snapshot.Annotations).ToNot(HaveKey(utils.PostgresMinorVersionAnnotationName))
+
+		version, ok, err := getSnapshotPostgresVersion(snapshot)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(version.String()).To(Equal("16"))
+	})
+
+	It("detects the version from the image the pod is running", func() {
+		pod := &corev1.Pod{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: specs.PostgresContainerName, Image: "ghcr.io/cloudnative-pg/postgresql:15.4-3"},
+				},
+			},
+		}
+		version, err := getPodPostgresVersion(pod)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version.String()).To(Equal("15.4"))
+
+		pod.Spec.Containers[0].Image = "ghcr.io/cloudnative-pg/postgresql:15-bookworm"
+		version, err = getPodPostgresVersion(pod)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version.String()).To(Equal("15"))
+	})
+
+	It("refuses malformed versions", func() {
+		_, _, err := getSnapshotPostgresVersion(newSnapshot(map[string]string{
+			utils.PostgresMajorVersionAnnotationName: "sixteen",
+			utils.PostgresMinorVersionAnnotationName: "2",
+		}))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("allows the same version and minor upgrades", func(ctx context.Context) {
+		snapshot := newSnapshot(map[string]string{
+			utils.PostgresMajorVersionAnnotationName: "16",
+			utils.PostgresMinorVersionAnnotationName: "2",
+		})
+		Expect(verify(ctx, snapshot, "ghcr.io/cloudnative-pg/postgresql:16.2")).To(Succeed())
+		Expect(verify(ctx, snapshot, "ghcr.io/cloudnative-pg/postgresql:16.4")).To(Succeed())
+	})
+
+	It("refuses minor downgrades", func(ctx context.Context) {
+		snapshot := newSnapshot(map[string]string{
+			utils.PostgresMajorVersionAnnotationName: "16",
+			utils.PostgresMinorVersionAnnotationName: "4",
+		})
+		err := verify(ctx, snapshot, "ghcr.io/cloudnative-pg/postgresql:16.2")
+		Expect(err).To(MatchError(ErrIncompatiblePostgresVersion))
+		Expect(err.Error()).To(ContainSubstring("downgrades"))
+	})
+
+	It("refuses a different major version", func(ctx context.Context) {
+		snapshot := newSnapshot(map[string]string{
+			utils.PostgresMajorVersionAnnotationName: "15",
+			utils.PostgresMinorVersionAnnotationName: "6",
+		})
+		Expect(verify(ctx, snapshot, "ghcr.io/cloudnative-pg/postgresql:16.2")).
+			To(MatchError(ErrIncompatiblePostgresVersion))
+	})
+
+	It("accepts snapshots without a recorded version", func(ctx context.Context) {
+		Expect(verify(ctx, newSnapshot(nil), "ghcr.io/cloudnative-pg/postgresql:16.2")).To(Succeed())
+	})
+
+	It("doesn't compare the minor versions when the image doesn't tell it", func(ctx context.Context) {
+		snapshot := newSnapshot(map[string]string{
+			utils.PostgresMajorVersionAnnotationName: "16",
+			utils.PostgresMinorVersionAnnotationName: "4",
+		})
+		Expect(verify(ctx, snapshot, "ghcr.io/cloudnative-pg/postgresql:16")).To(Succeed())
+		Expect(verify(ctx, snapshot, "ghcr.io/cloudnative-pg/postgresql:15")).
+			To(MatchError(ErrIncompatiblePostgresVersion))
+	})
+
+	It("doesn't compare the minor versions when the snapshot doesn't tell it", func(ctx context.Context) {
+		snapshot := newSnapshot(map[string]string{
+			utils.PostgresMajorVersionAnnotationName: "16",
+		})
+		Expect(verify(ctx, snapshot, "ghcr.io/cloudnative-pg/postgresql:16.1")).To(Succeed())
+	})
+
+	It("accepts images whose version can't be detected", func(ctx context.Context) {
+		snapshot := newSnapshot(map[string]string{
+			utils.PostgresMajorVersionAnnotationName: "16",
+			utils.PostgresMinorVersionAnnotationName: "4",
+		})
+		Expect(verify(ctx, snapshot, "ghcr.io/cloudnative-pg/postgresql:latest")).To(Succeed())
+	})
+})
@@ -0,0 +1,193 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// pgControldataRedoLocationKey is the key of the pg_controldata output
+// containing the LSN from which the recovery starts
+const pgControldataRedoLocationKey = "Latest checkpoint's REDO location"
+
+// lastArchivedWALBackoff is the backoff used to wait for a primary
+// instance which has just been unfenced to report the status of the
+// WAL archive, as PostgreSQL is not running while the instance is fenced
+var lastArchivedWALBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Steps:    5,
+}
+
+// setLastArchivedLSN records inside the backup status the LSN where
+// the WAL archive ended when the snapshots were completed. When the
+// primary instance has just been unfenced, its status is requested
+// until PostgreSQL is running again. This is done on a best-effort
+// basis: failing to detect it will not make the backup fail, and is
+// reported through a warning event
+func (se *Reconciler) setLastArchivedLSN(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	primaryUnfenced bool,
+) {
+	contextLogger := log.FromContext(ctx)
+
+	if !cluster.Spec.Backup.IsBarmanBackupConfigured() {
+		backup.Status.BackupSnapshotStatus.LastArchivedLSN = apiv1.BackupSnapshotNoWALArchive
+		return
+	}
+
+	// the WAL archive status is exposed by the instance manager, which is
+	// not reachable when the primary runs in a different Kubernetes cluster
+	if se.remote {
+		contextLogger.Info("Cannot detect the WAL archive status of a remote instance")
+		return
+	}
+
+	backoff := wait.Backoff{Steps: 1}
+	if primaryUnfenced {
+		backoff = lastArchivedWALBackoff
+	}
+
+	var lastArchivedWAL string
+	err := retry.OnError(backoff, func(error) bool { return ctx.Err() == nil }, func() (err error) {
+		lastArchivedWAL, err = se.getLastArchivedWAL(ctx, cluster)
+		return err
+	})
+	if err != nil {
+		contextLogger.Info("Cannot detect the WAL archive status from the primary instance",
+			"primary", cluster.Status.CurrentPrimary, "err", err.Error())
+		se.recorder.Eventf(backup, "Warning", "LastArchivedLSN",
+			"Cannot detect the end of the WAL archive: %v", err)
+		return
+	}
+
+	lastArchivedLSN, err := getLastArchivedLSN(cluster, lastArchivedWAL)
+	if err != nil {
+		contextLogger.Error(err, "while detecting the last archived LSN")
+		se.recorder.Eventf(backup, "Warning", "LastArchivedLSN",
+			"Cannot detect the end of the WAL archive: %v", err)
+		return
+	}
+
+	backup.Status.BackupSnapshotStatus.LastArchivedLSN = lastArchivedLSN
+}
+
+// getLastArchivedWAL gets the name of the last WAL file archived by the
+// primary instance, or by the designated primary in a replica cluster
+func (se *Reconciler) getLastArchivedWAL(ctx context.Context, cluster *apiv1.Cluster) (string, error) {
+	var primaryPod corev1.Pod
+	if err := se.cli.Get(
+		ctx,
+		types.NamespacedName{Name: cluster.Status.CurrentPrimary, Namespace: cluster.Namespace},
+		&primaryPod,
+	); err != nil {
+		return "", fmt.Errorf("while getting the primary Pod: %w", err)
+	}
+
+	statusList := se.instanceStatusGetter(
+		ctx,
+		corev1.PodList{Items: []corev1.Pod{primaryPod}},
+	)
+	if len(statusList.Items) == 0 {
+		return "", fmt.Errorf("no status reported by the primary instance %s", primaryPod.Name)
+	}
+	if statusList.Items[0].Error != nil {
+		return "", fmt.Errorf("while getting the status of the primary instance %s: %w",
+			primaryPod.Name, statusList.Items[0].Error)
+	}
+
+	return statusList.Items[0].LastArchivedWAL, nil
+}
+
+// getLastArchivedLSN computes the LSN where the WAL archive ends, given the
+// name of the last WAL file that has been archived
+func getLastArchivedLSN(cluster *apiv1.Cluster, lastArchivedWAL string) (string, error) {
+	if lastArchivedWAL == "" {
+		// Nothing has been archived yet
+		return "", nil
+	}
+
+	segment, err := postgres.SegmentFromName(lastArchivedWAL)
+	if err != nil {
+		return "", fmt.Errorf("while parsing the last archived WAL %s: %w", lastArchivedWAL, err)
+	}
+
+	return string(segment.EndLSN(getWALSegmentSize(cluster))), nil
+}
+
+// getWALSegmentSize gets the size of the WAL segments of the cluster,
+// or nil if the PostgreSQL default is being used
+func getWALSegmentSize(cluster *apiv1.Cluster) *int64 {
+	if cluster.Spec.Bootstrap == nil ||
+		cluster.Spec.Bootstrap.InitDB == nil ||
+		cluster.Spec.Bootstrap.InitDB.WalSegmentSize == 0 {
+		return nil
+	}
+
+	walSegmentSize := int64(cluster.Spec.Bootstrap.InitDB.WalSegmentSize) * 1024 * 1024
+	return &walSegmentSize
+}
+
+// setBeginLSN records inside the backup status the LSN from which a recovery
+// from the snapshots starts, that is the REDO location of the latest
+// checkpoint, as reported by the pg_controldata of the PG_DATA snapshot
+func setBeginLSN(backup *apiv1.Backup, snapshots []storagesnapshotv1.VolumeSnapshot) {
+	for i := range snapshots {
+		if utils.PVCRole(snapshots[i].Labels[utils.PvcRoleLabelName]) != utils.PVCRolePgData {
+			continue
+		}
+
+		if lsn := getCheckpointRedoLocation(snapshots[i].Annotations[utils.PgControldataAnnotationName]); lsn != "" {
+			backup.Status.BeginLSN = lsn
+		}
+	}
+}
+
+// getCheckpointRedoLocation extracts the REDO location of the latest
+// checkpoint from the output of pg_controldata
+func getCheckpointRedoLocation(pgControldata string) string {
+	return getPgControldataValue(pgControldata, pgControldataRedoLocationKey)
+}
+
+// getPgControldataValue extracts the value of the passed key from
+// the output of pg_controldata
+func getPgControldataValue(pgControldata, key string) string {
+	for _, line := range strings.Split(pgControldata, "\n") {
+		lineKey, value, found := strings.Cut(line, ":")
+		if found && strings.TrimSpace(lineKey) == key {
+			return strings.TrimSpace(value)
+		}
+	}
+
+	return ""
+}
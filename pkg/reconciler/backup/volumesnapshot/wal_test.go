@@ -0,0 +1,154 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package volumesnapshot
+
+import (
+	"context"
+	"errors"
+
+	storagesnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WAL archive status", func() {
+	var cluster *apiv1.Cluster
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-example",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					VolumeSnapshot: &apiv1.VolumeSnapshotConfiguration{},
+					BarmanObjectStore: &apiv1.BarmanObjectStoreConfiguration{
+						BarmanCredentials: apiv1.BarmanCredentials{
+							AWS: &apiv1.S3Credentials{},
+						},
+					},
+				},
+			},
+		}
+	})
+
+	It("records that there is no WAL archive when archiving is disabled", func(ctx context.Context) {
+		cluster.Spec.Backup.BarmanObjectStore = nil
+		backup := &apiv1.Backup{}
+
+		reconciler := &Reconciler{}
+		reconciler.setLastArchivedLSN(ctx, cluster, backup, false)
+		Expect(backup.Status.BackupSnapshotStatus.LastArchivedLSN).To(Equal(apiv1.BackupSnapshotNoWALArchive))
+	})
+
+	It("reports the failure to detect the end of the WAL archive", func(ctx context.Context) {
+		cluster.Status.CurrentPrimary = "cluster-example-1"
+		backup := &apiv1.Backup{}
+		recorder := record.NewFakeRecorder(10)
+
+		reconciler := &Reconciler{
+			cli: fake.NewClientBuilder().
+				WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+				WithObjects(&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster-example-1", Namespace: "default"},
+				}).
+				Build(),
+			recorder: recorder,
+			instanceStatusGetter: func(_ context.Context, pods corev1.PodList) postgres.PostgresqlStatusList {
+				return postgres.PostgresqlStatusList{Items: []postgres.PostgresqlStatus{
+					{Pod: &pods.Items[0], Error: errors.New("instance is fenced")},
+				}}
+			},
+		}
+		reconciler.setLastArchivedLSN(ctx, cluster, backup, false)
+		Expect(backup.Status.BackupSnapshotStatus.LastArchivedLSN).To(BeEmpty())
+		Expect(recorder.Events).To(Receive(ContainSubstring("LastArchivedLSN")))
+	})
+
+	It("computes the end of the WAL archive when archiving is enabled", func() {
+		lsn, err := getLastArchivedLSN(cluster, "000000010000000200000003")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lsn).To(Equal("2/4000000"))
+	})
+
+	It("takes into account a custom WAL segment size", func() {
+		cluster.Spec.Bootstrap = &apiv1.BootstrapConfiguration{
+			InitDB: &apiv1.BootstrapInitDB{
+				WalSegmentSize: 64,
+			},
+		}
+
+		lsn, err := getLastArchivedLSN(cluster, "000000010000000200000003")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lsn).To(Equal("2/10000000"))
+	})
+
+	It("records nothing when no WAL has been archived yet", func() {
+		lsn, err := getLastArchivedLSN(cluster, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lsn).To(BeEmpty())
+	})
+
+	It("fails when the last archived WAL is not a WAL segment", func() {
+		_, err := getLastArchivedLSN(cluster, "00000002.history")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Snapshot begin LSN", func() {
+	const pgControldata = "pg_control version number:            1300\n" +
+		"Latest checkpoint location:           0/3000060\n" +
+		"Latest checkpoint's REDO location:    0/3000028\n" +
+		"Latest checkpoint's REDO WAL file:    000000010000000000000003\n"
+
+	It("extracts the REDO location from the pg_controldata output", func() {
+		Expect(getCheckpointRedoLocation(pgControldata)).To(Equal("0/3000028"))
+		Expect(getCheckpointRedoLocation("")).To(BeEmpty())
+	})
+
+	It("records the REDO location of the PG_DATA snapshot", func() {
+		snapshots := []storagesnapshotv1.VolumeSnapshot{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{utils.PvcRoleLabelName: string(utils.PVCRolePgWal)},
+					Annotations: map[string]string{utils.PgControldataAnnotationName: "garbage"},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{utils.PvcRoleLabelName: string(utils.PVCRolePgData)},
+					Annotations: map[string]string{utils.PgControldataAnnotationName: pgControldata},
+				},
+			},
+		}
+
+		backup := &apiv1.Backup{}
+		setBeginLSN(backup, snapshots)
+		Expect(backup.Status.BeginLSN).To(Equal("0/3000028"))
+	})
+})
@@ -317,3 +317,36 @@ func GetInstancePVCs(
 
 	return pvcs, nil
 }
+
+// GetPodEphemeralPVCs gets the PVCs backing the generic ephemeral volumes
+// of a given Pod. Kubernetes names these PVCs after the Pod and the volume
+func GetPodEphemeralPVCs(
+	ctx context.Context,
+	cli client.Client,
+	pod *corev1.Pod,
+) ([]corev1.PersistentVolumeClaim, error) {
+	var pvcs []corev1.PersistentVolumeClaim
+
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Ephemeral == nil {
+			continue
+		}
+
+		var pvc corev1.PersistentVolumeClaim
+		err := cli.Get(
+			ctx,
+			types.NamespacedName{Name: pod.Name + "-" + volume.Name, Namespace: pod.Namespace},
+			&pvc,
+		)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		pvcs = append(pvcs, pvc)
+	}
+
+	return pvcs, nil
+}
@@ -22,8 +22,10 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	schemeBuilder "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/specs"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
 
@@ -108,3 +110,71 @@ var _ = Describe("PVCs used by instance", func() {
 		Expect(res).To(BeFalse())
 	})
 })
+
+var _ = Describe("Generic ephemeral volumes PVCs", func() {
+	const namespace = "default"
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cluster-example-1",
+			Namespace: namespace,
+		},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{
+				{
+					Name: "pgdata",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: "cluster-example-1",
+						},
+					},
+				},
+				{
+					Name: "pg-wal",
+					VolumeSource: corev1.VolumeSource{
+						Ephemeral: &corev1.EphemeralVolumeSource{},
+					},
+				},
+			},
+		},
+	}
+
+	It("finds the PVCs backing the ephemeral volumes of the Pod", func(ctx context.Context) {
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(
+				&corev1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "cluster-example-1",
+						Namespace: namespace,
+					},
+				},
+				&corev1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "cluster-example-1-pg-wal",
+						Namespace: namespace,
+						Labels: map[string]string{
+							utils.PvcRoleLabelName: string(utils.PVCRolePgWal),
+						},
+					},
+				},
+			).
+			Build()
+
+		pvcs, err := GetPodEphemeralPVCs(ctx, cli, pod)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvcs).To(HaveLen(1))
+		Expect(pvcs[0].Name).To(Equal("cluster-example-1-pg-wal"))
+		Expect(pvcs[0].Labels).To(HaveKeyWithValue(utils.PvcRoleLabelName, string(utils.PVCRolePgWal)))
+	})
+
+	It("ignores the ephemeral volumes whose PVC has not been created yet", func(ctx context.Context) {
+		cli := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			Build()
+
+		pvcs, err := GetPodEphemeralPVCs(ctx, cli, pod)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pvcs).To(BeEmpty())
+	})
+})
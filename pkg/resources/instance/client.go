@@ -17,6 +17,7 @@ limitations under the License.
 package instance
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -31,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
 
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/url"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
@@ -210,6 +212,259 @@ func (r *StatusClient) GetPgControlDataFromInstance(
 	return result.Data, result.Error
 }
 
+// GetInstalledExtensionsFromInstance obtains the list of the installed extensions
+// from the instance HTTP endpoint
+func (r *StatusClient) GetInstalledExtensionsFromInstance(
+	ctx context.Context,
+	pod *corev1.Pod,
+) ([]apiv1.InstalledExtension, error) {
+	contextLogger := log.FromContext(ctx)
+
+	httpURL := url.Build(pod.Status.PodIP, url.PathPgExtensions, url.StatusPort)
+	req, err := http.NewRequestWithContext(ctx, "GET", httpURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			contextLogger.Error(err, "while closing body")
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var result []apiv1.InstalledExtension
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetSourceReplicationFromInstance obtains, from the HTTP endpoint of the
+// designated primary of a replica cluster, its position as seen by the source
+func (r *StatusClient) GetSourceReplicationFromInstance(
+	ctx context.Context,
+	pod *corev1.Pod,
+) (*apiv1.SourceReplicationStatus, error) {
+	contextLogger := log.FromContext(ctx)
+
+	httpURL := url.Build(pod.Status.PodIP, url.PathPgSourceReplication, url.StatusPort)
+	req, err := http.NewRequestWithContext(ctx, "GET", httpURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			contextLogger.Error(err, "while closing body")
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var result apiv1.SourceReplicationStatus
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetTransactionRateFromInstance obtains the number of transactions per second
+// executed by the instance from its HTTP endpoint
+func (r *StatusClient) GetTransactionRateFromInstance(
+	ctx context.Context,
+	pod *corev1.Pod,
+) (float64, error) {
+	contextLogger := log.FromContext(ctx)
+
+	httpURL := url.Build(pod.Status.PodIP, url.PathPgTransactionRate, url.StatusPort)
+	req, err := http.NewRequestWithContext(ctx, "GET", httpURL, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			contextLogger.Error(err, "while closing body")
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.StatusCode != 200 {
+		return 0, &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var result float64
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+// CleanTemporaryFilesInInstance requests a fenced instance to remove
+// its temporary files through its HTTP endpoint
+func (r *StatusClient) CleanTemporaryFilesInInstance(
+	ctx context.Context,
+	pod *corev1.Pod,
+) error {
+	contextLogger := log.FromContext(ctx)
+
+	httpURL := url.Build(pod.Status.PodIP, url.PathPgCleanTemporaryFiles, url.StatusPort)
+	req, err := http.NewRequestWithContext(ctx, "POST", httpURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			contextLogger.Error(err, "while closing body")
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return nil
+}
+
+// GetOnlineBackupStatusFromInstance gets the status of the online backup
+// executed by the instance from its HTTP endpoint, or nil if there is none
+func (r *StatusClient) GetOnlineBackupStatusFromInstance(
+	ctx context.Context,
+	pod *corev1.Pod,
+) (*postgres.OnlineBackupStatus, error) {
+	contextLogger := log.FromContext(ctx)
+
+	httpURL := url.Build(pod.Status.PodIP, url.PathPgOnlineBackup, url.StatusPort)
+	req, err := http.NewRequestWithContext(ctx, "GET", httpURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			contextLogger.Error(err, "while closing body")
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case 200:
+	case 404:
+		return nil, nil
+	default:
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var result postgres.OnlineBackupStatus
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// RequestOnlineBackupInInstance asks the instance, through its HTTP
+// endpoint, to start or stop an online backup
+func (r *StatusClient) RequestOnlineBackupInInstance(
+	ctx context.Context,
+	pod *corev1.Pod,
+	request postgres.OnlineBackupRequest,
+) error {
+	contextLogger := log.FromContext(ctx)
+
+	rawRequest, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	httpURL := url.Build(pod.Status.PodIP, url.PathPgOnlineBackup, url.StatusPort)
+	req, err := http.NewRequestWithContext(ctx, "POST", httpURL, bytes.NewReader(rawRequest))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			contextLogger.Error(err, "while closing body")
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return nil
+}
+
 // rawInstanceStatusRequest retrieves the status of PostgreSQL pods via an HTTP request with GET method.
 func (r *StatusClient) rawInstanceStatusRequest(
 	ctx context.Context,
@@ -17,7 +17,9 @@ limitations under the License.
 package specs
 
 import (
+	"encoding/base64"
 	"fmt"
+	"strings"
 
 	"github.com/kballard/go-shellquote"
 	batchv1 "k8s.io/api/batch/v1"
@@ -135,8 +137,18 @@ func buildInitDBFlags(cluster apiv1.Cluster) (initCommand []string) {
 	return initCommand
 }
 
-// CreatePrimaryJobViaRestoreSnapshot creates a new primary instance in a Pod, restoring from a volumeSnapshot
-func CreatePrimaryJobViaRestoreSnapshot(cluster apiv1.Cluster, nodeSerial int, backup *apiv1.Backup) *batchv1.Job {
+// CreatePrimaryJobViaRestoreSnapshot creates a new primary instance in a Pod, restoring from a volumeSnapshot.
+// The required extensions are the ones installed in the snapshotted cluster, and are checked against
+// the ones available in the image. The backup label and the tablespace map, set when the snapshot has
+// been taken online, are written in the restored data directory
+func CreatePrimaryJobViaRestoreSnapshot(
+	cluster apiv1.Cluster,
+	nodeSerial int,
+	backup *apiv1.Backup,
+	requiredExtensions []string,
+	backupLabel string,
+	tablespaceMap string,
+) *batchv1.Job {
 	initCommand := []string{
 		"/controller/manager",
 		"instance",
@@ -144,6 +156,15 @@ func CreatePrimaryJobViaRestoreSnapshot(cluster apiv1.Cluster, nodeSerial int, b
 	}
 
 	initCommand = append(initCommand, buildCommonInitJobFlags(cluster)...)
+	if len(requiredExtensions) > 0 {
+		initCommand = append(initCommand, "--required-extensions", strings.Join(requiredExtensions, ","))
+	}
+	if backupLabel != "" {
+		initCommand = append(initCommand, "--backup-label", base64.StdEncoding.EncodeToString([]byte(backupLabel)))
+	}
+	if tablespaceMap != "" {
+		initCommand = append(initCommand, "--tablespace-map", base64.StdEncoding.EncodeToString([]byte(tablespaceMap)))
+	}
 
 	job := createPrimaryJob(cluster, nodeSerial, jobRoleSnapshotRecovery, initCommand)
 
@@ -164,4 +164,51 @@ var _ = Describe("Job created via InitDB", func() {
 		Expect(job.Spec.Template.Spec.Containers[0].Command).Should(ContainElement("testPostInitApplicationSql"))
 		Expect(job.Spec.Template.Spec.Containers[0].Command).Should(ContainElement(postInitApplicationSQLRefsFolder))
 	})
+
+	It("passes the required extensions to the snapshot recovery job", func() {
+		cluster := apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-restore",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						VolumeSnapshots: &apiv1.DataSource{},
+					},
+				},
+			},
+		}
+
+		job := CreatePrimaryJobViaRestoreSnapshot(cluster, 1, nil, []string{"pgcrypto", "postgis"}, "", "")
+		Expect(job.Spec.Template.Spec.Containers[0].Command).Should(
+			ContainElements("--required-extensions", "pgcrypto,postgis"))
+
+		job = CreatePrimaryJobViaRestoreSnapshot(cluster, 1, nil, nil, "", "")
+		Expect(job.Spec.Template.Spec.Containers[0].Command).ShouldNot(ContainElement("--required-extensions"))
+	})
+
+	It("passes the backup label of a snapshot taken online to the restore job", func() {
+		cluster := apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cluster-restore",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						VolumeSnapshots: &apiv1.DataSource{},
+					},
+				},
+			},
+		}
+
+		job := CreatePrimaryJobViaRestoreSnapshot(cluster, 1, nil, nil, "START WAL LOCATION: 0/2000028", "")
+		Expect(job.Spec.Template.Spec.Containers[0].Command).Should(
+			ContainElements("--backup-label", "U1RBUlQgV0FMIExPQ0FUSU9OOiAwLzIwMDAwMjg="))
+		Expect(job.Spec.Template.Spec.Containers[0].Command).ShouldNot(ContainElement("--tablespace-map"))
+
+		job = CreatePrimaryJobViaRestoreSnapshot(cluster, 1, nil, nil, "", "")
+		Expect(job.Spec.Template.Spec.Containers[0].Command).ShouldNot(ContainElement("--backup-label"))
+	})
 })
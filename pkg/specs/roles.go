@@ -168,21 +168,10 @@ func externalClusterSecrets(cluster apiv1.Cluster) []string {
 	var result []string
 
 	for _, server := range cluster.Spec.ExternalClusters {
-		if server.SSLCert != nil {
-			result = append(result,
-				server.SSLCert.Name)
-		}
-		if server.SSLRootCert != nil {
-			result = append(result,
-				server.SSLRootCert.Name)
-		}
-		if server.SSLKey != nil {
-			result = append(result,
-				server.SSLKey.Name)
-		}
-		if server.Password != nil {
-			result = append(result,
-				server.Password.Name)
+		// the permission to read the connection secrets living in
+		// another namespace is granted by the user
+		if server.GetSecretsNamespace(cluster.Namespace) == cluster.Namespace {
+			result = append(result, externalClusterConnectionSecrets(server)...)
 		}
 		if barmanObjStore := server.BarmanObjectStore; barmanObjStore != nil {
 			result = append(
@@ -203,6 +192,31 @@ func externalClusterSecrets(cluster apiv1.Cluster) []string {
 	return result
 }
 
+// externalClusterConnectionSecrets returns the secrets needed to connect
+// to the passed external cluster
+func externalClusterConnectionSecrets(server apiv1.ExternalCluster) []string {
+	var result []string
+
+	if server.SSLCert != nil {
+		result = append(result,
+			server.SSLCert.Name)
+	}
+	if server.SSLRootCert != nil {
+		result = append(result,
+			server.SSLRootCert.Name)
+	}
+	if server.SSLKey != nil {
+		result = append(result,
+			server.SSLKey.Name)
+	}
+	if server.Password != nil {
+		result = append(result,
+			server.Password.Name)
+	}
+
+	return result
+}
+
 func backupSecrets(cluster apiv1.Cluster, backupOrigin *apiv1.Backup) []string {
 	var result []string
 
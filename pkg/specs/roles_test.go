@@ -183,6 +183,18 @@ var _ = Describe("Roles", func() {
 			"testPassword",
 		))
 	})
+
+	It("doesn't contain the secrets of the external clusters living in another namespace", func() {
+		crossNamespaceCluster := cluster.DeepCopy()
+		crossNamespaceCluster.Spec.ExternalClusters[0].SecretsNamespace = "source"
+		serviceAccount := CreateRole(*crossNamespaceCluster, nil)
+		Expect(serviceAccount.Rules[1].ResourceNames).ToNot(ContainElements(
+			"testSSLCert",
+			"testSSLRootCert",
+			"testSSLKey",
+			"testPassword",
+		))
+	})
 })
 
 var _ = Describe("Secrets", func() {
@@ -42,6 +42,14 @@ var (
 	// ErrorSingleInstanceUnfencing is emitted when unfencing a single instance
 	// while all the cluster is fenced
 	ErrorSingleInstanceUnfencing = errors.New("unfencing an instance while the whole cluster is fenced is not supported")
+
+	// ErrorInstanceNotFencedByBackup is emitted when removing the fencing requested
+	// by a backup from an instance that has been fenced for a different reason
+	ErrorInstanceNotFencedByBackup = errors.New("instance has not been fenced by this backup")
+
+	// ErrorBackupFenceOriginSyntax is emitted when the backupFenceOrigin annotation
+	// have an invalid syntax
+	ErrorBackupFenceOriginSyntax = errors.New("backupFenceOrigin annotation has invalid syntax")
 )
 
 const (
@@ -89,8 +97,32 @@ func SetFencedInstances(object *metav1.ObjectMeta, data *stringset.Data) error {
 }
 
 // AddFencedInstance adds the given server name to the FencedInstanceAnnotation annotation
-// returns an error if the instance was already fenced
+// returns an error if the instance was already fenced. When the instance was fenced
+// by a backup, the fencing is taken over, and it won't be removed together with
+// the backup
 func AddFencedInstance(serverName string, object *metav1.ObjectMeta) error {
+	fenceOrigins, err := GetBackupFenceOrigins(object.Annotations)
+	if err != nil {
+		return err
+	}
+
+	if _, fencedByBackup := fenceOrigins[serverName]; fencedByBackup {
+		delete(fenceOrigins, serverName)
+		return SetBackupFenceOrigins(object, fenceOrigins)
+	}
+
+	if serverName == FenceAllServers {
+		if err := SetBackupFenceOrigins(object, nil); err != nil {
+			return err
+		}
+	}
+
+	return addFencedInstance(serverName, object)
+}
+
+// addFencedInstance adds the given server name to the FencedInstanceAnnotation
+// annotation, returning an error if the instance was already fenced
+func addFencedInstance(serverName string, object *metav1.ObjectMeta) error {
 	fencedInstances, err := GetFencedInstances(object.Annotations)
 	if err != nil {
 		return err
@@ -135,3 +167,95 @@ func RemoveFencedInstance(serverName string, object *metav1.ObjectMeta) error {
 	fencedInstances.Delete(serverName)
 	return SetFencedInstances(object, fencedInstances)
 }
+
+// GetBackupFenceOrigins gets the map between the instances fenced by a backup
+// and the name of the backup that requested the fencing
+func GetBackupFenceOrigins(annotations map[string]string) (map[string]string, error) {
+	fenceOrigins, ok := annotations[BackupFenceOriginAnnotationName]
+	if !ok {
+		return map[string]string{}, nil
+	}
+
+	var result map[string]string
+	if err := json.Unmarshal([]byte(fenceOrigins), &result); err != nil {
+		return nil, ErrorBackupFenceOriginSyntax
+	}
+	if result == nil {
+		result = map[string]string{}
+	}
+
+	return result, nil
+}
+
+// SetBackupFenceOrigins sets the map between the instances fenced by a backup
+// and the name of the backup inside the annotations
+func SetBackupFenceOrigins(object *metav1.ObjectMeta, fenceOrigins map[string]string) error {
+	if len(fenceOrigins) == 0 {
+		delete(object.Annotations, BackupFenceOriginAnnotationName)
+		return nil
+	}
+
+	annotationValue, err := json.Marshal(fenceOrigins)
+	if err != nil {
+		return err
+	}
+	if object.Annotations == nil {
+		object.Annotations = make(map[string]string)
+	}
+	object.Annotations[BackupFenceOriginAnnotationName] = string(annotationValue)
+
+	return nil
+}
+
+// AddFencedInstanceForBackup returns a fencing function adding the given server
+// name to the FencedInstanceAnnotation annotation and recording the passed backup
+// as the origin of the fencing request
+func AddFencedInstanceForBackup(backupName string) func(string, *metav1.ObjectMeta) error {
+	return func(serverName string, object *metav1.ObjectMeta) error {
+		if err := addFencedInstance(serverName, object); err != nil {
+			return err
+		}
+
+		fenceOrigins, err := GetBackupFenceOrigins(object.Annotations)
+		if err != nil {
+			return err
+		}
+
+		fenceOrigins[serverName] = backupName
+		return SetBackupFenceOrigins(object, fenceOrigins)
+	}
+}
+
+// RemoveFencedInstanceForBackup returns a fencing function removing the given
+// server name from the FencedInstanceAnnotation annotation, together with the
+// record of the backup that requested the fencing. The function returns
+// ErrorInstanceNotFencedByBackup, without touching the annotations, if the
+// fencing was not requested by the passed backup
+func RemoveFencedInstanceForBackup(backupName string) func(string, *metav1.ObjectMeta) error {
+	return func(serverName string, object *metav1.ObjectMeta) error {
+		fencedInstances, err := GetFencedInstances(object.Annotations)
+		if err != nil {
+			return err
+		}
+
+		fenceOrigins, err := GetBackupFenceOrigins(object.Annotations)
+		if err != nil {
+			return err
+		}
+
+		if fenceOrigins[serverName] != backupName {
+			if !fencedInstances.Has(serverName) && !fencedInstances.Has(FenceAllServers) {
+				return ErrorServerAlreadyUnfenced
+			}
+			return ErrorInstanceNotFencedByBackup
+		}
+
+		if err := RemoveFencedInstance(serverName, object); err != nil &&
+			!errors.Is(err, ErrorServerAlreadyUnfenced) {
+			return err
+		}
+
+		delete(fenceOrigins, serverName)
+		return SetBackupFenceOrigins(object, fenceOrigins)
+	}
+}
@@ -105,4 +105,100 @@ var _ = Describe("Fencing annotation handling", func() {
 			Expect(clusterMeta.Annotations).To(HaveKeyWithValue(FencedInstanceAnnotation, jsonMarshal("cluster-example-1")))
 		})
 	})
+
+	When("An instance is fenced by a backup", func() {
+		It("should record the backup as the origin of the fencing", func() {
+			clusterMeta := metav1.ObjectMeta{}
+			err := AddFencedInstanceForBackup("backup-one")("cluster-example-1", &clusterMeta)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(clusterMeta.Annotations).To(HaveKeyWithValue(FencedInstanceAnnotation, jsonMarshal("cluster-example-1")))
+
+			fenceOrigins, err := GetBackupFenceOrigins(clusterMeta.Annotations)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fenceOrigins).To(Equal(map[string]string{"cluster-example-1": "backup-one"}))
+		})
+		It("should not record the origin if the instance was already fenced", func() {
+			clusterMeta := metav1.ObjectMeta{
+				Annotations: map[string]string{
+					FencedInstanceAnnotation: jsonMarshal("cluster-example-1"),
+				},
+			}
+			err := AddFencedInstanceForBackup("backup-one")("cluster-example-1", &clusterMeta)
+			Expect(err).To(Equal(ErrorServerAlreadyFenced))
+			Expect(clusterMeta.Annotations).NotTo(HaveKey(BackupFenceOriginAnnotationName))
+		})
+		It("should remove the origin when unfenced", func() {
+			clusterMeta := metav1.ObjectMeta{
+				Annotations: map[string]string{
+					FencedInstanceAnnotation:        jsonMarshal("cluster-example-1", "cluster-example-2"),
+					BackupFenceOriginAnnotationName: `{"cluster-example-1":"backup-one"}`,
+				},
+			}
+			err := RemoveFencedInstanceForBackup("backup-one")("cluster-example-1", &clusterMeta)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(clusterMeta.Annotations).To(HaveKeyWithValue(FencedInstanceAnnotation, jsonMarshal("cluster-example-2")))
+			Expect(clusterMeta.Annotations).NotTo(HaveKey(BackupFenceOriginAnnotationName))
+		})
+		It("should not unfence an instance fenced by a different backup", func() {
+			clusterMeta := metav1.ObjectMeta{
+				Annotations: map[string]string{
+					FencedInstanceAnnotation:        jsonMarshal("cluster-example-1"),
+					BackupFenceOriginAnnotationName: `{"cluster-example-1":"backup-two"}`,
+				},
+			}
+			err := RemoveFencedInstanceForBackup("backup-one")("cluster-example-1", &clusterMeta)
+			Expect(err).To(Equal(ErrorInstanceNotFencedByBackup))
+			Expect(clusterMeta.Annotations).To(HaveKeyWithValue(FencedInstanceAnnotation, jsonMarshal("cluster-example-1")))
+		})
+		It("should not unfence an instance fenced by the user", func() {
+			clusterMeta := metav1.ObjectMeta{
+				Annotations: map[string]string{
+					FencedInstanceAnnotation: jsonMarshal("cluster-example-1"),
+				},
+			}
+			err := RemoveFencedInstanceForBackup("backup-one")("cluster-example-1", &clusterMeta)
+			Expect(err).To(Equal(ErrorInstanceNotFencedByBackup))
+			Expect(clusterMeta.Annotations).To(HaveKeyWithValue(FencedInstanceAnnotation, jsonMarshal("cluster-example-1")))
+		})
+		It("should let the user take over the fencing", func() {
+			clusterMeta := metav1.ObjectMeta{
+				Annotations: map[string]string{
+					FencedInstanceAnnotation:        jsonMarshal("cluster-example-1", "cluster-example-2"),
+					BackupFenceOriginAnnotationName: `{"cluster-example-1":"backup-one","cluster-example-2":"backup-two"}`,
+				},
+			}
+			err := AddFencedInstance("cluster-example-1", &clusterMeta)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(clusterMeta.Annotations).
+				To(HaveKeyWithValue(FencedInstanceAnnotation, jsonMarshal("cluster-example-1", "cluster-example-2")))
+			Expect(clusterMeta.Annotations).
+				To(HaveKeyWithValue(BackupFenceOriginAnnotationName, `{"cluster-example-2":"backup-two"}`))
+
+			err = RemoveFencedInstanceForBackup("backup-one")("cluster-example-1", &clusterMeta)
+			Expect(err).To(Equal(ErrorInstanceNotFencedByBackup))
+		})
+		It("should let the user take over every fencing when fencing all the instances", func() {
+			clusterMeta := metav1.ObjectMeta{
+				Annotations: map[string]string{
+					FencedInstanceAnnotation:        jsonMarshal("cluster-example-1"),
+					BackupFenceOriginAnnotationName: `{"cluster-example-1":"backup-one"}`,
+				},
+			}
+			err := AddFencedInstance(FenceAllServers, &clusterMeta)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(clusterMeta.Annotations).To(HaveKeyWithValue(FencedInstanceAnnotation, jsonMarshal(FenceAllServers)))
+			Expect(clusterMeta.Annotations).NotTo(HaveKey(BackupFenceOriginAnnotationName))
+		})
+		It("should report an instance which is not fenced anymore", func() {
+			clusterMeta := metav1.ObjectMeta{}
+			err := RemoveFencedInstanceForBackup("backup-one")("cluster-example-1", &clusterMeta)
+			Expect(err).To(Equal(ErrorServerAlreadyUnfenced))
+		})
+		It("should return an error if the origin annotation is not valid", func() {
+			_, err := GetBackupFenceOrigins(map[string]string{
+				BackupFenceOriginAnnotationName: "[not-valid",
+			})
+			Expect(err).To(Equal(ErrorBackupFenceOriginSyntax))
+		})
+	})
 })
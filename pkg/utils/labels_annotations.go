@@ -49,6 +49,18 @@ const (
 	// BackupNameLabelName is the name of the label containing the backup id, available on backup resources
 	BackupNameLabelName = MetadataNamespace + "/backupName"
 
+	// BackupOriginLabelName is the name of the label containing the origin of the backup
+	// which took a volume snapshot, either manual or scheduled
+	BackupOriginLabelName = MetadataNamespace + "/backupOrigin"
+
+	// SnapshotSourcePodLabelName is the name of the label containing the name of the
+	// instance whose volume has been captured by a volume snapshot
+	SnapshotSourcePodLabelName = MetadataNamespace + "/snapshotSourcePod"
+
+	// SnapshotSourceRoleLabelName is the name of the label containing the role of the
+	// instance whose volume has been captured by a volume snapshot, when it was taken
+	SnapshotSourceRoleLabelName = MetadataNamespace + "/snapshotSourceRole"
+
 	// PgbouncerNameLabel is the name of the label of containing the pooler name
 	PgbouncerNameLabel = MetadataNamespace + "/poolerName"
 
@@ -108,6 +120,56 @@ const (
 	// PgControldataAnnotationName is the name of the annotation containing the pg_controldata output of the cluster
 	PgControldataAnnotationName = MetadataNamespace + "/pgControldata"
 
+	// InstalledExtensionsAnnotationName is the name of the annotation containing the list
+	// of the PostgreSQL extensions installed in the cluster when the snapshot was taken
+	InstalledExtensionsAnnotationName = MetadataNamespace + "/installedExtensions"
+
+	// BackupNodeAnnotationName is the name of the annotation containing the name of
+	// the Kubernetes node where the target Pod of a volume snapshot backup was running
+	BackupNodeAnnotationName = MetadataNamespace + "/backupNode"
+
+	// ConsistentLSNAnnotationName is the name of the annotation containing the LSN
+	// at which the PVC captured by a volume snapshot was consistent
+	ConsistentLSNAnnotationName = MetadataNamespace + "/consistentLSN"
+
+	// PostgresMajorVersionAnnotationName is the name of the annotation containing
+	// the PostgreSQL major version of the target instance when a volume snapshot was taken
+	PostgresMajorVersionAnnotationName = MetadataNamespace + "/postgresMajor"
+
+	// PostgresMinorVersionAnnotationName is the name of the annotation containing
+	// the PostgreSQL minor version of the target instance when a volume snapshot was taken
+	PostgresMinorVersionAnnotationName = MetadataNamespace + "/postgresMinor"
+
+	// BackupLabelFileAnnotationName is the name of the annotation containing the backup
+	// label returned by `pg_backup_stop` when the PG_DATA volume snapshot was taken online
+	BackupLabelFileAnnotationName = MetadataNamespace + "/backupLabelFile"
+
+	// TablespaceMapFileAnnotationName is the name of the annotation containing the tablespace
+	// map returned by `pg_backup_stop` when the PG_DATA volume snapshot was taken online
+	TablespaceMapFileAnnotationName = MetadataNamespace + "/tablespaceMapFile"
+
+	// SnapshotMetadataChecksumAnnotationName is the name of the annotation containing
+	// the SHA-256 checksum of the metadata recorded in a volume snapshot when it was taken
+	SnapshotMetadataChecksumAnnotationName = MetadataNamespace + "/metadataChecksum"
+
+	// VolumeSnapshotClassAnnotationName is the name of the annotation which, when set
+	// on a PVC, selects the volume snapshot class used to take the snapshots of that PVC
+	VolumeSnapshotClassAnnotationName = MetadataNamespace + "/volumeSnapshotClass"
+
+	// IncrementalSnapshotsAnnotationName is the name of the annotation which, when set
+	// to "true" on a volume snapshot class, declares that its CSI driver can take
+	// incremental snapshots chained to a parent snapshot of the same volume
+	IncrementalSnapshotsAnnotationName = MetadataNamespace + "/incrementalSnapshots"
+
+	// ParentSnapshotAnnotationName is the name of the annotation containing the name
+	// of the volume snapshot an incremental volume snapshot is chained to
+	ParentSnapshotAnnotationName = MetadataNamespace + "/parentSnapshot"
+
+	// ParentSnapshotContentAnnotationName is the name of the annotation containing the
+	// name of the volume snapshot content bound to the parent of an incremental
+	// volume snapshot
+	ParentSnapshotContentAnnotationName = MetadataNamespace + "/parentSnapshotContent"
+
 	// skipEmptyWalArchiveCheck is the name of the annotation which turns off the checks that ensure that the WAL
 	// archive is empty before writing data
 	skipEmptyWalArchiveCheck = MetadataNamespace + "/skipEmptyWalArchiveCheck"
@@ -145,6 +207,11 @@ const (
 	// If the list contain the "*" element, every node is fenced.
 	FencedInstanceAnnotation = MetadataNamespace + "/fencedInstances"
 
+	// BackupFenceOriginAnnotationName is the annotation used to track the instances that have
+	// been fenced by a volume snapshot backup. The value is a JSON object mapping the name of
+	// each of those instances to the name of the backup which requested the fencing.
+	BackupFenceOriginAnnotationName = MetadataNamespace + "/backupFenceOrigin"
+
 	// CNPGHashAnnotationName is the name of the annotation containing the hash of the resource used by operator
 	// expect the pooler that uses PoolerSpecHashAnnotationName
 	CNPGHashAnnotationName = MetadataNamespace + "/hash"
@@ -179,6 +246,28 @@ const (
 	PVCRolePgWal PVCRole = "PG_WAL"
 )
 
+// BackupOrigin describes what requested a backup
+type BackupOrigin string
+
+const (
+	// BackupOriginManual is a backup which was requested directly by the user
+	BackupOriginManual BackupOrigin = "manual"
+	// BackupOriginScheduled is a backup which was created by a ScheduledBackup
+	BackupOriginScheduled BackupOrigin = "scheduled"
+)
+
+// SnapshotSourceRole describes the role of the instance whose volumes
+// have been captured by a volume snapshot backup
+type SnapshotSourceRole string
+
+const (
+	// SnapshotSourceRolePrimary is the primary of the cluster, or the
+	// designated primary of a replica cluster
+	SnapshotSourceRolePrimary SnapshotSourceRole = "primary"
+	// SnapshotSourceRoleStandby is a standby of the cluster
+	SnapshotSourceRoleStandby SnapshotSourceRole = "standby"
+)
+
 // LabelClusterName labels the object with the cluster name
 func LabelClusterName(object *metav1.ObjectMeta, name string) {
 	if object.Labels == nil {
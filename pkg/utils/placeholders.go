@@ -0,0 +1,52 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExpandPlaceholders replaces the `${name}` placeholders in the passed
+// template with the corresponding values. An unknown or unterminated
+// placeholder raises an error, instead of being kept as it is
+func ExpandPlaceholders(template string, values map[string]string) (string, error) {
+	var result strings.Builder
+	remaining := template
+	for {
+		start := strings.Index(remaining, "${")
+		if start < 0 {
+			result.WriteString(remaining)
+			return result.String(), nil
+		}
+
+		end := strings.Index(remaining[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unterminated placeholder in %q", template)
+		}
+
+		name := remaining[start+2 : start+end]
+		value, ok := values[name]
+		if !ok {
+			return "", fmt.Errorf("unknown placeholder ${%s} in %q", name, template)
+		}
+
+		result.WriteString(remaining[:start])
+		result.WriteString(value)
+		remaining = remaining[start+end+1:]
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Placeholders expansion", func() {
+	values := map[string]string{
+		"cluster.name": "cluster-example",
+		"pvc.role":     "PG_DATA",
+	}
+
+	It("replaces every placeholder with its value", func() {
+		result, err := ExpandPlaceholders("${cluster.name}-${pvc.role}-${cluster.name}", values)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal("cluster-example-PG_DATA-cluster-example"))
+	})
+
+	It("keeps the strings without placeholders as they are", func() {
+		result, err := ExpandPlaceholders("gold-$tier", values)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal("gold-$tier"))
+	})
+
+	It("refuses the unknown placeholders", func() {
+		_, err := ExpandPlaceholders("${cluster.uid}", values)
+		Expect(err).To(MatchError(ContainSubstring("unknown placeholder ${cluster.uid}")))
+	})
+
+	It("refuses the unterminated placeholders", func() {
+		_, err := ExpandPlaceholders("${cluster.name", values)
+		Expect(err).To(MatchError(ContainSubstring("unterminated placeholder")))
+	})
+})
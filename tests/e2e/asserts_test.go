@@ -882,13 +882,7 @@ func AssertDetachReplicaModeCluster(
 	replicaCommandTimeout := time.Second * 10
 
 	By("disabling the replica mode", func() {
-		Eventually(func(g Gomega) {
-			_, _, err := testsUtils.RunUnchecked(fmt.Sprintf(
-				"kubectl patch cluster %v -n %v  -p '{\"spec\":{\"replica\":{\"enabled\":false}}}'"+
-					" --type='merge'",
-				replicaClusterName, namespace))
-			g.Expect(err).ToNot(HaveOccurred())
-		}, 60, 5).Should(Succeed())
+		env.AssertReplicaPromotionWithin(namespace, replicaClusterName, 300*time.Second)
 	})
 
 	By("verifying write operation on the replica cluster primary pod", func() {
@@ -0,0 +1,60 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/specs"
+
+	. "github.com/onsi/gomega" // nolint
+)
+
+// AssertReplicaPromotionWithin promotes a replica cluster by disabling its
+// replica mode, and checks that its designated primary leaves recovery
+// within the passed duration. The time the promotion took is returned
+func (env TestingEnvironment) AssertReplicaPromotionWithin(
+	namespace,
+	clusterName string,
+	maxDuration time.Duration,
+) time.Duration {
+	commandTimeout := time.Second * 10
+
+	cluster, err := env.GetCluster(namespace, clusterName)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(cluster.IsReplica()).To(BeTrue(), "cluster %s is not a replica cluster", clusterName)
+
+	origCluster := cluster.DeepCopy()
+	cluster.Spec.ReplicaCluster.Enabled = false
+	promotionStartedAt := time.Now()
+	Expect(env.Client.Patch(env.Ctx, cluster, client.MergeFrom(origCluster))).To(Succeed())
+
+	Eventually(func(g Gomega) {
+		primary, err := env.GetClusterPrimary(namespace, clusterName)
+		g.Expect(err).ToNot(HaveOccurred())
+		stdOut, _, err := env.ExecCommand(env.Ctx, *primary, specs.PostgresContainerName,
+			&commandTimeout, "psql", "-U", "postgres", "-tAc", "SELECT pg_catalog.pg_is_in_recovery()")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(strings.TrimSpace(stdOut)).To(Equal("f"))
+	}, maxDuration, time.Second).Should(Succeed(),
+		"the promotion of replica cluster %s took more than %v", clusterName, maxDuration)
+
+	return time.Since(promotionStartedAt)
+}